@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// AcceptShortcuts maps the short names --accept takes to the media type sent
+// as the Accept header.
+var AcceptShortcuts = map[string]string{
+	"json": "application/json",
+	"csv":  "text/csv",
+	"txt":  "text/plain",
+	"text": "text/plain",
+}
+
+// ResolveAccept returns the Accept header for an --accept value: the media
+// type of a shortcut in AcceptShortcuts, or value itself when it is a full
+// media type such as application/vnd.api+json.
+func ResolveAccept(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if mediaType, ok := AcceptShortcuts[strings.ToLower(value)]; ok {
+		return mediaType, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			names := make([]string, 0, len(AcceptShortcuts))
+			for name := range AcceptShortcuts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("invalid --accept %q: use %s or a media type such as text/csv", value, strings.Join(names, ", "))
+		}
+	}
+	return value, nil
+}
+
+// AcceptsNonJSON reports whether an Accept header admits a response that is
+// not JSON: it names a media type other than application/json or a +json
+// type, or a wildcard. An empty header asks for nothing in particular, and
+// the API answers JSON.
+func AcceptsNonJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return true
+		}
+	}
+	return false
+}
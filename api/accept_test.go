@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestResolveAccept(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"json", "application/json"},
+		{"CSV", "text/csv"},
+		{"txt", "text/plain"},
+		{"text", "text/plain"},
+		{"application/vnd.openapi+json", "application/vnd.openapi+json"},
+		{"text/csv; charset=utf-8", "text/csv; charset=utf-8"},
+		{"text/csv, application/json;q=0.5", "text/csv, application/json;q=0.5"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveAccept(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+
+	for _, bad := range []string{"xml", "", "text/"} {
+		_, err := ResolveAccept(bad)
+		assert.ErrorContains(t, err, "use csv, json, text, txt or a media type", bad)
+	}
+}
+
+func TestAcceptsNonJSON(t *testing.T) {
+	assert.False(t, AcceptsNonJSON(""))
+	assert.False(t, AcceptsNonJSON("application/json"))
+	assert.False(t, AcceptsNonJSON("application/problem+json; charset=utf-8"))
+	assert.True(t, AcceptsNonJSON("text/csv"))
+	assert.True(t, AcceptsNonJSON("application/json, text/plain;q=0.1"))
+	assert.True(t, AcceptsNonJSON("*/*"))
+}
+
+// csvServer answers text/csv when the Accept header asks for it and JSON
+// otherwise, like an endpoint with content negotiation.
+func csvServer(t *testing.T, accepts *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*accepts = append(*accepts, r.Header.Get("Accept"))
+		if r.Header.Get("Accept") == "text/csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("id,name\n1,X\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"id":"1","name":"X"}]}`))
+	}))
+}
+
+func TestSendRequestAccept(t *testing.T) {
+	var accepts []string
+	server := csvServer(t, &accepts)
+	defer server.Close()
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Accept: "text/csv"})
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,X\n", string(resp), "the negotiated body comes back verbatim")
+
+	resp, err = client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"id":"1","name":"X"}]}`, string(resp))
+
+	resp, err = client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Accept: "text/csv", Headers: []string{"Accept: application/json"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"id":"1","name":"X"}]}`, string(resp), "-H Accept wins")
+
+	resp, err = client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Headers: []string{"Accept: text/csv"}})
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,X\n", string(resp), "a non-JSON Accept from -H is honored too")
+
+	assert.Equal(t, []string{"text/csv", "", "application/json", "text/csv"}, accepts)
+}
+
+func TestSendRequestAcceptCache(t *testing.T) {
+	var accepts []string
+	server := csvServer(t, &accepts)
+	defer server.Close()
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).
+		WithResponseCache(NewResponseCache(filepath.Join(t.TempDir(), "cache"), time.Minute))
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Accept: "application/json"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":[{"id":"1","name":"X"}]}`, string(resp))
+	}
+	resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/report"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"id":"1","name":"X"}]}`, string(resp))
+	assert.Equal(t, []string{"application/json", ""}, accepts, "the Accept header is part of the cache key")
+}
+
+func TestHandleRequestPrintsNegotiatedBodyVerbatim(t *testing.T) {
+	var accepts []string
+	server := csvServer(t, &accepts)
+	defer server.Close()
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+	require.NoError(t, HandleRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Accept: "text/csv"}, false, "", client))
+	assert.Equal(t, "id,name\n1,X\n", buf.String())
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, HandleRequest(RequestOptions{Method: "GET", Endpoint: "/2/report", Accept: "text/csv", OutputFile: path}, false, "", client))
+	saved, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,X\n", string(saved))
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// SetIDWindow sets the since_id and until_id query parameters of endpoint,
+// replacing any already present. Empty values leave the parameter untouched.
+func SetIDWindow(endpoint, sinceID, untilID string) (string, error) {
+	if sinceID == "" && untilID == "" {
+		return endpoint, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+	q := u.Query()
+	if sinceID != "" {
+		q.Set("since_id", sinceID)
+	}
+	if untilID != "" {
+		q.Set("until_id", untilID)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Backfill walks a reverse-chronological timeline backwards: after each page
+// it asks again with until_id set to the oldest post seen so far, stopping at
+// an empty page, a page with nothing new, or after maxPages requests. Posts
+// are de-duplicated by ID and merged, with their includes, into a single
+// {"data", "includes", "meta"} document whose meta holds the newest and
+// oldest IDs seen and the number of pages fetched. Any since_id already on
+// the endpoint bounds every page.
+func Backfill(client Client, opts RequestOptions, maxPages int) (json.RawMessage, error) {
+	var posts []json.RawMessage
+	includes := map[string][]json.RawMessage{}
+	stats, err := walkBackfill(client, opts, maxPages, func(page []json.RawMessage, pageIncludes map[string][]json.RawMessage) {
+		posts = append(posts, page...)
+		for k, v := range pageIncludes {
+			includes[k] = append(includes[k], v...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type meta struct {
+		ResultCount int    `json:"result_count"`
+		NewestID    string `json:"newest_id,omitempty"`
+		OldestID    string `json:"oldest_id,omitempty"`
+		Pages       int    `json:"pages"`
+	}
+	out := struct {
+		Data     []json.RawMessage            `json:"data"`
+		Includes map[string][]json.RawMessage `json:"includes,omitempty"`
+		Meta     meta                         `json:"meta"`
+	}{
+		Data: posts,
+		Meta: meta{ResultCount: len(posts), NewestID: stats.newest, OldestID: stats.oldest, Pages: stats.pages},
+	}
+	if out.Data == nil {
+		out.Data = []json.RawMessage{}
+	}
+	if len(includes) > 0 {
+		out.Includes = includes
+	}
+	return json.Marshal(out)
+}
+
+// StreamBackfill walks a timeline like Backfill but sends each new post to
+// items as soon as its page arrives instead of merging the pages, closing
+// items when done. It returns the newest post ID seen.
+func StreamBackfill(client Client, opts RequestOptions, maxPages int, items chan<- json.RawMessage) (string, error) {
+	defer close(items)
+	stats, err := walkBackfill(client, opts, maxPages, func(page []json.RawMessage, _ map[string][]json.RawMessage) {
+		for _, post := range page {
+			items <- post
+		}
+	})
+	return stats.newest, err
+}
+
+// backfillStats describes the pages walkBackfill fetched.
+type backfillStats struct {
+	newest, oldest string
+	pages          int
+}
+
+// walkBackfill fetches the pages for Backfill, handing onPage the posts not
+// seen on an earlier page, in order, along with the page's includes.
+func walkBackfill(client Client, opts RequestOptions, maxPages int, onPage func(posts []json.RawMessage, includes map[string][]json.RawMessage)) (backfillStats, error) {
+	var stats backfillStats
+	if maxPages < 1 {
+		return stats, fmt.Errorf("max pages must be at least 1")
+	}
+
+	seen := map[string]bool{}
+	endpoint := opts.Endpoint
+
+	for stats.pages < maxPages {
+		opts.Endpoint = endpoint
+		resp, err := client.SendRequest(opts)
+		if err != nil {
+			return stats, err
+		}
+		stats.pages++
+		reportProgress(opts.Progress, ProgressEvent{Phase: ProgressPhasePage, Page: stats.pages})
+
+		var page struct {
+			Data     []json.RawMessage            `json:"data"`
+			Includes map[string][]json.RawMessage `json:"includes"`
+		}
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return stats, fmt.Errorf("could not parse timeline page: %w", err)
+		}
+
+		var added []json.RawMessage
+		for _, raw := range page.Data {
+			var post struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(raw, &post); err != nil || post.ID == "" {
+				return stats, fmt.Errorf("timeline page has a post without an id")
+			}
+			if seen[post.ID] {
+				continue
+			}
+			seen[post.ID] = true
+			added = append(added, raw)
+			if stats.newest == "" || compareIDs(post.ID, stats.newest) > 0 {
+				stats.newest = post.ID
+			}
+			if stats.oldest == "" || compareIDs(post.ID, stats.oldest) < 0 {
+				stats.oldest = post.ID
+			}
+		}
+		onPage(added, page.Includes)
+		if len(added) == 0 {
+			break
+		}
+
+		endpoint, err = SetIDWindow(endpoint, "", stats.oldest)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// NewestID returns the newest post ID in a timeline response: meta.newest_id
+// when the API sent it, otherwise the largest data[].id. It is empty when the
+// response holds no posts.
+func NewestID(resp json.RawMessage) string {
+	var page struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Meta struct {
+			NewestID string `json:"newest_id"`
+		} `json:"meta"`
+	}
+	if json.Unmarshal(resp, &page) != nil {
+		return ""
+	}
+	if page.Meta.NewestID != "" {
+		return page.Meta.NewestID
+	}
+	newest := ""
+	for _, p := range page.Data {
+		if newest == "" || compareIDs(p.ID, newest) > 0 {
+			newest = p.ID
+		}
+	}
+	return newest
+}
+
+// compareIDs orders two numeric post IDs without parsing them: a longer ID is
+// the larger one, and IDs of equal length compare lexically.
+func compareIDs(a, b string) int {
+	switch {
+	case len(a) != len(b):
+		return len(a) - len(b)
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
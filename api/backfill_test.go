@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/utils"
+)
+
+// timelinePages serves descending-ID pages keyed by the until_id asked for.
+// Neighbouring pages overlap by one post, as happens when new posts arrive
+// while a backfill is running.
+func timelinePages(t *testing.T, pages map[string]string) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+		assert.Equal(t, "100", r.URL.Query().Get("since_id"), "since_id must bound every page")
+		body, ok := pages[r.URL.Query().Get("until_id")]
+		if !ok {
+			body = `{"meta":{"result_count":0}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestBackfill(t *testing.T) {
+	server, queries := timelinePages(t, map[string]string{
+		"":     `{"data":[{"id":"1009"},{"id":"1008"},{"id":"1007"}],"includes":{"users":[{"id":"1"}]},"meta":{"newest_id":"1009","oldest_id":"1007"}}`,
+		"1007": `{"data":[{"id":"1007"},{"id":"1006"},{"id":"1005"}],"meta":{"newest_id":"1007","oldest_id":"1005"}}`,
+		"1005": `{"data":[{"id":"1005"},{"id":"999"}],"includes":{"users":[{"id":"2"}]},"meta":{"newest_id":"1005","oldest_id":"999"}}`,
+	})
+	client := shortcutClient(t, server)
+
+	opts := baseTestOpts()
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/1/mentions?since_id=100"
+	resp, err := Backfill(client, opts, 10)
+	require.NoError(t, err)
+
+	var out struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Includes map[string][]json.RawMessage `json:"includes"`
+		Meta     struct {
+			ResultCount int    `json:"result_count"`
+			NewestID    string `json:"newest_id"`
+			OldestID    string `json:"oldest_id"`
+			Pages       int    `json:"pages"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &out))
+
+	var ids []string
+	for _, p := range out.Data {
+		ids = append(ids, p.ID)
+	}
+	assert.Equal(t, []string{"1009", "1008", "1007", "1006", "1005", "999"}, ids, "overlapping posts must appear once")
+	assert.Equal(t, 6, out.Meta.ResultCount)
+	assert.Equal(t, "1009", out.Meta.NewestID)
+	assert.Equal(t, "999", out.Meta.OldestID, "IDs compare numerically, not lexically")
+	assert.Equal(t, 4, out.Meta.Pages, "three pages of posts and the empty page that ends the walk")
+	assert.Len(t, out.Includes["users"], 2)
+	assert.Equal(t, "1009", NewestID(resp))
+
+	require.Len(t, *queries, 4)
+	assert.NotContains(t, (*queries)[0], "until_id")
+	assert.Contains(t, (*queries)[3], "until_id=999")
+}
+
+func TestBackfillMaxPages(t *testing.T) {
+	server, queries := timelinePages(t, map[string]string{
+		"":     `{"data":[{"id":"1009"},{"id":"1008"}]}`,
+		"1008": `{"data":[{"id":"1007"},{"id":"1006"}]}`,
+	})
+	client := shortcutClient(t, server)
+
+	opts := baseTestOpts()
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/1/mentions?since_id=100"
+	resp, err := Backfill(client, opts, 2)
+	require.NoError(t, err)
+	assert.Len(t, *queries, 2)
+	assert.Contains(t, string(resp), `"pages":2`)
+
+	_, err = Backfill(client, opts, 0)
+	assert.Error(t, err)
+}
+
+func TestExecuteTimelineRequestNDJSON(t *testing.T) {
+	server, queries := timelinePages(t, map[string]string{
+		"":     `{"data":[{"id":"1009"},{"id":"1008"},{"id":"1007"}],"meta":{"newest_id":"1009"}}`,
+		"1007": `{"data":[{"id":"1007"},{"id":"1006"},{"id":"1005"}],"meta":{"newest_id":"1007"}}`,
+		"1005": `{"data":[{"id":"1005"},{"id":"999"}],"meta":{"newest_id":"1005"}}`,
+	})
+	client := shortcutClient(t, server)
+
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+	format := utils.NewFormatter()
+	require.NoError(t, format.SetOutputFormat(utils.OutputNDJSON))
+
+	opts := baseTestOpts()
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/1/mentions?since_id=100"
+	opts.Format = format
+	newest, err := ExecuteTimelineRequest(opts, client, true, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "1009", newest)
+	assert.Len(t, *queries, 4)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, []string{
+		`{"id":"1009"}`, `{"id":"1008"}`, `{"id":"1007"}`,
+		`{"id":"1006"}`, `{"id":"1005"}`, `{"id":"999"}`,
+	}, lines, "one line per post, in page order, without the merged envelope")
+}
+
+func TestSetIDWindow(t *testing.T) {
+	got, err := SetIDWindow("/2/users/1/mentions?max_results=5&until_id=1", "100", "200")
+	require.NoError(t, err)
+	assert.Equal(t, "/2/users/1/mentions?max_results=5&since_id=100&until_id=200", got)
+
+	got, err = SetIDWindow("https://api.x.com/2/tweets/search/recent?query=go", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.x.com/2/tweets/search/recent?query=go", got, "no window leaves the endpoint alone")
+}
+
+func TestNewestID(t *testing.T) {
+	assert.Equal(t, "20", NewestID(json.RawMessage(`{"data":[{"id":"9"},{"id":"20"}]}`)))
+	assert.Equal(t, "30", NewestID(json.RawMessage(`{"data":[{"id":"9"}],"meta":{"newest_id":"30"}}`)))
+	assert.Equal(t, "", NewestID(json.RawMessage(`{"meta":{"result_count":0}}`)))
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadDataArg resolves a -d value the way curl does: "@FILE" is replaced by
+// the contents of FILE and "@-" by everything on stdin, with trailing line
+// breaks removed. Any other value, including a lone "@", is returned as is.
+func ReadDataArg(data string, stdin io.Reader) (string, error) {
+	source, isRef := strings.CutPrefix(data, "@")
+	if !isRef || source == "" {
+		return data, nil
+	}
+	var raw []byte
+	var err error
+	if source == "-" {
+		raw, err = io.ReadAll(stdin)
+	} else {
+		raw, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read -d %s: %v", data, err)
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+// BuildRequestBody assembles the request body from -d data and --field
+// pairs. Fields build a JSON object: key=value sets a string value and
+// key:=value sets a raw JSON value (number, boolean, array, object). A later
+// field overrides an earlier one with the same key. Fields and -d data are
+// mutually exclusive.
+func BuildRequestBody(data string, fields []string) (string, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+	if data != "" {
+		return "", fmt.Errorf("--field cannot be combined with -d/--data")
+	}
+
+	obj := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		eq := strings.Index(field, "=")
+		if eq <= 0 {
+			return "", fmt.Errorf("invalid --field %q: expected key=value or key:=json", field)
+		}
+		key, value := field[:eq], field[eq+1:]
+		if strings.HasSuffix(key, ":") {
+			key = strings.TrimSuffix(key, ":")
+			if key == "" || !json.Valid([]byte(value)) {
+				return "", fmt.Errorf("invalid --field %q: value after := must be valid JSON", field)
+			}
+			obj[key] = json.RawMessage(value)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		obj[key] = encoded
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// BodySyntaxError locates a JSON syntax error found by ValidateJSONBody.
+// Line and Column are 1-based; Column counts bytes.
+type BodySyntaxError struct {
+	Line   int
+	Column int
+	Offset int64
+	Msg    string
+}
+
+func (e *BodySyntaxError) Error() string {
+	return fmt.Sprintf("invalid JSON body at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ValidateJSONBody checks that body is exactly one JSON value, for
+// --validate-only. A syntax error is returned as a *BodySyntaxError.
+func ValidateJSONBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("there is no request body to validate")
+	}
+	dec := json.NewDecoder(strings.NewReader(body))
+	var v json.RawMessage
+	if err := dec.Decode(&v); err != nil {
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return newBodySyntaxError(body, syntaxErr.Offset, syntaxErr.Error())
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			end := len(strings.TrimRight(body, " \t\r\n"))
+			return newBodySyntaxError(body, int64(end), "unexpected end of JSON input")
+		}
+		return err
+	}
+	// Anything but whitespace after the value is an error too.
+	rest := dec.InputOffset()
+	if trailing := bytes.TrimLeft([]byte(body[rest:]), " \t\r\n"); len(trailing) > 0 {
+		offset := int64(len(body) - len(trailing))
+		return newBodySyntaxError(body, offset+1, fmt.Sprintf("invalid character %q after top-level value", trailing[0]))
+	}
+	return nil
+}
+
+// newBodySyntaxError locates offset, the number of bytes read when the error
+// was found, so the error points at the last byte read.
+func newBodySyntaxError(body string, offset int64, msg string) *BodySyntaxError {
+	line, col := 1, 0
+	for i := int64(0); i < offset && i < int64(len(body)); i++ {
+		if body[i] == '\n' {
+			line, col = line+1, 0
+			continue
+		}
+		col++
+	}
+	return &BodySyntaxError{Line: line, Column: max(col, 1), Offset: offset, Msg: msg}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResponseCache keeps successful GET responses on disk, one file per
+// request, and serves them again for TTL (--cache-ttl). Like the notice
+// cache it is best effort: an unreadable or unwritable entry is a miss,
+// never a failed request.
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// cacheEntry is the file a response is cached in.
+type cacheEntry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// NewResponseCache returns a cache of the responses in dir that are at most
+// ttl old.
+func NewResponseCache(dir string, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// ResponseCacheKey identifies a request in the cache: its method, its full
+// URL and the account it is sent as, so responses for one account are never
+// served to another.
+func ResponseCacheKey(method, url, account string) string {
+	return strings.ToUpper(method) + " " + url + " " + account
+}
+
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the response cached for key and its age, if one was stored
+// less than the TTL ago.
+func (c *ResponseCache) Get(key string) (json.RawMessage, time.Duration, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, 0, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Key != key {
+		return nil, 0, false
+	}
+	age := c.now().Sub(entry.StoredAt)
+	if age < 0 || age >= c.ttl {
+		return nil, 0, false
+	}
+	return entry.Body, age, true
+}
+
+// Put stores body as the response for key, replacing the file in one rename.
+func (c *ResponseCache) Put(key string, body json.RawMessage) {
+	data, err := json.Marshal(cacheEntry{Key: key, StoredAt: c.now(), Body: body})
+	if err != nil || os.MkdirAll(c.dir, 0700) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err != nil || cerr != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.path(key))
+}
+
+// ClearResponseCache removes every cached response in dir and returns how
+// many there were. A missing dir holds none.
+func ClearResponseCache(dir string) (int, error) {
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for i, path := range entries {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/2/tweets/404" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"title":"Not Found"}`)
+			return
+		}
+		if r.URL.Path == "/2/empty" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"n":%d}}`, requests)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := NewResponseCache(filepath.Join(t.TempDir(), "responses"), time.Minute)
+	cache.now = func() time.Time { return now }
+	client := shortcutClient(t, server).WithResponseCache(cache)
+	get := func(endpoint, username string) string {
+		t.Helper()
+		opts := baseTestOpts()
+		opts.Method, opts.Endpoint, opts.Username = "GET", endpoint, username
+		resp, err := client.SendRequest(opts)
+		require.NoError(t, err)
+		return string(resp)
+	}
+
+	t.Run("miss then hit", func(t *testing.T) {
+		assert.JSONEq(t, `{"data":{"n":1}}`, get("/2/users/me", ""))
+		now = now.Add(59 * time.Second)
+		assert.JSONEq(t, `{"data":{"n":1}}`, get("/2/users/me", ""), "served from cache")
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("other URLs and accounts miss", func(t *testing.T) {
+		assert.JSONEq(t, `{"data":{"n":2}}`, get("/2/users/me?user.fields=id", ""))
+		assert.JSONEq(t, `{"data":{"n":3}}`, get("/2/users/me", "bob"))
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("expired entries are fetched again", func(t *testing.T) {
+		now = now.Add(2 * time.Second)
+		assert.JSONEq(t, `{"data":{"n":4}}`, get("/2/users/me", ""))
+		assert.JSONEq(t, `{"data":{"n":4}}`, get("/2/users/me", ""))
+		assert.Equal(t, 4, requests)
+	})
+
+	t.Run("errors and other methods are not cached", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			opts := baseTestOpts()
+			opts.Method, opts.Endpoint, opts.HideErrorBody = "GET", "/2/tweets/404", true
+			_, err := client.SendRequest(opts)
+			require.Error(t, err)
+
+			opts = baseTestOpts()
+			opts.Method, opts.Endpoint, opts.Data = "POST", "/2/tweets", `{"text":"hi"}`
+			_, err = client.SendRequest(opts)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 8, requests)
+	})
+
+	t.Run("responses without a body are not cached", func(t *testing.T) {
+		opts := baseTestOpts()
+		opts.Method, opts.Endpoint = "GET", "/2/empty"
+		for i := 0; i < 2; i++ {
+			resp, err := client.SendRequest(opts)
+			require.NoError(t, err)
+			assert.True(t, IsNoContent(resp), "a cache hit would come back as a plain null")
+		}
+		assert.Equal(t, 10, requests)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		n, err := ClearResponseCache(cache.dir)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		get("/2/users/me", "")
+		assert.Equal(t, 11, requests)
+
+		n, err = ClearResponseCache(filepath.Join(t.TempDir(), "none"))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
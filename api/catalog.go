@@ -0,0 +1,170 @@
+package api
+
+// Endpoint describes an X API endpoint in the built-in catalog. Path
+// parameters are written ":name".
+type Endpoint struct {
+	Category  string `json:"category"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Streaming bool   `json:"streaming"`
+	// Scopes are the OAuth2 scopes a user-context request needs; none means
+	// the endpoint takes app-only (bearer token) authentication.
+	Scopes []string `json:"scopes"`
+	// Rich says whether --rich adds default fields to GET requests for it.
+	Rich    bool   `json:"rich"`
+	Summary string `json:"summary"`
+	// Pagination says how --paginate pages through the endpoint; nil means
+	// it is not paginated.
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes how an endpoint pages through its results and the
+// quirks --paginate has to work around.
+type Pagination struct {
+	// TokenParam is the query parameter that asks for the page after a
+	// meta.next_token.
+	TokenParam string `json:"token_param"`
+	// PageSize is the page size when the request sets no max_results.
+	PageSize int `json:"page_size,omitempty"`
+	// ResultCeiling is the most results the endpoint returns over all
+	// pages, if it has such a limit.
+	ResultCeiling int `json:"result_ceiling,omitempty"`
+	// TokenlessRetries is how many more times a full page without a
+	// next_token is asked for, for endpoints that sometimes leave the token
+	// out while more results exist.
+	TokenlessRetries int `json:"tokenless_retries,omitempty"`
+}
+
+// OAuth2 scope sets shared by many endpoints.
+var (
+	scopesRead       = []string{"tweet.read", "users.read"}
+	scopesTweetWrite = []string{"tweet.read", "tweet.write", "users.read"}
+)
+
+func withScopes(extra ...string) []string {
+	return append(append([]string{}, scopesRead...), extra...)
+}
+
+// Pagination styles shared by many endpoints.
+var (
+	byPaginationToken = &Pagination{TokenParam: "pagination_token"}
+	byNextToken       = &Pagination{TokenParam: "next_token"}
+	// The likes and reposts of a post return the 100 most recent users, and
+	// sometimes drop next_token from a full page before then.
+	engagingUsers = &Pagination{TokenParam: "pagination_token", PageSize: 100, ResultCeiling: 100, TokenlessRetries: 2}
+)
+
+// catalog is the endpoint catalog in display order; see Catalog.
+var catalog = []Endpoint{
+	{Category: "Posts", Method: "GET", Path: "/2/tweets", Scopes: scopesRead, Summary: "Look up posts by ID"},
+	{Category: "Posts", Method: "GET", Path: "/2/tweets/:id", Scopes: scopesRead, Summary: "Look up a post"},
+	{Category: "Posts", Method: "POST", Path: "/2/tweets", Scopes: scopesTweetWrite, Summary: "Create a post"},
+	{Category: "Posts", Method: "DELETE", Path: "/2/tweets/:id", Scopes: scopesTweetWrite, Summary: "Delete a post"},
+	{Category: "Posts", Method: "GET", Path: "/2/tweets/:id/quote_tweets", Scopes: scopesRead, Summary: "Quotes of a post", Pagination: byPaginationToken},
+	{Category: "Posts", Method: "GET", Path: "/2/users/:id/tweets", Scopes: scopesRead, Summary: "A user's posts", Pagination: byPaginationToken},
+	{Category: "Posts", Method: "GET", Path: "/2/users/:id/mentions", Scopes: scopesRead, Summary: "Posts mentioning a user", Pagination: byPaginationToken},
+	{Category: "Posts", Method: "GET", Path: "/2/users/:id/timelines/reverse_chronological", Scopes: scopesRead, Summary: "Your home timeline", Pagination: byPaginationToken},
+
+	{Category: "Search", Method: "GET", Path: "/2/tweets/search/recent", Scopes: scopesRead, Summary: "Search posts from the last 7 days", Pagination: byNextToken},
+	{Category: "Search", Method: "GET", Path: "/2/tweets/search/all", Summary: "Search the full archive", Pagination: byNextToken},
+	{Category: "Search", Method: "GET", Path: "/2/tweets/counts/recent", Summary: "Count posts from the last 7 days", Pagination: byNextToken},
+	{Category: "Search", Method: "GET", Path: "/2/tweets/counts/all", Summary: "Count posts in the full archive", Pagination: byNextToken},
+
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/search/stream", Streaming: true, Summary: "Filtered stream"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/search/stream/rules", Summary: "List filtered stream rules"},
+	{Category: "Streams", Method: "POST", Path: "/2/tweets/search/stream/rules", Summary: "Add or delete filtered stream rules"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/sample/stream", Streaming: true, Summary: "1% sample stream"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/sample10/stream", Streaming: true, Summary: "10% sample stream"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/firehose/stream", Streaming: true, Summary: "Firehose"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/firehose/stream/lang/en", Streaming: true, Summary: "English firehose"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/firehose/stream/lang/ja", Streaming: true, Summary: "Japanese firehose"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/firehose/stream/lang/ko", Streaming: true, Summary: "Korean firehose"},
+	{Category: "Streams", Method: "GET", Path: "/2/tweets/firehose/stream/lang/pt", Streaming: true, Summary: "Portuguese firehose"},
+
+	{Category: "Users", Method: "GET", Path: "/2/users/me", Scopes: scopesRead, Summary: "The authenticated user"},
+	{Category: "Users", Method: "GET", Path: "/2/users", Scopes: scopesRead, Summary: "Look up users by ID"},
+	{Category: "Users", Method: "GET", Path: "/2/users/:id", Scopes: scopesRead, Summary: "Look up a user"},
+	{Category: "Users", Method: "GET", Path: "/2/users/by", Scopes: scopesRead, Summary: "Look up users by username"},
+	{Category: "Users", Method: "GET", Path: "/2/users/by/username/:username", Scopes: scopesRead, Summary: "Look up a user by username"},
+
+	{Category: "Follows", Method: "GET", Path: "/2/users/:id/followers", Scopes: withScopes("follows.read"), Summary: "A user's followers", Pagination: byPaginationToken},
+	{Category: "Follows", Method: "GET", Path: "/2/users/:id/following", Scopes: withScopes("follows.read"), Summary: "Accounts a user follows", Pagination: byPaginationToken},
+	{Category: "Follows", Method: "POST", Path: "/2/users/:id/following", Scopes: withScopes("follows.write"), Summary: "Follow a user"},
+	{Category: "Follows", Method: "DELETE", Path: "/2/users/:id/following/:target_user_id", Scopes: withScopes("follows.write"), Summary: "Unfollow a user"},
+
+	{Category: "Blocks and mutes", Method: "GET", Path: "/2/users/:id/blocking", Scopes: withScopes("block.read"), Summary: "Accounts you block", Pagination: byPaginationToken},
+	{Category: "Blocks and mutes", Method: "POST", Path: "/2/users/:id/blocking", Scopes: withScopes("block.write"), Summary: "Block a user"},
+	{Category: "Blocks and mutes", Method: "DELETE", Path: "/2/users/:id/blocking/:target_user_id", Scopes: withScopes("block.write"), Summary: "Unblock a user"},
+	{Category: "Blocks and mutes", Method: "GET", Path: "/2/users/:id/muting", Scopes: withScopes("mute.read"), Summary: "Accounts you mute", Pagination: byPaginationToken},
+	{Category: "Blocks and mutes", Method: "POST", Path: "/2/users/:id/muting", Scopes: withScopes("mute.write"), Summary: "Mute a user"},
+	{Category: "Blocks and mutes", Method: "DELETE", Path: "/2/users/:id/muting/:target_user_id", Scopes: withScopes("mute.write"), Summary: "Unmute a user"},
+
+	{Category: "Likes and reposts", Method: "GET", Path: "/2/users/:id/liked_tweets", Scopes: withScopes("like.read"), Summary: "Posts a user liked", Pagination: byPaginationToken},
+	{Category: "Likes and reposts", Method: "GET", Path: "/2/tweets/:id/liking_users", Scopes: withScopes("like.read"), Summary: "Users who liked a post", Pagination: engagingUsers},
+	{Category: "Likes and reposts", Method: "POST", Path: "/2/users/:id/likes", Scopes: withScopes("like.write"), Summary: "Like a post"},
+	{Category: "Likes and reposts", Method: "DELETE", Path: "/2/users/:id/likes/:tweet_id", Scopes: withScopes("like.write"), Summary: "Unlike a post"},
+	{Category: "Likes and reposts", Method: "GET", Path: "/2/tweets/:id/retweeted_by", Scopes: scopesRead, Summary: "Users who reposted a post", Pagination: engagingUsers},
+	{Category: "Likes and reposts", Method: "POST", Path: "/2/users/:id/retweets", Scopes: scopesTweetWrite, Summary: "Repost a post"},
+	{Category: "Likes and reposts", Method: "DELETE", Path: "/2/users/:id/retweets/:source_tweet_id", Scopes: scopesTweetWrite, Summary: "Undo a repost"},
+
+	{Category: "Bookmarks", Method: "GET", Path: "/2/users/:id/bookmarks", Scopes: withScopes("bookmark.read"), Summary: "Your bookmarks", Pagination: byPaginationToken},
+	{Category: "Bookmarks", Method: "POST", Path: "/2/users/:id/bookmarks", Scopes: withScopes("bookmark.write"), Summary: "Bookmark a post"},
+	{Category: "Bookmarks", Method: "DELETE", Path: "/2/users/:id/bookmarks/:tweet_id", Scopes: withScopes("bookmark.write"), Summary: "Remove a bookmark"},
+
+	{Category: "Lists", Method: "POST", Path: "/2/lists", Scopes: withScopes("list.write"), Summary: "Create a list"},
+	{Category: "Lists", Method: "GET", Path: "/2/lists/:id", Scopes: withScopes("list.read"), Summary: "Look up a list"},
+	{Category: "Lists", Method: "DELETE", Path: "/2/lists/:id", Scopes: withScopes("list.write"), Summary: "Delete a list"},
+	{Category: "Lists", Method: "GET", Path: "/2/lists/:id/tweets", Scopes: withScopes("list.read"), Summary: "Posts from a list's members", Pagination: byPaginationToken},
+	{Category: "Lists", Method: "GET", Path: "/2/lists/:id/members", Scopes: withScopes("list.read"), Summary: "A list's members", Pagination: byPaginationToken},
+	{Category: "Lists", Method: "POST", Path: "/2/lists/:id/members", Scopes: withScopes("list.write"), Summary: "Add a list member"},
+	{Category: "Lists", Method: "DELETE", Path: "/2/lists/:id/members/:user_id", Scopes: withScopes("list.write"), Summary: "Remove a list member"},
+
+	{Category: "Direct messages", Method: "GET", Path: "/2/dm_events", Scopes: withScopes("dm.read"), Summary: "Recent direct message events", Pagination: byPaginationToken},
+	{Category: "Direct messages", Method: "GET", Path: "/2/dm_conversations/with/:participant_id/dm_events", Scopes: withScopes("dm.read"), Summary: "Messages with a user", Pagination: byPaginationToken},
+	{Category: "Direct messages", Method: "POST", Path: "/2/dm_conversations/with/:participant_id/messages", Scopes: withScopes("dm.read", "dm.write"), Summary: "Send a direct message"},
+
+	{Category: "Media", Method: "POST", Path: "/2/media/upload/initialize", Scopes: []string{"media.write"}, Summary: "Start a chunked upload"},
+	{Category: "Media", Method: "POST", Path: "/2/media/upload/:id/append", Scopes: []string{"media.write"}, Summary: "Upload a chunk"},
+	{Category: "Media", Method: "POST", Path: "/2/media/upload/:id/finalize", Scopes: []string{"media.write"}, Summary: "Finish a chunked upload"},
+	{Category: "Media", Method: "GET", Path: "/2/media/upload", Scopes: []string{"media.write"}, Summary: "Processing status of an upload"},
+
+	{Category: "Spaces", Method: "GET", Path: "/2/spaces/:id", Scopes: withScopes("space.read"), Summary: "Look up a Space"},
+	{Category: "Spaces", Method: "GET", Path: "/2/spaces/search", Scopes: withScopes("space.read"), Summary: "Search Spaces"},
+
+	{Category: "Trends", Method: "GET", Path: "/2/trends/by/woeid/:woeid", Summary: "Trends for a location"},
+	{Category: "Trends", Method: "GET", Path: "/2/users/personalized_trends", Scopes: scopesRead, Summary: "Trends for you"},
+
+	{Category: "Compliance and usage", Method: "POST", Path: "/2/compliance/jobs", Summary: "Create a batch compliance job"},
+	{Category: "Compliance and usage", Method: "GET", Path: "/2/compliance/jobs", Summary: "List batch compliance jobs"},
+	{Category: "Compliance and usage", Method: "GET", Path: "/2/compliance/jobs/:id", Summary: "Look up a batch compliance job"},
+	{Category: "Compliance and usage", Method: "GET", Path: "/2/usage/tweets", Summary: "Post consumption for your project"},
+}
+
+// Catalog returns the built-in endpoint catalog, grouped by category, with
+// Rich filled in from RichFields.
+func Catalog() []Endpoint {
+	out := make([]Endpoint, len(catalog))
+	for i, e := range catalog {
+		e.Rich = e.Method == "GET" && richParams(e.Path) != nil
+		if e.Scopes == nil {
+			e.Scopes = []string{}
+		}
+		if e.Pagination != nil {
+			p := *e.Pagination
+			e.Pagination = &p
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// streamingPaths returns the paths of the streaming endpoints in the catalog.
+func streamingPaths() map[string]bool {
+	paths := map[string]bool{}
+	for _, e := range catalog {
+		if e.Streaming {
+			paths[e.Path] = true
+		}
+	}
+	return paths
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"strings"
 )
@@ -98,8 +97,7 @@ func GetChatUsersPublicKeys(client Client, userIDs []string, opts RequestOptions
 		return nil, nil
 	}
 	var keys []ChatPublicKey
-	for start := 0; start < len(userIDs); start += 100 {
-		batch := userIDs[start:min(start+100, len(userIDs))]
+	for _, batch := range batches(userIDs, MaxLookupIDs) {
 		opts.Method = "GET"
 		opts.Endpoint = "/2/users/public_keys?ids=" + url.QueryEscape(strings.Join(batch, ","))
 		opts.Data = ""
@@ -392,7 +390,7 @@ func DownloadChatMedia(client Client, conversationID, mediaHashKey string, opts
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := plainHTTPClient(client).Do(req)
 	if err != nil {
 		return nil, err
 	}
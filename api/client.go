@@ -9,14 +9,18 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
 	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/store"
+	"github.com/xdevplatform/xurl/utils"
 	"github.com/xdevplatform/xurl/version"
 )
 
@@ -30,6 +34,65 @@ type RequestOptions struct {
 	Username string
 	Verbose  bool
 	Trace    bool
+	// Fields are --field key=value pairs assembled into a JSON body by
+	// HandleRequest (see BuildRequestBody).
+	Fields []string
+	// PrintBody makes HandleRequest print the constructed body and return
+	// without sending anything.
+	PrintBody bool
+	// ValidateOnly makes HandleRequest check that the constructed body is
+	// valid JSON (see ValidateJSONBody) and return without sending anything.
+	ValidateOnly bool
+	// BodyReader, when set, is sent as the request body instead of Data
+	// without buffering it. Its length is unknown, so the request uses
+	// chunked transfer encoding and is not subject to the client timeout.
+	BodyReader io.Reader
+	// HideErrorBody suppresses printing the JSON body of an API error
+	// response (--fail); the request still fails.
+	HideErrorBody bool
+	// StreamJSONArray makes StreamRequest parse the response as one JSON
+	// array and deliver each element (compacted onto one line) to OnLine as
+	// soon as it is decoded, instead of splitting the body on newlines.
+	StreamJSONArray bool
+	// DryRun makes the client return a description of the request (see
+	// IsDryRun) instead of sending it, for --dry-run.
+	DryRun bool
+	// Progress, when set, receives a page event for every page fetched by
+	// the paginating helpers (Paginate, Backfill, GetUserTimeline and their
+	// Stream variants), a stream event from ExecuteStreamRequest and the
+	// processing events of ExecuteMediaStatus.
+	Progress ProgressReporter
+	// Locale sets the Accept-Language header and, for search endpoints,
+	// language and country filters.
+	Locale Locale
+	// Accept is sent as the Accept header unless Headers set one (--accept,
+	// see ResolveAccept). When the header admits other types than JSON, a
+	// successful response that is not JSON is returned verbatim instead of
+	// as {}.
+	Accept string
+	// Rich adds default fields and expansions to GET requests for the
+	// endpoints in RichFields, unless the URL sets them (--rich).
+	Rich bool
+	// OutputFile, when set, makes HandleRequest write the raw response body
+	// to this file, created or truncated, instead of printing it (-o). A
+	// stream is written line by line as it arrives.
+	OutputFile string
+	// OutputAppend makes OutputFile be appended to instead of replaced
+	// (--output-append). Each appended response ends in a newline, so the
+	// file collects one record per run.
+	OutputAppend bool
+	// Format prints the responses of HandleRequest and the other Execute*
+	// functions; nil prints them as utils.NewFormatter does.
+	Format *utils.Formatter
+}
+
+// formatter returns options.Format, or a default utils.Formatter when it is
+// not set.
+func (options RequestOptions) formatter() *utils.Formatter {
+	if options.Format != nil {
+		return options.Format
+	}
+	return utils.NewFormatter()
 }
 
 // MultipartOptions contains options specific to multipart requests
@@ -47,10 +110,19 @@ type Client interface {
 	BuildRequest(requestOptions RequestOptions) (*http.Request, error)
 	BuildMultipartRequest(options MultipartOptions) (*http.Request, error)
 	SendRequest(options RequestOptions) (json.RawMessage, error)
-	StreamRequest(options RequestOptions) error
+	StreamRequest(options RequestOptions, handler StreamHandler) error
 	SendMultipartRequest(options MultipartOptions) (json.RawMessage, error)
 }
 
+// StreamHandler receives a streaming response. OnConnect, if set, is called
+// once the server has accepted the stream; OnLine is called for every
+// non-empty line. Returning an error from OnLine closes the stream and makes
+// StreamRequest return that error.
+type StreamHandler struct {
+	OnConnect func()
+	OnLine    func(line string) error
+}
+
 // ApiClient handles API requests
 type ApiClient struct {
 	url    string
@@ -62,15 +134,196 @@ type ApiClient struct {
 	// clients leave it false so a missing credential surfaces as a clear auth
 	// error instead of a confusing server-side 401.
 	allowUnauthenticated bool
+	// verboseOut receives the request/response trace for requests made with
+	// Verbose set; nil discards it. Header values in the trace are cut to
+	// maxHeaderSize bytes (see truncateHeaderValue).
+	verboseOut    io.Writer
+	maxHeaderSize int
+	// noticeOut receives deprecation/warning notices found in responses; nil
+	// silences them. noticeCache limits each endpoint to one notice per day
+	// and is loaded on first use.
+	noticeOut   io.Writer
+	noticeCache *store.NoticeCache
+	// noticeMu guards noticeCache, as FanOut sends requests concurrently.
+	noticeMu sync.Mutex
+	// onNotices is called with the notices of every response that has any,
+	// whether or not noticeOut shows them.
+	onNotices func(endpoint string, notices []Notice)
+	// defaultHeaders ("Name: value") are sent with every request unless the
+	// request's own Headers set the same name.
+	defaultHeaders []string
+	// requestID is sent as RequestIDHeader with every request unless the
+	// request sets the header itself; empty sends none.
+	requestID string
+	// onRequest is called with every request just before it is sent.
+	onRequest func(*http.Request)
+	// responseCache, when set, serves GET requests made with SendRequest
+	// from disk within its TTL and stores their successful responses.
+	responseCache *ResponseCache
+	// rateLimits, when set, records the rate-limit headers of every
+	// response.
+	rateLimits *RateLimitTracker
+	// authMethodOut receives the credential each request is signed with
+	// (--show-auth-method); lastAuthMethod, guarded by authMethodMu, keeps
+	// it to one line per change.
+	authMethodOut  io.Writer
+	authMethodMu   sync.Mutex
+	lastAuthMethod AuthMethod
+	// insecureRedirectAuth forwards the Authorization header across
+	// redirects that leave the host or downgrade to http (see
+	// checkRedirect).
+	insecureRedirectAuth bool
+	// retries is how many times SendRequest retries an idempotent request
+	// that failed with a 429 or 5xx, waiting as retryPolicy allows (see
+	// WithRetry).
+	retries     int
+	retryPolicy RetryAfterPolicy
+	// retryLog, guarded by retryLogMu, receives a line per retry
+	// (--retry-log).
+	retryLog   io.Writer
+	retryLogMu sync.Mutex
 }
 
-// NewApiClient creates a new ApiClient
+// NewApiClient creates a new ApiClient. The client never prints: verbose
+// traces and API notices are discarded unless a writer is configured with
+// WithVerboseWriter / WithNoticeWriter.
 func NewApiClient(config *config.Config, auth *auth.Auth) *ApiClient {
-	return &ApiClient{
-		url:    config.APIBaseURL,
-		client: &http.Client{Timeout: 30 * time.Second},
-		auth:   auth,
+	c := &ApiClient{
+		url:           config.APIBaseURL,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		auth:          auth,
+		maxHeaderSize: DefaultMaxHeaderSize,
+	}
+	c.client.CheckRedirect = c.checkRedirect
+	return c
+}
+
+// WithVerboseWriter sets where the request/response trace of requests made
+// with RequestOptions.Verbose is written. A nil writer discards it.
+func (c *ApiClient) WithVerboseWriter(w io.Writer) *ApiClient {
+	c.verboseOut = w
+	return c
+}
+
+// WithMaxHeaderSize sets how many bytes of each header value the verbose
+// trace prints before cutting it short; zero or less prints values whole.
+func (c *ApiClient) WithMaxHeaderSize(n int) *ApiClient {
+	c.maxHeaderSize = n
+	return c
+}
+
+// WithNoticeWriter sets where deprecation and warning notices from API
+// responses are reported. A nil writer silences them.
+func (c *ApiClient) WithNoticeWriter(w io.Writer) *ApiClient {
+	c.noticeOut = w
+	return c
+}
+
+// WithNoticeHook sets a function called with the endpoint and notices of
+// every response that carries any. Unlike WithNoticeWriter it is not limited
+// to one call per endpoint per day. FanOut may call it concurrently.
+func (c *ApiClient) WithNoticeHook(fn func(endpoint string, notices []Notice)) *ApiClient {
+	c.onNotices = fn
+	return c
+}
+
+// WithTransport sets the transport every request of the client goes
+// through, including streams and uploads, e.g. one built with PinTransport
+// or ProxyAuthTransport. A nil transport uses http.DefaultTransport.
+func (c *ApiClient) WithTransport(rt http.RoundTripper) *ApiClient {
+	c.client.Transport = rt
+	return c
+}
+
+// plainHTTPClient returns an http.Client without a timeout for requests that
+// bypass SendRequest, such as pre-signed URLs and binary downloads. It sends
+// through client's transport when client is an *ApiClient.
+func plainHTTPClient(client Client) *http.Client {
+	if c, ok := client.(*ApiClient); ok {
+		return &http.Client{Transport: c.client.Transport}
+	}
+	return &http.Client{}
+}
+
+// WithDefaultHeaders sets "Name: value" headers sent with every request, such
+// as those read from a --header-file. A header of the same name in a
+// request's own Headers replaces the default.
+func (c *ApiClient) WithDefaultHeaders(headers []string) *ApiClient {
+	c.defaultHeaders = headers
+	return c
+}
+
+// WithRequestID sends id as the RequestIDHeader of every request made with
+// the client, unless a request sets that header itself. An empty id sends
+// none.
+func (c *ApiClient) WithRequestID(id string) *ApiClient {
+	c.requestID = id
+	return c
+}
+
+// RequestID returns the ID set with WithRequestID.
+func (c *ApiClient) RequestID() string {
+	return c.requestID
+}
+
+// WithRequestHook makes the client call hook with every request just before
+// sending it, after all headers are set. Dry runs send nothing and do not call
+// it. A nil hook removes it.
+func (c *ApiClient) WithRequestHook(hook func(*http.Request)) *ApiClient {
+	c.onRequest = hook
+	return c
+}
+
+// WithResponseCache makes SendRequest answer GET requests from cache while
+// its entries are fresh, and cache their successful responses. A nil cache
+// turns caching off.
+func (c *ApiClient) WithResponseCache(cache *ResponseCache) *ApiClient {
+	c.responseCache = cache
+	return c
+}
+
+// WithAuthMethodWriter makes the client write which credential signs a
+// request, such as "using oauth2 (username: alice)", to w before sending it.
+// A line is written only when the credential differs from the previous
+// request's. A nil writer writes nothing.
+func (c *ApiClient) WithAuthMethodWriter(w io.Writer) *ApiClient {
+	c.authMethodOut = w
+	return c
+}
+
+// WithRateLimitTracker makes the client record the rate-limit headers of
+// every response it receives in t. A nil tracker records nothing.
+func (c *ApiClient) WithRateLimitTracker(t *RateLimitTracker) *ApiClient {
+	c.rateLimits = t
+	return c
+}
+
+// CachedUserID returns the user ID cached on the stored credential a request
+// with authType and username would be signed with (see auth.Auth.CachedUserID).
+func (c *ApiClient) CachedUserID(authType, username string) (userID, pinned string) {
+	if c.auth == nil {
+		return "", ""
+	}
+	return c.auth.CachedUserID(authType, username)
+}
+
+// SaveIdentity caches the user ID and display name on the stored credential a
+// request with authType and username would be signed with.
+func (c *ApiClient) SaveIdentity(authType, username, userID, name string) error {
+	if c.auth == nil {
+		return xurlErrors.NewAuthError("AuthNotSet", errors.New("auth not set"))
+	}
+	return c.auth.SaveIdentity(authType, username, userID, name)
+}
+
+// GrantedScopes returns the scopes granted to the stored OAuth2 token a
+// request with authType and username would be signed with (see
+// auth.Auth.GrantedScopes).
+func (c *ApiClient) GrantedScopes(authType, username string) (scopes []string, known bool) {
+	if c.auth == nil {
+		return nil, false
 	}
+	return c.auth.GrantedScopes(authType, username)
 }
 
 // BuildRequest builds an HTTP request
@@ -80,7 +333,16 @@ func (c *ApiClient) BuildRequest(requestOptions RequestOptions) (*http.Request,
 	var body io.Reader
 	contentType := ""
 
-	if requestOptions.Data != "" && (httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH") {
+	hasBody := httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH"
+
+	if requestOptions.BodyReader != nil && hasBody {
+		// Wrap the reader so net/http cannot detect a length for it (e.g. a
+		// *bytes.Reader) and always streams it with chunked encoding.
+		body = io.MultiReader(requestOptions.BodyReader)
+		if !hasHeader(requestOptions.Headers, "Content-Type") {
+			contentType = "application/octet-stream"
+		}
+	} else if requestOptions.Data != "" && hasBody {
 		body = bytes.NewBufferString(requestOptions.Data)
 
 		var js json.RawMessage
@@ -91,16 +353,7 @@ func (c *ApiClient) BuildRequest(requestOptions RequestOptions) (*http.Request,
 		}
 	}
 
-	return c.buildBaseRequest(
-		requestOptions.Method,
-		requestOptions.Endpoint,
-		body,
-		contentType,
-		requestOptions.Headers,
-		requestOptions.AuthType,
-		requestOptions.Username,
-		requestOptions.Trace,
-	)
+	return c.buildBaseRequest(requestOptions, body, contentType)
 }
 
 // BuildMultipartRequest builds an HTTP request with multipart form data
@@ -146,34 +399,79 @@ func (c *ApiClient) BuildMultipartRequest(options MultipartOptions) (*http.Reque
 	}
 
 	// Use the common base request builder with the multipart content type
-	return c.buildBaseRequest(
-		options.Method,
-		options.Endpoint,
-		body,
-		writer.FormDataContentType(),
-		options.Headers,
-		options.AuthType,
-		options.Username,
-		options.Trace,
-	)
-}
-
-// SendRequest sends an HTTP request
+	return c.buildBaseRequest(options.RequestOptions, body, writer.FormDataContentType())
+}
+
+// SendRequest sends an HTTP request, retrying it as WithRetry allows.
 func (c *ApiClient) SendRequest(options RequestOptions) (json.RawMessage, error) {
+	return c.sendWithRetry(options, func() (json.RawMessage, error) {
+		return c.sendRequest(options)
+	})
+}
+
+// sendRequest sends an HTTP request once.
+func (c *ApiClient) sendRequest(options RequestOptions) (json.RawMessage, error) {
 	req, err := c.BuildRequest(options)
 	if err != nil {
 		return nil, err
 	}
+	if options.DryRun {
+		return describeRequest(req, nil)
+	}
 
-	c.logRequest(req, options.Verbose)
+	var cacheKey string
+	if c.responseCache != nil && req.Method == "GET" {
+		cacheKey = ResponseCacheKey(req.Method, req.URL.String(), c.cacheAccount(options))
+		if accept := req.Header.Get("Accept"); accept != "" {
+			cacheKey += " accept=" + accept
+		}
+		if body, age, ok := c.responseCache.Get(cacheKey); ok {
+			if options.Verbose && c.verboseOut != nil {
+				fmt.Fprintf(c.verboseOut, "\033[1;34m* %s %s\033[0m served from the response cache (%s old)\n\n", req.Method, req.URL, age.Round(time.Second))
+			}
+			return body, nil
+		}
+	}
 
-	resp, err := c.client.Do(req)
+	c.beforeSend(req, options.Verbose)
+
+	httpClient := c.client
+	if options.BodyReader != nil {
+		// An unbounded streamed body must not be cut off by the timeout.
+		httpClient = &http.Client{Transport: c.client.Transport, CheckRedirect: c.client.CheckRedirect}
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, xurlErrors.NewHTTPError(err)
 	}
 	defer resp.Body.Close()
 
-	return c.processResponse(resp, options.Verbose)
+	body, err := c.processResponse(resp, options.Verbose)
+	if err == nil && cacheKey != "" && !IsNoContent(body) {
+		c.responseCache.Put(cacheKey, body)
+	}
+	return body, err
+}
+
+// cacheAccount names the account a request is sent as in its cache key: the
+// app, the auth type and the user asked for.
+func (c *ApiClient) cacheAccount(options RequestOptions) string {
+	app := ""
+	if c.auth != nil && c.auth.TokenStore != nil {
+		app = c.auth.TokenStore.GetActiveAppName(c.auth.AppName())
+	}
+	return app + "/" + strings.ToLower(options.AuthType) + "/" + options.Username
+}
+
+// hasHeader reports whether a "Name: value" header list sets name.
+func hasHeader(headers []string, name string) bool {
+	for _, header := range headers {
+		if key, _, ok := strings.Cut(header, ":"); ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return true
+		}
+	}
+	return false
 }
 
 // SendMultipartRequest sends an HTTP request with multipart form data
@@ -182,8 +480,11 @@ func (c *ApiClient) SendMultipartRequest(options MultipartOptions) (json.RawMess
 	if err != nil {
 		return nil, err
 	}
+	if options.DryRun {
+		return describeRequest(req, dryRunMultipartBody(options))
+	}
 
-	c.logRequest(req, options.Verbose)
+	c.beforeSend(req, options.Verbose)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -194,44 +495,39 @@ func (c *ApiClient) SendMultipartRequest(options MultipartOptions) (json.RawMess
 	return c.processResponse(resp, options.Verbose)
 }
 
-// StreamRequest sends an HTTP request and streams the response
-func (c *ApiClient) StreamRequest(options RequestOptions) error {
+// StreamRequest sends an HTTP request and delivers the streaming response
+// line by line (or element by element with StreamJSONArray) to handler until
+// the server closes the stream.
+func (c *ApiClient) StreamRequest(options RequestOptions, handler StreamHandler) error {
 	req, err := c.BuildRequest(options)
 	if err != nil {
 		return err
 	}
-
-	if options.Verbose {
-		fmt.Printf("\033[1;34m> %s\033[0m %s\n", req.Method, req.URL)
-		for key, values := range req.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;36m> %s\033[0m: %s\n", key, value)
-			}
+	if options.DryRun {
+		desc, err := describeRequest(req, nil)
+		if err != nil {
+			return err
 		}
-		fmt.Println()
+		return handler.OnLine(string(desc))
 	}
 
+	c.beforeSend(req, options.Verbose)
+
 	client := &http.Client{
-		Timeout: 0,
+		Transport:     c.client.Transport,
+		Timeout:       0,
+		CheckRedirect: c.client.CheckRedirect,
 	}
 
-	fmt.Printf("\033[1;32mConnecting to streaming endpoint: %s\033[0m\n", options.Endpoint)
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return xurlErrors.NewHTTPError(err)
 	}
 	defer resp.Body.Close()
 
-	if options.Verbose {
-		fmt.Printf("\033[1;31m< %s\033[0m\n", resp.Status)
-		for key, values := range resp.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;32m< %s\033[0m: %s\n", key, value)
-			}
-		}
-		fmt.Println()
-	}
+	c.logResponse(resp, options.Verbose)
+	c.reportNotices(resp, nil)
+	c.recordRateLimit(resp)
 
 	if resp.StatusCode >= 400 {
 		body, err := io.ReadAll(resp.Body)
@@ -239,31 +535,48 @@ func (c *ApiClient) StreamRequest(options RequestOptions) error {
 			return xurlErrors.NewIOError(err)
 		}
 
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return newRateLimitError(resp, body)
+		}
+
 		var js json.RawMessage
 		if err := json.Unmarshal(body, &js); err != nil {
 			return xurlErrors.NewJSONError(err)
 		}
 
-		return xurlErrors.NewAPIError(js)
+		return newAPIError(resp, js)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
+	if handler.OnConnect != nil {
+		handler.OnConnect()
+	}
+
+	if options.StreamJSONArray {
+		return consumeJSONArray(resp.Body, handler)
+	}
+	return consumeLines(resp.Body, handler)
+}
+
+// consumeLines delivers an NDJSON (or any line-delimited) stream to handler.
+func consumeLines(body io.Reader, handler StreamHandler) error {
+	scanner := bufio.NewScanner(body)
 
 	const maxScanTokenSize = 1024 * 1024
 	buf := make([]byte, maxScanTokenSize)
 	scanner.Buffer(buf, maxScanTokenSize)
 
-	fmt.Println("\033[1;32m--- Streaming response started ---\033[0m")
-	fmt.Println("\033[1;32m--- Press Ctrl+C to stop ---\033[0m")
-
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		// Skip keep-alive newlines
 		if line == "" {
 			continue
 		}
-		// We can't pretty-print streaming responses
-		fmt.Println(line)
+		if handler.OnLine != nil {
+			if err := handler.OnLine(line); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -273,13 +586,48 @@ func (c *ApiClient) StreamRequest(options RequestOptions) error {
 		return xurlErrors.NewIOError(err)
 	}
 
-	fmt.Println("\033[1;32m--- End of stream ---\033[0m")
+	return nil
+}
+
+// consumeJSONArray delivers each element of a streamed top-level JSON array
+// to handler as it is decoded, so a multi-megabyte array is never held in
+// memory as a whole; only one element is buffered at a time.
+func consumeJSONArray(body io.Reader, handler StreamHandler) error {
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return xurlErrors.NewJSONError(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return xurlErrors.NewJSONError(fmt.Errorf("expected a JSON array, got %v", tok))
+	}
+
+	for dec.More() {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return xurlErrors.NewJSONError(err)
+		}
+		var line bytes.Buffer
+		if err := json.Compact(&line, elem); err != nil {
+			return xurlErrors.NewJSONError(err)
+		}
+		if handler.OnLine != nil {
+			if err := handler.OnLine(line.String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return xurlErrors.NewJSONError(err)
+	}
 	return nil
 }
 
 // buildBaseRequest creates the base HTTP request with common headers and settings
-func (c *ApiClient) buildBaseRequest(method, endpoint string, body io.Reader, contentType string, headers []string, authType, username string, trace bool) (*http.Request, error) {
-	httpMethod := strings.ToUpper(method)
+func (c *ApiClient) buildBaseRequest(options RequestOptions, body io.Reader, contentType string) (*http.Request, error) {
+	httpMethod := strings.ToUpper(options.Method)
+	endpoint := options.Endpoint
 
 	// Build the full URL
 	url := endpoint
@@ -294,6 +642,15 @@ func (c *ApiClient) buildBaseRequest(method, endpoint string, body io.Reader, co
 			url += endpoint
 		}
 	}
+	url, err := options.Locale.applySearchFilters(url)
+	if err != nil {
+		return nil, xurlErrors.NewHTTPError(err)
+	}
+	if options.Rich {
+		if url, err = applyRichFields(httpMethod, url); err != nil {
+			return nil, xurlErrors.NewHTTPError(err)
+		}
+	}
 
 	// Create the request
 	req, err := http.NewRequest(httpMethod, url, body)
@@ -301,13 +658,28 @@ func (c *ApiClient) buildBaseRequest(method, endpoint string, body io.Reader, co
 		return nil, xurlErrors.NewHTTPError(err)
 	}
 
-	// Add headers
-	for _, header := range headers {
+	// Add headers, defaults first
+	for _, header := range c.defaultHeaders {
+		name, value, ok := strings.Cut(header, ":")
+		if ok && !hasHeader(options.Headers, strings.TrimSpace(name)) {
+			req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	for _, header := range options.Headers {
 		parts := strings.SplitN(header, ":", 2)
 		if len(parts) == 2 {
 			req.Header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
 		}
 	}
+	if options.Locale.AcceptLanguage != "" && !hasHeader(options.Headers, "Accept-Language") {
+		req.Header.Set("Accept-Language", options.Locale.AcceptLanguage)
+	}
+	if options.Accept != "" && !hasHeader(options.Headers, "Accept") {
+		req.Header.Set("Accept", options.Accept)
+	}
+	if c.requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, c.requestID)
+	}
 
 	// Set content type if provided
 	if contentType != "" {
@@ -320,92 +692,214 @@ func (c *ApiClient) buildBaseRequest(method, endpoint string, body io.Reader, co
 	// opts into unauthenticated requests (allowUnauthenticated, set only by
 	// library/test constructors), where we proceed and let the server decide.
 	if req.Header.Get("Authorization") == "" {
-		authHeader, err := c.getAuthHeader(httpMethod, url, authType, username)
+		authHeader, method, err := c.getAuthHeader(httpMethod, url, oauth1BodyParams(contentType, options.Data), options.AuthType, options.Username)
 		if err != nil {
 			if !c.allowUnauthenticated {
 				return nil, err
 			}
 		} else {
 			req.Header.Add("Authorization", authHeader)
+			c.reportAuthMethod(method)
 		}
 	}
 
 	// Add common headers
 	req.Header.Add("User-Agent", "xurl/"+version.Version)
 
-	if trace {
+	if options.Trace {
 		req.Header.Add("X-B3-Flags", "1")
 	}
 
 	return req, nil
 }
 
-// GetAuthHeader gets the authorization header for a request
-func (c *ApiClient) getAuthHeader(method, url string, authType string, username string) (string, error) {
+// oauth1BodyParams returns the parameters of a form-encoded request body,
+// which OAuth1 signs along with the query; other bodies are not signed.
+func oauth1BodyParams(contentType, data string) map[string]string {
+	if contentType != "application/x-www-form-urlencoded" || data == "" {
+		return nil
+	}
+	values, err := neturl.ParseQuery(data)
+	if err != nil {
+		return nil
+	}
+	params := make(map[string]string, len(values))
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+	return params
+}
+
+// AuthMethod describes the credential a request is signed with.
+type AuthMethod struct {
+	// Type is "oauth2", "oauth1" or "app".
+	Type string
+	// Username is the OAuth2 user, when known.
+	Username string
+}
+
+// String describes m as --show-auth-method prints it.
+func (m AuthMethod) String() string {
+	switch {
+	case m.Type == "app":
+		return "bearer"
+	case m.Type == "oauth2" && m.Username != "":
+		return fmt.Sprintf("oauth2 (username: %s)", m.Username)
+	default:
+		return m.Type
+	}
+}
+
+// reportAuthMethod writes m to the auth method writer, unless it was the
+// last one written.
+func (c *ApiClient) reportAuthMethod(m AuthMethod) {
+	if c.authMethodOut == nil {
+		return
+	}
+	c.authMethodMu.Lock()
+	defer c.authMethodMu.Unlock()
+	if m == c.lastAuthMethod {
+		return
+	}
+	c.lastAuthMethod = m
+	fmt.Fprintf(c.authMethodOut, "using %s\n", m)
+}
+
+// getAuthHeader gets the authorization header for a request, and which
+// credential it comes from. bodyParams are the parameters of a form-encoded
+// body, which an OAuth1 signature covers. Without an authType it falls back from the
+// OAuth2 token to OAuth1 and then to the app-only bearer token.
+func (c *ApiClient) getAuthHeader(method, url string, bodyParams map[string]string, authType string, username string) (string, AuthMethod, error) {
 	if c.auth == nil {
-		return "", xurlErrors.NewAuthError("AuthNotSet", errors.New("auth not set"))
+		return "", AuthMethod{}, xurlErrors.NewAuthError("AuthNotSet", errors.New("auth not set"))
 	}
 
 	if authType != "" {
-		switch strings.ToLower(authType) {
+		var header string
+		var err error
+		m := AuthMethod{Type: strings.ToLower(authType)}
+		switch m.Type {
 		case "oauth1":
-			return c.auth.GetOAuth1Header(method, url, nil)
+			header, err = c.auth.GetOAuth1Header(method, url, bodyParams)
 		case "oauth2":
-			return c.auth.GetOAuth2Header(username)
+			header, err = c.auth.GetOAuth2Header(username)
+			m.Username = c.oauth2Username(username)
 		case "app":
-			return c.auth.GetBearerTokenHeader()
+			header, err = c.auth.GetBearerTokenHeader()
 		default:
-			return "", xurlErrors.NewAuthError("InvalidAuthType", fmt.Errorf("invalid auth type: %s", authType))
+			return "", AuthMethod{}, xurlErrors.NewAuthError("InvalidAuthType", fmt.Errorf("invalid auth type: %s", authType))
 		}
+		if err != nil {
+			return "", AuthMethod{}, err
+		}
+		return header, m, nil
 	}
 
 	// If no auth type is specified, try to use the first OAuth2 token
 	appName := c.auth.AppName()
+	strict := c.auth.StrictAuth()
 	token := c.auth.TokenStore.GetFirstOAuth2TokenForApp(appName)
 	if token != nil {
 		accessToken, err := c.auth.GetOAuth2Header(username)
 		if err == nil {
-			return accessToken, nil
+			return accessToken, AuthMethod{Type: "oauth2", Username: c.oauth2Username(username)}, nil
 		}
 		// When a specific user was requested (-u/--username), do not silently
 		// downgrade to OAuth1 or app-only auth: that hides the failure and
 		// would act as a different principal than asked. Surface the error so
-		// the caller learns to re-authenticate that account.
-		if username != "" {
-			return "", err
+		// the caller learns to re-authenticate that account. Strict mode
+		// extends the same rule to the default user.
+		if username != "" || strict {
+			return "", AuthMethod{}, err
 		}
+	} else if username != "" && strict {
+		// A named user only ever maps to an OAuth2 token; with none stored,
+		// strict mode refuses to substitute OAuth1 or app-only credentials.
+		return "", AuthMethod{}, xurlErrors.NewAuthError("TokenNotFound",
+			fmt.Errorf("no OAuth2 token stored for %q (strict auth disables fallback to other credentials)", username))
 	}
 
 	// If no OAuth2 token is available, try to use the first OAuth1 token
 	token = c.auth.TokenStore.GetOAuth1TokensForApp(appName)
 	if token != nil {
-		authHeader, err := c.auth.GetOAuth1Header(method, url, nil)
+		authHeader, err := c.auth.GetOAuth1Header(method, url, bodyParams)
 		if err == nil {
-			return authHeader, nil
+			return authHeader, AuthMethod{Type: "oauth1"}, nil
+		}
+		if strict {
+			return "", AuthMethod{}, err
 		}
 	}
 
 	// If no OAuth1 token is available, try to use the bearer token
 	bearerToken, err := c.auth.GetBearerTokenHeader()
 	if err == nil {
-		return bearerToken, nil
+		return bearerToken, AuthMethod{Type: "app"}, nil
 	}
 
 	// If no authentication method is available, return an error
-	return "", xurlErrors.NewAuthError("NoAuthMethod", errors.New("no authentication method available"))
+	return "", AuthMethod{}, xurlErrors.NewAuthError("NoAuthMethod", errors.New("no authentication method available"))
 }
 
-// logRequest logs request details if verbose mode is enabled
+// oauth2Username is the user an OAuth2 header for username was made for:
+// username itself, or else the app's first stored user.
+func (c *ApiClient) oauth2Username(username string) string {
+	if username != "" {
+		return username
+	}
+	stored, _ := c.auth.TokenStore.GetFirstOAuth2TokenRecordForApp(c.auth.AppName())
+	return stored
+}
+
+// beforeSend logs req when verbose and calls the request hook; every request
+// passes through it right before it is sent.
+func (c *ApiClient) beforeSend(req *http.Request, verbose bool) {
+	c.logRequest(req, verbose)
+	if c.onRequest != nil {
+		c.onRequest(req)
+	}
+}
+
+// logRequest writes the request line and headers to the verbose writer.
 func (c *ApiClient) logRequest(req *http.Request, verbose bool) {
-	if verbose {
-		fmt.Printf("\033[1;34m> %s\033[0m %s\n", req.Method, req.URL)
-		for key, values := range req.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;36m> %s\033[0m: %s\n", key, value)
-			}
+	if !verbose || c.verboseOut == nil {
+		return
+	}
+	fmt.Fprintf(c.verboseOut, "\033[1;34m> %s\033[0m %s\n", req.Method, req.URL)
+	for key, values := range req.Header {
+		for _, value := range values {
+			fmt.Fprintf(c.verboseOut, "\033[1;36m> %s\033[0m: %s\n", key, truncateHeaderValue(value, c.maxHeaderSize))
+		}
+	}
+	fmt.Fprintln(c.verboseOut)
+}
+
+// logResponse writes the response status and headers to the verbose writer.
+func (c *ApiClient) logResponse(resp *http.Response, verbose bool) {
+	if !verbose || c.verboseOut == nil {
+		return
+	}
+	fmt.Fprintf(c.verboseOut, "\033[1;31m< %s\033[0m\n", resp.Status)
+	for key, values := range resp.Header {
+		for _, value := range values {
+			fmt.Fprintf(c.verboseOut, "\033[1;32m< %s\033[0m: %s\n", key, truncateHeaderValue(value, c.maxHeaderSize))
 		}
-		fmt.Println()
 	}
+	fmt.Fprintln(c.verboseOut)
+}
+
+// NoContent is the response the client returns for a successful request
+// whose reply has no body, such as 204 No Content, instead of a made-up {}.
+// It is JSON null, so unmarshalling it leaves the target untouched, and the
+// formatter prints a success line for it instead of JSON (see
+// utils.Formatter.Print). A body that is a literal null is returned
+// as its own value and is not NoContent.
+var NoContent = utils.NoContent
+
+// IsNoContent reports whether resp is NoContent, as opposed to any other
+// response, including a JSON null sent by the server.
+func IsNoContent(resp json.RawMessage) bool {
+	return utils.IsNoContent(resp)
 }
 
 // processResponse handles common response processing logic
@@ -415,21 +909,31 @@ func (c *ApiClient) processResponse(resp *http.Response, verbose bool) (json.Raw
 		return nil, xurlErrors.NewIOError(err)
 	}
 
-	if verbose {
-		fmt.Printf("\033[1;31m< %s\033[0m\n", resp.Status)
-		for key, values := range resp.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;32m< %s\033[0m: %s\n", key, value)
-			}
-		}
-		fmt.Println()
+	c.logResponse(resp, verbose)
+
+	c.reportNotices(resp, responseBody)
+	c.recordRateLimit(resp)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, newRateLimitError(resp, responseBody)
+	}
+
+	if resp.StatusCode < 400 && (resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(responseBody)) == 0) {
+		return NoContent, nil
 	}
 
 	var js json.RawMessage
 	if len(responseBody) > 0 {
 		if err := json.Unmarshal(responseBody, &js); err != nil {
 			if resp.StatusCode >= 400 {
-				return nil, xurlErrors.NewHTTPError(fmt.Errorf("HTTP error: %s", resp.Status))
+				e := xurlErrors.NewHTTPError(fmt.Errorf("HTTP error: %s", resp.Status))
+				e.StatusCode = resp.StatusCode
+				e.RetryAfter = parseRetryAfter(resp.Header, time.Now())
+				return nil, e
+			}
+			if resp.Request != nil && AcceptsNonJSON(resp.Request.Header.Get("Accept")) {
+				// Asked for, so handed back as is (see RequestOptions.Accept).
+				return responseBody, nil
 			}
 			js = json.RawMessage("{}")
 		}
@@ -438,8 +942,42 @@ func (c *ApiClient) processResponse(resp *http.Response, verbose bool) (json.Raw
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, xurlErrors.NewAPIError(js)
+		return nil, newAPIError(resp, js)
 	}
 
 	return js, nil
 }
+
+// recordRateLimit hands resp to the client's rate-limit tracker, if any.
+func (c *ApiClient) recordRateLimit(resp *http.Response) {
+	if c.rateLimits != nil {
+		c.rateLimits.Record(resp)
+	}
+}
+
+// newAPIError builds the error for a non-429 error response, recording the
+// status, Date header and whether the request was OAuth1-signed so clock-skew
+// problems can be diagnosed, and any
+// Retry-After so transient failures can be retried when the server says.
+func newAPIError(resp *http.Response, body json.RawMessage) error {
+	e := xurlErrors.NewAPIError(body)
+	e.StatusCode = resp.StatusCode
+	e.RetryAfter = parseRetryAfter(resp.Header, time.Now())
+	if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		e.ServerDate = t
+	}
+	if resp.Request != nil {
+		e.OAuth1 = strings.HasPrefix(resp.Request.Header.Get("Authorization"), "OAuth ")
+	}
+	return e
+}
+
+// newRateLimitError builds the error for a 429 response, keeping the JSON body
+// (when there is one) as the message so it can still be printed.
+func newRateLimitError(resp *http.Response, body []byte) error {
+	message := fmt.Sprintf("HTTP error: %s", resp.Status)
+	if json.Valid(body) {
+		message = string(body)
+	}
+	return xurlErrors.NewRateLimitError(message, parseRateLimitReset(resp.Header, time.Now()))
+}
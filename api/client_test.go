@@ -1,13 +1,17 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
@@ -290,7 +294,7 @@ func TestGetAuthHeader(t *testing.T) {
 	t.Run("No auth set", func(t *testing.T) {
 		client := NewApiClient(cfg, nil)
 
-		_, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", "", "")
+		_, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "")
 
 		assert.Error(t, err, "Expected an error")
 		assert.True(t, xurlErrors.IsAuthError(err), "Expected auth error")
@@ -301,7 +305,7 @@ func TestGetAuthHeader(t *testing.T) {
 		defer os.RemoveAll(tempDir)
 		client := NewApiClient(cfg, authMock)
 
-		_, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", "invalid", "")
+		_, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "invalid", "")
 
 		assert.Error(t, err, "Expected an error")
 		assert.True(t, xurlErrors.IsAuthError(err), "Expected auth error")
@@ -318,7 +322,7 @@ func TestGetAuthHeader(t *testing.T) {
 		a := auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore).WithAppName("my-app")
 		client := NewApiClient(cfg, a)
 
-		header, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", "", "")
+		header, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "")
 		require.NoError(t, err)
 		assert.Equal(t, "Bearer bearer-my-app", header)
 	})
@@ -336,12 +340,158 @@ func TestGetAuthHeader(t *testing.T) {
 		a := auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore)
 		client := NewApiClient(cfg, a)
 
-		header, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", "", "")
+		header, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "")
 		require.NoError(t, err)
 		assert.Equal(t, "Bearer bearer-default", header)
 	})
 }
 
+func TestGetAuthHeaderMethod(t *testing.T) {
+	cfg := &config.Config{APIBaseURL: "https://api.x.com"}
+	future := uint64(time.Now().Add(time.Hour).Unix())
+
+	tests := []struct {
+		name               string
+		oauth2Users        []string
+		oauth1, bearer     bool
+		authType, username string
+		want               AuthMethod
+		wantString         string
+	}{
+		{name: "bearer only", bearer: true, want: AuthMethod{Type: "app"}, wantString: "bearer"},
+		{name: "oauth1 before bearer", oauth1: true, bearer: true, want: AuthMethod{Type: "oauth1"}, wantString: "oauth1"},
+		{name: "default oauth2 user", oauth2Users: []string{"alice"}, oauth1: true, bearer: true, want: AuthMethod{Type: "oauth2", Username: "alice"}, wantString: "oauth2 (username: alice)"},
+		{name: "named oauth2 user", oauth2Users: []string{"alice", "bob"}, username: "bob", want: AuthMethod{Type: "oauth2", Username: "bob"}, wantString: "oauth2 (username: bob)"},
+		{name: "explicit app", oauth2Users: []string{"alice"}, bearer: true, authType: "app", want: AuthMethod{Type: "app"}, wantString: "bearer"},
+		{name: "explicit oauth2", oauth2Users: []string{"alice"}, bearer: true, authType: "OAuth2", want: AuthMethod{Type: "oauth2", Username: "alice"}, wantString: "oauth2 (username: alice)"},
+		{name: "explicit oauth1", oauth1: true, authType: "oauth1", want: AuthMethod{Type: "oauth1"}, wantString: "oauth1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenStore, tempDir := createTempTokenStore(t)
+			defer os.RemoveAll(tempDir)
+			for _, user := range tt.oauth2Users {
+				require.NoError(t, tokenStore.SaveOAuth2Token(user, "access-"+user, "refresh", future))
+			}
+			if tt.oauth1 {
+				require.NoError(t, tokenStore.SaveOAuth1Tokens("token", "secret", "key", "consumer-secret"))
+			}
+			if tt.bearer {
+				require.NoError(t, tokenStore.SaveBearerToken("bearer"))
+			}
+			client := NewApiClient(cfg, auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore))
+
+			_, method, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, tt.authType, tt.username)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, method)
+			assert.Equal(t, tt.wantString, method.String())
+		})
+	}
+}
+
+func TestAuthMethodWriter(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, tokenStore.SaveBearerToken("bearer"))
+	require.NoError(t, tokenStore.SaveOAuth1Tokens("token", "secret", "key", "consumer-secret"))
+
+	var out bytes.Buffer
+	client := NewApiClient(&config.Config{APIBaseURL: "https://api.x.com"}, auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore)).
+		WithAuthMethodWriter(&out)
+	for _, authType := range []string{"", "", "app", "oauth1"} {
+		_, err := client.BuildRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me", AuthType: authType})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "using oauth1\nusing bearer\nusing oauth1\n", out.String(), "one line per change of credential")
+}
+
+func TestOAuth1SignsFormBody(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, tokenStore.SaveOAuth1Tokens("token", "secret", "key", "consumer-secret"))
+
+	var debug bytes.Buffer
+	client := NewApiClient(&config.Config{APIBaseURL: "https://api.x.com"}, auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore).WithOAuth1Debug(&debug))
+	send := func(data string) string {
+		debug.Reset()
+		_, err := client.BuildRequest(RequestOptions{Method: "POST", Endpoint: "/1.1/account/update_profile.json", Data: data, AuthType: "oauth1"})
+		require.NoError(t, err)
+		return debug.String()
+	}
+
+	signed := send("name=Ada+Lovelace&description=hi")
+	assert.Contains(t, signed, "  name=Ada%20Lovelace\n", "form parameters are signed")
+	assert.Contains(t, signed, "  description=hi\n")
+	assert.NotContains(t, send(`{"name":"Ada"}`), "name=", "a JSON body is not")
+}
+
+func TestGetAuthHeaderStrictAuth(t *testing.T) {
+	cfg := &config.Config{APIBaseURL: "https://api.x.com"}
+
+	t.Run("Unknown username falls back to bearer by default", func(t *testing.T) {
+		tokenStore, tempDir := createTempTokenStore(t)
+		defer os.RemoveAll(tempDir)
+		require.NoError(t, tokenStore.SaveBearerToken("bearer-default"))
+
+		a := auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore)
+		client := NewApiClient(cfg, a)
+
+		header, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer bearer-default", header)
+	})
+
+	t.Run("Unknown username errors under strict auth", func(t *testing.T) {
+		tokenStore, tempDir := createTempTokenStore(t)
+		defer os.RemoveAll(tempDir)
+		require.NoError(t, tokenStore.SaveBearerToken("bearer-default"))
+
+		a := auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore).WithStrictAuth(true)
+		client := NewApiClient(cfg, a)
+
+		_, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "alice")
+		require.Error(t, err)
+		assert.True(t, xurlErrors.IsAuthError(err), "expected an auth error")
+		assert.Contains(t, err.Error(), "alice")
+	})
+
+	// An expired OAuth2 token whose refresh fails is silently skipped in
+	// favor of the bearer token by default, but is fatal in strict mode.
+	refreshFails := func(t *testing.T, strict bool) (string, error) {
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		}))
+		defer tokenServer.Close()
+
+		tokenStore, tempDir := createTempTokenStore(t)
+		defer os.RemoveAll(tempDir)
+		require.NoError(t, tokenStore.SaveOAuth2Token("alice", "expired", "refresh", 1))
+		require.NoError(t, tokenStore.SaveBearerToken("bearer-default"))
+
+		a := auth.NewAuth(&config.Config{ClientID: "id", TokenURL: tokenServer.URL}).
+			WithTokenStore(tokenStore).
+			WithStrictAuth(strict)
+		client := NewApiClient(cfg, a)
+
+		header, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "")
+		return header, err
+	}
+
+	t.Run("Failed refresh falls back by default", func(t *testing.T) {
+		header, err := refreshFails(t, false)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer bearer-default", header)
+	})
+
+	t.Run("Failed refresh errors under strict auth", func(t *testing.T) {
+		_, err := refreshFails(t, true)
+		require.Error(t, err)
+		assert.True(t, xurlErrors.IsAuthError(err), "expected an auth error")
+	})
+}
+
 func TestBuildRequestPropagatesAuthError(t *testing.T) {
 	cfg := &config.Config{APIBaseURL: "https://api.x.com"}
 	tokenStore, tempDir := createTempTokenStore(t)
@@ -388,8 +538,8 @@ func TestStreamRequest(t *testing.T) {
 		if r.URL.Path == "/2/tweets/search/stream" {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
-			// In a real test, we would write multiple JSON objects with flushing
-			// but for this simple test, we'll just close the connection
+			// Two lines separated by a keep-alive newline, then close.
+			w.Write([]byte("{\"data\":1}\r\n\r\n{\"data\":2}\r\n"))
 			return
 		}
 
@@ -422,11 +572,158 @@ func TestStreamRequest(t *testing.T) {
 			Verbose:  false,
 		}
 
-		err := client.StreamRequest(options)
+		connected := false
+		err := client.StreamRequest(options, StreamHandler{OnConnect: func() { connected = true }})
 
 		assert.Error(t, err, "Expected an error")
 		assert.True(t, xurlErrors.IsAPIError(err), "Expected API error")
+		assert.False(t, connected, "OnConnect must not fire for a rejected stream")
+	})
+
+	t.Run("Stream delivers lines to the handler", func(t *testing.T) {
+		var lines []string
+		connected := false
+		err := client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"}, StreamHandler{
+			OnConnect: func() { connected = true },
+			OnLine: func(line string) error {
+				lines = append(lines, line)
+				return nil
+			},
+		})
+
+		require.NoError(t, err)
+		assert.True(t, connected)
+		assert.Equal(t, []string{`{"data":1}`, `{"data":2}`}, lines, "keep-alive newlines are skipped")
 	})
+
+	t.Run("Handler error stops the stream", func(t *testing.T) {
+		stop := fmt.Errorf("stop")
+		calls := 0
+		err := client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"}, StreamHandler{
+			OnLine: func(line string) error {
+				calls++
+				return stop
+			},
+		})
+
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestVerboseTraceGoesToConfiguredWriter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	opts := RequestOptions{Method: "GET", Endpoint: "/2/users/me", Verbose: true}
+
+	// No writer configured: verbose is a no-op rather than printing to stdout.
+	_, err := client.SendRequest(opts)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	client.WithVerboseWriter(&buf)
+	_, err = client.SendRequest(opts)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "> GET")
+	assert.Contains(t, buf.String(), "< 200 OK")
+	assert.Contains(t, buf.String(), "X-Test")
+}
+
+func TestVerboseTraceTruncatesLongHeaders(t *testing.T) {
+	long := strings.Repeat("a", 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Huge", long)
+		w.Header().Set("X-Small", "fine")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	var buf bytes.Buffer
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).WithVerboseWriter(&buf)
+	opts := RequestOptions{Method: "GET", Endpoint: "/2/users/me", Verbose: true, Headers: []string{"X-Sent: " + long}}
+
+	_, err := client.SendRequest(opts)
+	require.NoError(t, err)
+	out := buf.String()
+	assert.NotContains(t, out, long[:DefaultMaxHeaderSize+1])
+	assert.Contains(t, out, long[:DefaultMaxHeaderSize]+"… (20000 bytes, truncated)")
+	assert.Equal(t, 2, strings.Count(out, "(20000 bytes, truncated)"), "request and response headers alike")
+	assert.Contains(t, out, ": fine\n")
+
+	buf.Reset()
+	client.WithMaxHeaderSize(0)
+	_, err = client.SendRequest(opts)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), long+"\n", "0 prints values whole")
+}
+
+func TestTruncateHeaderValue(t *testing.T) {
+	assert.Equal(t, "short", truncateHeaderValue("short", 5))
+	assert.Equal(t, "abc… (6 bytes, truncated)", truncateHeaderValue("abcdef", 3))
+	assert.Equal(t, "a… (6 bytes, truncated)", truncateHeaderValue("aéèx", 2), "a character is not split")
+	assert.Equal(t, "abcdef", truncateHeaderValue("abcdef", 0))
+}
+
+// TestEmptyResponses checks that successful responses without a body come
+// back as NoContent from the regular, multipart and shortcut paths, while
+// bodies, including a literal null, and errors are left alone.
+func TestEmptyResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      string
+		noContent bool
+		wantErr   bool
+	}{
+		{"204", http.StatusNoContent, "", "null", true, false},
+		{"200 empty", http.StatusOK, "", "null", true, false},
+		{"200 whitespace", http.StatusOK, " \n", "null", true, false},
+		{"200 null", http.StatusOK, "null", "null", false, false},
+		{"200 empty object", http.StatusOK, `{}`, `{}`, false, false},
+		{"201 body", http.StatusCreated, `{"data":{"id":"1"}}`, `{"data":{"id":"1"}}`, false, false},
+		{"200 not JSON", http.StatusOK, "OK", `{}`, false, false},
+		{"404 empty", http.StatusNotFound, "", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client := shortcutClient(t, server)
+			opts := RequestOptions{Method: "DELETE", Endpoint: "/2/users/1/likes/2", AuthType: "app"}
+
+			check := func(path string, resp json.RawMessage, err error) {
+				if tt.wantErr {
+					assert.Error(t, err, path)
+					return
+				}
+				require.NoError(t, err, path)
+				assert.Equal(t, tt.want, string(resp), path)
+				assert.Equal(t, tt.noContent, IsNoContent(resp), path)
+			}
+			resp, err := client.SendRequest(opts)
+			check("regular", resp, err)
+			resp, err = client.SendMultipartRequest(MultipartOptions{RequestOptions: opts, FormFields: map[string]string{"command": "APPEND"}})
+			check("multipart", resp, err)
+			resp, err = UnlikePost(client, "1", "2", opts)
+			check("shortcut", resp, err)
+		})
+	}
+
+	var target struct{ Data struct{ ID string } }
+	assert.NoError(t, json.Unmarshal(NoContent, &target), "NoContent unmarshals into anything")
 }
 
 // TestExplicitUsernameDoesNotDowngradeToAppOnly ensures that when a specific
@@ -452,7 +749,111 @@ func TestExplicitUsernameDoesNotDowngradeToAppOnly(t *testing.T) {
 	mockAuth.WithTokenStore(ts)
 
 	client := NewApiClient(cfg, mockAuth)
-	_, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", "", "alice")
+	_, _, err := client.getAuthHeader("GET", "https://api.x.com/2/users/me", nil, "", "alice")
 	require.Error(t, err, "explicit user with a failed token must not downgrade to app-only")
 	assert.False(t, xurlErrors.IsAPIError(err) && err.Error() == "", "should surface the refresh error")
 }
+
+func TestSendRequestStreamsBodyReaderChunked(t *testing.T) {
+	type received struct {
+		transferEncoding []string
+		contentLength    int64
+		contentType      string
+		body             string
+	}
+	got := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.TransferEncoding, r.ContentLength, r.Header.Get("Content-Type"), string(body)}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	// An unbounded producer: the body is written to a pipe while the request
+	// is already in flight.
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(pw, "line %d\n", i)
+			time.Sleep(10 * time.Millisecond)
+		}
+		pw.Close()
+	}()
+
+	resp, err := client.SendRequest(RequestOptions{Method: "POST", Endpoint: "/2/ingest", BodyReader: pr})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(resp))
+
+	r := <-got
+	assert.Equal(t, []string{"chunked"}, r.transferEncoding)
+	assert.Equal(t, int64(-1), r.contentLength)
+	assert.Equal(t, "application/octet-stream", r.contentType)
+	assert.Equal(t, "line 0\nline 1\nline 2\n", r.body)
+}
+
+func TestStreamRequestJSONArray(t *testing.T) {
+	const total = 2000
+	firstSeen := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		padding := strings.Repeat("x", 1024)
+
+		// One multi-megabyte array with no newlines, sent in chunks.
+		w.Write([]byte("[\n"))
+		for i := 0; i < total; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"id": %d, "pad": "%s"}`, i, padding)
+			if i == 0 {
+				flusher.Flush()
+				// The client must emit the first element before the rest of
+				// the array has even been written.
+				select {
+				case <-firstSeen:
+				case <-time.After(5 * time.Second):
+					t.Error("first element was not emitted while the array was still streaming")
+				}
+			}
+		}
+		w.Write([]byte("]"))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	var count int
+	err := client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/array", StreamJSONArray: true}, StreamHandler{
+		OnLine: func(line string) error {
+			if count == 0 {
+				assert.True(t, strings.HasPrefix(line, `{"id":0,"pad":"xxx`), line)
+				close(firstSeen)
+			}
+			assert.NotContains(t, line, "\n")
+			count++
+			return nil
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, total, count)
+
+	t.Run("non-array body is an error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":[]}`))
+		}))
+		defer srv.Close()
+		c := NewApiClient(&config.Config{APIBaseURL: srv.URL}, authMock)
+		err := c.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/array", StreamJSONArray: true}, StreamHandler{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ComplianceEndpoint is the endpoint for batch compliance jobs.
+const ComplianceEndpoint = "/2/compliance/jobs"
+
+// Compliance job states, as reported in ComplianceJob.Status.
+const (
+	ComplianceCreated    = "created"
+	ComplianceInProgress = "in_progress"
+	ComplianceComplete   = "complete"
+	ComplianceFailed     = "failed"
+	ComplianceExpired    = "expired"
+)
+
+// complianceCheckInterval is how often WaitForComplianceJob checks a job;
+// jobs take minutes, and the endpoint does not suggest an interval.
+const complianceCheckInterval = 15 * time.Second
+
+// ComplianceJob is a batch compliance job. Upload the IDs to UploadURL with
+// UploadComplianceIDs, then, once Status is complete, fetch the results from
+// DownloadURL with DownloadComplianceResults.
+type ComplianceJob struct {
+	ID                string `json:"id"`
+	Type              string `json:"type"`
+	Name              string `json:"name,omitempty"`
+	Status            string `json:"status"`
+	UploadURL         string `json:"upload_url"`
+	UploadExpiresAt   string `json:"upload_expires_at"`
+	DownloadURL       string `json:"download_url"`
+	DownloadExpiresAt string `json:"download_expires_at"`
+	CreatedAt         string `json:"created_at"`
+}
+
+// ParseComplianceJob reads the job from a create or status response.
+func ParseComplianceJob(resp json.RawMessage) (ComplianceJob, error) {
+	var body struct {
+		Data ComplianceJob `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return ComplianceJob{}, fmt.Errorf("could not parse compliance job: %v", err)
+	}
+	if body.Data.ID == "" {
+		return ComplianceJob{}, fmt.Errorf("response has no compliance job")
+	}
+	return body.Data, nil
+}
+
+// CreateComplianceJob creates a compliance job for "tweets" or "users"; name
+// is optional.
+func CreateComplianceJob(client Client, jobType, name string, opts RequestOptions) (json.RawMessage, error) {
+	body := map[string]string{"type": jobType}
+	if name != "" {
+		body["name"] = name
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Method = "POST"
+	opts.Endpoint = ComplianceEndpoint
+	opts.Data = string(data)
+
+	return client.SendRequest(opts)
+}
+
+// ListComplianceJobs lists the project's compliance jobs of jobType,
+// optionally only those in status.
+func ListComplianceJobs(client Client, jobType, status string, opts RequestOptions) (json.RawMessage, error) {
+	q := url.Values{"type": {jobType}}
+	if status != "" {
+		q.Set("status", status)
+	}
+
+	opts.Method = "GET"
+	opts.Endpoint = ComplianceEndpoint + "?" + q.Encode()
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetComplianceJob fetches a compliance job by ID.
+func GetComplianceJob(client Client, jobID string, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = ComplianceEndpoint + "/" + url.PathEscape(jobID)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// WaitForComplianceJob polls a job until it is complete, within the limits
+// of wait, and returns the final status response. A failed or expired job is
+// an error. onStatus, if set, receives every status seen.
+func WaitForComplianceJob(client Client, jobID string, wait ProcessingWait, opts RequestOptions, onStatus func(ComplianceJob)) (json.RawMessage, error) {
+	var final json.RawMessage
+	err := wait.poll(func() (bool, time.Duration, error) {
+		resp, err := GetComplianceJob(client, jobID, opts)
+		if err != nil {
+			return false, 0, err
+		}
+		job, err := ParseComplianceJob(resp)
+		if err != nil {
+			return false, 0, err
+		}
+		if onStatus != nil {
+			onStatus(job)
+		}
+		switch job.Status {
+		case ComplianceComplete:
+			final = resp
+			return true, 0, nil
+		case ComplianceFailed, ComplianceExpired:
+			return false, 0, fmt.Errorf("compliance job %s %s", jobID, job.Status)
+		}
+		return false, complianceCheckInterval, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return final, nil
+}
+
+// UploadComplianceIDs uploads the IDs file at path, one ID per line, to a
+// job's pre-signed upload URL with a plain PUT. Pre-signed URLs carry their
+// own signature, so this and DownloadComplianceResults only use client's
+// transport and never add an Authorization header.
+func UploadComplianceIDs(client Client, uploadURL, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", uploadURL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := plainHTTPClient(client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload_url answered %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// DownloadComplianceResults copies a completed job's results, one JSON object
+// per line, from its pre-signed download URL to w.
+func DownloadComplianceResults(client Client, downloadURL string, w io.Writer) (int64, error) {
+	resp, err := plainHTTPClient(client).Get(downloadURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return 0, fmt.Errorf("download_url answered %s: %s", resp.Status, body)
+	}
+	return io.Copy(w, resp.Body)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplianceJobFlow(t *testing.T) {
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	sleep = func(time.Duration) {}
+
+	var uploaded []byte
+	var uploadAuth string
+	statusChecks := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		job := func(status string) string {
+			return `{"data":{"id":"77","type":"tweets","status":"` + status + `",` +
+				`"upload_url":"` + server.URL + `/upload?sig=abc","download_url":"` + server.URL + `/download?sig=def"}}`
+		}
+		switch {
+		case r.Method == "POST" && r.URL.Path == ComplianceEndpoint:
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"type":"tweets","name":"nightly"}`, string(body))
+			w.Write([]byte(job(ComplianceCreated)))
+		case r.Method == "PUT" && r.URL.Path == "/upload":
+			uploadAuth = r.Header.Get("Authorization")
+			assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+			uploaded, _ = io.ReadAll(r.Body)
+		case r.Method == "GET" && r.URL.Path == ComplianceEndpoint+"/77":
+			statusChecks++
+			if statusChecks == 1 {
+				w.Write([]byte(job(ComplianceInProgress)))
+			} else {
+				w.Write([]byte(job(ComplianceComplete)))
+			}
+		case r.Method == "GET" && r.URL.Path == "/download":
+			w.Write([]byte("{\"id\":\"1\",\"action\":\"delete\"}\n"))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	idsFile := filepath.Join(t.TempDir(), "ids.txt")
+	require.NoError(t, os.WriteFile(idsFile, []byte("1\n2\n"), 0600))
+
+	resp, err := CreateComplianceJob(client, "tweets", "nightly", baseTestOpts())
+	require.NoError(t, err)
+	job, err := ParseComplianceJob(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "77", job.ID)
+
+	require.NoError(t, UploadComplianceIDs(client, job.UploadURL, idsFile))
+	assert.Equal(t, "1\n2\n", string(uploaded))
+	assert.Empty(t, uploadAuth, "pre-signed URLs get no Authorization header")
+
+	var seen []string
+	final, err := WaitForComplianceJob(client, job.ID, ProcessingWait{}, baseTestOpts(), func(j ComplianceJob) { seen = append(seen, j.Status) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{ComplianceInProgress, ComplianceComplete}, seen)
+	job, err = ParseComplianceJob(final)
+	require.NoError(t, err)
+
+	var results bytes.Buffer
+	n, err := DownloadComplianceResults(client, job.DownloadURL, &results)
+	require.NoError(t, err)
+	assert.Equal(t, int64(results.Len()), n)
+	assert.Equal(t, "{\"id\":\"1\",\"action\":\"delete\"}\n", results.String())
+}
+
+func TestWaitForComplianceJobFailed(t *testing.T) {
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", RequestOptions{Method: "GET", Endpoint: ComplianceEndpoint + "/9"}).
+		Return(json.RawMessage(`{"data":{"id":"9","status":"expired"}}`), nil).Once()
+
+	_, err := WaitForComplianceJob(mockClient, "9", ProcessingWait{}, RequestOptions{}, nil)
+	assert.EqualError(t, err, "compliance job 9 expired")
+	mockClient.AssertExpectations(t)
+}
+
+func TestListComplianceJobs(t *testing.T) {
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", RequestOptions{Method: "GET", Endpoint: ComplianceEndpoint + "?status=complete&type=users"}).
+		Return(json.RawMessage(`{"data":[]}`), nil).Once()
+
+	_, err := ListComplianceJobs(mockClient, "users", "complete", RequestOptions{})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
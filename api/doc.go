@@ -0,0 +1,20 @@
+// Package api is xurl's client for the X API, usable as a library.
+//
+// The entry points are:
+//
+//   - Client, implemented by ApiClient (NewApiClient), which builds, signs,
+//     and sends requests described by RequestOptions / MultipartOptions and
+//     returns the JSON body or an error from the xurl errors package.
+//     StreamRequest delivers streaming endpoints line by line to a
+//     StreamHandler.
+//   - MediaUploader for chunked media uploads; progress is reported through
+//     OnProgress.
+//   - The shortcut helpers (CreatePost, SearchPosts, GetMe, ...) and chat
+//     helpers, thin wrappers that take a Client and return the raw response.
+//
+// Nothing in Client or MediaUploader writes to stdout or stderr. Verbose
+// request traces and API deprecation notices are discarded unless a writer
+// is configured with ApiClient.WithVerboseWriter / WithNoticeWriter. The
+// Execute* and Handle* functions are the presentation layer used by the xurl
+// CLI: they print responses and progress and are not intended for embedding.
+package api
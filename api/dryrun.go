@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// dryRunRequest is what a dry run returns in place of a response.
+type dryRunRequest struct {
+	DryRun  bool              `json:"dry_run"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// IsDryRun reports whether resp is the description of a request that
+// RequestOptions.DryRun kept from being sent.
+func IsDryRun(resp json.RawMessage) bool {
+	var r struct {
+		DryRun bool `json:"dry_run"`
+	}
+	return json.Unmarshal(resp, &r) == nil && r.DryRun
+}
+
+// describeRequest returns the dry-run description of req: its method, URL,
+// headers and body. Authorization keeps its scheme but not its credentials,
+// so the output is safe to paste. body overrides what is read from req.Body;
+// a streamed body (see RequestOptions.BodyReader) is not read at all.
+func describeRequest(req *http.Request, body any) (json.RawMessage, error) {
+	out := dryRunRequest{
+		DryRun:  true,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: map[string]string{},
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(req.Header.Values(name), ", ")
+		if strings.EqualFold(name, "Authorization") {
+			scheme, _, _ := strings.Cut(value, " ")
+			value = scheme + " ***"
+		}
+		out.Headers[name] = value
+	}
+
+	switch {
+	case body != nil:
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		out.Body = b
+	case req.GetBody != nil:
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		out.Body = dryRunBody(data)
+	case req.Body != nil && req.Body != http.NoBody:
+		out.Body = json.RawMessage(`"(streamed body)"`)
+	}
+	return json.Marshal(out)
+}
+
+// dryRunBody embeds a JSON body as-is and any other body as a string.
+func dryRunBody(data []byte) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		return data
+	}
+	b, _ := json.Marshal(string(data))
+	return b
+}
+
+// dryRunMultipartBody summarises a multipart body without its file contents.
+func dryRunMultipartBody(options MultipartOptions) any {
+	body := map[string]any{}
+	if len(options.FormFields) > 0 {
+		body["fields"] = options.FormFields
+	}
+	switch {
+	case options.FileField != "" && options.FilePath != "":
+		body["file"] = map[string]string{"field": options.FileField, "path": options.FilePath}
+	case options.FileField != "" && len(options.FileData) > 0:
+		body["file"] = map[string]any{"field": options.FileField, "name": options.FileName, "bytes": len(options.FileData)}
+	}
+	return body
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunSendsNothing(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	opts := baseTestOpts()
+	opts.DryRun = true
+	opts.AuthType = "app"
+
+	t.Run("request", func(t *testing.T) {
+		opts := opts
+		opts.Method = "POST"
+		opts.Endpoint = "/2/users/1/likes"
+		opts.Data = `{"tweet_id":"20"}`
+		resp, err := client.SendRequest(opts)
+		require.NoError(t, err)
+		assert.True(t, IsDryRun(resp))
+
+		var desc dryRunRequest
+		require.NoError(t, json.Unmarshal(resp, &desc))
+		assert.Equal(t, "POST", desc.Method)
+		assert.Equal(t, server.URL+"/2/users/1/likes", desc.URL)
+		assert.Equal(t, "Bearer ***", desc.Headers["Authorization"], "credentials are masked")
+		assert.Equal(t, "application/json", desc.Headers["Content-Type"])
+		assert.JSONEq(t, `{"tweet_id":"20"}`, string(desc.Body))
+	})
+
+	t.Run("shortcut", func(t *testing.T) {
+		resp, err := UnlikePost(client, "1", "20", opts)
+		require.NoError(t, err)
+		assert.Contains(t, string(resp), `"method":"DELETE"`)
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		resp, err := client.SendMultipartRequest(MultipartOptions{
+			RequestOptions: RequestOptions{Method: "POST", Endpoint: "/2/media/upload", AuthType: "app", DryRun: true},
+			FormFields:     map[string]string{"command": "APPEND"},
+			FileField:      "media",
+			FileName:       "clip.mp4",
+			FileData:       []byte("0123456789"),
+		})
+		require.NoError(t, err)
+		var desc dryRunRequest
+		require.NoError(t, json.Unmarshal(resp, &desc))
+		assert.JSONEq(t, `{"fields":{"command":"APPEND"},"file":{"field":"media","name":"clip.mp4","bytes":10}}`, string(desc.Body))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		opts := opts
+		opts.Method = "GET"
+		opts.Endpoint = "/2/tweets/search/stream"
+		var lines []string
+		err := client.StreamRequest(opts, StreamHandler{OnLine: func(line string) error {
+			lines = append(lines, line)
+			return nil
+		}})
+		require.NoError(t, err)
+		require.Len(t, lines, 1)
+		assert.True(t, IsDryRun(json.RawMessage(lines[0])))
+	})
+
+	assert.Zero(t, calls.Load(), "a dry run must not reach the server")
+	assert.False(t, IsDryRun(json.RawMessage(`{"data":{"liked":true}}`)))
+}
@@ -1,20 +1,13 @@
 package api
 
 import (
+	"net/url"
 	"strings"
 )
 
-// StreamingEndpoints is a map of endpoint prefixes that should be streamed
-var StreamingEndpoints = map[string]bool{
-	"/2/tweets/search/stream":           true,
-	"/2/tweets/sample/stream":           true,
-	"/2/tweets/sample10/stream":         true,
-	"/2/tweets/firehose/stream":         true,
-	"/2/tweets/firehose/stream/lang/en": true,
-	"/2/tweets/firehose/stream/lang/ja": true,
-	"/2/tweets/firehose/stream/lang/ko": true,
-	"/2/tweets/firehose/stream/lang/pt": true,
-}
+// StreamingEndpoints is a map of endpoint prefixes that should be streamed,
+// built from the streaming entries of the endpoint catalog.
+var StreamingEndpoints = streamingPaths()
 
 // IsStreamingEndpoint checks if an endpoint should be streamed
 func IsStreamingEndpoint(endpoint string) bool {
@@ -35,3 +28,102 @@ func IsStreamingEndpoint(endpoint string) bool {
 
 	return StreamingEndpoints[normalizedEndpoint]
 }
+
+// Default fields requested by --rich, so responses carry useful data without
+// knowing the field names.
+const (
+	richTweetFields = "created_at,author_id,public_metrics,conversation_id,lang,entities"
+	richUserFields  = "created_at,description,location,public_metrics,verified,profile_image_url"
+)
+
+var (
+	richTweetParams = map[string]string{
+		"tweet.fields": richTweetFields,
+		"expansions":   "author_id",
+		"user.fields":  "username,name,verified",
+	}
+	richUserParams = map[string]string{
+		"user.fields": richUserFields,
+	}
+)
+
+// RichFields maps endpoint paths to the query parameters --rich adds to GET
+// requests for them. In a path, ":id" matches any single segment.
+var RichFields = map[string]map[string]string{
+	"/2/tweets":                                    richTweetParams,
+	"/2/tweets/:id":                                richTweetParams,
+	"/2/tweets/:id/quote_tweets":                   richTweetParams,
+	"/2/tweets/search/recent":                      richTweetParams,
+	"/2/tweets/search/all":                         richTweetParams,
+	"/2/tweets/search/stream":                      richTweetParams,
+	"/2/tweets/sample/stream":                      richTweetParams,
+	"/2/users/:id/tweets":                          richTweetParams,
+	"/2/users/:id/mentions":                        richTweetParams,
+	"/2/users/:id/liked_tweets":                    richTweetParams,
+	"/2/users/:id/bookmarks":                       richTweetParams,
+	"/2/users/:id/timelines/reverse_chronological": richTweetParams,
+	"/2/lists/:id/tweets":                          richTweetParams,
+	"/2/users":                                     richUserParams,
+	"/2/users/:id":                                 richUserParams,
+	"/2/users/by":                                  richUserParams,
+	"/2/users/by/username/:id":                     richUserParams,
+	"/2/users/:id/following":                       richUserParams,
+	"/2/users/:id/followers":                       richUserParams,
+	"/2/users/:id/blocking":                        richUserParams,
+	"/2/users/:id/muting":                          richUserParams,
+	"/2/tweets/:id/liking_users":                   richUserParams,
+	"/2/tweets/:id/retweeted_by":                   richUserParams,
+	"/2/lists/:id/members":                         richUserParams,
+}
+
+// richParams returns the RichFields entry for path, preferring an exact
+// match over one through ":id".
+func richParams(path string) map[string]string {
+	path = strings.TrimSuffix(path, "/")
+	if params, ok := RichFields[path]; ok {
+		return params
+	}
+	segments := strings.Split(path, "/")
+	for pattern, params := range RichFields {
+		if matchEndpointPattern(strings.Split(pattern, "/"), segments) {
+			return params
+		}
+	}
+	return nil
+}
+
+func matchEndpointPattern(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != segments[i] && (!strings.HasPrefix(p, ":") || segments[i] == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRichFields adds the RichFields parameters for rawURL's endpoint to a
+// GET request, skipping any parameter the URL already sets.
+func applyRichFields(method, rawURL string) (string, error) {
+	if method != "GET" {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	params := richParams(u.Path)
+	if params == nil {
+		return rawURL, nil
+	}
+	q := u.Query()
+	for key, value := range params {
+		if !q.Has(key) {
+			q.Set(key, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
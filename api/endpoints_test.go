@@ -1,9 +1,11 @@
 package api
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsStreamingEndpoint(t *testing.T) {
@@ -47,3 +49,50 @@ func TestIsStreamingEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyRichFields(t *testing.T) {
+	rich := func(method, rawURL string) url.Values {
+		t.Helper()
+		got, err := applyRichFields(method, rawURL)
+		require.NoError(t, err)
+		u, err := url.Parse(got)
+		require.NoError(t, err)
+		return u.Query()
+	}
+
+	q := rich("GET", "https://api.x.com/2/tweets/1234")
+	assert.Equal(t, richTweetFields, q.Get("tweet.fields"))
+	assert.Equal(t, "author_id", q.Get("expansions"))
+	assert.Equal(t, "username,name,verified", q.Get("user.fields"))
+
+	q = rich("GET", "https://api.x.com/2/tweets/search/recent?query=golang&tweet.fields=text")
+	assert.Equal(t, "golang", q.Get("query"))
+	assert.Equal(t, "text", q.Get("tweet.fields"), "parameters the URL sets are kept")
+	assert.Equal(t, "author_id", q.Get("expansions"))
+
+	q = rich("GET", "https://api.x.com/2/users/me")
+	assert.Equal(t, richUserFields, q.Get("user.fields"))
+	assert.False(t, q.Has("tweet.fields"))
+
+	q = rich("GET", "https://api.x.com/2/users/by/username/xdevelopers")
+	assert.Equal(t, richUserFields, q.Get("user.fields"))
+
+	for _, tc := range []struct{ method, url string }{
+		{"POST", "https://api.x.com/2/tweets"},
+		{"GET", "https://api.x.com/2/compliance/jobs?type=tweets"},
+		{"GET", "https://api.x.com/2/tweets/1234/extra/segments"},
+	} {
+		got, err := applyRichFields(tc.method, tc.url)
+		require.NoError(t, err)
+		assert.Equal(t, tc.url, got, "%s %s is unchanged", tc.method, tc.url)
+	}
+}
+
+func TestCatalogStreamingEndpoints(t *testing.T) {
+	for _, e := range Catalog() {
+		assert.Equal(t, e.Streaming, IsStreamingEndpoint(e.Path), e.Path)
+		assert.NotEmpty(t, e.Category, e.Path)
+		assert.NotEmpty(t, e.Summary, e.Path)
+	}
+	assert.True(t, IsStreamingEndpoint("https://api.x.com/2/tweets/firehose/stream/lang/ja?partition=1"))
+}
@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// exampleAuth returns an Auth backed by an in-memory token store holding an
+// app-only bearer token. Real programs normally use
+// auth.NewAuth(config.NewConfig()), which reads the same ~/.xurl store as
+// the CLI.
+func exampleAuth() *auth.Auth {
+	ts := &store.TokenStore{
+		Apps: map[string]*store.App{
+			"default": {
+				OAuth2Tokens: map[string]store.Token{},
+				BearerToken:  &store.Token{Type: store.BearerTokenType, Bearer: "AAAA-example"},
+			},
+		},
+		DefaultApp: "default",
+		FilePath:   os.DevNull,
+	}
+	return auth.NewAuth(&config.Config{}).WithTokenStore(ts)
+}
+
+func ExampleApiClient_SendRequest() {
+	// A stand-in for https://api.x.com.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":"2244994945","username":"XDevelopers"}}`)
+	}))
+	defer server.Close()
+
+	client := api.NewApiClient(&config.Config{APIBaseURL: server.URL}, exampleAuth())
+
+	resp, err := client.SendRequest(api.RequestOptions{
+		Method:   "GET",
+		Endpoint: "/2/users/by/username/XDevelopers",
+		AuthType: "app",
+	})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(string(resp))
+	// Output: {"data":{"id":"2244994945","username":"XDevelopers"}}
+}
+
+func ExampleApiClient_StreamRequest() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"data\":{\"text\":\"first\"}}\r\n\r\n{\"data\":{\"text\":\"second\"}}\r\n")
+	}))
+	defer server.Close()
+
+	client := api.NewApiClient(&config.Config{APIBaseURL: server.URL}, exampleAuth())
+
+	err := client.StreamRequest(api.RequestOptions{
+		Method:   "GET",
+		Endpoint: "/2/tweets/sample/stream",
+	}, api.StreamHandler{
+		OnConnect: func() { fmt.Println("connected") },
+		OnLine: func(line string) error {
+			fmt.Println("got:", line)
+			return nil
+		},
+	})
+	if err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// connected
+	// got: {"data":{"text":"first"}}
+	// got: {"data":{"text":"second"}}
+}
+
+func ExampleMediaUploader_OnProgress() {
+	client := api.NewApiClient(config.NewConfig(), auth.NewAuth(config.NewConfig()))
+
+	uploader, err := api.NewMediaUploader(client, "photo.jpg", false, false, "oauth2", "", nil)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	uploader.OnProgress(func(p api.MediaProgress) {
+		if p.Stage == api.MediaStageChunk {
+			fmt.Printf("uploaded %d/%d bytes\n", p.BytesUploaded, p.TotalBytes)
+		}
+	})
+
+	if err := uploader.Init("image/jpeg", "tweet_image"); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	if err := uploader.Append(); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	if _, err := uploader.Finalize(); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("media id:", uploader.GetMediaID())
+}
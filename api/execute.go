@@ -3,6 +3,19 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 	"github.com/xdevplatform/xurl/utils"
 )
 
@@ -11,48 +24,294 @@ func ExecuteRequest(options RequestOptions, client Client) error {
 
 	response, clientErr := client.SendRequest(options)
 	if clientErr != nil {
-		return handleRequestError(clientErr)
+		return handleRequestError(clientErr, options)
 	}
 
-	return utils.FormatAndPrintResponse(response)
+	return printResponse(options, response)
+}
+
+// printResponse prints response, or writes it unformatted to
+// options.OutputFile when set (see openOutputFile). A response without a
+// body leaves the file empty, or unchanged when appending. A response that
+// is not JSON, which the client only returns when it was asked for with an
+// Accept header, is printed verbatim. With
+// options.Verbose, the write is confirmed on stderr.
+func printResponse(options RequestOptions, response json.RawMessage) error {
+	if options.OutputFile == "" {
+		if !json.Valid(response) {
+			return options.formatter().PrintVerbatim(response)
+		}
+		return options.formatter().Print(response)
+	}
+	if IsNoContent(response) {
+		response = nil
+	}
+	if options.OutputAppend && len(response) > 0 && response[len(response)-1] != '\n' {
+		response = append(response[:len(response):len(response)], '\n')
+	}
+	file, err := openOutputFile(options)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(response); err != nil {
+		file.Close()
+		return xurlErrors.NewIOError(err)
+	}
+	if err := file.Close(); err != nil {
+		return xurlErrors.NewIOError(err)
+	}
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Wrote %d bytes to %s\n", len(response), options.OutputFile)
+	}
+	return nil
+}
+
+// openOutputFile opens options.OutputFile for writing, truncating it or,
+// with options.OutputAppend, appending to it, and creating it if missing.
+func openOutputFile(options RequestOptions) (*os.File, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if options.OutputAppend {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(options.OutputFile, flags, 0644)
+	if err != nil {
+		return nil, xurlErrors.NewIOError(err)
+	}
+	return file, nil
 }
 
-// ExecuteStreamRequest handles the execution of a streaming API request
+// ExecuteStreamRequest handles the execution of a streaming API request,
+// printing each line as it arrives. In jsonl output format the status lines
+// go to stderr, and when standard output is a file the records are written
+// through a utils.SyncWriter so a long capture survives a crash. With
+// options.OutputFile, the raw lines are written to that file the same way
+// and the status lines go to stderr.
 func ExecuteStreamRequest(options RequestOptions, client Client) error {
+	format := options.formatter()
+	status := os.Stdout
+	var capture *utils.SyncWriter
+	switch {
+	case options.OutputFile != "":
+		status = os.Stderr
+		file, err := openOutputFile(options)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		capture = utils.NewSyncWriter(file, format.SyncPolicy())
+		stop := syncOnInterrupt(capture)
+		defer stop()
+	case format.IsJSONL():
+		status = os.Stderr
+		if isRegularFile(os.Stdout) {
+			capture = utils.NewSyncWriter(os.Stdout, format.SyncPolicy())
+			stop := syncOnInterrupt(capture)
+			defer stop()
+		}
+	}
+	fmt.Fprintf(status, "\033[1;32mConnecting to streaming endpoint: %s\033[0m\n", options.Endpoint)
 
-	clientErr := client.StreamRequest(options)
+	items := 0
+	var bytesDone int64
+	clientErr := client.StreamRequest(options, StreamHandler{
+		OnConnect: func() {
+			fmt.Fprintln(status, "\033[1;32m--- Streaming response started ---\033[0m")
+			fmt.Fprintln(status, "\033[1;32m--- Press Ctrl+C to stop ---\033[0m")
+		},
+		OnLine: func(line string) error {
+			items++
+			bytesDone += int64(len(line))
+			defer reportProgress(options.Progress, ProgressEvent{Phase: ProgressPhaseStream, Items: items, BytesDone: bytesDone})
+			if options.OutputFile != "" {
+				if err := capture.WriteRecord([]byte(line)); err != nil {
+					return xurlErrors.NewIOError(err)
+				}
+				return nil
+			}
+
+			// We can't pretty-print streaming responses
+			line = format.RedactLine(line)
+			out, err := format.JQLine(line)
+			if err != nil {
+				// Like jq, report the message that failed and go on with the next.
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+			}
+			for _, l := range out {
+				if capture != nil {
+					if err := capture.WriteRecord([]byte(l)); err != nil {
+						return xurlErrors.NewIOError(err)
+					}
+				} else {
+					fmt.Println(l)
+				}
+			}
+			return nil
+		},
+	})
+	if capture != nil {
+		if err := capture.Close(); err != nil && clientErr == nil {
+			clientErr = xurlErrors.NewIOError(err)
+		}
+	}
 	if clientErr != nil {
-		return handleRequestError(clientErr)
+		return handleRequestError(clientErr, options)
 	}
 
+	fmt.Fprintln(status, "\033[1;32m--- End of stream ---\033[0m")
+	if options.OutputFile != "" && options.Verbose {
+		fmt.Fprintf(os.Stderr, "Wrote %d lines to %s\n", items, options.OutputFile)
+	}
 	return nil
 }
 
+// isRegularFile reports whether f is a regular file rather than a terminal
+// or pipe, which cannot be synced.
+func isRegularFile(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// syncOnInterrupt syncs capture before xurl exits on Ctrl+C or SIGTERM, the
+// usual end of a stream capture. The returned function stops watching.
+func syncOnInterrupt(capture *utils.SyncWriter) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sig:
+			capture.Close()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// ExecuteTimelineRequest runs a timeline GET and prints the response. With
+// backfill set it walks the timeline backwards through up to maxPages pages
+// (see Backfill). It returns the newest post ID in what was printed, or ""
+// when there were no posts.
+func ExecuteTimelineRequest(options RequestOptions, client Client, backfill bool, maxPages int) (string, error) {
+	var response json.RawMessage
+	var clientErr error
+	if options.DryRun {
+		backfill = false
+	}
+	if backfill && options.formatter().IsNDJSON() {
+		var newest string
+		err := StreamToNDJSON(options.formatter(), func(items chan<- json.RawMessage) (err error) {
+			newest, err = StreamBackfill(client, options, maxPages, items)
+			return err
+		})
+		if err != nil {
+			return "", handleRequestError(err, options)
+		}
+		return newest, nil
+	}
+	if backfill {
+		response, clientErr = Backfill(client, options, maxPages)
+	} else {
+		response, clientErr = client.SendRequest(options)
+	}
+	if clientErr != nil {
+		return "", handleRequestError(clientErr, options)
+	}
+
+	return NewestID(response), options.formatter().Print(response)
+}
+
+// StreamToNDJSON runs stream, which must send items and then close the
+// channel, while format prints each item as an NDJSON line as it arrives. It
+// returns stream's error, or else any error printing.
+func StreamToNDJSON(format *utils.Formatter, stream func(items chan<- json.RawMessage) error) error {
+	items := make(chan json.RawMessage)
+	printed := make(chan error, 1)
+	go func() { printed <- format.PrintNDJSONItems(items) }()
+	err := stream(items)
+	printErr := <-printed
+	if err != nil {
+		return err
+	}
+	return printErr
+}
+
 // handleRequestError processes API client errors in a consistent way. When the
-// error carries a JSON body (an API error response) it is pretty-printed and a
-// generic failure is returned; otherwise the original error (e.g. a network or
-// auth failure) is returned unchanged so its real message reaches the user.
-func handleRequestError(clientErr error) error {
+// error carries a JSON body (an API error response) it is pretty-printed to
+// stderr, so it never reaches a pipeline expecting data, unless
+// options.HideErrorBody is set, and a generic failure is returned; otherwise the
+// original error (e.g. a network or auth failure) is returned unchanged so its
+// real message reaches the user. Rate-limit errors always report how long
+// until the limit resets, and OAuth1 timestamp rejections, or OAuth1 401s
+// while the clock is visibly off, hint at clock skew.
+func handleRequestError(clientErr error, options RequestOptions) error {
 	var rawJSON json.RawMessage
-	if json.Unmarshal([]byte(clientErr.Error()), &rawJSON) == nil {
-		utils.FormatAndPrintResponse(rawJSON)
+	isJSON := json.Unmarshal([]byte(clientErr.Error()), &rawJSON) == nil
+	if isJSON && !options.HideErrorBody {
+		options.formatter().PrintTo(color.Error, rawJSON)
+	}
+	if xurlErrors.IsRateLimitError(clientErr) {
+		return fmt.Errorf("%s", xurlErrors.DescribeRateLimit(clientErr, time.Now()))
+	}
+	if xurlErrors.IsTimestampError(clientErr) {
+		return fmt.Errorf("%s", xurlErrors.DescribeClockSkew(clientErr, time.Now()))
+	}
+	if hint := xurlErrors.OAuth1ClockSkewHint(clientErr, time.Now()); hint != "" {
+		if isJSON {
+			return fmt.Errorf("request failed: %s", hint)
+		}
+		return fmt.Errorf("%v: %s", clientErr, hint)
+	}
+	if isJSON {
 		return fmt.Errorf("request failed")
 	}
 	return clientErr
 }
 
-// HandleRequest determines the type of request and executes it accordingly
+// HandleRequest determines the type of request and executes it accordingly.
+// options.Data is sent as it is; a -d "@FILE" is resolved by the caller (see
+// ReadDataArg).
 func HandleRequest(options RequestOptions, forceStream bool, mediaFile string, client Client) error {
+	body, err := BuildRequestBody(options.Data, options.Fields)
+	if err != nil {
+		return err
+	}
+	options.Data = body
+	options.Fields = nil
+
+	if options.PrintBody {
+		fmt.Fprintln(color.Output, body)
+		return nil
+	}
+
+	if options.ValidateOnly {
+		if options.BodyReader != nil {
+			data, err := io.ReadAll(options.BodyReader)
+			if err != nil {
+				return err
+			}
+			body = string(data)
+		}
+		if err := ValidateJSONBody(body); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "\033[32mRequest body is valid JSON (%d bytes)\033[0m\n", len(body))
+		return nil
+	}
+
 	if IsMediaAppendRequest(options.Endpoint, mediaFile) {
 		response, err := HandleMediaAppendRequest(options, mediaFile, client)
 		if err != nil {
 			return err
 		}
 
-		return utils.FormatAndPrintResponse(response)
+		return printResponse(options, response)
 	}
 
-	shouldStream := forceStream || IsStreamingEndpoint(options.Endpoint)
+	shouldStream := forceStream || options.StreamJSONArray || IsStreamingEndpoint(options.Endpoint)
 
 	if shouldStream {
 		return ExecuteStreamRequest(options, client)
@@ -60,3 +319,227 @@ func HandleRequest(options RequestOptions, forceStream bool, mediaFile string, c
 		return ExecuteRequest(options, client)
 	}
 }
+
+// MediaUploadOptions are the options of ExecuteMediaUpload.
+type MediaUploadOptions struct {
+	FilePath string
+	// MediaType and MediaCategory are detected from the file when empty.
+	MediaType     string
+	MediaCategory string
+	// PreUploadCmd, when set, is run first (see RunPreUploadCommand) and its
+	// output is uploaded in place of FilePath.
+	PreUploadCmd string
+	// SidecarSuffix, when set, names the sidecar next to FilePath (see
+	// ReadMediaSidecar) whose alt text is set on the media once it is
+	// uploaded; a sidecar that is missing or cannot be applied is a warning
+	// on stderr, not an error.
+	SidecarSuffix string
+	AuthType      string
+	Username      string
+	Verbose       bool
+	Trace         bool
+	Headers       []string
+	// WaitForProcessing waits for videos and GIFs to be processed, within
+	// the bounds of Processing.
+	WaitForProcessing bool
+	Processing        ProcessingWait
+	// Parallel, when set, uploads the chunks with AppendParallel.
+	Parallel *ParallelUpload
+	// Progress, when set, receives the upload's progress in place of the
+	// human lines printed with Verbose.
+	Progress ProgressReporter
+	// Format prints the API's responses; nil prints them as
+	// utils.NewFormatter does.
+	Format *utils.Formatter
+}
+
+// ExecuteMediaUpload handles the media upload command execution.
+func ExecuteMediaUpload(options MediaUploadOptions, client Client) error {
+	filePath := options.FilePath
+	if options.PreUploadCmd != "" {
+		outPath, cleanup, err := RunPreUploadCommand(options.PreUploadCmd, filePath, os.Stderr)
+		if err != nil {
+			return fmt.Errorf("error running pre-upload command: %v", err)
+		}
+		defer cleanup()
+		filePath = outPath
+	}
+
+	uploader, err := NewMediaUploader(client, filePath, options.Verbose, options.Trace, options.AuthType, options.Username, options.Headers)
+	if err != nil {
+		return fmt.Errorf("error: %v", err)
+	}
+	format := options.Format
+	if format == nil {
+		format = utils.NewFormatter()
+	}
+	uploader.OnProgress(printMediaProgress(options.Verbose, options.Progress, format)).SetProcessingWait(options.Processing)
+
+	// Fill in sensible defaults from the file itself when not specified. If the
+	// type can't be detected, or it is a recognized-but-unsupported type, fail
+	// clearly rather than guessing and letting the API reject the upload with an
+	// opaque server error.
+	mediaType, mediaCategory := options.MediaType, options.MediaCategory
+	if mediaType == "" {
+		detected := DetectMediaType(filePath)
+		if detected == "application/octet-stream" {
+			return fmt.Errorf("could not detect media type for %q; pass --media-type (and --category)", filePath)
+		}
+		mediaType = detected
+	}
+	if mediaCategory == "" {
+		category, ok := DefaultMediaCategory(mediaType)
+		if !ok {
+			return fmt.Errorf("unsupported media type %q; pass --category to override", mediaType)
+		}
+		mediaCategory = category
+	}
+
+	if err := uploader.Init(mediaType, mediaCategory); err != nil {
+		return fmt.Errorf("error initializing upload: %v", err)
+	}
+
+	if options.Parallel != nil {
+		err = uploader.AppendParallel(options.Parallel.Workers, options.Parallel.MaxMemory)
+	} else {
+		err = uploader.Append()
+	}
+	if err != nil {
+		return fmt.Errorf("error uploading media: %v", err)
+	}
+
+	finalizeResponse, err := uploader.Finalize()
+	if err != nil {
+		return fmt.Errorf("error finalizing upload: %v", err)
+	}
+
+	format.Print(finalizeResponse)
+
+	// Wait for processing if requested (videos and GIFs are processed async)
+	if options.WaitForProcessing && mediaNeedsProcessing(mediaCategory) {
+		processingResponse, err := uploader.WaitForProcessing()
+		if err != nil {
+			return fmt.Errorf("error during media processing: %v", err)
+		}
+
+		format.Print(processingResponse)
+	}
+
+	fmt.Printf("\033[32mMedia uploaded successfully! Media ID: %s\033[0m\n", uploader.GetMediaID())
+	if options.SidecarSuffix != "" {
+		applied, warning := applyMediaSidecar(uploader, options.FilePath, options.SidecarSuffix)
+		if warning != nil {
+			fmt.Fprintf(os.Stderr, "\033[33mWarning: %v\033[0m\n", warning)
+		} else {
+			fmt.Printf("Applied %s to media %s\n", applied, uploader.GetMediaID())
+		}
+	}
+	return nil
+}
+
+// RunPreUploadCommand runs a user-supplied transcoder on inPath before upload.
+// {in} and {out} in command are replaced by the shell-quoted input path and a
+// temporary output path with the input's extension, and the result is run by
+// the system shell with its output sent to w. It returns the output path and
+// a cleanup func that removes it; the command must create a non-empty file
+// there.
+func RunPreUploadCommand(command, inPath string, w io.Writer) (outPath string, cleanup func(), err error) {
+	if !strings.Contains(command, "{out}") {
+		return "", nil, fmt.Errorf("the command must write to {out}")
+	}
+	if _, err := os.Stat(inPath); err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "xurl-media-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	outPath = filepath.Join(dir, "media"+filepath.Ext(inPath))
+
+	line := strings.NewReplacer("{in}", shellQuote(inPath), "{out}", shellQuote(outPath)).Replace(command)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", line)
+	} else {
+		cmd = exec.Command("sh", "-c", line)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%s: %v", line, err)
+	}
+
+	if info, err := os.Stat(outPath); err != nil || info.Size() == 0 {
+		cleanup()
+		return "", nil, fmt.Errorf("%s: no output was written to {out}", line)
+	}
+	return outPath, cleanup, nil
+}
+
+// shellQuote quotes s as a single argument for the shell RunPreUploadCommand
+// uses.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ExecuteMediaStatus handles the media status command execution. Of options,
+// the auth, verbose, trace, headers, progress and format settings are used.
+func ExecuteMediaStatus(mediaID string, options RequestOptions, wait bool, processing ProcessingWait, client Client) error {
+	uploader := NewMediaUploaderWithoutFile(client, options.Verbose, options.Trace, options.AuthType, options.Username, options.Headers)
+	uploader.OnProgress(printMediaProgress(options.Verbose, options.Progress, options.formatter())).SetProcessingWait(processing)
+
+	uploader.SetMediaID(mediaID)
+
+	if wait {
+		processingResponse, err := uploader.WaitForProcessing()
+		if err != nil {
+			return fmt.Errorf("error during media processing: %v", err)
+		}
+
+		prettyJSON, err := json.MarshalIndent(processingResponse, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(prettyJSON))
+	} else {
+		statusResponse, err := uploader.CheckStatus()
+		if err != nil {
+			return fmt.Errorf("error checking status: %v", err)
+		}
+
+		prettyJSON, err := json.MarshalIndent(statusResponse, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(prettyJSON))
+	}
+
+	return nil
+}
+
+// printMediaProgress returns a MediaUploader progress callback. Its events go
+// to progress (--progress json) or, when progress is nil and verbose is set,
+// are printed as human progress lines along with the API's responses, which
+// format prints.
+func printMediaProgress(verbose bool, progress ProgressReporter, format *utils.Formatter) func(MediaProgress) {
+	reporter := progress
+	if reporter == nil && verbose {
+		reporter = NewTextProgressReporter(color.Output)
+	}
+	segment := 0
+	return func(p MediaProgress) {
+		if p.Stage == MediaStageChunk {
+			segment++
+		}
+		if verbose && progress == nil && (p.Stage == MediaStageInitialized || p.Stage == MediaStageStatus) {
+			format.Print(p.Response)
+		}
+		reportProgress(reporter, mediaProgressEvent(p, segment))
+	}
+}
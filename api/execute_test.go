@@ -2,16 +2,25 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/xdevplatform/xurl/config"
 	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/utils"
 )
 
 // redirectColor sends colorized output (used by FormatAndPrintResponse) to w and
@@ -27,13 +36,21 @@ func redirectColor(w io.Writer) func() {
 	}
 }
 
+// redirectColorError sends colorized stderr output (color.Error, used for
+// API error bodies) to w, returning a restore func.
+func redirectColorError(w io.Writer) func() {
+	oldErr := color.Error
+	color.Error = w
+	return func() { color.Error = oldErr }
+}
+
 func TestHandleRequestError(t *testing.T) {
 	t.Run("non-JSON error is returned unchanged and prints nothing", func(t *testing.T) {
 		var buf bytes.Buffer
 		defer redirectColor(&buf)()
 
 		origErr := fmt.Errorf("dial tcp 127.0.0.1:9: connect: connection refused")
-		got := handleRequestError(origErr)
+		got := handleRequestError(origErr, RequestOptions{})
 
 		require.Error(t, got)
 		assert.Contains(t, got.Error(), "connection refused", "real error message must be preserved")
@@ -41,15 +58,454 @@ func TestHandleRequestError(t *testing.T) {
 		assert.NotContains(t, buf.String(), "null", "the old null-printing regression must not return")
 	})
 
-	t.Run("JSON API error body is printed and request-failed is returned", func(t *testing.T) {
+	t.Run("JSON API error body is printed to stderr and request-failed is returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		defer redirectColor(&stdout)()
+		defer redirectColorError(&stderr)()
+
+		apiErr := xurlErrors.NewAPIError([]byte(`{"errors":[{"message":"bad request"}]}`))
+		got := handleRequestError(apiErr, RequestOptions{})
+
+		require.Error(t, got)
+		assert.Equal(t, "request failed", got.Error())
+		assert.Contains(t, stderr.String(), "bad request", "the JSON error body should be printed")
+		assert.Empty(t, stdout.String(), "an error body must not reach stdout, where a pipeline expects data")
+	})
+
+	t.Run("Rate-limit error prints the body and reports time until reset", func(t *testing.T) {
+		var buf bytes.Buffer
+		defer redirectColorError(&buf)()
+
+		rlErr := xurlErrors.NewRateLimitError(`{"title":"Too Many Requests"}`, time.Now().Add(2*time.Minute))
+		got := handleRequestError(rlErr, RequestOptions{})
+
+		require.Error(t, got)
+		assert.Contains(t, got.Error(), "resets in")
+		assert.Contains(t, buf.String(), "Too Many Requests")
+	})
+
+	t.Run("hidden JSON error body is not printed but the request still fails", func(t *testing.T) {
 		var buf bytes.Buffer
 		defer redirectColor(&buf)()
+		defer redirectColorError(&buf)()
 
 		apiErr := xurlErrors.NewAPIError([]byte(`{"errors":[{"message":"bad request"}]}`))
-		got := handleRequestError(apiErr)
+		got := handleRequestError(apiErr, RequestOptions{HideErrorBody: true})
 
 		require.Error(t, got)
 		assert.Equal(t, "request failed", got.Error())
-		assert.Contains(t, buf.String(), "bad request", "the JSON error body should be printed")
+		assert.Empty(t, strings.TrimSpace(buf.String()))
+	})
+}
+
+func TestHandleRequestErrorClockSkew(t *testing.T) {
+	// The server's clock is ten minutes behind ours, so we are ahead of it.
+	serverTime := time.Now().Add(-10 * time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"code":135,"message":"Timestamp out of bounds."}]}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	var buf bytes.Buffer
+	defer redirectColorError(&buf)()
+
+	_, clientErr := client.SendRequest(RequestOptions{
+		Method:   "GET",
+		Endpoint: "/2/users/me",
+		Headers:  []string{"Authorization: Bearer test-token"},
+	})
+	require.Error(t, clientErr)
+	assert.True(t, xurlErrors.IsTimestampError(clientErr))
+
+	got := handleRequestError(clientErr, RequestOptions{})
+	require.Error(t, got)
+	assert.Contains(t, got.Error(), "check your system time")
+	assert.Contains(t, got.Error(), "ahead of the server")
+	// Date has one-second resolution, so the estimate may be a second over.
+	assert.Regexp(t, `10m[01]s ahead`, got.Error(), "the skew should be estimated from the Date header")
+	assert.Contains(t, buf.String(), "Timestamp out of bounds", "the error body is still printed")
+
+	t.Run("other 401s get no clock hint", func(t *testing.T) {
+		apiErr := xurlErrors.NewAPIError([]byte(`{"title":"Unauthorized","status":401}`))
+		apiErr.StatusCode = http.StatusUnauthorized
+		assert.False(t, xurlErrors.IsTimestampError(apiErr))
+		assert.Equal(t, "request failed", handleRequestError(apiErr, RequestOptions{HideErrorBody: true}).Error())
+	})
+
+	t.Run("missing Date header", func(t *testing.T) {
+		apiErr := xurlErrors.NewAPIError([]byte(`{"errors":[{"code":135,"message":"Timestamp out of bounds."}]}`))
+		apiErr.StatusCode = http.StatusUnauthorized
+		got := handleRequestError(apiErr, RequestOptions{HideErrorBody: true})
+		assert.Contains(t, got.Error(), "no Date header")
 	})
 }
+
+// TestHandleRequestErrorOAuth1Skew checks that a generic 401 to an
+// OAuth1-signed request explains itself when the Date header shows the local
+// clock is off, and only then.
+func TestHandleRequestErrorOAuth1Skew(t *testing.T) {
+	var serverTime time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"title":"Unauthorized","status":401,"detail":"Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	send := func(authorization string) error {
+		_, err := client.SendRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/users/me",
+			Headers:  []string{"Authorization: " + authorization},
+		})
+		require.Error(t, err)
+		return handleRequestError(err, RequestOptions{HideErrorBody: true})
+	}
+
+	// The server is 7m12s ahead of us, so our clock is behind.
+	serverTime = time.Now().Add(7*time.Minute + 12*time.Second)
+	got := send(`OAuth oauth_consumer_key="key"`)
+	assert.Regexp(t, `your system clock is 7m1[123]s behind server time — OAuth1 signatures will fail until it's corrected`, got.Error())
+
+	assert.Equal(t, "request failed", send("Bearer token").Error(), "only OAuth1 signatures embed a timestamp")
+
+	serverTime = time.Now().Add(-2 * time.Minute)
+	assert.Equal(t, "request failed", send(`OAuth oauth_consumer_key="key"`).Error(), "small skews are within bounds")
+}
+
+func TestOAuth1ClockSkewHint(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	newErr := func(status int, date time.Time, oauth1 bool) error {
+		e := xurlErrors.NewAPIError([]byte(`{"title":"Unauthorized"}`))
+		e.StatusCode, e.ServerDate, e.OAuth1 = status, date, oauth1
+		return e
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"behind", newErr(401, now.Add(7*time.Minute+12*time.Second), true), "your system clock is 7m12s behind server time — OAuth1 signatures will fail until it's corrected"},
+		{"ahead", newErr(401, now.Add(-time.Hour), true), "your system clock is 1h0m0s ahead of server time — OAuth1 signatures will fail until it's corrected"},
+		{"at the limit", newErr(401, now.Add(xurlErrors.MaxClockSkew), true), ""},
+		{"no Date header", newErr(401, time.Time{}, true), ""},
+		{"not OAuth1", newErr(401, now.Add(time.Hour), false), ""},
+		{"not a 401", newErr(403, now.Add(time.Hour), true), ""},
+		{"not an API error", fmt.Errorf("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, xurlErrors.OAuth1ClockSkewHint(tt.err, now))
+		})
+	}
+}
+
+func TestBuildRequestBody(t *testing.T) {
+	body, err := BuildRequestBody("", []string{
+		"text=hello world",
+		"reply_settings=following",
+		"for_super_followers_only:=false",
+		"poll:={\"options\":[\"a\",\"b\"],\"duration_minutes\":60}",
+		"text=hello again",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"text": "hello again",
+		"reply_settings": "following",
+		"for_super_followers_only": false,
+		"poll": {"options": ["a", "b"], "duration_minutes": 60}
+	}`, body)
+
+	body, err = BuildRequestBody(`{"text":"raw"}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"raw"}`, body, "-d data passes through untouched")
+
+	for _, bad := range [][]string{{"novalue"}, {"=x"}, {"n:=not json"}} {
+		_, err := BuildRequestBody("", bad)
+		assert.Error(t, err, "%v", bad)
+	}
+	_, err = BuildRequestBody(`{"a":1}`, []string{"b=2"})
+	assert.Error(t, err, "fields and -d are mutually exclusive")
+}
+
+func TestReadDataArg(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\"add\": [{\"value\": \"cat has:media\"}]}\n"), 0644))
+
+	data, err := ReadDataArg("@"+path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"add": [{"value": "cat has:media"}]}`, data, "the trailing newline is dropped")
+
+	data, err = ReadDataArg("@-", strings.NewReader("status=hi&lang=en\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "status=hi&lang=en", data)
+
+	for _, literal := range []string{`{"text":"hi"}`, "@", "", "a=@b"} {
+		data, err = ReadDataArg(literal, nil)
+		require.NoError(t, err)
+		assert.Equal(t, literal, data)
+	}
+
+	_, err = ReadDataArg("@"+filepath.Join(dir, "missing.json"), nil)
+	assert.ErrorContains(t, err, "could not read -d @")
+	assert.ErrorContains(t, err, "missing.json")
+}
+
+// TestHandleRequestDataFromFile checks that a -d @FILE resolved with
+// ReadDataArg sends the file's contents, with the content type detected from
+// them, and that HandleRequest itself sends a body starting with "@" as it
+// is.
+func TestHandleRequestDataFromFile(t *testing.T) {
+	var gotType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name, contents, wantType, wantBody string
+	}{
+		{"json", "{\n  \"text\": \"a long post\"\n}\n", "application/json", "{\n  \"text\": \"a long post\"\n}"},
+		{"form", "name=xurl&description=a+list\n", "application/x-www-form-urlencoded", "name=xurl&description=a+list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			require.NoError(t, os.WriteFile(path, []byte(tt.contents), 0644))
+			data, err := ReadDataArg("@"+path, nil)
+			require.NoError(t, err)
+			require.NoError(t, HandleRequest(RequestOptions{Method: "POST", Endpoint: "/2/tweets", Data: data}, false, "", client))
+			assert.Equal(t, tt.wantType, gotType)
+			assert.Equal(t, tt.wantBody, gotBody)
+		})
+	}
+
+	t.Run("body starting with @", func(t *testing.T) {
+		secret := filepath.Join(dir, "secret")
+		require.NoError(t, os.WriteFile(secret, []byte("do not send"), 0644))
+		require.NoError(t, HandleRequest(RequestOptions{Method: "POST", Endpoint: "/2/tweets", Data: "@" + secret}, false, "", client))
+		assert.Equal(t, "@"+secret, gotBody, "sent as it is, not read from the file")
+	})
+}
+
+func TestValidateJSONBody(t *testing.T) {
+	assert.NoError(t, ValidateJSONBody(`{"text":"hi"}`))
+	assert.NoError(t, ValidateJSONBody("[1, 2]\n"))
+	assert.ErrorContains(t, ValidateJSONBody("  "), "no request body")
+
+	tests := []struct {
+		name         string
+		body         string
+		line, column int
+	}{
+		{"missing value", "{\n  \"text\": \"hi\",\n  \"reply\": }\n", 3, 12},
+		{"trailing comma", `{"a": 1,}`, 1, 9},
+		{"truncated", "{\n  \"poll\": {\"options\": [\"yes\"\n", 2, 28},
+		{"trailing data", "{\"a\":1}\n{\"b\":2}", 2, 1},
+		{"single quotes", "{'a': 1}", 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSONBody(tt.body)
+			var syntaxErr *BodySyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.line, syntaxErr.Line, "line")
+			assert.Equal(t, tt.column, syntaxErr.Column, "column")
+			assert.Contains(t, err.Error(), fmt.Sprintf("line %d, column %d", tt.line, tt.column))
+		})
+	}
+}
+
+func TestHandleRequestValidateOnlyDoesNotSend(t *testing.T) {
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+
+	client := new(MockApiClient)
+	err := HandleRequest(RequestOptions{
+		Method:       "POST",
+		Endpoint:     "/2/tweets",
+		Data:         "{\"text\": \"hi\"\n\"reply\": {}}",
+		ValidateOnly: true,
+	}, false, "", client)
+	assert.EqualError(t, err, "invalid JSON body at line 2, column 1: invalid character '\"' after object key:value pair")
+
+	err = HandleRequest(RequestOptions{
+		Method:       "POST",
+		Endpoint:     "/2/tweets",
+		Fields:       []string{"text=hi"},
+		ValidateOnly: true,
+	}, false, "", client)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Request body is valid JSON (13 bytes)")
+
+	err = HandleRequest(RequestOptions{
+		Method:       "POST",
+		Endpoint:     "/2/tweets",
+		BodyReader:   strings.NewReader(`{"text":`),
+		ValidateOnly: true,
+	}, false, "", client)
+	assert.ErrorContains(t, err, "line 1, column 8: unexpected end of JSON input")
+	client.AssertNotCalled(t, "SendRequest", mock.Anything)
+}
+
+func TestHandleRequestPrintBodyDoesNotSend(t *testing.T) {
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+
+	client := new(MockApiClient)
+	err := HandleRequest(RequestOptions{
+		Method:    "POST",
+		Endpoint:  "/2/tweets",
+		Fields:    []string{"text=hi", "nullcast:=true"},
+		PrintBody: true,
+	}, false, "", client)
+
+	require.NoError(t, err)
+	assert.Equal(t, "{\"nullcast\":true,\"text\":\"hi\"}\n", buf.String())
+	client.AssertNotCalled(t, "SendRequest", mock.Anything)
+}
+
+func TestHandleRequestOutputFile(t *testing.T) {
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+	path := filepath.Join(t.TempDir(), "result.json")
+	require.NoError(t, os.WriteFile(path, []byte("an older, longer result"), 0644))
+
+	body := json.RawMessage(`{"data":{"id":"1","name":"X"}}`)
+	opts := RequestOptions{Method: "GET", Endpoint: "/2/users/me", OutputFile: path}
+	client := new(MockApiClient)
+	client.On("SendRequest", opts).Return(body, nil)
+
+	require.NoError(t, HandleRequest(opts, false, "", client))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(body), string(written), "the raw body replaces the file")
+	assert.Empty(t, buf.String(), "nothing is printed")
+
+	opts.OutputFile = filepath.Join(t.TempDir(), "missing", "result.json")
+	client.On("SendRequest", opts).Return(body, nil)
+	err = HandleRequest(opts, false, "", client)
+	require.Error(t, err)
+	assert.True(t, xurlErrors.IsIOError(err), "got %v", err)
+}
+
+func TestHandleRequestOutputAppend(t *testing.T) {
+	var buf bytes.Buffer
+	defer redirectColor(&buf)()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+
+	opts := RequestOptions{Method: "GET", Endpoint: "/2/users/me", OutputFile: path, OutputAppend: true}
+	client := new(MockApiClient)
+	client.On("SendRequest", opts).Return(json.RawMessage(`{"data":{"id":"1"}}`), nil).Once()
+	client.On("SendRequest", opts).Return(json.RawMessage(`{"data":{"id":"2"}}`), nil).Once()
+
+	require.NoError(t, HandleRequest(opts, false, "", client))
+	require.NoError(t, HandleRequest(opts, false, "", client))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n{\"data\":{\"id\":\"2\"}}\n", string(written), "the second run appends")
+}
+
+// lineStreamer is a Client whose streams deliver lines.
+type lineStreamer struct {
+	MockApiClient
+	lines []string
+}
+
+func (s *lineStreamer) StreamRequest(options RequestOptions, handler StreamHandler) error {
+	if handler.OnConnect != nil {
+		handler.OnConnect()
+	}
+	for _, line := range s.lines {
+		if err := handler.OnLine(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExecuteStreamRequestJSONL(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "capture.jsonl"))
+	require.NoError(t, err)
+	defer out.Close()
+	status, err := os.Create(filepath.Join(dir, "status.txt"))
+	require.NoError(t, err)
+	defer status.Close()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = out, status
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+	format := utils.NewFormatter()
+	require.NoError(t, format.SetOutputFormat(utils.OutputJSONL))
+
+	client := &lineStreamer{lines: []string{`{"data":{"id":"1"}}`, `{"data":{"id":"2"}}`}}
+	require.NoError(t, ExecuteStreamRequest(RequestOptions{Endpoint: "/2/tweets/search/stream", Format: format}, client))
+
+	captured, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n{\"data\":{\"id\":\"2\"}}\n", string(captured), "only records reach the capture")
+	banners, err := os.ReadFile(status.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(banners), "Streaming response started")
+}
+
+func TestExecuteStreamRequestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout.txt"))
+	require.NoError(t, err)
+	defer stdout.Close()
+	status, err := os.Create(filepath.Join(dir, "status.txt"))
+	require.NoError(t, err)
+	defer status.Close()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, status
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+
+	path := filepath.Join(dir, "stream.ndjson")
+	client := &lineStreamer{lines: []string{`{"data":{"id":"1"}}`, `{"data":{"id":"2"}}`}}
+	rec := &recordingReporter{}
+	require.NoError(t, ExecuteStreamRequest(RequestOptions{Endpoint: "/2/tweets/search/stream", OutputFile: path, Progress: rec}, client))
+	require.Len(t, rec.events, 2, "each line is reported to the request's Progress")
+	assert.Equal(t, ProgressEvent{Phase: "stream", Items: 2, BytesDone: 38}, rec.events[1])
+
+	captured, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n{\"data\":{\"id\":\"2\"}}\n", string(captured))
+	printed, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Empty(t, string(printed), "nothing on stdout")
+	banners, err := os.ReadFile(status.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(banners), "End of stream")
+
+	require.NoError(t, ExecuteStreamRequest(RequestOptions{Endpoint: "/2/tweets/search/stream", OutputFile: path, OutputAppend: true}, client))
+	captured, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("{\"data\":{\"id\":\"1\"}}\n{\"data\":{\"id\":\"2\"}}\n", 2), string(captured), "--output-append keeps the first capture")
+}
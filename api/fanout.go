@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// FanOut runs the n sub-requests of one command, such as the batches of a
+// lookup, with at most concurrency of them in flight, and returns their
+// results in index order however they complete. The first sub-request runs
+// alone, so a token refresh or a dry run happens once before the others
+// start.
+//
+// The sub-requests share one pacer: after a rate limit, no sub-request
+// starts until the limit resets, as pause.RetryAfter allows, and the limited
+// one is then sent again. Without pause, a rate limit is an error like any
+// other. The first error stops new sub-requests from starting and is
+// returned once those in flight have finished.
+func FanOut(n, concurrency int, pause *RateLimitPause, do func(i int) (json.RawMessage, error)) ([]json.RawMessage, error) {
+	results := make([]json.RawMessage, n)
+	if n == 0 {
+		return results, nil
+	}
+	p := &fanOutPacer{pause: pause}
+	p.cond = sync.NewCond(&p.mu)
+
+	var err error
+	if results[0], err = p.run(0, do); err != nil || n == 1 || IsDryRun(results[0]) {
+		return results, err
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(concurrency, 1), n-1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				resp, err := p.run(i, do)
+				results[i] = resp
+				if err != nil {
+					p.fail(err)
+				}
+			}
+		}()
+	}
+	for i := 1; i < n && p.err() == nil; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, p.err()
+}
+
+// fanOutPacer holds back the sub-requests of FanOut while a rate limit is
+// being waited out, and records the first failure.
+type fanOutPacer struct {
+	pause   *RateLimitPause
+	mu      sync.Mutex
+	cond    *sync.Cond
+	waiting bool
+	// limited counts the rate limits in a row, for the backoff of
+	// RetryAfter.
+	limited  int
+	firstErr error
+}
+
+// run sends sub-request i, waiting out rate limits when there is a pause.
+func (p *fanOutPacer) run(i int, do func(i int) (json.RawMessage, error)) (json.RawMessage, error) {
+	for {
+		p.mu.Lock()
+		for p.waiting {
+			p.cond.Wait()
+		}
+		p.mu.Unlock()
+
+		resp, err := do(i)
+		if err == nil || p.pause == nil || !xurlErrors.IsRateLimitError(err) {
+			if err == nil {
+				p.mu.Lock()
+				p.limited = 0
+				p.mu.Unlock()
+			}
+			return resp, err
+		}
+
+		p.mu.Lock()
+		if p.waiting {
+			// Another sub-request is already waiting out this limit.
+			p.mu.Unlock()
+			continue
+		}
+		p.waiting = true
+		attempt := p.limited
+		p.limited++
+		p.mu.Unlock()
+
+		p.pause.wait(err, attempt)
+
+		p.mu.Lock()
+		p.waiting = false
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+func (p *fanOutPacer) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.firstErr == nil {
+		p.firstErr = err
+	}
+}
+
+func (p *fanOutPacer) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.firstErr
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOutBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	var inFlight, most atomic.Int32
+	results, err := FanOut(20, 4, nil, func(i int) (json.RawMessage, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// Later sub-requests finish first.
+		time.Sleep(time.Duration(20-i) * time.Millisecond)
+		return json.RawMessage(strconv.Itoa(i)), nil
+	})
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, most.Load(), int32(4))
+	assert.Greater(t, most.Load(), int32(1))
+	for i, r := range results {
+		assert.Equal(t, strconv.Itoa(i), string(r))
+	}
+}
+
+func TestFanOutStopsAtFirstError(t *testing.T) {
+	var started atomic.Int32
+	_, err := FanOut(100, 2, nil, func(i int) (json.RawMessage, error) {
+		started.Add(1)
+		if i == 3 {
+			return nil, errors.New("boom")
+		}
+		time.Sleep(time.Millisecond)
+		return json.RawMessage(`{}`), nil
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Less(t, started.Load(), int32(10), "no new sub-requests after the failure")
+}
+
+func TestLookupUsersConcurrentlyWaitsOutRateLimit(t *testing.T) {
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	var slept time.Duration
+	var mu sync.Mutex
+	sleep = func(d time.Duration) {
+		mu.Lock()
+		slept += d
+		mu.Unlock()
+	}
+
+	var limitedOnce sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := strings.Split(r.URL.Query().Get("usernames"), ",")
+		if names[0] == "user200" {
+			limited := false
+			limitedOnce.Do(func() { limited = true })
+			if limited {
+				w.Header().Set("x-rate-limit-remaining", "0")
+				w.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(2*time.Second).Unix(), 10))
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"title":"Too Many Requests"}`)
+				return
+			}
+		}
+		var data []map[string]string
+		for _, name := range names {
+			data = append(data, map[string]string{"id": strings.TrimPrefix(name, "user"), "username": name})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	var usernames []string
+	for i := 0; i < 250; i++ {
+		usernames = append(usernames, fmt.Sprintf("user%d", i))
+	}
+	resp, err := LookupUsersConcurrently(shortcutClient(t, server), usernames, nil, baseTestOpts(), 3, &RateLimitPause{})
+	require.NoError(t, err)
+
+	var merged struct {
+		Data []struct{ Username string } `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &merged))
+	require.Len(t, merged.Data, 250)
+	for i, u := range merged.Data {
+		assert.Equal(t, usernames[i], u.Username, "batch order is kept")
+	}
+	assert.InDelta(t, 2*time.Second, slept, float64(time.Second))
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxHeaderSize is how many bytes of a header value the verbose trace
+// prints by default (see ApiClient.WithMaxHeaderSize).
+const DefaultMaxHeaderSize = 8192
+
+// ReadHeaderFile reads a --header-file: one "Name: value" header per line,
+// with blank lines and lines starting with "#" ignored. Errors name the file
+// and line number.
+func ReadHeaderFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read header file: %v", err)
+	}
+	defer f.Close()
+
+	headers, err := ParseHeaders(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	return headers, nil
+}
+
+// ParseHeaders parses header-file syntax (see ReadHeaderFile), returning the
+// headers normalised to "Name: value". Errors are "LINE: reason".
+func ParseHeaders(r io.Reader) ([]string, error) {
+	var headers []string
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%d: expected \"Name: value\", got %q", lineNo, line)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%d: invalid header name %q", lineNo, name)
+		}
+		headers = append(headers, name+": "+strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%d: %v", lineNo+1, err)
+	}
+	return headers, nil
+}
+
+// truncateHeaderValue returns value cut to its first max bytes, without
+// splitting a UTF-8 character, followed by an ellipsis and the full length.
+// A value within max, or any value when max is zero or less, is returned
+// unchanged.
+func truncateHeaderValue(value string, max int) string {
+	if max <= 0 || len(value) <= max {
+		return value
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… (%d bytes, truncated)", value[:cut], len(value))
+}
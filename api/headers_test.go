@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders(strings.NewReader(`# gateway headers
+X-Tenant: acme
+
+  X-Trace-Id:abc-123
+X-Proxy-Signature: a:b:c
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"X-Tenant: acme", "X-Trace-Id: abc-123", "X-Proxy-Signature: a:b:c"}, headers)
+
+	_, err = ParseHeaders(strings.NewReader("X-Tenant: acme\n\nno colon here\n"))
+	assert.EqualError(t, err, `3: expected "Name: value", got "no colon here"`)
+
+	_, err = ParseHeaders(strings.NewReader("Bad Name: value\n"))
+	assert.ErrorContains(t, err, "1: invalid header name")
+}
+
+func TestReadHeaderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers")
+	require.NoError(t, os.WriteFile(path, []byte("X-Tenant: acme\n:missing name\n"), 0600))
+	_, err := ReadHeaderFile(path)
+	assert.EqualError(t, err, path+`:2: invalid header name ""`)
+
+	_, err = ReadHeaderFile(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "could not read header file")
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	got := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := shortcutClient(t, server).WithDefaultHeaders([]string{"X-Tenant: acme", "X-Trace-Id: from-file"})
+
+	opts := baseTestOpts()
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/me"
+	opts.Headers = []string{"x-trace-id: from-flag"}
+	_, err := client.SendRequest(opts)
+	require.NoError(t, err)
+	h := <-got
+	assert.Equal(t, "acme", h.Get("X-Tenant"))
+	assert.Equal(t, []string{"from-flag"}, h.Values("X-Trace-Id"), "-H must win over the header file")
+
+	err = client.StreamRequest(opts, StreamHandler{OnLine: func(string) error { return nil }})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", (<-got).Get("X-Tenant"), "streaming requests get the file headers too")
+}
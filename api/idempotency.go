@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// IdempotencyKeyHeader is the header --idempotency-key sets. Write endpoints
+// that support it treat repeated requests with the same key as one.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random (version 4) UUID.
+func NewIdempotencyKey() (string, error) {
+	key, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("could not generate idempotency key: %v", err)
+	}
+	return key, nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// AddIdempotencyKey adds an Idempotency-Key header with key, or a new random
+// key when key is empty, and returns the key sent. A key already present in
+// headers (e.g. from -H) is kept and returned instead. The headers are fixed
+// for the request, so every attempt at it sends the same key.
+func AddIdempotencyKey(headers []string, key string) ([]string, string, error) {
+	for _, header := range headers {
+		if name, value, ok := strings.Cut(header, ":"); ok && strings.EqualFold(strings.TrimSpace(name), IdempotencyKeyHeader) {
+			return headers, strings.TrimSpace(value), nil
+		}
+	}
+	if key == "" {
+		var err error
+		if key, err = NewIdempotencyKey(); err != nil {
+			return nil, "", err
+		}
+	}
+	return append(headers, IdempotencyKeyHeader+": "+key), key, nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	a, err := NewIdempotencyKey()
+	require.NoError(t, err)
+	b, err := NewIdempotencyKey()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, a)
+	assert.NotEqual(t, a, b)
+}
+
+func TestAddIdempotencyKey(t *testing.T) {
+	headers, key, err := AddIdempotencyKey([]string{"X-Tenant: acme"}, "my-key")
+	require.NoError(t, err)
+	assert.Equal(t, "my-key", key)
+	assert.Equal(t, []string{"X-Tenant: acme", "Idempotency-Key: my-key"}, headers)
+
+	headers, key, err = AddIdempotencyKey(nil, "")
+	require.NoError(t, err)
+	assert.Len(t, key, 36, "an empty value generates a UUID")
+	assert.Equal(t, []string{"Idempotency-Key: " + key}, headers)
+
+	headers, key, err = AddIdempotencyKey([]string{"idempotency-key: from-flag"}, "other")
+	require.NoError(t, err)
+	assert.Equal(t, "from-flag", key, "an explicit -H header wins")
+	assert.Len(t, headers, 1)
+}
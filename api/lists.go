@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// listMemberFields are the user.fields requested for list members.
+const listMemberFields = "user.fields=username,name,verified,public_metrics"
+
+// CreateList creates a List owned by the authenticated user. description is
+// optional.
+func CreateList(client Client, name, description string, private bool, opts RequestOptions) (json.RawMessage, error) {
+	body := struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Private     bool   `json:"private,omitempty"`
+	}{name, description, private}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Method = "POST"
+	opts.Endpoint = "/2/lists"
+	opts.Data = string(data)
+
+	return client.SendRequest(opts)
+}
+
+// DeleteList deletes a List owned by the authenticated user.
+func DeleteList(client Client, listID string, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "DELETE"
+	opts.Endpoint = "/2/lists/" + url.PathEscape(listID)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// AddListMember adds a user to a List.
+func AddListMember(client Client, listID, userID string, opts RequestOptions) (json.RawMessage, error) {
+	data, err := json.Marshal(map[string]string{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Method = "POST"
+	opts.Endpoint = "/2/lists/" + url.PathEscape(listID) + "/members"
+	opts.Data = string(data)
+
+	return client.SendRequest(opts)
+}
+
+// RemoveListMember removes a user from a List.
+func RemoveListMember(client Client, listID, userID string, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "DELETE"
+	opts.Endpoint = "/2/lists/" + url.PathEscape(listID) + "/members/" + url.PathEscape(userID)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetListMembers fetches one page of up to maxResults (1–100) members of a
+// List.
+func GetListMembers(client Client, listID string, maxResults int, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = listMembersEndpoint(listID)(maxResults, "")
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetAllListMembers pages through the members of a List, up to max of them,
+// and merges the pages like GetUserTimeline.
+func GetAllListMembers(client Client, listID string, max int, opts RequestOptions) (json.RawMessage, error) {
+	return collectPages(client, opts, max, listMembersEndpoint(listID))
+}
+
+// listMembersEndpoint pages through /2/lists/{id}/members.
+func listMembersEndpoint(listID string) pageEndpoint {
+	return func(remaining int, nextToken string) string {
+		endpoint := fmt.Sprintf("/2/lists/%s/members?max_results=%d&%s", url.PathEscape(listID), clampResults(remaining, 1, 100), listMemberFields)
+		if nextToken != "" {
+			endpoint += "&pagination_token=" + url.QueryEscape(nextToken)
+		}
+		return endpoint
+	}
+}
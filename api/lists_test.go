@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWrites(t *testing.T) {
+	type call struct{ method, path, body string }
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, call{r.Method, r.URL.Path, string(body)})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	_, err := CreateList(client, "Gophers", "Go people", true, baseTestOpts())
+	require.NoError(t, err)
+	_, err = CreateList(client, "Public", "", false, baseTestOpts())
+	require.NoError(t, err)
+	_, err = AddListMember(client, "77", "12", baseTestOpts())
+	require.NoError(t, err)
+	_, err = RemoveListMember(client, "77", "12", baseTestOpts())
+	require.NoError(t, err)
+	_, err = DeleteList(client, "77", baseTestOpts())
+	require.NoError(t, err)
+
+	require.Len(t, calls, 5)
+	assert.Equal(t, call{"POST", "/2/lists", `{"name":"Gophers","description":"Go people","private":true}`}, calls[0])
+	assert.Equal(t, call{"POST", "/2/lists", `{"name":"Public"}`}, calls[1])
+	assert.Equal(t, call{"POST", "/2/lists/77/members", `{"user_id":"12"}`}, calls[2])
+	assert.Equal(t, call{"DELETE", "/2/lists/77/members/12", ""}, calls[3])
+	assert.Equal(t, call{"DELETE", "/2/lists/77", ""}, calls[4])
+}
+
+func TestGetAllListMembersPaginates(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/lists/77/members", r.URL.Path)
+		q := r.URL.Query()
+		queries = append(queries, q)
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("pagination_token") {
+		case "":
+			w.Write([]byte(`{"data":[{"id":"1"},{"id":"2"}],"meta":{"result_count":2,"next_token":"p2"}}`))
+		case "p2":
+			w.Write([]byte(`{"data":[{"id":"3"}],"meta":{"result_count":1}}`))
+		default:
+			t.Errorf("unexpected page %q", q.Get("pagination_token"))
+		}
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	resp, err := GetAllListMembers(client, "77", math.MaxInt, baseTestOpts())
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "100", queries[0].Get("max_results"))
+	assert.Contains(t, queries[0].Get("user.fields"), "username")
+
+	var out struct {
+		Data []struct{ ID string } `json:"data"`
+		Meta struct {
+			ResultCount int `json:"result_count"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &out))
+	assert.Len(t, out.Data, 3)
+	assert.Equal(t, 3, out.Meta.ResultCount)
+
+	queries = nil
+	_, err = GetListMembers(client, "77", 500, baseTestOpts())
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "100", queries[0].Get("max_results"))
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Locale holds the --accept-language, --tweet-lang and --place-country
+// settings of a request.
+type Locale struct {
+	// AcceptLanguage is sent as the Accept-Language header unless the
+	// request's own Headers set one.
+	AcceptLanguage string
+	// TweetLang and PlaceCountry are added to the query of a search request
+	// as the lang: and place_country: operators.
+	TweetLang    string
+	PlaceCountry string
+}
+
+// searchPaths are the endpoints whose query parameter takes search
+// operators.
+var searchPaths = []string{
+	"/2/tweets/search/recent",
+	"/2/tweets/search/all",
+	"/2/tweets/counts/recent",
+	"/2/tweets/counts/all",
+}
+
+// isSearchPath reports whether path is one of searchPaths.
+func isSearchPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, p := range searchPaths {
+		if strings.HasSuffix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// applySearchFilters adds the lang: and place_country: operators of l to
+// the query parameter of rawURL, a search request. It fails for other
+// endpoints rather than silently sending an unfiltered request.
+func (l Locale) applySearchFilters(rawURL string) (string, error) {
+	if l.TweetLang == "" && l.PlaceCountry == "" {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if !isSearchPath(u.Path) {
+		return "", fmt.Errorf("--tweet-lang and --place-country only apply to search endpoints (%s), not %s", strings.Join(searchPaths, ", "), u.Path)
+	}
+	q := u.Query()
+	query := q.Get("query")
+	if query == "" {
+		return "", fmt.Errorf("--tweet-lang and --place-country need a search request with a query parameter")
+	}
+	if l.TweetLang != "" {
+		query += " lang:" + l.TweetLang
+	}
+	if l.PlaceCountry != "" {
+		query += " place_country:" + strings.ToUpper(l.PlaceCountry)
+	}
+	q.Set("query", query)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
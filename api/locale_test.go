@@ -0,0 +1,77 @@
+package api
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestBuildRequestLocale(t *testing.T) {
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: "https://api.x.com"}, authMock)
+
+	t.Run("Accept-Language header", func(t *testing.T) {
+		req, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/users/me",
+			Locale:   Locale{AcceptLanguage: "en-US"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "en-US", req.Header.Get("Accept-Language"))
+	})
+
+	t.Run("-H Accept-Language wins", func(t *testing.T) {
+		req, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/users/me",
+			Headers:  []string{"Accept-Language: ja"},
+			Locale:   Locale{AcceptLanguage: "en-US"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ja"}, req.Header.Values("Accept-Language"))
+	})
+
+	t.Run("search filters map to query operators", func(t *testing.T) {
+		req, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/recent?query=golang&max_results=10",
+			Locale:   Locale{TweetLang: "en", PlaceCountry: "us"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "golang lang:en place_country:US", req.URL.Query().Get("query"))
+		assert.Equal(t, "10", req.URL.Query().Get("max_results"))
+	})
+
+	t.Run("counts endpoint", func(t *testing.T) {
+		req, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "https://api.x.com/2/tweets/counts/all?query=%23go",
+			Locale:   Locale{TweetLang: "fr"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "#go lang:fr", req.URL.Query().Get("query"))
+	})
+
+	t.Run("non-search endpoints are rejected", func(t *testing.T) {
+		_, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/users/me",
+			Locale:   Locale{TweetLang: "en"},
+		})
+		assert.ErrorContains(t, err, "only apply to search endpoints")
+	})
+
+	t.Run("search without a query", func(t *testing.T) {
+		_, err := client.BuildRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/recent",
+			Locale:   Locale{PlaceCountry: "GB"},
+		})
+		assert.ErrorContains(t, err, "query parameter")
+	})
+}
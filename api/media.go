@@ -10,8 +10,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/xdevplatform/xurl/utils"
 )
 
 const (
@@ -19,6 +17,9 @@ const (
 	MediaEndpoint = "/2/media/upload"
 )
 
+// mediaChunkSize is the size of each appended segment.
+var mediaChunkSize int64 = 4 * 1024 * 1024
+
 // extToMediaType maps common file extensions to the MIME types the X API accepts.
 var extToMediaType = map[string]string{
 	".jpg":  "image/jpeg",
@@ -82,6 +83,36 @@ type MediaUploader struct {
 	username string
 	headers  []string
 	trace    bool
+	progress func(MediaProgress)
+	wait     ProcessingWait
+}
+
+// MediaStage identifies a step of a media upload reported to the
+// uploader's progress callback.
+type MediaStage string
+
+const (
+	MediaStageInit        MediaStage = "init"        // initialize request about to be sent
+	MediaStageInitialized MediaStage = "initialized" // Response holds the init response
+	MediaStageAppend      MediaStage = "append"      // chunk upload starting
+	MediaStageChunk       MediaStage = "chunk"       // a chunk was uploaded (BytesUploaded/TotalBytes)
+	MediaStageUploaded    MediaStage = "uploaded"    // all chunks uploaded
+	MediaStageFinalize    MediaStage = "finalize"    // finalize request about to be sent
+	MediaStageStatus      MediaStage = "status"      // Response holds a status response
+	MediaStageWaiting     MediaStage = "waiting"     // polling for processing starts
+	MediaStageProcessing  MediaStage = "processing"  // still processing (ProgressPercent, CheckAfter)
+	MediaStageRetrying    MediaStage = "retrying"    // a status check failed transiently (CheckAfter)
+	MediaStageProcessed   MediaStage = "processed"   // processing succeeded
+)
+
+// MediaProgress is one progress event from a MediaUploader.
+type MediaProgress struct {
+	Stage           MediaStage
+	BytesUploaded   int64
+	TotalBytes      int64
+	ProgressPercent int
+	CheckAfter      time.Duration
+	Response        json.RawMessage
 }
 
 type InitRequest struct {
@@ -114,6 +145,8 @@ func NewMediaUploader(client Client, filePath string, verbose, trace bool, authT
 	}, nil
 }
 
+// NewMediaUploaderWithoutFile creates a MediaUploader for an existing media
+// id (set with SetMediaID), e.g. to check its processing status.
 func NewMediaUploaderWithoutFile(client Client, verbose, trace bool, authType string, username string, headers []string) *MediaUploader {
 	return &MediaUploader{
 		client:   client,
@@ -125,11 +158,28 @@ func NewMediaUploaderWithoutFile(client Client, verbose, trace bool, authType st
 	}
 }
 
+// OnProgress registers a callback that receives progress events. The
+// uploader itself never prints.
+func (m *MediaUploader) OnProgress(fn func(MediaProgress)) *MediaUploader {
+	m.progress = fn
+	return m
+}
+
+// SetProcessingWait sets the limits WaitForProcessing polls within.
+func (m *MediaUploader) SetProcessingWait(w ProcessingWait) *MediaUploader {
+	m.wait = w
+	return m
+}
+
+func (m *MediaUploader) report(p MediaProgress) {
+	if m.progress != nil {
+		m.progress(p)
+	}
+}
+
 // Init initializes the media upload
 func (m *MediaUploader) Init(mediaType string, mediaCategory string) error {
-	if m.verbose {
-		fmt.Printf("\033[32mInitializing media upload...\033[0m\n")
-	}
+	m.report(MediaProgress{Stage: MediaStageInit, TotalBytes: m.fileSize})
 
 	finalUrl := MediaEndpoint +
 		"/initialize"
@@ -173,10 +223,7 @@ func (m *MediaUploader) Init(mediaType string, mediaCategory string) error {
 	}
 
 	m.mediaID = initResponse.Data.ID
-
-	if m.verbose {
-		utils.FormatAndPrintResponse(initResponse)
-	}
+	m.report(MediaProgress{Stage: MediaStageInitialized, TotalBytes: m.fileSize, Response: response})
 
 	return nil
 }
@@ -187,9 +234,7 @@ func (m *MediaUploader) Append() error {
 		return fmt.Errorf("media ID not set, call Init first")
 	}
 
-	if m.verbose {
-		fmt.Printf("\033[32mUploading media in chunks...\033[0m\n")
-	}
+	m.report(MediaProgress{Stage: MediaStageAppend, TotalBytes: m.fileSize})
 
 	// Open the file
 	file, err := os.Open(m.filePath)
@@ -199,8 +244,7 @@ func (m *MediaUploader) Append() error {
 	defer file.Close()
 
 	// Upload in chunks of 4MB
-	chunkSize := 4 * 1024 * 1024
-	buffer := make([]byte, chunkSize)
+	buffer := make([]byte, mediaChunkSize)
 	segmentIndex := 0
 	bytesUploaded := int64(0)
 
@@ -213,50 +257,54 @@ func (m *MediaUploader) Append() error {
 			return fmt.Errorf("error reading file: %v", err)
 		}
 
-		finalUrl := MediaEndpoint + fmt.Sprintf("/%s/append", m.mediaID)
-
-		// Prepare form fields
-		formFields := map[string]string{
-			"segment_index": strconv.Itoa(segmentIndex),
+		if err := m.appendSegment(segmentIndex, buffer[:bytesRead]); err != nil {
+			return err
 		}
 
-		requestOptions := RequestOptions{
-			Method:   "POST",
-			Endpoint: finalUrl,
-			Headers:  m.headers,
-			Data:     "",
-			AuthType: m.authType,
-			Username: m.username,
-			Verbose:  m.verbose,
-			Trace:    m.trace,
-		}
-		multipartOptions := MultipartOptions{
-			RequestOptions: requestOptions,
-			FormFields:     formFields,
-			FileField:      "media",
-			FileName:       filepath.Base(m.filePath),
-			FileData:       buffer[:bytesRead],
-		}
+		bytesUploaded += int64(bytesRead)
+		segmentIndex++
+
+		m.report(MediaProgress{Stage: MediaStageChunk, BytesUploaded: bytesUploaded, TotalBytes: m.fileSize})
+	}
 
-		// Send multipart request with buffer
-		_, clientErr := m.client.SendMultipartRequest(multipartOptions)
+	m.report(MediaProgress{Stage: MediaStageUploaded, BytesUploaded: bytesUploaded, TotalBytes: m.fileSize})
 
-		if clientErr != nil {
-			return fmt.Errorf("append request failed: %v", clientErr)
-		}
+	return nil
+}
 
-		bytesUploaded += int64(bytesRead)
-		segmentIndex++
+// appendSegment uploads one chunk of the file as segment index.
+func (m *MediaUploader) appendSegment(index int, data []byte) error {
+	finalUrl := MediaEndpoint + fmt.Sprintf("/%s/append", m.mediaID)
 
-		if m.verbose {
-			fmt.Printf("\033[33mUploaded %d of %d bytes (%.2f%%)\033[0m\n", bytesUploaded, m.fileSize, float64(bytesUploaded)/float64(m.fileSize)*100)
-		}
+	// Prepare form fields
+	formFields := map[string]string{
+		"segment_index": strconv.Itoa(index),
 	}
 
-	if m.verbose {
-		fmt.Printf("\033[32mUpload complete!\033[0m\n")
+	requestOptions := RequestOptions{
+		Method:   "POST",
+		Endpoint: finalUrl,
+		Headers:  m.headers,
+		Data:     "",
+		AuthType: m.authType,
+		Username: m.username,
+		Verbose:  m.verbose,
+		Trace:    m.trace,
+	}
+	multipartOptions := MultipartOptions{
+		RequestOptions: requestOptions,
+		FormFields:     formFields,
+		FileField:      "media",
+		FileName:       filepath.Base(m.filePath),
+		FileData:       data,
 	}
 
+	// Send multipart request with buffer
+	_, clientErr := m.client.SendMultipartRequest(multipartOptions)
+
+	if clientErr != nil {
+		return fmt.Errorf("append request failed: %v", clientErr)
+	}
 	return nil
 }
 
@@ -266,9 +314,7 @@ func (m *MediaUploader) Finalize() (json.RawMessage, error) {
 		return nil, fmt.Errorf("media ID not set, call Init first")
 	}
 
-	if m.verbose {
-		fmt.Printf("\033[32mFinalizing media upload...\033[0m\n")
-	}
+	m.report(MediaProgress{Stage: MediaStageFinalize, TotalBytes: m.fileSize})
 
 	finalUrl := MediaEndpoint + fmt.Sprintf("/%s/finalize", m.mediaID)
 	requestOptions := RequestOptions{
@@ -295,10 +341,6 @@ func (m *MediaUploader) CheckStatus() (json.RawMessage, error) {
 		return nil, fmt.Errorf("media ID not set, call Init first")
 	}
 
-	if m.verbose {
-		fmt.Println("Checking media status...")
-	}
-
 	url := MediaEndpoint + "?command=STATUS&media_id=" + m.mediaID
 
 	requestOptions := RequestOptions{
@@ -313,30 +355,29 @@ func (m *MediaUploader) CheckStatus() (json.RawMessage, error) {
 	}
 	response, clientErr := m.client.SendRequest(requestOptions)
 	if clientErr != nil {
-		return nil, fmt.Errorf("status request failed: %v", clientErr)
+		return nil, fmt.Errorf("status request failed: %w", clientErr)
 	}
 
-	if m.verbose {
-		utils.FormatAndPrintResponse(response)
-	}
+	m.report(MediaProgress{Stage: MediaStageStatus, Response: response})
 
 	return response, nil
 }
 
-// WaitForProcessing waits for media processing to complete
+// WaitForProcessing waits for media processing to complete, within the
+// limits set by SetProcessingWait. A "failed" processing state is an
+// immediate error; transient STATUS failures are retried.
 func (m *MediaUploader) WaitForProcessing() (json.RawMessage, error) {
 	if m.mediaID == "" {
 		return nil, fmt.Errorf("media ID not set, call Init first")
 	}
 
-	if m.verbose {
-		fmt.Printf("\033[32mWaiting for media processing to complete...\033[0m\n")
-	}
+	m.report(MediaProgress{Stage: MediaStageWaiting})
 
-	for {
+	var processed json.RawMessage
+	err := m.wait.poll(func() (bool, time.Duration, error) {
 		response, err := m.CheckStatus()
 		if err != nil {
-			return nil, err
+			return false, 0, err
 		}
 
 		var statusResponse struct {
@@ -350,17 +391,16 @@ func (m *MediaUploader) WaitForProcessing() (json.RawMessage, error) {
 		}
 
 		if err := json.Unmarshal(response, &statusResponse); err != nil {
-			return nil, fmt.Errorf("failed to parse status response: %v", err)
+			return false, 0, fmt.Errorf("failed to parse status response: %v", err)
 		}
 
 		state := statusResponse.Data.ProcessingInfo.State
 		if state == "succeeded" {
-			if m.verbose {
-				fmt.Printf("\033[32mMedia processing complete!\033[0m\n")
-			}
-			return response, nil
+			m.report(MediaProgress{Stage: MediaStageProcessed, ProgressPercent: 100, Response: response})
+			processed = response
+			return true, 0, nil
 		} else if state == "failed" {
-			return nil, fmt.Errorf("media processing failed")
+			return false, 0, fmt.Errorf("media processing failed")
 		}
 
 		checkAfterSecs := statusResponse.Data.ProcessingInfo.CheckAfterSecs
@@ -368,14 +408,21 @@ func (m *MediaUploader) WaitForProcessing() (json.RawMessage, error) {
 			checkAfterSecs = 1
 		}
 
-		if m.verbose {
-			fmt.Printf("\033[33mMedia processing in progress (%d%%), checking again in %d seconds...\033[0m\n",
-				statusResponse.Data.ProcessingInfo.ProgressPercent,
-				checkAfterSecs)
-		}
-
-		time.Sleep(time.Duration(checkAfterSecs) * time.Second)
+		checkAfter := time.Duration(checkAfterSecs) * time.Second
+		m.report(MediaProgress{
+			Stage:           MediaStageProcessing,
+			ProgressPercent: statusResponse.Data.ProcessingInfo.ProgressPercent,
+			CheckAfter:      checkAfter,
+			Response:        response,
+		})
+		return false, checkAfter, nil
+	}, func(delay time.Duration) {
+		m.report(MediaProgress{Stage: MediaStageRetrying, CheckAfter: delay})
+	})
+	if err != nil {
+		return nil, err
 	}
+	return processed, nil
 }
 
 // GetMediaID returns the media ID
@@ -388,94 +435,6 @@ func (m *MediaUploader) SetMediaID(mediaID string) {
 	m.mediaID = mediaID
 }
 
-// ExecuteMediaUpload handles the media upload command execution
-func ExecuteMediaUpload(filePath, mediaType, mediaCategory, authType, username string, verbose, waitForProcessing, trace bool, headers []string, client Client) error {
-	uploader, err := NewMediaUploader(client, filePath, verbose, trace, authType, username, headers)
-	if err != nil {
-		return fmt.Errorf("error: %v", err)
-	}
-
-	// Fill in sensible defaults from the file itself when not specified. If the
-	// type can't be detected, or it is a recognized-but-unsupported type, fail
-	// clearly rather than guessing and letting the API reject the upload with an
-	// opaque server error.
-	if mediaType == "" {
-		detected := DetectMediaType(filePath)
-		if detected == "application/octet-stream" {
-			return fmt.Errorf("could not detect media type for %q; pass --media-type (and --category)", filePath)
-		}
-		mediaType = detected
-	}
-	if mediaCategory == "" {
-		category, ok := DefaultMediaCategory(mediaType)
-		if !ok {
-			return fmt.Errorf("unsupported media type %q; pass --category to override", mediaType)
-		}
-		mediaCategory = category
-	}
-
-	if err := uploader.Init(mediaType, mediaCategory); err != nil {
-		return fmt.Errorf("error initializing upload: %v", err)
-	}
-
-	if err := uploader.Append(); err != nil {
-		return fmt.Errorf("error uploading media: %v", err)
-	}
-
-	finalizeResponse, err := uploader.Finalize()
-	if err != nil {
-		return fmt.Errorf("error finalizing upload: %v", err)
-	}
-
-	utils.FormatAndPrintResponse(finalizeResponse)
-
-	// Wait for processing if requested (videos and GIFs are processed async)
-	if waitForProcessing && mediaNeedsProcessing(mediaCategory) {
-		processingResponse, err := uploader.WaitForProcessing()
-		if err != nil {
-			return fmt.Errorf("error during media processing: %v", err)
-		}
-
-		utils.FormatAndPrintResponse(processingResponse)
-	}
-
-	fmt.Printf("\033[32mMedia uploaded successfully! Media ID: %s\033[0m\n", uploader.GetMediaID())
-	return nil
-}
-
-// ExecuteMediaStatus handles the media status command execution
-func ExecuteMediaStatus(mediaID, authType, username string, verbose, wait, trace bool, headers []string, client Client) error {
-	uploader := NewMediaUploaderWithoutFile(client, verbose, trace, authType, username, headers)
-
-	uploader.SetMediaID(mediaID)
-
-	if wait {
-		processingResponse, err := uploader.WaitForProcessing()
-		if err != nil {
-			return fmt.Errorf("error during media processing: %v", err)
-		}
-
-		prettyJSON, err := json.MarshalIndent(processingResponse, "", "  ")
-		if err != nil {
-			return fmt.Errorf("error formatting JSON: %v", err)
-		}
-		fmt.Println(string(prettyJSON))
-	} else {
-		statusResponse, err := uploader.CheckStatus()
-		if err != nil {
-			return fmt.Errorf("error checking status: %v", err)
-		}
-
-		prettyJSON, err := json.MarshalIndent(statusResponse, "", "  ")
-		if err != nil {
-			return fmt.Errorf("error formatting JSON: %v", err)
-		}
-		fmt.Println(string(prettyJSON))
-	}
-
-	return nil
-}
-
 // HandleMediaAppendRequest handles a media append request with a file
 func HandleMediaAppendRequest(options RequestOptions, mediaFile string, client Client) (json.RawMessage, error) {
 	// TODO: This function is in a weird state since append accepts either a multipart request or a json request
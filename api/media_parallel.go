@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultUploadWorkers is how many segments AppendParallel uploads at once
+// when the memory cap allows it.
+const DefaultUploadWorkers = 4
+
+// mediaFile is the part of *os.File that AppendParallel reads through.
+type mediaFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// openMediaFile and newChunkBuffer are swapped out by tests to observe how
+// AppendParallel reads the file and how much memory it allocates.
+var openMediaFile = func(path string) (mediaFile, error) {
+	return os.Open(path)
+}
+
+var newChunkBuffer = func(size int64) []byte {
+	return make([]byte, size)
+}
+
+// uploadWorkers returns how many segments may be in flight at once: workers,
+// lowered so that their chunk buffers fit in maxMemory bytes (when set), but
+// always at least one.
+func uploadWorkers(workers int, maxMemory, chunkSize int64) int {
+	if workers < 1 {
+		workers = DefaultUploadWorkers
+	}
+	if maxMemory > 0 && int64(workers)*chunkSize > maxMemory {
+		workers = int(maxMemory / chunkSize)
+	}
+	return max(workers, 1)
+}
+
+// AppendParallel uploads the media in chunks like Append, but reads each
+// segment straight from the file through an io.SectionReader and uploads up
+// to workers segments at once. Each worker reuses one chunk-sized buffer, so
+// memory use is bounded by the number of workers, never by the file size;
+// the number of workers is lowered to fit maxMemory bytes (0 for no cap).
+func (m *MediaUploader) AppendParallel(workers int, maxMemory int64) error {
+	if m.mediaID == "" {
+		return fmt.Errorf("media ID not set, call Init first")
+	}
+
+	m.report(MediaProgress{Stage: MediaStageAppend, TotalBytes: m.fileSize})
+
+	file, err := openMediaFile(m.filePath)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	chunkSize := mediaChunkSize
+	segments := int((m.fileSize + chunkSize - 1) / chunkSize)
+	workers = min(uploadWorkers(workers, maxMemory, chunkSize), max(segments, 1))
+
+	var (
+		mu            sync.Mutex
+		firstErr      error
+		bytesUploaded int64
+		wg            sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buffer := newChunkBuffer(chunkSize)
+			for index := range jobs {
+				offset := int64(index) * chunkSize
+				n := min(chunkSize, m.fileSize-offset)
+				if _, err := io.ReadFull(io.NewSectionReader(file, offset, n), buffer[:n]); err != nil {
+					fail(fmt.Errorf("error reading file: %v", err))
+					continue
+				}
+				if err := m.appendSegment(index, buffer[:n]); err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				bytesUploaded += n
+				m.report(MediaProgress{Stage: MediaStageChunk, BytesUploaded: bytesUploaded, TotalBytes: m.fileSize})
+				mu.Unlock()
+			}
+		}()
+	}
+	for index := 0; index < segments && !failed(); index++ {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	m.report(MediaProgress{Stage: MediaStageUploaded, BytesUploaded: bytesUploaded, TotalBytes: m.fileSize})
+	return nil
+}
+
+// ParallelUpload makes ExecuteMediaUpload use AppendParallel with these
+// settings instead of Append.
+type ParallelUpload struct {
+	Workers   int
+	MaxMemory int64
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// segmentRecorder is a Client that keeps a copy of every appended segment.
+type segmentRecorder struct {
+	MockApiClient
+	mu       sync.Mutex
+	segments map[int][]byte
+}
+
+func (r *segmentRecorder) SendMultipartRequest(options MultipartOptions) (json.RawMessage, error) {
+	index, err := strconv.Atoi(options.FormFields["segment_index"])
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.segments[index] = bytes.Clone(options.FileData)
+	return json.RawMessage("{}"), nil
+}
+
+// countingFile records how AppendParallel reads the media file.
+type countingFile struct {
+	mediaFile
+	reads   atomic.Int64
+	maxRead atomic.Int64
+	total   atomic.Int64
+}
+
+func (f *countingFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.mediaFile.ReadAt(p, off)
+	f.reads.Add(1)
+	f.total.Add(int64(n))
+	for {
+		prev := f.maxRead.Load()
+		if int64(len(p)) <= prev || f.maxRead.CompareAndSwap(prev, int64(len(p))) {
+			break
+		}
+	}
+	return n, err
+}
+
+func TestUploadWorkers(t *testing.T) {
+	assert.Equal(t, 4, uploadWorkers(4, 0, 1024))
+	assert.Equal(t, DefaultUploadWorkers, uploadWorkers(0, 0, 1024))
+	assert.Equal(t, 2, uploadWorkers(4, 2048, 1024))
+	assert.Equal(t, 1, uploadWorkers(4, 100, 1024), "always at least one worker")
+}
+
+func TestMediaUploader_AppendParallelBoundsMemory(t *testing.T) {
+	const chunk = 1024
+	const segments = 37
+	fileSize := chunk*segments - 100
+	tempFile, data := createTempTestFile(t, fileSize)
+	defer os.Remove(tempFile)
+
+	defer func(size int64) { mediaChunkSize = size }(mediaChunkSize)
+	mediaChunkSize = chunk
+
+	var file *countingFile
+	defer func(open func(string) (mediaFile, error)) { openMediaFile = open }(openMediaFile)
+	openMediaFile = func(path string) (mediaFile, error) {
+		f, err := os.Open(path)
+		file = &countingFile{mediaFile: f}
+		return file, err
+	}
+
+	var buffers, allocated atomic.Int64
+	defer func(alloc func(int64) []byte) { newChunkBuffer = alloc }(newChunkBuffer)
+	newChunkBuffer = func(size int64) []byte {
+		buffers.Add(1)
+		allocated.Add(size)
+		return make([]byte, size)
+	}
+
+	client := &segmentRecorder{segments: map[int][]byte{}}
+	uploader, err := NewMediaUploader(client, tempFile, false, false, "oauth2", "testuser", nil)
+	require.NoError(t, err)
+	uploader.SetMediaID("test_media_id")
+
+	var last MediaProgress
+	uploader.OnProgress(func(p MediaProgress) { last = p })
+
+	// Four workers are asked for, but only two chunk buffers fit the cap.
+	require.NoError(t, uploader.AppendParallel(4, 2*chunk))
+
+	assert.Equal(t, int64(2), buffers.Load())
+	assert.LessOrEqual(t, allocated.Load(), int64(2*chunk), "buffers stay within --max-upload-memory")
+	assert.LessOrEqual(t, file.maxRead.Load(), int64(chunk), "no read is larger than a chunk")
+	assert.Equal(t, int64(fileSize), file.total.Load(), "the file is read exactly once")
+
+	require.Len(t, client.segments, segments)
+	var uploaded []byte
+	for i := 0; i < segments; i++ {
+		segment, ok := client.segments[i]
+		require.True(t, ok, fmt.Sprintf("segment %d", i))
+		uploaded = append(uploaded, segment...)
+	}
+	assert.Equal(t, data, uploaded)
+	assert.Equal(t, MediaProgress{Stage: MediaStageUploaded, BytesUploaded: int64(fileSize), TotalBytes: int64(fileSize)}, last)
+
+	uploader.SetMediaID("")
+	err = uploader.AppendParallel(4, 0)
+	assert.ErrorContains(t, err, "media ID not set")
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MediaMetadataEndpoint is the endpoint that sets the alt text of
+	// uploaded media.
+	MediaMetadataEndpoint = "/2/media/metadata"
+
+	// DefaultSidecarSuffix is added to a media file's name to find its
+	// sidecar: photo.png's is photo.png.meta.json.
+	DefaultSidecarSuffix = ".meta.json"
+
+	// maxAltTextLength is the longest alt text the API accepts.
+	maxAltTextLength = 1000
+)
+
+// MediaSidecar is the metadata kept in a file next to a media file, such as
+// {"alt_text": "..."}. It may be JSON or YAML.
+type MediaSidecar struct {
+	AltText string `yaml:"alt_text"`
+}
+
+// ReadMediaSidecar reads the sidecar of filePath, the file named
+// filePath+suffix. found is false when there is none.
+func ReadMediaSidecar(filePath, suffix string) (sidecar MediaSidecar, found bool, err error) {
+	raw, err := os.ReadFile(filePath + suffix)
+	if os.IsNotExist(err) {
+		return sidecar, false, nil
+	}
+	if err != nil {
+		return sidecar, true, err
+	}
+	if err := yaml.Unmarshal(raw, &sidecar); err != nil {
+		return sidecar, true, fmt.Errorf("%s is not valid JSON or YAML: %v", filepath.Base(filePath+suffix), err)
+	}
+	return sidecar, true, nil
+}
+
+// SetAltText sets the alt text of the uploaded media.
+func (m *MediaUploader) SetAltText(text string) (json.RawMessage, error) {
+	if m.mediaID == "" {
+		return nil, fmt.Errorf("media ID not set, call Init first")
+	}
+
+	var body struct {
+		ID       string `json:"id"`
+		Metadata struct {
+			AltText struct {
+				Text string `json:"text"`
+			} `json:"alt_text"`
+		} `json:"metadata"`
+	}
+	body.ID = m.mediaID
+	body.Metadata.AltText.Text = text
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling body: %v", err)
+	}
+
+	response, clientErr := m.client.SendRequest(RequestOptions{
+		Method:   "POST",
+		Endpoint: MediaMetadataEndpoint,
+		Headers:  m.headers,
+		Data:     string(jsonData),
+		AuthType: m.authType,
+		Username: m.username,
+		Verbose:  m.verbose,
+		Trace:    m.trace,
+	})
+	if clientErr != nil {
+		return nil, fmt.Errorf("metadata request failed: %v", clientErr)
+	}
+	return response, nil
+}
+
+// applyMediaSidecar sets the metadata in the sidecar of filePath on the
+// media m uploaded, and describes what it applied. A sidecar that is
+// missing, malformed or refused is returned as warning instead: it never
+// fails the upload.
+func applyMediaSidecar(m *MediaUploader, filePath, suffix string) (applied string, warning error) {
+	name := filepath.Base(filePath + suffix)
+	sidecar, found, err := ReadMediaSidecar(filePath, suffix)
+	switch {
+	case !found:
+		return "", fmt.Errorf("%s has no sidecar %s", filepath.Base(filePath), name)
+	case err != nil:
+		return "", err
+	case sidecar.AltText == "":
+		return "", fmt.Errorf("%s has no alt_text", name)
+	case len([]rune(sidecar.AltText)) > maxAltTextLength:
+		return "", fmt.Errorf("the alt_text in %s is %d characters, the limit is %d", name, len([]rune(sidecar.AltText)), maxAltTextLength)
+	}
+	if _, err := m.SetAltText(sidecar.AltText); err != nil {
+		return "", fmt.Errorf("could not set the alt text from %s: %v", name, err)
+	}
+	return fmt.Sprintf("alt text from %s", name), nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaUploadSidecars(t *testing.T) {
+	var metadata []string
+	ids := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == MediaMetadataEndpoint {
+			body, _ := io.ReadAll(r.Body)
+			metadata = append(metadata, string(body))
+			w.Write([]byte(`{"data":{"associated_metadata":true}}`))
+			return
+		}
+		switch ExtractCommand(r.URL.Path) {
+		case "initialize":
+			ids++
+			w.Write([]byte(`{"data":{"id":"` + strings.Repeat("1", ids) + `"}}`))
+		case "append", "finalize":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	client := &ApiClient{url: server.URL, client: &http.Client{Timeout: 30 * time.Second}, allowUnauthenticated: true}
+
+	dir := t.TempDir()
+	sidecars := map[string]string{
+		"present.png":  `{"alt_text": "A cat on a mat"}`,
+		"invalid.png":  `{"alt_text": `,
+		"yaml.png":     "alt_text: A dog\n",
+		"untitled.png": `{"title": "no alt text"}`,
+	}
+	for _, name := range []string{"present.png", "absent.png", "invalid.png", "yaml.png", "untitled.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("png"), 0600))
+		if sidecar, ok := sidecars[name]; ok {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name+".meta"), []byte(sidecar), 0600))
+		}
+	}
+
+	for _, name := range []string{"present.png", "absent.png", "invalid.png", "yaml.png", "untitled.png"} {
+		err := ExecuteMediaUpload(MediaUploadOptions{FilePath: filepath.Join(dir, name), MediaType: "image/png", MediaCategory: "tweet_image", SidecarSuffix: ".meta"}, client)
+		assert.NoError(t, err, "a sidecar never fails the upload of %s", name)
+	}
+	require.Len(t, metadata, 2, "only the usable sidecars are applied")
+	assert.JSONEq(t, `{"id":"1","metadata":{"alt_text":{"text":"A cat on a mat"}}}`, metadata[0])
+	assert.JSONEq(t, `{"id":"1111","metadata":{"alt_text":{"text":"A dog"}}}`, metadata[1])
+
+	uploader := &MediaUploader{client: client, mediaID: "9"}
+	applied, warning := applyMediaSidecar(uploader, filepath.Join(dir, "present.png"), ".meta")
+	assert.NoError(t, warning)
+	assert.Equal(t, "alt text from present.png.meta", applied)
+	_, warning = applyMediaSidecar(uploader, filepath.Join(dir, "absent.png"), ".meta")
+	assert.EqualError(t, warning, "absent.png has no sidecar absent.png.meta")
+	_, warning = applyMediaSidecar(uploader, filepath.Join(dir, "invalid.png"), ".meta")
+	assert.ErrorContains(t, warning, "invalid.png.meta is not valid JSON or YAML")
+	_, warning = applyMediaSidecar(uploader, filepath.Join(dir, "untitled.png"), ".meta")
+	assert.EqualError(t, warning, "untitled.png.meta has no alt_text")
+}
@@ -3,10 +3,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -14,6 +16,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
 // MockApiClient is a mock implementation of the ApiClient for testing
@@ -41,7 +46,7 @@ func (m *MockApiClient) BuildMultipartRequest(options MultipartOptions) (*http.R
 	return args.Get(0).(*http.Request), args.Error(1)
 }
 
-func (m *MockApiClient) StreamRequest(options RequestOptions) error {
+func (m *MockApiClient) StreamRequest(options RequestOptions, handler StreamHandler) error {
 	args := m.Called(options.Method, options.Endpoint, options.Headers, options.Data, options.AuthType, options.Username, options.Verbose)
 	return args.Error(0)
 }
@@ -199,11 +204,21 @@ func TestMediaUploader_Append(t *testing.T) {
 	mockClient.On("SendMultipartRequest", multipartOptions).Return(json.RawMessage("{}"), nil)
 	mockClient.On("SendMultipartRequest", multipartOptions1).Return(json.RawMessage("{}"), nil)
 
+	var events []MediaProgress
+	uploader.OnProgress(func(p MediaProgress) { events = append(events, p) })
+
 	err = uploader.Append()
 	assert.NoError(t, err)
 
 	mockClient.AssertExpectations(t)
 
+	// Progress is reported through the callback rather than printed.
+	require.Len(t, events, 4)
+	assert.Equal(t, MediaStageAppend, events[0].Stage)
+	assert.Equal(t, MediaProgress{Stage: MediaStageChunk, BytesUploaded: 4 * 1024 * 1024, TotalBytes: int64(fileSize)}, events[1])
+	assert.Equal(t, MediaProgress{Stage: MediaStageChunk, BytesUploaded: int64(fileSize), TotalBytes: int64(fileSize)}, events[2])
+	assert.Equal(t, MediaStageUploaded, events[3].Stage)
+
 	uploader.SetMediaID("")
 	err = uploader.Append()
 	assert.Error(t, err)
@@ -392,6 +407,104 @@ func TestMediaUploader_WaitForProcessing(t *testing.T) {
 	assert.Nil(t, response)
 }
 
+// serverError is the error the client returns for a 5xx STATUS response.
+func serverError(retryAfter time.Duration) error {
+	e := xurlErrors.NewAPIError([]byte(`{"title":"Service Unavailable"}`))
+	e.StatusCode = http.StatusServiceUnavailable
+	if retryAfter > 0 {
+		e.RetryAfter = time.Now().Add(retryAfter)
+	}
+	return e
+}
+
+func TestMediaUploader_WaitForProcessingRetries(t *testing.T) {
+	var slept []time.Duration
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	statusOptions := RequestOptions{
+		Method:   "GET",
+		Endpoint: MediaEndpoint + "?command=STATUS&media_id=test_media_id",
+		Headers:  []string{},
+	}
+	successResponse := json.RawMessage(`{"data":{"processing_info":{"state":"succeeded","progress_percent":100}}}`)
+	failedResponse := json.RawMessage(`{"data":{"processing_info":{"state":"failed"}}}`)
+	newUploader := func(client Client, wait ProcessingWait) (*MediaUploader, *[]MediaProgress) {
+		var events []MediaProgress
+		uploader := NewMediaUploaderWithoutFile(client, false, false, "", "", nil).SetProcessingWait(wait)
+		uploader.OnProgress(func(p MediaProgress) { events = append(events, p) })
+		uploader.SetMediaID("test_media_id")
+		return uploader, &events
+	}
+
+	t.Run("recovers after two 503s", func(t *testing.T) {
+		slept = nil
+		mockClient := new(MockApiClient)
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), serverError(30*time.Second)).Once()
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), serverError(0)).Once()
+		mockClient.On("SendRequest", statusOptions).Return(successResponse, nil).Once()
+
+		uploader, events := newUploader(mockClient, ProcessingWait{})
+		response, err := uploader.WaitForProcessing()
+		require.NoError(t, err)
+		assert.Equal(t, successResponse, response)
+		mockClient.AssertExpectations(t)
+
+		require.Len(t, slept, 2)
+		assert.InDelta(t, 30*time.Second, slept[0], float64(time.Second), "Retry-After is honored")
+		assert.Equal(t, 10*time.Second, slept[1], "backoff doubles without Retry-After")
+		var retries int
+		for _, e := range *events {
+			if e.Stage == MediaStageRetrying {
+				retries++
+			}
+		}
+		assert.Equal(t, 2, retries)
+	})
+
+	t.Run("gives up after consecutive failures", func(t *testing.T) {
+		mockClient := new(MockApiClient)
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), serverError(0)).Times(3)
+
+		uploader, _ := newUploader(mockClient, ProcessingWait{MaxFailures: 3})
+		_, err := uploader.WaitForProcessing()
+		assert.ErrorContains(t, err, "giving up after 3 failed status checks")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Retry-After beyond the timeout", func(t *testing.T) {
+		mockClient := new(MockApiClient)
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), serverError(time.Hour)).Once()
+
+		uploader, _ := newUploader(mockClient, ProcessingWait{Timeout: time.Minute})
+		_, err := uploader.WaitForProcessing()
+		assert.ErrorContains(t, err, "processing timeout of 1m0s reached")
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		badRequest := xurlErrors.NewAPIError([]byte(`{"title":"Invalid Request"}`))
+		badRequest.StatusCode = http.StatusBadRequest
+		mockClient := new(MockApiClient)
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), badRequest).Once()
+
+		uploader, _ := newUploader(mockClient, ProcessingWait{})
+		_, err := uploader.WaitForProcessing()
+		assert.ErrorContains(t, err, "status request failed")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("failed processing is immediate", func(t *testing.T) {
+		mockClient := new(MockApiClient)
+		mockClient.On("SendRequest", statusOptions).Return(json.RawMessage(nil), serverError(0)).Once()
+		mockClient.On("SendRequest", statusOptions).Return(failedResponse, nil).Once()
+
+		uploader, _ := newUploader(mockClient, ProcessingWait{MaxFailures: 10})
+		_, err := uploader.WaitForProcessing()
+		assert.ErrorContains(t, err, "media processing failed")
+		mockClient.AssertExpectations(t)
+	})
+}
+
 func TestExecuteMediaUpload(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, MediaEndpoint) {
@@ -451,10 +564,10 @@ func TestExecuteMediaUpload(t *testing.T) {
 	tempFile, _ := createTempTestFile(t, 1024)
 	defer os.Remove(tempFile)
 
-	err := ExecuteMediaUpload(tempFile, "image/jpeg", "tweet_image", "oauth2", "testuser", false, false, false, []string{}, client)
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: tempFile, MediaType: "image/jpeg", MediaCategory: "tweet_image", AuthType: "oauth2", Username: "testuser"}, client)
 	assert.NoError(t, err)
 
-	err = ExecuteMediaUpload("nonexistent.txt", "image/jpeg", "tweet_image", "oauth2", "testuser", false, false, false, []string{}, client)
+	err = ExecuteMediaUpload(MediaUploadOptions{FilePath: "nonexistent.txt", MediaType: "image/jpeg", MediaCategory: "tweet_image", AuthType: "oauth2", Username: "testuser"}, client)
 	assert.Error(t, err)
 }
 
@@ -485,7 +598,7 @@ func TestExecuteMediaStatus(t *testing.T) {
 		allowUnauthenticated: true,
 	}
 
-	err := ExecuteMediaStatus("test_media_id", "oauth2", "testuser", false, false, false, []string{}, client)
+	err := ExecuteMediaStatus("test_media_id", RequestOptions{AuthType: "oauth2", Username: "testuser"}, false, ProcessingWait{}, client)
 	assert.NoError(t, err)
 }
 
@@ -528,7 +641,7 @@ func TestExecuteMediaUploadWaitsForVideoProcessing(t *testing.T) {
 	defer os.Remove(tempFile)
 
 	// Args: verbose=false, waitForProcessing=true, trace=false.
-	err := ExecuteMediaUpload(tempFile, "video/mp4", "tweet_video", "", "", false, true, false, []string{}, client)
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: tempFile, MediaType: "video/mp4", MediaCategory: "tweet_video", WaitForProcessing: true}, client)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, atomic.LoadInt32(&statusCalls), int32(2), "expected the status endpoint to be polled while waiting")
 }
@@ -599,7 +712,7 @@ func TestExecuteMediaUploadWaitsForGIF(t *testing.T) {
 	defer os.Remove(gifFile)
 
 	// Empty media-type/category → auto-detect to image/gif + tweet_gif.
-	err := ExecuteMediaUpload(gifFile, "", "", "", "", false, true, false, []string{}, client)
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: gifFile, WaitForProcessing: true}, client)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, atomic.LoadInt32(&statusCalls), int32(2), "GIF upload should poll processing")
 }
@@ -609,7 +722,7 @@ func TestExecuteMediaUploadUndetectableTypeErrors(t *testing.T) {
 	f := tempFileWithExt(t, ".unknownext", 16)
 	defer os.Remove(f)
 
-	err := ExecuteMediaUpload(f, "", "", "", "", false, false, false, nil, mockClient)
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: f}, mockClient)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "could not detect media type")
 }
@@ -622,11 +735,70 @@ func TestExecuteMediaUploadUnsupportedTypeErrors(t *testing.T) {
 	f := tempFileWithExt(t, ".bin", 16)
 	defer os.Remove(f)
 
-	err := ExecuteMediaUpload(f, "application/pdf", "", "", "", false, false, false, nil, mockClient)
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: f, MediaType: "application/pdf"}, mockClient)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported media type")
 }
 
+func TestExecuteMediaUploadPreUploadCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub command uses a POSIX shell")
+	}
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch ExtractCommand(r.URL.Path) {
+		case "initialize":
+			w.Write([]byte(`{"data":{"id":"pre123"}}`))
+		case "append":
+			r.ParseMultipartForm(1 << 20)
+			f, _, err := r.FormFile("media")
+			if assert.NoError(t, err) {
+				uploaded, _ = io.ReadAll(f)
+			}
+			w.Write([]byte(`{}`))
+		case "finalize":
+			w.Write([]byte(`{"data":{"id":"pre123"}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	client := &ApiClient{url: server.URL, client: &http.Client{Timeout: 30 * time.Second}, allowUnauthenticated: true}
+
+	dir := t.TempDir()
+	// A space and a quote in the name check that {in} is quoted for the shell.
+	in := filepath.Join(dir, "my 'clip'.jpg")
+	require.NoError(t, os.WriteFile(in, []byte("original"), 0600))
+	record := filepath.Join(dir, "paths")
+
+	// The stub "transcoder" appends to a copy of the input and records the
+	// paths it was given.
+	cmd := "cp {in} {out} && printf -- '-transcoded' >> {out} && printf '%s\\n%s' {in} {out} > " + record
+	err := ExecuteMediaUpload(MediaUploadOptions{FilePath: in, PreUploadCmd: cmd}, client)
+	require.NoError(t, err)
+	assert.Equal(t, "original-transcoded", string(uploaded), "the command's output must be uploaded in place of the original")
+
+	paths, err := os.ReadFile(record)
+	require.NoError(t, err)
+	lines := strings.Split(string(paths), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, in, lines[0])
+	assert.Equal(t, ".jpg", filepath.Ext(lines[1]), "{out} keeps the input's extension")
+	_, err = os.Stat(lines[1])
+	assert.True(t, os.IsNotExist(err), "the temporary output must be removed after upload")
+
+	original, err := os.ReadFile(in)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(original), "the input file must be left alone")
+
+	err = ExecuteMediaUpload(MediaUploadOptions{FilePath: in, PreUploadCmd: "cp {in} /dev/null"}, client)
+	assert.ErrorContains(t, err, "{out}")
+	err = ExecuteMediaUpload(MediaUploadOptions{FilePath: in, PreUploadCmd: "true {out}"}, client)
+	assert.ErrorContains(t, err, "no output was written")
+	err = ExecuteMediaUpload(MediaUploadOptions{FilePath: in, PreUploadCmd: "exit 3 {out}"}, client)
+	assert.ErrorContains(t, err, "exit status 3")
+}
+
 func tempFileWithExt(t *testing.T, ext string, size int) string {
 	t.Helper()
 	f, err := os.CreateTemp("", "media_test_*"+ext)
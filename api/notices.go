@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xdevplatform/xurl/store"
+)
+
+// Notice is a deprecation or warning announcement attached to an API
+// response, via the Deprecation, Sunset, or Warning headers or a warnings
+// list in the body.
+type Notice struct {
+	Kind    string `json:"kind"` // "deprecation", "sunset", or "warning"
+	Message string `json:"message"`
+}
+
+// noticeEndpointKey identifies the endpoint a notice belongs to: method plus
+// path with the query string dropped and numeric id segments (after the
+// leading API version) normalized, so /2/tweets/1 and /2/tweets/2 count as
+// the same endpoint.
+func noticeEndpointKey(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	segments := strings.Split(req.URL.Path, "/")
+	for i := 2; i < len(segments); i++ {
+		if segments[i] != "" && strings.Trim(segments[i], "0123456789") == "" {
+			segments[i] = ":id"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+// detectNotices extracts deprecation and warning notices from a response's
+// headers and (JSON) body.
+func detectNotices(header http.Header, body []byte) []Notice {
+	var notices []Notice
+
+	if v := strings.TrimSpace(header.Get("Deprecation")); v != "" {
+		msg := "endpoint is deprecated"
+		// RFC 9745 uses a structured date ("@<unix seconds>"); older drafts
+		// used "true" or an HTTP date.
+		if strings.HasPrefix(v, "@") {
+			var secs int64
+			if _, err := fmt.Sscanf(v, "@%d", &secs); err == nil {
+				msg = "endpoint deprecated since " + time.Unix(secs, 0).UTC().Format("2006-01-02")
+			}
+		} else if t, err := http.ParseTime(v); err == nil {
+			msg = "endpoint deprecated since " + t.UTC().Format("2006-01-02")
+		}
+		notices = append(notices, Notice{Kind: "deprecation", Message: msg})
+	}
+	if v := strings.TrimSpace(header.Get("Sunset")); v != "" {
+		msg := "endpoint will be removed: " + v
+		if t, err := http.ParseTime(v); err == nil {
+			msg = "endpoint will be removed on " + t.UTC().Format("2006-01-02")
+		}
+		notices = append(notices, Notice{Kind: "sunset", Message: msg})
+	}
+	for _, v := range header.Values("Warning") {
+		// RFC 7234 format: 299 - "message"
+		if i := strings.Index(v, `"`); i >= 0 {
+			if j := strings.LastIndex(v, `"`); j > i {
+				v = v[i+1 : j]
+			}
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			notices = append(notices, Notice{Kind: "warning", Message: v})
+		}
+	}
+
+	var parsed struct {
+		Warnings []json.RawMessage `json:"warnings"`
+		Meta     struct {
+			Warnings []json.RawMessage `json:"warnings"`
+		} `json:"meta"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &parsed) == nil {
+		for _, w := range append(parsed.Warnings, parsed.Meta.Warnings...) {
+			if msg := warningMessage(w); msg != "" {
+				notices = append(notices, Notice{Kind: "warning", Message: msg})
+			}
+		}
+	}
+
+	return notices
+}
+
+// warningMessage renders one body warning, which may be a plain string or
+// an object with a message/detail/title field.
+func warningMessage(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+		Title   string `json:"title"`
+	}
+	if json.Unmarshal(raw, &obj) != nil {
+		return ""
+	}
+	switch {
+	case obj.Message != "":
+		return obj.Message
+	case obj.Detail != "":
+		return obj.Detail
+	default:
+		return obj.Title
+	}
+}
+
+// reportNotices passes a response's notices to the notice hook and prints a
+// one-line yellow summary of them to the client's notice writer, at most once
+// per endpoint per day.
+func (c *ApiClient) reportNotices(resp *http.Response, body []byte) {
+	if c.noticeOut == nil && c.onNotices == nil {
+		return
+	}
+	notices := detectNotices(resp.Header, body)
+	if len(notices) == 0 {
+		return
+	}
+	key := noticeEndpointKey(resp.Request)
+	if c.onNotices != nil {
+		c.onNotices(key, notices)
+	}
+	if c.noticeOut == nil {
+		return
+	}
+	c.noticeMu.Lock()
+	defer c.noticeMu.Unlock()
+	if c.noticeCache == nil {
+		c.noticeCache = store.NewNoticeCache()
+	}
+	if !c.noticeCache.ShouldShow(key, time.Now()) {
+		return
+	}
+	printNotices(c.noticeOut, key, notices)
+}
+
+func printNotices(w io.Writer, endpoint string, notices []Notice) {
+	msgs := make([]string, len(notices))
+	for i, n := range notices {
+		msgs[i] = n.Message
+	}
+	fmt.Fprintf(w, "\033[33mNotice: %s: %s (silence with --no-warnings)\033[0m\n", endpoint, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+func noticeTestClient(t *testing.T, handler http.HandlerFunc) (*ApiClient, *bytes.Buffer) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	authMock, tempDir := createMockAuth(t)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	var out bytes.Buffer
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).WithNoticeWriter(&out)
+	client.noticeCache = store.NewNoticeCacheWithPath(filepath.Join(t.TempDir(), "notices.yml"))
+	return client, &out
+}
+
+func TestDeprecationNoticeHeaders(t *testing.T) {
+	client, out := noticeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/2/old") {
+			w.Header().Set("Deprecation", "@1767225600")
+			w.Header().Set("Sunset", "Tue, 01 Dec 2026 00:00:00 GMT")
+		}
+		w.Write([]byte(`{"data":{}}`))
+	})
+
+	_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/old/123?x=1"})
+	require.NoError(t, err)
+	line := out.String()
+	assert.Contains(t, line, "GET /2/old/:id")
+	assert.Contains(t, line, "deprecated since 2026-01-01")
+	assert.Contains(t, line, "removed on 2026-12-01")
+	assert.Equal(t, 1, strings.Count(line, "\n"), "notices are a single line")
+
+	// Same endpoint (different id) on the same day: not repeated.
+	out.Reset()
+	_, err = client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/old/456"})
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	// Responses without notices print nothing.
+	_, err = client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+}
+
+func TestWarningNoticesFromBodyAndWarningHeader(t *testing.T) {
+	client, out := noticeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Warning", `299 - "field foo is deprecated"`)
+		w.Write([]byte(`{"data":{},"meta":{"warnings":[{"message":"use bar instead"}]},"warnings":["plain"]}`))
+	})
+
+	_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/things"})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "field foo is deprecated; plain; use bar instead")
+}
+
+func TestNoticesSilencedWithNilWriter(t *testing.T) {
+	client, out := noticeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sunset", "Tue, 01 Dec 2026 00:00:00 GMT")
+		w.Write([]byte(`{}`))
+	})
+	client.WithNoticeWriter(nil)
+
+	_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/old"})
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+}
+
+func TestNoticeHookSeesEveryResponse(t *testing.T) {
+	client, _ := noticeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sunset", "Tue, 01 Dec 2026 00:00:00 GMT")
+		w.Write([]byte(`{}`))
+	})
+	var endpoints []string
+	var got []Notice
+	client.WithNoticeWriter(nil).WithNoticeHook(func(endpoint string, notices []Notice) {
+		endpoints = append(endpoints, endpoint)
+		got = append(got, notices...)
+	})
+
+	for _, id := range []string{"1", "2"} {
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/old/" + id})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"GET /2/old/:id", "GET /2/old/:id"}, endpoints, "not limited to once a day")
+	assert.Equal(t, []Notice{
+		{Kind: "sunset", Message: "endpoint will be removed on 2026-12-01"},
+		{Kind: "sunset", Message: "endpoint will be removed on 2026-12-01"},
+	}, got)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// pageEndpoint returns the endpoint of the next page of a paginated request:
+// remaining is how many more items are wanted and nextToken the previous
+// page's meta.next_token ("" for the first page).
+type pageEndpoint func(remaining int, nextToken string) string
+
+// walkPages follows next_token pagination, handing onPage each page's data
+// and includes, until max items were seen, the last page has no next_token,
+// or a page is empty. It returns the last page's next_token. In a dry run it
+// stops at the first request and returns its description instead.
+func walkPages(client Client, opts RequestOptions, max int, endpoint pageEndpoint, onPage func(items []json.RawMessage, includes map[string][]json.RawMessage)) (nextToken string, dryRun json.RawMessage, err error) {
+	if max < 1 {
+		return "", nil, fmt.Errorf("max must be at least 1")
+	}
+
+	count := 0
+	pages := 0
+	for count < max {
+		opts.Method = "GET"
+		opts.Endpoint = endpoint(max-count, nextToken)
+		opts.Data = ""
+
+		resp, err := client.SendRequest(opts)
+		if err != nil {
+			return "", nil, err
+		}
+		if IsDryRun(resp) {
+			return "", resp, nil
+		}
+		pages++
+		reportProgress(opts.Progress, ProgressEvent{Phase: ProgressPhasePage, Page: pages})
+
+		var page struct {
+			Data     []json.RawMessage            `json:"data"`
+			Includes map[string][]json.RawMessage `json:"includes"`
+			Meta     struct {
+				NextToken string `json:"next_token"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return "", nil, fmt.Errorf("could not parse page %d: %w", pages, err)
+		}
+
+		// The API's per-page minimum can overshoot the last page.
+		if len(page.Data) > max-count {
+			page.Data = page.Data[:max-count]
+		}
+		count += len(page.Data)
+		onPage(page.Data, page.Includes)
+
+		nextToken = page.Meta.NextToken
+		if nextToken == "" || len(page.Data) == 0 {
+			break
+		}
+	}
+	return nextToken, nil, nil
+}
+
+// collectPages walks pages like walkPages and merges them into a single
+// {"data", "includes", "meta"} document; meta.next_token is the token of the
+// last page fetched, if there are more.
+func collectPages(client Client, opts RequestOptions, max int, endpoint pageEndpoint) (json.RawMessage, error) {
+	var items []json.RawMessage
+	includes := map[string][]json.RawMessage{}
+	nextToken, dryRun, err := walkPages(client, opts, max, endpoint, func(page []json.RawMessage, pageIncludes map[string][]json.RawMessage) {
+		items = append(items, page...)
+		for k, v := range pageIncludes {
+			includes[k] = append(includes[k], v...)
+		}
+	})
+	if err != nil || dryRun != nil {
+		return dryRun, err
+	}
+
+	type meta struct {
+		ResultCount int    `json:"result_count"`
+		NextToken   string `json:"next_token,omitempty"`
+	}
+	out := struct {
+		Data     []json.RawMessage            `json:"data"`
+		Includes map[string][]json.RawMessage `json:"includes,omitempty"`
+		Meta     meta                         `json:"meta"`
+	}{
+		Data: items,
+		Meta: meta{ResultCount: len(items), NextToken: nextToken},
+	}
+	if out.Data == nil {
+		out.Data = []json.RawMessage{}
+	}
+	if len(includes) > 0 {
+		out.Includes = includes
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// PaginationState is how far a paginated GET request has got: the request it
+// belongs to, the next_token of the last page fetched, and how many pages and
+// items were fetched. It is kept in a --resume-file between runs.
+type PaginationState struct {
+	Endpoint  string `json:"endpoint"`
+	Query     string `json:"query,omitempty"`
+	NextToken string `json:"next_token,omitempty"`
+	Pages     int    `json:"pages"`
+	Items     int    `json:"items"`
+	// Complete is set once the last page was fetched.
+	Complete bool `json:"complete,omitempty"`
+	// Truncated says why a complete pagination may have missed results:
+	// it hit the endpoint's result ceiling, or the endpoint kept leaving
+	// next_token off a full page.
+	Truncated string `json:"truncated,omitempty"`
+}
+
+// NewPaginationState returns the state of a pagination of endpoint that has
+// not started. Any pagination token already on endpoint is dropped.
+func NewPaginationState(endpoint string) (*PaginationState, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+	q := u.Query()
+	q.Del(paginationRules(u.Path).TokenParam)
+	u.RawQuery = ""
+	return &PaginationState{Endpoint: u.String(), Query: q.Encode()}, nil
+}
+
+// SameRequest reports whether s and other paginate the same request.
+func (s *PaginationState) SameRequest(other *PaginationState) bool {
+	return s.Endpoint == other.Endpoint && s.Query == other.Query
+}
+
+// nextEndpoint returns the endpoint of the page after the last one fetched.
+func (s *PaginationState) nextEndpoint() string {
+	u, _ := url.Parse(s.Endpoint)
+	q, _ := url.ParseQuery(s.Query)
+	if s.NextToken != "" {
+		q.Set(paginationRules(u.Path).TokenParam, s.NextToken)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// pageSize returns how many items a full page of s holds: its max_results,
+// or the endpoint's default page size.
+func (s *PaginationState) pageSize(rules Pagination) int {
+	q, _ := url.ParseQuery(s.Query)
+	if n, err := strconv.Atoi(q.Get("max_results")); err == nil && n > 0 {
+		return n
+	}
+	return rules.PageSize
+}
+
+// paginationRules returns the catalog's Pagination for GET requests to path,
+// preferring an exact match over one through a path parameter. Endpoints
+// the catalog does not know page with pagination_token.
+func paginationRules(path string) Pagination {
+	path = strings.TrimSuffix(path, "/")
+	segments := strings.Split(path, "/")
+	var match *Pagination
+	for _, e := range catalog {
+		if e.Method != "GET" || e.Pagination == nil {
+			continue
+		}
+		if e.Path == path {
+			return *e.Pagination
+		}
+		if match == nil && matchEndpointPattern(strings.Split(e.Path, "/"), segments) {
+			match = e.Pagination
+		}
+	}
+	if match == nil {
+		return *byPaginationToken
+	}
+	return *match
+}
+
+// LoadPaginationState reads the state saved in path by SavePaginationState.
+// A missing file is not an error: it returns nil, meaning nothing to resume.
+func LoadPaginationState(path string) (*PaginationState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state PaginationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("could not parse resume file %s: %v", path, err)
+	}
+	return &state, nil
+}
+
+// SavePaginationState writes state to path, replacing the file in one rename
+// so an interrupted write never leaves a torn cursor behind.
+func SavePaginationState(path string, state *PaginationState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// RateLimitPause makes Paginate wait out rate limits instead of stopping at
+// them: it sleeps until the limit resets, as RetryAfter allows, and then
+// asks for the same page again.
+type RateLimitPause struct {
+	RetryAfter RetryAfterPolicy
+	// Countdown, when set, is called with the time left before the retry
+	// when the wait starts and then about once a second.
+	Countdown func(left time.Duration)
+}
+
+// wait sleeps for the delay err calls for on the given zero-based attempt,
+// counting down.
+func (p *RateLimitPause) wait(err error, attempt int) {
+	left := p.RetryAfter.Delay(err, attempt, time.Now())
+	for left > 0 {
+		if p.Countdown != nil {
+			p.Countdown(left)
+		}
+		step := min(left, time.Second)
+		sleep(step)
+		left -= step
+	}
+	if p.Countdown != nil {
+		p.Countdown(0)
+	}
+}
+
+// Paginate fetches the pages of a GET request one at a time, starting after
+// the last page recorded in state. Each page's data goes to onPage as soon
+// as it arrives; state is then advanced and handed to checkpoint, so a
+// checkpoint saved after a page has been emitted never replays it. It stops
+// after a page without a next_token, marking state Complete. In a dry run
+// onPage receives the description of the first request instead.
+//
+// With maxItems above zero it also stops, marking state Complete, once
+// state.Items reaches maxItems, cutting the last page short so exactly
+// maxItems items are emitted over the whole pagination, resumed runs
+// included.
+//
+// The endpoint's catalog Pagination is followed: pagination stops, with
+// state.Truncated saying so, at its result ceiling, and a full page without
+// a next_token is asked for again up to TokenlessRetries times before it is
+// taken as the last one. An empty page always ends the pagination, even
+// with a next_token.
+//
+// With pause set, a rate limit is waited out (see RateLimitPause) and the
+// same page is asked for again. Without it, a rate-limit error is returned
+// unchanged, with state still pointing at the page that could not be
+// fetched, so the pagination can be resumed later. Other failures are
+// reported like any other request.
+func Paginate(client Client, opts RequestOptions, state *PaginationState, maxItems int, pause *RateLimitPause, onPage func(items []json.RawMessage) error, checkpoint func(state *PaginationState) error) error {
+	u, _ := url.Parse(state.Endpoint)
+	rules := paginationRules(u.Path)
+	pageSize := state.pageSize(rules)
+	limited, tokenless := 0, 0
+	for !state.Complete {
+		if maxItems > 0 && state.Items >= maxItems {
+			state.Complete = true
+			return checkpoint(state)
+		}
+		opts.Method = "GET"
+		opts.Endpoint = state.nextEndpoint()
+		opts.Data = ""
+
+		resp, err := client.SendRequest(opts)
+		if err != nil {
+			if !xurlErrors.IsRateLimitError(err) {
+				return handleRequestError(err, opts)
+			}
+			if pause == nil {
+				return err
+			}
+			pause.wait(err, limited)
+			limited++
+			continue
+		}
+		limited = 0
+		if IsDryRun(resp) {
+			return onPage([]json.RawMessage{resp})
+		}
+
+		var page struct {
+			Data []json.RawMessage `json:"data"`
+			Meta struct {
+				NextToken string `json:"next_token"`
+			} `json:"meta"`
+		}
+		if !IsNoContent(resp) {
+			if err := json.Unmarshal(resp, &page); err != nil {
+				return fmt.Errorf("could not parse page %d: %w", state.Pages+1, err)
+			}
+		}
+		full := pageSize > 0 && len(page.Data) >= pageSize
+		ceiling := rules.ResultCeiling > 0 && state.Items+len(page.Data) >= rules.ResultCeiling
+		if page.Meta.NextToken == "" && full && !ceiling && tokenless < rules.TokenlessRetries {
+			tokenless++
+			continue
+		}
+
+		capped := maxItems > 0 && state.Items+len(page.Data) >= maxItems
+		if capped {
+			page.Data = page.Data[:maxItems-state.Items]
+		}
+		if err := onPage(page.Data); err != nil {
+			return err
+		}
+
+		state.Pages++
+		state.Items += len(page.Data)
+		state.NextToken = page.Meta.NextToken
+		state.Complete = capped || ceiling || state.NextToken == "" || len(page.Data) == 0
+		switch {
+		case capped:
+		case ceiling:
+			state.Truncated = fmt.Sprintf("%s returns at most %d results; stopped there", u.Path, rules.ResultCeiling)
+		case state.NextToken == "" && full && tokenless > 0:
+			state.Truncated = fmt.Sprintf("%s sent a full page without a next_token %d times; there may be more results", u.Path, tokenless+1)
+		}
+		tokenless = 0
+		reportProgress(opts.Progress, ProgressEvent{Phase: ProgressPhasePage, Page: state.Pages})
+		if err := checkpoint(state); err != nil {
+			return fmt.Errorf("could not save the pagination cursor: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// followersServer serves pages of two followers each, 1..total, paging with
+// pagination_token. The page numbers in limitedPages answer 429 once, with
+// the quota used up until reset.
+func followersServer(t *testing.T, total int, reset time.Time, limitedPages ...int) *httptest.Server {
+	t.Helper()
+	limited := map[int]bool{}
+	for _, p := range limitedPages {
+		limited[p] = true
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1000", r.URL.Query().Get("max_results"))
+		page := 1
+		if token := r.URL.Query().Get("pagination_token"); token != "" {
+			page, _ = strconv.Atoi(token)
+		}
+		if limited[page] {
+			delete(limited, page)
+			w.Header().Set("x-rate-limit-remaining", "0")
+			w.Header().Set("x-rate-limit-reset", strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"title":"Too Many Requests"}`))
+			return
+		}
+		var data []map[string]string
+		for id := page*2 - 1; id <= page*2 && id <= total; id++ {
+			data = append(data, map[string]string{"id": strconv.Itoa(id)})
+		}
+		meta := map[string]any{"result_count": len(data)}
+		if page*2 < total {
+			meta["next_token"] = strconv.Itoa(page + 1)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data, "meta": meta})
+	}))
+}
+
+func TestPaginateResumesAfterRateLimit(t *testing.T) {
+	server := followersServer(t, 9, time.Now().Add(time.Hour), 3)
+	defer server.Close()
+	client := shortcutClient(t, server)
+	resumeFile := filepath.Join(t.TempDir(), "state.json")
+
+	var ids []string
+	onPage := func(items []json.RawMessage) error {
+		for _, item := range items {
+			var user struct{ ID string }
+			require.NoError(t, json.Unmarshal(item, &user))
+			ids = append(ids, user.ID)
+		}
+		return nil
+	}
+	checkpoint := func(state *PaginationState) error { return SavePaginationState(resumeFile, state) }
+
+	state, err := NewPaginationState("/2/users/42/followers?max_results=1000")
+	require.NoError(t, err)
+	err = Paginate(client, baseTestOpts(), state, 0, nil, onPage, checkpoint)
+	require.True(t, xurlErrors.IsRateLimitError(err), "got %v", err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+
+	// A new invocation starts from the saved cursor.
+	saved, err := LoadPaginationState(resumeFile)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.True(t, saved.SameRequest(state))
+	assert.Equal(t, "3", saved.NextToken)
+	assert.Equal(t, 2, saved.Pages)
+	assert.Equal(t, 4, saved.Items)
+	assert.False(t, saved.Complete)
+
+	require.NoError(t, Paginate(client, baseTestOpts(), saved, 0, nil, onPage, checkpoint))
+	var want []string
+	for id := 1; id <= 9; id++ {
+		want = append(want, strconv.Itoa(id))
+	}
+	assert.Equal(t, want, ids, "no duplicates or gaps across the interruption")
+
+	done, err := LoadPaginationState(resumeFile)
+	require.NoError(t, err)
+	assert.True(t, done.Complete)
+	assert.Equal(t, 5, done.Pages)
+	assert.Equal(t, 9, done.Items)
+}
+
+func TestPaginateMaxItems(t *testing.T) {
+	requests := 0
+	server := followersServer(t, 9, time.Now())
+	defer server.Close()
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+
+	var ids []string
+	state, err := NewPaginationState("/2/users/42/followers?max_results=1000")
+	require.NoError(t, err)
+	err = Paginate(shortcutClient(t, counting), baseTestOpts(), state, 5, nil, func(items []json.RawMessage) error {
+		for _, item := range items {
+			var user struct{ ID string }
+			require.NoError(t, json.Unmarshal(item, &user))
+			ids = append(ids, user.ID)
+		}
+		return nil
+	}, func(*PaginationState) error { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, ids, "the third page is cut short")
+	assert.Equal(t, 3, requests, "no page is fetched after the cap")
+	assert.Equal(t, 5, state.Items)
+	assert.True(t, state.Complete)
+
+	// A resumed pagination that already reached the cap fetches nothing.
+	resumed := &PaginationState{Endpoint: state.Endpoint, Query: state.Query, NextToken: "2", Pages: 1, Items: 2}
+	require.NoError(t, Paginate(shortcutClient(t, counting), baseTestOpts(), resumed, 2, nil, func([]json.RawMessage) error {
+		t.Fatal("no page expected")
+		return nil
+	}, func(*PaginationState) error { return nil }))
+	assert.True(t, resumed.Complete)
+	assert.Equal(t, 3, requests)
+}
+
+func TestPaginateWaitsOutRateLimit(t *testing.T) {
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	var slept time.Duration
+	sleep = func(d time.Duration) { slept += d }
+
+	server := followersServer(t, 6, time.Now().Add(3*time.Second), 2)
+	defer server.Close()
+
+	var ids []string
+	var countdown []time.Duration
+	pause := &RateLimitPause{Countdown: func(left time.Duration) { countdown = append(countdown, left) }}
+	state, err := NewPaginationState("/2/users/42/followers?max_results=1000")
+	require.NoError(t, err)
+	err = Paginate(shortcutClient(t, server), baseTestOpts(), state, 0, pause, func(items []json.RawMessage) error {
+		for _, item := range items {
+			var user struct{ ID string }
+			require.NoError(t, json.Unmarshal(item, &user))
+			ids = append(ids, user.ID)
+		}
+		return nil
+	}, func(*PaginationState) error { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5", "6"}, ids, "the limited page is fetched again after the reset")
+	assert.True(t, state.Complete)
+	assert.Equal(t, 3, state.Pages)
+
+	// The wait lasts until the reset, counting down a second at a time.
+	assert.InDelta(t, 3*time.Second, slept, float64(time.Second))
+	require.GreaterOrEqual(t, len(countdown), 3)
+	assert.Equal(t, time.Duration(0), countdown[len(countdown)-1])
+	for i := 1; i < len(countdown); i++ {
+		assert.Less(t, countdown[i], countdown[i-1])
+	}
+}
+
+func TestPaginationState(t *testing.T) {
+	t.Run("token on the endpoint is dropped", func(t *testing.T) {
+		state, err := NewPaginationState("/2/users/42/followers?pagination_token=abc&max_results=10")
+		require.NoError(t, err)
+		assert.Equal(t, "/2/users/42/followers", state.Endpoint)
+		assert.Equal(t, "max_results=10", state.Query)
+		assert.Equal(t, "/2/users/42/followers?max_results=10", state.nextEndpoint())
+
+		state.NextToken = "def"
+		assert.Equal(t, "/2/users/42/followers?max_results=10&pagination_token=def", state.nextEndpoint())
+	})
+
+	t.Run("search endpoints page with next_token", func(t *testing.T) {
+		state, err := NewPaginationState("/2/tweets/search/recent?query=go&next_token=abc")
+		require.NoError(t, err)
+		state.NextToken = "def"
+		assert.Equal(t, "/2/tweets/search/recent?next_token=def&query=go", state.nextEndpoint())
+	})
+
+	t.Run("different requests do not match", func(t *testing.T) {
+		a, _ := NewPaginationState("/2/users/42/followers?max_results=10")
+		b, _ := NewPaginationState("/2/users/42/following?max_results=10")
+		c, _ := NewPaginationState("/2/users/42/followers?max_results=10&pagination_token=x")
+		assert.False(t, a.SameRequest(b))
+		assert.True(t, a.SameRequest(c))
+	})
+
+	t.Run("missing resume file", func(t *testing.T) {
+		state, err := LoadPaginationState(filepath.Join(t.TempDir(), "none.json"))
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+}
+
+func TestPaginateReportsOtherErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"title":"Forbidden"}`)
+	}))
+	defer server.Close()
+
+	state, err := NewPaginationState("/2/users/42/followers")
+	require.NoError(t, err)
+	opts := baseTestOpts()
+	opts.HideErrorBody = true
+	err = Paginate(shortcutClient(t, server), opts, state, 0, nil, func([]json.RawMessage) error { return nil }, func(*PaginationState) error { return nil })
+	require.Error(t, err)
+	assert.False(t, xurlErrors.IsRateLimitError(err))
+	assert.Equal(t, 0, state.Pages)
+}
+
+// likingUsersServer serves the users who liked post 7, 1..total, in pages of
+// max_results, paging with pagination_token and always sending a next_token
+// like the API past its result ceiling. A page number in tokenless leaves
+// next_token out that many times before sending it.
+func likingUsersServer(t *testing.T, total int, tokenless map[int]int, requests *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/tweets/7/liking_users", r.URL.Path)
+		*requests++
+		size, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		page := 1
+		if token := r.URL.Query().Get("pagination_token"); token != "" {
+			page, _ = strconv.Atoi(token)
+		}
+		data := []map[string]string{}
+		for id := (page-1)*size + 1; id <= page*size && id <= total; id++ {
+			data = append(data, map[string]string{"id": strconv.Itoa(id)})
+		}
+		meta := map[string]any{"result_count": len(data), "next_token": strconv.Itoa(page + 1)}
+		if tokenless[page] > 0 {
+			tokenless[page]--
+			delete(meta, "next_token")
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data, "meta": meta})
+	}))
+}
+
+func paginateIDs(t *testing.T, server *httptest.Server, endpoint string) ([]string, *PaginationState) {
+	t.Helper()
+	state, err := NewPaginationState(endpoint)
+	require.NoError(t, err)
+	var ids []string
+	err = Paginate(shortcutClient(t, server), baseTestOpts(), state, 0, nil, func(items []json.RawMessage) error {
+		for _, item := range items {
+			var user struct{ ID string }
+			require.NoError(t, json.Unmarshal(item, &user))
+			ids = append(ids, user.ID)
+		}
+		return nil
+	}, func(*PaginationState) error { return nil })
+	require.NoError(t, err)
+	return ids, state
+}
+
+func TestPaginateEngagingUsersQuirks(t *testing.T) {
+	t.Run("stops at the result ceiling", func(t *testing.T) {
+		requests := 0
+		server := likingUsersServer(t, 150, nil, &requests)
+		defer server.Close()
+
+		ids, state := paginateIDs(t, server, "/2/tweets/7/liking_users?max_results=40")
+		assert.Len(t, ids, 120, "the page that crosses the ceiling is kept whole")
+		assert.Equal(t, 3, requests, "no page is asked for past the ceiling")
+		assert.True(t, state.Complete)
+		assert.Contains(t, state.Truncated, "at most 100 results")
+	})
+
+	t.Run("a full page without a token is asked for again", func(t *testing.T) {
+		requests := 0
+		server := likingUsersServer(t, 25, map[int]int{2: 1}, &requests)
+		defer server.Close()
+
+		ids, state := paginateIDs(t, server, "/2/tweets/7/liking_users?max_results=10")
+		var want []string
+		for id := 1; id <= 25; id++ {
+			want = append(want, strconv.Itoa(id))
+		}
+		assert.Equal(t, want, ids, "no duplicates or gaps")
+		assert.Equal(t, 5, requests)
+		assert.True(t, state.Complete, "the short last page ends it")
+		assert.Empty(t, state.Truncated)
+	})
+
+	t.Run("a token that never comes ends it with a warning", func(t *testing.T) {
+		requests := 0
+		server := likingUsersServer(t, 50, map[int]int{2: 5}, &requests)
+		defer server.Close()
+
+		ids, state := paginateIDs(t, server, "/2/tweets/7/liking_users?max_results=10")
+		assert.Len(t, ids, 20)
+		assert.Equal(t, 4, requests, "the page is retried twice")
+		assert.True(t, state.Complete)
+		assert.Contains(t, state.Truncated, "without a next_token 3 times")
+	})
+
+	t.Run("an empty page with a token ends it", func(t *testing.T) {
+		requests := 0
+		server := likingUsersServer(t, 20, nil, &requests)
+		defer server.Close()
+
+		ids, state := paginateIDs(t, server, "/2/tweets/7/liking_users?max_results=10")
+		assert.Len(t, ids, 20)
+		assert.Equal(t, 3, requests)
+		assert.True(t, state.Complete)
+		assert.Empty(t, state.Truncated)
+	})
+
+	t.Run("other endpoints take a tokenless page as the last", func(t *testing.T) {
+		server := followersServer(t, 4, time.Now())
+		defer server.Close()
+
+		ids, state := paginateIDs(t, server, "/2/users/42/followers?max_results=1000")
+		assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+		assert.Empty(t, state.Truncated)
+	})
+}
+
+func TestPaginationRules(t *testing.T) {
+	assert.Equal(t, "next_token", paginationRules("/2/tweets/search/recent").TokenParam)
+	assert.Equal(t, "next_token", paginationRules("/2/tweets/counts/all/").TokenParam)
+	assert.Equal(t, "pagination_token", paginationRules("/2/users/42/followers").TokenParam)
+	assert.Equal(t, "pagination_token", paginationRules("/2/not/in/the/catalog").TokenParam)
+
+	rules := paginationRules("/2/tweets/7/retweeted_by")
+	assert.Equal(t, 100, rules.ResultCeiling)
+	assert.Equal(t, 2, rules.TokenlessRetries)
+	assert.Zero(t, paginationRules("/2/dm_events").ResultCeiling)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// ProcessingWait bounds how long a status wait (media processing, compliance
+// jobs) keeps polling. Status requests that fail transiently (a 5xx or
+// network error) are retried after the server's Retry-After, as RetryAfter
+// allows, or with exponential backoff, until MaxFailures fail in a row.
+// Timeout limits the whole wait; zero means no limit.
+type ProcessingWait struct {
+	Timeout     time.Duration
+	MaxFailures int
+	RetryAfter  RetryAfterPolicy
+}
+
+// DefaultStatusFailures is how many status requests in a row may fail
+// transiently before a wait gives up.
+const DefaultStatusFailures = 5
+
+// sleep is swapped out by tests so waits do not.
+var sleep = time.Sleep
+
+// poll calls check until it reports done or fails. After each check that is
+// not done it sleeps for the returned interval. A transient error from check
+// is retried within w's limits, calling onRetry with the delay first; any
+// other error ends the wait.
+func (w ProcessingWait) poll(check func() (done bool, next time.Duration, err error), onRetry func(delay time.Duration)) error {
+	maxFailures := w.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultStatusFailures
+	}
+	var deadline time.Time
+	if w.Timeout > 0 {
+		deadline = time.Now().Add(w.Timeout)
+	}
+	failures := 0
+
+	for {
+		done, next, err := check()
+		if err != nil {
+			if !xurlErrors.IsTransientError(err) {
+				return err
+			}
+			failures++
+			if failures >= maxFailures {
+				return fmt.Errorf("giving up after %d failed status checks: %w", failures, err)
+			}
+			now := time.Now()
+			delay := w.RetryAfter.Delay(err, failures-1, now)
+			if !deadline.IsZero() && now.Add(delay).After(deadline) {
+				return fmt.Errorf("processing timeout of %s reached: %w", w.Timeout, err)
+			}
+			if onRetry != nil {
+				onRetry(delay)
+			}
+			sleep(delay)
+			continue
+		}
+		failures = 0
+		if done {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().Add(next).After(deadline) {
+			return fmt.Errorf("processing timeout of %s reached before processing finished", w.Timeout)
+		}
+		sleep(next)
+	}
+}
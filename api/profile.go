@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Profile changes are only possible through v1.1 endpoints, which need
+// OAuth1; the result is read back through v2.
+const (
+	updateProfileEndpoint       = "/1.1/account/update_profile.json"
+	updateProfileImageEndpoint  = "/1.1/account/update_profile_image.json"
+	updateProfileBannerEndpoint = "/1.1/account/update_profile_banner.json"
+	profileEndpoint             = "/2/users/me?user.fields=description,location,url,profile_image_url,profile_banner_url"
+)
+
+// Largest profile image and banner files the v1.1 endpoints accept.
+const (
+	MaxProfileImageSize  = 700 * 1024
+	MaxProfileBannerSize = 5 * 1024 * 1024
+)
+
+// ProfileFields are the profile settings update_profile accepts, keyed by
+// the flag that sets them in `xurl profile update`.
+var ProfileFields = map[string]string{
+	"name":     "name",
+	"bio":      "description",
+	"location": "location",
+	"url":      "url",
+}
+
+// BannerCrop is the region of a banner image to use, in pixels.
+type BannerCrop struct {
+	Width, Height, Left, Top int
+}
+
+var bannerCropPattern = regexp.MustCompile(`^(\d+)x(\d+)\+(\d+)\+(\d+)$`)
+
+// ParseBannerCrop parses a crop in the WxH+X+Y geometry of ImageMagick, e.g.
+// 1500x500+0+120.
+func ParseBannerCrop(s string) (BannerCrop, error) {
+	m := bannerCropPattern.FindStringSubmatch(s)
+	if m == nil {
+		return BannerCrop{}, fmt.Errorf("invalid crop %q: expected WxH+X+Y, e.g. 1500x500+0+0", s)
+	}
+	var n [4]int
+	for i := range n {
+		v, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return BannerCrop{}, fmt.Errorf("invalid crop %q: %v", s, err)
+		}
+		n[i] = v
+	}
+	if n[0] == 0 || n[1] == 0 {
+		return BannerCrop{}, fmt.Errorf("invalid crop %q: width and height must not be zero", s)
+	}
+	return BannerCrop{Width: n[0], Height: n[1], Left: n[2], Top: n[3]}, nil
+}
+
+// formFields returns c as the form fields of update_profile_banner.
+func (c BannerCrop) formFields() map[string]string {
+	return map[string]string{
+		"width":       strconv.Itoa(c.Width),
+		"height":      strconv.Itoa(c.Height),
+		"offset_left": strconv.Itoa(c.Left),
+		"offset_top":  strconv.Itoa(c.Top),
+	}
+}
+
+// GetProfile fetches the authenticated user's profile, with the fields the
+// profile helpers change.
+func GetProfile(client Client, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = profileEndpoint
+	opts.Data = ""
+	return client.SendRequest(opts)
+}
+
+// UpdateProfile sets the given update_profile fields (name, description,
+// location, url) with a form-encoded POST, signed with OAuth1, and returns
+// the profile as fetched afterwards. An empty value clears a field. In a dry
+// run the update request is returned as it is.
+func UpdateProfile(client Client, fields url.Values, opts RequestOptions) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("nothing to update: set at least one of --name, --bio, --location or --url")
+	}
+	form := url.Values{"skip_status": {"true"}}
+	for key, values := range fields {
+		form[key] = values
+	}
+	opts.AuthType = "oauth1"
+	opts.Method = "POST"
+	opts.Endpoint = updateProfileEndpoint
+	opts.Data = form.Encode()
+	return sendAndConfirmProfile(client, opts, func() (json.RawMessage, error) {
+		return client.SendRequest(opts)
+	})
+}
+
+// SetProfileImage uploads the image at path, base64-encoded in a
+// form-encoded POST as update_profile_image requires, and returns the
+// profile as fetched afterwards.
+func SetProfileImage(client Client, path string, opts RequestOptions) (json.RawMessage, error) {
+	image, err := readProfileFile(path, MaxProfileImageSize, "profile image")
+	if err != nil {
+		return nil, err
+	}
+	opts.AuthType = "oauth1"
+	opts.Method = "POST"
+	opts.Endpoint = updateProfileImageEndpoint
+	opts.Data = url.Values{
+		"image":       {base64.StdEncoding.EncodeToString(image)},
+		"skip_status": {"true"},
+	}.Encode()
+	return sendAndConfirmProfile(client, opts, func() (json.RawMessage, error) {
+		return client.SendRequest(opts)
+	})
+}
+
+// SetProfileBanner uploads the image at path as a multipart POST to
+// update_profile_banner, cropped to crop when it is set, and returns the
+// profile as fetched afterwards.
+func SetProfileBanner(client Client, path string, crop *BannerCrop, opts RequestOptions) (json.RawMessage, error) {
+	banner, err := readProfileFile(path, MaxProfileBannerSize, "banner")
+	if err != nil {
+		return nil, err
+	}
+	opts.AuthType = "oauth1"
+	opts.Method = "POST"
+	opts.Endpoint = updateProfileBannerEndpoint
+	opts.Data = ""
+	multipart := MultipartOptions{
+		RequestOptions: opts,
+		FileField:      "banner",
+		FileName:       filepath.Base(path),
+		FileData:       banner,
+	}
+	if crop != nil {
+		multipart.FormFields = crop.formFields()
+	}
+	return sendAndConfirmProfile(client, opts, func() (json.RawMessage, error) {
+		return client.SendMultipartRequest(multipart)
+	})
+}
+
+// readProfileFile reads an image to upload as what, failing before anything
+// is sent when it is larger than max bytes.
+func readProfileFile(path string, max int64, what string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing file: %v", err)
+	}
+	if info.Size() > max {
+		return nil, fmt.Errorf("%s %s is %d bytes; X accepts at most %d", what, path, info.Size(), max)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	return data, nil
+}
+
+// sendAndConfirmProfile sends a profile change and fetches the profile to
+// confirm it. In a dry run the change is returned as it is.
+func sendAndConfirmProfile(client Client, opts RequestOptions, send func() (json.RawMessage, error)) (json.RawMessage, error) {
+	resp, err := send()
+	if err != nil || IsDryRun(resp) {
+		return resp, err
+	}
+	profile, err := GetProfile(client, opts)
+	if err != nil {
+		return nil, fmt.Errorf("the profile was updated, but fetching it back failed: %w", err)
+	}
+	return profile, nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+)
+
+// profileRequest is a request received by profileServer.
+type profileRequest struct {
+	method, path, authorization string
+	form                        url.Values
+	banner                      []byte
+}
+
+// profileServer returns a client whose OAuth1-signed requests go to a server
+// that records them, answering the v1.1 endpoints as X does and
+// /2/users/me with a profile.
+func profileServer(t *testing.T, got *[]profileRequest) *ApiClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := profileRequest{method: r.Method, path: r.URL.Path, authorization: r.Header.Get("Authorization")}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			req.form = r.MultipartForm.Value
+			if f, _, err := r.FormFile("banner"); err == nil {
+				req.banner, _ = io.ReadAll(f)
+				f.Close()
+			}
+		} else {
+			require.NoError(t, r.ParseForm())
+			req.form = r.PostForm
+		}
+		*got = append(*got, req)
+		switch r.URL.Path {
+		case "/2/users/me":
+			w.Write([]byte(`{"data":{"id":"42","name":"Release Bot","username":"releasebot"}}`))
+		case updateProfileBannerEndpoint:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.Write([]byte(`{"id_str":"42"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tokenStore, tempDir := createTempTokenStore(t)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+	require.NoError(t, tokenStore.SaveOAuth1Tokens("token", "secret", "key", "consumer-secret"))
+	return NewApiClient(&config.Config{APIBaseURL: server.URL}, auth.NewAuth(&config.Config{}).WithTokenStore(tokenStore))
+}
+
+func writeImage(t *testing.T, size int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "image.png")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644))
+	return path
+}
+
+func TestUpdateProfile(t *testing.T) {
+	var got []profileRequest
+	client := profileServer(t, &got)
+
+	resp, err := UpdateProfile(client, url.Values{"name": {"Release Bot"}, "location": {""}}, baseTestOpts())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"42","name":"Release Bot","username":"releasebot"}}`, string(resp), "confirmed from /2/users/me")
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "POST", got[0].method)
+	assert.Equal(t, updateProfileEndpoint, got[0].path)
+	assert.True(t, strings.HasPrefix(got[0].authorization, "OAuth "))
+	assert.Equal(t, url.Values{"name": {"Release Bot"}, "location": {""}, "skip_status": {"true"}}, got[0].form)
+	assert.Equal(t, "GET /2/users/me", got[1].method+" "+got[1].path)
+	assert.True(t, strings.HasPrefix(got[1].authorization, "OAuth "))
+
+	_, err = UpdateProfile(client, url.Values{}, baseTestOpts())
+	assert.ErrorContains(t, err, "nothing to update")
+}
+
+func TestSetProfileImage(t *testing.T) {
+	var got []profileRequest
+	client := profileServer(t, &got)
+
+	_, err := SetProfileImage(client, writeImage(t, 10), baseTestOpts())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, updateProfileImageEndpoint, got[0].path)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("xxxxxxxxxx")), got[0].form.Get("image"))
+	assert.Equal(t, "/2/users/me", got[1].path)
+
+	got = nil
+	_, err = SetProfileImage(client, writeImage(t, MaxProfileImageSize+1), baseTestOpts())
+	assert.ErrorContains(t, err, "X accepts at most 716800")
+	assert.Empty(t, got, "nothing is sent")
+}
+
+func TestSetProfileBanner(t *testing.T) {
+	var got []profileRequest
+	client := profileServer(t, &got)
+
+	_, err := SetProfileBanner(client, writeImage(t, 5), &BannerCrop{Width: 1500, Height: 500, Left: 0, Top: 240}, baseTestOpts())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, updateProfileBannerEndpoint, got[0].path)
+	assert.Equal(t, []byte("xxxxx"), got[0].banner)
+	assert.Equal(t, url.Values{"width": {"1500"}, "height": {"500"}, "offset_left": {"0"}, "offset_top": {"240"}}, got[0].form)
+	assert.Equal(t, "/2/users/me", got[1].path)
+
+	got = nil
+	opts := baseTestOpts()
+	opts.DryRun = true
+	resp, err := SetProfileBanner(client, writeImage(t, 5), nil, opts)
+	require.NoError(t, err)
+	assert.True(t, IsDryRun(resp))
+	assert.Empty(t, got, "a dry run sends nothing, not even the confirmation")
+}
+
+func TestParseBannerCrop(t *testing.T) {
+	crop, err := ParseBannerCrop("1500x500+10+240")
+	require.NoError(t, err)
+	assert.Equal(t, BannerCrop{Width: 1500, Height: 500, Left: 10, Top: 240}, crop)
+
+	for _, bad := range []string{"", "1500x500", "1500x500+0", "1500x500-0-0", "0x500+0+0", "axb+0+0"} {
+		_, err := ParseBannerCrop(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress phases reported besides the MediaStage values, which media
+// uploads report as their phase.
+const (
+	ProgressPhasePage   = "page"   // a page of a paginated request was fetched (Page)
+	ProgressPhaseStream = "stream" // stream statistics (Items, BytesDone)
+)
+
+// ProgressEvent is one progress update. Its JSON form is the schema printed
+// by --progress json, one event per line; fields that do not apply to a
+// phase are omitted.
+type ProgressEvent struct {
+	Phase      string  `json:"phase"`
+	BytesDone  int64   `json:"bytes_done,omitempty"`
+	BytesTotal int64   `json:"bytes_total,omitempty"`
+	Segment    int     `json:"segment,omitempty"`
+	Percent    float64 `json:"percent,omitempty"`
+	ETASeconds float64 `json:"eta_seconds,omitempty"`
+	Page       int     `json:"page,omitempty"`
+	Items      int     `json:"items,omitempty"`
+	// CheckAfterSeconds is how long until media processing is polled again.
+	CheckAfterSeconds int `json:"check_after_seconds,omitempty"`
+}
+
+// ProgressReporter receives progress events from media uploads, paginated
+// requests and streams, and renders them.
+type ProgressReporter interface {
+	Report(ProgressEvent)
+}
+
+// reportProgress sends e to r, if there is one.
+func reportProgress(r ProgressReporter, e ProgressEvent) {
+	if r != nil {
+		r.Report(e)
+	}
+}
+
+// JSONProgressReporter writes events as newline-delimited JSON. To bound the
+// output rate, an event is dropped if another of the same phase was written
+// less than minInterval ago, unless it completes its phase (all bytes done or
+// 100%). It fills in ETASeconds for byte-counted phases from the rate so far.
+type JSONProgressReporter struct {
+	w           io.Writer
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	lastPhase string
+	lastWrite time.Time
+	started   map[string]time.Time
+}
+
+// NewJSONProgressReporter creates a JSONProgressReporter writing to w.
+func NewJSONProgressReporter(w io.Writer, minInterval time.Duration) *JSONProgressReporter {
+	return &JSONProgressReporter{w: w, minInterval: minInterval, now: time.Now, started: map[string]time.Time{}}
+}
+
+// Report writes e unless the rate limit drops it.
+func (r *JSONProgressReporter) Report(e ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if _, ok := r.started[e.Phase]; !ok {
+		r.started[e.Phase] = now
+	}
+	complete := (e.BytesTotal > 0 && e.BytesDone >= e.BytesTotal) || e.Percent >= 100
+	if e.Phase == r.lastPhase && !complete && now.Sub(r.lastWrite) < r.minInterval {
+		return
+	}
+	if e.BytesTotal > 0 && e.BytesDone > 0 && e.ETASeconds == 0 {
+		elapsed := now.Sub(r.started[e.Phase]).Seconds()
+		e.ETASeconds = elapsed * float64(e.BytesTotal-e.BytesDone) / float64(e.BytesDone)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r.w.Write(append(line, '\n'))
+	r.lastPhase, r.lastWrite = e.Phase, now
+}
+
+// TextProgressReporter writes the human-readable progress lines xurl prints
+// with --verbose.
+type TextProgressReporter struct {
+	w io.Writer
+}
+
+// NewTextProgressReporter creates a TextProgressReporter writing to w.
+func NewTextProgressReporter(w io.Writer) *TextProgressReporter {
+	return &TextProgressReporter{w: w}
+}
+
+// Report writes the line for e, if its phase has one.
+func (r *TextProgressReporter) Report(e ProgressEvent) {
+	switch MediaStage(e.Phase) {
+	case MediaStageInit:
+		fmt.Fprintf(r.w, "\033[32mInitializing media upload...\033[0m\n")
+	case MediaStageAppend:
+		fmt.Fprintf(r.w, "\033[32mUploading media in chunks...\033[0m\n")
+	case MediaStageChunk:
+		fmt.Fprintf(r.w, "\033[33mUploaded %d of %d bytes (%.2f%%)\033[0m\n", e.BytesDone, e.BytesTotal, e.Percent)
+	case MediaStageUploaded:
+		fmt.Fprintf(r.w, "\033[32mUpload complete!\033[0m\n")
+	case MediaStageFinalize:
+		fmt.Fprintf(r.w, "\033[32mFinalizing media upload...\033[0m\n")
+	case MediaStageWaiting:
+		fmt.Fprintf(r.w, "\033[32mWaiting for media processing to complete...\033[0m\n")
+	case MediaStageProcessing:
+		fmt.Fprintf(r.w, "\033[33mMedia processing in progress (%d%%), checking again in %d seconds...\033[0m\n",
+			int(e.Percent), e.CheckAfterSeconds)
+	case MediaStageRetrying:
+		fmt.Fprintf(r.w, "\033[33mStatus check failed, retrying in %d seconds...\033[0m\n", e.CheckAfterSeconds)
+	case MediaStageProcessed:
+		fmt.Fprintf(r.w, "\033[32mMedia processing complete!\033[0m\n")
+	}
+}
+
+// mediaProgressEvent converts a MediaUploader progress event; segment is
+// the number of chunks uploaded so far.
+func mediaProgressEvent(p MediaProgress, segment int) ProgressEvent {
+	e := ProgressEvent{
+		Phase:             string(p.Stage),
+		BytesDone:         p.BytesUploaded,
+		BytesTotal:        p.TotalBytes,
+		Percent:           float64(p.ProgressPercent),
+		CheckAfterSeconds: int(p.CheckAfter / time.Second),
+	}
+	if p.Stage == MediaStageChunk || p.Stage == MediaStageUploaded {
+		e.Segment = segment
+		if p.TotalBytes > 0 {
+			e.Percent = float64(p.BytesUploaded) / float64(p.TotalBytes) * 100
+		}
+	}
+	return e
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/utils"
+)
+
+// recordingReporter keeps every event it is given.
+type recordingReporter struct {
+	events []ProgressEvent
+}
+
+func (r *recordingReporter) Report(e ProgressEvent) {
+	r.events = append(r.events, e)
+}
+
+func decodeProgress(t *testing.T, out string) []map[string]any {
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e), "each line is one JSON event: %s", line)
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestJSONProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONProgressReporter(&buf, time.Second)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	r.Report(ProgressEvent{Phase: "chunk", BytesDone: 0, BytesTotal: 400})
+	now = now.Add(100 * time.Millisecond)
+	r.Report(ProgressEvent{Phase: "chunk", BytesDone: 100, BytesTotal: 400, Segment: 1})
+	now = now.Add(900 * time.Millisecond)
+	r.Report(ProgressEvent{Phase: "chunk", BytesDone: 200, BytesTotal: 400, Segment: 2, Percent: 50})
+	now = now.Add(100 * time.Millisecond)
+	r.Report(ProgressEvent{Phase: "chunk", BytesDone: 400, BytesTotal: 400, Segment: 4, Percent: 100})
+	r.Report(ProgressEvent{Phase: "page", Page: 1})
+
+	events := decodeProgress(t, buf.String())
+	require.Len(t, events, 4, "the event 100ms after the first is dropped")
+	assert.Equal(t, map[string]any{
+		"phase": "chunk", "bytes_done": 200.0, "bytes_total": 400.0, "segment": 2.0, "percent": 50.0, "eta_seconds": 1.0,
+	}, events[1], "eta comes from the rate so far")
+	assert.Equal(t, 100.0, events[2]["percent"], "completing a phase is never dropped")
+	assert.Equal(t, map[string]any{"phase": "page", "page": 1.0}, events[3])
+}
+
+func TestMediaProgressReporting(t *testing.T) {
+	events := []MediaProgress{
+		{Stage: MediaStageInit, TotalBytes: 200},
+		{Stage: MediaStageInitialized, TotalBytes: 200, Response: json.RawMessage(`{"data":{"id":"1"}}`)},
+		{Stage: MediaStageAppend, TotalBytes: 200},
+		{Stage: MediaStageChunk, BytesUploaded: 100, TotalBytes: 200},
+		{Stage: MediaStageChunk, BytesUploaded: 200, TotalBytes: 200},
+		{Stage: MediaStageUploaded, BytesUploaded: 200, TotalBytes: 200},
+		{Stage: MediaStageProcessing, ProgressPercent: 40, CheckAfter: 2 * time.Second},
+	}
+
+	t.Run("reporter", func(t *testing.T) {
+		rec := &recordingReporter{}
+
+		var out bytes.Buffer
+		defer redirectColor(&out)()
+		report := printMediaProgress(true, rec, utils.NewFormatter())
+		for _, e := range events {
+			report(e)
+		}
+
+		require.Len(t, rec.events, len(events))
+		assert.Equal(t, ProgressEvent{Phase: "chunk", BytesDone: 200, BytesTotal: 200, Segment: 2, Percent: 100}, rec.events[4])
+		assert.Equal(t, ProgressEvent{Phase: "processing", Percent: 40, CheckAfterSeconds: 2}, rec.events[6])
+		assert.Empty(t, out.String(), "the human lines are replaced, not added to")
+	})
+
+	t.Run("human", func(t *testing.T) {
+		var out bytes.Buffer
+		defer redirectColor(&out)()
+		report := printMediaProgress(true, nil, utils.NewFormatter())
+		for _, e := range events {
+			report(e)
+		}
+		assert.Contains(t, out.String(), "Uploaded 100 of 200 bytes (50.00%)")
+		assert.Contains(t, out.String(), "Media processing in progress (40%), checking again in 2 seconds...")
+		assert.Contains(t, out.String(), `"id"`, "verbose still prints the API responses")
+	})
+}
+
+func TestBackfillReportsPages(t *testing.T) {
+	server, _ := timelinePages(t, map[string]string{
+		"":     `{"data":[{"id":"1009"},{"id":"1008"}]}`,
+		"1008": `{"data":[{"id":"1007"}]}`,
+	})
+	client := shortcutClient(t, server)
+
+	rec := &recordingReporter{}
+	opts := baseTestOpts()
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/1/mentions?since_id=100"
+	opts.Progress = rec
+	_, err := Backfill(client, opts, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ProgressEvent{
+		{Phase: "page", Page: 1}, {Phase: "page", Page: 2}, {Phase: "page", Page: 3},
+	}, rec.events)
+}
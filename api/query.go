@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AppendQueryData implements --get (curl's -G): it turns -d data and
+// --data-urlencode values into query parameters appended to endpoint, after
+// any it already has, so nothing is dropped and repeated keys are all sent.
+//
+// data holds name=value pairs joined by &, as curl takes them; each name and
+// value is decoded if it already is percent-encoded and then encoded again,
+// so raw and pre-encoded input both work. Each --data-urlencode value is
+// encoded as curl does: "name=content" encodes content, while "content" and
+// "=content" send the encoded content on its own. Spaces are encoded as %20.
+func AppendQueryData(endpoint, data string, urlencoded []string) (string, error) {
+	var params []string
+	if data = strings.TrimSpace(data); data != "" {
+		if strings.HasPrefix(data, "{") || strings.HasPrefix(data, "[") {
+			return "", fmt.Errorf("--get needs -d as name=value pairs joined by &, not JSON")
+		}
+		for _, pair := range strings.Split(data, "&") {
+			if pair == "" {
+				continue
+			}
+			name, value, hasValue := strings.Cut(pair, "=")
+			param := escapeQueryValue(unescapeQueryValue(name))
+			if hasValue {
+				param += "=" + escapeQueryValue(unescapeQueryValue(value))
+			}
+			params = append(params, param)
+		}
+	}
+	for _, item := range urlencoded {
+		name, content, ok := strings.Cut(item, "=")
+		switch {
+		case !ok:
+			params = append(params, escapeQueryValue(item))
+		case name == "":
+			params = append(params, escapeQueryValue(content))
+		default:
+			params = append(params, name+"="+escapeQueryValue(content))
+		}
+	}
+	if len(params) == 0 {
+		return endpoint, nil
+	}
+
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+		if strings.HasSuffix(endpoint, "?") || strings.HasSuffix(endpoint, "&") {
+			sep = ""
+		}
+	}
+	return endpoint + sep + strings.Join(params, "&"), nil
+}
+
+// unescapeQueryValue decodes s if it is valid query encoding, and otherwise
+// returns it unchanged, so a raw "100%" survives.
+func unescapeQueryValue(s string) string {
+	if decoded, err := url.QueryUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
+
+// escapeQueryValue encodes s for a query string, with spaces as %20.
+func escapeQueryValue(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
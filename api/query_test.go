@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendQueryData(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   string
+		data       string
+		urlencoded []string
+		want       string
+	}{
+		{"no data", "/2/tweets/search/recent", "", nil, "/2/tweets/search/recent"},
+		{"pairs", "/2/tweets/search/recent", "query=golang&max_results=10", nil, "/2/tweets/search/recent?query=golang&max_results=10"},
+		{"merges with the existing query", "/2/tweets/search/recent?max_results=10", "query=golang", nil, "/2/tweets/search/recent?max_results=10&query=golang"},
+		{"trailing separator", "/2/users?", "ids=1", nil, "/2/users?ids=1"},
+		{"duplicate keys are all kept", "/2/users?ids=1", "ids=2&ids=3", nil, "/2/users?ids=1&ids=2&ids=3"},
+		{"raw spaces are encoded", "/2/tweets/search/recent", "query=from:x lang:en", nil, "/2/tweets/search/recent?query=from%3Ax%20lang%3Aen"},
+		{"pre-encoded values are not encoded twice", "/2/tweets/search/recent", "query=from%3Ax%20lang%3Aen", nil, "/2/tweets/search/recent?query=from%3Ax%20lang%3Aen"},
+		{"invalid escapes are kept literally", "/x", "q=100%", nil, "/x?q=100%25"},
+		{"flag without value", "/x", "a&b=1", nil, "/x?a&b=1"},
+		{"data-urlencode name=content", "/2/tweets/search/recent", "", []string{"query=cats & dogs"}, "/2/tweets/search/recent?query=cats%20%26%20dogs"},
+		{"data-urlencode content only", "/x", "", []string{"a b", "=c&d"}, "/x?a%20b&c%26d"},
+		{"data and data-urlencode", "/x?a=1", "a=2", []string{"a=3 4"}, "/x?a=1&a=2&a=3%204"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendQueryData(tt.endpoint, tt.data, tt.urlencoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := AppendQueryData("/x", `{"query":"golang"}`, nil)
+	assert.ErrorContains(t, err, "not JSON")
+}
+
+func TestAppendQueryDataParsesBack(t *testing.T) {
+	got, err := AppendQueryData("https://api.x.com/2/tweets/search/recent?max_results=10", "query=from:XDevelopers -is:retweet", []string{"tweet.fields=created_at,lang"})
+	require.NoError(t, err)
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "10", q.Get("max_results"))
+	assert.Equal(t, "from:XDevelopers -is:retweet", q.Get("query"))
+	assert.Equal(t, "created_at,lang", q.Get("tweet.fields"))
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// Backoff schedule from X's reconnect guidance: rate-limited (429) requests
+// back off exponentially starting at one minute, up to the 15-minute
+// rate-limit window; other HTTP errors start at five seconds and double up to
+// 320 seconds.
+const (
+	rateLimitBackoffBase = time.Minute
+	rateLimitBackoffMax  = 15 * time.Minute
+	httpErrorBackoffBase = 5 * time.Second
+	httpErrorBackoffMax  = 320 * time.Second
+)
+
+// parseRateLimitReset reads the reset time from a 429 response. The
+// x-rate-limit-reset header (Unix seconds) takes precedence; Retry-After
+// (delta seconds or an HTTP date) is used otherwise. A zero time means the
+// server did not say.
+func parseRateLimitReset(header http.Header, now time.Time) time.Time {
+	if v := strings.TrimSpace(header.Get("x-rate-limit-reset")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs > 0 {
+			return time.Unix(secs, 0)
+		}
+	}
+	return parseRetryAfter(header, now)
+}
+
+// parseRetryAfter reads the Retry-After header, in delta seconds or as an
+// HTTP date. A zero time means the server did not say.
+func parseRetryAfter(header http.Header, now time.Time) time.Time {
+	if v := strings.TrimSpace(header.Get("Retry-After")); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return now.Add(time.Duration(secs) * time.Second)
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// RetryAfterPolicy says how much of the wait a server asks for, with a
+// rate-limit reset or a Retry-After header, a retry honours
+// (--honor-retry-after, --retry-after-cap). The zero value honours it in
+// full.
+type RetryAfterPolicy struct {
+	// Ignore disregards the server's wait and uses the backoff schedule.
+	Ignore bool
+	// Cap is the longest server-requested wait honoured; zero means no cap.
+	Cap time.Duration
+}
+
+// Delay returns how long to wait before retrying after err on the given
+// zero-based attempt. A rate-limit error with a future reset time waits until
+// that reset, and any other error with a Retry-After waits as long as it
+// says, clamped to p.Cap. Otherwise, or when p.Ignore is set, a rate-limit
+// error backs off exponentially from a minute and any other error uses the
+// shorter HTTP-error schedule.
+func (p RetryAfterPolicy) Delay(err error, attempt int, now time.Time) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	if !p.Ignore {
+		if wait, ok := serverWait(err, now); ok {
+			if p.Cap > 0 && wait > p.Cap {
+				wait = p.Cap
+			}
+			return wait
+		}
+	}
+	if xurlErrors.IsRateLimitError(err) {
+		return min(rateLimitBackoffBase<<min(attempt, 10), rateLimitBackoffMax)
+	}
+	delay := httpErrorBackoffBase << min(attempt, 10)
+	if delay > httpErrorBackoffMax {
+		delay = httpErrorBackoffMax
+	}
+	return delay
+}
+
+// serverWait returns how long err's response asked the client to wait: until
+// the reset of a rate limit, or the Retry-After of any other error.
+func serverWait(err error, now time.Time) (time.Duration, bool) {
+	if xurlErrors.IsRateLimitError(err) {
+		if resetAt, ok := xurlErrors.RateLimitReset(err); ok && resetAt.After(now) {
+			return resetAt.Sub(now), true
+		}
+		return 0, false
+	}
+	if retryAt, ok := xurlErrors.RetryAfter(err); ok {
+		return max(retryAt.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// RateLimitStatus is the quota of one endpoint family, such as
+// "GET /2/users/:id/followers", as its last response's x-rate-limit-*
+// headers reported it.
+type RateLimitStatus struct {
+	Family    string    `json:"family"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// RateLimitTracker keeps the latest RateLimitStatus of every endpoint family
+// the clients it is given to (see ApiClient.WithRateLimitTracker) received a
+// response from. It is safe for concurrent use.
+type RateLimitTracker struct {
+	mu     sync.Mutex
+	status map[string]RateLimitStatus
+}
+
+// NewRateLimitTracker returns a tracker that has seen no responses.
+func NewRateLimitTracker() *RateLimitTracker {
+	return &RateLimitTracker{status: map[string]RateLimitStatus{}}
+}
+
+// Record stores the rate-limit headers of resp under its endpoint family.
+// A response without them is ignored.
+func (t *RateLimitTracker) Record(resp *http.Response) {
+	limit, okLimit := headerInt(resp.Header, "x-rate-limit-limit")
+	remaining, okRemaining := headerInt(resp.Header, "x-rate-limit-remaining")
+	reset, okReset := headerInt(resp.Header, "x-rate-limit-reset")
+	if !okLimit && !okRemaining && !okReset {
+		return
+	}
+	status := RateLimitStatus{Family: noticeEndpointKey(resp.Request), Limit: limit, Remaining: remaining}
+	if okReset {
+		status.Reset = time.Unix(int64(reset), 0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status[status.Family] = status
+}
+
+// Statuses returns the status of every family seen, sorted by family.
+func (t *RateLimitTracker) Statuses() []RateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]RateLimitStatus, 0, len(t.status))
+	for _, s := range t.status {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Family < out[j].Family })
+	return out
+}
+
+func headerInt(header http.Header, name string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	return n, err == nil
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+func rateLimitedServer(t *testing.T, header http.Header, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, vs := range header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sendRateLimited(t *testing.T, header http.Header, body string) error {
+	t.Helper()
+	server := rateLimitedServer(t, header, body)
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+	require.Error(t, err)
+	return err
+}
+
+func TestProcessResponseRateLimit(t *testing.T) {
+	t.Run("Reset header is parsed", func(t *testing.T) {
+		reset := time.Now().Add(90 * time.Second).Truncate(time.Second)
+		header := http.Header{"X-Rate-Limit-Reset": {strconv.FormatInt(reset.Unix(), 10)}}
+		err := sendRateLimited(t, header, `{"title":"Too Many Requests"}`)
+
+		assert.True(t, xurlErrors.IsRateLimitError(err))
+		assert.False(t, xurlErrors.IsAPIError(err))
+		assert.JSONEq(t, `{"title":"Too Many Requests"}`, err.Error(), "JSON body is preserved")
+
+		resetAt, ok := xurlErrors.RateLimitReset(err)
+		require.True(t, ok)
+		assert.True(t, resetAt.Equal(reset))
+		assert.Contains(t, xurlErrors.DescribeRateLimit(err, reset.Add(-90*time.Second)), "resets in 1m30s")
+	})
+
+	t.Run("Missing reset header", func(t *testing.T) {
+		err := sendRateLimited(t, nil, "slow down")
+
+		assert.True(t, xurlErrors.IsRateLimitError(err))
+		_, ok := xurlErrors.RateLimitReset(err)
+		assert.False(t, ok)
+		assert.Contains(t, xurlErrors.DescribeRateLimit(err, time.Now()), "reset time unknown")
+	})
+
+	t.Run("Reset header in the past", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		header := http.Header{"X-Rate-Limit-Reset": {strconv.FormatInt(past.Unix(), 10)}}
+		err := sendRateLimited(t, header, `{}`)
+
+		assert.True(t, xurlErrors.IsRateLimitError(err))
+		assert.Contains(t, xurlErrors.DescribeRateLimit(err, time.Now()), "already reset")
+		assert.Equal(t, rateLimitBackoffBase, RetryAfterPolicy{}.Delay(err, 0, time.Now()),
+			"a past reset falls back to exponential backoff")
+	})
+
+	t.Run("Retry-After seconds", func(t *testing.T) {
+		now := time.Now()
+		resetAt := parseRateLimitReset(http.Header{"Retry-After": {"30"}}, now)
+		assert.Equal(t, now.Add(30*time.Second), resetAt)
+	})
+}
+
+func TestStreamRequestRateLimit(t *testing.T) {
+	server := rateLimitedServer(t, http.Header{"Retry-After": {"5"}}, `{"title":"Too Many Requests"}`)
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	err := client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"}, StreamHandler{})
+	require.Error(t, err)
+	assert.True(t, xurlErrors.IsRateLimitError(err))
+}
+
+func TestRetryAfterPolicyBackoff(t *testing.T) {
+	now := time.Now()
+
+	rateLimited := xurlErrors.NewRateLimitError("{}", time.Time{})
+	assert.Equal(t, time.Minute, RetryAfterPolicy{}.Delay(rateLimited, 0, now))
+	assert.Equal(t, 4*time.Minute, RetryAfterPolicy{}.Delay(rateLimited, 2, now))
+	assert.Equal(t, rateLimitBackoffMax, RetryAfterPolicy{}.Delay(rateLimited, 10, now), "capped at the rate-limit window")
+
+	withReset := xurlErrors.NewRateLimitError("{}", now.Add(42*time.Second))
+	assert.Equal(t, 42*time.Second, RetryAfterPolicy{}.Delay(withReset, 3, now), "a known reset wins over backoff")
+
+	apiErr := xurlErrors.NewAPIError([]byte(`{}`))
+	assert.Equal(t, 5*time.Second, RetryAfterPolicy{}.Delay(apiErr, 0, now))
+	assert.Equal(t, 20*time.Second, RetryAfterPolicy{}.Delay(apiErr, 2, now))
+	assert.Equal(t, httpErrorBackoffMax, RetryAfterPolicy{}.Delay(apiErr, 9, now))
+}
+
+func TestRetryAfterPolicy(t *testing.T) {
+	now := time.Now()
+	serverError := xurlErrors.NewAPIError([]byte(`{}`))
+	serverError.StatusCode = http.StatusServiceUnavailable
+	serverError.RetryAfter = now.Add(10 * time.Minute)
+	rateLimited := xurlErrors.NewRateLimitError("{}", now.Add(15*time.Minute))
+
+	t.Run("honoured in full by default", func(t *testing.T) {
+		assert.Equal(t, 10*time.Minute, RetryAfterPolicy{}.Delay(serverError, 0, now))
+		assert.Equal(t, 15*time.Minute, RetryAfterPolicy{}.Delay(rateLimited, 0, now))
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		p := RetryAfterPolicy{Cap: 30 * time.Second}
+		assert.Equal(t, 30*time.Second, p.Delay(serverError, 0, now))
+		assert.Equal(t, 30*time.Second, p.Delay(rateLimited, 0, now))
+
+		short := xurlErrors.NewAPIError([]byte(`{}`))
+		short.RetryAfter = now.Add(5 * time.Second)
+		assert.Equal(t, 5*time.Second, p.Delay(short, 0, now), "shorter waits are not stretched")
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		p := RetryAfterPolicy{Ignore: true, Cap: time.Second}
+		assert.Equal(t, 5*time.Second, p.Delay(serverError, 0, now))
+		assert.Equal(t, 10*time.Second, p.Delay(serverError, 1, now))
+		assert.Equal(t, time.Minute, p.Delay(rateLimited, 0, now), "rate limits fall back to their own backoff")
+	})
+}
+
+func TestProcessingWaitRetryAfterCap(t *testing.T) {
+	var slept []time.Duration
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	failures := 0
+	check := func() (bool, time.Duration, error) {
+		if failures < 2 {
+			failures++
+			e := xurlErrors.NewAPIError([]byte(`{}`))
+			e.StatusCode = http.StatusBadGateway
+			e.RetryAfter = time.Now().Add(time.Hour)
+			return false, 0, e
+		}
+		return true, 0, nil
+	}
+	w := ProcessingWait{RetryAfter: RetryAfterPolicy{Cap: 2 * time.Second}}
+	require.NoError(t, w.poll(check, nil))
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, slept)
+}
+
+func TestServerErrorRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"title":"Service Unavailable"}`))
+	}))
+	defer server.Close()
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	before := time.Now()
+	_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/media/upload"})
+	require.Error(t, err)
+
+	assert.True(t, xurlErrors.IsAPIError(err))
+	assert.True(t, xurlErrors.IsTransientError(err))
+	retryAt, ok := xurlErrors.RetryAfter(err)
+	require.True(t, ok)
+	assert.WithinDuration(t, before.Add(12*time.Second), retryAt, 2*time.Second)
+
+	assert.False(t, xurlErrors.IsTransientError(xurlErrors.NewAPIError([]byte(`{}`))), "no status is not a server error")
+	assert.True(t, xurlErrors.IsTransientError(xurlErrors.NewHTTPError(assert.AnError)), "network errors are transient")
+	assert.False(t, xurlErrors.IsTransientError(xurlErrors.NewRateLimitError("{}", time.Time{})))
+}
+
+func TestRateLimitTracker(t *testing.T) {
+	reset := time.Now().Add(15 * time.Minute).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2/tweets/search/recent" {
+			w.Header().Set("x-rate-limit-limit", "15")
+			w.Header().Set("x-rate-limit-remaining", r.URL.Query().Get("left"))
+			w.Header().Set("x-rate-limit-reset", strconv.FormatInt(reset.Unix(), 10))
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	tracker := NewRateLimitTracker()
+	client := shortcutClient(t, server).WithRateLimitTracker(tracker)
+	for _, endpoint := range []string{"/2/users/12/followers?left=14", "/2/users/99/followers?left=13", "/2/users/me?left=74", "/2/tweets/search/recent"} {
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: endpoint})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []RateLimitStatus{
+		{Family: "GET /2/users/:id/followers", Limit: 15, Remaining: 13, Reset: reset},
+		{Family: "GET /2/users/me", Limit: 15, Remaining: 74, Reset: reset},
+	}, tracker.Statuses(), "the latest response of a family wins; responses without headers are skipped")
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxRedirects is how many redirects a request follows, as in net/http.
+const maxRedirects = 10
+
+// WithInsecureRedirectAuth makes the client forward the Authorization header
+// across every redirect, even to another host or from https to http, where
+// it is dropped by default (--allow-insecure-redirect-auth). Only meant for
+// trusted gateways.
+func (c *ApiClient) WithInsecureRedirectAuth(allow bool) *ApiClient {
+	c.insecureRedirectAuth = allow
+	return c
+}
+
+// checkRedirect is the CheckRedirect of the client's HTTP clients. It keeps
+// net/http's limit on redirects and drops the Authorization header of a
+// redirect that leaves the original request's host and port or downgrades
+// https to http, so credentials are not leaked to another server or sent in
+// clear text. With WithInsecureRedirectAuth, the header is forwarded instead,
+// even where net/http would drop it.
+func (c *ApiClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+	auth := via[0].Header.Get("Authorization")
+	if auth == "" {
+		return nil
+	}
+	switch {
+	case c.insecureRedirectAuth:
+		req.Header.Set("Authorization", auth)
+	case !redirectKeepsAuth(via, req.URL):
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// redirectKeepsAuth reports whether a redirect to target after the requests
+// in via may carry the original request's Authorization header: target must
+// have the host and port of the original request, and no hop may go from
+// https to http.
+func redirectKeepsAuth(via []*http.Request, target *url.URL) bool {
+	if !strings.EqualFold(canonicalHost(via[0].URL), canonicalHost(target)) {
+		return false
+	}
+	secure := false
+	for _, r := range via {
+		secure = secure || r.URL.Scheme == "https"
+	}
+	return !secure || target.Scheme == "https"
+}
+
+// canonicalHost returns the host and port of u, with the scheme's default
+// port filled in.
+func canonicalHost(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	return u.Hostname() + ":" + port
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+// redirectServers returns a target server that records the Authorization
+// header it receives, and an origin server that redirects /away to the
+// target under the host name "localhost", /port to the target on the same
+// host name but another port, and /here to its own /final.
+func redirectServers(t *testing.T, got *[]string) (origin, target *httptest.Server) {
+	t.Helper()
+	record := func(w http.ResponseWriter, r *http.Request) {
+		*got = append(*got, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data":{}}`))
+	}
+	target = httptest.NewServer(http.HandlerFunc(record))
+	t.Cleanup(target.Close)
+	elsewhere := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away":
+			http.Redirect(w, r, elsewhere+"/final", http.StatusFound)
+		case "/port":
+			http.Redirect(w, r, target.URL+"/final", http.StatusFound)
+		case "/here":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		default:
+			record(w, r)
+		}
+	}))
+	t.Cleanup(origin.Close)
+	return origin, target
+}
+
+func TestRedirectAuthorization(t *testing.T) {
+	var got []string
+	origin, _ := redirectServers(t, &got)
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: origin.URL}, authMock)
+	send := func(endpoint string) string {
+		got = nil
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: endpoint, AuthType: "app"})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		return got[0]
+	}
+
+	assert.Equal(t, "Bearer test-bearer-token", send("/here"), "kept on the same host")
+	assert.Empty(t, send("/away"), "dropped on another host")
+	assert.Empty(t, send("/port"), "dropped on another port, which net/http alone would keep it for")
+
+	client.WithInsecureRedirectAuth(true)
+	assert.Equal(t, "Bearer test-bearer-token", send("/away"), "forwarded when allowed")
+	assert.Equal(t, "Bearer test-bearer-token", send("/port"))
+}
+
+func TestRedirectAuthorizationStreamAndBodyReader(t *testing.T) {
+	var got []string
+	origin, _ := redirectServers(t, &got)
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: origin.URL}, authMock)
+
+	require.NoError(t, client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/away", AuthType: "app"}, StreamHandler{OnLine: func(string) error { return nil }}))
+	_, err := client.SendRequest(RequestOptions{Method: "PUT", Endpoint: "/away", AuthType: "app", BodyReader: strings.NewReader("x")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", ""}, got, "every HTTP client of the ApiClient drops it")
+}
+
+func TestRedirectKeepsAuth(t *testing.T) {
+	via := func(urls ...string) []*http.Request {
+		var reqs []*http.Request
+		for _, u := range urls {
+			parsed, err := url.Parse(u)
+			require.NoError(t, err)
+			reqs = append(reqs, &http.Request{URL: parsed})
+		}
+		return reqs
+	}
+	target := func(u string) *url.URL {
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		name   string
+		via    []*http.Request
+		target string
+		want   bool
+	}{
+		{"same host", via("https://api.x.com/2/a"), "https://api.x.com/2/b", true},
+		{"host case", via("https://api.x.com/2/a"), "https://API.X.com/2/b", true},
+		{"default port", via("https://api.x.com/2/a"), "https://api.x.com:443/2/b", true},
+		{"upgrade", via("http://localhost:8080/a"), "https://localhost:8080/b", true},
+		{"other host", via("https://api.x.com/2/a"), "https://evil.example/2/b", false},
+		{"subdomain", via("https://api.x.com/2/a"), "https://upload.api.x.com/2/b", false},
+		{"other port", via("https://api.x.com/2/a"), "https://api.x.com:8443/2/b", false},
+		{"downgrade", via("https://api.x.com/2/a"), "http://api.x.com:443/2/b", false},
+		{"earlier downgrade", via("https://api.x.com/a", "https://api.x.com/b"), "http://api.x.com:443/c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redirectKeepsAuth(tt.via, target(tt.target)))
+		})
+	}
+}
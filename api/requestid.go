@@ -0,0 +1,18 @@
+package api
+
+import "fmt"
+
+// RequestIDHeader is the header carrying the ID of the xurl invocation a
+// request belongs to (see ApiClient.WithRequestID), so that the many requests
+// one command can make (pages, media chunks, batches) can be correlated in
+// proxy and server logs.
+const RequestIDHeader = "X-Request-ID"
+
+// NewRequestID returns a random request ID, a version 4 UUID.
+func NewRequestID() (string, error) {
+	id, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("could not generate request ID: %v", err)
+	}
+	return id, nil
+}
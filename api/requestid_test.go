@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestID(t *testing.T) {
+	a, err := NewRequestID()
+	require.NoError(t, err)
+	b, err := NewRequestID()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, a)
+	assert.NotEqual(t, a, b)
+}
+
+// TestRequestIDOnEverySubRequest checks that one request ID is sent with
+// every request a fan-out makes: each page, each multipart upload and each
+// stream, and that the request hook sees each of them.
+func TestRequestIDOnEverySubRequest(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/2/tweets/search/stream":
+			w.Write([]byte("{\"data\":{\"id\":\"1\"}}\n"))
+		case r.URL.Query().Get("pagination_token") == "":
+			w.Write([]byte(`{"data":[{"id":"1"}],"meta":{"next_token":"p2"}}`))
+		default:
+			w.Write([]byte(`{"data":[{"id":"2"}],"meta":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	var hooked []string
+	client := shortcutClient(t, server).WithRequestID("run-123").WithRequestHook(func(req *http.Request) {
+		hooked = append(hooked, req.Header.Get(RequestIDHeader))
+	})
+	assert.Equal(t, "run-123", client.RequestID())
+
+	opts := baseTestOpts()
+	opts.AuthType = "app"
+	_, err := GetAllBookmarks(client, "42", 5, opts)
+	require.NoError(t, err)
+
+	_, err = client.SendMultipartRequest(MultipartOptions{
+		RequestOptions: RequestOptions{Method: "POST", Endpoint: "/2/media/upload", AuthType: "app"},
+		FormFields:     map[string]string{"command": "APPEND"},
+		FileField:      "media",
+		FileName:       "clip.mp4",
+		FileData:       []byte("0123456789"),
+	})
+	require.NoError(t, err)
+
+	streamOpts := opts
+	streamOpts.Method, streamOpts.Endpoint = "GET", "/2/tweets/search/stream"
+	require.NoError(t, client.StreamRequest(streamOpts, StreamHandler{OnLine: func(string) error { return nil }}))
+
+	require.Len(t, ids, 4)
+	for _, id := range ids {
+		assert.Equal(t, "run-123", id)
+	}
+	assert.Equal(t, ids, hooked, "the hook sees every request with its final headers")
+
+	t.Run("a request's own header wins", func(t *testing.T) {
+		ids = nil
+		own := opts
+		own.Method, own.Endpoint = "GET", "/2/users/me"
+		own.Headers = []string{RequestIDHeader + ": mine"}
+		_, err := client.SendRequest(own)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mine"}, ids)
+	})
+
+	t.Run("dry runs send nothing", func(t *testing.T) {
+		ids, hooked = nil, nil
+		dry := opts
+		dry.Method, dry.Endpoint, dry.DryRun = "GET", "/2/users/me", true
+		resp, err := client.SendRequest(dry)
+		require.NoError(t, err)
+		assert.Contains(t, string(resp), "run-123", "the dry run shows the header")
+		assert.Empty(t, ids)
+		assert.Empty(t, hooked)
+	})
+}
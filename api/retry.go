@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// WithRetry makes SendRequest retry an idempotent request (GET, HEAD,
+// OPTIONS, PUT, DELETE, or any request with an Idempotency-Key) up to
+// retries times when it fails with a 429 or 5xx
+// response (--retry). Each retry waits as policy.Delay says, the same
+// backoff as pagination and polling. Zero retries, the default, sends every
+// request once.
+func (c *ApiClient) WithRetry(retries int, policy RetryAfterPolicy) *ApiClient {
+	c.retries = retries
+	c.retryPolicy = policy
+	return c
+}
+
+// WithRetryLog makes the client append a line to w for every retry
+// SendRequest makes (--retry-log): when it happened, the retry number, the
+// response that caused it and how long the client waited. A nil writer logs
+// nothing.
+func (c *ApiClient) WithRetryLog(w io.Writer) *ApiClient {
+	c.retryLog = w
+	return c
+}
+
+// logRetry writes a line about a retry to the retry log, if any. Requests
+// sent concurrently (see FanOut) share the log, so writes are serialized.
+func (c *ApiClient) logRetry(now time.Time, options RequestOptions, attempt int, err error, delay time.Duration) {
+	if c.retryLog == nil {
+		return
+	}
+	method := strings.ToUpper(options.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	c.retryLogMu.Lock()
+	defer c.retryLogMu.Unlock()
+	fmt.Fprintf(c.retryLog, "%s attempt=%d reason=%q backoff=%s request=%q\n",
+		now.UTC().Format(time.RFC3339), attempt, describeRetryError(err), delay, method+" "+options.Endpoint)
+}
+
+// sendWithRetry calls send until it succeeds, fails with an error that is not
+// worth retrying, or has been retried c.retries times.
+func (c *ApiClient) sendWithRetry(options RequestOptions, send func() (json.RawMessage, error)) (json.RawMessage, error) {
+	resp, err := send()
+	if c.retries <= 0 || options.DryRun || options.BodyReader != nil || !c.safeToRepeat(options) {
+		return resp, err
+	}
+	for attempt := 0; attempt < c.retries && err != nil && retryableError(err); attempt++ {
+		now := time.Now()
+		delay := c.retryPolicy.Delay(err, attempt, now)
+		c.logRetry(now, options, attempt+1, err, delay)
+		if options.Verbose && c.verboseOut != nil {
+			fmt.Fprintf(c.verboseOut, "\033[1;33m* %s; retrying in %s (retry %d of %d)\033[0m\n\n", describeRetryError(err), delay, attempt+1, c.retries)
+		}
+		sleep(delay)
+		resp, err = send()
+	}
+	return resp, err
+}
+
+// safeToRepeat reports whether a request may be sent again: its method is
+// idempotent, or it carries an Idempotency-Key, with which the server treats
+// the repeats as one request.
+func (c *ApiClient) safeToRepeat(options RequestOptions) bool {
+	return idempotentMethod(options.Method) || hasHeader(options.Headers, IdempotencyKeyHeader) || hasHeader(c.defaultHeaders, IdempotencyKeyHeader)
+}
+
+// idempotentMethod reports whether a request with this method may be sent
+// again without changing its effect. An empty method means GET.
+func idempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryableError reports whether err is a 429 or 5xx response. Network
+// errors are not retried, as the request may have reached the server.
+func retryableError(err error) bool {
+	if xurlErrors.IsRateLimitError(err) {
+		return true
+	}
+	var e *xurlErrors.Error
+	return errors.As(err, &e) && e.StatusCode >= 500
+}
+
+// describeRetryError names the response err came from in the verbose trace
+// and the retry log.
+func describeRetryError(err error) string {
+	var e *xurlErrors.Error
+	if errors.As(err, &e) && e.StatusCode != 0 {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return "429 Too Many Requests"
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// retryServer returns a server that answers the first len(failures)
+// requests with those status codes, setting a Retry-After of retryAfter on
+// the first when it is not empty, and every later request with 200. It
+// counts the requests in *calls.
+func retryServer(t *testing.T, calls *int, retryAfter string, failures ...int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= len(failures) {
+			if *calls == 1 && retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(failures[*calls-1])
+			w.Write([]byte(`{"title":"Too Many Requests"}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"1"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func retryClient(t *testing.T, server *httptest.Server, retries int, policy RetryAfterPolicy) *ApiClient {
+	t.Helper()
+	authMock, tempDir := createMockAuth(t)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+	return NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).WithRetry(retries, policy)
+}
+
+// recordSleeps swaps out sleep for the rest of the test, recording the
+// waits instead.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var slept []time.Duration
+	prev := sleep
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { sleep = prev })
+	return &slept
+}
+
+func TestSendRequestRetriesRateLimit(t *testing.T) {
+	slept := recordSleeps(t)
+	var calls int
+	server := retryServer(t, &calls, "3", http.StatusTooManyRequests, http.StatusTooManyRequests)
+
+	resp, err := retryClient(t, server, 3, RetryAfterPolicy{}).SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me", AuthType: "app"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"1"}}`, string(resp))
+	assert.Equal(t, 3, calls)
+	require.Len(t, *slept, 2)
+	assert.InDelta(t, 3*time.Second, (*slept)[0], float64(time.Second), "Retry-After")
+	assert.Equal(t, 2*time.Minute, (*slept)[1], "rate-limit backoff")
+}
+
+func TestSendRequestRetryLimits(t *testing.T) {
+	send := func(t *testing.T, method string, retries int, policy RetryAfterPolicy, retryAfter string, failures ...int) (calls int, slept []time.Duration, err error) {
+		sleeps := recordSleeps(t)
+		server := retryServer(t, &calls, retryAfter, failures...)
+		_, err = retryClient(t, server, retries, policy).SendRequest(RequestOptions{Method: method, Endpoint: "/2/tweets/1", AuthType: "app"})
+		return calls, *sleeps, err
+	}
+
+	t.Run("gives up after the last retry", func(t *testing.T) {
+		calls, slept, err := send(t, "DELETE", 2, RetryAfterPolicy{}, "", 503, 502, 500)
+		require.Error(t, err)
+		assert.True(t, xurlErrors.IsTransientError(err))
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second}, slept, "HTTP-error backoff")
+	})
+	t.Run("no retries by default", func(t *testing.T) {
+		calls, slept, err := send(t, "GET", 0, RetryAfterPolicy{}, "", 503)
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, slept)
+	})
+	t.Run("POST is not retried", func(t *testing.T) {
+		calls, _, err := send(t, "POST", 3, RetryAfterPolicy{}, "", 503)
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("POST with an idempotency key is retried", func(t *testing.T) {
+		sleeps := recordSleeps(t)
+		var calls int
+		server := retryServer(t, &calls, "", 503)
+		headers, _, err := AddIdempotencyKey(nil, "")
+		require.NoError(t, err)
+		_, err = retryClient(t, server, 3, RetryAfterPolicy{}).SendRequest(RequestOptions{Method: "POST", Endpoint: "/2/tweets", Headers: headers, Data: `{"text":"hi"}`, AuthType: "app"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Len(t, *sleeps, 1)
+	})
+	t.Run("4xx is not retried", func(t *testing.T) {
+		calls, _, err := send(t, "GET", 3, RetryAfterPolicy{}, "", 404)
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("Retry-After is capped", func(t *testing.T) {
+		calls, slept, err := send(t, "GET", 1, RetryAfterPolicy{Cap: 5 * time.Second}, "600", 429)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, []time.Duration{5 * time.Second}, slept)
+	})
+	t.Run("Retry-After is ignored", func(t *testing.T) {
+		_, slept, err := send(t, "GET", 1, RetryAfterPolicy{Ignore: true}, "600", 429)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{time.Minute}, slept)
+	})
+}
+
+func TestRetryLog(t *testing.T) {
+	recordSleeps(t)
+	var calls int
+	server := retryServer(t, &calls, "", http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	var log bytes.Buffer
+	client := retryClient(t, server, 2, RetryAfterPolicy{}).WithRetryLog(&log)
+	_, err := client.SendRequest(RequestOptions{Endpoint: "/2/users/me", AuthType: "app"})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	require.Len(t, lines, 2, log.String())
+	assert.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ attempt=1 reason="503 Service Unavailable" backoff=5s request="GET /2/users/me"$`, lines[0])
+	assert.Regexp(t, `^\S+ attempt=2 reason="429 Too Many Requests" backoff=2m0s request="GET /2/users/me"$`, lines[1])
+}
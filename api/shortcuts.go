@@ -36,6 +36,17 @@ type PostPoll struct {
 	DurationMinutes int      `json:"duration_minutes"`
 }
 
+// DMBody is the payload of a direct message
+type DMBody struct {
+	Text        string         `json:"text"`
+	Attachments []DMAttachment `json:"attachments,omitempty"`
+}
+
+// DMAttachment nests inside DMBody to attach uploaded media
+type DMAttachment struct {
+	MediaID string `json:"media_id"`
+}
+
 // ------------------------------------------------
 // Helpers
 // ------------------------------------------------
@@ -184,6 +195,46 @@ func SearchPosts(client Client, query string, maxResults int, opts RequestOption
 	return client.SendRequest(opts)
 }
 
+// spaceFields are the space.fields requested by SearchSpaces and GetSpace.
+const spaceFields = "title,state,creator_id,host_ids,speaker_ids,participant_count,subscriber_count,scheduled_start,started_at,ended_at,lang,is_ticketed"
+
+// spaceQuery returns the field and expansion parameters of a Spaces request:
+// the creator and hosts are expanded to users, and the speakers too when
+// withSpeakers is set.
+func spaceQuery(withSpeakers bool) string {
+	expansions := "creator_id,host_ids"
+	if withSpeakers {
+		expansions += ",speaker_ids"
+	}
+	return "space.fields=" + spaceFields + "&expansions=" + expansions + "&user.fields=username,name,verified"
+}
+
+// SearchSpaces searches Spaces by title. state is "live", "scheduled" or
+// "all" ("" means all).
+func SearchSpaces(client Client, query, state string, maxResults int, opts RequestOptions) (json.RawMessage, error) {
+	maxResults = clampResults(maxResults, 1, 100)
+	endpoint := fmt.Sprintf("/2/spaces/search?query=%s&max_results=%d", url.QueryEscape(query), maxResults)
+	if state != "" {
+		endpoint += "&state=" + url.QueryEscape(state)
+	}
+
+	opts.Method = "GET"
+	opts.Endpoint = endpoint + "&" + spaceQuery(false)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetSpace fetches a Space by ID, expanding its speakers to users when
+// withSpeakers is set.
+func GetSpace(client Client, spaceID string, withSpeakers bool, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = "/2/spaces/" + url.PathEscape(spaceID) + "?" + spaceQuery(withSpeakers)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
 // GetMe fetches the authenticated user's profile.
 func GetMe(client Client, opts RequestOptions) (json.RawMessage, error) {
 	opts.Method = "GET"
@@ -193,6 +244,39 @@ func GetMe(client Client, opts RequestOptions) (json.RawMessage, error) {
 	return client.SendRequest(opts)
 }
 
+// GetUsage fetches the project's post consumption against its monthly cap,
+// with a daily breakdown for the last days days (1–90). The endpoint only
+// accepts app-only auth.
+func GetUsage(client Client, days int, opts RequestOptions) (json.RawMessage, error) {
+	days = clampResults(days, 1, 90)
+	opts.Method = "GET"
+	opts.Endpoint = fmt.Sprintf("/2/usage/tweets?days=%d&usage.fields=cap_reset_day,daily_client_app_usage,daily_project_usage,project_cap,project_id,project_usage", days)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetTrends fetches the trending topics of a place by its WOEID (1 is
+// worldwide), at most maxTrends of them (1–50).
+func GetTrends(client Client, woeid, maxTrends int, opts RequestOptions) (json.RawMessage, error) {
+	maxTrends = clampResults(maxTrends, 1, 50)
+	opts.Method = "GET"
+	opts.Endpoint = fmt.Sprintf("/2/trends/by/woeid/%d?max_trends=%d&trend.fields=trend_name,tweet_count", woeid, maxTrends)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
+// GetPersonalizedTrends fetches the trends personalized for the
+// authenticated user.
+func GetPersonalizedTrends(client Client, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = "/2/users/personalized_trends?personalized_trend.fields=category,post_count,trend_name,trending_since"
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
 // LookupUser fetches a user by username.
 func LookupUser(client Client, username string, opts RequestOptions) (json.RawMessage, error) {
 	username = ResolveUsername(username)
@@ -214,6 +298,44 @@ func GetUserPosts(client Client, userID string, maxResults int, opts RequestOpti
 	return client.SendRequest(opts)
 }
 
+// userTimelineFields are the default fields requested by GetUserTimeline.
+const userTimelineFields = "tweet.fields=created_at,public_metrics,conversation_id,entities,lang,referenced_tweets&expansions=referenced_tweets.id"
+
+// GetUserTimeline fetches up to max recent posts by a user ID, following
+// pagination tokens across as many pages of /2/users/{id}/tweets as needed.
+// The pages are merged into a single {"data", "includes", "meta"} document;
+// meta.next_token is the token of the last page fetched, if there are more.
+func GetUserTimeline(client Client, userID string, max int, opts RequestOptions) (json.RawMessage, error) {
+	return collectPages(client, opts, max, userTimelineEndpoint(userID))
+}
+
+// StreamUserTimeline pages through a user's posts like GetUserTimeline but
+// sends each post to items as soon as its page arrives instead of merging
+// the pages, closing items when done.
+func StreamUserTimeline(client Client, userID string, max int, opts RequestOptions, items chan<- json.RawMessage) error {
+	defer close(items)
+	_, dryRun, err := walkPages(client, opts, max, userTimelineEndpoint(userID), func(page []json.RawMessage, _ map[string][]json.RawMessage) {
+		for _, post := range page {
+			items <- post
+		}
+	})
+	if dryRun != nil {
+		items <- dryRun
+	}
+	return err
+}
+
+// userTimelineEndpoint pages through /2/users/{id}/tweets.
+func userTimelineEndpoint(userID string) pageEndpoint {
+	return func(remaining int, nextToken string) string {
+		endpoint := fmt.Sprintf("/2/users/%s/tweets?max_results=%d&%s", userID, clampResults(remaining, 5, 100), userTimelineFields)
+		if nextToken != "" {
+			endpoint += "&pagination_token=" + url.QueryEscape(nextToken)
+		}
+		return endpoint
+	}
+}
+
 // GetTimeline fetches the authenticated user's reverse‑chronological timeline.
 // Route: GET /2/users/{id}/timelines/reverse_chronological
 func GetTimeline(client Client, userID string, maxResults int, opts RequestOptions) (json.RawMessage, error) {
@@ -307,16 +429,32 @@ func Unbookmark(client Client, userID, postID string, opts RequestOptions) (json
 	return client.SendRequest(opts)
 }
 
-// GetBookmarks fetches the authenticated user's bookmarks.
+// GetBookmarks fetches one page of the authenticated user's bookmarks.
 func GetBookmarks(client Client, userID string, maxResults int, opts RequestOptions) (json.RawMessage, error) {
-	maxResults = clampResults(maxResults, 1, 100)
 	opts.Method = "GET"
-	opts.Endpoint = fmt.Sprintf("/2/users/%s/bookmarks?max_results=%d&tweet.fields=created_at,public_metrics,entities&expansions=author_id&user.fields=username,name", userID, maxResults)
+	opts.Endpoint = bookmarksEndpoint(userID)(maxResults, "")
 	opts.Data = ""
 
 	return client.SendRequest(opts)
 }
 
+// GetAllBookmarks pages through the authenticated user's bookmarks, up to max
+// of them, and merges the pages like GetUserTimeline.
+func GetAllBookmarks(client Client, userID string, max int, opts RequestOptions) (json.RawMessage, error) {
+	return collectPages(client, opts, max, bookmarksEndpoint(userID))
+}
+
+// bookmarksEndpoint pages through /2/users/{id}/bookmarks.
+func bookmarksEndpoint(userID string) pageEndpoint {
+	return func(remaining int, nextToken string) string {
+		endpoint := fmt.Sprintf("/2/users/%s/bookmarks?max_results=%d&tweet.fields=created_at,public_metrics,entities&expansions=author_id&user.fields=username,name", userID, clampResults(remaining, 1, 100))
+		if nextToken != "" {
+			endpoint += "&pagination_token=" + url.QueryEscape(nextToken)
+		}
+		return endpoint
+	}
+}
+
 // FollowUser follows a user.
 func FollowUser(client Client, sourceUserID, targetUserID string, opts RequestOptions) (json.RawMessage, error) {
 	body := fmt.Sprintf(`{"target_user_id":"%s"}`, targetUserID)
@@ -357,11 +495,13 @@ func GetFollowers(client Client, userID string, maxResults int, opts RequestOpti
 	return client.SendRequest(opts)
 }
 
-// SendDM sends a direct message to a user.
-func SendDM(client Client, participantID, text string, opts RequestOptions) (json.RawMessage, error) {
-	data, err := json.Marshal(struct {
-		Text string `json:"text"`
-	}{Text: text})
+// SendDM sends a direct message to a user, attaching any uploaded media.
+func SendDM(client Client, participantID, text string, mediaIDs []string, opts RequestOptions) (json.RawMessage, error) {
+	body := DMBody{Text: text}
+	for _, id := range mediaIDs {
+		body.Attachments = append(body.Attachments, DMAttachment{MediaID: id})
+	}
+	data, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal DM body: %w", err)
 	}
@@ -373,11 +513,25 @@ func SendDM(client Client, participantID, text string, opts RequestOptions) (jso
 	return client.SendRequest(opts)
 }
 
+// dmEventFields are the fields requested for DM events.
+const dmEventFields = "dm_event.fields=created_at,dm_conversation_id,sender_id,text,attachments&expansions=sender_id&user.fields=username,name"
+
+// GetDMConversationEvents fetches recent DM events in the one-to-one
+// conversation with a user.
+func GetDMConversationEvents(client Client, participantID string, maxResults int, opts RequestOptions) (json.RawMessage, error) {
+	maxResults = clampResults(maxResults, 1, 100)
+	opts.Method = "GET"
+	opts.Endpoint = fmt.Sprintf("/2/dm_conversations/with/%s/dm_events?max_results=%d&%s", participantID, maxResults, dmEventFields)
+	opts.Data = ""
+
+	return client.SendRequest(opts)
+}
+
 // GetDMEvents fetches recent DM events.
 func GetDMEvents(client Client, maxResults int, opts RequestOptions) (json.RawMessage, error) {
 	maxResults = clampResults(maxResults, 1, 100)
 	opts.Method = "GET"
-	opts.Endpoint = fmt.Sprintf("/2/dm_events?max_results=%d&dm_event.fields=created_at,dm_conversation_id,sender_id,text&expansions=sender_id&user.fields=username,name", maxResults)
+	opts.Endpoint = fmt.Sprintf("/2/dm_events?max_results=%d&%s", maxResults, dmEventFields)
 	opts.Data = ""
 
 	return client.SendRequest(opts)
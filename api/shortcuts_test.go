@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/xdevplatform/xurl/config"
@@ -300,7 +301,7 @@ func TestSendDMEscapesText(t *testing.T) {
 	// Text with quotes, a backslash, and a newline would corrupt naive string
 	// interpolation; json.Marshal must round-trip it intact.
 	tricky := "He said \"hi\"\nC:\\temp\tend"
-	_, err := SendDM(client, "123", tricky, baseTestOpts())
+	_, err := SendDM(client, "123", tricky, nil, baseTestOpts())
 	require.NoError(t, err)
 
 	var parsed struct {
@@ -310,6 +311,102 @@ func TestSendDMEscapesText(t *testing.T) {
 	assert.Equal(t, tricky, parsed.Text)
 }
 
+func TestSendDMWithMedia(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/dm_conversations/with/123/messages", r.URL.Path)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"data":{"dm_event_id":"1"}}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	_, err := SendDM(client, "123", "look", []string{"555"}, baseTestOpts())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":"look","attachments":[{"media_id":"555"}]}`, string(gotBody))
+}
+
+func TestGetDMConversationEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/dm_conversations/with/123/dm_events", r.URL.Path)
+		assert.Equal(t, "20", r.URL.Query().Get("max_results"))
+		assert.Contains(t, r.URL.Query().Get("dm_event.fields"), "text")
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	_, err := GetDMConversationEvents(client, "123", 20, baseTestOpts())
+	require.NoError(t, err)
+}
+
+// ---- GetUserTimeline ----
+
+func TestGetUserTimelinePaginates(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/users/42/tweets", r.URL.Path)
+		q := r.URL.Query()
+		queries = append(queries, q)
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("pagination_token") {
+		case "":
+			w.Write([]byte(`{"data":[{"id":"1"},{"id":"2"},{"id":"3"}],"includes":{"tweets":[{"id":"q1"}]},"meta":{"result_count":3,"next_token":"p2"}}`))
+		case "p2":
+			w.Write([]byte(`{"data":[{"id":"4"},{"id":"5"},{"id":"6"}],"includes":{"tweets":[{"id":"q2"}]},"meta":{"result_count":3,"next_token":"p3"}}`))
+		default:
+			t.Errorf("unexpected page %q", q.Get("pagination_token"))
+		}
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	resp, err := GetUserTimeline(client, "42", 5, baseTestOpts())
+	require.NoError(t, err)
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, "5", queries[0].Get("max_results"))
+	assert.Equal(t, "5", queries[1].Get("max_results"), "the per-page minimum is 5")
+	assert.Contains(t, queries[0].Get("tweet.fields"), "created_at")
+
+	var out struct {
+		Data     []struct{ ID string } `json:"data"`
+		Includes struct {
+			Tweets []struct{ ID string } `json:"tweets"`
+		} `json:"includes"`
+		Meta struct {
+			ResultCount int    `json:"result_count"`
+			NextToken   string `json:"next_token"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &out))
+	require.Len(t, out.Data, 5, "the last page is trimmed to max")
+	assert.Equal(t, "5", out.Data[4].ID)
+	assert.Len(t, out.Includes.Tweets, 2)
+	assert.Equal(t, 5, out.Meta.ResultCount)
+	assert.Equal(t, "p3", out.Meta.NextToken)
+}
+
+func TestGetUserTimelineStopsAtLastPage(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "100", r.URL.Query().Get("max_results"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"id":"1"}],"meta":{"result_count":1}}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	resp, err := GetUserTimeline(client, "42", 200, baseTestOpts())
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.JSONEq(t, `{"data":[{"id":"1"}],"meta":{"result_count":1}}`, string(resp))
+
+	_, err = GetUserTimeline(client, "42", 0, baseTestOpts())
+	assert.Error(t, err)
+}
+
 // ---- max-results clamping ----
 
 func TestMaxResultsClamping(t *testing.T) {
@@ -345,3 +442,82 @@ func TestMaxResultsClamping(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 100, maxResultsOf(), "dm events should clamp to 100")
 }
+
+// ---- GetUsage ----
+
+func TestGetUsage(t *testing.T) {
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return o.Method == "GET" && o.AuthType == "app" &&
+			strings.HasPrefix(o.Endpoint, "/2/usage/tweets?days=90&usage.fields=")
+	})).Return(json.RawMessage(`{"data":{}}`), nil)
+
+	_, err := GetUsage(mockClient, 365, RequestOptions{AuthType: "app"})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetTrends(t *testing.T) {
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return o.Method == "GET" &&
+			strings.HasPrefix(o.Endpoint, "/2/trends/by/woeid/2459115?max_trends=50&")
+	})).Return(json.RawMessage(`{"data":[]}`), nil)
+
+	_, err := GetTrends(mockClient, 2459115, 100, baseTestOpts())
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSpacesRequests(t *testing.T) {
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return o.Method == "GET" &&
+			strings.HasPrefix(o.Endpoint, "/2/spaces/search?query=go+lang&max_results=100&state=live&space.fields=") &&
+			strings.HasSuffix(o.Endpoint, "&expansions=creator_id,host_ids&user.fields=username,name,verified")
+	})).Return(json.RawMessage(`{"data":[]}`), nil).Once()
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return o.Method == "GET" && strings.HasPrefix(o.Endpoint, "/2/spaces/1DXxyRYNejbKM?space.fields=") &&
+			strings.Contains(o.Endpoint, "&expansions=creator_id,host_ids,speaker_ids&")
+	})).Return(json.RawMessage(`{"data":{}}`), nil).Once()
+
+	_, err := SearchSpaces(mockClient, "go lang", "live", 500, baseTestOpts())
+	require.NoError(t, err)
+	_, err = GetSpace(mockClient, "1DXxyRYNejbKM", true, baseTestOpts())
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetAllBookmarksPaginates(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/users/42/bookmarks", r.URL.Path)
+		q := r.URL.Query()
+		tokens = append(tokens, q.Get("pagination_token"))
+		assert.Equal(t, "author_id", q.Get("expansions"))
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("pagination_token") {
+		case "":
+			w.Write([]byte(`{"data":[{"id":"1"},{"id":"2"}],"includes":{"users":[{"id":"7"}]},"meta":{"next_token":"b2"}}`))
+		default:
+			w.Write([]byte(`{"data":[{"id":"3"}],"includes":{"users":[{"id":"8"}]},"meta":{"next_token":"b3"}}`))
+		}
+	}))
+	defer server.Close()
+
+	resp, err := GetAllBookmarks(shortcutClient(t, server), "42", 3, baseTestOpts())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "b2"}, tokens, "stops once max bookmarks were fetched")
+
+	var out struct {
+		Data     []struct{ ID string }            `json:"data"`
+		Includes map[string][]struct{ ID string } `json:"includes"`
+		Meta     struct {
+			NextToken string `json:"next_token"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &out))
+	assert.Len(t, out.Data, 3)
+	assert.Len(t, out.Includes["users"], 2, "includes of every page are merged")
+	assert.Equal(t, "b3", out.Meta.NextToken)
+}
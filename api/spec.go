@@ -0,0 +1,326 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SpecEndpoint serves the X API's OpenAPI spec, without authentication.
+const SpecEndpoint = "/2/openapi.json"
+
+// SpecChange is one operation that differs between two versions of the
+// OpenAPI spec.
+type SpecChange struct {
+	// Kind is "added", "removed" or "changed".
+	Kind   string `json:"kind"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Details lists what changed in a "changed" operation: parameters,
+	// request body fields and deprecation.
+	Details []string `json:"details,omitempty"`
+	// InCatalog says whether the operation is in xurl's endpoint catalog.
+	InCatalog bool `json:"in_catalog"`
+}
+
+// DiffSpecs compares two OpenAPI documents operation by operation: an
+// operation is a method on a path. For operations in both it compares the
+// parameters (name, location, whether required and type) and the top-level
+// fields of the JSON request body, ignoring descriptions, examples and the
+// rest of the document. Changes are sorted by path and method.
+func DiffSpecs(oldSpec, newSpec []byte) ([]SpecChange, error) {
+	oldOps, err := parseSpecOperations(oldSpec)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the old spec: %v", err)
+	}
+	newOps, err := parseSpecOperations(newSpec)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the new spec: %v", err)
+	}
+
+	routes := map[specRoute]bool{}
+	for r := range oldOps {
+		routes[r] = true
+	}
+	for r := range newOps {
+		routes[r] = true
+	}
+	catalogRoutes := map[string]bool{}
+	for _, e := range catalog {
+		catalogRoutes[e.Method+" "+routeShape(e.Path)] = true
+	}
+
+	var changes []SpecChange
+	for r := range routes {
+		change := SpecChange{Method: r.method, Path: r.path, InCatalog: catalogRoutes[r.method+" "+routeShape(r.path)]}
+		before, inOld := oldOps[r]
+		after, inNew := newOps[r]
+		switch {
+		case !inOld:
+			change.Kind = "added"
+		case !inNew:
+			change.Kind = "removed"
+		default:
+			change.Kind = "changed"
+			change.Details = diffOperations(before, after)
+			if len(change.Details) == 0 {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		return changes[i].Method < changes[j].Method
+	})
+	return changes, nil
+}
+
+// routeShape replaces the parameters of an OpenAPI ("{id}") or catalog
+// (":id") path with "*", so the two notations can be matched.
+func routeShape(path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || (strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+type specRoute struct{ method, path string }
+
+// specOperation is the part of an operation DiffSpecs compares. Parameters
+// are keyed "in name", e.g. "query max_results".
+type specOperation struct {
+	deprecated bool
+	params     map[string]specValue
+	fields     map[string]specValue
+}
+
+type specValue struct {
+	required bool
+	typ      string
+}
+
+// openAPIDocument is the subset of an OpenAPI 3 document DiffSpecs reads.
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters    map[string]*openAPIParameter   `json:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+		Schemas       map[string]*openAPISchema      `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Deprecated  bool                `json:"deprecated"`
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string         `json:"$ref"`
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *openAPISchema `json:"schema"`
+	} `json:"content"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	AllOf      []*openAPISchema          `json:"allOf"`
+}
+
+var specMethods = []string{"get", "put", "post", "delete", "patch"}
+
+// parseSpecOperations reads every operation of an OpenAPI document.
+func parseSpecOperations(data []byte) (map[specRoute]specOperation, error) {
+	var doc openAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Paths == nil {
+		return nil, fmt.Errorf("no paths in the document")
+	}
+
+	ops := map[specRoute]specOperation{}
+	for path, item := range doc.Paths {
+		var shared []*openAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+		for _, method := range specMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(method), path, err)
+			}
+			parsed := specOperation{deprecated: op.Deprecated, params: map[string]specValue{}, fields: map[string]specValue{}}
+			for _, p := range append(append([]*openAPIParameter{}, shared...), op.Parameters...) {
+				p = doc.parameter(p)
+				if p == nil || p.Name == "" {
+					continue
+				}
+				parsed.params[p.In+" "+p.Name] = specValue{required: p.Required, typ: doc.schemaType(p.Schema)}
+			}
+			if body := doc.requestBody(op.RequestBody); body != nil {
+				if media, ok := body.Content["application/json"]; ok {
+					required, props := doc.flatten(media.Schema, 0)
+					for name, schema := range props {
+						parsed.fields[name] = specValue{required: required[name], typ: doc.schemaType(schema)}
+					}
+				}
+			}
+			ops[specRoute{strings.ToUpper(method), path}] = parsed
+		}
+	}
+	return ops, nil
+}
+
+// refName returns the last segment of a local $ref.
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (d *openAPIDocument) parameter(p *openAPIParameter) *openAPIParameter {
+	if p != nil && p.Ref != "" {
+		return d.Components.Parameters[refName(p.Ref)]
+	}
+	return p
+}
+
+func (d *openAPIDocument) requestBody(b *openAPIRequestBody) *openAPIRequestBody {
+	if b != nil && b.Ref != "" {
+		return d.Components.RequestBodies[refName(b.Ref)]
+	}
+	return b
+}
+
+// maxSchemaDepth bounds $ref and allOf resolution, so a recursive schema
+// cannot loop.
+const maxSchemaDepth = 16
+
+func (d *openAPIDocument) schema(s *openAPISchema, depth int) *openAPISchema {
+	for s != nil && s.Ref != "" && depth < maxSchemaDepth {
+		s = d.Components.Schemas[refName(s.Ref)]
+		depth++
+	}
+	return s
+}
+
+// schemaType names the type of s: its type, or the name of the schema it
+// refers to.
+func (d *openAPIDocument) schemaType(s *openAPISchema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+	return s.Type
+}
+
+// flatten returns the required fields and properties of an object schema,
+// merging allOf.
+func (d *openAPIDocument) flatten(s *openAPISchema, depth int) (map[string]bool, map[string]*openAPISchema) {
+	required := map[string]bool{}
+	props := map[string]*openAPISchema{}
+	s = d.schema(s, depth)
+	if s == nil || depth >= maxSchemaDepth {
+		return required, props
+	}
+	for _, part := range s.AllOf {
+		r, p := d.flatten(part, depth+1)
+		for k := range r {
+			required[k] = true
+		}
+		for k, v := range p {
+			props[k] = v
+		}
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	return required, props
+}
+
+// diffOperations describes what changed between two versions of an
+// operation, one line per change, sorted.
+func diffOperations(before, after specOperation) []string {
+	var details []string
+	if before.deprecated != after.deprecated {
+		if after.deprecated {
+			details = append(details, "now deprecated")
+		} else {
+			details = append(details, "no longer deprecated")
+		}
+	}
+	details = append(details, diffValues("parameter", before.params, after.params)...)
+	details = append(details, diffValues("body field", before.fields, after.fields)...)
+	return details
+}
+
+func diffValues(label string, before, after map[string]specValue) []string {
+	var lines []string
+	for name, a := range after {
+		b, ok := before[name]
+		switch {
+		case !ok && a.required:
+			lines = append(lines, fmt.Sprintf("+ %s %s (required)", label, name))
+		case !ok:
+			lines = append(lines, fmt.Sprintf("+ %s %s", label, name))
+		default:
+			if a.required != b.required {
+				state := "now required"
+				if !a.required {
+					state = "no longer required"
+				}
+				lines = append(lines, fmt.Sprintf("~ %s %s: %s", label, name, state))
+			}
+			if a.typ != b.typ {
+				lines = append(lines, fmt.Sprintf("~ %s %s: type %s -> %s", label, name, orNone(b.typ), orNone(a.typ)))
+			}
+		}
+	}
+	for name, b := range before {
+		if _, ok := after[name]; ok {
+			continue
+		}
+		if b.required {
+			lines = append(lines, fmt.Sprintf("- %s %s (was required)", label, name))
+		} else {
+			lines = append(lines, fmt.Sprintf("- %s %s", label, name))
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][2:] < lines[j][2:] })
+	return lines
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// specBefore and specAfter are trimmed versions of the spec around the move
+// of media uploads from ?command= to path-style endpoints.
+const specBefore = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/2/media/upload": {
+      "post": {
+        "parameters": [{"name": "command", "in": "query", "required": true, "schema": {"type": "string"}}]
+      }
+    },
+    "/2/users/{id}/followers": {
+      "parameters": [{"$ref": "#/components/parameters/UserIdParameter"}],
+      "get": {
+        "description": "Returns followers.",
+        "parameters": [
+          {"name": "max_results", "in": "query", "schema": {"type": "integer"}},
+          {"name": "since", "in": "query", "schema": {"type": "string"}}
+        ]
+      }
+    },
+    "/2/tweets": {
+      "post": {
+        "requestBody": {"$ref": "#/components/requestBodies/TweetCreate"}
+      }
+    },
+    "/2/internal/thing": {
+      "get": {"parameters": []}
+    }
+  },
+  "components": {
+    "parameters": {
+      "UserIdParameter": {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}
+    },
+    "requestBodies": {
+      "TweetCreate": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/TweetCreateRequest"}}}}
+    },
+    "schemas": {
+      "TweetCreateRequest": {
+        "type": "object",
+        "properties": {"text": {"type": "string"}, "reply": {"$ref": "#/components/schemas/Reply"}}
+      },
+      "Reply": {"type": "object"}
+    }
+  }
+}`
+
+const specAfter = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/2/media/upload/initialize": {
+      "post": {"requestBody": {"content": {"application/json": {"schema": {
+        "type": "object", "required": ["total_bytes"],
+        "properties": {"total_bytes": {"type": "integer"}, "media_type": {"type": "string"}}
+      }}}}}
+    },
+    "/2/media/upload/{id}/append": {
+      "post": {"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}]}
+    },
+    "/2/users/{id}/followers": {
+      "parameters": [{"$ref": "#/components/parameters/UserIdParameter"}],
+      "get": {
+        "description": "Returns the followers of a user.",
+        "parameters": [
+          {"name": "max_results", "in": "query", "required": true, "schema": {"type": "integer"}},
+          {"name": "pagination_token", "in": "query", "schema": {"type": "string"}}
+        ]
+      }
+    },
+    "/2/tweets": {
+      "post": {
+        "deprecated": true,
+        "requestBody": {"$ref": "#/components/requestBodies/TweetCreate"}
+      }
+    },
+    "/2/internal/thing": {
+      "get": {"parameters": [{"name": "x", "in": "query", "schema": {"type": "string"}}]}
+    }
+  },
+  "components": {
+    "parameters": {
+      "UserIdParameter": {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}
+    },
+    "requestBodies": {
+      "TweetCreate": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/TweetCreateRequest"}}}}
+    },
+    "schemas": {
+      "TweetCreateRequest": {
+        "allOf": [
+          {"type": "object", "required": ["text"], "properties": {"text": {"type": "string"}}},
+          {"type": "object", "properties": {"reply": {"type": "object"}, "media": {"type": "object"}}}
+        ]
+      }
+    }
+  }
+}`
+
+func TestDiffSpecs(t *testing.T) {
+	changes, err := DiffSpecs([]byte(specBefore), []byte(specAfter))
+	require.NoError(t, err)
+
+	byRoute := map[string]SpecChange{}
+	for _, c := range changes {
+		byRoute[c.Method+" "+c.Path] = c
+	}
+	require.Len(t, byRoute, 6, "%+v", changes)
+
+	removed := byRoute["POST /2/media/upload"]
+	assert.Equal(t, "removed", removed.Kind)
+	assert.False(t, removed.InCatalog, "the query-command route is no longer in the catalog")
+
+	for _, route := range []string{"POST /2/media/upload/initialize", "POST /2/media/upload/{id}/append"} {
+		assert.Equal(t, "added", byRoute[route].Kind, route)
+		assert.True(t, byRoute[route].InCatalog, route)
+	}
+
+	followers := byRoute["GET /2/users/{id}/followers"]
+	assert.Equal(t, "changed", followers.Kind)
+	assert.True(t, followers.InCatalog)
+	assert.Equal(t, []string{
+		"~ parameter query max_results: now required",
+		"+ parameter query pagination_token",
+		"- parameter query since",
+	}, followers.Details, "description changes are ignored")
+
+	tweets := byRoute["POST /2/tweets"]
+	assert.True(t, tweets.InCatalog)
+	assert.Equal(t, []string{
+		"now deprecated",
+		"+ body field media",
+		"~ body field reply: type Reply -> object",
+		"~ body field text: now required",
+	}, tweets.Details)
+
+	internal := byRoute["GET /2/internal/thing"]
+	assert.False(t, internal.InCatalog)
+	assert.Equal(t, []string{"+ parameter query x"}, internal.Details)
+}
+
+func TestDiffSpecsUnchanged(t *testing.T) {
+	changes, err := DiffSpecs([]byte(specBefore), []byte(specBefore))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = DiffSpecs([]byte(specBefore), []byte(`{"openapi": "3.0.0"}`))
+	assert.Error(t, err)
+}
+
+func TestRouteShape(t *testing.T) {
+	assert.Equal(t, routeShape("/2/users/:id/likes/:tweet_id"), routeShape("/2/users/{id}/likes/{tweet_id}"))
+	assert.NotEqual(t, routeShape("/2/users/:id/likes"), routeShape("/2/users/{id}/liked_tweets"))
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// templateNow is the clock of the now and date template functions.
+var templateNow = time.Now
+
+// newRequestTemplate creates the template of a request URL, header or body,
+// with these functions besides the builtins such as urlquery:
+//
+//	json VALUE         VALUE as JSON, e.g. a quoted and escaped string
+//	jsonEscape STRING  STRING escaped for use inside a JSON string
+//	env NAME           the environment variable NAME, which must be set
+//	readFile PATH      the contents of a file; a relative PATH is resolved
+//	                   against dir when it is set
+//	trim STRING        STRING without leading and trailing whitespace
+//	now                the current time, whose methods such as Format,
+//	                   AddDate and UTC can be called
+//	date LAYOUT        the current time formatted with a Go layout
+func newRequestTemplate(name, dir string) *template.Template {
+	now := templateNow()
+	return template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"jsonEscape": func(s string) (string, error) {
+			b, err := json.Marshal(s)
+			if err != nil {
+				return "", err
+			}
+			return string(b[1 : len(b)-1]), nil
+		},
+		"env": func(name string) (string, error) {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			return value, nil
+		},
+		"readFile": func(path string) (string, error) {
+			if dir != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			data, err := os.ReadFile(path)
+			return string(data), err
+		},
+		"trim": strings.TrimSpace,
+		"now":  func() time.Time { return now },
+		"date": func(layout string) string { return now.Format(layout) },
+	})
+}
+
+// ParseTemplateVars parses --var name=value pairs into the variables of
+// ExpandRequestTemplates. A later pair overrides an earlier one with the
+// same name.
+func ParseTemplateVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", pair)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// ExpandRequestTemplates renders the endpoint, the header values and the
+// body (Data) of options as Go templates over vars, for --expand-template:
+// '/2/users/{{.id}}' with id=123 becomes '/2/users/123'. Referencing a
+// variable that is not in vars is an error. {{json .name}} inserts a value
+// as a quoted JSON string, and {{urlquery .name}} escapes it for a URL; see
+// newRequestTemplate for the other functions. The rendered endpoint must be
+// a usable URL (see ValidateEndpoint).
+func ExpandRequestTemplates(options *RequestOptions, vars map[string]string) error {
+	endpoint, err := renderTemplate("URL", options.Endpoint, vars)
+	if err != nil {
+		return err
+	}
+	if err := ValidateEndpoint(endpoint); err != nil {
+		return fmt.Errorf("the rendered URL %q is invalid: %v", endpoint, err)
+	}
+
+	headers := make([]string, len(options.Headers))
+	for i, header := range options.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			headers[i] = header
+			continue
+		}
+		rendered, err := renderTemplate("header "+name, value, vars)
+		if err != nil {
+			return err
+		}
+		if strings.ContainsAny(rendered, "\r\n") {
+			return fmt.Errorf("the rendered header %s contains a line break", name)
+		}
+		headers[i] = name + ":" + rendered
+	}
+
+	data, err := renderTemplate("body", options.Data, vars)
+	if err != nil {
+		return err
+	}
+
+	options.Endpoint, options.Headers, options.Data = endpoint, headers, data
+	return nil
+}
+
+// renderTemplate executes text as a template over vars; what names the
+// text in error messages.
+func renderTemplate(what, text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := newRequestTemplate(what, "").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template in the %s: %v", what, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("could not render the %s (set variables with --var name=value): %v", what, err)
+	}
+	return out.String(), nil
+}
+
+// RenderBodyTemplate renders the request body template in the file at path
+// (--data-template) over vars, like ExpandRequestTemplates renders -d;
+// readFile resolves relative paths against the template's directory. Errors
+// name the template line. The rendered body must be valid JSON (see
+// ValidateJSONBody).
+func RenderBodyTemplate(path string, vars map[string]string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read the body template: %v", err)
+	}
+	name := filepath.Base(path)
+	tmpl, err := newRequestTemplate(name, filepath.Dir(path)).Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid body template: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("could not render the body template (set variables with --var name=value): %v", err)
+	}
+	body := out.String()
+	if err := ValidateJSONBody(body); err != nil {
+		return "", fmt.Errorf("the body rendered from %s is not valid JSON: %v", name, err)
+	}
+	return body, nil
+}
+
+// ValidateEndpoint checks that endpoint can be requested: an http or https
+// URL with a host, or a path on the API such as /2/users/me, without
+// whitespace or control characters.
+func ValidateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("it is empty")
+	}
+	if i := strings.IndexFunc(endpoint, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }); i >= 0 {
+		return fmt.Errorf("it contains whitespace or a control character at offset %d", i)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(endpoint), "http") {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("the scheme must be http or https")
+		}
+		if u.Host == "" {
+			return fmt.Errorf("it has no host")
+		}
+		return nil
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return fmt.Errorf("it must be an http(s) URL or a path such as /2/users/me")
+	}
+	return nil
+}
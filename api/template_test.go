@@ -0,0 +1,124 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateVars(t *testing.T) {
+	vars, err := ParseTemplateVars([]string{"id=123", "q=a=b", "id=456", "empty="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "456", "q": "a=b", "empty": ""}, vars, "a later pair wins; values may contain =")
+
+	for _, bad := range []string{"id", "=123"} {
+		_, err := ParseTemplateVars([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestExpandRequestTemplatesURL(t *testing.T) {
+	vars := map[string]string{"id": "123", "q": "from:x dev", "host": "api.x.com"}
+	for template, want := range map[string]string{
+		"/2/users/{{.id}}": "/2/users/123",
+		"/2/tweets/search/recent?query={{urlquery .q}}": "/2/tweets/search/recent?query=from%3Ax+dev",
+		"https://{{.host}}/2/users/{{.id}}/followers":   "https://api.x.com/2/users/123/followers",
+		"/2/users/me": "/2/users/me",
+	} {
+		options := RequestOptions{Endpoint: template}
+		require.NoError(t, ExpandRequestTemplates(&options, vars), template)
+		assert.Equal(t, want, options.Endpoint, template)
+	}
+}
+
+func TestExpandRequestTemplatesHeadersAndBody(t *testing.T) {
+	options := RequestOptions{
+		Endpoint: "/2/users/{{.id}}",
+		Headers:  []string{"X-Request-Source: job-{{.id}}", "Accept: application/json", "X-Raw"},
+		Data:     `{"text": {{json .text}}}`,
+	}
+	require.NoError(t, ExpandRequestTemplates(&options, map[string]string{"id": "7", "text": `say "hi"`}))
+	assert.Equal(t, []string{"X-Request-Source: job-7", "Accept: application/json", "X-Raw"}, options.Headers)
+	assert.JSONEq(t, `{"text": "say \"hi\""}`, options.Data)
+}
+
+func TestExpandRequestTemplatesErrors(t *testing.T) {
+	vars := map[string]string{"id": "1 2", "newline": "a\r\nX-Injected: 1", "scheme": "ftp"}
+	for name, tc := range map[string]struct {
+		options RequestOptions
+		err     string
+	}{
+		"missing variable":  {RequestOptions{Endpoint: "/2/users/{{.nope}}"}, `map has no entry for key "nope"`},
+		"bad syntax":        {RequestOptions{Endpoint: "/2/users/{{.id"}, "invalid template in the URL"},
+		"whitespace in URL": {RequestOptions{Endpoint: "/2/users/{{.id}}"}, "whitespace"},
+		"bad scheme":        {RequestOptions{Endpoint: "{{.scheme}}://api.x.com/2/users/me"}, "must be an http(s) URL"},
+		"no host":           {RequestOptions{Endpoint: "https:///2/users/{{urlquery .id}}"}, "no host"},
+		"header injection":  {RequestOptions{Endpoint: "/2/users/me", Headers: []string{"X-A: {{.newline}}"}}, "line break"},
+		"missing in body":   {RequestOptions{Endpoint: "/2/tweets", Data: `{"text": "{{.text}}"}`}, "could not render the body"},
+	} {
+		original := tc.options
+		err := ExpandRequestTemplates(&tc.options, vars)
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.err, name)
+		}
+		assert.Equal(t, original, tc.options, "%s: options are left alone on error", name)
+	}
+}
+
+func writeTemplateFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRenderBodyTemplate(t *testing.T) {
+	defer func(now func() time.Time) { templateNow = now }(templateNow)
+	templateNow = func() time.Time { return time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC) }
+	t.Setenv("XURL_TEST_REGION", "eu-west")
+
+	dir := t.TempDir()
+	writeTemplateFixture(t, dir, "footer.txt", "Powered by \"xurl\"\n")
+	path := writeTemplateFixture(t, dir, "metrics.tmpl", `{
+  "text": "Daily metrics for {{date "Jan 2"}} ({{(now.AddDate 0 0 -1).Format "2006-01-02"}} data)\n`+
+		`Signups: {{.signups}} in {{env "XURL_TEST_REGION"}}\n{{readFile "footer.txt" | trim | jsonEscape}}",
+  "reply_settings": {{json .audience}}
+}`)
+
+	body, err := RenderBodyTemplate(path, map[string]string{"signups": "1,204", "audience": "following"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"text": "Daily metrics for Mar 1 (2026-02-28 data)\nSignups: 1,204 in eu-west\nPowered by \"xurl\"",
+		"reply_settings": "following"
+	}`, body)
+}
+
+func TestRenderBodyTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		template string
+		err      string
+	}{
+		"missing variable": {"{\n  \"a\": 1,\n  \"text\": {{json .text}}\n}", "body.tmpl:3:"},
+		"unset env":        {"{\n  \"text\": \"{{env \"XURL_TEST_UNSET\"}}\"\n}", "body.tmpl:2:"},
+		"syntax error":     {"{\n\n  \"text\": \"{{.text\"\n}", "body.tmpl:3:"},
+		"missing file":     {`{"text": {{readFile "missing.txt" | json}}}`, "missing.txt"},
+		"invalid JSON":     {"{\n  \"text\": {{.text}}\n}", "not valid JSON"},
+	} {
+		path := writeTemplateFixture(t, dir, "body.tmpl", tc.template)
+		_, err := RenderBodyTemplate(path, map[string]string{"text": "hello"})
+		if name == "missing variable" {
+			_, err = RenderBodyTemplate(path, nil)
+		}
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.err, name)
+		}
+	}
+
+	_, err := RenderBodyTemplate(filepath.Join(dir, "nope.tmpl"), nil)
+	assert.ErrorContains(t, err, "could not read the body template")
+}
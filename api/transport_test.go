@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestParsePublicKeyPins(t *testing.T) {
+	valid := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	pins, err := ParsePublicKeyPins([]string{"sha256//" + valid, " " + valid + " ; sha256//" + valid})
+	require.NoError(t, err)
+	assert.Equal(t, []string{valid, valid, valid}, pins)
+
+	_, err = ParsePublicKeyPins([]string{"not-base64!"})
+	assert.Error(t, err)
+	_, err = ParsePublicKeyPins([]string{"dGVzdA=="}) // valid base64, wrong length
+	assert.Error(t, err)
+}
+
+func TestPinTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	base := srv.Client().Transport.(*http.Transport)
+	pin := PublicKeyPin(srv.Certificate().RawSubjectPublicKeyInfo)
+
+	t.Run("matching pin connects", func(t *testing.T) {
+		client := &http.Client{Transport: PinTransport(base, []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", pin})}
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("mismatched pin fails the connection", func(t *testing.T) {
+		client := &http.Client{Transport: PinTransport(base, []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="})}
+		_, err := client.Get(srv.URL)
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "sha256//"+pin), err.Error())
+	})
+
+	t.Run("base transport is not modified", func(t *testing.T) {
+		assert.Nil(t, base.TLSClientConfig.VerifyConnection)
+	})
+
+	t.Run("an ApiClient sends requests and streams through its transport", func(t *testing.T) {
+		authMock, tempDir := createMockAuth(t)
+		defer os.RemoveAll(tempDir)
+		client := NewApiClient(&config.Config{APIBaseURL: srv.URL}, authMock).
+			WithTransport(PinTransport(base, []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}))
+
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sha256//"+pin)
+		err = client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"}, StreamHandler{
+			OnLine: func(string) error { return nil },
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sha256//"+pin)
+
+		client.WithTransport(PinTransport(base, []string{pin}))
+		resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ok":true}`, string(resp))
+	})
+}
+
+func TestParseProxyAuth(t *testing.T) {
+	user, password, err := ParseProxyAuth("alice:s3:cret")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "s3:cret", password)
+
+	for _, bad := range []string{"alice", ":secret", ""} {
+		_, _, err := ParseProxyAuth(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestProxyAuthTransport(t *testing.T) {
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:s3:cret"))
+
+	// proxy stands in for an authenticated corporate proxy: it answers
+	// requests for any host itself, but only with the right credentials.
+	var connectAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			connectAuth = r.Header.Get("Proxy-Authorization")
+			http.Error(w, "tunnels not allowed", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != want {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="corp"`)
+			http.Error(w, "proxy auth required", http.StatusProxyAuthRequired)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"id":"1","host":"` + r.URL.Host + `"}}` + "\n"))
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	base := CloneDefaultTransport()
+	base.Proxy = http.ProxyURL(proxyURL)
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: "http://api.x.test"}, authMock)
+
+	t.Run("without credentials the proxy refuses", func(t *testing.T) {
+		client.WithTransport(base)
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+	})
+
+	client.WithTransport(ProxyAuthTransport(base, "alice", "s3:cret"))
+
+	t.Run("regular requests", func(t *testing.T) {
+		resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"1","host":"api.x.test"}}`, string(resp))
+	})
+
+	t.Run("streaming requests", func(t *testing.T) {
+		var lines []string
+		err := client.StreamRequest(RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"}, StreamHandler{
+			OnLine: func(line string) error { lines = append(lines, line); return nil },
+		})
+		require.NoError(t, err)
+		assert.Len(t, lines, 1)
+	})
+
+	t.Run("HTTPS tunnels", func(t *testing.T) {
+		c := NewApiClient(&config.Config{APIBaseURL: "https://api.x.test"}, authMock).WithTransport(ProxyAuthTransport(base, "alice", "s3:cret"))
+		_, err := c.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+		assert.Equal(t, want, connectAuth, "CONNECT carries the credentials")
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxLookupIDs is the most IDs or usernames the multi-user lookup endpoints
+// (and other ?ids= endpoints) accept in one request.
+const MaxLookupIDs = 100
+
+// batches splits items into consecutive slices of at most size items, for
+// endpoints that limit how many IDs one request may carry.
+func batches(items []string, size int) [][]string {
+	var out [][]string
+	for start := 0; start < len(items); start += size {
+		out = append(out, items[start:min(start+size, len(items))])
+	}
+	return out
+}
+
+// UserLookup is the merged result of the batched requests of LookupUsers:
+// every user found and every error the API reported, such as users that do
+// not exist or are suspended.
+type UserLookup struct {
+	Data   []json.RawMessage `json:"data"`
+	Errors []json.RawMessage `json:"errors,omitempty"`
+}
+
+// LookupUsers looks up users by username (with or without "@") and by ID,
+// through /2/users/by?usernames= and /2/users?ids= in batches of
+// MaxLookupIDs, and merges the responses. In a dry run the first request is
+// returned as it is.
+func LookupUsers(client Client, usernames, ids []string, opts RequestOptions) (json.RawMessage, error) {
+	return LookupUsersConcurrently(client, usernames, ids, opts, 1, nil)
+}
+
+// LookupUsersConcurrently is LookupUsers sending up to concurrency batches
+// at once through FanOut, which waits out rate limits when pause is set.
+// The merged users and errors are in batch order either way.
+func LookupUsersConcurrently(client Client, usernames, ids []string, opts RequestOptions, concurrency int, pause *RateLimitPause) (json.RawMessage, error) {
+	names := make([]string, len(usernames))
+	for i, u := range usernames {
+		names[i] = ResolveUsername(u)
+	}
+	var endpoints []string
+	for _, batch := range batches(names, MaxLookupIDs) {
+		endpoints = append(endpoints, "/2/users/by?usernames="+url.QueryEscape(strings.Join(batch, ",")))
+	}
+	for _, batch := range batches(ids, MaxLookupIDs) {
+		endpoints = append(endpoints, "/2/users?ids="+url.QueryEscape(strings.Join(batch, ",")))
+	}
+
+	pages, err := FanOut(len(endpoints), concurrency, pause, func(i int) (json.RawMessage, error) {
+		batchOpts := opts
+		batchOpts.Method = "GET"
+		batchOpts.Endpoint = endpoints[i] + "&user.fields=username,name,verified"
+		batchOpts.Data = ""
+		return client.SendRequest(batchOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > 0 && IsDryRun(pages[0]) {
+		return pages[0], nil
+	}
+
+	merged := UserLookup{Data: []json.RawMessage{}}
+	for _, resp := range pages {
+		var page UserLookup
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse users response: %w", err)
+		}
+		merged.Data = append(merged.Data, page.Data...)
+		merged.Errors = append(merged.Errors, page.Errors...)
+	}
+	return json.Marshal(merged)
+}
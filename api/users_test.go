@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatches(t *testing.T) {
+	assert.Nil(t, batches(nil, 100))
+	assert.Equal(t, [][]string{{"1", "2"}, {"3"}}, batches([]string{"1", "2", "3"}, 2))
+	assert.Equal(t, [][]string{{"1", "2"}}, batches([]string{"1", "2"}, 100))
+}
+
+func TestLookupUsers(t *testing.T) {
+	ids := make([]string, 150)
+	for i := range ids {
+		ids[i] = fmt.Sprint(1000 + i)
+	}
+
+	mockClient := new(MockApiClient)
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return strings.HasPrefix(o.Endpoint, "/2/users/by?usernames=jack%2Cnobody&")
+	})).Return(json.RawMessage(`{"data":[{"id":"12","username":"jack"}],"errors":[{"value":"nobody","detail":"Could not find user with usernames: [nobody]."}]}`), nil).Once()
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return strings.HasPrefix(o.Endpoint, "/2/users?ids=1000%2C") && strings.Count(o.Endpoint, "%2C") == 99
+	})).Return(json.RawMessage(`{"data":[{"id":"1000","username":"a"}]}`), nil).Once()
+	mockClient.On("SendRequest", mock.MatchedBy(func(o RequestOptions) bool {
+		return strings.HasPrefix(o.Endpoint, "/2/users?ids=1100%2C") && strings.Count(o.Endpoint, "%2C") == 49
+	})).Return(json.RawMessage(`{"data":[{"id":"1149","username":"b"}],"errors":[{"value":"1101"}]}`), nil).Once()
+
+	usernames := []string{"@jack", "nobody"}
+	resp, err := LookupUsers(mockClient, usernames, ids, baseTestOpts())
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	assert.Equal(t, []string{"@jack", "nobody"}, usernames, "the arguments are not modified")
+
+	var merged UserLookup
+	require.NoError(t, json.Unmarshal(resp, &merged))
+	assert.Len(t, merged.Data, 3, "data of every batch is merged")
+	assert.Len(t, merged.Errors, 2, "errors of every batch are merged")
+}
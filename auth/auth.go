@@ -2,9 +2,7 @@ package auth
 
 import (
 	"context"
-	"crypto/hmac"
 	"crypto/rand"
-	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
@@ -16,8 +14,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"sort"
 	"strings"
 	"time"
 
@@ -25,8 +21,6 @@ import (
 	xurlErrors "github.com/xdevplatform/xurl/errors"
 	"github.com/xdevplatform/xurl/store"
 
-	"runtime"
-
 	"golang.org/x/oauth2"
 )
 
@@ -39,23 +33,32 @@ type Auth struct {
 	tokenURL           string
 	redirectURI        string
 	redirectURIFromEnv bool
+	callbackPath       string // replaces the redirect URI's path when set
 	appName            string // explicit app override (empty = use default)
+	strictAuth         bool   // disable the silent credential fallback chain
+	printURLOnly       bool   // never launch a browser in OAuth2Flow
+	openCmd            string // command template OAuth2Flow opens the URL with
+	successRedirect    string // where the callback sends the browser on success
+	oauth1Debug        io.Writer
+	transport          http.RoundTripper // nil uses http.DefaultTransport
 }
 
 var openBrowserFunc = openBrowser
 
-var startListenerFunc = StartListener
+var startListenerFunc = startCallbackListener
 
 // oauth2ExpirySkewSeconds refreshes a token slightly before its real expiry so a
 // token handed to a caller does not expire mid-request.
 const oauth2ExpirySkewSeconds = 30
 
 // NewAuth creates a new Auth object.
-// Credentials are resolved in order: env-var config → active app in .xurl store.
+// Credentials are resolved in order: env-var config → active app in the token store.
 // If env var credentials are present, they're also backfilled into any migrated
-// app that has tokens but no stored credentials.
+// app that has tokens but no stored credentials. ~/.twurlrc is not imported
+// here, so the caller can honor --no-twurl-import first; see
+// store.TokenStore.AutoImportTwurlrc.
 func NewAuth(cfg *config.Config) *Auth {
-	ts := store.NewTokenStoreWithCredentials(cfg.ClientID, cfg.ClientSecret)
+	ts := store.OpenTokenStore(cfg.StorePath, cfg.ClientID, cfg.ClientSecret)
 
 	// Resolve client ID / secret: env vars take priority, then the active app.
 	clientID := cfg.ClientID
@@ -112,6 +115,138 @@ func (a *Auth) WithAppName(appName string) *Auth {
 	return a
 }
 
+// WithConfig re-applies endpoint URLs and env-provided credentials from cfg,
+// e.g. after an --env-file has been loaded. Credentials and the redirect URI
+// are only replaced when cfg actually carries them from the environment.
+func (a *Auth) WithConfig(cfg *config.Config) *Auth {
+	a.infoURL = cfg.InfoURL
+	a.authURL = cfg.AuthURL
+	a.tokenURL = cfg.TokenURL
+	if cfg.ClientID != "" {
+		a.clientID = cfg.ClientID
+	}
+	if cfg.ClientSecret != "" {
+		a.clientSecret = cfg.ClientSecret
+	}
+	if cfg.RedirectURIFromEnv {
+		a.redirectURI = cfg.RedirectURI
+		a.redirectURIFromEnv = true
+	}
+	return a
+}
+
+// WithClientCredentials overrides the OAuth2 client ID and secret resolved
+// from the environment and the active app, e.g. with 'auth oauth2
+// --client-id'. Empty values keep the resolved ones.
+func (a *Auth) WithClientCredentials(clientID, clientSecret string) *Auth {
+	if clientID != "" {
+		a.clientID = clientID
+	}
+	if clientSecret != "" {
+		a.clientSecret = clientSecret
+	}
+	return a
+}
+
+// WithOAuth1Debug makes GetOAuth1Header write the inputs of every signature
+// it computes to w (secrets are reduced to their lengths). nil disables it.
+func (a *Auth) WithOAuth1Debug(w io.Writer) *Auth {
+	a.oauth1Debug = w
+	return a
+}
+
+// WithPrintURLOnly makes OAuth2Flow print the authorization URL without
+// trying to open a browser.
+func (a *Auth) WithPrintURLOnly(printOnly bool) *Auth {
+	a.printURLOnly = printOnly
+	return a
+}
+
+// WithCallbackPath makes the OAuth2 flows use path, such as
+// "/oauth/callback", as both the redirect URI's path and the path the local
+// callback listener serves, to match the redirect URI registered for the app.
+// An empty path keeps the redirect URI's own. The path is checked with
+// ValidateCallbackPath when a flow starts.
+func (a *Auth) WithCallbackPath(path string) *Auth {
+	a.callbackPath = path
+	return a
+}
+
+// ValidateCallbackPath reports whether path can be used with
+// WithCallbackPath: it must start with "/" and be a bare path, without a
+// query, fragment or whitespace.
+func ValidateCallbackPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("callback path %q must begin with /", path)
+	}
+	if strings.ContainsAny(path, "?# \t\r\n") {
+		return fmt.Errorf("callback path %q must be a bare path, without a query, fragment or spaces", path)
+	}
+	return nil
+}
+
+// effectiveRedirectURI is the redirect URI the OAuth2 flows use: the app's,
+// with its path replaced by the callback path when one is set.
+func (a *Auth) effectiveRedirectURI() (string, error) {
+	if a.callbackPath == "" || a.redirectURI == "" {
+		return a.redirectURI, nil
+	}
+	if err := ValidateCallbackPath(a.callbackPath); err != nil {
+		return "", xurlErrors.NewAuthError("InvalidCallbackPath", err)
+	}
+	u, err := url.Parse(a.redirectURI)
+	if err != nil {
+		return "", xurlErrors.NewAuthError("InvalidRedirectURI", err)
+	}
+	u.Path, u.RawPath = a.callbackPath, ""
+	return u.String(), nil
+}
+
+// WithOpenCommand makes OAuth2Flow open the authorization URL by running
+// command, such as "wslview {url}", instead of the platform's launchers.
+// {url} is replaced by the URL, which is appended when command has no {url}.
+// An empty command restores the default.
+func (a *Auth) WithOpenCommand(command string) *Auth {
+	a.openCmd = command
+	return a
+}
+
+// WithSuccessRedirect makes the OAuth2Flow callback redirect the browser to
+// target after a successful login instead of showing xurl's own success page.
+// target is checked with ValidateSuccessRedirect when the flow starts. An
+// empty target restores the page.
+func (a *Auth) WithSuccessRedirect(target string) *Auth {
+	a.successRedirect = target
+	return a
+}
+
+// StrictAuth reports whether credential fallback is disabled. In strict mode a
+// request whose selected credential cannot be produced fails instead of
+// silently trying the next stored credential type.
+func (a *Auth) StrictAuth() bool {
+	return a.strictAuth
+}
+
+// WithStrictAuth enables or disables strict credential selection.
+func (a *Auth) WithStrictAuth(strict bool) *Auth {
+	a.strictAuth = strict
+	return a
+}
+
+// WithTransport sets the transport of the OAuth2 token exchange, token
+// refreshes and identity lookups. A nil transport uses
+// http.DefaultTransport.
+func (a *Auth) WithTransport(rt http.RoundTripper) *Auth {
+	a.transport = rt
+	return a
+}
+
+// httpContext returns a context that makes the oauth2 package send through
+// the configured transport.
+func (a *Auth) httpContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: a.transport})
+}
+
 func (a *Auth) resolveRedirectURIForApp(appName string) string {
 	app := a.TokenStore.ResolveApp(appName)
 	if app != nil && app.RedirectURI != "" {
@@ -152,10 +287,14 @@ func (a *Auth) GetOAuth1Header(method, urlStr string, additionalParams map[strin
 	params["oauth_token"] = oauth1Token.AccessToken
 	params["oauth_version"] = "1.0"
 
-	signature, err := generateSignature(method, urlStr, params, oauth1Token.ConsumerSecret, oauth1Token.TokenSecret)
+	sig, err := signOAuth1(method, urlStr, params, oauth1Token.ConsumerSecret, oauth1Token.TokenSecret)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("SignatureGenerationError", err)
 	}
+	if a.oauth1Debug != nil {
+		sig.WriteTo(a.oauth1Debug)
+	}
+	signature := sig.Signature
 
 	var oauthParams []string
 	oauthParams = append(oauthParams, fmt.Sprintf("oauth_consumer_key=\"%s\"", encode(oauth1Token.ConsumerKey)))
@@ -242,38 +381,73 @@ type oauth2Attempt struct {
 // prepareOAuth2Flow generates the state and PKCE verifier/challenge and builds
 // the authorize URL.
 func (a *Auth) prepareOAuth2Flow() (*oauth2Attempt, error) {
+	if a.clientID == "" {
+		return nil, xurlErrors.NewAuthError("MissingClientID", errors.New("no OAuth2 client ID: pass --client-id, set CLIENT_ID, or register an app with 'xurl auth apps add'"))
+	}
+	redirectURI, err := a.effectiveRedirectURI()
+	if err != nil {
+		return nil, err
+	}
 	config := a.newOAuth2Config()
+	config.RedirectURL = redirectURI
 
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		return nil, xurlErrors.NewAuthError("IOError", err)
 	}
-	state := base64.StdEncoding.EncodeToString(b)
+	state := base64.RawURLEncoding.EncodeToString(b)
 
 	verifier, challenge, err := generateCodeVerifierAndChallenge()
 	if err != nil {
 		return nil, xurlErrors.NewAuthError("IOError", err)
 	}
 
-	authURL := config.AuthCodeURL(state,
-		oauth2.SetAuthURLParam("code_challenge", challenge),
-		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+	authURL, err := authorizeURL(config, state, challenge)
+	if err != nil {
+		return nil, err
+	}
 
 	return &oauth2Attempt{config: config, state: state, verifier: verifier, authURL: authURL}, nil
 }
 
+// authorizeURL builds the authorize URL for config with every parameter X
+// requires set explicitly, rather than left to x/oauth2, which drops
+// redirect_uri and scope when they are empty. Values are percent-encoded
+// (spaces as %20, not +), so the URL survives being handed to a browser
+// launcher on any platform.
+func authorizeURL(config *oauth2.Config, state, challenge string) (string, error) {
+	if config.RedirectURL == "" {
+		return "", xurlErrors.NewAuthError("MissingRedirectURI", errors.New("no OAuth2 redirect URI: set REDIRECT_URI or the app's redirect URI"))
+	}
+	u, err := url.Parse(config.Endpoint.AuthURL)
+	if err != nil {
+		return "", xurlErrors.NewAuthError("InvalidURL", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", config.ClientID)
+	q.Set("redirect_uri", config.RedirectURL)
+	q.Set("scope", strings.Join(config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	// Encode escapes a literal + as %2B, so any + left is an encoded space.
+	u.RawQuery = strings.ReplaceAll(q.Encode(), "+", "%20")
+	return u.String(), nil
+}
+
 // exchangeAndSave swaps an authorization code for a token (using the PKCE
 // verifier) and persists it. Diagnostics go to stderr so callers that reserve
 // stdout for machine output (e.g. the mcp bridge) are never corrupted.
 func (a *Auth) exchangeAndSave(attempt *oauth2Attempt, username, code string) (string, error) {
-	token, err := attempt.config.Exchange(context.Background(), code,
+	token, err := attempt.config.Exchange(a.httpContext(), code,
 		oauth2.SetAuthURLParam("code_verifier", attempt.verifier))
 	if err != nil {
 		return "", xurlErrors.NewAuthError("TokenExchangeError", err)
 	}
 
-	usernameStr, resolvedFromLookup := a.resolveStorageUsername(username, token.AccessToken)
-	if err := a.saveOAuth2Token(usernameStr, token); err != nil {
+	usernameStr, identity, resolvedFromLookup := a.resolveStorageUsername(username, token.AccessToken)
+	if err := a.saveOAuth2Token(usernameStr, token, identity); err != nil {
 		return "", xurlErrors.NewAuthError("TokenStorageError", err)
 	}
 	if username == "" && !resolvedFromLookup {
@@ -293,10 +467,15 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 		return "", err
 	}
 
-	listenerConfig, err := listenerConfigFromRedirectURI(a.redirectURI)
+	listenerConfig, err := listenerConfigFromRedirectURI(attempt.config.RedirectURL)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("InvalidRedirectURI", err)
 	}
+	if a.successRedirect != "" {
+		if err := ValidateSuccessRedirect(a.successRedirect); err != nil {
+			return "", xurlErrors.NewAuthError("InvalidSuccessRedirect", err)
+		}
+	}
 
 	codeChan := make(chan string, 1)
 	listenerReady := make(chan struct{})
@@ -316,7 +495,7 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 	}
 
 	go func() {
-		if err := startListenerFunc(listenerConfig.Addresses, listenerConfig.CallbackPath, callback, listenerReady); err != nil {
+		if err := startListenerFunc(listenerConfig.Addresses, listenerConfig.CallbackPath, a.successRedirect, callback, listenerReady); err != nil {
 			listenerErrChan <- err
 		}
 	}()
@@ -327,11 +506,23 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 		return "", xurlErrors.NewAuthError("ListenerError", err)
 	}
 
-	if err := openBrowserFunc(attempt.authURL); err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to open browser automatically. Please visit this URL manually:")
-		fmt.Fprintln(os.Stderr, attempt.authURL)
-		fmt.Fprintln(os.Stderr, "(On a remote/headless machine, re-run with --headless to paste the code instead.)")
+	// Print the URL before trying the browser, so it is always there to copy
+	// whether or not a browser opens (or the right one does).
+	fmt.Fprintln(os.Stderr, "Open this URL in your browser to authorize xurl:")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "  "+attempt.authURL)
+	fmt.Fprintln(os.Stderr)
+	if !a.printURLOnly {
+		open := openBrowserFunc
+		if a.openCmd != "" {
+			open = func(url string) error { return runOpenCommand(a.openCmd, url, openCommandGrace) }
+		}
+		if err := open(attempt.authURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not open a browser automatically (%v).\n", err)
+			fmt.Fprintln(os.Stderr, "Open the URL above manually. (On a remote/headless machine, re-run with --headless to paste the code instead.)")
+		}
 	}
+	fmt.Fprintf(os.Stderr, "Waiting for the callback on %s ...\n", attempt.config.RedirectURL)
 
 	var code string
 	select {
@@ -376,7 +567,7 @@ func (h *HeadlessLogin) AuthURL() string { return h.attempt.authURL }
 
 // RedirectURI is the callback the browser is redirected to (where the code
 // appears in the address bar), shown to the user so they know what to copy.
-func (h *HeadlessLogin) RedirectURI() string { return h.auth.redirectURI }
+func (h *HeadlessLogin) RedirectURI() string { return h.attempt.config.RedirectURL }
 
 // Complete finishes the login from the value the user pasted back -- the full
 // redirect URL, a bare query string, or just the code -- verifying state (when
@@ -457,7 +648,7 @@ func (a *Auth) refreshOAuth2Token(username string, force bool) (string, error) {
 		},
 	}
 
-	tokenSource := config.TokenSource(context.Background(), &oauth2.Token{
+	tokenSource := config.TokenSource(a.httpContext(), &oauth2.Token{
 		RefreshToken: token.OAuth2.RefreshToken,
 	})
 
@@ -465,18 +656,25 @@ func (a *Auth) refreshOAuth2Token(username string, force bool) (string, error) {
 	if err != nil {
 		return "", xurlErrors.NewAuthError("RefreshTokenError", err)
 	}
+	// A provider that rotates refresh tokens returns a new one with every
+	// refresh and invalidates the old one, so the response's token is the one
+	// to store. One that omits it expects the old token to be reused.
+	if rotated, _ := newToken.Extra("refresh_token").(string); rotated == "" {
+		newToken.RefreshToken = token.OAuth2.RefreshToken
+		fmt.Fprintln(os.Stderr, "Warning: the token refresh response did not include a refresh token; keeping the existing one.")
+	}
 
 	usernameStr := storedUsername
+	var identity *userIdentity
 	if usernameStr == "" {
-		resolvedUsername, _ := a.resolveStorageUsername("", newToken.AccessToken)
-		usernameStr = resolvedUsername
+		usernameStr, identity, _ = a.resolveStorageUsername("", newToken.AccessToken)
 	}
 	if storedUsername == "" && usernameStr != "" {
 		if err := a.TokenStore.ClearOAuth2TokenForApp(a.appName, storedUsername); err != nil {
 			return "", xurlErrors.NewAuthError("RefreshTokenError", err)
 		}
 	}
-	if err := a.saveOAuth2Token(usernameStr, newToken); err != nil {
+	if err := a.saveOAuth2Token(usernameStr, newToken, identity); err != nil {
 		return "", xurlErrors.NewAuthError("RefreshTokenError", err)
 	}
 
@@ -496,34 +694,50 @@ func (a *Auth) GetValidOAuth2Token(username string) (string, error) {
 	return a.RefreshOAuth2Token(username)
 }
 
+// ExistingOAuth2Login reports whether the active app already holds a usable
+// OAuth2 token for username (or its default user when empty), refreshing an
+// expired one if possible, and returns the username the token is stored
+// under. `xurl auth oauth2` uses it to skip a needless browser round-trip.
+func (a *Auth) ExistingOAuth2Login(username string) (string, bool) {
+	storedUsername, token := a.getOAuth2TokenRecord(username)
+	if token == nil || token.OAuth2 == nil {
+		return "", false
+	}
+	if _, err := a.RefreshOAuth2Token(username); err != nil {
+		return "", false
+	}
+	if storedUsername == "" {
+		// A refresh may have resolved and re-keyed an unnamed token.
+		storedUsername, _ = a.getOAuth2TokenRecord(username)
+	}
+	return storedUsername, true
+}
+
 type oauth2ListenerConfig struct {
 	Addresses    []string
 	CallbackPath string
 }
 
+// listenerConfigFromRedirectURI returns where the callback listener for
+// redirectURI binds and the exact path it serves (see ValidateRedirectURI).
 func listenerConfigFromRedirectURI(redirectURI string) (oauth2ListenerConfig, error) {
-	parsedURL, err := url.Parse(redirectURI)
-	if err != nil {
+	if err := ValidateRedirectURI(redirectURI); err != nil {
 		return oauth2ListenerConfig{}, err
 	}
-
-	host := parsedURL.Hostname()
-	if host == "" {
-		host = "localhost"
-	}
+	parsedURL, _ := url.Parse(redirectURI)
 
 	port := parsedURL.Port()
 	if port == "" {
-		port = "8080"
+		port = "80"
 	}
 
-	callbackPath := parsedURL.Path
+	callbackPath := parsedURL.EscapedPath()
 	if callbackPath == "" {
-		callbackPath = "/callback"
+		callbackPath = "/"
 	}
 
 	return oauth2ListenerConfig{
-		Addresses:    listenerAddressesForHost(host, port),
+		Addresses:    listenerAddressesForHost(parsedURL.Hostname(), port),
 		CallbackPath: callbackPath,
 	}, nil
 }
@@ -539,17 +753,20 @@ func listenerAddressesForHost(host, port string) []string {
 	return []string{net.JoinHostPort(host, port)}
 }
 
-func (a *Auth) resolveStorageUsername(explicitUsername, accessToken string) (string, bool) {
+// resolveStorageUsername returns the username to store a new token under:
+// explicitUsername, or else the one /2/users/me reports for the token, in
+// which case the rest of the looked-up identity is returned too.
+func (a *Auth) resolveStorageUsername(explicitUsername, accessToken string) (string, *userIdentity, bool) {
 	if explicitUsername != "" {
-		return explicitUsername, true
+		return explicitUsername, nil, true
 	}
 
-	username, err := a.fetchUsername(accessToken)
+	identity, err := a.fetchIdentity(accessToken)
 	if err != nil {
-		return "", false
+		return "", nil, false
 	}
 
-	return username, true
+	return identity.Username, identity, true
 }
 
 func (a *Auth) getOAuth2TokenRecord(username string) (string, *store.Token) {
@@ -560,7 +777,79 @@ func (a *Auth) getOAuth2TokenRecord(username string) (string, *store.Token) {
 	return a.TokenStore.GetFirstOAuth2TokenRecordForApp(a.appName)
 }
 
-func (a *Auth) saveOAuth2Token(username string, token *oauth2.Token) error {
+// userIDCredential returns the stored credential a user-context request with
+// authType and username is signed with, following the same preference as the
+// API client (the OAuth2 token, then OAuth1), along with the auth type that
+// selects it and its OAuth2 storage username. kind is "" for app-only auth or
+// when no such credential is stored.
+func (a *Auth) userIDCredential(authType, username string) (kind, key string, token *store.Token) {
+	switch strings.ToLower(authType) {
+	case "oauth2":
+		if key, token = a.getOAuth2TokenRecord(username); token != nil {
+			return "oauth2", key, token
+		}
+	case "oauth1":
+		if token = a.TokenStore.GetOAuth1TokensForApp(a.appName); token != nil {
+			return "oauth1", "", token
+		}
+	case "":
+		if a.TokenStore.GetFirstOAuth2TokenForApp(a.appName) != nil {
+			return a.userIDCredential("oauth2", username)
+		}
+		if username == "" {
+			return a.userIDCredential("oauth1", "")
+		}
+	}
+	return "", "", nil
+}
+
+// CachedUserID returns the user ID cached on the credential that a request
+// with authType and username would use, or "" if none is cached. pinned is
+// the auth type that selects exactly that credential, for looking the ID up
+// without falling back to another one; it is "" when the request would not
+// act as a user.
+func (a *Auth) CachedUserID(authType, username string) (userID, pinned string) {
+	kind, _, token := a.userIDCredential(authType, username)
+	switch {
+	case token == nil:
+		return "", ""
+	case token.OAuth2 != nil:
+		return token.OAuth2.UserID, kind
+	case token.OAuth1 != nil:
+		return token.OAuth1.UserID, kind
+	}
+	return "", kind
+}
+
+// GrantedScopes returns the scopes granted to the OAuth2 token a request with
+// authType and username would be signed with. known is false when they are
+// not recorded: for OAuth1 and app-only auth, which have no scopes, and for
+// OAuth2 tokens stored before scopes were recorded.
+func (a *Auth) GrantedScopes(authType, username string) (scopes []string, known bool) {
+	_, _, token := a.userIDCredential(authType, username)
+	if token == nil || token.OAuth2 == nil || len(token.OAuth2.Scopes) == 0 {
+		return nil, false
+	}
+	return token.OAuth2.Scopes, true
+}
+
+// SaveIdentity caches the user ID and display name of the account behind the
+// credential that a request with authType and username would use. Empty
+// values clear the cache. OAuth1 tokens only keep the ID.
+func (a *Auth) SaveIdentity(authType, username, userID, name string) error {
+	kind, key, _ := a.userIDCredential(authType, username)
+	switch kind {
+	case "oauth2":
+		return a.TokenStore.SetOAuth2IdentityForApp(a.appName, key, userID, name)
+	case "oauth1":
+		return a.TokenStore.SetOAuth1UserIDForApp(a.appName, userID)
+	}
+	return xurlErrors.NewAuthError("TokenNotFound", errors.New("no user-context credential to cache a user ID on"))
+}
+
+// saveOAuth2Token stores token under username, along with the account's
+// identity when it was looked up.
+func (a *Auth) saveOAuth2Token(username string, token *oauth2.Token, identity *userIdentity) error {
 	// A zero expiry means the provider didn't return one; store 0 so the token
 	// is treated as already expired and refreshed on next use rather than cast
 	// into a far-future timestamp that would never refresh.
@@ -568,7 +857,18 @@ func (a *Auth) saveOAuth2Token(username string, token *oauth2.Token) error {
 	if !token.Expiry.IsZero() {
 		expirationTime = uint64(token.Expiry.Unix())
 	}
-	return a.TokenStore.SaveOAuth2TokenForApp(a.appName, username, token.AccessToken, token.RefreshToken, expirationTime)
+	if err := a.TokenStore.SaveOAuth2TokenForApp(a.appName, username, token.AccessToken, token.RefreshToken, expirationTime); err != nil {
+		return err
+	}
+	if scope, _ := token.Extra("scope").(string); scope != "" {
+		if err := a.TokenStore.SetOAuth2ScopesForApp(a.appName, username, strings.Fields(scope)); err != nil {
+			return err
+		}
+	}
+	if identity != nil && identity.ID != "" {
+		return a.TokenStore.SetOAuth2IdentityForApp(a.appName, username, identity.ID, identity.Name)
+	}
+	return nil
 }
 
 // GetBearerTokenHeader gets the bearer token from the token store
@@ -580,74 +880,45 @@ func (a *Auth) GetBearerTokenHeader() (string, error) {
 	return "Bearer " + token.Bearer, nil
 }
 
-func (a *Auth) fetchUsername(accessToken string) (string, error) {
+// userIdentity is the account an OAuth2 token belongs to, as reported by
+// /2/users/me.
+type userIdentity struct {
+	Username string `json:"username"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+}
+
+func (a *Auth) fetchIdentity(accessToken string) (*userIdentity, error) {
 	req, err := http.NewRequest("GET", a.infoURL, nil)
 	if err != nil {
-		return "", xurlErrors.NewAuthError("RequestCreationError", err)
+		return nil, xurlErrors.NewAuthError("RequestCreationError", err)
 	}
 
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Transport: a.transport, Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", xurlErrors.NewAuthError("NetworkError", err)
+		return nil, xurlErrors.NewAuthError("NetworkError", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", xurlErrors.NewAuthError("IOError", err)
-	}
-
-	var data map[string]any
-	if err := json.Unmarshal(body, &data); err != nil {
-		return "", xurlErrors.NewAuthError("JSONDeserializationError", err)
-	}
-
-	if data["data"] != nil {
-		if userData, ok := data["data"].(map[string]any); ok {
-			if username, ok := userData["username"].(string); ok {
-				return username, nil
-			}
-		}
+		return nil, xurlErrors.NewAuthError("IOError", err)
 	}
 
-	return "", xurlErrors.NewAuthError("UsernameNotFound", errors.New("username not found when fetching username"))
-}
-
-func generateSignature(method, urlStr string, params map[string]string, consumerSecret, tokenSecret string) (string, error) {
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return "", xurlErrors.NewAuthError("InvalidURL", err)
+	var data struct {
+		Data *userIdentity `json:"data"`
 	}
-
-	baseURL := fmt.Sprintf("%s://%s%s", parsedURL.Scheme, parsedURL.Host, parsedURL.Path)
-
-	var keys []string
-	for key := range params {
-		keys = append(keys, key)
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, xurlErrors.NewAuthError("JSONDeserializationError", err)
 	}
-	sort.Strings(keys)
 
-	var paramPairs []string
-	for _, key := range keys {
-		paramPairs = append(paramPairs, fmt.Sprintf("%s=%s", encode(key), encode(params[key])))
+	if data.Data == nil || data.Data.Username == "" {
+		return nil, xurlErrors.NewAuthError("UsernameNotFound", errors.New("username not found when fetching username"))
 	}
-	paramString := strings.Join(paramPairs, "&")
-
-	signatureBaseString := fmt.Sprintf("%s&%s&%s",
-		strings.ToUpper(method),
-		encode(baseURL),
-		encode(paramString))
-
-	signingKey := fmt.Sprintf("%s&%s", encode(consumerSecret), encode(tokenSecret))
-
-	h := hmac.New(sha1.New, []byte(signingKey))
-	h.Write([]byte(signatureBaseString))
-	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
-
-	return signature, nil
+	return data.Data, nil
 }
 
 func generateNonce() string {
@@ -659,10 +930,6 @@ func generateTimestamp() string {
 	return fmt.Sprintf("%d", time.Now().Unix())
 }
 
-func encode(s string) string {
-	return url.QueryEscape(s)
-}
-
 func generateCodeVerifierAndChallenge() (string, string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
@@ -716,19 +983,3 @@ func getOAuth2Scopes() []string {
 
 	return scopes
 }
-
-func openBrowser(url string) error {
-	cmd, args := browserLaunchCommand(runtime.GOOS, url)
-	return exec.Command(cmd, args...).Start()
-}
-
-func browserLaunchCommand(goos, url string) (string, []string) {
-	switch goos {
-	case "windows":
-		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
-	case "darwin":
-		return "open", []string{url}
-	default:
-		return "xdg-open", []string{url}
-	}
-}
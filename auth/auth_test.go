@@ -1,11 +1,18 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -124,7 +131,7 @@ func TestEncode(t *testing.T) {
 		expected string
 	}{
 		{"abc", "abc"},
-		{"a b c", "a+b+c"},
+		{"a b c", "a%20b%20c"},
 		{"a+b+c", "a%2Bb%2Bc"},
 		{"a/b/c", "a%2Fb%2Fc"},
 		{"a?b=c", "a%3Fb%3Dc"},
@@ -198,6 +205,7 @@ func TestWithAppName(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
 	tokenStore, tsDir := createTempTokenStore(t)
 	defer os.RemoveAll(tsDir)
@@ -225,6 +233,7 @@ func TestWithAppNameOverridesEnvCredentials(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
 	tokenStore, tsDir := createTempTokenStore(t)
 	defer os.RemoveAll(tsDir)
@@ -254,6 +263,7 @@ func TestAppFlagTokenIsolation(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
 	tokenStore, tsDir := createTempTokenStore(t)
 	defer os.RemoveAll(tsDir)
@@ -316,6 +326,7 @@ func TestWithAppNameNonexistent(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
 	tokenStore, tsDir := createTempTokenStore(t)
 	defer os.RemoveAll(tsDir)
@@ -411,6 +422,48 @@ func TestRefreshOAuth2TokenSavesToNamedApp(t *testing.T) {
 	assert.Nil(t, tokenStore.GetOAuth2TokenForApp("default", "alice"))
 }
 
+func TestRefreshOAuth2TokenRotation(t *testing.T) {
+	past := uint64(time.Now().Add(-time.Hour).Unix())
+
+	t.Run("rotated refresh token is stored", func(t *testing.T) {
+		server := mockTokenServer(t, "new-access", "rotated-refresh")
+		defer server.Close()
+		ts, dir := createTempTokenStore(t)
+		defer os.RemoveAll(dir)
+		require.NoError(t, ts.SaveOAuth2TokenForApp("default", "alice", "old-access", "old-refresh", past))
+
+		a := NewAuth(&config.Config{TokenURL: server.URL + "/token"}).WithTokenStore(ts)
+		_, err := a.RefreshOAuth2Token("alice")
+		require.NoError(t, err)
+
+		stored := ts.GetOAuth2TokenForApp("default", "alice")
+		require.NotNil(t, stored)
+		assert.Equal(t, "new-access", stored.OAuth2.AccessToken)
+		assert.Equal(t, "rotated-refresh", stored.OAuth2.RefreshToken)
+
+		// The next refresh must send the rotated token, not the old one.
+		reloaded := store.OpenTokenStore(ts.FilePath, "", "")
+		assert.Equal(t, "rotated-refresh", reloaded.GetOAuth2TokenForApp("default", "alice").OAuth2.RefreshToken)
+	})
+
+	t.Run("omitted refresh token keeps the old one", func(t *testing.T) {
+		server := mockTokenServer(t, "new-access", "")
+		defer server.Close()
+		ts, dir := createTempTokenStore(t)
+		defer os.RemoveAll(dir)
+		require.NoError(t, ts.SaveOAuth2TokenForApp("default", "alice", "old-access", "old-refresh", past))
+
+		a := NewAuth(&config.Config{TokenURL: server.URL + "/token"}).WithTokenStore(ts)
+		_, err := a.RefreshOAuth2Token("alice")
+		require.NoError(t, err)
+
+		stored := ts.GetOAuth2TokenForApp("default", "alice")
+		require.NotNil(t, stored)
+		assert.Equal(t, "new-access", stored.OAuth2.AccessToken)
+		assert.Equal(t, "old-refresh", stored.OAuth2.RefreshToken)
+	})
+}
+
 func TestRefreshOAuth2TokenSavesToDefaultAppWhenNoOverride(t *testing.T) {
 	server := mockTokenServer(t, "new-access-token", "new-refresh-token")
 	defer server.Close()
@@ -439,31 +492,6 @@ func TestRefreshOAuth2TokenSavesToDefaultAppWhenNoOverride(t *testing.T) {
 	assert.Equal(t, "new-access-token", tok.OAuth2.AccessToken)
 }
 
-func TestBrowserLaunchCommand(t *testing.T) {
-	url := "https://x.com/i/oauth2/authorize?client_id=abc&redirect_uri=http%3A%2F%2Flocalhost%3A8080%2Fcallback&response_type=code&scope=tweet.read+users.read&state=123&code_challenge=xyz&code_challenge_method=S256"
-
-	t.Run("windows keeps the full oauth url as a single argument", func(t *testing.T) {
-		cmd, args := browserLaunchCommand("windows", url)
-
-		assert.Equal(t, "rundll32", cmd)
-		assert.Equal(t, []string{"url.dll,FileProtocolHandler", url}, args)
-	})
-
-	t.Run("darwin uses open", func(t *testing.T) {
-		cmd, args := browserLaunchCommand("darwin", url)
-
-		assert.Equal(t, "open", cmd)
-		assert.Equal(t, []string{url}, args)
-	})
-
-	t.Run("linux uses xdg-open", func(t *testing.T) {
-		cmd, args := browserLaunchCommand("linux", url)
-
-		assert.Equal(t, "xdg-open", cmd)
-		assert.Equal(t, []string{url}, args)
-	})
-}
-
 func TestListenerConfigFromRedirectURI(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -484,11 +512,29 @@ func TestListenerConfigFromRedirectURI(t *testing.T) {
 			wantCallback:  "/oauth/callback",
 		},
 		{
-			name:          "missing host and port fall back safely",
-			redirectURI:   "/callback",
-			wantAddresses: []string{"127.0.0.1:8080", "[::1]:8080"},
+			name:          "non-default path and port",
+			redirectURI:   "http://localhost:8181/oauth/x/done",
+			wantAddresses: []string{"127.0.0.1:8181", "[::1]:8181"},
+			wantCallback:  "/oauth/x/done",
+		},
+		{
+			name:          "trailing slash is part of the path",
+			redirectURI:   "http://127.0.0.1:8080/oauth/x/done/",
+			wantAddresses: []string{"127.0.0.1:8080"},
+			wantCallback:  "/oauth/x/done/",
+		},
+		{
+			name:          "missing port is the http default",
+			redirectURI:   "http://localhost/callback",
+			wantAddresses: []string{"127.0.0.1:80", "[::1]:80"},
 			wantCallback:  "/callback",
 		},
+		{
+			name:          "ipv6 loopback",
+			redirectURI:   "http://[::1]:9000/cb",
+			wantAddresses: []string{"[::1]:9000"},
+			wantCallback:  "/cb",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -501,6 +547,77 @@ func TestListenerConfigFromRedirectURI(t *testing.T) {
 	}
 }
 
+func TestValidateRedirectURI(t *testing.T) {
+	assert.NoError(t, ValidateRedirectURI("http://localhost:8080/callback"))
+	assert.NoError(t, ValidateRedirectURI("http://127.0.0.2:3000/oauth/x/done/"))
+	assert.ErrorContains(t, ValidateRedirectURI("/callback"), "absolute http:// URL")
+	assert.ErrorContains(t, ValidateRedirectURI("localhost:8080/callback"), "absolute http:// URL")
+	assert.ErrorContains(t, ValidateRedirectURI("https://localhost:8080/callback"), "only serves http")
+	assert.ErrorContains(t, ValidateRedirectURI("http://example.com/callback"), "not a loopback address")
+	assert.ErrorContains(t, ValidateRedirectURI("http://localhost:8080/callback?x=1"), "query or fragment")
+	assert.ErrorContains(t, ValidateRedirectURI("http://localhost:%zz/callback"), "cannot be parsed")
+}
+
+func TestCallbackListenerServesOnlyItsPath(t *testing.T) {
+	for _, path := range []string{"/oauth/x/done", "/oauth/x/done/"} {
+		t.Run(path, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			addr := l.Addr().String()
+			l.Close()
+
+			ready := make(chan struct{})
+			result := make(chan error, 1)
+			go func() {
+				result <- StartListener([]string{addr}, path, func(code, state string) error {
+					assert.Equal(t, "c", code)
+					return nil
+				}, ready)
+			}()
+			<-ready
+
+			// Other paths, including the same path with or without a
+			// trailing slash, are not the callback.
+			other := strings.TrimSuffix(path, "/")
+			if other == path {
+				other += "/"
+			}
+			for _, p := range []string{"/", "/callback", other, path + "/extra"} {
+				resp, err := http.Get("http://" + addr + p + "?code=c&state=s")
+				require.NoError(t, err)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode, p)
+			}
+
+			resp, err := http.Get("http://" + addr + path + "?code=c&state=s")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NoError(t, <-result)
+		})
+	}
+}
+
+func TestOAuth2FlowRejectsNonLoopbackRedirect(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	cfg := &config.Config{
+		ClientID:    "client-id",
+		AuthURL:     "https://x.com/i/oauth2/authorize",
+		RedirectURI: "https://example.com/callback",
+	}
+	a := NewAuth(cfg).WithTokenStore(tokenStore)
+
+	prevOpen := openBrowserFunc
+	defer func() { openBrowserFunc = prevOpen }()
+	openBrowserFunc = func(string) error {
+		t.Fatal("the browser must not open for an unusable redirect URI")
+		return nil
+	}
+	_, err := a.OAuth2Flow("")
+	assert.ErrorContains(t, err, "only serves http")
+}
+
 func TestRefreshOAuth2TokenPreservesUnnamedTokenWhenUsernameLookupFails(t *testing.T) {
 	tokenServer := mockTokenServer(t, "new-access-token", "new-refresh-token")
 	defer tokenServer.Close()
@@ -545,6 +662,8 @@ func TestRefreshOAuth2TokenMigratesUnnamedTokenWhenUsernameLookupSucceeds(t *tes
 		json.NewEncoder(w).Encode(map[string]any{
 			"data": map[string]any{
 				"username": "alice",
+				"id":       "42",
+				"name":     "Alice",
 			},
 		})
 	}))
@@ -571,6 +690,8 @@ func TestRefreshOAuth2TokenMigratesUnnamedTokenWhenUsernameLookupSucceeds(t *tes
 	tok := tokenStore.GetOAuth2TokenForApp("default", "alice")
 	require.NotNil(t, tok)
 	assert.Equal(t, "new-access-token", tok.OAuth2.AccessToken)
+	assert.Equal(t, "42", tok.OAuth2.UserID, "the looked-up identity is cached with the token")
+	assert.Equal(t, "Alice", tok.OAuth2.Name)
 }
 
 func serverURL(server *httptest.Server, suffix string) string {
@@ -785,8 +906,145 @@ func TestHeadlessLoginExchangesPastedCode(t *testing.T) {
 	assert.Equal(t, "headless-refresh", stored.OAuth2.RefreshToken)
 }
 
+// TestAuthorizeURLParams checks that the authorize URL carries every parameter
+// X needs, correctly encoded, whatever the platform.
+func TestAuthorizeURLParams(t *testing.T) {
+	cfg := &config.Config{
+		ClientID:    "client id&x",
+		AuthURL:     "https://x.com/i/oauth2/authorize",
+		TokenURL:    "https://api.x.com/2/oauth2/token",
+		RedirectURI: "http://localhost:8080/callback?app=a b",
+	}
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	a := NewAuth(cfg).WithTokenStore(tokenStore)
+
+	attempt, err := a.prepareOAuth2Flow()
+	require.NoError(t, err)
+
+	u, err := url.Parse(attempt.authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "https://x.com/i/oauth2/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.NotContains(t, u.RawQuery, "+", "spaces are encoded as %20")
+
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "client id&x", q.Get("client_id"))
+	assert.Equal(t, "http://localhost:8080/callback?app=a b", q.Get("redirect_uri"))
+	assert.Equal(t, strings.Join(getOAuth2Scopes(), " "), q.Get("scope"))
+	assert.Equal(t, attempt.state, q.Get("state"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	sum := sha256.Sum256([]byte(attempt.verifier))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), q.Get("code_challenge"))
+	assert.Equal(t, url.QueryEscape(attempt.state), attempt.state, "state needs no escaping")
+
+	a.redirectURI = ""
+	_, err = a.prepareOAuth2Flow()
+	assert.ErrorContains(t, err, "redirect URI")
+}
+
 // TestHeadlessLoginRejectsStateMismatch verifies a pasted redirect URL whose
 // state does not match the login attempt is rejected before any token exchange.
+// TestOAuth2FlowCallbackPath runs the interactive flow with a custom callback
+// path: the authorize URL must redirect to it and the listener must answer on
+// it.
+func TestOAuth2FlowCallbackPath(t *testing.T) {
+	server := mockTokenServer(t, "path-access", "path-refresh")
+	defer server.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	cfg := &config.Config{
+		ClientID:    "client-id",
+		AuthURL:     "https://x.com/i/oauth2/authorize",
+		TokenURL:    server.URL + "/token",
+		RedirectURI: fmt.Sprintf("http://127.0.0.1:%d/callback", port),
+	}
+	a := NewAuth(cfg).WithTokenStore(tokenStore).WithCallbackPath("/oauth/callback")
+
+	wantRedirect := fmt.Sprintf("http://127.0.0.1:%d/oauth/callback", port)
+	callbackStatus := make(chan int, 1)
+	prevOpen := openBrowserFunc
+	defer func() { openBrowserFunc = prevOpen }()
+	openBrowserFunc = func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		redirect := u.Query().Get("redirect_uri")
+		assert.Equal(t, wantRedirect, redirect)
+		go func() {
+			resp, err := http.Get(redirect + "?code=test-code&state=" + url.QueryEscape(u.Query().Get("state")))
+			if err != nil {
+				callbackStatus <- 0
+				return
+			}
+			resp.Body.Close()
+			callbackStatus <- resp.StatusCode
+		}()
+		return nil
+	}
+
+	tok, err := a.OAuth2Flow("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "path-access", tok)
+	assert.Equal(t, http.StatusOK, <-callbackStatus)
+
+	_, err = a.WithCallbackPath("oauth/callback").OAuth2Flow("alice")
+	assert.ErrorContains(t, err, "must begin with /")
+}
+
+func TestCallbackHandlerResponses(t *testing.T) {
+	serve := func(redirect string, callbackErr error) (*httptest.ResponseRecorder, error) {
+		var outcome error
+		handler := callbackHandler(func(code, state string) error {
+			assert.Equal(t, "c", code)
+			assert.Equal(t, "s", state)
+			return callbackErr
+		}, redirect, func(err error) { outcome = err })
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/callback?code=c&state=s", nil))
+		return rec, outcome
+	}
+
+	rec, outcome := serve("", nil)
+	assert.NoError(t, outcome)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "<h1>You&#39;re signed in to xurl</h1>")
+	assert.Contains(t, rec.Body.String(), "You can close this tab")
+
+	rec, outcome = serve("https://example.com/done?from=xurl", nil)
+	assert.NoError(t, outcome)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://example.com/done?from=xurl", rec.Header().Get("Location"))
+
+	rec, outcome = serve("https://example.com/done", errors.New("invalid <state>"))
+	assert.EqualError(t, outcome, "invalid <state>")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "failures are never redirected")
+	assert.Contains(t, rec.Body.String(), "invalid &lt;state&gt;. Return to the terminal")
+}
+
+func TestValidateSuccessRedirect(t *testing.T) {
+	assert.NoError(t, ValidateSuccessRedirect("https://example.com/welcome"))
+	assert.NoError(t, ValidateSuccessRedirect("http://localhost:3000/"))
+	assert.Error(t, ValidateSuccessRedirect("/relative"))
+	assert.Error(t, ValidateSuccessRedirect("javascript:alert(1)"))
+}
+
+func TestValidateCallbackPath(t *testing.T) {
+	assert.NoError(t, ValidateCallbackPath("/oauth/callback"))
+	assert.ErrorContains(t, ValidateCallbackPath("callback"), "must begin with /")
+	assert.ErrorContains(t, ValidateCallbackPath(""), "must begin with /")
+	assert.ErrorContains(t, ValidateCallbackPath("/callback?x=1"), "bare path")
+}
+
 func TestHeadlessLoginRejectsStateMismatch(t *testing.T) {
 	server := mockTokenServer(t, "should-not-be-used", "nope")
 	defer server.Close()
@@ -809,3 +1067,86 @@ func TestHeadlessLoginRejectsStateMismatch(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "state mismatch")
 }
+
+func TestCachedUserID(t *testing.T) {
+	ts, dir := createTempTokenStore(t)
+	defer os.RemoveAll(dir)
+	a := NewAuth(&config.Config{}).WithTokenStore(ts)
+
+	userID, pinned := a.CachedUserID("", "")
+	assert.Empty(t, userID)
+	assert.Empty(t, pinned, "no stored credential acts as a user")
+
+	require.NoError(t, ts.SaveOAuth1TokensForApp("default", "access", "secret", "ck", "cs"))
+	_, pinned = a.CachedUserID("", "")
+	assert.Equal(t, "oauth1", pinned, "without OAuth2 tokens the OAuth1 token is used")
+	require.NoError(t, a.SaveIdentity("", "", "1", ""))
+
+	require.NoError(t, ts.SaveOAuth2TokenForApp("default", "alice", "access", "refresh", 1))
+	userID, pinned = a.CachedUserID("", "")
+	assert.Empty(t, userID)
+	assert.Equal(t, "oauth2", pinned, "an OAuth2 token is preferred, like the API client does")
+	require.NoError(t, a.SaveIdentity("", "", "42", "Alice"))
+	assert.Equal(t, "42", ts.GetUserIDForApp("default", "alice"))
+
+	userID, _ = a.CachedUserID("oauth1", "")
+	assert.Equal(t, "1", userID)
+	userID, _ = a.CachedUserID("", "alice")
+	assert.Equal(t, "42", userID)
+
+	_, pinned = a.CachedUserID("", "bob")
+	assert.Empty(t, pinned, "a named user without a token is never substituted")
+	_, pinned = a.CachedUserID("app", "")
+	assert.Empty(t, pinned)
+	assert.Error(t, a.SaveIdentity("app", "", "3", ""))
+}
+
+func TestGrantedScopes(t *testing.T) {
+	ts, dir := createTempTokenStore(t)
+	defer os.RemoveAll(dir)
+	a := NewAuth(&config.Config{}).WithTokenStore(ts)
+
+	_, known := a.GrantedScopes("", "")
+	assert.False(t, known, "no stored credential")
+
+	require.NoError(t, ts.SaveOAuth2TokenForApp("default", "alice", "access", "refresh", 1))
+	_, known = a.GrantedScopes("", "alice")
+	assert.False(t, known, "tokens stored before scopes were recorded")
+
+	token := (&oauth2.Token{AccessToken: "access-2", RefreshToken: "refresh-2"}).WithExtra(map[string]any{"scope": "tweet.read bookmark.read"})
+	require.NoError(t, a.saveOAuth2Token("alice", token, nil))
+	scopes, known := a.GrantedScopes("", "alice")
+	assert.True(t, known)
+	assert.Equal(t, []string{"tweet.read", "bookmark.read"}, scopes)
+
+	_, known = a.GrantedScopes("app", "")
+	assert.False(t, known, "app-only auth has no scopes")
+}
+
+func TestClientCredentialsOverride(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+	tokenStore.AddApp("my-app", "stored-id", "stored-secret")
+
+	cfg := &config.Config{
+		AuthURL:     "https://x.com/i/oauth2/authorize",
+		TokenURL:    "https://api.x.com/2/oauth2/token",
+		RedirectURI: "http://localhost:8080/callback",
+	}
+	a := NewAuth(cfg).WithTokenStore(tokenStore).WithAppName("my-app").WithClientCredentials("flag-id", "flag-secret")
+
+	hl, err := a.StartHeadlessLogin("alice")
+	require.NoError(t, err)
+	authURL, err := url.Parse(hl.AuthURL())
+	require.NoError(t, err)
+	assert.Equal(t, "flag-id", authURL.Query().Get("client_id"))
+	assert.Equal(t, "flag-secret", a.newOAuth2Config().ClientSecret)
+
+	a.WithClientCredentials("", "")
+	assert.Equal(t, "flag-id", a.newOAuth2Config().ClientID, "empty values keep the current credentials")
+
+	noClient := &Auth{TokenStore: tokenStore, authURL: cfg.AuthURL, redirectURI: cfg.RedirectURI}
+	_, err = noClient.StartHeadlessLogin("alice")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--client-id")
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// openCommandGrace is how long runOpenCommand waits for an --open-cmd to
+// fail before assuming it launched the browser. Launchers such as wslview
+// return at once, while a browser started directly may run until closed.
+const openCommandGrace = 3 * time.Second
+
+// commandRunner starts an external command without waiting for it to exit.
+// Tests substitute a fake to observe which commands would be launched.
+type commandRunner func(name string, args ...string) error
+
+// startCommand starts a command and reaps it in the background, so a
+// launcher that exits does not linger as a zombie until xurl does.
+func startCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// openBrowser opens url in the user's browser, trying each launcher that
+// suits the platform until one starts.
+func openBrowser(url string) error {
+	return launchBrowser(url, runtime.GOOS, isWSL(), os.Getenv("BROWSER"), startCommand)
+}
+
+// launchBrowser tries each candidate from browserCommands in turn and returns
+// nil as soon as one starts, or an error naming every launcher that failed.
+func launchBrowser(url, goos string, wsl bool, browserEnv string, run commandRunner) error {
+	var failures []string
+	for _, c := range browserCommands(goos, wsl, browserEnv, url) {
+		if err := run(c[0], c[1:]...); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c[0], err))
+			continue
+		}
+		return nil
+	}
+	return errors.New("no browser launcher worked (" + strings.Join(failures, "; ") + ")")
+}
+
+// runOpenCommand opens url with the user's command template, in which {url}
+// is replaced by the quoted URL (or the URL is appended when there is no
+// {url}). The line runs through the platform shell. It fails if the command
+// exits unsuccessfully within grace; one still running by then is assumed to
+// have opened the browser.
+func runOpenCommand(template, url string, grace time.Duration) error {
+	line := template + " {url}"
+	if strings.Contains(template, "{url}") {
+		line = template
+	}
+	line = strings.ReplaceAll(line, "{url}", quoteOpenArg(url))
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", line)
+	} else {
+		cmd = exec.Command("sh", "-c", line)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %v", template, err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s: %v", template, err)
+		}
+		return nil
+	case <-time.After(grace):
+		return nil
+	}
+}
+
+// quoteOpenArg quotes s as a single argument for the shell runOpenCommand
+// uses.
+func quoteOpenArg(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// browserCommands returns the launch commands to try, in order, for goos.
+// Under WSL the Windows side is invoked, since a Linux browser is rarely
+// installed (or able to reach the user). $BROWSER, when set, is tried first
+// on Unix systems, matching xdg-open's own convention: it is a
+// colon-separated list of commands, and an entry containing %s has the URL
+// put there instead of appended.
+func browserCommands(goos string, wsl bool, browserEnv, url string) [][]string {
+	var cmds [][]string
+	switch {
+	case goos == "windows":
+		// rundll32 passes the URL through untouched. "start" is interpreted
+		// by cmd.exe, so the query string's & must be escaped, and the empty
+		// "" is the window title start would otherwise take from the URL.
+		return [][]string{
+			{"rundll32", "url.dll,FileProtocolHandler", url},
+			{"cmd", "/c", "start", "", cmdEscape(url)},
+		}
+	case goos == "darwin":
+		return [][]string{{"open", url}}
+	}
+
+	for _, b := range strings.Split(browserEnv, ":") {
+		if strings.Contains(b, "%s") {
+			args := strings.Fields(b)
+			for i := range args {
+				args[i] = strings.ReplaceAll(args[i], "%s", url)
+			}
+			cmds = append(cmds, args)
+		} else if b != "" {
+			cmds = append(cmds, []string{b, url})
+		}
+	}
+	if wsl {
+		cmds = append(cmds,
+			[]string{"wslview", url},
+			[]string{"cmd.exe", "/c", "start", "", cmdEscape(url)},
+			[]string{"powershell.exe", "-NoProfile", "-Command", "Start-Process '" + strings.ReplaceAll(url, "'", "''") + "'"},
+		)
+	}
+	return append(cmds,
+		[]string{"xdg-open", url},
+		[]string{"gio", "open", url},
+		[]string{"sensible-browser", url},
+		[]string{"x-www-browser", url},
+	)
+}
+
+// cmdEscape escapes the characters cmd.exe treats specially outside quotes.
+func cmdEscape(s string) string {
+	r := strings.NewReplacer("^", "^^", "&", "^&", "|", "^|", "<", "^<", ">", "^>")
+	return r.Replace(s)
+}
+
+// isWSL reports whether we are running under the Windows Subsystem for Linux.
+func isWSL() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
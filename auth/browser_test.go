@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRunner records every launch attempt and fails for the commands in
+// fail.
+type recordingRunner struct {
+	calls [][]string
+	fail  map[string]bool
+}
+
+func (r *recordingRunner) run(name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	if r.fail[name] {
+		return errors.New("executable file not found")
+	}
+	return nil
+}
+
+func TestLaunchBrowser(t *testing.T) {
+	url := "https://x.com/i/oauth2/authorize?client_id=abc&redirect_uri=http%3A%2F%2Flocalhost%3A8080%2Fcallback&response_type=code&scope=tweet.read+users.read&state=123&code_challenge=xyz&code_challenge_method=S256"
+	escaped := "https://x.com/i/oauth2/authorize?client_id=abc^&redirect_uri=http%3A%2F%2Flocalhost%3A8080%2Fcallback^&response_type=code^&scope=tweet.read+users.read^&state=123^&code_challenge=xyz^&code_challenge_method=S256"
+
+	t.Run("windows keeps the full oauth url as a single argument", func(t *testing.T) {
+		r := &recordingRunner{}
+		require.NoError(t, launchBrowser(url, "windows", false, "", r.run))
+		assert.Equal(t, [][]string{{"rundll32", "url.dll,FileProtocolHandler", url}}, r.calls)
+	})
+
+	t.Run("windows falls back to start with an escaped url", func(t *testing.T) {
+		r := &recordingRunner{fail: map[string]bool{"rundll32": true}}
+		require.NoError(t, launchBrowser(url, "windows", false, "", r.run))
+		require.Len(t, r.calls, 2)
+		assert.Equal(t, []string{"cmd", "/c", "start", "", escaped}, r.calls[1])
+	})
+
+	t.Run("darwin uses open", func(t *testing.T) {
+		r := &recordingRunner{}
+		require.NoError(t, launchBrowser(url, "darwin", false, "", r.run))
+		assert.Equal(t, [][]string{{"open", url}}, r.calls)
+	})
+
+	t.Run("linux uses xdg-open then falls back", func(t *testing.T) {
+		r := &recordingRunner{fail: map[string]bool{"xdg-open": true}}
+		require.NoError(t, launchBrowser(url, "linux", false, "", r.run))
+		assert.Equal(t, [][]string{{"xdg-open", url}, {"gio", "open", url}}, r.calls)
+	})
+
+	t.Run("linux tries $BROWSER first", func(t *testing.T) {
+		r := &recordingRunner{}
+		require.NoError(t, launchBrowser(url, "linux", false, "firefox", r.run))
+		assert.Equal(t, [][]string{{"firefox", url}}, r.calls)
+	})
+
+	t.Run("$BROWSER entries may place the url with %s", func(t *testing.T) {
+		r := &recordingRunner{fail: map[string]bool{"chromium": true}}
+		require.NoError(t, launchBrowser(url, "linux", false, "chromium:firefox --new-window %s", r.run))
+		assert.Equal(t, [][]string{{"chromium", url}, {"firefox", "--new-window", url}}, r.calls)
+	})
+
+	t.Run("wsl invokes the windows side before xdg-open", func(t *testing.T) {
+		r := &recordingRunner{fail: map[string]bool{"wslview": true}}
+		require.NoError(t, launchBrowser(url, "linux", true, "", r.run))
+		assert.Equal(t, [][]string{
+			{"wslview", url},
+			{"cmd.exe", "/c", "start", "", escaped},
+		}, r.calls)
+	})
+
+	t.Run("reports every failure when nothing starts", func(t *testing.T) {
+		r := &recordingRunner{fail: map[string]bool{"open": true}}
+		err := launchBrowser(url, "darwin", false, "", r.run)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "open: executable file not found")
+	})
+}
+
+// TestRunOpenCommand runs a stub --open-cmd through the shell: the URL must
+// arrive intact as one argument, and a failing command must be reported.
+func TestRunOpenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub commands use a POSIX shell")
+	}
+	url := "https://x.com/i/oauth2/authorize?client_id=abc&state=it's&scope=a%20b"
+	out := filepath.Join(t.TempDir(), "opened")
+
+	require.NoError(t, runOpenCommand("printf '%s' {url} > "+quoteOpenArg(out), url, 5*time.Second))
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, url, string(got))
+
+	require.NoError(t, runOpenCommand("printf '%s' > "+quoteOpenArg(out), url, 5*time.Second))
+	got, err = os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, url, string(got), "the URL is appended without {url}")
+
+	err = runOpenCommand("exit 3", url, 5*time.Second)
+	assert.ErrorContains(t, err, "exit 3: exit status 3")
+
+	assert.NoError(t, runOpenCommand("sleep 5; : {url}", url, 50*time.Millisecond), "a command still running is assumed to have opened the browser")
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Outcomes of an AppProbe.
+const (
+	ProbePass = "pass"
+	ProbeWarn = "warn"
+	ProbeFail = "fail"
+)
+
+// AppProbe is the outcome of one of CheckApp's probes. Detail holds what the
+// server answered, for when Message is not enough.
+type AppProbe struct {
+	Name    string
+	Status  string
+	Message string
+	Detail  string
+}
+
+// maxProbeDetail caps how much of a response body an AppProbe keeps.
+const maxProbeDetail = 2048
+
+// CheckApp checks the client credentials and redirect URI without logging
+// in or opening a browser. It asks the token endpoint for a
+// client_credentials grant, whose error tells a wrong secret apart from a
+// grant the app may not use, and requests the authorization URL, looking for
+// an invalid client or an unregistered redirect URI in the immediate
+// response. Redirects are reported, never followed.
+func (a *Auth) CheckApp(client *http.Client) []AppProbe {
+	if a.clientID == "" {
+		return []AppProbe{{Name: "client id", Status: ProbeFail, Message: "no OAuth2 client ID: pass --client-id, set CLIENT_ID, or register an app with 'xurl auth apps add'"}}
+	}
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return []AppProbe{
+		a.probeClientSecret(&noRedirects),
+		a.probeAuthorize(&noRedirects),
+	}
+}
+
+// probeClientSecret asks for a client_credentials token. An invalid_client
+// error (or a bare 401) means the ID and secret do not match; any other
+// error means they were accepted and only the grant was refused.
+func (a *Auth) probeClientSecret(client *http.Client) AppProbe {
+	p := AppProbe{Name: "client secret"}
+	if a.clientSecret == "" {
+		p.Status, p.Message = ProbeWarn, "no client secret, so only a public client (a Native App) can log in; it cannot be checked"
+		return p
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest("POST", a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		p.Status, p.Message = ProbeFail, err.Error()
+		return p
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	resp, body, err := probe(client, req)
+	if err != nil {
+		p.Status, p.Message = ProbeFail, fmt.Sprintf("the token endpoint did not answer: %v", err)
+		return p
+	}
+	status := resp.StatusCode
+	p.Detail = fmt.Sprintf("POST %s: %s\n%s", a.tokenURL, resp.Status, body)
+
+	var answer struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal([]byte(body), &answer)
+	switch {
+	case status == http.StatusOK:
+		p.Status, p.Message = ProbePass, "the client ID and secret are valid"
+	case answer.Error == "invalid_client" || status == http.StatusUnauthorized:
+		p.Status, p.Message = ProbeFail, "the token endpoint rejected the client ID and secret (invalid_client)"
+	case answer.Error != "":
+		p.Status, p.Message = ProbePass, fmt.Sprintf("the client ID and secret were accepted (the test grant itself was refused: %s)", answer.Error)
+	default:
+		p.Status, p.Message = ProbeWarn, fmt.Sprintf("unexpected answer from the token endpoint: %d", status)
+	}
+	return p
+}
+
+// probeAuthorize requests the authorization URL the login would open. The
+// provider answers an unknown client or unregistered redirect URI with an
+// error straight away, before anyone signs in.
+func (a *Auth) probeAuthorize(client *http.Client) AppProbe {
+	p := AppProbe{Name: "authorize"}
+	redirectURI, err := a.effectiveRedirectURI()
+	if err != nil {
+		p.Status, p.Message = ProbeFail, err.Error()
+		return p
+	}
+	config := a.newOAuth2Config()
+	config.RedirectURL = redirectURI
+	_, challenge, err := generateCodeVerifierAndChallenge()
+	if err != nil {
+		p.Status, p.Message = ProbeFail, err.Error()
+		return p
+	}
+	authURL, err := authorizeURL(config, "check-app", challenge)
+	if err != nil {
+		p.Status, p.Message = ProbeFail, err.Error()
+		return p
+	}
+
+	req, err := http.NewRequest("GET", authURL, nil)
+	if err != nil {
+		p.Status, p.Message = ProbeFail, err.Error()
+		return p
+	}
+	resp, body, err := probe(client, req)
+	if err != nil {
+		p.Status, p.Message = ProbeFail, fmt.Sprintf("the authorization endpoint did not answer: %v", err)
+		return p
+	}
+	location := resp.Header.Get("Location")
+	p.Detail = fmt.Sprintf("GET %s: %s", authURL, resp.Status)
+	if location != "" {
+		p.Detail += "\nLocation: " + location
+	}
+	p.Detail += "\n" + body
+
+	// An error sent back to the redirect URI names itself in the query.
+	if loc, err := url.Parse(location); err == nil && strings.HasPrefix(location, redirectURI) {
+		if e := loc.Query().Get("error"); e != "" {
+			p.Status, p.Message = ProbeFail, fmt.Sprintf("the authorization endpoint answered with %s", e)
+			return p
+		}
+	}
+	text := strings.ToLower(body + " " + location)
+	switch {
+	case containsAny(text, "invalid_client", "invalid client"):
+		p.Status, p.Message = ProbeFail, "the authorization endpoint does not know this client ID"
+	case containsAny(text, "redirect_uri_mismatch", "invalid_redirect_uri", "redirect uri mismatch", "redirect_uri mismatch", "invalid redirect uri"):
+		p.Status, p.Message = ProbeFail, fmt.Sprintf("%s is not a callback URL registered for this app", redirectURI)
+	case resp.StatusCode >= 400:
+		p.Status, p.Message = ProbeFail, fmt.Sprintf("the authorization endpoint answered %s", resp.Status)
+	default:
+		p.Status, p.Message = ProbePass, fmt.Sprintf("no client or redirect URI error for %s (the consent screen may still refuse scopes)", redirectURI)
+	}
+	return p
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// probe sends req and returns the response, closed, with the start of its
+// body.
+func probe(client *http.Client, req *http.Request) (*http.Response, string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProbeDetail))
+	return resp, string(body), nil
+}
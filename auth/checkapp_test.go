@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestCheckApp(t *testing.T) {
+	const redirectURI = "http://localhost:8080/callback"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			switch {
+			case r.FormValue("grant_type") != "client_credentials":
+				w.WriteHeader(http.StatusBadRequest)
+			case id == "good" && secret == "right":
+				fmt.Fprint(w, `{"access_token":"app","token_type":"bearer"}`)
+			case id == "native" && secret == "right":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"unauthorized_client"}`)
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client","error_description":"Missing valid authorization header"}`)
+			}
+		case "/authorize":
+			q := r.URL.Query()
+			switch {
+			case q.Get("client_id") == "unknown":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<p>Error: invalid_client</p>`)
+			case q.Get("redirect_uri") != redirectURI:
+				fmt.Fprint(w, `<p>Something went wrong: redirect_uri_mismatch</p>`)
+			default:
+				http.Redirect(w, r, "/login?redirect_after_login=...", http.StatusFound)
+			}
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	check := func(clientID, clientSecret, redirect string) map[string]AppProbe {
+		t.Helper()
+		tokenStore, dir := createTempTokenStore(t)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		a := NewAuth(&config.Config{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token", RedirectURI: redirect}).
+			WithTokenStore(tokenStore).WithClientCredentials(clientID, clientSecret)
+		probes := map[string]AppProbe{}
+		for _, p := range a.CheckApp(&http.Client{Timeout: 5 * time.Second}) {
+			probes[p.Name] = p
+		}
+		return probes
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		probes := check("good", "right", redirectURI)
+		assert.Equal(t, ProbePass, probes["client secret"].Status)
+		assert.Equal(t, ProbePass, probes["authorize"].Status)
+		assert.Contains(t, probes["authorize"].Detail, "Location: /login", "the redirect is reported, not followed")
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		probes := check("good", "wrong", redirectURI)
+		assert.Equal(t, ProbeFail, probes["client secret"].Status)
+		assert.Contains(t, probes["client secret"].Message, "invalid_client")
+		assert.Contains(t, probes["client secret"].Detail, "Missing valid authorization header")
+	})
+
+	t.Run("grant refused after the secret was accepted", func(t *testing.T) {
+		probes := check("native", "right", redirectURI)
+		assert.Equal(t, ProbePass, probes["client secret"].Status)
+		assert.Contains(t, probes["client secret"].Message, "unauthorized_client")
+	})
+
+	t.Run("no secret", func(t *testing.T) {
+		assert.Equal(t, ProbeWarn, check("good", "", redirectURI)["client secret"].Status)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		probes := check("unknown", "right", redirectURI)
+		assert.Equal(t, ProbeFail, probes["authorize"].Status)
+		assert.Contains(t, probes["authorize"].Message, "client ID")
+	})
+
+	t.Run("unregistered redirect URI", func(t *testing.T) {
+		probes := check("good", "right", "http://localhost:9999/other")
+		assert.Equal(t, ProbeFail, probes["authorize"].Status)
+		assert.Contains(t, probes["authorize"].Message, "http://localhost:9999/other is not a callback URL")
+	})
+
+	t.Run("no client ID", func(t *testing.T) {
+		probes := check("", "", redirectURI)
+		require.Len(t, probes, 1)
+		assert.Equal(t, ProbeFail, probes["client id"].Status)
+	})
+}
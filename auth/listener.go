@@ -4,15 +4,130 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/xdevplatform/xurl/config"
 	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
+// callbackPage is the page the OAuth2 callback shows in the browser; %s are
+// the title and the message, both HTML-escaped.
+const callbackPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>xurl: %[1]s</title>
+<style>
+  body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif;
+         background: #f7f9f9; color: #0f1419; display: flex; align-items: center;
+         justify-content: center; min-height: 100vh; margin: 0; }
+  main { background: #fff; border: 1px solid #cfd9de; border-radius: 16px;
+         padding: 2rem 2.5rem; max-width: 28rem; text-align: center; }
+  h1 { font-size: 1.4rem; margin: 0 0 .75rem; }
+  p { color: #536471; margin: 0; line-height: 1.4; }
+</style>
+</head>
+<body>
+<main>
+<h1>%[1]s</h1>
+<p>%[2]s</p>
+</main>
+</body>
+</html>
+`
+
+// ValidateSuccessRedirect reports whether target can be used with
+// WithSuccessRedirect: it must be an absolute http or https URL.
+func ValidateSuccessRedirect(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("success redirect %q must be an absolute http or https URL", target)
+	}
+	return nil
+}
+
+// ValidateRedirectURI reports whether redirectURI can receive the OAuth2
+// callback on xurl's local listener: it must be an absolute http URL on a
+// loopback host (localhost, 127.0.0.0/8 or ::1), without a query or
+// fragment. The listener binds its port (80 when none is given) and serves
+// exactly its path.
+func ValidateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("redirect URI %q cannot be parsed: %v", redirectURI, err)
+	}
+	if u.Scheme == "https" {
+		return fmt.Errorf("redirect URI %q uses https, but xurl's callback listener only serves http; register an http:// loopback URI such as %s", redirectURI, config.DefaultRedirectURI)
+	}
+	if u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("redirect URI %q must be an absolute http:// URL, such as %s", redirectURI, config.DefaultRedirectURI)
+	}
+	if !isLoopbackHost(u.Hostname()) {
+		return fmt.Errorf("redirect URI %q is not a loopback address, so the callback cannot reach xurl; use localhost or 127.0.0.1, or --headless", redirectURI)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("redirect URI %q must not have a query or fragment", redirectURI)
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback IP.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeCallbackPage writes callbackPage with title and message and status.
+func writeCallbackPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, callbackPage, html.EscapeString(title), html.EscapeString(message))
+}
+
+// callbackHandler serves the OAuth2 callback: it passes the code and state to
+// callback and answers the browser with a success page, or with a redirect
+// to successRedirect when one is set, or with an error page. finish receives
+// the outcome.
+func callbackHandler(callback func(code, state string) error, successRedirect string, finish func(error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Query().Get("code")
+		state := r.URL.Query().Get("state")
+
+		if err := callback(code, state); err != nil {
+			writeCallbackPage(w, http.StatusBadRequest, "Authorization failed", err.Error()+". Return to the terminal for details.")
+			finish(err)
+			return
+		}
+
+		if successRedirect != "" {
+			http.Redirect(w, r, successRedirect, http.StatusFound)
+		} else {
+			writeCallbackPage(w, http.StatusOK, "You're signed in to xurl", "Authentication successful. You can close this tab and return to the terminal.")
+		}
+		finish(nil)
+	}
+}
+
+// StartListener serves the OAuth2 callback on callbackPath at every address
+// until it is called once, and returns what callback returned. ready is
+// closed once the addresses are bound.
 func StartListener(addresses []string, callbackPath string, callback func(code, state string) error, ready chan<- struct{}) error {
+	return startCallbackListener(addresses, callbackPath, "", callback, ready)
+}
+
+// startCallbackListener is StartListener, redirecting the browser to
+// successRedirect after a successful callback when it is set.
+func startCallbackListener(addresses []string, callbackPath, successRedirect string, callback func(code, state string) error, ready chan<- struct{}) error {
 	mux := http.NewServeMux()
 	done := make(chan error, 1)
 	servers := make([]*http.Server, 0, len(addresses))
@@ -30,22 +145,15 @@ func StartListener(addresses []string, callbackPath string, callback func(code,
 		})
 	}
 
-	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		state := r.URL.Query().Get("state")
-
-		err := callback(code, state)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "Error: %s", err.Error())
-			finish(err)
+	// Serve the callback on exactly callbackPath: a ServeMux pattern ending in
+	// a slash would also match every path below it.
+	handler := callbackHandler(callback, successRedirect, finish)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != callbackPath {
+			http.NotFound(w, r)
 			return
 		}
-
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, "Authentication successful! You can close this window.")
-
-		finish(nil)
+		handler(w, r)
 	})
 
 	for _, address := range addresses {
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// OAuth1Signature holds every input and intermediate value of an OAuth1
+// HMAC-SHA1 signature, for debugging 401s. The consumer and token secrets are
+// deliberately absent: only their lengths are kept.
+type OAuth1Signature struct {
+	Method string
+	// BaseURL is the request URL without query string or fragment.
+	BaseURL string
+	// Params are the percent-encoded key=value pairs, sorted, exactly as they
+	// are joined into ParamString.
+	Params            []string
+	ParamString       string
+	BaseString        string
+	ConsumerSecretLen int
+	TokenSecretLen    int
+	Nonce             string
+	Timestamp         string
+	Signature         string
+}
+
+// signOAuth1 computes the HMAC-SHA1 signature of a request whose parameters
+// (query, body and oauth_* values) are in params.
+func signOAuth1(method, urlStr string, params map[string]string, consumerSecret, tokenSecret string) (*OAuth1Signature, error) {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("InvalidURL", err)
+	}
+
+	sig := &OAuth1Signature{
+		Method:            strings.ToUpper(method),
+		BaseURL:           fmt.Sprintf("%s://%s%s", parsedURL.Scheme, parsedURL.Host, parsedURL.Path),
+		ConsumerSecretLen: len(consumerSecret),
+		TokenSecretLen:    len(tokenSecret),
+		Nonce:             params["oauth_nonce"],
+		Timestamp:         params["oauth_timestamp"],
+	}
+
+	// Sort on the encoded pairs, as RFC 5849 §3.4.1.3.2 requires.
+	for key, value := range params {
+		sig.Params = append(sig.Params, encode(key)+"="+encode(value))
+	}
+	sort.Strings(sig.Params)
+	sig.ParamString = strings.Join(sig.Params, "&")
+	sig.BaseString = sig.Method + "&" + encode(sig.BaseURL) + "&" + encode(sig.ParamString)
+
+	signingKey := encode(consumerSecret) + "&" + encode(tokenSecret)
+	h := hmac.New(sha1.New, []byte(signingKey))
+	h.Write([]byte(sig.BaseString))
+	sig.Signature = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return sig, nil
+}
+
+// WriteTo prints the signature inputs in the order they are fed to HMAC-SHA1.
+// Secrets are shown only as <consumer_secret:N chars>.
+func (s *OAuth1Signature) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("--- OAuth1 signature ---\n")
+	fmt.Fprintf(&b, "method:      %s\n", s.Method)
+	fmt.Fprintf(&b, "base url:    %s\n", s.BaseURL)
+	b.WriteString("parameters (encoded, sorted):\n")
+	for _, p := range s.Params {
+		fmt.Fprintf(&b, "  %s\n", p)
+	}
+	fmt.Fprintf(&b, "param string:\n%s\n", s.ParamString)
+	fmt.Fprintf(&b, "base string:\n%s\n", s.BaseString)
+	fmt.Fprintf(&b, "signing key: <consumer_secret:%d chars>&<token_secret:%d chars>\n", s.ConsumerSecretLen, s.TokenSecretLen)
+	fmt.Fprintf(&b, "nonce:       %s\n", s.Nonce)
+	fmt.Fprintf(&b, "timestamp:   %s\n", s.Timestamp)
+	fmt.Fprintf(&b, "signature:   %s\n", s.Signature)
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// oauth1KnownAnswer is the worked example from X's "Creating a signature"
+// documentation for OAuth 1.0a.
+var oauth1KnownAnswer = struct {
+	method, url                 string
+	params                      map[string]string
+	consumerSecret, tokenSecret string
+	signature                   string
+}{
+	method: "POST",
+	url:    "https://api.twitter.com/1.1/statuses/update.json?include_entities=true",
+	params: map[string]string{
+		"include_entities":       "true",
+		"status":                 "Hello Ladies + Gentlemen, a signed OAuth request!",
+		"oauth_consumer_key":     "xvz1evFS4wEEPTGEFPHBog",
+		"oauth_nonce":            "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg",
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        "1318622958",
+		"oauth_token":            "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		"oauth_version":          "1.0",
+	},
+	consumerSecret: "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+	tokenSecret:    "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE",
+	signature:      "hCtSmYh+iHYCEqBWrE7C7hYmtUk=",
+}
+
+// VerifyOAuth1Signing signs the documented known-answer request and returns
+// the computed signature details along with the expected signature.
+func VerifyOAuth1Signing() (got *OAuth1Signature, want string, err error) {
+	k := oauth1KnownAnswer
+	got, err = signOAuth1(k.method, k.url, k.params, k.consumerSecret, k.tokenSecret)
+	return got, k.signature, err
+}
+
+// encode percent-encodes s as RFC 3986 (and OAuth1) require: everything but
+// unreserved characters is escaped, and a space becomes %20 rather than +.
+func encode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
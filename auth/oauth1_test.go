@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestVerifyOAuth1Signing(t *testing.T) {
+	got, want, err := VerifyOAuth1Signing()
+	require.NoError(t, err)
+	assert.Equal(t, want, got.Signature)
+	assert.Equal(t, "https://api.twitter.com/1.1/statuses/update.json", got.BaseURL)
+	assert.Contains(t, got.Params, "status=Hello%20Ladies%20%2B%20Gentlemen%2C%20a%20signed%20OAuth%20request%21")
+	assert.Equal(t, "POST&https%3A%2F%2Fapi.twitter.com%2F1.1%2Fstatuses%2Fupdate.json&include_entities%3Dtrue%26oauth_consumer_key%3Dxvz1evFS4wEEPTGEFPHBog%26oauth_nonce%3DkYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D1318622958%26oauth_token%3D370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb%26oauth_version%3D1.0%26status%3DHello%2520Ladies%2520%252B%2520Gentlemen%252C%2520a%2520signed%2520OAuth%2520request%2521", got.BaseString)
+}
+
+func TestOAuth1DebugRedactsSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	ts := &store.TokenStore{Apps: map[string]*store.App{}, FilePath: tempDir + "/auth.yml"}
+	require.NoError(t, ts.SaveOAuth1Tokens("access-token", "token-secret-value", "consumer-key", "consumer-secret-value"))
+
+	var debug bytes.Buffer
+	a := NewAuth(&config.Config{}).WithTokenStore(ts).WithOAuth1Debug(&debug)
+	header, err := a.GetOAuth1Header("GET", "https://api.x.com/2/tweets/search/recent?query=hello world", nil)
+	require.NoError(t, err)
+
+	out := debug.String()
+	assert.Contains(t, out, "base url:    https://api.x.com/2/tweets/search/recent\n")
+	assert.Contains(t, out, "  query=hello%20world\n")
+	assert.Contains(t, out, "signing key: <consumer_secret:21 chars>&<token_secret:18 chars>\n")
+	assert.NotContains(t, out, "consumer-secret-value")
+	assert.NotContains(t, out, "token-secret-value")
+
+	// The debug output describes the signature actually sent.
+	var sig string
+	for _, line := range bytes.Split(debug.Bytes(), []byte("\n")) {
+		if v, ok := bytes.CutPrefix(line, []byte("signature:   ")); ok {
+			sig = string(v)
+		}
+	}
+	require.NotEmpty(t, sig)
+	assert.Contains(t, header, `oauth_signature="`+encode(sig)+`"`)
+}
@@ -2,14 +2,19 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
+	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
 	"github.com/xdevplatform/xurl/store"
@@ -24,26 +29,53 @@ var (
 )
 
 // CreateAuthCommand creates the auth command and its subcommands
-func CreateAuthCommand(a *auth.Auth) *cobra.Command {
+func CreateAuthCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var authCmd = &cobra.Command{
 		Use:   "auth",
 		Short: "Authentication management",
+		Long: `Manage credentials for the X API.
+
+xurl supports three auth types: OAuth2 user-context tokens ('auth oauth2'),
+OAuth1 user tokens ('auth oauth1') and app-only Bearer Tokens ('auth app-only').
+Credentials are stored per registered app ('auth apps'); --app selects the app
+for a single command and 'auth default' sets the one used otherwise.`,
+		Example: `  xurl auth apps add my-app --client-id abc --client-secret xyz
+  xurl auth oauth2
+  xurl auth status
+  xurl auth clear --all`,
 	}
 
-	authCmd.AddCommand(createAuthAppOnlyCmd(a))
-	authCmd.AddCommand(createAuthOAuth2Cmd(a))
-	authCmd.AddCommand(createAuthOAuth1Cmd(a))
-	authCmd.AddCommand(createAuthStatusCmd())
-	authCmd.AddCommand(createAuthClearCmd(a))
-	authCmd.AddCommand(createAppCmd(a))
-	authCmd.AddCommand(createDefaultCmd(a))
+	authCmd.AddCommand(createAuthAppOnlyCmd(a, g))
+	authCmd.AddCommand(createAuthOAuth2Cmd(a, g))
+	authCmd.AddCommand(createAuthOAuth1Cmd(a, g))
+	authCmd.AddCommand(createAuthStatusCmd(a))
+	authCmd.AddCommand(createAuthTestCmd(a, g))
+	authCmd.AddCommand(createAuthCheckAppCmd(a, g))
+	authCmd.AddCommand(CreateTokenCommand(a, g))
+	authCmd.AddCommand(createAuthClearCmd(a, g))
+	authCmd.AddCommand(createAuthImportCmd(a, g))
+	authCmd.AddCommand(createAppCmd(a, g))
+	authCmd.AddCommand(createDefaultCmd(a, g))
 
 	return authCmd
 }
 
+// requireWritableStore is the PreRun of the auth commands whose point is to
+// change the token store. On a read-only store they fail up front instead
+// of appearing to succeed and losing the change when xurl exits.
+func requireWritableStore(a *auth.Auth, g *globalOptions) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if readOnly, reason := a.TokenStore.ReadOnly(); readOnly {
+			g.fprintError(os.Stderr, "Error: '%s' saves to the token store %s, which is read-only because %s", cmd.CommandPath(), a.TokenStore.FilePath, reason)
+			fmt.Fprintln(os.Stderr, "Use --store-path to save to a writable file instead.")
+			os.Exit(1)
+		}
+	}
+}
+
 // ─── auth app-only ──────────────────────────────────────────────────
 
-func createAuthAppOnlyCmd(a *auth.Auth) *cobra.Command {
+func createAuthAppOnlyCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var bearerToken string
 
 	cmd := &cobra.Command{
@@ -55,13 +87,12 @@ func createAuthAppOnlyCmd(a *auth.Auth) *cobra.Command {
 This is X's "OAuth 2.0 App-Only" auth, selected at request time with --auth app.
 It is distinct from OAuth2 user-context tokens (obtained via 'xurl auth oauth2') --
 both are sent as "Authorization: Bearer", which is why this command is named for
-the auth mode (app-only) rather than the token scheme (bearer).
-
-Examples:
-  xurl auth app-only AAAA...                # token as an argument
+the auth mode (app-only) rather than the token scheme (bearer).`,
+		Example: `  xurl auth app-only AAAA...                # token as an argument
   xurl auth app-only --app prod AAAA...     # for a specific registered app
   cat token.txt | xurl auth app-only -      # read the token from stdin (keeps it out of shell history)`,
-		Args: cobra.MaximumNArgs(1),
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			token := bearerToken
 			if len(args) == 1 {
@@ -96,8 +127,9 @@ Examples:
 
 // ─── auth oauth2 ────────────────────────────────────────────────────
 
-func createAuthOAuth2Cmd(a *auth.Auth) *cobra.Command {
-	var headless bool
+func createAuthOAuth2Cmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var headless, reauth, printURLOnly, exportEnv bool
+	var clientID, clientSecret, callbackPath, openCmd, successRedirect string
 	cmd := &cobra.Command{
 		Use:   "oauth2 [USERNAME]",
 		Short: "Configure OAuth2 authentication",
@@ -106,19 +138,90 @@ func createAuthOAuth2Cmd(a *auth.Auth) *cobra.Command {
 By default this opens a browser and listens on the app's redirect URI
 (localhost) for the callback. On a remote/headless machine where that callback
 is unreachable, use --headless: xurl prints the authorization URL, you open it
-on any device, and paste the resulting redirect URL (or code) back in.`,
-		Args: cobra.MaximumNArgs(1),
+on any device, and paste the resulting redirect URL (or code) back in.
+
+The authorization URL is always printed before the browser is opened, so it can
+be copied if the wrong browser (or none) opens. --print-url-only skips opening
+a browser entirely while still waiting for the local callback. --open-cmd
+opens it with your own command instead, e.g. 'wslview {url}' ({url} is
+replaced by the URL). Without it, $BROWSER is tried first on Linux.
+If the command fails, open the printed URL yourself.
+
+If the app already holds a valid token for USERNAME (or its default user when
+no USERNAME is given), nothing is opened and xurl reports that you are already
+authenticated. Pass --reauth (or --force) to authorize again anyway, e.g. to
+grant new scopes or switch accounts.
+
+--client-id and --client-secret override the app's stored client credentials
+(and CLIENT_ID/CLIENT_SECRET) for this login. If the app has no client ID
+stored yet, they are saved to it so the token can be refreshed later.
+
+--callback-path replaces the path of the redirect URI (/callback by default),
+both in the authorization URL and on the local listener, for apps whose
+registered callback URL uses another path. After a successful callback the
+browser shows a page saying it can be closed; --success-redirect sends it to
+a page of your own instead.
+
+--export-env prints 'export X_ACCESS_TOKEN=...' for the new (or already
+stored) token once the flow succeeds, and sends every other message to
+stderr, so the login can be eval'd. When stdout is a terminal the line is
+not printed; run 'xurl auth token --export-env' later instead.`,
+		Example: `  xurl auth oauth2
+  xurl auth oauth2 alice --app prod
+  xurl auth oauth2 --headless          # on a machine without a browser
+  xurl auth oauth2 --reauth            # authorize again, e.g. for new scopes
+  xurl auth oauth2 --client-id abc --client-secret xyz
+  xurl auth oauth2 --callback-path /oauth/callback
+  xurl auth oauth2 --open-cmd 'wslview {url}'
+  eval "$(xurl auth oauth2 --export-env)"`,
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			username := ""
 			if len(args) > 0 {
 				username = args[0]
 			}
 
+			if callbackPath != "" {
+				if err := auth.ValidateCallbackPath(callbackPath); err != nil {
+					g.fprintError(os.Stderr, "Error: --callback-path: %v", err)
+					os.Exit(1)
+				}
+			}
+			if successRedirect != "" {
+				if err := auth.ValidateSuccessRedirect(successRedirect); err != nil {
+					g.fprintError(os.Stderr, "Error: --success-redirect: %v", err)
+					os.Exit(1)
+				}
+			}
+			// With --export-env, stdout only gets the export line.
+			out := os.Stdout
+			if exportEnv {
+				out = os.Stderr
+			}
+			a.WithClientCredentials(clientID, clientSecret).WithCallbackPath(callbackPath)
+			if storedUsername, ok := existingOAuth2Login(a, username, reauth); ok {
+				who := ""
+				if storedUsername != "" {
+					who = " as @" + storedUsername
+				}
+				fmt.Fprintf(out, "\033[32mAlready authenticated%s.\033[0m Use --reauth to authorize again.\n", who)
+				if exportEnv {
+					token, err := a.GetValidOAuth2Token(username)
+					if err != nil {
+						g.fprintError(os.Stderr, "Error: %v", err)
+						os.Exit(1)
+					}
+					exportLoginToken(token)
+				}
+				return
+			}
+
 			// Warn when --app is not specified and the active/default app has
 			// no client credentials but another registered app does. Tokens
 			// saved to a credential-less app cannot be refreshed, causing
 			// cryptic 401 errors on all subsequent API calls.
-			if warn, targetName, credentialed := oauth2NoAppCredentialWarning(a.TokenStore, a.AppName()); warn {
+			if warn, targetName, credentialed := oauth2NoAppCredentialWarning(a.TokenStore, a.AppName()); warn && clientID == "" {
 				fmt.Fprintf(os.Stderr, "\033[33m⚠️  No --app specified. The OAuth2 token will be saved to the %q app,\n", targetName)
 				fmt.Fprintf(os.Stderr, "    which has no client credentials stored. API calls will fail with 401 errors.\n\n")
 				fmt.Fprintf(os.Stderr, "    App(s) with credentials available:\n")
@@ -129,32 +232,93 @@ on any device, and paste the resulting redirect URL (or code) back in.`,
 				fmt.Fprintf(os.Stderr, "\n    Run instead:  xurl auth oauth2 --app %s\n\n", credentialed[0])
 			}
 
+			var token string
 			var err error
 			if headless {
-				err = runHeadlessLogin(a, username)
+				token, err = runHeadlessLogin(a, username)
 			} else {
-				_, err = a.OAuth2Flow(username)
+				token, err = a.WithPrintURLOnly(printURLOnly).WithOpenCommand(openCmd).WithSuccessRedirect(successRedirect).OAuth2Flow(username)
 			}
 			if err != nil {
 				fmt.Fprintln(os.Stderr, "OAuth2 authentication failed:", err)
 				os.Exit(1)
 			}
-			fmt.Printf("\033[32mOAuth2 authentication successful!\033[0m\n")
+			fmt.Fprintf(out, "\033[32mOAuth2 authentication successful!\033[0m\n")
+			if saved, name := saveFlagClientCredentials(a.TokenStore, a.AppName(), clientID, clientSecret); saved {
+				fmt.Fprintf(out, "Saved the client credentials to app %q so the token can be refreshed.\n", name)
+			}
+			if exportEnv {
+				exportLoginToken(token)
+			}
 		},
 	}
 
+	cmd.Flags().StringVar(&clientID, "client-id", "", "OAuth2 client ID to log in with, instead of the app's stored one or CLIENT_ID")
+	cmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client secret to log in with, instead of the app's stored one or CLIENT_SECRET")
+	cmd.Flags().StringVar(&callbackPath, "callback-path", "", "Path of the redirect URI to use and listen on, e.g. /oauth/callback (must begin with /)")
 	cmd.Flags().BoolVar(&headless, "headless", false, "Authenticate without a local browser/callback: print the URL and paste the code back (for remote/headless machines)")
+	cmd.Flags().StringVar(&openCmd, "open-cmd", "", "Command that opens the authorization URL, with {url} where the URL goes, e.g. 'wslview {url}'")
+	cmd.Flags().BoolVar(&printURLOnly, "print-url-only", false, "Print the authorization URL without opening a browser (the local callback is still used)")
+	cmd.Flags().StringVar(&successRedirect, "success-redirect", "", "After a successful callback, redirect the browser to this http(s) URL instead of showing xurl's success page")
+	cmd.Flags().BoolVar(&reauth, "reauth", false, "Authorize again even if a valid token is already stored")
+	cmd.Flags().BoolVar(&reauth, "force", false, "Alias for --reauth")
+	cmd.Flags().BoolVar(&exportEnv, "export-env", false, "After a successful login, print 'export X_ACCESS_TOKEN=...' for a shell to eval; other messages go to stderr")
 
 	return cmd
 }
 
+// exportLoginToken prints the export line for the token `auth oauth2
+// --export-env` logged in with. On a terminal it prints a hint instead, as
+// --force already means --reauth there.
+func exportLoginToken(token string) {
+	if isTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stderr, "Not printing the token to a terminal. Run: eval \"$(xurl auth token --export-env)\"")
+		return
+	}
+	writeTokenExports(os.Stdout, token)
+}
+
+// saveFlagClientCredentials stores client credentials given with --client-id
+// on the app a token was just saved to, if that app has no client ID yet, so
+// the token can be refreshed without passing them again. It returns the app's
+// name when it saved them.
+func saveFlagClientCredentials(ts *store.TokenStore, appOverride, clientID, clientSecret string) (bool, string) {
+	if clientID == "" {
+		return false, ""
+	}
+	name := appOverride
+	if name == "" {
+		name = ts.GetDefaultApp()
+	}
+	app := ts.GetApp(name)
+	if app == nil || app.ClientID != "" {
+		return false, name
+	}
+	if err := ts.UpdateApp(name, clientID, clientSecret); err != nil {
+		fmt.Fprintf(os.Stderr, "\033[33mWarning: could not save the client credentials to app %q: %v\033[0m\n", name, err)
+		return false, name
+	}
+	return true, name
+}
+
+// existingOAuth2Login reports whether the OAuth2 flow can be skipped because
+// the active app already holds a valid token for username. reauth always
+// forces the flow.
+func existingOAuth2Login(a *auth.Auth, username string, reauth bool) (string, bool) {
+	if reauth {
+		return "", false
+	}
+	return a.ExistingOAuth2Login(username)
+}
+
 // runHeadlessLogin drives the headless OAuth2 flow: print the authorize URL,
-// read the pasted redirect URL/code from stdin, and complete the exchange. The
-// auth package owns the protocol; this function owns the (styled) presentation.
-func runHeadlessLogin(a *auth.Auth, username string) error {
+// read the pasted redirect URL/code from stdin, and complete the exchange,
+// returning the new access token. The auth package owns the protocol; this
+// function owns the (styled) presentation.
+func runHeadlessLogin(a *auth.Auth, username string) (string, error) {
 	hl, err := a.StartHeadlessLogin(username)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	out := os.Stderr
@@ -162,13 +326,12 @@ func runHeadlessLogin(a *auth.Auth, username string) error {
 
 	line, rerr := bufio.NewReader(os.Stdin).ReadString('\n')
 	if rerr != nil && strings.TrimSpace(line) == "" {
-		return fmt.Errorf("failed to read pasted code: %w", rerr)
+		return "", fmt.Errorf("failed to read pasted code: %w", rerr)
 	}
 
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, subtleStyle.Render("Exchanging code for a token…"))
-	_, err = hl.Complete(line)
-	return err
+	return hl.Complete(line)
 }
 
 // renderHeadlessInstructions prints the step-by-step headless prompt. When styled
@@ -203,12 +366,20 @@ func renderHeadlessInstructions(out io.Writer, authURL, redirectURI string, styl
 
 // ─── auth oauth1 ────────────────────────────────────────────────────
 
-func createAuthOAuth1Cmd(a *auth.Auth) *cobra.Command {
+func createAuthOAuth1Cmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var consumerKey, consumerSecret, accessToken, tokenSecret string
 
 	cmd := &cobra.Command{
 		Use:   "oauth1",
 		Short: "Configure OAuth1 authentication",
+		Long: `Store OAuth1 (user-context) credentials for the active app.
+
+All four values come from the "Keys and tokens" page of the app in the X
+developer portal. OAuth1 is required for 'xurl webhook start', which signs CRC
+responses with the consumer secret.`,
+		Example: `  xurl auth oauth1 --consumer-key KEY --consumer-secret SECRET \
+    --access-token TOKEN --token-secret TOKEN_SECRET`,
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := a.TokenStore.SaveOAuth1TokensForApp(a.AppName(), accessToken, tokenSecret, consumerKey, consumerSecret)
 			if err != nil {
@@ -229,17 +400,52 @@ func createAuthOAuth1Cmd(a *auth.Auth) *cobra.Command {
 	cmd.MarkFlagRequired("access-token")
 	cmd.MarkFlagRequired("token-secret")
 
+	cmd.AddCommand(createAuthOAuth1VerifyCmd(g))
 	return cmd
 }
 
+func createAuthOAuth1VerifyCmd(g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Check OAuth1 signing against a known-answer test vector",
+		Long: `Sign the worked example from X's OAuth 1.0a "Creating a signature"
+documentation and compare the result with its published signature, proving
+that request signing is correct on this build and platform. The full
+signature inputs are printed, in the same form as --debug-oauth1.
+
+No stored credentials are used or needed.`,
+		Example: `  xurl auth oauth1 verify`,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			got, want, err := auth.VerifyOAuth1Signing()
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			got.WriteTo(os.Stdout)
+			fmt.Printf("expected:    %s\n", want)
+			if got.Signature != want {
+				g.fprintError(os.Stderr, "FAIL: OAuth1 signature does not match the known-answer vector")
+				os.Exit(1)
+			}
+			fmt.Println("\033[32mPASS: OAuth1 signing matches the known-answer vector\033[0m")
+		},
+	}
+}
+
 // ─── auth status ────────────────────────────────────────────────────
 
-func createAuthStatusCmd() *cobra.Command {
+func createAuthStatusCmd(a *auth.Auth) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show authentication status",
+		Long: `Show every registered app, its client ID and the credentials stored for it.
+
+The default app is marked with ▸; OAuth2 users, OAuth1 and app-only tokens are
+listed underneath each app.`,
+		Example: `  xurl auth status`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ts := store.NewTokenStore()
+			ts := a.TokenStore
 
 			apps := ts.ListApps()
 			defaultApp := ts.GetDefaultApp()
@@ -263,7 +469,7 @@ func createAuthStatusCmd() *cobra.Command {
 				}
 				fmt.Printf("%s %s  [%s]\n", marker, name, clientHint)
 
-				redirectURI, fromEnv, source := config.ResolveRedirectURI(name)
+				redirectURI, fromEnv, source := config.ResolveRedirectURI(ts.FilePath, name)
 				if fromEnv {
 					fmt.Printf("      redirect_uri: %s  [effective via %s]\n", redirectURI, source)
 					if app.RedirectURI != "" {
@@ -311,16 +517,208 @@ func createAuthStatusCmd() *cobra.Command {
 	return cmd
 }
 
+// ─── auth test ──────────────────────────────────────────────────────
+
+// authTestEndpoints is the cheapest call that proves each auth type works:
+// the user's own profile for user context, the v1.1 credential check for
+// OAuth1 (exercising request signing), and a well-known public post for
+// app-only auth, which has no user identity.
+var authTestEndpoints = map[string]string{
+	"oauth2": "/2/users/me",
+	"oauth1": "/1.1/account/verify_credentials.json?skip_status=true&include_entities=false",
+	"app":    "/2/tweets/20",
+}
+
+func createAuthTestCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var authType, username string
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Make one authenticated call to check a credential",
+		Long: `Make a single minimal request with one specific credential and report whether
+it worked, and as whom.
+
+Unlike a normal request, no other stored credential is tried if the chosen one
+fails. The request depends on --auth:
+  oauth2   GET /2/users/me, reporting the user
+  oauth1   GET /1.1/account/verify_credentials.json, reporting the user
+  app      GET /2/tweets/20 (app-only tokens have no identity)
+
+Exits non-zero if the request fails.`,
+		Example: `  xurl auth test --auth oauth2
+  xurl auth test --auth oauth2 --username alice
+  xurl auth test --auth oauth1
+  xurl --app my-app auth test --auth app`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			a.WithStrictAuth(true)
+			identity, err := runAuthTest(g.newClient(a), authType, username)
+			if err != nil {
+				g.fprintError(os.Stderr, "✗ %s: %v", authType, err)
+				os.Exit(1)
+			}
+			fmt.Printf("\033[32m✓ %s: %s\033[0m\n", authType, identity)
+		},
+	}
+
+	cmd.Flags().StringVar(&authType, "auth", "", "Authentication type to test (oauth1, oauth2, or app)")
+	cmd.Flags().StringVarP(&username, "username", "u", "", "OAuth2 user to test (default: the app's default user)")
+	cmd.MarkFlagRequired("auth")
+	return cmd
+}
+
+// runAuthTest makes the authTestEndpoints call for authType and describes
+// the identity the API reported.
+func runAuthTest(client api.Client, authType, username string) (string, error) {
+	authType = strings.ToLower(authType)
+	endpoint, ok := authTestEndpoints[authType]
+	if !ok {
+		return "", fmt.Errorf("unknown auth type %q (want oauth1, oauth2, or app)", authType)
+	}
+	if username != "" && authType != "oauth2" {
+		return "", fmt.Errorf("--username only applies to --auth oauth2")
+	}
+
+	resp, err := client.SendRequest(api.RequestOptions{
+		Method:   "GET",
+		Endpoint: endpoint,
+		AuthType: authType,
+		Username: username,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var body struct {
+		Data struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"data"`
+		IDStr      string `json:"id_str"`
+		ScreenName string `json:"screen_name"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return "", fmt.Errorf("could not parse response: %v", err)
+	}
+
+	switch authType {
+	case "oauth2":
+		return fmt.Sprintf("authenticated as @%s (id %s) via GET %s", body.Data.Username, body.Data.ID, endpoint), nil
+	case "oauth1":
+		return fmt.Sprintf("authenticated as @%s (id %s) via GET /1.1/account/verify_credentials.json", body.ScreenName, body.IDStr), nil
+	default:
+		return fmt.Sprintf("app-only token accepted via GET %s", endpoint), nil
+	}
+}
+
+// ─── auth check-app ─────────────────────────────────────────────────
+
+func createAuthCheckAppCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var clientID, clientSecret string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "check-app",
+		Short: "Check an app's client credentials and redirect URI without logging in",
+		Long: `Check that an OAuth2 client ID and secret are valid and the app is set up
+for 'xurl auth oauth2', without logging in and without opening a browser.
+
+xurl makes two harmless requests and prints a pass/warn/fail line for each:
+  client secret   asks the token endpoint for a client_credentials token; an
+                  invalid_client answer means the ID or secret is wrong (any
+                  other refusal means they were accepted)
+  authorize       requests the authorization URL a login would open, and looks
+                  for an unknown client or an unregistered redirect URI in
+                  the immediate answer
+A check of the redirect URI itself comes first. -v prints what the server
+answered under each line.
+
+The credentials are the app's (or CLIENT_ID/CLIENT_SECRET), unless
+--client-id and --client-secret are given. Nothing is saved. Exits non-zero
+if any check fails.`,
+		Example: `  xurl auth check-app
+  xurl auth check-app --client-id abc --client-secret xyz -v
+  xurl --app prod auth check-app`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			a.WithClientCredentials(clientID, clientSecret)
+			if !runCheckApp(os.Stdout, a, &http.Client{Transport: g.transport, Timeout: 10 * time.Second}, verbose) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&clientID, "client-id", "", "OAuth2 client ID to check, instead of the app's stored one or CLIENT_ID")
+	cmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client secret to check, instead of the app's stored one or CLIENT_SECRET")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print what the server answered to each check")
+	return cmd
+}
+
+// runCheckApp prints the checks of `auth check-app` to w and reports
+// whether all of them passed.
+func runCheckApp(w io.Writer, a *auth.Auth, client *http.Client, verbose bool) bool {
+	redirectURI, _, _ := config.ResolveRedirectURI(a.TokenStore.FilePath, a.AppName())
+	checks := []doctorCheck{checkRedirectURI(redirectURI)}
+	details := []string{""}
+	if checks[0].Status != doctorFail {
+		for _, p := range a.CheckApp(client) {
+			checks = append(checks, doctorCheck{Name: p.Name, Status: p.Status, Message: p.Message})
+			details = append(details, p.Detail)
+		}
+	}
+
+	for i, c := range checks {
+		printDoctorChecks(w, []doctorCheck{c})
+		if verbose && details[i] != "" {
+			for _, line := range strings.Split(strings.TrimRight(details[i], "\n"), "\n") {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+	}
+	return doctorOK(checks)
+}
+
 // ─── auth clear ─────────────────────────────────────────────────────
 
-func createAuthClearCmd(a *auth.Auth) *cobra.Command {
-	var all, oauth1, bearer, appOnly bool
+func createAuthClearCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var all, oauth1, bearer, appOnly, yes bool
 	var oauth2Username string
 
 	cmd := &cobra.Command{
 		Use:   "clear",
 		Short: "Clear authentication tokens",
+		Long: `Remove stored credentials for the active app.
+
+Pick what to remove with --all, --oauth1, --oauth2-username or --app-only. The
+app registration itself is kept; use 'xurl auth apps remove' to delete it.
+xurl lists what will be removed and asks first; --yes skips the question, and
+is required when not running in a terminal.`,
+		Example: `  xurl auth clear --all
+  xurl auth clear --oauth2-username alice
+  xurl auth clear --app-only --app prod --yes`,
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
+			appName := a.TokenStore.GetActiveAppName(a.AppName())
+			app := a.TokenStore.GetApp(appName)
+			switch {
+			case all:
+				if !confirmDestructive(yes, fmt.Sprintf("clear every credential of app %q", appName), storedCredentials(app)...) {
+					return
+				}
+			case oauth1:
+				if !confirmDestructive(yes, fmt.Sprintf("clear the OAuth1 token of app %q", appName), "OAuth1 token") {
+					return
+				}
+			case oauth2Username != "":
+				if !confirmDestructive(yes, fmt.Sprintf("clear an OAuth2 token of app %q", appName), "OAuth2 token of @"+oauth2Username) {
+					return
+				}
+			case bearer || appOnly:
+				if !confirmDestructive(yes, fmt.Sprintf("clear the app-only token of app %q", appName), "app-only (bearer) token") {
+					return
+				}
+			}
+
 			if all {
 				err := a.TokenStore.ClearAllForApp(a.AppName())
 				if err != nil {
@@ -362,39 +760,119 @@ func createAuthClearCmd(a *auth.Auth) *cobra.Command {
 	cmd.Flags().BoolVar(&appOnly, "app-only", false, "Clear the app-only (bearer) token")
 	cmd.Flags().BoolVar(&bearer, "bearer", false, "Clear the app-only (bearer) token")
 	_ = cmd.Flags().MarkHidden("bearer") // back-compat alias for --app-only
+	addYesFlag(cmd, &yes)
+
+	return cmd
+}
+
+// storedCredentials lists the credentials stored for app, one per line, for
+// confirmDestructive.
+func storedCredentials(app *store.App) []string {
+	if app == nil {
+		return nil
+	}
+	var items []string
+	usernames := make([]string, 0, len(app.OAuth2Tokens))
+	for username := range app.OAuth2Tokens {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	for _, username := range usernames {
+		if username == "" {
+			items = append(items, "OAuth2 token of "+displayOAuth2Username(username))
+		} else {
+			items = append(items, "OAuth2 token of @"+username)
+		}
+	}
+	if app.OAuth1Token != nil {
+		items = append(items, "OAuth1 token")
+	}
+	if app.BearerToken != nil {
+		items = append(items, "app-only (bearer) token")
+	}
+	if len(items) == 0 {
+		items = append(items, "no stored credentials")
+	}
+	return items
+}
+
+// ─── auth import ────────────────────────────────────────────────────
+
+func createAuthImportCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var fromTwurl bool
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "import --from-twurl",
+		Short: "Import credentials from another tool",
+		Long: `Import credentials from twurl's ~/.twurlrc into the active app: the first
+OAuth1 profile (unless the app already has OAuth1 tokens) and the first
+Bearer Token.
+
+xurl does this automatically while the active app is missing OAuth1 or
+app-only credentials. Pass --no-twurl-import or set XURL_NO_TWURL_IMPORT=1 to
+turn that off and import explicitly with this command instead.`,
+		Example: `  xurl auth import --from-twurl
+  xurl auth import --from-twurl --file ./old.twurlrc --app legacy`,
+		Args:   cobra.NoArgs,
+		PreRun: requireWritableStore(a, g),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !fromTwurl {
+				fmt.Fprintln(os.Stderr, "Error: nothing to import; pass --from-twurl")
+				os.Exit(1)
+			}
+			if file == "" {
+				file = store.TwurlrcPath()
+			}
+			if err := a.TokenStore.ImportTwurlrcForApp(a.AppName(), file); err != nil {
+				fmt.Fprintf(os.Stderr, "Error importing from %s: %v\n", file, err)
+				os.Exit(1)
+			}
+			fmt.Printf("\033[32mImported credentials from %s\033[0m\n", file)
+		},
+	}
+
+	cmd.Flags().BoolVar(&fromTwurl, "from-twurl", false, "Import OAuth1 and Bearer tokens from twurl's credentials file")
+	cmd.Flags().StringVar(&file, "file", "", "twurl credentials file to read (default ~/.twurlrc)")
 
 	return cmd
 }
 
 // ─── auth apps  (add / remove / list) ───────────────────────────────
 
-func createAppCmd(a *auth.Auth) *cobra.Command {
+func createAppCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	appCmd := &cobra.Command{
 		Use:   "apps",
 		Short: "Manage registered X API apps",
+		Long: `Manage the X API apps xurl knows about.
+
+Each app holds its own client credentials, redirect URI and tokens. The first
+app added becomes the default; change it with 'xurl auth default'.`,
+		Example: `  xurl auth apps add my-app --client-id abc --client-secret xyz
+  xurl auth apps list
+  xurl auth apps remove my-app`,
 	}
 
-	appCmd.AddCommand(createAppAddCmd(a))
-	appCmd.AddCommand(createAppUpdateCmd(a))
-	appCmd.AddCommand(createAppRemoveCmd(a))
-	appCmd.AddCommand(createAppListCmd())
-	appCmd.AddCommand(createAppRedirectURICmd(a))
+	appCmd.AddCommand(createAppAddCmd(a, g))
+	appCmd.AddCommand(createAppUpdateCmd(a, g))
+	appCmd.AddCommand(createAppRemoveCmd(a, g))
+	appCmd.AddCommand(createAppListCmd(a))
+	appCmd.AddCommand(createAppRedirectURICmd(a, g))
 
 	return appCmd
 }
 
-func createAppAddCmd(a *auth.Auth) *cobra.Command {
+func createAppAddCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var clientID, clientSecret, redirectURI string
 
 	cmd := &cobra.Command{
 		Use:   "add NAME",
 		Short: "Register a new X API app",
-		Long: `Register a new X API app with a client ID and secret.
-
-Examples:
-  xurl auth apps add my-app --client-id abc --client-secret xyz
+		Long:  `Register a new X API app with a client ID and secret.`,
+		Example: `  xurl auth apps add my-app --client-id abc --client-secret xyz
   xurl auth apps add my-app --client-id abc --client-secret xyz --redirect-uri http://localhost:8080/callback`,
-		Args: cobra.ExactArgs(1),
+		Args:   cobra.ExactArgs(1),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
 			err := a.TokenStore.AddApp(name, clientID, clientSecret)
@@ -424,19 +902,18 @@ Examples:
 	return cmd
 }
 
-func createAppUpdateCmd(a *auth.Auth) *cobra.Command {
+func createAppUpdateCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var clientID, clientSecret, redirectURI string
 
 	cmd := &cobra.Command{
 		Use:   "update NAME",
 		Short: "Update credentials for an existing app",
-		Long: `Update the client ID and/or secret for an existing registered app.
-
-Examples:
-  xurl auth apps update default --client-id abc --client-secret xyz
+		Long:  `Update the client ID and/or secret for an existing registered app.`,
+		Example: `  xurl auth apps update default --client-id abc --client-secret xyz
   xurl auth apps update my-app --client-id newid
   xurl auth apps update my-app --redirect-uri http://localhost:8080/callback`,
-		Args: cobra.ExactArgs(1),
+		Args:   cobra.ExactArgs(1),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
 			if clientID == "" && clientSecret == "" && redirectURI == "" {
@@ -464,13 +941,29 @@ Examples:
 	return cmd
 }
 
-func createAppRemoveCmd(a *auth.Auth) *cobra.Command {
+func createAppRemoveCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var yes bool
 	cmd := &cobra.Command{
 		Use:   "remove NAME",
 		Short: "Remove a registered app and all its tokens",
-		Args:  cobra.ExactArgs(1),
+		Long: `Remove a registered app together with every token stored for it. xurl
+lists what will be removed and asks first; --yes skips the question.`,
+		Example: `  xurl auth apps remove my-app
+  xurl auth apps remove my-app --yes`,
+		Args:   cobra.ExactArgs(1),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
+			if app := a.TokenStore.GetApp(name); app != nil {
+				registration := "app registration (no client ID)"
+				if app.ClientID != "" {
+					registration = fmt.Sprintf("app registration (client ID %s…)", truncate(app.ClientID, 8))
+				}
+				affected := append([]string{registration}, storedCredentials(app)...)
+				if !confirmDestructive(yes, fmt.Sprintf("remove app %q", name), affected...) {
+					return
+				}
+			}
 			err := a.TokenStore.RemoveApp(name)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
@@ -479,15 +972,21 @@ func createAppRemoveCmd(a *auth.Auth) *cobra.Command {
 			fmt.Printf("\033[32mApp %q removed.\033[0m\n", name)
 		},
 	}
+	addYesFlag(cmd, &yes)
 	return cmd
 }
 
-func createAppListCmd() *cobra.Command {
+func createAppListCmd(a *auth.Auth) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List registered apps",
+		Long: `List registered apps with their client ID and effective redirect URI.
+
+The default app is marked with ▸. The source of the redirect URI (environment,
+stored value or built-in default) is shown in brackets.`,
+		Example: `  xurl auth apps list`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ts := store.NewTokenStore()
+			ts := a.TokenStore
 			apps := ts.ListApps()
 			defaultApp := ts.GetDefaultApp()
 
@@ -506,7 +1005,7 @@ func createAppListCmd() *cobra.Command {
 				if app.ClientID != "" {
 					clientHint = fmt.Sprintf(" (client_id: %s…)", truncate(app.ClientID, 8))
 				}
-				redirectURI, fromEnv, source := config.ResolveRedirectURI(name)
+				redirectURI, fromEnv, source := config.ResolveRedirectURI(ts.FilePath, name)
 				redirectHint := fmt.Sprintf(" redirect_uri: %s [%s]", redirectURI, source)
 				if fromEnv && app.RedirectURI != "" {
 					redirectHint = fmt.Sprintf(" redirect_uri: %s [%s, stored: %s]", redirectURI, source, app.RedirectURI)
@@ -518,14 +1017,20 @@ func createAppListCmd() *cobra.Command {
 	return cmd
 }
 
-func createAppRedirectURICmd(a *auth.Auth) *cobra.Command {
+func createAppRedirectURICmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	redirectCmd := &cobra.Command{
 		Use:   "redirect-uri",
 		Short: "Get or set the stored OAuth2 redirect URI for an app",
+		Long: `Get or set the OAuth2 redirect URI stored for an app.
+
+The REDIRECT_URI environment variable, when set, takes precedence over the
+stored value; 'get' shows which one is in effect.`,
+		Example: `  xurl auth apps redirect-uri get my-app
+  xurl auth apps redirect-uri set my-app http://localhost:8080/callback`,
 	}
 
 	redirectCmd.AddCommand(createAppRedirectURIGetCmd(a))
-	redirectCmd.AddCommand(createAppRedirectURISetCmd(a))
+	redirectCmd.AddCommand(createAppRedirectURISetCmd(a, g))
 
 	return redirectCmd
 }
@@ -534,7 +1039,11 @@ func createAppRedirectURIGetCmd(a *auth.Auth) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get [NAME]",
 		Short: "Show the effective and stored redirect URI for an app",
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Show the effective and stored redirect URI for an app, and where the effective
+value comes from. NAME defaults to the default app.`,
+		Example: `  xurl auth apps redirect-uri get
+  xurl auth apps redirect-uri get my-app`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			ts := a.TokenStore
 			appName := resolveAppNameArg(ts, args)
@@ -548,7 +1057,7 @@ func createAppRedirectURIGetCmd(a *auth.Auth) *cobra.Command {
 				os.Exit(1)
 			}
 
-			effective, _, source := config.ResolveRedirectURI(appName)
+			effective, _, source := config.ResolveRedirectURI(ts.FilePath, appName)
 			stored := app.RedirectURI
 			if stored == "" {
 				stored = "(not set)"
@@ -564,11 +1073,15 @@ func createAppRedirectURIGetCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func createAppRedirectURISetCmd(a *auth.Auth) *cobra.Command {
+func createAppRedirectURISetCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set NAME URI",
 		Short: "Set the stored OAuth2 redirect URI for an app",
-		Args:  cobra.ExactArgs(2),
+		Long: `Set the OAuth2 redirect URI stored for an app. It must match a callback URL
+registered for the app in the X developer portal.`,
+		Example: `  xurl auth apps redirect-uri set my-app http://localhost:8080/callback`,
+		Args:    cobra.ExactArgs(2),
+		PreRun:  requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
 			redirectURI := args[1]
@@ -585,7 +1098,7 @@ func createAppRedirectURISetCmd(a *auth.Auth) *cobra.Command {
 
 // ─── auth default ───────────────────────────────────────────────────
 
-func createDefaultCmd(a *auth.Auth) *cobra.Command {
+func createDefaultCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "default [APP_NAME [USERNAME]]",
 		Short: "Set default app and/or user (interactive or by argument)",
@@ -593,13 +1106,12 @@ func createDefaultCmd(a *auth.Auth) *cobra.Command {
 
 Without arguments: launches an interactive picker (Bubble Tea).
 With one argument:  sets the default app.
-With two arguments: sets the default app and default OAuth2 user.
-
-Examples:
-  xurl auth default                     # interactive picker
+With two arguments: sets the default app and default OAuth2 user.`,
+		Example: `  xurl auth default                     # interactive picker
   xurl auth default my-app              # set default app
   xurl auth default my-app alice        # set default app + user`,
-		Args: cobra.MaximumNArgs(2),
+		Args:   cobra.MaximumNArgs(2),
+		PreRun: requireWritableStore(a, g),
 		Run: func(cmd *cobra.Command, args []string) {
 			ts := a.TokenStore
 
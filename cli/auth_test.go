@@ -1,11 +1,20 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
 	"github.com/xdevplatform/xurl/store"
 )
 
@@ -84,3 +93,137 @@ func TestOAuth2NoAppCredentialWarning(t *testing.T) {
 		assert.Equal(t, "default", targetName)
 	})
 }
+
+func TestExistingOAuth2Login(t *testing.T) {
+	a := mcpTestAuthRefreshable(t, "access", "http://127.0.0.1:0/token")
+
+	t.Run("skips the flow when a valid token is stored", func(t *testing.T) {
+		username, ok := existingOAuth2Login(a, "", false)
+		assert.True(t, ok)
+		assert.Equal(t, "alice", username)
+
+		username, ok = existingOAuth2Login(a, "alice", false)
+		assert.True(t, ok)
+		assert.Equal(t, "alice", username)
+	})
+
+	t.Run("runs the flow for a user without a token", func(t *testing.T) {
+		_, ok := existingOAuth2Login(a, "bob", false)
+		assert.False(t, ok)
+	})
+
+	t.Run("reauth forces the flow", func(t *testing.T) {
+		_, ok := existingOAuth2Login(a, "", true)
+		assert.False(t, ok)
+		_, ok = existingOAuth2Login(a, "alice", true)
+		assert.False(t, ok)
+	})
+
+	t.Run("runs the flow when an expired token cannot be refreshed", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		}))
+		defer srv.Close()
+
+		expired := mcpTestAuthRefreshable(t, "access", srv.URL)
+		require.NoError(t, expired.TokenStore.SaveOAuth2TokenForApp("default", "alice", "access", "refresh", 1))
+		_, ok := existingOAuth2Login(expired, "alice", false)
+		assert.False(t, ok)
+	})
+}
+
+func TestRunAuthTest(t *testing.T) {
+	responses := map[string]string{
+		"oauth2": `{"data":{"id":"42","username":"alice"}}`,
+		"oauth1": `{"id_str":"7","screen_name":"bob"}`,
+		"app":    `{"data":{"id":"20","text":"just setting up my twttr"}}`,
+	}
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			assert.Equal(t, "GET", options.Method)
+			assert.Equal(t, authTestEndpoints[options.AuthType], options.Endpoint)
+			if options.Username == "nobody" {
+				return nil, fmt.Errorf("no OAuth2 token found for nobody")
+			}
+			return json.RawMessage(responses[options.AuthType]), nil
+		},
+	}
+
+	got, err := runAuthTest(client, "oauth2", "alice")
+	require.NoError(t, err)
+	assert.Contains(t, got, "@alice (id 42)")
+
+	got, err = runAuthTest(client, "OAuth1", "")
+	require.NoError(t, err)
+	assert.Contains(t, got, "@bob (id 7)")
+	assert.Contains(t, got, "verify_credentials")
+
+	got, err = runAuthTest(client, "app", "")
+	require.NoError(t, err)
+	assert.Contains(t, got, "app-only token accepted")
+
+	_, err = runAuthTest(client, "oauth2", "nobody")
+	assert.ErrorContains(t, err, "no OAuth2 token")
+
+	_, err = runAuthTest(client, "basic", "")
+	assert.ErrorContains(t, err, "unknown auth type")
+
+	_, err = runAuthTest(client, "app", "alice")
+	assert.ErrorContains(t, err, "--username")
+}
+
+func TestSaveFlagClientCredentials(t *testing.T) {
+	ts := &store.TokenStore{
+		Apps: map[string]*store.App{
+			"default": {OAuth2Tokens: map[string]store.Token{}},
+			"prod":    {ClientID: "stored", ClientSecret: "stored-secret", OAuth2Tokens: map[string]store.Token{}},
+		},
+		DefaultApp: "default",
+		FilePath:   filepath.Join(t.TempDir(), "auth.yml"),
+	}
+
+	saved, _ := saveFlagClientCredentials(ts, "", "", "")
+	assert.False(t, saved, "nothing to save without --client-id")
+
+	saved, name := saveFlagClientCredentials(ts, "prod", "flag-id", "flag-secret")
+	assert.False(t, saved, "stored credentials are never replaced")
+	assert.Equal(t, "prod", name)
+	assert.Equal(t, "stored", ts.GetApp("prod").ClientID)
+
+	saved, name = saveFlagClientCredentials(ts, "", "flag-id", "flag-secret")
+	assert.True(t, saved)
+	assert.Equal(t, "default", name)
+	assert.Equal(t, "flag-id", ts.GetApp("default").ClientID)
+	assert.Equal(t, "flag-secret", ts.GetApp("default").ClientSecret)
+}
+
+func TestRunCheckApp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}))
+	defer server.Close()
+
+	const redirectURI = "http://localhost:8080/callback"
+	t.Setenv("REDIRECT_URI", redirectURI)
+	ts := &store.TokenStore{Apps: map[string]*store.App{}, FilePath: filepath.Join(t.TempDir(), ".xurl")}
+	a := auth.NewAuth(&config.Config{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token", RedirectURI: redirectURI}).
+		WithTokenStore(ts).WithClientCredentials("abc", "wrong")
+
+	var out bytes.Buffer
+	assert.False(t, runCheckApp(&out, a, server.Client(), false))
+	assert.Contains(t, out.String(), "redirect uri")
+	assert.Contains(t, out.String(), "rejected the client ID and secret")
+	assert.NotContains(t, out.String(), `{"error":"invalid_client"}`)
+
+	out.Reset()
+	runCheckApp(&out, a, server.Client(), true)
+	assert.Contains(t, out.String(), `      {"error":"invalid_client"}`, "-v shows the raw answer")
+	assert.Contains(t, out.String(), "      Location: /login")
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// defaultBatchName names the result files of --batch-output-dir after the
+// input line number.
+const defaultBatchName = "{{.line}}"
+
+// batchItem is one request of a batch file.
+type batchItem struct {
+	// Line is the 1-based line of the batch file the request is on.
+	Line    int
+	Request *store.ScheduledRequest
+	// Name is the base name of the item's result file under
+	// --batch-output-dir, without its extension.
+	Name string
+}
+
+// batchRecord is the NDJSON line `xurl batch` prints for an item, and the
+// contents of its error file under --batch-output-dir. Error is the API
+// error body when there is one and otherwise the error message.
+type batchRecord struct {
+	Line     int             `json:"line"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    json.RawMessage `json:"error,omitempty"`
+}
+
+// CreateBatchCommand creates the `batch` command, which sends one request per
+// line of a file.
+func CreateBatchCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var outputDir, nameTemplate string
+
+	cmd := &cobra.Command{
+		Use:   "batch FILE",
+		Short: "Send one request per line of a file",
+		Long: `Send the requests in FILE, one per line, in order. Each line is a request in
+the JSON format of 'xurl schedule add --request-file', written on one line.
+Blank lines are skipped. Use - to read stdin. Every line is parsed before
+anything is sent, so a malformed line sends nothing.
+
+A failed request does not stop the batch. Each result is printed as an NDJSON
+line, {"line": N, "response": ...} or {"line": N, "error": ...}, where error
+is the API's error body when there is one.
+
+With --batch-output-dir, each result goes to a file of its own in that
+directory instead: NAME.json holds the response, and NAME.error.json the
+error record of a failed request. NAME is --batch-name, a Go template over
+  .line     the input line number
+  .input    the input line (decoded JSON), e.g. {{.input.data.text}}
+Names must be distinct and may not contain a path separator.
+
+Exits non-zero when any request failed.`,
+		Example: `  xurl batch requests.ndjson > results.ndjson
+  xurl batch requests.ndjson --batch-output-dir results/
+  xurl batch requests.ndjson --batch-output-dir results/ --batch-name 'user-{{.input.username}}'
+
+  # requests.ndjson
+  {"endpoint": "/2/users/by/username/xdevelopers"}
+  {"endpoint": "/2/tweets", "data": {"text": "Hello"}, "username": "alice"}`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var in io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			var names *template.Template
+			if outputDir != "" {
+				var err error
+				if names, err = template.New("batch-name").Option("missingkey=error").Parse(nameTemplate); err != nil {
+					g.fprintError(os.Stderr, "Error: invalid --batch-name: %v", err)
+					os.Exit(1)
+				}
+			}
+			items, err := readBatch(in, args[0], names)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+
+			write := func(item batchItem, resp json.RawMessage, err error) error {
+				return writeBatchLine(os.Stdout, item, resp, err)
+			}
+			if outputDir != "" {
+				if err := os.MkdirAll(outputDir, 0755); err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
+				}
+				write = func(item batchItem, resp json.RawMessage, err error) error {
+					return writeBatchFile(outputDir, item, resp, err)
+				}
+			}
+
+			failed, err := runBatch(g.newClient(a), items, write)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			if failed > 0 {
+				g.fprintError(os.Stderr, "Error: %d of %d requests failed", failed, len(items))
+				g.reportRequestID(os.Stderr)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&outputDir, "batch-output-dir", "", "Write each result to a file of its own in this directory instead of printing NDJSON")
+	cmd.Flags().StringVar(&nameTemplate, "batch-name", defaultBatchName, "Go template naming the result files of --batch-output-dir (.line, .input)")
+	return cmd
+}
+
+// readBatch parses the requests of a batch file, one per non-blank line;
+// source is only used in error messages. When names is set, each item is
+// named by executing it, and the names are checked to be usable and
+// distinct.
+func readBatch(r io.Reader, source string, names *template.Template) ([]batchItem, error) {
+	var items []batchItem
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		where := fmt.Sprintf("%s:%d", source, line)
+		req, err := parseRequestFile(where, raw)
+		if err != nil {
+			return nil, err
+		}
+		item := batchItem{Line: line, Request: req}
+
+		if names != nil {
+			var input any
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			if err := dec.Decode(&input); err != nil {
+				return nil, fmt.Errorf("invalid request file %s: %v", where, err)
+			}
+			var name bytes.Buffer
+			if err := names.Execute(&name, map[string]any{"line": line, "input": input}); err != nil {
+				return nil, fmt.Errorf("could not name the result of %s: %v", where, err)
+			}
+			item.Name = strings.TrimSpace(name.String())
+			switch {
+			case item.Name == "" || item.Name == "." || item.Name == "..":
+				return nil, fmt.Errorf("the result of %s would be named %q", where, item.Name)
+			case strings.ContainsAny(item.Name, `/\`):
+				return nil, fmt.Errorf("the result name %q of %s contains a path separator", item.Name, where)
+			}
+			if prev, ok := seen[item.Name]; ok {
+				return nil, fmt.Errorf("lines %d and %d would both write results named %q", prev, line, item.Name)
+			}
+			seen[item.Name] = line
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", source, err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s has no requests", source)
+	}
+	return items, nil
+}
+
+// runBatch sends each item's request through client in order and passes
+// the outcome to write. It returns how many requests failed; only an error
+// from write stops it.
+func runBatch(client api.Client, items []batchItem, write func(item batchItem, resp json.RawMessage, err error) error) (failed int, err error) {
+	for _, item := range items {
+		resp, reqErr := client.SendRequest(api.RequestOptions{
+			Method:   item.Request.Method,
+			Endpoint: item.Request.Endpoint,
+			Headers:  item.Request.Headers,
+			Data:     item.Request.Data,
+			AuthType: item.Request.AuthType,
+			Username: item.Request.Username,
+		})
+		if reqErr != nil {
+			failed++
+		}
+		if err := write(item, resp, reqErr); err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
+}
+
+// newBatchRecord builds the record of one outcome.
+func newBatchRecord(item batchItem, resp json.RawMessage, err error) batchRecord {
+	record := batchRecord{Line: item.Line}
+	if err == nil {
+		var compact bytes.Buffer
+		if json.Compact(&compact, resp) == nil {
+			record.Response = compact.Bytes()
+		} else {
+			record.Response, _ = json.Marshal(string(resp))
+		}
+		return record
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, []byte(err.Error())) == nil {
+		record.Error = compact.Bytes()
+	} else {
+		record.Error, _ = json.Marshal(err.Error())
+	}
+	return record
+}
+
+// writeBatchLine prints the record of one outcome to w as an NDJSON line.
+func writeBatchLine(w io.Writer, item batchItem, resp json.RawMessage, err error) error {
+	line, marshalErr := json.Marshal(newBatchRecord(item, resp, err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, writeErr := fmt.Fprintf(w, "%s\n", line)
+	return writeErr
+}
+
+// writeBatchFile writes one outcome into dir: the response to NAME.json, or
+// the error record to NAME.error.json. The other file is removed, so a
+// rerun into the same directory leaves one result per item.
+func writeBatchFile(dir string, item batchItem, resp json.RawMessage, err error) error {
+	result := filepath.Join(dir, item.Name+".json")
+	errorFile := filepath.Join(dir, item.Name+".error.json")
+	if err == nil {
+		if err := os.Remove(errorFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.WriteFile(result, resp, 0644)
+	}
+	record, marshalErr := json.MarshalIndent(newBatchRecord(item, resp, err), "", "  ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if err := os.Remove(result); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.WriteFile(errorFile, append(record, '\n'), 0644)
+}
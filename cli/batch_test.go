@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+const testBatch = `{"endpoint": "/2/users/by/username/alice", "username": "alice"}
+
+{"endpoint": "/2/users/by/username/bob", "username": "bob"}
+{"endpoint": "/2/tweets", "data": {"text": "hi"}, "username": "carol"}
+`
+
+// batchTestClient answers every request with its endpoint, and fails the one
+// for bob with an API error body.
+func batchTestClient(sent *[]api.RequestOptions) fakeClient {
+	return fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			*sent = append(*sent, options)
+			if strings.HasSuffix(options.Endpoint, "/bob") {
+				return nil, errors.New(`{"title": "Not Found Error"}`)
+			}
+			return json.RawMessage(`{"data": {"endpoint": "` + options.Endpoint + `"}}`), nil
+		},
+	}
+}
+
+func TestBatchOutputDirWritesOneFilePerLine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		template string
+		files    []string
+	}{
+		"line numbers": {defaultBatchName, []string{"1.json", "3.error.json", "4.json"}},
+		"input field":  {"user-{{.input.username}}", []string{"user-alice.json", "user-bob.error.json", "user-carol.json"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			names := template.Must(template.New("batch-name").Option("missingkey=error").Parse(tc.template))
+			items, err := readBatch(strings.NewReader(testBatch), "batch.ndjson", names)
+			require.NoError(t, err)
+			require.Len(t, items, 3)
+
+			dir := t.TempDir()
+			var sent []api.RequestOptions
+			failed, err := runBatch(batchTestClient(&sent), items, func(item batchItem, resp json.RawMessage, err error) error {
+				return writeBatchFile(dir, item, resp, err)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 1, failed, "a failure does not stop the batch")
+			require.Len(t, sent, 3)
+			assert.Equal(t, "POST", sent[2].Method)
+			assert.Equal(t, `{"text":"hi"}`, sent[2].Data)
+			assert.Equal(t, "carol", sent[2].Username)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			sort.Strings(files)
+			assert.Equal(t, tc.files, files)
+
+			first, err := os.ReadFile(filepath.Join(dir, tc.files[0]))
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"data": {"endpoint": "/2/users/by/username/alice"}}`, string(first))
+			failure, err := os.ReadFile(filepath.Join(dir, tc.files[1]))
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"line": 3, "error": {"title": "Not Found Error"}}`, string(failure))
+		})
+	}
+}
+
+func TestBatchOutputDirReplacesEarlierResult(t *testing.T) {
+	dir := t.TempDir()
+	item := batchItem{Line: 1, Name: "1"}
+	require.NoError(t, writeBatchFile(dir, item, nil, errors.New("connection refused")))
+	require.NoError(t, writeBatchFile(dir, item, json.RawMessage(`{"data":{}}`), nil))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "1.json", entries[0].Name())
+}
+
+func TestBatchNDJSONOutput(t *testing.T) {
+	items, err := readBatch(strings.NewReader(testBatch), "-", nil)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	var sent []api.RequestOptions
+	failed, err := runBatch(batchTestClient(&sent), items, func(item batchItem, resp json.RawMessage, err error) error {
+		return writeBatchLine(&out, item, resp, err)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, `{"line":1,"response":{"data":{"endpoint":"/2/users/by/username/alice"}}}
+{"line":3,"error":{"title":"Not Found Error"}}
+{"line":4,"response":{"data":{"endpoint":"/2/tweets"}}}
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, writeBatchLine(&out, batchItem{Line: 2}, nil, errors.New("connection refused")))
+	assert.Equal(t, `{"line":2,"error":"connection refused"}`+"\n", out.String())
+}
+
+func TestReadBatchRejectsBadInput(t *testing.T) {
+	parse := func(tmpl string) *template.Template {
+		return template.Must(template.New("batch-name").Option("missingkey=error").Parse(tmpl))
+	}
+	for name, tc := range map[string]struct {
+		input string
+		names *template.Template
+		err   string
+	}{
+		"malformed line":  {`{"endpoint": "/2/users/me"}` + "\n" + `{"endpoint": `, nil, "batch.ndjson:2"},
+		"unknown field":   {`{"endpoint": "/2/users/me", "bogus": 1}`, nil, "batch.ndjson:1"},
+		"empty":           {"\n\n", nil, "has no requests"},
+		"duplicate names": {`{"endpoint": "/2/users/me", "method": "GET"}` + "\n" + `{"endpoint": "/2/users/1", "method": "GET"}`, parse("{{.input.method}}"), "lines 1 and 2"},
+		"separator":       {`{"endpoint": "/2/users/me"}`, parse("{{.input.endpoint}}"), "path separator"},
+		"missing field":   {`{"endpoint": "/2/users/me"}`, parse("{{.input.username}}"), "could not name"},
+		"blank name":      {`{"endpoint": "/2/users/me"}`, parse(" "), "would be named"},
+	} {
+		_, err := readBatch(strings.NewReader(tc.input), "batch.ndjson", tc.names)
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.err, name)
+		}
+	}
+}
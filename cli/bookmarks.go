@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// Scopes the bookmarks endpoints need on top of tweet.read and users.read.
+const (
+	scopeBookmarkRead  = "bookmark.read"
+	scopeBookmarkWrite = "bookmark.write"
+)
+
+// bookmarkTimeLayout is how a bookmarked post's creation time is shown, in
+// local time.
+const bookmarkTimeLayout = "2006-01-02 15:04"
+
+// bookmarkTextWidth is how many characters of a post's text the bookmarks
+// table shows.
+const bookmarkTextWidth = 80
+
+// bookmark is a bookmarked post, with its author joined from includes.
+type bookmark struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+	AuthorID  string `json:"author_id"`
+	Author    string `json:"-"`
+}
+
+// scopeChecker is implemented by clients that know the scopes granted to the
+// stored token a request is signed with (see api.ApiClient.GrantedScopes).
+type scopeChecker interface {
+	GrantedScopes(authType, username string) (scopes []string, known bool)
+}
+
+// requireScopes fails early, with a hint to re-authorize, when the token a
+// request with opts would use is known to lack one of scopes. Tokens whose
+// scopes are unknown are let through, and the API has the final say.
+func requireScopes(client api.Client, opts api.RequestOptions, appName string, scopes ...string) error {
+	checker, ok := client.(scopeChecker)
+	if !ok {
+		return nil
+	}
+	granted, known := checker.GrantedScopes(opts.AuthType, opts.Username)
+	if !known {
+		return nil
+	}
+	var missing []string
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			found = found || g == scope
+		}
+		if !found {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	noun := "scope"
+	if len(missing) > 1 {
+		noun = "scopes"
+	}
+	return fmt.Errorf("your token was not granted the %s %s; run 'xurl auth oauth2%s' to re-authorize it",
+		strings.Join(missing, ", "), noun, appFlagHint(appName))
+}
+
+// CreateBookmarksCommand creates the `bookmarks` command, which lists the
+// authenticated user's bookmarks, and its add, remove and list subcommands.
+func CreateBookmarksCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var list bookmarksListFlags
+	cmd := &cobra.Command{
+		Use:   "bookmarks",
+		Short: "List and manage your bookmarks",
+		Long: `List, add and remove your bookmarks without looking up your user ID. The
+ID is cached on the stored token after the first lookup. Bookmarks need the
+bookmark.read and bookmark.write scopes; if your token is known to lack
+them, xurl says so before sending the request.
+
+On its own, 'xurl bookmarks' is the same as 'xurl bookmarks list'.`,
+		Example: `  xurl bookmarks
+  xurl bookmarks list --paginate --max 500
+  xurl bookmarks add 1234567890
+  xurl bookmarks remove https://x.com/user/status/1234567890`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runBookmarksList(cmd, a, list, g)
+		},
+	}
+	addBookmarksListFlags(cmd, &list)
+	addCommonFlags(cmd)
+	cmd.AddCommand(bookmarksListCmd(a, g), bookmarksAddCmd(a, g), bookmarksRemoveCmd(a, g))
+	return cmd
+}
+
+// bookmarksListFlags are the flags of `bookmarks list`.
+type bookmarksListFlags struct {
+	maxResults int
+	paginate   bool
+	max        int
+	asJSON     bool
+}
+
+func addBookmarksListFlags(cmd *cobra.Command, f *bookmarksListFlags) {
+	cmd.Flags().IntVarP(&f.maxResults, "max-results", "n", 10, "Number of bookmarks in a single page (1–100)")
+	cmd.Flags().BoolVar(&f.paginate, "paginate", false, "Fetch every page of bookmarks")
+	cmd.Flags().IntVar(&f.max, "max", 0, "With --paginate, stop after this many bookmarks (0 means all)")
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Print the raw response instead of a table")
+}
+
+func bookmarksListCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var list bookmarksListFlags
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List your bookmarks",
+		Long: `List your bookmarked posts as a table of creation time, author and text:
+one page of up to -n bookmarks, or with --paginate every page (up to --max
+bookmarks). --json prints the raw response instead.`,
+		Example: `  xurl bookmarks list
+  xurl bookmarks list -n 50
+  xurl bookmarks list --paginate --json > bookmarks.json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runBookmarksList(cmd, a, list, g)
+		},
+	}
+	addBookmarksListFlags(cmd, &list)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func runBookmarksList(cmd *cobra.Command, a *auth.Auth, f bookmarksListFlags, g *globalOptions) {
+	if cmd.Flags().Changed("max") && !f.paginate {
+		g.fprintError(os.Stderr, "Error: --max needs --paginate; use -n for a single page")
+		os.Exit(1)
+	}
+	limit := 0
+	if f.paginate {
+		limit = f.max
+		if limit <= 0 {
+			limit = math.MaxInt
+		}
+	}
+	resp, err := listBookmarks(g.newClient(a), g.baseOpts(cmd), a.AppName(), f.maxResults, limit)
+	if err != nil || f.asJSON || api.IsDryRun(resp) {
+		g.printResult(resp, err)
+		return
+	}
+	bookmarks, err := parseBookmarks(resp)
+	if err != nil {
+		g.fprintError(os.Stderr, "Error: could not parse the bookmarks response: %v", err)
+		os.Exit(1)
+	}
+	printBookmarks(os.Stdout, bookmarks, time.Local)
+}
+
+func bookmarksAddCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add POST_ID_OR_URL",
+		Short: "Bookmark a post",
+		Long:  `Bookmark a post. Accepts a post ID or full URL. Same as 'xurl bookmark'.`,
+		Example: `  xurl bookmarks add 1234567890
+  xurl bookmarks add https://x.com/user/status/1234567890`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g.printResult(addBookmark(g.newClient(a), g.baseOpts(cmd), a.AppName(), args[0]))
+		},
+	}
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func bookmarksRemoveCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove POST_ID_OR_URL",
+		Short:   "Remove a bookmark",
+		Long:    `Remove a post from your bookmarks. Accepts a post ID or full URL. Same as 'xurl unbookmark'.`,
+		Example: `  xurl bookmarks remove 1234567890`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g.printResult(removeBookmark(g.newClient(a), g.baseOpts(cmd), a.AppName(), args[0]))
+		},
+	}
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// listBookmarks checks the token's scopes and fetches one page of up to
+// maxResults bookmarks, or with max > 0 up to max bookmarks across pages.
+func listBookmarks(client api.Client, opts api.RequestOptions, appName string, maxResults, max int) (json.RawMessage, error) {
+	if err := requireScopes(client, opts, appName, scopeBookmarkRead); err != nil {
+		return nil, err
+	}
+	userID, err := resolveMyUserID(client, opts)
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 {
+		return api.GetAllBookmarks(client, userID, max, opts)
+	}
+	return api.GetBookmarks(client, userID, maxResults, opts)
+}
+
+// addBookmark checks the token's scopes and bookmarks postID.
+func addBookmark(client api.Client, opts api.RequestOptions, appName, postID string) (json.RawMessage, error) {
+	if err := requireScopes(client, opts, appName, scopeBookmarkWrite); err != nil {
+		return nil, err
+	}
+	return withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+		return api.Bookmark(client, userID, postID, opts)
+	})
+}
+
+// removeBookmark checks the token's scopes and removes the bookmark of postID.
+func removeBookmark(client api.Client, opts api.RequestOptions, appName, postID string) (json.RawMessage, error) {
+	if err := requireScopes(client, opts, appName, scopeBookmarkWrite); err != nil {
+		return nil, err
+	}
+	return withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+		return api.Unbookmark(client, userID, postID, opts)
+	})
+}
+
+// parseBookmarks reads the posts of a bookmarks response and joins their
+// authors from includes.
+func parseBookmarks(resp json.RawMessage) ([]bookmark, error) {
+	var body struct {
+		Data     []bookmark `json:"data"`
+		Includes struct {
+			Users []struct {
+				ID       string `json:"id"`
+				Username string `json:"username"`
+			} `json:"users"`
+		} `json:"includes"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return nil, err
+	}
+	usernames := make(map[string]string, len(body.Includes.Users))
+	for _, u := range body.Includes.Users {
+		usernames[u.ID] = u.Username
+	}
+	for i := range body.Data {
+		b := &body.Data[i]
+		if username, ok := usernames[b.AuthorID]; ok {
+			b.Author = "@" + username
+		} else {
+			b.Author = b.AuthorID
+		}
+	}
+	return body.Data, nil
+}
+
+// bookmarkText flattens text to one line of at most width characters.
+func bookmarkText(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return text
+}
+
+// printBookmarks writes bookmarks as a table of creation time (in loc),
+// author and text.
+func printBookmarks(w io.Writer, bookmarks []bookmark, loc *time.Location) {
+	if len(bookmarks) == 0 {
+		fmt.Fprintln(w, "No bookmarks.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CREATED\tAUTHOR\tTEXT")
+	for _, b := range bookmarks {
+		created := b.CreatedAt
+		if t, err := time.Parse(time.RFC3339, b.CreatedAt); err == nil {
+			created = t.In(loc).Format(bookmarkTimeLayout)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", created, b.Author, bookmarkText(b.Text, bookmarkTextWidth))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// scopedClient is a cachingClient that also reports the granted scopes.
+type scopedClient struct {
+	cachingClient
+	scopes []string
+}
+
+func (c *scopedClient) GrantedScopes(authType, username string) ([]string, bool) {
+	return c.scopes, c.scopes != nil
+}
+
+func TestRequireScopes(t *testing.T) {
+	client := &scopedClient{scopes: []string{"tweet.read", "users.read", "bookmark.read"}}
+	assert.NoError(t, requireScopes(client, api.RequestOptions{}, "", scopeBookmarkRead))
+
+	err := requireScopes(client, api.RequestOptions{}, "prod", scopeBookmarkRead, scopeBookmarkWrite)
+	require.Error(t, err)
+	assert.Equal(t, "your token was not granted the bookmark.write scope; run 'xurl auth oauth2 --app prod' to re-authorize it", err.Error())
+
+	client.scopes = nil
+	assert.NoError(t, requireScopes(client, api.RequestOptions{}, "", scopeBookmarkWrite), "unknown scopes are let through")
+	assert.NoError(t, requireScopes(fakeClient{}, api.RequestOptions{}, "", scopeBookmarkWrite))
+}
+
+func TestBookmarksUseCachedUserID(t *testing.T) {
+	var endpoints []string
+	client := &scopedClient{
+		cachingClient: cachingClient{cached: "42", fakeClient: fakeClient{
+			sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+				endpoints = append(endpoints, options.Method+" "+options.Endpoint)
+				return json.RawMessage(`{"data":{"bookmarked":true}}`), nil
+			},
+		}},
+		scopes: []string{"bookmark.read", "bookmark.write"},
+	}
+
+	_, err := addBookmark(client, api.RequestOptions{}, "", "https://x.com/user/status/99")
+	require.NoError(t, err)
+	_, err = removeBookmark(client, api.RequestOptions{}, "", "99")
+	require.NoError(t, err)
+	_, err = listBookmarks(client, api.RequestOptions{}, "", 5, 0)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 3, "no /2/users/me lookup with a cached ID")
+	assert.Equal(t, "POST /2/users/42/bookmarks", endpoints[0])
+	assert.Equal(t, "DELETE /2/users/42/bookmarks/99", endpoints[1])
+	assert.Contains(t, endpoints[2], "GET /2/users/42/bookmarks?max_results=5")
+
+	endpoints = nil
+	client.scopes = []string{"bookmark.read"}
+	_, err = addBookmark(client, api.RequestOptions{}, "", "99")
+	assert.ErrorContains(t, err, "bookmark.write")
+	assert.Empty(t, endpoints, "nothing is sent without the scope")
+}
+
+func TestPrintBookmarks(t *testing.T) {
+	bookmarks, err := parseBookmarks(json.RawMessage(`{
+		"data": [
+			{"id": "1", "text": "Hello\nworld", "created_at": "2026-03-01T18:30:00.000Z", "author_id": "7"},
+			{"id": "2", "text": "` + "A long post that goes on and on, well past the width of the table, so it is cut short" + `", "created_at": "2026-03-02T09:05:00.000Z", "author_id": "8"}
+		],
+		"includes": {"users": [{"id": "7", "username": "alice"}]}
+	}`))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printBookmarks(&out, bookmarks, time.FixedZone("CET", 3600))
+	assert.Equal(t, `CREATED           AUTHOR  TEXT
+2026-03-01 19:30  @alice  Hello world
+2026-03-02 10:05  8       A long post that goes on and on, well past the width of the table, so it is cut…
+`, out.String())
+
+	out.Reset()
+	printBookmarks(&out, nil, time.UTC)
+	assert.Equal(t, "No bookmarks.\n", out.String())
+}
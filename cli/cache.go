@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// CreateCacheCommand creates the `cache` command, which manages the response
+// cache used by --cache-ttl.
+func CreateCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of GET responses",
+		Long: `Manage the on-disk cache of GET responses.
+
+With --cache-ttl, successful GET responses are kept in the cache directory
+(see 'xurl config paths') and identical requests made by the same account
+within the TTL are answered from it without touching the network. --no-cache
+ignores the cache for one run.`,
+		Example: `  xurl --cache-ttl 60s /2/users/me
+  xurl cache clear`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:     "clear",
+		Short:   "Remove every cached response",
+		Long:    `Remove every response cached by --cache-ttl.`,
+		Example: `  xurl cache clear`,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := api.ClearResponseCache(store.ResponseCacheDir())
+			if err != nil {
+				fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed %d cached response(s)\n", n)
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// CreateChainCommand creates the `chain` command, which runs request files in
+// order, letting each one reference the responses before it.
+func CreateChainCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "chain STEP_FILE...",
+		Short: "Run request files in order, feeding each response into the next",
+		Long: `Run a sequence of requests, where later steps can use values from earlier
+responses: for example, create a post and then reply to it.
+
+Each step is a request file in the same JSON format as 'xurl schedule add
+--request-file'. Before a step is parsed it is expanded as a Go template with:
+  .prev     the previous step's response (decoded JSON)
+  .steps    every earlier response, in order ({{index .steps 0}} is the first)
+Use {{json VALUE}} to insert a value as JSON, quoted and escaped. Referencing
+a field that is not in the response is an error.
+
+Every response is printed. The chain stops at the first failing step and
+exits non-zero.`,
+		Example: `  xurl chain create.json reply.json
+
+  # create.json
+  {"endpoint": "/2/tweets", "data": {"text": "First!"}}
+
+  # reply.json
+  {"endpoint": "/2/tweets",
+   "data": {"text": "Second!", "reply": {"in_reply_to_tweet_id": {{json .prev.data.id}}}}}`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runChain(g.newClient(a), args, func(step int, path string, resp json.RawMessage) {
+				fmt.Fprintf(os.Stderr, "▸ step %d: %s\n", step+1, path)
+				g.format.Print(resp)
+			})
+			if err != nil {
+				g.printResult(nil, err)
+			}
+		},
+	}
+}
+
+// chainFuncs are the functions available to chain step templates.
+var chainFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// runChain runs each step file through client in order. A step is expanded
+// as a template over the earlier responses (see CreateChainCommand) and then
+// parsed as a request file. onResponse is called with each step's response.
+func runChain(client api.Client, paths []string, onResponse func(step int, path string, resp json.RawMessage)) error {
+	var steps []any
+	for i, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var prev any
+		if len(steps) > 0 {
+			prev = steps[len(steps)-1]
+		}
+		expanded, err := expandChainStep(path, raw, map[string]any{"prev": prev, "steps": steps})
+		if err != nil {
+			return err
+		}
+		req, err := parseRequestFile(path, expanded)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.SendRequest(api.RequestOptions{
+			Method:   req.Method,
+			Endpoint: req.Endpoint,
+			Headers:  req.Headers,
+			Data:     req.Data,
+			AuthType: req.AuthType,
+			Username: req.Username,
+		})
+		if err != nil {
+			return err
+		}
+		onResponse(i, path, resp)
+
+		// UseNumber keeps large numbers intact instead of printing them as
+		// floats when they are substituted into the next step.
+		var decoded any
+		dec := json.NewDecoder(bytes.NewReader(resp))
+		dec.UseNumber()
+		if err := dec.Decode(&decoded); err != nil {
+			return fmt.Errorf("step %d (%s): could not parse response: %v", i+1, path, err)
+		}
+		steps = append(steps, decoded)
+	}
+	return nil
+}
+
+// expandChainStep executes a step file as a template over data.
+func expandChainStep(path string, raw []byte, data map[string]any) ([]byte, error) {
+	tmpl, err := template.New(path).Funcs(chainFuncs).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template in %s: %v", path, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("could not expand %s: %v", path, err)
+	}
+	return out.Bytes(), nil
+}
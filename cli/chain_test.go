@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestRunChain(t *testing.T) {
+	dir := t.TempDir()
+	create := filepath.Join(dir, "create.json")
+	reply := filepath.Join(dir, "reply.json")
+	require.NoError(t, os.WriteFile(create, []byte(`{"endpoint": "/2/tweets", "data": {"text": "First!"}}`), 0600))
+	require.NoError(t, os.WriteFile(reply, []byte(`{
+		"endpoint": "/2/tweets",
+		"data": {"text": {{json .prev.data.text}}, "reply": {"in_reply_to_tweet_id": "{{.prev.data.id}}"}}
+	}`), 0600))
+
+	var sent []api.RequestOptions
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			sent = append(sent, options)
+			if len(sent) == 1 {
+				return json.RawMessage(`{"data":{"id":"111","text":"First! \"quoted\""}}`), nil
+			}
+			return json.RawMessage(`{"data":{"id":"222"}}`), nil
+		},
+	}
+
+	var printed []string
+	err := runChain(client, []string{create, reply}, func(step int, path string, resp json.RawMessage) {
+		printed = append(printed, string(resp))
+	})
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+	assert.Equal(t, "POST", sent[1].Method)
+	assert.JSONEq(t, `{"text":"First! \"quoted\"","reply":{"in_reply_to_tweet_id":"111"}}`, sent[1].Data,
+		"the second step's body must be built from the first response")
+	assert.Len(t, printed, 2)
+}
+
+func TestRunChainMissingField(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	require.NoError(t, os.WriteFile(first, []byte(`{"endpoint": "/2/users/me"}`), 0600))
+	require.NoError(t, os.WriteFile(second, []byte(`{"endpoint": "/2/users/{{.prev.data.nope}}"}`), 0600))
+
+	calls := 0
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			calls++
+			return json.RawMessage(`{"data":{"id":"1"}}`), nil
+		},
+	}
+	err := runChain(client, []string{first, second}, func(int, string, json.RawMessage) {})
+	assert.ErrorContains(t, err, "nope")
+	assert.Equal(t, 1, calls, "a step that cannot be expanded must not be sent")
+
+	err = runChain(client, []string{second}, func(int, string, json.RawMessage) {})
+	assert.Error(t, err, "the first step has no previous response")
+}
@@ -24,7 +24,6 @@ import (
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/store"
-	"github.com/xdevplatform/xurl/utils"
 )
 
 // chatSupported reports whether this build includes the XChat client.
@@ -32,7 +31,7 @@ const chatSupported = true
 
 // CreateChatCommand creates the `chat` command family: an end-to-end
 // encrypted XChat client backed by the chat-xdk crypto binding.
-func CreateChatCommand(a *auth.Auth) *cobra.Command {
+func CreateChatCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
 	chatCmd := &cobra.Command{
 		Use:   "chat",
 		Short: "Send and read end-to-end encrypted XChat messages",
@@ -53,24 +52,28 @@ exported private-key blob).
 Conversations can be addressed by @username, user id, or conversation id
 (e.g. 123-456 or g123). Requires OAuth2 user authentication with the
 dm.read and dm.write scopes (run 'xurl auth oauth2' first).`,
+		Example: `  xurl chat keys restore
+  xurl chat send @bob "hello"
+  xurl chat read @bob -n 20
+  xurl chat listen @bob`,
 	}
 
 	chatCmd.AddCommand(
-		createChatKeysCommand(a),
-		chatConversationsCmd(a),
-		chatReadCmd(a),
-		chatSendCmd(a),
-		chatListenCmd(a),
-		chatRotateCmd(a),
-		chatDownloadCmd(a),
-		chatMembersCmd(a),
-		chatMarkReadCmd(a),
-		chatTypingCmd(a),
+		createChatKeysCommand(a, g),
+		chatConversationsCmd(a, g),
+		chatReadCmd(a, g),
+		chatSendCmd(a, g),
+		chatListenCmd(a, g),
+		chatRotateCmd(a, g),
+		chatDownloadCmd(a, g),
+		chatMembersCmd(a, g),
+		chatMarkReadCmd(a, g),
+		chatTypingCmd(a, g),
 	)
 	return chatCmd
 }
 
-func chatDownloadCmd(a *auth.Auth) *cobra.Command {
+func chatDownloadCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "download CONVERSATION|@USERNAME MEDIA_HASH_KEY",
 		Short: "Download and decrypt a chat media attachment",
@@ -78,10 +81,11 @@ func chatDownloadCmd(a *auth.Auth) *cobra.Command {
 file. Find the media hash key with 'chat read --json' (attachments carry
 a media_hash_key). The attachment is decrypted with the conversation key
 version that was active when the message was sent.`,
-		Args: cobra.ExactArgs(2),
+		Example: `  xurl chat download @bob 3f2a9c... -o photo.jpg`,
+		Args:    cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			out, _ := cmd.Flags().GetString("output")
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -94,7 +98,7 @@ version that was active when the message was sent.`,
 	return cmd
 }
 
-func chatMembersCmd(a *auth.Auth) *cobra.Command {
+func chatMembersCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add-members GROUP @USER [@USER...]",
 		Short: "Add members to a group conversation",
@@ -102,10 +106,11 @@ func chatMembersCmd(a *auth.Auth) *cobra.Command {
 conversation key so the new members can read messages from now on. Only a
 current member can add members. New members do not gain access to messages
 sent before they were added.`,
-		Args: cobra.MinimumNArgs(2),
+		Example: `  xurl chat add-members g123 @carol @dave`,
+		Args:    cobra.MinimumNArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			yes, _ := cmd.Flags().GetBool("yes")
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -121,13 +126,16 @@ sent before they were added.`,
 	return cmd
 }
 
-func chatMarkReadCmd(a *auth.Auth) *cobra.Command {
+func chatMarkReadCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mark-read CONVERSATION|@USERNAME",
 		Short: "Mark a conversation read up to its latest message",
-		Args:  cobra.ExactArgs(1),
+		Long: `Mark a conversation read up to its latest message, clearing its unread count
+in other XChat clients.`,
+		Example: `  xurl chat mark-read @bob`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -139,13 +147,16 @@ func chatMarkReadCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func chatTypingCmd(a *auth.Auth) *cobra.Command {
+func chatTypingCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "typing CONVERSATION|@USERNAME",
 		Short: "Send a typing indicator to a conversation",
-		Args:  cobra.ExactArgs(1),
+		Long: `Send a typing indicator to a conversation. The indicator expires on its own
+after a few seconds.`,
+		Example: `  xurl chat typing @bob`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -159,7 +170,7 @@ func chatTypingCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func chatRotateCmd(a *auth.Auth) *cobra.Command {
+func chatRotateCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rotate CONVERSATION|@USERNAME",
 		Short: "Rotate a conversation's encryption key",
@@ -171,10 +182,12 @@ access going forward when their keys were registered after the last rotation
 (they gain access to new messages only). Rotation protects future messages:
 anyone holding an earlier key version can still read the messages encrypted
 under it, and members without the old versions still cannot read old history.`,
+		Example: `  xurl chat rotate @bob
+  xurl chat rotate g123 --yes`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			yes, _ := cmd.Flags().GetBool("yes")
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -191,7 +204,7 @@ under it, and members without the old versions still cannot read old history.`,
 // chat keys
 // -----------------------------------------------------------------
 
-func createChatKeysCommand(a *auth.Auth) *cobra.Command {
+func createChatKeysCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
 	keysCmd := &cobra.Command{
 		Use:   "keys",
 		Short: "Manage XChat encryption keys",
@@ -199,18 +212,24 @@ func createChatKeysCommand(a *auth.Auth) *cobra.Command {
 
 xurl never generates or registers keys — it only fetches keys that already
 exist for the account (registered by another XChat client).`,
+		Example: `  xurl chat keys status
+  xurl chat keys restore
+  xurl chat keys import < keys.b64`,
 	}
-	keysCmd.AddCommand(chatKeysStatusCmd(a), chatKeysRestoreCmd(a), chatKeysImportCmd(a))
+	keysCmd.AddCommand(chatKeysStatusCmd(a, g), chatKeysRestoreCmd(a, g), chatKeysImportCmd(a, g))
 	return keysCmd
 }
 
-func chatKeysStatusCmd(a *auth.Auth) *cobra.Command {
+func chatKeysStatusCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show local and registered XChat key status",
-		Args:  cobra.NoArgs,
+		Long: `Show whether private keys are stored on this machine and which public keys
+are registered for the account, so a mismatch can be spotted before sending.`,
+		Example: `  xurl chat keys status`,
+		Args:    cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			exitOnError(s.keyStatus())
@@ -220,7 +239,7 @@ func chatKeysStatusCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func chatKeysRestoreCmd(a *auth.Auth) *cobra.Command {
+func chatKeysRestoreCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "restore",
 		Short: "Recover XChat keys from Juicebox onto this machine",
@@ -228,10 +247,12 @@ func chatKeysRestoreCmd(a *auth.Auth) *cobra.Command {
 PIN-protected recovery service and saves them locally (mode 600). The keys
 must have been stored in Juicebox by another XChat client; this is a
 read-only recovery — xurl never writes to Juicebox.`,
+		Example: `  xurl chat keys restore
+  xurl chat keys restore --pin 123456`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			pin, _ := cmd.Flags().GetString("pin")
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			exitOnError(s.restoreKeys(pin))
@@ -242,7 +263,7 @@ read-only recovery — xurl never writes to Juicebox.`,
 	return cmd
 }
 
-func chatKeysImportCmd(a *auth.Auth) *cobra.Command {
+func chatKeysImportCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "import [PRIVATE_KEYS_B64]",
 		Short: "Import an exported XChat private-key blob",
@@ -253,13 +274,15 @@ when not passed as an argument, so it stays out of shell history.
 The key version is detected by matching the imported identity against the
 public keys registered on the account; a blob whose keys are not registered
 is rejected.`,
+		Example: `  xurl chat keys import
+  xurl chat keys import < keys.b64`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			blob := ""
 			if len(args) == 1 {
 				blob = args[0]
 			}
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			exitOnError(s.importKeys(blob))
@@ -273,15 +296,19 @@ is rejected.`,
 // chat conversations / read / send / listen
 // -----------------------------------------------------------------
 
-func chatConversationsCmd(a *auth.Auth) *cobra.Command {
+func chatConversationsCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "conversations",
 		Short: "List your XChat inbox",
-		Args:  cobra.NoArgs,
+		Long: `List the conversations in your XChat inbox, most recent first, with their
+participants and unread state.`,
+		Example: `  xurl chat conversations
+  xurl chat conversations -n 50 --json`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			maxResults, _ := cmd.Flags().GetInt("max-results")
 			asJSON, _ := cmd.Flags().GetBool("json")
-			s, err := newChatSession(a, cmd, false)
+			s, err := newChatSession(a, cmd, false, g)
 			exitOnError(err)
 			defer s.Close()
 			exitOnError(s.listConversations(maxResults, asJSON))
@@ -293,16 +320,20 @@ func chatConversationsCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func chatReadCmd(a *auth.Auth) *cobra.Command {
+func chatReadCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "read CONVERSATION|@USERNAME",
 		Short: "Read decrypted messages from a conversation",
-		Args:  cobra.ExactArgs(1),
+		Long: `Fetch and decrypt the latest messages in a conversation, oldest first. The
+conversation is marked read afterwards unless --no-mark-read is given.`,
+		Example: `  xurl chat read @bob
+  xurl chat read g123 -n 100 --json`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			maxResults, _ := cmd.Flags().GetInt("max-results")
 			asJSON, _ := cmd.Flags().GetBool("json")
 			noMarkRead, _ := cmd.Flags().GetBool("no-mark-read")
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -317,13 +348,16 @@ func chatReadCmd(a *auth.Auth) *cobra.Command {
 	return cmd
 }
 
-func chatSendCmd(a *auth.Auth) *cobra.Command {
+func chatSendCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "send CONVERSATION|@USERNAME \"TEXT\"",
 		Short: "Send an encrypted message",
 		Long: `Encrypts and sends a message. On the first message of a 1:1
 conversation, a conversation key is generated and distributed to both
 participants automatically.`,
+		Example: `  xurl chat send @bob "hello"
+  xurl chat send @bob "see attached" -F photo.jpg
+  xurl chat send g123 "agreed" --reply-to 1234567890`,
 		Args: cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			file, _ := cmd.Flags().GetString("file")
@@ -337,7 +371,7 @@ participants automatically.`,
 			if text == "" && file == "" {
 				exitOnError(fmt.Errorf("provide message text, --file, or both"))
 			}
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -353,15 +387,19 @@ participants automatically.`,
 	return cmd
 }
 
-func chatListenCmd(a *auth.Auth) *cobra.Command {
+func chatListenCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "listen CONVERSATION|@USERNAME",
 		Short: "Print new messages as they arrive (Ctrl-C to stop)",
-		Args:  cobra.ExactArgs(1),
+		Long: `Poll a conversation and print new messages as they arrive, decrypted, until
+interrupted with Ctrl-C.`,
+		Example: `  xurl chat listen @bob
+  xurl chat listen @bob --interval 10 --no-mark-read`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			interval, _ := cmd.Flags().GetInt("interval")
 			noMarkRead, _ := cmd.Flags().GetBool("no-mark-read")
-			s, err := newChatSession(a, cmd, true)
+			s, err := newChatSession(a, cmd, true, g)
 			exitOnError(err)
 			defer s.Close()
 			convID, err := s.resolveConversation(args[0])
@@ -403,9 +441,9 @@ type chatSession struct {
 // newChatSession resolves the authenticated user and creates a chat-xdk
 // instance. When requireKeys is true the local private keys are imported and
 // the session identity is set, erroring if no keys exist yet.
-func newChatSession(a *auth.Auth, cmd *cobra.Command, requireKeys bool) (*chatSession, error) {
-	client := newClient(a)
-	opts := baseOpts(cmd)
+func newChatSession(a *auth.Auth, cmd *cobra.Command, requireKeys bool, g *globalOptions) (*chatSession, error) {
+	client := g.newClient(a)
+	opts := g.baseOpts(cmd)
 
 	userID, err := resolveMyUserID(client, opts)
 	if err != nil {
@@ -760,7 +798,7 @@ func (s *chatSession) listConversations(maxResults int, asJSON bool) error {
 		return err
 	}
 	if asJSON {
-		utils.FormatAndPrintResponse(resp)
+		s.opts.Format.Print(resp)
 		return nil
 	}
 
@@ -775,7 +813,7 @@ func (s *chatSession) listConversations(maxResults int, asJSON bool) error {
 	}
 	if err := json.Unmarshal(resp, &out); err != nil || len(out.Data) == 0 {
 		// Unexpected shape or empty inbox: show the raw response.
-		utils.FormatAndPrintResponse(resp)
+		s.opts.Format.Print(resp)
 		return nil
 	}
 
@@ -1095,7 +1133,7 @@ func (s *chatSession) readConversation(conversationID string, maxResults int, as
 		for _, e := range messages {
 			events = append(events, e.Raw())
 		}
-		utils.FormatAndPrintResponse(events)
+		s.opts.Format.Print(events)
 	} else {
 		if len(messages) == 0 {
 			fmt.Println("No messages.")
@@ -1439,7 +1477,7 @@ func (s *chatSession) sendMessage(conversationID, text string, sopts sendOptions
 		s.markReadLatest(signConvID, events)
 	}
 	if s.opts.Verbose {
-		utils.FormatAndPrintResponse(resp)
+		s.opts.Format.Print(resp)
 	}
 	return nil
 }
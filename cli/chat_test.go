@@ -92,7 +92,7 @@ func TestMatchesRegisteredKeyEncodings(t *testing.T) {
 }
 
 func TestChatCommandStructure(t *testing.T) {
-	cmd := CreateChatCommand(nil)
+	cmd := CreateChatCommand(nil, newGlobalOptions())
 	assert.Equal(t, "chat", cmd.Name())
 	assert.True(t, chatSupported)
 
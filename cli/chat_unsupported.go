@@ -17,10 +17,15 @@ const chatSupported = false
 // CreateChatCommand returns a stub on platforms where the chat-xdk crypto
 // binding is unavailable (it requires cgo and prebuilt static libraries for
 // darwin/amd64, darwin/arm64, or linux/amd64).
-func CreateChatCommand(a *auth.Auth) *cobra.Command {
+func CreateChatCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "chat",
 		Short: "Send and read end-to-end encrypted XChat messages (unavailable in this build)",
+		Long: `An end-to-end encrypted XChat client.
+
+This build does not include it: the XChat crypto library supports macOS
+(amd64/arm64) and Linux (amd64) only, and a source build also needs cgo.`,
+		Example: `  CGO_ENABLED=1 go install github.com/xdevplatform/xurl@latest`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Fprintln(os.Stderr, "xurl chat is not available in this build: the XChat crypto library supports macOS (amd64/arm64) and Linux (amd64) only.")
 			fmt.Fprintln(os.Stderr, "Release binaries for those platforms include chat; on them a source build also needs cgo: CGO_ENABLED=1 go install github.com/xdevplatform/xurl@latest")
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// Phases of a compliance job, named in complianceError.
+const (
+	phaseCreate   = "creating the compliance job"
+	phaseUpload   = "uploading the IDs file to upload_url"
+	phaseWait     = "waiting for the compliance job"
+	phaseDownload = "downloading the results from download_url"
+)
+
+// complianceError records which phase of a compliance job failed.
+type complianceError struct {
+	Phase string
+	Err   error
+}
+
+func (e *complianceError) Error() string { return e.Phase + " failed: " + e.Err.Error() }
+func (e *complianceError) Unwrap() error { return e.Err }
+
+// CreateComplianceCommand creates the `compliance` command and its
+// subcommands, which run batch compliance jobs.
+func CreateComplianceCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compliance",
+		Short: "Run batch compliance jobs",
+		Long: `Find out which of a large set of post or user IDs have since been deleted,
+suspended or otherwise changed, so stored data can be brought into compliance.
+
+A job is created, the IDs file is uploaded to the job's pre-signed upload URL,
+and once X has processed it the results (one JSON object per line) are
+downloaded from its pre-signed download URL. 'compliance create --wait' does
+all of this in one go. The endpoints use app-only auth unless --auth says
+otherwise.`,
+		Example: `  xurl compliance create --type tweets --ids-file ids.txt --wait --output results.json
+  xurl compliance list --type tweets
+  xurl compliance status 1382081613278814209`,
+	}
+	cmd.AddCommand(complianceCreateCmd(a, g), complianceListCmd(a, g), complianceStatusCmd(a, g))
+	return cmd
+}
+
+func complianceCreateCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var jobType, idsFile, name, output string
+	var wait bool
+	cmd := &cobra.Command{
+		Use:   "create --type tweets|users --ids-file FILE",
+		Short: "Create a compliance job and upload its IDs",
+		Long: `Create a compliance job for post ("tweets") or user IDs and upload the IDs
+file (one ID per line) to the job's pre-signed upload URL. That upload is a
+plain PUT without xurl's Authorization header, since the URL carries its own
+signature.
+
+Without --wait the created job is printed; check it later with 'compliance
+status'. With --wait xurl polls until the job is complete, retrying failed
+status checks like 'media upload' does, and writes the results to --output
+(standard output by default).
+
+A failure names the phase that failed: creating the job, uploading the IDs,
+waiting, or downloading the results.`,
+		Example: `  xurl compliance create --type tweets --ids-file ids.txt
+  xurl compliance create --type users --ids-file users.txt --name nightly --wait --output results.json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if jobType != "tweets" && jobType != "users" {
+				g.fprintError(os.Stderr, "Error: --type must be tweets or users")
+				os.Exit(1)
+			}
+			if output != "" && !wait {
+				g.fprintError(os.Stderr, "Error: --output needs --wait")
+				os.Exit(1)
+			}
+			opts := g.complianceOpts(cmd)
+			client := g.newClient(a)
+
+			resp, err := createComplianceJob(client, jobType, name, idsFile, opts)
+			if err != nil {
+				g.printComplianceError(err)
+			}
+			if api.IsDryRun(resp) || !wait {
+				g.format.Print(resp)
+				return
+			}
+			job, _ := api.ParseComplianceJob(resp)
+			if err := finishComplianceJob(client, job.ID, g.processingWait(cmd), opts, output, os.Stderr); err != nil {
+				g.printComplianceError(err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&jobType, "type", "", "Kind of IDs in the file: tweets or users")
+	cmd.Flags().StringVar(&idsFile, "ids-file", "", "File of IDs to check, one per line")
+	cmd.Flags().StringVar(&name, "name", "", "Name for the job")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for the job to complete and download its results")
+	cmd.Flags().StringVar(&output, "output", "", "With --wait, write the results to this file instead of standard output")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("ids-file")
+	addProcessingWaitFlags(cmd)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func complianceListCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var jobType, status string
+	cmd := &cobra.Command{
+		Use:   "list --type tweets|users",
+		Short: "List compliance jobs",
+		Long: `List the project's compliance jobs of one type, optionally only those in one
+status (created, in_progress, complete, failed or expired).`,
+		Example: `  xurl compliance list --type tweets
+  xurl compliance list --type users --status complete`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if jobType != "tweets" && jobType != "users" {
+				g.fprintError(os.Stderr, "Error: --type must be tweets or users")
+				os.Exit(1)
+			}
+			g.printResult(api.ListComplianceJobs(g.newClient(a), jobType, status, g.complianceOpts(cmd)))
+		},
+	}
+	cmd.Flags().StringVar(&jobType, "type", "", "Kind of jobs to list: tweets or users")
+	cmd.Flags().StringVar(&status, "status", "", "Only list jobs in this status")
+	cmd.MarkFlagRequired("type")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func complianceStatusCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var output string
+	var wait bool
+	cmd := &cobra.Command{
+		Use:   "status JOB_ID",
+		Short: "Show a compliance job, or wait for it and download its results",
+		Long: `Print a compliance job's status. With --wait, poll until it is complete
+(retrying failed status checks like 'media upload' does) and write the
+results to --output, or standard output.`,
+		Example: `  xurl compliance status 1382081613278814209
+  xurl compliance status 1382081613278814209 --wait --output results.json`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if output != "" && !wait {
+				g.fprintError(os.Stderr, "Error: --output needs --wait")
+				os.Exit(1)
+			}
+			opts := g.complianceOpts(cmd)
+			client := g.newClient(a)
+			if !wait {
+				g.printResult(api.GetComplianceJob(client, args[0], opts))
+				return
+			}
+			if err := finishComplianceJob(client, args[0], g.processingWait(cmd), opts, output, os.Stderr); err != nil {
+				g.printComplianceError(err)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for the job to complete and download its results")
+	cmd.Flags().StringVar(&output, "output", "", "With --wait, write the results to this file instead of standard output")
+	addProcessingWaitFlags(cmd)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// complianceOpts returns the request options of a compliance command, which
+// default to app-only auth.
+func (g *globalOptions) complianceOpts(cmd *cobra.Command) api.RequestOptions {
+	opts := g.baseOpts(cmd)
+	if opts.AuthType == "" {
+		opts.AuthType = "app"
+	}
+	return opts
+}
+
+// createComplianceJob creates a job and uploads idsFile to it, returning the
+// create response. Nothing is uploaded in a dry run.
+func createComplianceJob(client api.Client, jobType, name, idsFile string, opts api.RequestOptions) (json.RawMessage, error) {
+	if _, err := os.Stat(idsFile); err != nil {
+		return nil, &complianceError{phaseUpload, err}
+	}
+	resp, err := api.CreateComplianceJob(client, jobType, name, opts)
+	if err != nil {
+		return nil, &complianceError{phaseCreate, err}
+	}
+	if api.IsDryRun(resp) {
+		return resp, nil
+	}
+	job, err := api.ParseComplianceJob(resp)
+	if err != nil {
+		return nil, &complianceError{phaseCreate, err}
+	}
+	if err := api.UploadComplianceIDs(client, job.UploadURL, idsFile); err != nil {
+		return nil, &complianceError{phaseUpload, err}
+	}
+	return resp, nil
+}
+
+// finishComplianceJob waits for a job to complete and writes its results to
+// output, or standard output when output is empty. Status changes are
+// reported to log.
+func finishComplianceJob(client api.Client, jobID string, wait api.ProcessingWait, opts api.RequestOptions, output string, log io.Writer) error {
+	last := ""
+	final, err := api.WaitForComplianceJob(client, jobID, wait, opts, func(job api.ComplianceJob) {
+		if job.Status != last {
+			fmt.Fprintf(log, "Compliance job %s: %s\n", jobID, job.Status)
+			last = job.Status
+		}
+	})
+	if err != nil {
+		return &complianceError{phaseWait, err}
+	}
+	job, err := api.ParseComplianceJob(final)
+	if err != nil {
+		return &complianceError{phaseWait, err}
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return &complianceError{phaseDownload, err}
+		}
+		defer f.Close()
+		w = f
+	}
+	n, err := api.DownloadComplianceResults(client, job.DownloadURL, w)
+	if err != nil {
+		return &complianceError{phaseDownload, err}
+	}
+	if output != "" {
+		fmt.Fprintf(log, "Wrote %d bytes of results to %s\n", n, output)
+	}
+	return nil
+}
+
+// printComplianceError names the phase that failed, then reports the
+// underlying error like printResult, and exits.
+func (g *globalOptions) printComplianceError(err error) {
+	var ce *complianceError
+	if errors.As(err, &ce) {
+		g.fprintError(os.Stderr, "Error: %s failed", ce.Phase)
+		err = ce.Err
+	}
+	g.printResult(nil, err)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// presignedServer stands in for the storage behind a job's pre-signed URLs;
+// it answers uploads with uploadStatus and serves results unless
+// downloadStatus says otherwise.
+func presignedServer(t *testing.T, uploadStatus, downloadStatus int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			w.WriteHeader(uploadStatus)
+		case "GET":
+			w.WriteHeader(downloadStatus)
+			w.Write([]byte("{\"id\":\"1\",\"action\":\"delete\"}\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// complianceClient answers every API request with a job in status, whose
+// URLs point at storage.
+func complianceClient(storage *httptest.Server, status string, err error) fakeClient {
+	return fakeClient{sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(fmt.Sprintf(`{"data":{"id":"77","status":%q,"upload_url":%q,"download_url":%q}}`,
+			status, storage.URL+"/upload", storage.URL+"/download")), nil
+	}}
+}
+
+func TestComplianceJobPhases(t *testing.T) {
+	idsFile := filepath.Join(t.TempDir(), "ids.txt")
+	require.NoError(t, os.WriteFile(idsFile, []byte("1\n2\n"), 0600))
+	phaseOf := func(err error) string {
+		var ce *complianceError
+		require.ErrorAs(t, err, &ce)
+		return ce.Phase
+	}
+
+	t.Run("create fails", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusOK, http.StatusOK)
+		_, err := createComplianceJob(complianceClient(storage, "", errors.New("boom")), "tweets", "", idsFile, api.RequestOptions{})
+		assert.Equal(t, phaseCreate, phaseOf(err))
+	})
+
+	t.Run("upload rejected", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusForbidden, http.StatusOK)
+		_, err := createComplianceJob(complianceClient(storage, api.ComplianceCreated, nil), "tweets", "", idsFile, api.RequestOptions{})
+		assert.Equal(t, phaseUpload, phaseOf(err))
+		assert.ErrorContains(t, err, "403 Forbidden")
+	})
+
+	t.Run("missing IDs file", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusOK, http.StatusOK)
+		_, err := createComplianceJob(complianceClient(storage, api.ComplianceCreated, nil), "tweets", "", "no-such-file", api.RequestOptions{})
+		assert.Equal(t, phaseUpload, phaseOf(err))
+	})
+
+	t.Run("job failed", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusOK, http.StatusOK)
+		var log bytes.Buffer
+		err := finishComplianceJob(complianceClient(storage, api.ComplianceFailed, nil), "77", api.ProcessingWait{}, api.RequestOptions{}, "", &log)
+		assert.Equal(t, phaseWait, phaseOf(err))
+		assert.EqualError(t, err, "waiting for the compliance job failed: compliance job 77 failed")
+	})
+
+	t.Run("download fails", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusOK, http.StatusNotFound)
+		var log bytes.Buffer
+		err := finishComplianceJob(complianceClient(storage, api.ComplianceComplete, nil), "77", api.ProcessingWait{}, api.RequestOptions{}, filepath.Join(t.TempDir(), "out.json"), &log)
+		assert.Equal(t, phaseDownload, phaseOf(err))
+	})
+
+	t.Run("results written to --output", func(t *testing.T) {
+		storage := presignedServer(t, http.StatusOK, http.StatusOK)
+		client := complianceClient(storage, api.ComplianceComplete, nil)
+		_, err := createComplianceJob(client, "tweets", "", idsFile, api.RequestOptions{})
+		require.NoError(t, err)
+
+		output := filepath.Join(t.TempDir(), "out.json")
+		var log bytes.Buffer
+		require.NoError(t, finishComplianceJob(client, "77", api.ProcessingWait{}, api.RequestOptions{}, output, &log))
+		got, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, "{\"id\":\"1\",\"action\":\"delete\"}\n", string(got))
+		assert.Equal(t, "Compliance job 77: complete\nWrote 29 bytes of results to "+output+"\n", log.String())
+	})
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/config/paths"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// CreateConfigCommand creates the `config` command group.
+func CreateConfigCommand(a *auth.Auth) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect xurl's configuration",
+		Long: `Inspect xurl's configuration.
+
+Settings are read from config.yml in the config directory, from the
+environment and from a .env file in the working directory (or --env-file).`,
+		Example: `  xurl config paths`,
+	}
+	cmd.AddCommand(configPathsCmd(a))
+	return cmd
+}
+
+func configPathsCmd(a *auth.Auth) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "Print every file and directory xurl uses",
+		Long: `Print every file and directory xurl uses.
+
+xurl follows the XDG Base Directory spec: configuration lives in
+$XDG_CONFIG_HOME/xurl, tokens and keys in $XDG_DATA_HOME/xurl and caches in
+$XDG_CACHE_HOME/xurl (macOS and Windows use their native equivalents). State
+from the old ~/.xurl directory is copied over automatically on first use and a
+MOVED.txt stub is left behind; --remove-legacy deletes the old directory.`,
+		Example: `  xurl config paths
+  xurl config paths --remove-legacy`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			removeLegacy, _ := cmd.Flags().GetBool("remove-legacy")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			if !removeLegacy {
+				printPaths(os.Stdout, a.TokenStore.FilePath)
+				return
+			}
+
+			legacy, exists := store.LegacyStorePath()
+			if !exists {
+				fmt.Printf("%s does not exist; nothing to remove\n", legacy)
+				return
+			}
+			if !confirmDestructive(yes, "remove the legacy ~/.xurl directory", legacy+" and everything in it") {
+				return
+			}
+			if err := store.RemoveLegacyStore(); err != nil {
+				fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed %s\n", legacy)
+		},
+	}
+
+	cmd.Flags().Bool("remove-legacy", false, "Delete the migrated ~/.xurl directory (asks for confirmation)")
+	cmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	return cmd
+}
+
+// printPaths writes one "label  path" line per location in use, with
+// tokensPath as the token store (which --store-path can move).
+func printPaths(w io.Writer, tokensPath string) {
+	rows := [][2]string{
+		{"config dir", paths.ConfigDir()},
+		{"data dir", paths.DataDir()},
+		{"cache dir", paths.CacheDir()},
+		{"settings", config.FilePath()},
+		{"tokens", tokensPath},
+		{"chat keys", store.KeysFilePath()},
+		{"schedule", store.ScheduleFilePath()},
+		{"notices", store.NoticesFilePath()},
+		{"api spec", store.SpecFilePath()},
+		{"response cache", store.ResponseCacheDir()},
+	}
+	if abs, err := filepath.Abs(config.DotEnvFileName); err == nil {
+		if _, err := os.Stat(abs); err == nil {
+			rows = append(rows, [2]string{"env file", abs})
+		}
+	}
+	if legacy, exists := store.LegacyStorePath(); exists {
+		rows = append(rows, [2]string{"legacy dir", legacy + " (no longer used; remove with --remove-legacy)"})
+	}
+
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-11s %s\n", r[0], r[1])
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestPrintPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tempDir, "cache"))
+
+	var buf bytes.Buffer
+	printPaths(&buf, store.AuthFilePath())
+	out := buf.String()
+
+	assert.Contains(t, out, filepath.Join(tempDir, "cfg", "xurl"))
+	assert.Contains(t, out, filepath.Join(tempDir, "data", "xurl", "auth.yml"))
+	assert.Contains(t, out, filepath.Join(tempDir, "data", "xurl", "keys.yml"))
+	assert.Contains(t, out, filepath.Join(tempDir, "cfg", "xurl", "schedule.yml"))
+	assert.Contains(t, out, filepath.Join(tempDir, "cache", "xurl", "notices.yml"))
+	assert.NotContains(t, out, "legacy dir")
+}
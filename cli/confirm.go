@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// errNotConfirmed is returned by confirmAction when the user does not confirm.
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirmAction asks before a destructive action: it writes what the action
+// will affect, one item per line, to out and reads the answer from in, where
+// only "y" or "yes" confirms. When the session is not interactive it fails
+// without asking, so that scripts have to pass --yes.
+func confirmAction(in io.Reader, out io.Writer, interactive bool, action string, affected []string) error {
+	if !interactive {
+		return fmt.Errorf("refusing to %s non-interactively — pass --yes to confirm", action)
+	}
+	fmt.Fprintf(out, "This will %s:\n", action)
+	for _, item := range affected {
+		fmt.Fprintf(out, "  - %s\n", item)
+	}
+	fmt.Fprint(out, "This cannot be undone. Continue? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return errNotConfirmed
+	}
+	return nil
+}
+
+// confirmDestructive asks for confirmation with confirmAction on the terminal
+// unless yes is set, and reports whether to go ahead. A run whose standard
+// input or output is not a terminal exits with an error instead of asking.
+func confirmDestructive(yes bool, action string, affected ...string) bool {
+	if yes {
+		return true
+	}
+	err := confirmAction(os.Stdin, os.Stderr, isTerminal(os.Stdin) && isTerminal(os.Stdout), action, affected)
+	if errors.Is(err, errNotConfirmed) {
+		fmt.Fprintln(os.Stderr, "Aborted")
+		return false
+	}
+	if err != nil {
+		fprintError(os.Stderr, "Error: %v", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// addYesFlag adds the --yes (-y) flag that skips confirmDestructive.
+func addYesFlag(cmd *cobra.Command, yes *bool) {
+	cmd.Flags().BoolVarP(yes, "yes", "y", false, "Do not ask for confirmation")
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestConfirmAction(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   error
+	}{
+		{"y\n", nil},
+		{"YES\n", nil},
+		{"n\n", errNotConfirmed},
+		{"\n", errNotConfirmed},
+		{"", errNotConfirmed},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := confirmAction(strings.NewReader(tt.answer), &out, true, "delete a post", []string{"post 123"})
+		assert.Equal(t, tt.want, err, "answer %q", tt.answer)
+		assert.Equal(t, "This will delete a post:\n  - post 123\nThis cannot be undone. Continue? [y/N] ", out.String())
+	}
+}
+
+func TestConfirmActionNonInteractive(t *testing.T) {
+	var out bytes.Buffer
+	err := confirmAction(strings.NewReader("y\n"), &out, false, "clear all tokens", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pass --yes")
+	assert.Empty(t, out.String(), "nothing is asked without a terminal")
+}
+
+func TestStoredCredentials(t *testing.T) {
+	app := &store.App{
+		OAuth2Tokens: map[string]store.Token{"bob": {}, "alice": {}},
+		BearerToken:  &store.Token{},
+	}
+	assert.Equal(t, []string{"OAuth2 token of @alice", "OAuth2 token of @bob", "app-only (bearer) token"}, storedCredentials(app))
+	assert.Equal(t, []string{"no stored credentials"}, storedCredentials(&store.App{}))
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/utils"
+)
+
+// Output formats of `xurl diff`.
+const (
+	diffFormatJSON = "json"
+	diffFormatText = "text"
+)
+
+// diffResult is what `xurl diff` prints.
+type diffResult struct {
+	Equal       bool               `json:"equal"`
+	Differences []utils.Difference `json:"differences"`
+}
+
+// CreateDiffCommand creates the `diff` command, which compares two responses.
+func CreateDiffCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var ignore []string
+	var files bool
+	var arrays, arrayKey, format string
+
+	cmd := &cobra.Command{
+		Use:   "diff SOURCE_A SOURCE_B",
+		Short: "Compare two responses field by field",
+		Long: `Fetch two endpoints (or URLs) with GET and print the differences between
+their JSON responses, for comparing staging against production or spotting
+response drift. A source written as @FILE is read from a saved response
+instead, such as a baseline captured earlier with 'xurl ENDPOINT > FILE', and
+- reads standard input. With --files, both sources are files, which suits
+process substitution: xurl diff --files <(xurl ...) <(xurl ...).
+
+Key order and whitespace do not matter; numbers compare exactly as written.
+Each difference has a jq-style path, a kind (added, removed or changed) and
+the old and/or new value. --ignore skips a field, at any depth, for values
+that change on every request.
+
+--arrays picks how array elements are paired up:
+  index      the elements at the same position (the default)
+  key        objects with the same --array-key field (id by default), as in
+             .data[id="20"]; an array whose elements do not all have a
+             distinct string or number key is compared by index
+  unordered  equal elements wherever they are, so only elements missing
+             from one side are reported
+
+--format text prints one colored line per difference instead of JSON:
+'+ path: new', '- path: old' or '~ path: old -> new'.
+
+Exits 0 when the responses are equal and 1 when they differ, like diff(1).`,
+		Example: `  xurl diff https://api.staging.example/2/users/me https://api.x.com/2/users/me
+  xurl /2/tweets/20 > baseline.json
+  xurl diff @baseline.json /2/tweets/20 --ignore created_at,public_metrics
+  xurl diff --files --format text <(xurl /2/users/me) <(xurl -u bob /2/users/me)
+  xurl /2/users/me/followers | xurl diff @followers.json - --arrays key`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			switch arrays {
+			case utils.ArraysByIndex, utils.ArraysByKey, utils.ArraysUnordered:
+			default:
+				g.fprintError(os.Stderr, "Error: --arrays must be index, key or unordered")
+				os.Exit(1)
+			}
+			if format != diffFormatJSON && format != diffFormatText {
+				g.fprintError(os.Stderr, "Error: --format must be json or text")
+				os.Exit(1)
+			}
+			if args[0] == "-" && args[1] == "-" {
+				g.fprintError(os.Stderr, "Error: only one source can be standard input")
+				os.Exit(1)
+			}
+
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			var docs [2]any
+			for i, source := range args {
+				if files && source != "-" && !strings.HasPrefix(source, "@") {
+					source = "@" + source
+				}
+				raw, err := loadDiffSource(client, source, opts, os.Stdin)
+				if err != nil {
+					g.printResult(nil, err)
+				}
+				if docs[i], err = utils.DecodeJSON(raw); err != nil {
+					g.fprintError(os.Stderr, "Error: %s is not valid JSON: %v", args[i], err)
+					os.Exit(1)
+				}
+			}
+
+			ignored := map[string]bool{}
+			for _, f := range ignore {
+				ignored[strings.TrimSpace(f)] = true
+			}
+			diffs := utils.JSONDiff(docs[0], docs[1], utils.DiffOptions{Ignore: ignored, Arrays: arrays, ArrayKey: arrayKey})
+			if diffs == nil {
+				diffs = []utils.Difference{}
+			}
+			if format == diffFormatText {
+				utils.PrintDifferences(color.Output, diffs)
+			} else {
+				g.format.Print(diffResult{Equal: len(diffs) == 0, Differences: diffs})
+			}
+			if len(diffs) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringSliceVar(&ignore, "ignore", nil, "Field to ignore at any depth (repeatable, or comma-separated)")
+	cmd.Flags().BoolVar(&files, "files", false, "Read both sources from files (- for stdin) instead of fetching them")
+	cmd.Flags().StringVar(&arrays, "arrays", utils.ArraysByIndex, "How to pair array elements: index, key or unordered")
+	cmd.Flags().StringVar(&arrayKey, "array-key", utils.DefaultArrayKey, "Field that pairs array objects with --arrays key")
+	cmd.Flags().StringVar(&format, "format", diffFormatJSON, "Output format: json or text")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// loadDiffSource returns the JSON for one side of a diff: stdin for "-", the
+// contents of FILE for "@FILE", otherwise the response to a GET of source.
+func loadDiffSource(client api.Client, source string, opts api.RequestOptions, stdin io.Reader) (json.RawMessage, error) {
+	if source == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not read standard input: %v", err)
+		}
+		return data, nil
+	}
+	if path, ok := strings.CutPrefix(source, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", path, err)
+		}
+		return data, nil
+	}
+	opts.Method = "GET"
+	opts.Endpoint = source
+	return client.SendRequest(opts)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestLoadDiffSource(t *testing.T) {
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			assert.Equal(t, "GET", options.Method)
+			assert.Equal(t, "/2/users/me", options.Endpoint)
+			return json.RawMessage(`{"data":{"id":"1"}}`), nil
+		},
+	}
+
+	resp, err := loadDiffSource(client, "/2/users/me", api.RequestOptions{}, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"1"}}`, string(resp))
+
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(baseline, []byte(`{"data":{"id":"2"}}`), 0600))
+	resp, err = loadDiffSource(client, "@"+baseline, api.RequestOptions{}, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"2"}}`, string(resp), "@FILE is read, not fetched")
+
+	_, err = loadDiffSource(client, "@"+baseline+".missing", api.RequestOptions{}, nil)
+	assert.Error(t, err)
+
+	resp, err = loadDiffSource(client, "-", api.RequestOptions{}, strings.NewReader(`{"data":{"id":"3"}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"3"}}`, string(resp), "- reads stdin")
+}
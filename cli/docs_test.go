@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+)
+
+func newDocsTestRoot() *cobra.Command {
+	cfg := &config.Config{}
+	return CreateRootCommand(cfg, auth.NewAuth(cfg))
+}
+
+// docsFileNames returns the file name cobra/doc gives each available command,
+// e.g. "xurl-auth-oauth2.1" or "xurl_auth_oauth2.md".
+func docsFileNames(root *cobra.Command, sep, ext string) []string {
+	var names []string
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if !c.IsAvailableCommand() && c != root {
+			return
+		}
+		names = append(names, strings.ReplaceAll(c.CommandPath(), " ", sep)+ext)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+	return names
+}
+
+func TestGenerateDocs(t *testing.T) {
+	cases := []struct {
+		format, sep, ext string
+	}{
+		{docsFormatMan, "-", ".1"},
+		{docsFormatMarkdown, "_", ".md"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			root := newDocsTestRoot()
+			dir := filepath.Join(t.TempDir(), "out")
+			require.NoError(t, generateDocs(root, tc.format, dir))
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+
+			assert.ElementsMatch(t, docsFileNames(root, tc.sep, tc.ext), got)
+			assert.Contains(t, got, "xurl"+tc.sep+"auth"+tc.sep+"oauth2"+tc.ext)
+			assert.NotContains(t, got, "xurl"+tc.sep+"docs"+tc.ext, "hidden commands must not be documented")
+		})
+	}
+}
+
+func TestGenerateDocsUnknownFormat(t *testing.T) {
+	err := generateDocs(newDocsTestRoot(), "html", t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown format")
+}
+
+// Every documented command should render a complete page: a long description,
+// at least one example, and usage text for each of its flags.
+func TestCommandsHaveHelpText(t *testing.T) {
+	root := newDocsTestRoot()
+	root.InitDefaultCompletionCmd()
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c != root && (!c.IsAvailableCommand() || c.Name() == "completion") {
+			return
+		}
+		assert.NotEmpty(t, c.Long, "%q has no Long description", c.CommandPath())
+		assert.NotEmpty(t, c.Example, "%q has no Example", c.CommandPath())
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			assert.NotEmpty(t, f.Usage, "%q flag --%s has no usage text", c.CommandPath(), f.Name)
+		})
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
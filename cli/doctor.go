@@ -0,0 +1,434 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/http/httpproxy"
+	"gopkg.in/yaml.v3"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/store"
+	"github.com/xdevplatform/xurl/version"
+)
+
+// Result of a single doctor check.
+const (
+	doctorPass = "pass"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+const (
+	// defaultAPIHost is always probed, in addition to a custom API_BASE_URL.
+	defaultAPIHost = "https://api.x.com"
+	// maxClockSkew is the skew beyond which OAuth1 signatures are rejected.
+	maxClockSkew   = xurlErrors.MaxClockSkew
+	warnClockSkew  = 30 * time.Second
+	slowAPILatency = 2 * time.Second
+)
+
+// latestReleaseURL is queried for the newest published xurl version.
+var latestReleaseURL = "https://api.github.com/repos/xdevplatform/xurl/releases/latest"
+
+// doctorCheck is one line of `xurl doctor` output.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// CreateDoctorCommand creates the `doctor` command, which diagnoses common
+// configuration problems.
+func CreateDoctorCommand(a *auth.Auth, cfg *config.Config, g *globalOptions) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose common configuration problems",
+		Long: `Run a series of checks and print a pass/warn/fail line for each:
+
+  token store     auth.yml is readable, parses, and is not readable by others
+  credentials     CLIENT_ID / CLIENT_SECRET / REDIRECT_URI are present and agree
+                  with the active app
+  reachability    api.x.com (and a custom API_BASE_URL) answer, and how fast
+  clock skew      local time versus the API's Date header (OAuth1 signatures
+                  are rejected beyond 5 minutes)
+  callback port   the redirect URI's local port is free for 'xurl auth oauth2'
+  proxy           HTTP(S)_PROXY / NO_PROXY settings that apply to the API
+  version         whether a newer xurl release is available
+
+Exits non-zero if any check fails.`,
+		Example: `  xurl doctor
+  xurl doctor --app prod
+  xurl doctor --json | jq '.checks[] | select(.status != "pass")'`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			checks := runDoctorChecks(a, cfg, g)
+			if asJSON {
+				out, _ := json.MarshalIndent(struct {
+					OK     bool          `json:"ok"`
+					Checks []doctorCheck `json:"checks"`
+				}{doctorOK(checks), checks}, "", "  ")
+				fmt.Println(string(out))
+			} else {
+				printDoctorChecks(os.Stdout, checks)
+			}
+			if !doctorOK(checks) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the results as JSON")
+	return cmd
+}
+
+// runDoctorChecks runs every check in display order.
+func runDoctorChecks(a *auth.Auth, cfg *config.Config, g *globalOptions) []doctorCheck {
+	app := a.TokenStore.ResolveApp(a.AppName())
+	redirectURI, _, _ := config.ResolveRedirectURI(a.TokenStore.FilePath, a.AppName())
+	client := &http.Client{Transport: g.transport, Timeout: 10 * time.Second}
+
+	checks := []doctorCheck{
+		checkTokenStore(a.TokenStore.FilePath),
+		checkCredentials(cfg, app),
+		checkRedirectURI(redirectURI),
+	}
+
+	bases := []string{defaultAPIHost}
+	if base := strings.TrimRight(cfg.APIBaseURL, "/"); base != "" && base != defaultAPIHost {
+		bases = append(bases, base)
+	}
+	var apiDate string
+	for _, base := range bases {
+		check, date := checkReachability(client, base)
+		checks = append(checks, check)
+		if apiDate == "" {
+			apiDate = date
+		}
+	}
+	checks = append(checks,
+		checkClockSkew(apiDate, time.Now()),
+		checkCallbackPort(redirectURI),
+		checkProxy(bases[len(bases)-1], os.Getenv),
+		checkVersion(client, version.Version),
+	)
+	return checks
+}
+
+func doctorOK(checks []doctorCheck) bool {
+	for _, c := range checks {
+		if c.Status == doctorFail {
+			return false
+		}
+	}
+	return true
+}
+
+func printDoctorChecks(w io.Writer, checks []doctorCheck) {
+	labels := map[string]string{
+		doctorPass: color.GreenString("PASS"),
+		doctorWarn: color.YellowString("WARN"),
+		doctorFail: color.RedString("FAIL"),
+	}
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s  %-16s %s\n", labels[c.Status], c.Name, c.Message)
+	}
+}
+
+// ─── individual checks ──────────────────────────────────────────────
+
+func checkTokenStore(path string) doctorCheck {
+	c := doctorCheck{Name: "token store"}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s does not exist yet — register an app with 'xurl auth apps add'", path)
+		return c
+	}
+	if err != nil {
+		c.Status, c.Message = doctorFail, err.Error()
+		return c
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.Status, c.Message = doctorFail, fmt.Sprintf("%s is not readable: %v", path, err)
+		return c
+	}
+	var parsed struct {
+		Apps       map[string]any `yaml:"apps"`
+		DefaultApp string         `yaml:"default_app"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		c.Status, c.Message = doctorFail, fmt.Sprintf("%s is not valid YAML: %v", path, err)
+		return c
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s is accessible by other users (mode %04o) — run: chmod 600 %s", path, info.Mode().Perm(), path)
+		return c
+	}
+	if parsed.DefaultApp != "" && parsed.Apps[parsed.DefaultApp] == nil {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("default app %q is not registered in %s", parsed.DefaultApp, path)
+		return c
+	}
+	c.Status, c.Message = doctorPass, fmt.Sprintf("%s (%d app(s))", path, len(parsed.Apps))
+	return c
+}
+
+// checkCredentials reports on the OAuth2 client credentials, which come from
+// CLIENT_ID/CLIENT_SECRET when set and from the active app otherwise.
+func checkCredentials(cfg *config.Config, app *store.App) doctorCheck {
+	c := doctorCheck{Name: "credentials"}
+	id, secret := cfg.ClientID, cfg.ClientSecret
+	if app != nil {
+		if id == "" {
+			id = app.ClientID
+		}
+		if secret == "" {
+			secret = app.ClientSecret
+		}
+	}
+
+	switch {
+	case id == "" && secret == "":
+		c.Status, c.Message = doctorWarn, "no OAuth2 client ID/secret (set CLIENT_ID and CLIENT_SECRET, or 'xurl auth apps add'); only app-only and OAuth1 auth will work"
+	case id == "":
+		c.Status, c.Message = doctorFail, "CLIENT_SECRET is set but there is no client ID"
+	case secret == "":
+		c.Status, c.Message = doctorFail, "a client ID is set but there is no CLIENT_SECRET"
+	case app != nil && cfg.ClientID != "" && app.ClientID != "" && cfg.ClientID != app.ClientID:
+		c.Status, c.Message = doctorWarn, "CLIENT_ID from the environment differs from the active app's stored client ID"
+	default:
+		c.Status, c.Message = doctorPass, fmt.Sprintf("client ID %s…", truncate(id, 8))
+	}
+	return c
+}
+
+func checkRedirectURI(redirectURI string) doctorCheck {
+	c := doctorCheck{Name: "redirect uri"}
+	u, err := url.Parse(redirectURI)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.Status, c.Message = doctorFail, fmt.Sprintf("%q is not an absolute http(s) URL", redirectURI)
+		return c
+	}
+	if !isLoopbackHost(u.Hostname()) {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s is not a localhost address, so 'xurl auth oauth2' cannot receive the callback (use --headless)", redirectURI)
+		return c
+	}
+	c.Status, c.Message = doctorPass, redirectURI
+	return c
+}
+
+// checkReachability probes base and returns the response's Date header for
+// the clock-skew check. Any HTTP response counts as reachable.
+func checkReachability(client *http.Client, base string) (doctorCheck, string) {
+	c := doctorCheck{Name: "reachability"}
+	start := time.Now()
+	resp, err := client.Get(base)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		c.Status, c.Message = doctorFail, fmt.Sprintf("%s: %v", base, err)
+		return c, ""
+	}
+	resp.Body.Close()
+
+	c.Status, c.Message = doctorPass, fmt.Sprintf("%s answered in %s", base, elapsed)
+	if elapsed > slowAPILatency {
+		c.Status = doctorWarn
+		c.Message += " (slow)"
+	}
+	return c, resp.Header.Get("Date")
+}
+
+func checkClockSkew(dateHeader string, now time.Time) doctorCheck {
+	c := doctorCheck{Name: "clock skew"}
+	if dateHeader == "" {
+		c.Status, c.Message = doctorWarn, "could not check: the API did not answer with a Date header"
+		return c
+	}
+	serverTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("could not check: unparseable Date header %q", dateHeader)
+		return c
+	}
+	skew := now.Sub(serverTime).Round(time.Second)
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	switch {
+	case abs > maxClockSkew:
+		c.Status, c.Message = doctorFail, fmt.Sprintf("local clock is %s %s the API; OAuth1 requests will be rejected — sync your clock", abs, direction)
+	case abs > warnClockSkew:
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("local clock is %s %s the API", abs, direction)
+	default:
+		c.Status, c.Message = doctorPass, abs.String()
+	}
+	return c
+}
+
+// checkCallbackPort tries to bind the redirect URI's local port, as the
+// OAuth2 login flow will.
+func checkCallbackPort(redirectURI string) doctorCheck {
+	c := doctorCheck{Name: "callback port"}
+	u, err := url.Parse(redirectURI)
+	if err != nil || !isLoopbackHost(u.Hostname()) {
+		c.Status, c.Message = doctorWarn, "skipped: the redirect URI is not a localhost address"
+		return c
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	addr := net.JoinHostPort("127.0.0.1", port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s is in use; 'xurl auth oauth2' will fail until it is free: %v", addr, err)
+		return c
+	}
+	ln.Close()
+	c.Status, c.Message = doctorPass, fmt.Sprintf("%s is free", addr)
+	return c
+}
+
+// checkProxy lists proxy variables from getenv and whether they apply to base.
+func checkProxy(base string, getenv func(string) string) doctorCheck {
+	c := doctorCheck{Name: "proxy", Status: doctorPass}
+	env := func(name string) string {
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return getenv(strings.ToLower(name))
+	}
+
+	var set []string
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		v := env(name)
+		if v == "" {
+			continue
+		}
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			v = u.Redacted()
+		}
+		set = append(set, name+"="+v)
+	}
+	if len(set) == 0 {
+		c.Message = "no proxy configured"
+		if env("ALL_PROXY") != "" {
+			c.Status, c.Message = doctorWarn, "ALL_PROXY is set but xurl ignores it; set HTTPS_PROXY instead"
+		}
+		return c
+	}
+
+	// Resolve with the same rules net/http applies to the environment.
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  env("HTTP_PROXY"),
+		HTTPSProxy: env("HTTPS_PROXY"),
+		NoProxy:    env("NO_PROXY"),
+	}).ProxyFunc()
+	target, err := url.Parse(base)
+	if err != nil {
+		c.Status, c.Message = doctorFail, fmt.Sprintf("invalid API base URL %q: %v", base, err)
+		return c
+	}
+	proxyURL, err := proxyFunc(target)
+	switch {
+	case err != nil:
+		c.Status, c.Message = doctorFail, fmt.Sprintf("invalid proxy setting (%s): %v", strings.Join(set, ", "), err)
+	case proxyURL == nil:
+		c.Message = fmt.Sprintf("%s (not used for %s)", strings.Join(set, ", "), base)
+	default:
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("requests to %s go through %s (%s)", base, proxyURL.Redacted(), strings.Join(set, ", "))
+	}
+	return c
+}
+
+// checkVersion compares current with the latest published release.
+func checkVersion(client *http.Client, current string) doctorCheck {
+	c := doctorCheck{Name: "version"}
+	if _, ok := parseVersion(current); !ok {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s is a development build; cannot check for updates", current)
+		return c
+	}
+	resp, err := client.Get(latestReleaseURL)
+	if err != nil {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s (could not check for updates: %v)", current, err)
+		return c
+	}
+	defer resp.Body.Close()
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&release) != nil || release.TagName == "" {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s (could not check for updates: HTTP %d)", current, resp.StatusCode)
+		return c
+	}
+	if newerVersion(release.TagName, current) {
+		c.Status, c.Message = doctorWarn, fmt.Sprintf("%s is out of date; %s is available", current, release.TagName)
+		return c
+	}
+	c.Status, c.Message = doctorPass, fmt.Sprintf("%s is the latest release", current)
+	return c
+}
+
+// ─── helpers ────────────────────────────────────────────────────────
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parseVersion parses "v1.2.3" (a leading v and any -suffix are optional)
+// into its numeric parts.
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+// newerVersion reports whether latest is a higher version than current.
+func newerVersion(latest, current string) bool {
+	l, ok1 := parseVersion(latest)
+	c, ok2 := parseVersion(current)
+	if !ok1 || !ok2 {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
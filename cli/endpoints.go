@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// CreateEndpointsCommand creates the endpoints command, which prints the
+// built-in endpoint catalog.
+func CreateEndpointsCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "endpoints",
+		Short: "List the X API endpoints xurl knows about",
+		Long: `Print the built-in endpoint catalog grouped by category: the method, the
+path, whether xurl streams the response, and the OAuth2 scopes a user-context
+request needs. Endpoints without scopes take app-only (bearer token)
+authentication. A "rich" mark means --rich adds default fields to the request.
+
+--json prints the catalog as a JSON array instead.`,
+		Example: `  xurl endpoints
+  xurl endpoints --json | jq -r '.[] | select(.streaming) | .path'`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if asJSON {
+				err = printEndpointsJSON(os.Stdout, api.Catalog())
+			} else {
+				err = printEndpoints(os.Stdout, api.Catalog())
+			}
+			if err != nil {
+				fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the catalog as a JSON array")
+	return cmd
+}
+
+// printEndpoints writes endpoints as one table per category.
+func printEndpoints(w io.Writer, endpoints []api.Endpoint) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	category := ""
+	for _, e := range endpoints {
+		if e.Category != category {
+			if category != "" {
+				fmt.Fprintln(tw)
+			}
+			category = e.Category
+			fmt.Fprintf(tw, "%s:\n", category)
+		}
+		var marks []string
+		if e.Streaming {
+			marks = append(marks, "stream")
+		}
+		if e.Rich {
+			marks = append(marks, "rich")
+		}
+		scopes := "app-only"
+		if len(e.Scopes) > 0 {
+			scopes = strings.Join(e.Scopes, " ")
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", e.Method, e.Path, strings.Join(marks, ","), scopes, e.Summary)
+	}
+	return tw.Flush()
+}
+
+// printEndpointsJSON writes endpoints as an indented JSON array.
+func printEndpointsJSON(w io.Writer, endpoints []api.Endpoint) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(endpoints)
+}
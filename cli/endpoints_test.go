@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestPrintEndpoints(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printEndpoints(&buf, api.Catalog()))
+	out := buf.String()
+
+	assert.Contains(t, out, "Posts:\n")
+	assert.Contains(t, out, "Streams:\n")
+	assert.Regexp(t, `POST\s+/2/tweets\s+tweet\.read tweet\.write users\.read\s+Create a post`, out)
+	assert.Regexp(t, `GET\s+/2/tweets/search/stream\s+stream,rich\s+app-only`, out)
+	assert.Regexp(t, `GET\s+/2/users/:id/bookmarks\s+rich\s+tweet\.read users\.read bookmark\.read`, out)
+}
+
+func TestPrintEndpointsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printEndpointsJSON(&buf, api.Catalog()))
+
+	var endpoints []api.Endpoint
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &endpoints))
+	byRoute := map[string]api.Endpoint{}
+	for _, e := range endpoints {
+		byRoute[e.Method+" "+e.Path] = e
+	}
+
+	stream, ok := byRoute["GET /2/tweets/sample/stream"]
+	require.True(t, ok)
+	assert.True(t, stream.Streaming)
+	assert.Empty(t, stream.Scopes)
+
+	follow, ok := byRoute["POST /2/users/:id/following"]
+	require.True(t, ok)
+	assert.Equal(t, "Follows", follow.Category)
+	assert.Contains(t, follow.Scopes, "follows.write")
+	assert.Contains(t, buf.String(), `"scopes": []`, "app-only endpoints list no scopes rather than null")
+}
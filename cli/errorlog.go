@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// logRecord is a line of the --log file, and a failure as --error-format json
+// prints it. It holds either the notices of one response, with the endpoint
+// that sent them, or the error a command failed with, with the request ID
+// once a request was sent and every notice received before the failure.
+type logRecord struct {
+	Time      time.Time    `json:"time"`
+	Endpoint  string       `json:"endpoint,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Notices   []api.Notice `json:"notices,omitempty"`
+}
+
+// recordNotices is the notice hook configureClient installs. It keeps a
+// response's notices for the error record of a later failure and appends
+// them to --log, whether or not --no-warnings keeps them off stderr.
+func (g *globalOptions) recordNotices(endpoint string, notices []api.Notice) {
+	g.logMu.Lock()
+	defer g.logMu.Unlock()
+	g.notices = append(g.notices, notices...)
+	g.writeLog(logRecord{Time: time.Now(), Endpoint: endpoint, Notices: notices})
+}
+
+// logError appends a failure to --log and returns its record. msg is the
+// message fprintError prints, without its "Error: " prefix in the record.
+func (g *globalOptions) logError(msg string) logRecord {
+	g.logMu.Lock()
+	defer g.logMu.Unlock()
+	record := logRecord{Time: time.Now(), Error: strings.TrimPrefix(msg, "Error: ")}
+	if g.requestSent.Load() {
+		record.RequestID = g.requestID
+	}
+	record.Notices = append([]api.Notice(nil), g.notices...)
+	g.writeLog(record)
+	return record
+}
+
+// writeLog appends record to --log as a JSON line; g.logMu must be held.
+func (g *globalOptions) writeLog(record logRecord) {
+	if g.log == nil {
+		return
+	}
+	line, _ := json.Marshal(record)
+	fmt.Fprintf(g.log, "%s\n", line)
+}
+
+// fprintError is fprintError for commands that send requests. Once the run
+// has sent one, the request ID follows (see reportRequestID), and then the
+// --explain-rate-limit report. The error is also appended to --log, and with
+// --error-format json it is printed as its JSON record instead.
+func (g *globalOptions) fprintError(w *os.File, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	record := g.logError(msg)
+	if g.jsonErrors {
+		if record.RequestID != "" {
+			g.requestIDReported.Store(true)
+		}
+		line, _ := json.Marshal(record)
+		fmt.Fprintf(w, "%s\n", line)
+	} else {
+		fprintError(w, "%s", msg)
+		g.reportRequestID(w)
+	}
+	g.reportRateLimits(w)
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestLogRecordsNoticesAndErrors(t *testing.T) {
+	g := newGlobalOptions()
+	var log bytes.Buffer
+	g.log = &log
+	g.requestID = "run-123"
+	sunset := api.Notice{Kind: "sunset", Message: "endpoint will be removed on 2026-12-01"}
+
+	g.recordNotices("GET /2/old", []api.Notice{sunset})
+	g.logError("Error: boom")
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 2)
+	var notice, failure logRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &notice))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &failure))
+	assert.Equal(t, "GET /2/old", notice.Endpoint)
+	assert.Equal(t, []api.Notice{sunset}, notice.Notices)
+	assert.Equal(t, "boom", failure.Error)
+	assert.Empty(t, failure.RequestID, "no request was sent")
+	assert.Equal(t, []api.Notice{sunset}, failure.Notices, "notices received before the failure")
+}
+
+func TestFprintErrorJSON(t *testing.T) {
+	g := newGlobalOptions()
+	g.jsonErrors = true
+	g.requestID = "run-123"
+	g.markRequestSent(nil)
+	g.recordNotices("GET /2/old", []api.Notice{{Kind: "warning", Message: "use /2/new"}})
+
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	defer stderr.Close()
+	g.fprintError(stderr, "Error: %s", "request failed")
+	g.reportRequestID(stderr)
+
+	out, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(t, lines, 1, "the request ID is only in the record")
+	var record logRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "request failed", record.Error)
+	assert.Equal(t, "run-123", record.RequestID)
+	assert.Equal(t, []api.Notice{{Kind: "warning", Message: "use /2/new"}}, record.Notices)
+}
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// Values of `xurl init --auth-type` and `--store`.
+const (
+	initAuthOAuth2 = "oauth2"
+	initAuthApp    = "app"
+	initStoreFile  = "config"
+	initStoreEnv   = "env"
+)
+
+// initOptions holds the answers `xurl init` was given as flags. An empty
+// value (or an unset bool) is asked for when the wizard is interactive.
+type initOptions struct {
+	authType     string
+	clientID     string
+	clientSecret string
+	bearerToken  string
+	redirectURI  string
+	storeIn      string
+	login        bool
+	loginSet     bool
+	skipVerify   bool
+}
+
+// initWizard asks the questions of `xurl init`. When it is not interactive
+// it never reads in: flags and defaults answer instead, and a question
+// without either is an error naming the flag to pass.
+type initWizard struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+	// readSecret reads a secret without echoing it; nil reads a line from
+	// in.
+	readSecret func() (string, error)
+	// exports receives the export lines of --store env.
+	exports io.Writer
+	// login runs the OAuth2 flow, and verify makes one call with the new
+	// credential, reporting whom it authenticated.
+	login  func(a *auth.Auth) error
+	verify func(a *auth.Auth, authType string) (string, error)
+}
+
+// CreateInitCommand creates the `init` command, a first-run setup wizard.
+func CreateInitCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var opts initOptions
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up xurl step by step",
+		Long: `Walk through setting up xurl for an X API app:
+
+  1. pick the kind of access: oauth2 (act as a user) or app (app-only,
+     read-only Bearer Token)
+  2. enter the app's client ID and secret, or its Bearer Token
+  3. for oauth2, choose the redirect URI, which must also be registered as a
+     callback URL in the developer portal; its local port is checked
+  4. store the credentials in the token store (--app, or the default app),
+     or print them as environment variable exports
+  5. for oauth2, optionally log in with 'xurl auth oauth2'
+  6. verify the setup with one call: GET /2/users/me for oauth2, the same
+     call as 'xurl auth test --auth app' for app-only
+
+Every question has a flag. Flags that are given are not asked, and when
+standard input is not a terminal nothing is asked at all: missing answers
+take their defaults, and a missing credential is an error. App-only tokens
+have no environment variable, so they are always stored.`,
+		Example: `  xurl init
+  xurl init --auth-type oauth2 --client-id abc --client-secret xyz --login
+  xurl init --auth-type oauth2 --client-id abc --client-secret xyz --store env >> .env
+  xurl init --app reader --auth-type app --bearer-token AAAA...`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.loginSet = cmd.Flags().Changed("login")
+			w := &initWizard{
+				in:          bufio.NewReader(os.Stdin),
+				out:         os.Stderr,
+				interactive: isTerminal(os.Stdin) && isTerminal(os.Stderr),
+				readSecret: func() (string, error) {
+					secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+					fmt.Fprintln(os.Stderr)
+					return string(secret), err
+				},
+				exports: os.Stdout,
+				login: func(a *auth.Auth) error {
+					_, err := a.OAuth2Flow("")
+					return err
+				},
+				verify: func(a *auth.Auth, authType string) (string, error) {
+					a.WithStrictAuth(true)
+					return runAuthTest(g.newClient(a), authType, "")
+				},
+			}
+			if err := runInit(w, a, opts); err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.authType, "auth-type", "", "Kind of access to set up: oauth2 (act as a user) or app (app-only Bearer Token)")
+	cmd.Flags().StringVar(&opts.clientID, "client-id", "", "OAuth2 client ID of the app")
+	cmd.Flags().StringVar(&opts.clientSecret, "client-secret", "", "OAuth2 client secret of the app")
+	cmd.Flags().StringVar(&opts.bearerToken, "bearer-token", "", "App-only Bearer Token of the app")
+	cmd.Flags().StringVar(&opts.redirectURI, "redirect-uri", "", "OAuth2 redirect URI (default: the app's, or "+config.DefaultRedirectURI+")")
+	cmd.Flags().StringVar(&opts.storeIn, "store", "", "Where to keep the OAuth2 credentials: config (the token store) or env (print export lines)")
+	cmd.Flags().BoolVar(&opts.login, "login", false, "Log in with OAuth2 at the end (asked when interactive)")
+	cmd.Flags().BoolVar(&opts.skipVerify, "skip-verify", false, "Do not make the verification call at the end")
+	return cmd
+}
+
+// runInit runs the setup wizard for the app a is bound to.
+func runInit(w *initWizard, a *auth.Auth, opts initOptions) error {
+	appName := a.TokenStore.GetActiveAppName(a.AppName())
+	if appName == "" {
+		appName = "default"
+	}
+	app := a.TokenStore.GetApp(appName)
+	if app == nil {
+		app = &store.App{}
+	}
+	fmt.Fprintf(w.out, "Setting up xurl for app %q (token store: %s).\n\n", appName, a.TokenStore.FilePath)
+
+	authType, err := w.choose("Kind of access: oauth2 acts as a user (post, like, DMs), app is app-only and read-only", opts.authType, "auth-type", initAuthOAuth2, initAuthApp)
+	if err != nil {
+		return err
+	}
+
+	if authType == initAuthApp {
+		token, err := w.askSecret("Bearer Token", opts.bearerToken, app.BearerToken != nil, "bearer-token")
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			fmt.Fprintf(w.out, "Keeping the Bearer Token of app %q.\n", appName)
+		} else {
+			if err := ensureInitApp(a.TokenStore, appName); err != nil {
+				return err
+			}
+			if err := a.TokenStore.SaveBearerTokenForApp(appName, token); err != nil {
+				return err
+			}
+			fmt.Fprintf(w.out, "Saved the Bearer Token to app %q.\n", appName)
+		}
+		return w.finish(a, opts, initAuthApp)
+	}
+
+	clientID, err := w.ask("Client ID", opts.clientID, app.ClientID, "client-id")
+	if err != nil {
+		return err
+	}
+	clientSecret, err := w.askSecret("Client secret", opts.clientSecret, app.ClientSecret != "", "client-secret")
+	if err != nil {
+		return err
+	}
+	if clientSecret == "" {
+		clientSecret = app.ClientSecret
+	}
+
+	fmt.Fprintln(w.out, "\nThe redirect URI must be registered exactly as a callback URL in your app's")
+	fmt.Fprintln(w.out, "authentication settings in the developer portal (https://developer.x.com/en/portal/dashboard).")
+	defaultRedirect := app.RedirectURI
+	if defaultRedirect == "" {
+		defaultRedirect = config.DefaultRedirectURI
+	}
+	redirectURI, err := w.askValid("Redirect URI", opts.redirectURI, defaultRedirect, "redirect-uri", auth.ValidateRedirectURI)
+	if err != nil {
+		return err
+	}
+	printDoctorChecks(w.out, []doctorCheck{checkRedirectURI(redirectURI), checkCallbackPort(redirectURI)})
+	fmt.Fprintln(w.out)
+
+	storeIn, err := w.choose("Keep the credentials in: config (the token store) or env (print export lines)", opts.storeIn, "store", initStoreFile, initStoreEnv)
+	if err != nil {
+		return err
+	}
+	if storeIn == initStoreFile {
+		if err := ensureInitApp(a.TokenStore, appName); err != nil {
+			return err
+		}
+		if err := a.TokenStore.UpdateApp(appName, clientID, clientSecret); err != nil {
+			return err
+		}
+		if err := a.TokenStore.SetAppRedirectURI(appName, redirectURI); err != nil {
+			return err
+		}
+		fmt.Fprintf(w.out, "Saved the client credentials and redirect URI to app %q.\n", appName)
+		a.WithAppName(a.AppName())
+	} else {
+		fmt.Fprintln(w.out, "Add these to your shell profile or .env file:")
+		exports := [][2]string{{"CLIENT_ID", clientID}, {"CLIENT_SECRET", clientSecret}, {"REDIRECT_URI", redirectURI}}
+		for _, e := range exports {
+			fmt.Fprintf(w.exports, "export %s=%s\n", e[0], shellQuote(e[1]))
+			// The rest of this run uses them as if they were already set.
+			os.Setenv(e[0], e[1])
+		}
+		a.WithConfig(config.NewConfig())
+	}
+
+	login, err := w.confirm("Log in with OAuth2 now?", opts.login, opts.loginSet, true)
+	if err != nil {
+		return err
+	}
+	if login {
+		if err := w.login(a); err != nil {
+			return fmt.Errorf("OAuth2 login failed: %v", err)
+		}
+	} else if _, ok := a.ExistingOAuth2Login(""); !ok {
+		fmt.Fprintln(w.out, "Log in later with: xurl auth oauth2")
+		return nil
+	}
+	return w.finish(a, opts, initAuthOAuth2)
+}
+
+// finish makes the verification call for authType, unless skipped.
+func (w *initWizard) finish(a *auth.Auth, opts initOptions, authType string) error {
+	if opts.skipVerify {
+		return nil
+	}
+	identity, err := w.verify(a, authType)
+	if err != nil {
+		return fmt.Errorf("verification failed: %v", err)
+	}
+	fmt.Fprintf(w.out, "\033[32m✓ %s\033[0m\nxurl is ready.\n", identity)
+	return nil
+}
+
+// ensureInitApp registers name in ts, without credentials, if it is not
+// there yet.
+func ensureInitApp(ts *store.TokenStore, name string) error {
+	if ts.GetApp(name) != nil {
+		return nil
+	}
+	return ts.AddApp(name, "", "")
+}
+
+// ask returns value if set, and otherwise asks label, offering def.
+func (w *initWizard) ask(label, value, def, flag string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if !w.interactive {
+		if def != "" {
+			return def, nil
+		}
+		return "", fmt.Errorf("--%s is required when xurl init is not run interactively", flag)
+	}
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", label)
+		}
+		line, err := w.in.ReadString('\n')
+		if answer := strings.TrimSpace(line); answer != "" {
+			return answer, nil
+		}
+		if def != "" {
+			return def, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("no %s given", strings.ToLower(label))
+		}
+	}
+}
+
+// askSecret is ask for a secret, which is not echoed. When the app already
+// has one (hasCurrent), an empty answer keeps it and askSecret returns "".
+func (w *initWizard) askSecret(label, value string, hasCurrent bool, flag string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if !w.interactive {
+		if hasCurrent {
+			return "", nil
+		}
+		return "", fmt.Errorf("--%s is required when xurl init is not run interactively", flag)
+	}
+	for {
+		if hasCurrent {
+			fmt.Fprintf(w.out, "%s [keep current]: ", label)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", label)
+		}
+		var line string
+		var err error
+		if w.readSecret != nil {
+			line, err = w.readSecret()
+		} else {
+			line, err = w.in.ReadString('\n')
+		}
+		if answer := strings.TrimSpace(line); answer != "" || hasCurrent {
+			return answer, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("no %s given", strings.ToLower(label))
+		}
+	}
+}
+
+// askValid is ask, repeating the question while valid rejects the answer.
+// An invalid flag value is an error.
+func (w *initWizard) askValid(label, value, def, flag string, valid func(string) error) (string, error) {
+	for {
+		answer, err := w.ask(label, value, def, flag)
+		if err != nil {
+			return "", err
+		}
+		err = valid(answer)
+		if err == nil {
+			return answer, nil
+		}
+		if value != "" || !w.interactive {
+			return "", fmt.Errorf("--%s: %v", flag, err)
+		}
+		fmt.Fprintf(w.out, "%v\n", err)
+	}
+}
+
+// choose asks for one of options, the first being the default.
+func (w *initWizard) choose(label, value, flag string, options ...string) (string, error) {
+	return w.askValid(label+" ("+strings.Join(options, "/")+")", value, options[0], flag, func(answer string) error {
+		if !slices.Contains(options, answer) {
+			return fmt.Errorf("%q is not one of %s", answer, strings.Join(options, ", "))
+		}
+		return nil
+	})
+}
+
+// confirm asks a yes/no question unless the flag was set, in which case
+// value is the answer. Without a terminal the answer is value.
+func (w *initWizard) confirm(label string, value, set, def bool) (bool, error) {
+	if set || !w.interactive {
+		return value, nil
+	}
+	hint := "[Y/n]"
+	if !def {
+		hint = "[y/N]"
+	}
+	fmt.Fprintf(w.out, "%s %s ", label, hint)
+	line, _ := w.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// shellQuote quotes s for a POSIX shell when it needs it.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// initTestWizard returns a wizard reading input, with login and verify
+// recorded in calls instead of reaching the network.
+func initTestWizard(input string, interactive bool, calls *[]string) (*initWizard, *bytes.Buffer, *bytes.Buffer) {
+	var out, exports bytes.Buffer
+	return &initWizard{
+		in:          bufio.NewReader(strings.NewReader(input)),
+		out:         &out,
+		interactive: interactive,
+		exports:     &exports,
+		login: func(*auth.Auth) error {
+			*calls = append(*calls, "login")
+			return nil
+		},
+		verify: func(_ *auth.Auth, authType string) (string, error) {
+			*calls = append(*calls, "verify "+authType)
+			return "authenticated as @alice", nil
+		},
+	}, &out, &exports
+}
+
+func initTestAuth(t *testing.T) *auth.Auth {
+	t.Helper()
+	for _, name := range []string{"CLIENT_ID", "CLIENT_SECRET", "REDIRECT_URI"} {
+		t.Setenv(name, "")
+	}
+	ts := &store.TokenStore{Apps: map[string]*store.App{}, FilePath: filepath.Join(t.TempDir(), ".xurl")}
+	return auth.NewAuth(&config.Config{}).WithTokenStore(ts)
+}
+
+func TestInitScripted(t *testing.T) {
+	t.Run("oauth2 into the token store", func(t *testing.T) {
+		a := initTestAuth(t)
+		var calls []string
+		w, out, exports := initTestWizard("", false, &calls)
+		err := runInit(w, a, initOptions{
+			authType:     "oauth2",
+			clientID:     "abc",
+			clientSecret: "xyz",
+			redirectURI:  "http://127.0.0.1:0/oauth/done",
+			login:        true,
+			loginSet:     true,
+		})
+		require.NoError(t, err)
+
+		app := a.TokenStore.GetApp("default")
+		require.NotNil(t, app)
+		assert.Equal(t, "abc", app.ClientID)
+		assert.Equal(t, "xyz", app.ClientSecret)
+		assert.Equal(t, "http://127.0.0.1:0/oauth/done", app.RedirectURI)
+		assert.Equal(t, []string{"login", "verify oauth2"}, calls)
+		assert.Contains(t, out.String(), "callback port")
+		assert.Contains(t, out.String(), "@alice")
+		assert.Empty(t, exports.String())
+	})
+
+	t.Run("app-only token", func(t *testing.T) {
+		a := initTestAuth(t)
+		var calls []string
+		w, _, _ := initTestWizard("", false, &calls)
+		require.NoError(t, runInit(w, a, initOptions{authType: "app", bearerToken: "AAAA"}))
+		app := a.TokenStore.GetApp("default")
+		require.NotNil(t, app)
+		require.NotNil(t, app.BearerToken)
+		assert.Equal(t, "AAAA", app.BearerToken.Bearer)
+		assert.Equal(t, []string{"verify app"}, calls)
+	})
+
+	t.Run("missing answers name their flags", func(t *testing.T) {
+		var calls []string
+		w, _, _ := initTestWizard("", false, &calls)
+		err := runInit(w, initTestAuth(t), initOptions{})
+		assert.ErrorContains(t, err, "--client-id is required")
+
+		err = runInit(w, initTestAuth(t), initOptions{clientID: "abc", clientSecret: "xyz", redirectURI: "https://example.com/cb"})
+		assert.ErrorContains(t, err, "--redirect-uri")
+
+		err = runInit(w, initTestAuth(t), initOptions{authType: "oauth1"})
+		assert.ErrorContains(t, err, "--auth-type")
+		assert.Empty(t, calls)
+	})
+}
+
+func TestInitInteractive(t *testing.T) {
+	a := initTestAuth(t)
+	var calls []string
+	input := strings.Join([]string{
+		"",                           // kind of access: the oauth2 default
+		"abc",                        // client ID
+		"xyz",                        // client secret
+		"https://example.com/cb",     // rejected, asked again
+		"http://localhost:0/it/done", // redirect URI
+		"env",                        // print exports
+		"n",                          // no login
+	}, "\n") + "\n"
+	w, out, exports := initTestWizard(input, true, &calls)
+	require.NoError(t, runInit(w, a, initOptions{}))
+
+	assert.Equal(t, "export CLIENT_ID=abc\nexport CLIENT_SECRET=xyz\nexport REDIRECT_URI=http://localhost:0/it/done\n", exports.String())
+	assert.Contains(t, out.String(), "only serves http")
+	assert.Contains(t, out.String(), "xurl auth oauth2")
+	assert.Empty(t, calls, "no login and so nothing to verify")
+	if app := a.TokenStore.GetApp("default"); app != nil {
+		assert.Empty(t, app.ClientID, "env credentials are not stored")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "abc-1_2", shellQuote("abc-1_2"))
+	assert.Equal(t, "'a b'", shellQuote("a b"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "''", shellQuote(""))
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// Formats of `lists members`.
+const (
+	listFormatJSON  = "json"
+	listFormatTable = "table"
+	listFormatCSV   = "csv"
+)
+
+// listMember is the part of a list member `lists members` prints as a table
+// or CSV.
+type listMember struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Name          string `json:"name"`
+	PublicMetrics struct {
+		FollowersCount int `json:"followers_count"`
+	} `json:"public_metrics"`
+}
+
+// CreateListsCommand creates the `lists` command and its subcommands, which
+// manage the authenticated user's Lists.
+func CreateListsCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lists",
+		Short: "Create, delete and manage the members of Lists",
+		Long: `Manage Lists without building the request bodies by hand. Members can be
+given as @handles, which are resolved to user IDs, or as IDs. The write
+commands act as the authenticated user and need user-context auth.`,
+		Example: `  xurl lists create "Gophers" --private --description "Go people"
+  xurl lists add-member 1234567890 @golang
+  xurl lists members 1234567890 --paginate --format table
+  xurl lists delete 1234567890`,
+	}
+	cmd.AddCommand(
+		listsCreateCmd(a, g), listsDeleteCmd(a, g),
+		listsAddMemberCmd(a, g), listsRemoveMemberCmd(a, g), listsMembersCmd(a, g),
+	)
+	return cmd
+}
+
+func listsCreateCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var description string
+	var private bool
+	cmd := &cobra.Command{
+		Use:   `create "NAME"`,
+		Short: "Create a List",
+		Long:  `Create a List owned by you. Lists are public unless --private is given.`,
+		Example: `  xurl lists create "Gophers"
+  xurl lists create "Watch list" --private --description "Accounts to keep an eye on"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g.printResult(api.CreateList(g.newClient(a), args[0], description, private, g.baseOpts(cmd)))
+		},
+	}
+	cmd.Flags().StringVar(&description, "description", "", "Description of the List")
+	cmd.Flags().BoolVar(&private, "private", false, "Make the List private")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func listsDeleteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "delete LIST_ID",
+		Short: "Delete a List",
+		Long: `Delete a List owned by you. This cannot be undone, so xurl asks first;
+--yes skips the question, and is required when standard input is not a
+terminal.`,
+		Example: `  xurl lists delete 1234567890
+  xurl lists delete 1234567890 --yes`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := g.baseOpts(cmd)
+			if !confirmDestructive(yes || opts.DryRun, "delete a List", "List "+args[0]) {
+				return
+			}
+			g.printResult(api.DeleteList(g.newClient(a), args[0], opts))
+		},
+	}
+	addYesFlag(cmd, &yes)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func listsAddMemberCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-member LIST_ID USER",
+		Short: "Add a user to a List",
+		Long:  `Add a user, given as an @handle or a user ID, to a List you own.`,
+		Example: `  xurl lists add-member 1234567890 @golang
+  xurl lists add-member 1234567890 2244994945`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			userID, err := resolveUserArg(client, args[1], opts)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			g.printResult(api.AddListMember(client, args[0], userID, opts))
+		},
+	}
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func listsRemoveMemberCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove-member LIST_ID USER",
+		Short:   "Remove a user from a List",
+		Long:    `Remove a user, given as an @handle or a user ID, from a List you own.`,
+		Example: `  xurl lists remove-member 1234567890 @golang`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			userID, err := resolveUserArg(client, args[1], opts)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			g.printResult(api.RemoveListMember(client, args[0], userID, opts))
+		},
+	}
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func listsMembersCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var maxResults, max int
+	var paginate bool
+	var format string
+	cmd := &cobra.Command{
+		Use:   "members LIST_ID",
+		Short: "List the members of a List",
+		Long: `Fetch the members of a List: one page of up to -n members, or with
+--paginate every page (up to --max members), merged into one response.
+
+--format table prints the ID, username, name and follower count of each
+member, and --format csv the same columns as CSV with a header row.`,
+		Example: `  xurl lists members 1234567890
+  xurl lists members 1234567890 --paginate --format table
+  xurl lists members 1234567890 --paginate --format csv > members.csv`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			switch format {
+			case listFormatJSON, listFormatTable, listFormatCSV:
+			default:
+				g.fprintError(os.Stderr, "Error: --format must be json, table or csv")
+				os.Exit(1)
+			}
+			if cmd.Flags().Changed("max") && !paginate {
+				g.fprintError(os.Stderr, "Error: --max needs --paginate; use -n for a single page")
+				os.Exit(1)
+			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+
+			var resp json.RawMessage
+			var err error
+			if paginate {
+				limit := max
+				if limit <= 0 {
+					limit = math.MaxInt
+				}
+				resp, err = api.GetAllListMembers(client, args[0], limit, opts)
+			} else {
+				resp, err = api.GetListMembers(client, args[0], maxResults, opts)
+			}
+			if err != nil || format == listFormatJSON || api.IsDryRun(resp) {
+				g.printResult(resp, err)
+				return
+			}
+			members, err := parseListMembers(resp)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: could not parse the members response: %v", err)
+				os.Exit(1)
+			}
+			if format == listFormatCSV {
+				err = writeListMembersCSV(os.Stdout, members)
+			} else {
+				printListMembers(os.Stdout, members)
+			}
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 100, "Number of members in a single page (1–100)")
+	cmd.Flags().BoolVar(&paginate, "paginate", false, "Fetch every page of members")
+	cmd.Flags().IntVar(&max, "max", 0, "With --paginate, stop after this many members (0 means all)")
+	cmd.Flags().StringVar(&format, "format", listFormatJSON, "Output format: json, table or csv")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// resolveUserArg returns the user ID of arg: a numeric ID as it is, or an
+// @handle (or bare username) looked up with resolveUserID.
+func resolveUserArg(client api.Client, arg string, opts api.RequestOptions) (string, error) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, "@") && isNumericID(arg) {
+		return arg, nil
+	}
+	return resolveUserID(client, arg, opts)
+}
+
+// parseListMembers reads the members of a list members response.
+func parseListMembers(resp json.RawMessage) ([]listMember, error) {
+	var body struct {
+		Data []listMember `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// printListMembers writes members as a table.
+func printListMembers(w io.Writer, members []listMember) {
+	if len(members) == 0 {
+		fmt.Fprintln(w, "No members.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSERNAME\tNAME\tFOLLOWERS")
+	for _, m := range members {
+		fmt.Fprintf(tw, "%s\t@%s\t%s\t%d\n", m.ID, m.Username, m.Name, m.PublicMetrics.FollowersCount)
+	}
+	tw.Flush()
+}
+
+// writeListMembersCSV writes members as CSV with a header row.
+func writeListMembersCSV(w io.Writer, members []listMember) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "username", "name", "followers_count"})
+	for _, m := range members {
+		cw.Write([]string{m.ID, m.Username, m.Name, strconv.Itoa(m.PublicMetrics.FollowersCount)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
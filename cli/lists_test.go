@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+const listMembersFixture = `{
+	"data": [
+		{"id": "2244994945", "username": "XDevelopers", "name": "Developers", "public_metrics": {"followers_count": 580000}},
+		{"id": "12", "username": "jack", "name": "jack, the \"founder\""}
+	],
+	"meta": {"result_count": 2}
+}`
+
+func TestResolveUserArg(t *testing.T) {
+	t.Cleanup(func() { userIDCache = map[string]string{} })
+	userIDCache = map[string]string{}
+
+	var endpoints []string
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			endpoints = append(endpoints, options.Endpoint)
+			return json.RawMessage(`{"data":{"id":"42"}}`), nil
+		},
+	}
+
+	id, err := resolveUserArg(client, "2244994945", api.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "2244994945", id)
+	assert.Empty(t, endpoints, "a numeric ID is used as it is")
+
+	id, err = resolveUserArg(client, "@12", api.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "42", id, `"@12" is a handle`)
+	require.Len(t, endpoints, 1)
+	assert.Contains(t, endpoints[0], "/2/users/by/username/12")
+}
+
+func TestPrintListMembers(t *testing.T) {
+	members, err := parseListMembers(json.RawMessage(listMembersFixture))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printListMembers(&out, members)
+	assert.Equal(t, `ID          USERNAME      NAME                 FOLLOWERS
+2244994945  @XDevelopers  Developers           580000
+12          @jack         jack, the "founder"  0
+`, out.String())
+
+	out.Reset()
+	printListMembers(&out, nil)
+	assert.Equal(t, "No members.\n", out.String())
+}
+
+func TestWriteListMembersCSV(t *testing.T) {
+	members, err := parseListMembers(json.RawMessage(listMembersFixture))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writeListMembersCSV(&out, members))
+	assert.Equal(t, `id,username,name,followers_count
+2244994945,XDevelopers,Developers,580000
+12,jack,"jack, the ""founder""",0
+`, out.String())
+}
@@ -67,7 +67,7 @@ type mcpBridge struct {
 
 	// tokenMu serialises all access to the (mutex-less) token store, so the
 	// message loop and the server->client listener never refresh/persist
-	// concurrently (which would be a fatal map race and could corrupt ~/.xurl).
+	// concurrently (which would be a fatal map race and could corrupt auth.yml).
 	tokenMu sync.Mutex
 
 	in  io.Reader
@@ -81,11 +81,11 @@ type mcpBridge struct {
 	sessionReady chan struct{}
 }
 
-func newMCPBridge(url string, a *auth.Auth, username string) *mcpBridge {
-	return newMCPBridgeWithIO(url, a, username, os.Stdin, os.Stdout)
+func newMCPBridge(url string, a *auth.Auth, username string, transport http.RoundTripper) *mcpBridge {
+	return newMCPBridgeWithIO(url, a, username, os.Stdin, os.Stdout, transport)
 }
 
-func newMCPBridgeWithIO(url string, a *auth.Auth, username string, in io.Reader, out io.Writer) *mcpBridge {
+func newMCPBridgeWithIO(url string, a *auth.Auth, username string, in io.Reader, out io.Writer, transport http.RoundTripper) *mcpBridge {
 	return &mcpBridge{
 		url:        url,
 		auth:       a,
@@ -93,7 +93,7 @@ func newMCPBridgeWithIO(url string, a *auth.Auth, username string, in io.Reader,
 		oauth2Flow: a.OAuth2Flow,
 		// No client timeout: SSE responses and the server->client stream are
 		// long-lived; cancellation is driven by the request context instead.
-		httpClient:   &http.Client{},
+		httpClient:   &http.Client{Transport: transport},
 		in:           in,
 		out:          out,
 		sessionReady: make(chan struct{}),
@@ -722,7 +722,7 @@ func drainClose(resp *http.Response) {
 
 // CreateMCPCommand creates the `mcp` command: a stdio<->Streamable-HTTP MCP bridge
 // that authenticates with the active app's OAuth2 token.
-func CreateMCPCommand(a *auth.Auth) *cobra.Command {
+func CreateMCPCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mcp [URL]",
 		Short: "Bridge a stdio MCP client to a remote (X API) MCP server",
@@ -754,6 +754,8 @@ Example MCP client config:
       }
     }
   }`,
+		Example: `  xurl mcp
+  xurl mcp https://api.x.com/mcp --app prod -u alice`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			url := defaultMCPURL
@@ -762,10 +764,10 @@ Example MCP client config:
 			}
 			username, _ := cmd.Flags().GetString("username")
 
-			bridge := newMCPBridge(url, a, username)
+			bridge := newMCPBridge(url, a, username, g.transport)
 
 			if err := bridge.bootstrap(); err != nil {
-				fprintError(os.Stderr, "Error: %v", err)
+				g.fprintError(os.Stderr, "Error: %v", err)
 				os.Exit(1)
 			}
 
@@ -774,7 +776,7 @@ Example MCP client config:
 
 			bridge.logf("bridging stdio <-> %s", url)
 			if err := bridge.run(ctx); err != nil {
-				fprintError(os.Stderr, "mcp bridge error: %v", err)
+				g.fprintError(os.Stderr, "mcp bridge error: %v", err)
 				os.Exit(1)
 			}
 		},
@@ -99,7 +99,7 @@ func TestMCPBridgeJSONResponse(t *testing.T) {
 	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\n")
 	var out bytes.Buffer
 
-	b := newMCPBridgeWithIO(server.URL, a, "", in, &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", in, &out, nil)
 	require.NoError(t, b.run(context.Background()))
 
 	mu.Lock()
@@ -142,7 +142,7 @@ func TestMCPBridgeSSEResponse(t *testing.T) {
 	in := strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call"}` + "\n")
 	var out bytes.Buffer
 
-	b := newMCPBridgeWithIO(server.URL, a, "", in, &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", in, &out, nil)
 	require.NoError(t, b.run(context.Background()))
 
 	mu.Lock()
@@ -176,7 +176,7 @@ func TestMCPBridgeForwardsSessionID(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok-1")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	ctx := context.Background()
 	b.forwardPost(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
@@ -204,7 +204,7 @@ func TestMCPBridgeAcceptedNoBody(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok-2")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
 	assert.Empty(t, strings.TrimSpace(out.String()), "202 responses must not write to stdout")
@@ -245,7 +245,7 @@ func TestMCPBridge401ForcesRefresh(t *testing.T) {
 
 	a := mcpTestAuthRefreshable(t, "old-access", tokenServer.URL+"/token")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(mcpServer.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(mcpServer.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
 
@@ -273,7 +273,7 @@ func TestMCPBridgeErrorStatusJSONForwarded(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok-err")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"x"}`))
 	assert.Contains(t, out.String(), `"error"`)
@@ -286,7 +286,7 @@ func TestMCPBridgeErrorStatusJSONForwarded(t *testing.T) {
 func TestMCPBridgePumpSSEMultilineAndNonJSON(t *testing.T) {
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO("", a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO("", a, "", strings.NewReader(""), &out, nil)
 
 	sse := strings.Join([]string{
 		": keep-alive",
@@ -320,7 +320,7 @@ func TestMCPBridgeOpenServerStreamSSE(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok-get")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	status, eventStream, err := b.openServerStream(context.Background())
 	require.NoError(t, err)
@@ -346,7 +346,7 @@ func TestMCPBridgeRunReturnsOnContextCancel(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", pr, &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", pr, &out, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	done := make(chan error, 1)
@@ -388,7 +388,7 @@ func TestMCPBridgeTransportFailureSynthesizesError(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/list"}`))
 
@@ -410,7 +410,7 @@ func TestMCPBridgeNotificationNoSynthesisOnFailure(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled"}`))
 	assert.Empty(t, strings.TrimSpace(out.String()), "notifications must not get a synthesized reply")
@@ -429,7 +429,7 @@ func TestMCPBridgeErrorStatusEmptyBodySynthesizesError(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":9,"method":"x"}`))
 	line := strings.TrimSpace(out.String())
@@ -450,7 +450,7 @@ func TestMCPBridgeResponseNoSynthesisOnFailure(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(url, a, "", strings.NewReader(""), &out, nil)
 
 	// A response carries an id + result but no method.
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":5,"result":{"ok":true}}`))
@@ -474,7 +474,7 @@ func TestMCPBridgeNonJSONRPCErrorBodySynthesizes(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":11,"method":"tools/call"}`))
 	line := strings.TrimSpace(out.String())
@@ -503,7 +503,7 @@ func TestMCPBridgeLargeErrorBodyForwardedNotTruncated(t *testing.T) {
 
 	a := mcpTestAuth(t, "tok")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", strings.NewReader(""), &out, nil)
 
 	b.forwardPost(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"x"}`))
 	got := strings.TrimSpace(out.String())
@@ -554,7 +554,7 @@ func TestMCPBridgeNotificationNotHeadOfLineBlocked(t *testing.T) {
 		`{"jsonrpc":"2.0","id":1,"method":"tools/call"}` + "\n" +
 			`{"jsonrpc":"2.0","method":"notifications/cancelled"}` + "\n")
 	var out bytes.Buffer
-	b := newMCPBridgeWithIO(server.URL, a, "", in, &out)
+	b := newMCPBridgeWithIO(server.URL, a, "", in, &out, nil)
 
 	done := make(chan error, 1)
 	go func() { done <- b.run(context.Background()) }()
@@ -578,7 +578,7 @@ func newTokenlessBridge(t *testing.T, flow func(string) (string, error)) *mcpBri
 		FilePath:   filepath.Join(t.TempDir(), ".xurl"),
 	}
 	a := auth.NewAuth(&config.Config{}).WithTokenStore(ts)
-	b := newMCPBridgeWithIO("http://127.0.0.1:0", a, "", strings.NewReader(""), &bytes.Buffer{})
+	b := newMCPBridgeWithIO("http://127.0.0.1:0", a, "", strings.NewReader(""), &bytes.Buffer{}, nil)
 	b.oauth2Flow = flow
 	return b
 }
@@ -587,7 +587,7 @@ func newTokenlessBridge(t *testing.T, flow func(string) (string, error)) *mcpBri
 // bootstrap without invoking the browser login.
 func TestMCPBridgeBootstrapSkipsLoginWithToken(t *testing.T) {
 	a := mcpTestAuth(t, "tok")
-	b := newMCPBridgeWithIO("http://127.0.0.1:0", a, "", strings.NewReader(""), &bytes.Buffer{})
+	b := newMCPBridgeWithIO("http://127.0.0.1:0", a, "", strings.NewReader(""), &bytes.Buffer{}, nil)
 	called := false
 	b.oauth2Flow = func(string) (string, error) { called = true; return "", nil }
 	require.NoError(t, b.bootstrap())
@@ -3,51 +3,129 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
-	"github.com/xdevplatform/xurl/config"
 )
 
 // CreateMediaCommand creates the media command and its subcommands
-func CreateMediaCommand(auth *auth.Auth) *cobra.Command {
+func CreateMediaCommand(auth *auth.Auth, g *globalOptions) *cobra.Command {
 	// Create media command
 	var mediaCmd = &cobra.Command{
 		Use:   "media",
 		Short: "Media upload operations",
+		Long: `Upload media and check its processing status.
+
+Uploaded media IDs can be attached to posts with 'xurl post --media-id'.`,
+		Example: `  xurl media upload photo.jpg
+  xurl media status 1234567890 --wait`,
 	}
 
-	mediaCmd.AddCommand(createMediaUploadCmd(auth))
-	mediaCmd.AddCommand(createMediaStatusCmd(auth))
+	mediaCmd.AddCommand(createMediaUploadCmd(auth, g))
+	mediaCmd.AddCommand(createMediaStatusCmd(auth, g))
 
 	return mediaCmd
 }
 
 // Create media upload subcommand
-func createMediaUploadCmd(auth *auth.Auth) *cobra.Command {
-	var mediaType, mediaCategory string
-	var waitForProcessing bool
+func createMediaUploadCmd(auth *auth.Auth, g *globalOptions) *cobra.Command {
+	var mediaType, mediaCategory, preUploadCmd, sidecarSuffix string
+	var waitForProcessing, subfileChunks, sidecars bool
+	var maxUploadMemory int
 
 	cmd := &cobra.Command{
-		Use:   "upload [flags] FILE",
-		Short: "Upload media file",
-		Long:  `Upload a media file to X API. Supports images, GIFs, and videos.`,
-		Args:  cobra.ExactArgs(1),
+		Use:   "upload [flags] FILE...",
+		Short: "Upload media files",
+		Long: `Upload media files to X API. Supports images, GIFs, and videos.
+
+Several files are uploaded one after another. A file that fails to upload
+does not stop the others; xurl lists the failed files at the end and exits
+non-zero.
+
+--sidecars sets alt text kept next to each file in a JSON or YAML sidecar:
+photo.png.meta.json holding {"alt_text": "..."} (--sidecar-suffix changes
+the ".meta.json" suffix). The alt text is set once the file is uploaded. A
+missing or malformed sidecar is a warning, not a failure.
+
+--pre-upload-cmd runs a command (for example a transcoder) on the file first
+and uploads its output instead. {in} is replaced by the file's path and {out}
+by a temporary path with the same extension, which is removed afterwards.
+
+--subfile-chunks reads each 4 MiB chunk straight from the file and uploads
+up to four at once, each with its own reused buffer, so memory stays bounded
+however large the file is. --max-upload-memory caps the chunk buffers in
+flight (fewer chunks are uploaded at once to fit).
+
+While waiting for processing, STATUS checks that fail with a 5xx or network
+error are retried after the server's Retry-After, or with exponential
+backoff. The wait gives up after --max-status-failures failures in a row or
+once --processing-timeout has passed. A failed processing state is reported
+at once.`,
+		Example: `  xurl media upload photo.jpg
+  xurl media upload --media-type video/mp4 --category tweet_video clip.mp4
+  xurl media upload --wait=false large.mp4
+  xurl media upload --pre-upload-cmd 'ffmpeg -i {in} -c:v libx264 -crf 28 {out}' clip.mp4
+  xurl media upload --subfile-chunks --max-upload-memory 8 huge.mp4
+  xurl media upload --processing-timeout 30m long-video.mp4
+  xurl media upload --sidecars images/*.png`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			filePath := args[0]
 			authType, _ := cmd.Flags().GetString("auth")
 			username, _ := cmd.Flags().GetString("username")
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			headers, _ := cmd.Flags().GetStringArray("header")
 			trace, _ := cmd.Flags().GetBool("trace")
-			config := config.NewConfig()
-			client := api.NewApiClient(config, auth)
+			client := g.newClient(auth)
 
-			err := api.ExecuteMediaUpload(filePath, mediaType, mediaCategory, authType, username, verbose, waitForProcessing, trace, headers, client)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31m%v\033[0m\n", err)
+			var parallel *api.ParallelUpload
+			if subfileChunks {
+				parallel = &api.ParallelUpload{Workers: api.DefaultUploadWorkers, MaxMemory: int64(maxUploadMemory) << 20}
+			} else if cmd.Flags().Changed("max-upload-memory") {
+				fmt.Fprintln(os.Stderr, "\033[31m--max-upload-memory needs --subfile-chunks\033[0m")
+				os.Exit(1)
+			}
+			if !sidecars {
+				if cmd.Flags().Changed("sidecar-suffix") {
+					fmt.Fprintln(os.Stderr, "\033[31m--sidecar-suffix needs --sidecars\033[0m")
+					os.Exit(1)
+				}
+				sidecarSuffix = ""
+			}
+
+			var failed []string
+			for i, filePath := range args {
+				if len(args) > 1 {
+					fmt.Fprintf(os.Stderr, "▸ %s (%d of %d)\n", filePath, i+1, len(args))
+				}
+				err := api.ExecuteMediaUpload(api.MediaUploadOptions{
+					FilePath:          filePath,
+					MediaType:         mediaType,
+					MediaCategory:     mediaCategory,
+					PreUploadCmd:      preUploadCmd,
+					SidecarSuffix:     sidecarSuffix,
+					AuthType:          authType,
+					Username:          username,
+					Verbose:           verbose,
+					Trace:             trace,
+					Headers:           headers,
+					WaitForProcessing: waitForProcessing,
+					Processing:        g.processingWait(cmd),
+					Parallel:          parallel,
+					Progress:          g.progress,
+					Format:            g.format,
+				}, client)
+				if err != nil {
+					g.fprintError(os.Stderr, "%v", err)
+					failed = append(failed, filePath)
+				}
+			}
+			if len(failed) > 0 {
+				if len(args) > 1 {
+					g.fprintError(os.Stderr, "%d of %d files failed to upload: %s", len(failed), len(args), strings.Join(failed, ", "))
+				}
 				os.Exit(1)
 			}
 		},
@@ -56,6 +134,12 @@ func createMediaUploadCmd(auth *auth.Auth) *cobra.Command {
 	cmd.Flags().StringVar(&mediaType, "media-type", "", "Media MIME type (auto-detected from the file extension if omitted)")
 	cmd.Flags().StringVar(&mediaCategory, "category", "", "Media category (derived from the media type if omitted)")
 	cmd.Flags().BoolVar(&waitForProcessing, "wait", true, "Wait for media processing to complete")
+	addProcessingWaitFlags(cmd)
+	cmd.Flags().BoolVar(&subfileChunks, "subfile-chunks", false, "Read chunks straight from the file and upload several at once with bounded memory")
+	cmd.Flags().IntVar(&maxUploadMemory, "max-upload-memory", 16, "With --subfile-chunks, the most memory in MiB to hold in chunk buffers at once")
+	cmd.Flags().BoolVar(&sidecars, "sidecars", false, "Set the alt text in the JSON or YAML sidecar next to each file, e.g. photo.png.meta.json")
+	cmd.Flags().StringVar(&sidecarSuffix, "sidecar-suffix", api.DefaultSidecarSuffix, "With --sidecars, the suffix added to a file's name to find its sidecar")
+	cmd.Flags().StringVar(&preUploadCmd, "pre-upload-cmd", "", "Shell command run before upload; {in} is the file, and the command must write the file to upload to {out}")
 	cmd.Flags().String("auth", "", "Authentication type (oauth1, oauth2, or app)")
 	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
 	cmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
@@ -66,12 +150,18 @@ func createMediaUploadCmd(auth *auth.Auth) *cobra.Command {
 }
 
 // Create media status subcommand
-func createMediaStatusCmd(auth *auth.Auth) *cobra.Command {
+func createMediaStatusCmd(auth *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status [flags] MEDIA_ID",
 		Short: "Check media upload status",
-		Long:  `Check the status of a media upload by media ID.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Check the status of a media upload by media ID.
+
+With --wait, polls until processing finishes, retrying STATUS checks that
+fail transiently as 'media upload' does.`,
+		Example: `  xurl media status 1234567890
+  xurl media status --wait 1234567890
+  xurl media status --wait --processing-timeout 10m --max-status-failures 10 1234567890`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			mediaID := args[0]
 			authType, _ := cmd.Flags().GetString("auth")
@@ -80,12 +170,20 @@ func createMediaStatusCmd(auth *auth.Auth) *cobra.Command {
 			wait, _ := cmd.Flags().GetBool("wait")
 			trace, _ := cmd.Flags().GetBool("trace")
 			headers, _ := cmd.Flags().GetStringArray("header")
-			config := config.NewConfig()
-			client := api.NewApiClient(config, auth)
+			client := g.newClient(auth)
 
-			err := api.ExecuteMediaStatus(mediaID, authType, username, verbose, wait, trace, headers, client)
+			opts := api.RequestOptions{
+				AuthType: authType,
+				Username: username,
+				Verbose:  verbose,
+				Trace:    trace,
+				Headers:  headers,
+				Progress: g.progress,
+				Format:   g.format,
+			}
+			err := api.ExecuteMediaStatus(mediaID, opts, wait, g.processingWait(cmd), client)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31m%v\033[0m\n", err)
+				g.fprintError(os.Stderr, "%v", err)
 				os.Exit(1)
 			}
 		},
@@ -95,7 +193,22 @@ func createMediaStatusCmd(auth *auth.Auth) *cobra.Command {
 	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
 	cmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
 	cmd.Flags().BoolP("wait", "w", false, "Wait for media processing to complete")
+	addProcessingWaitFlags(cmd)
 	cmd.Flags().BoolP("trace", "t", false, "Add trace header to request")
 	cmd.Flags().StringArrayP("header", "H", []string{}, "Request headers")
 	return cmd
 }
+
+// addProcessingWaitFlags adds the flags read by processingWait.
+func addProcessingWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("processing-timeout", 0, "Give up waiting for media processing after this long (0 for no limit)")
+	cmd.Flags().Int("max-status-failures", api.DefaultStatusFailures, "Give up waiting after this many status checks fail in a row")
+}
+
+// processingWait returns the limits set by addProcessingWaitFlags' flags,
+// with the global Retry-After policy.
+func (g *globalOptions) processingWait(cmd *cobra.Command) api.ProcessingWait {
+	timeout, _ := cmd.Flags().GetDuration("processing-timeout")
+	failures, _ := cmd.Flags().GetInt("max-status-failures")
+	return api.ProcessingWait{Timeout: timeout, MaxFailures: failures, RetryAfter: g.retryAfter}
+}
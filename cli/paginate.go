@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/xdevplatform/xurl/api"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// exitResumable is the exit code of a --paginate run that stopped at a rate
+// limit after saving its cursor to --resume-file: the pages fetched were
+// written, and rerunning the same command later picks up where it left off.
+const exitResumable = 3
+
+// paginateRequest runs a --paginate request, printing each page's items as
+// NDJSON lines, or appending them to output when set, and keeping the cursor
+// in resumeFile when set. It stops after maxItems items when maxItems is
+// above zero. With graceful set, rate limits are waited out with a countdown
+// on stderr. It returns the exit code.
+func (g *globalOptions) paginateRequest(opts api.RequestOptions, client api.Client, resumeFile, output string, maxItems int, graceful bool) int {
+	state, err := api.NewPaginationState(opts.Endpoint)
+	if err != nil {
+		g.fprintError(os.Stderr, "Error: %v", err)
+		return 1
+	}
+	if resumeFile != "" {
+		saved, err := api.LoadPaginationState(resumeFile)
+		if err != nil {
+			g.fprintError(os.Stderr, "Error: %v", err)
+			return 1
+		}
+		if saved != nil {
+			if !saved.SameRequest(state) {
+				g.fprintError(os.Stderr, "Error: %s holds the cursor of another request (%s); remove it or pick another --resume-file", resumeFile, saved.Endpoint)
+				return 1
+			}
+			if saved.Complete {
+				fmt.Fprintf(os.Stderr, "%s: pagination already complete (%d pages, %d items); remove it to start over\n", resumeFile, saved.Pages, saved.Items)
+				return 0
+			}
+			state = saved
+			fmt.Fprintf(os.Stderr, "Resuming after page %d (%d items) from %s\n", state.Pages, state.Items, resumeFile)
+		}
+	}
+
+	out := io.Writer(color.Output)
+	var file *os.File
+	if output != "" {
+		if file, err = os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+			g.fprintError(os.Stderr, "Error: %v", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	onPage := func(items []json.RawMessage) error {
+		if err := g.format.WriteNDJSONItems(out, items); err != nil {
+			return err
+		}
+		// Items must be on disk before the cursor moves past them.
+		if file != nil {
+			return file.Sync()
+		}
+		return nil
+	}
+	checkpoint := func(state *api.PaginationState) error {
+		if resumeFile == "" {
+			return nil
+		}
+		return api.SavePaginationState(resumeFile, state)
+	}
+
+	var pause *api.RateLimitPause
+	if graceful {
+		pause = &api.RateLimitPause{RetryAfter: g.retryAfter, Countdown: rateLimitCountdown(os.Stderr, isTerminal(os.Stderr))}
+	}
+
+	if err := api.Paginate(client, opts, state, maxItems, pause, onPage, checkpoint); err != nil {
+		if resumeFile != "" && xurlErrors.IsRateLimitError(err) {
+			fmt.Fprintf(os.Stderr, "Stopped after page %d (%d items): %s.\n", state.Pages, state.Items, xurlErrors.DescribeRateLimit(err, time.Now()))
+			fmt.Fprintf(os.Stderr, "Resume later by running the same command; the cursor is saved in %s.\n", resumeFile)
+			return exitResumable
+		}
+		if xurlErrors.IsRateLimitError(err) {
+			err = fmt.Errorf("%s", xurlErrors.DescribeRateLimit(err, time.Now()))
+		}
+		g.fprintError(os.Stderr, "Error: %v", err)
+		return 1
+	}
+	if state.Truncated != "" {
+		fmt.Fprintf(os.Stderr, "\033[33mWarning: %s\033[0m\n", state.Truncated)
+	}
+	return 0
+}
+
+// rateLimitCountdown returns a --graceful-rate-limit countdown printer. On a
+// terminal it redraws one line every second; otherwise it prints a line when
+// a wait starts and when it ends.
+func rateLimitCountdown(w io.Writer, terminal bool) func(left time.Duration) {
+	waiting := false
+	return func(left time.Duration) {
+		switch {
+		case left <= 0 && waiting:
+			waiting = false
+			if terminal {
+				fmt.Fprint(w, "\r\033[K")
+			}
+			fmt.Fprintln(w, "Rate limit reset; resuming")
+		case left <= 0:
+		case terminal:
+			waiting = true
+			fmt.Fprintf(w, "\r\033[KRate limited; resuming in %s", left.Round(time.Second))
+		case !waiting:
+			waiting = true
+			fmt.Fprintf(w, "Rate limited; resuming in %s\n", left.Round(time.Second))
+		}
+	}
+}
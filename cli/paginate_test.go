@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitCountdown(t *testing.T) {
+	t.Run("terminal redraws the line", func(t *testing.T) {
+		var buf bytes.Buffer
+		countdown := rateLimitCountdown(&buf, true)
+		countdown(2 * time.Second)
+		countdown(time.Second)
+		countdown(0)
+		assert.Equal(t, "\r\033[KRate limited; resuming in 2s\r\033[KRate limited; resuming in 1s\r\033[KRate limit reset; resuming\n", buf.String())
+	})
+
+	t.Run("pipe gets a line per wait", func(t *testing.T) {
+		var buf bytes.Buffer
+		countdown := rateLimitCountdown(&buf, false)
+		countdown(15*time.Minute + 200*time.Millisecond)
+		countdown(15 * time.Minute)
+		countdown(0)
+		countdown(0)
+		assert.Equal(t, "Rate limited; resuming in 15m0s\nRate limit reset; resuming\n", buf.String())
+	})
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+)
+
+// defaultPingEndpoint is served without authentication and is cheap to fetch.
+const defaultPingEndpoint = "/2/openapi.json"
+
+// pingResult is the outcome of one `xurl ping` attempt. The phase durations
+// are zero when the phase did not happen (e.g. DNS for an IP address).
+type pingResult struct {
+	Status    string
+	Err       error
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	FirstByte time.Duration
+	Total     time.Duration
+}
+
+// CreatePingCommand creates the `ping` command.
+func CreatePingCommand(a *auth.Auth, cfg *config.Config, g *globalOptions) *cobra.Command {
+	var count int
+	var endpoint string
+	cmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Check connectivity and latency to the X API",
+		Long: `Send a few lightweight requests to the X API and report how long each took.
+
+Every attempt opens a fresh connection, so the DNS, connect and TLS times are
+measured each time, followed by the time to the first response byte. Any HTTP
+response counts as a successful attempt. The requests are unauthenticated,
+or use the app-only Bearer Token when one is stored; no other credentials are
+needed. Proxy environment variables and --pin-sha256 are honoured.
+
+Exits non-zero if every attempt fails.`,
+		Example: `  xurl ping
+  xurl ping --count 10
+  xurl ping --endpoint /2/tweets/20`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if count < 1 {
+				g.fprintError(os.Stderr, "Error: --count must be at least 1")
+				os.Exit(1)
+			}
+			target := endpoint
+			if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+				target = strings.TrimRight(cfg.APIBaseURL, "/") + "/" + strings.TrimLeft(target, "/")
+			}
+			// App-only auth when available; otherwise the request goes out bare.
+			authHeader, _ := a.GetBearerTokenHeader()
+
+			if !runPing(os.Stdout, pingTransport(g.transport), target, authHeader, count) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&count, "count", "c", 5, "Number of requests to send")
+	cmd.Flags().StringVar(&endpoint, "endpoint", defaultPingEndpoint, "Endpoint path or URL to request")
+	return cmd
+}
+
+// pingTransport returns a copy of base, the cli's transport (so proxy settings and
+// --pin-sha256 apply) that does not reuse connections between attempts.
+func pingTransport(base http.RoundTripper) http.RoundTripper {
+	t, ok := base.(*http.Transport)
+	if ok {
+		t = t.Clone()
+	} else {
+		t = api.CloneDefaultTransport()
+	}
+	t.DisableKeepAlives = true
+	return t
+}
+
+// runPing sends count requests to target, printing a line per attempt and a
+// min/avg/max summary to w. It reports whether any attempt succeeded.
+func runPing(w io.Writer, transport http.RoundTripper, target, authHeader string, count int) bool {
+	mode := "unauthenticated"
+	if authHeader != "" {
+		mode = "app-only auth"
+	}
+	fmt.Fprintf(w, "PING %s (%s)\n", target, mode)
+
+	client := &http.Client{Transport: transport, Timeout: 30 * time.Second}
+	var results []pingResult
+	for i := 1; i <= count; i++ {
+		r := pingOnce(client, target, authHeader)
+		results = append(results, r)
+		printPingResult(w, i, r)
+	}
+
+	ok, min, avg, max := summarizePings(results)
+	fmt.Fprintf(w, "--- %s ping statistics ---\n", target)
+	fmt.Fprintf(w, "%d attempts, %d succeeded, %d failed\n", len(results), ok, len(results)-ok)
+	if ok > 0 {
+		fmt.Fprintf(w, "min/avg/max = %s/%s/%s\n", fmtMillis(min), fmtMillis(avg), fmtMillis(max))
+	}
+	return ok > 0
+}
+
+// pingOnce performs a single GET of target, timing each connection phase.
+func pingOnce(client *http.Client, target, authHeader string) pingResult {
+	var r pingResult
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { r.DNS = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { r.Connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { r.TLS = time.Since(tlsStart) },
+	}
+
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	trace.GotFirstResponseByte = func() { r.FirstByte = time.Since(start) }
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Err = err
+		r.Total = time.Since(start)
+		return r
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	r.Total = time.Since(start)
+	r.Status = resp.Status
+	return r
+}
+
+func printPingResult(w io.Writer, seq int, r pingResult) {
+	if r.Err != nil {
+		fmt.Fprintf(w, "%d: error after %s: %v\n", seq, fmtMillis(r.Total), r.Err)
+		return
+	}
+	fmt.Fprintf(w, "%d: %s  time=%s  (dns %s, connect %s, tls %s, first byte %s)\n",
+		seq, r.Status, fmtMillis(r.Total), fmtMillis(r.DNS), fmtMillis(r.Connect), fmtMillis(r.TLS), fmtMillis(r.FirstByte))
+}
+
+// summarizePings returns how many attempts succeeded and their min, average
+// and max total time.
+func summarizePings(results []pingResult) (ok int, min, avg, max time.Duration) {
+	var sum time.Duration
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if ok == 0 || r.Total < min {
+			min = r.Total
+		}
+		if r.Total > max {
+			max = r.Total
+		}
+		sum += r.Total
+		ok++
+	}
+	if ok > 0 {
+		avg = sum / time.Duration(ok)
+	}
+	return ok, min, avg, max
+}
+
+// fmtMillis formats d in milliseconds with one decimal place.
+func fmtMillis(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
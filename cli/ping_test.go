@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPing(t *testing.T) {
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/openapi.json", r.URL.Path)
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	ok := runPing(&out, pingTransport(nil), server.URL+"/2/openapi.json", "Bearer app-token", 3)
+	require.True(t, ok)
+
+	assert.Equal(t, []string{"Bearer app-token", "Bearer app-token", "Bearer app-token"}, auths)
+	text := out.String()
+	assert.Contains(t, text, "(app-only auth)")
+	assert.Contains(t, text, "1: 200 OK")
+	assert.Contains(t, text, "3: 200 OK")
+	assert.Contains(t, text, "connect ")
+	assert.Contains(t, text, "3 attempts, 3 succeeded, 0 failed")
+	assert.Contains(t, text, "min/avg/max = ")
+}
+
+func TestRunPingAllFail(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	target := server.URL + "/2/openapi.json"
+	server.Close()
+
+	var out bytes.Buffer
+	ok := runPing(&out, pingTransport(nil), target, "", 2)
+	assert.False(t, ok, "ping must fail when no attempt got a response")
+	assert.Contains(t, out.String(), "(unauthenticated)")
+	assert.Contains(t, out.String(), "1: error after")
+	assert.Contains(t, out.String(), "2 attempts, 0 succeeded, 2 failed")
+	assert.NotContains(t, out.String(), "min/avg/max")
+}
+
+func TestSummarizePings(t *testing.T) {
+	results := []pingResult{
+		{Total: 30 * time.Millisecond},
+		{Total: 10 * time.Millisecond},
+		{Err: assert.AnError, Total: time.Second},
+		{Total: 20 * time.Millisecond},
+	}
+	ok, min, avg, max := summarizePings(results)
+	assert.Equal(t, 3, ok)
+	assert.Equal(t, 10*time.Millisecond, min)
+	assert.Equal(t, 20*time.Millisecond, avg)
+	assert.Equal(t, 30*time.Millisecond, max)
+	assert.Equal(t, "12.5ms", fmtMillis(12500*time.Microsecond))
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// oauth1SetupHint is the command that stores OAuth1 credentials.
+const oauth1SetupHint = "xurl auth oauth1 --consumer-key KEY --consumer-secret SECRET --access-token TOKEN --token-secret SECRET"
+
+// CreateProfileCommand creates the `profile` command, whose subcommands
+// change the authenticated user's profile.
+func CreateProfileCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Update your profile, profile image and banner",
+		Long: `Change the authenticated user's profile. X only allows this through v1.1
+endpoints, which need OAuth1 credentials ('xurl auth oauth1'); xurl checks
+for them before sending anything and says which are missing. After each
+change the profile is fetched from /2/users/me and printed, to confirm it.`,
+		Example: `  xurl profile update --name "Release Bot" --bio "I post release notes"
+  xurl profile set-image avatar.png
+  xurl profile set-banner banner.jpg --crop 1500x500+0+0`,
+	}
+	cmd.AddCommand(profileUpdateCmd(a, g), profileSetImageCmd(a, g), profileSetBannerCmd(a, g))
+	return cmd
+}
+
+func profileUpdateCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Change your name, bio, location or URL",
+		Long: `Change the name, bio, location or URL of your profile through
+POST /1.1/account/update_profile.json. Only the flags given are changed; an
+empty value, e.g. --location "", clears a field.`,
+		Example: `  xurl profile update --name "Release Bot"
+  xurl profile update --bio "I post release notes" --url https://example.com
+  xurl profile update --location ""`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fields := url.Values{}
+			for flag, field := range api.ProfileFields {
+				if cmd.Flags().Changed(flag) {
+					value, _ := cmd.Flags().GetString(flag)
+					fields.Set(field, value)
+				}
+			}
+			requireProfileCredentials(a, g)
+			g.printResult(api.UpdateProfile(g.newClient(a), fields, g.profileOpts(cmd)))
+		},
+	}
+	cmd.Flags().String("name", "", "Display name (up to 50 characters)")
+	cmd.Flags().String("bio", "", "Bio (up to 160 characters)")
+	cmd.Flags().String("location", "", "Location (up to 30 characters)")
+	cmd.Flags().String("url", "", "Website URL (up to 100 characters)")
+	addProfileFlags(cmd)
+	return cmd
+}
+
+func profileSetImageCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-image FILE",
+		Short: "Change your profile image",
+		Long: `Upload FILE, a GIF, JPEG or PNG image under 700 KB, as your profile image
+through POST /1.1/account/update_profile_image.json, which takes it
+base64-encoded. The new image may take a moment to show up everywhere.`,
+		Example: `  xurl profile set-image avatar.png`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireProfileCredentials(a, g)
+			g.printResult(api.SetProfileImage(g.newClient(a), args[0], g.profileOpts(cmd)))
+		},
+	}
+	addProfileFlags(cmd)
+	return cmd
+}
+
+func profileSetBannerCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var crop string
+	cmd := &cobra.Command{
+		Use:   "set-banner FILE",
+		Short: "Change your profile banner",
+		Long: `Upload FILE, an image under 5 MB, as your profile banner through
+POST /1.1/account/update_profile_banner.json, which takes it as a multipart
+upload. X recommends 1500x500 pixels; --crop WxH+X+Y picks the region of a
+larger image to use, W by H pixels from X pixels in and Y pixels down.`,
+		Example: `  xurl profile set-banner banner.jpg
+  xurl profile set-banner photo.jpg --crop 1500x500+0+240`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var region *api.BannerCrop
+			if crop != "" {
+				c, err := api.ParseBannerCrop(crop)
+				if err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
+				}
+				region = &c
+			}
+			requireProfileCredentials(a, g)
+			g.printResult(api.SetProfileBanner(g.newClient(a), args[0], region, g.profileOpts(cmd)))
+		},
+	}
+	cmd.Flags().StringVar(&crop, "crop", "", "Use this region of the image, as WxH+X+Y, e.g. 1500x500+0+0")
+	addProfileFlags(cmd)
+	return cmd
+}
+
+// addProfileFlags adds the request flags of the profile commands. There is
+// no --auth or --username: the profile endpoints only take OAuth1.
+func addProfileFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("verbose", "v", false, "Print verbose request/response info")
+	cmd.Flags().BoolP("trace", "t", false, "Add X-B3-Flags trace header")
+}
+
+// profileOpts returns the request options of a profile command.
+func (g *globalOptions) profileOpts(cmd *cobra.Command) api.RequestOptions {
+	opts := g.baseOpts(cmd)
+	opts.AuthType = "oauth1"
+	return opts
+}
+
+// requireProfileCredentials exits with an error naming what is missing when
+// the app has no complete OAuth1 credentials.
+func requireProfileCredentials(a *auth.Auth, g *globalOptions) {
+	if a == nil || a.TokenStore == nil {
+		g.fprintError(os.Stderr, "Error: authentication module not initialized properly")
+		os.Exit(1)
+	}
+	appName := a.TokenStore.GetActiveAppName(a.AppName())
+	if err := checkOAuth1Credentials(a.TokenStore.GetOAuth1TokensForApp(a.AppName()), appName); err != nil {
+		g.fprintError(os.Stderr, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+// checkOAuth1Credentials returns an error saying which OAuth1 credentials of
+// the app named appName are missing from token, if any.
+func checkOAuth1Credentials(token *store.Token, appName string) error {
+	hint := oauth1SetupHint + appFlagHint(appName)
+	if token == nil || token.OAuth1 == nil {
+		return fmt.Errorf("profile changes need OAuth1 credentials, and app %q has none; run '%s'", appName, hint)
+	}
+	var missing, flags []string
+	for _, c := range []struct{ value, name, flag string }{
+		{token.OAuth1.ConsumerKey, "consumer key", "--consumer-key"},
+		{token.OAuth1.ConsumerSecret, "consumer secret", "--consumer-secret"},
+		{token.OAuth1.AccessToken, "access token", "--access-token"},
+		{token.OAuth1.TokenSecret, "token secret", "--token-secret"},
+	} {
+		if c.value == "" {
+			missing = append(missing, c.name)
+			flags = append(flags, c.flag)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the OAuth1 credentials of app %q lack the %s (%s); run '%s' to store them all again",
+		appName, strings.Join(missing, ", "), strings.Join(flags, ", "), hint)
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestCheckOAuth1Credentials(t *testing.T) {
+	complete := &store.OAuth1Token{ConsumerKey: "key", ConsumerSecret: "consumer-secret", AccessToken: "token", TokenSecret: "secret"}
+	assert.NoError(t, checkOAuth1Credentials(&store.Token{OAuth1: complete}, "default"))
+
+	err := checkOAuth1Credentials(nil, "prod")
+	require.Error(t, err)
+	assert.Equal(t, `profile changes need OAuth1 credentials, and app "prod" has none; run 'xurl auth oauth1 --consumer-key KEY --consumer-secret SECRET --access-token TOKEN --token-secret SECRET --app prod'`, err.Error())
+
+	partial := *complete
+	partial.ConsumerSecret, partial.TokenSecret = "", ""
+	err = checkOAuth1Credentials(&store.Token{OAuth1: &partial}, "default")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `the OAuth1 credentials of app "default" lack the consumer secret, token secret (--consumer-secret, --token-secret)`)
+}
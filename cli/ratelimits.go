@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// reportRateLimits writes what --explain-rate-limit recorded to w, once, at
+// the end of a run, whether it succeeded or failed.
+func (g *globalOptions) reportRateLimits(w io.Writer) {
+	if g.rateLimits == nil || !g.rateLimitsReported.CompareAndSwap(false, true) {
+		return
+	}
+	printRateLimits(w, g.rateLimits.Statuses(), time.Now())
+}
+
+// printRateLimits writes one line per endpoint family: the quota left and
+// when it resets, in local time and relative to now.
+func printRateLimits(w io.Writer, statuses []api.RateLimitStatus, now time.Time) {
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "Rate limits: no response carried rate-limit headers.")
+		return
+	}
+	fmt.Fprintln(w, "Rate limits:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ENDPOINT\tREMAINING\tRESETS")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "  %s\t%d/%d\t%s\n", s.Family, s.Remaining, s.Limit, describeReset(s.Reset, now))
+	}
+	tw.Flush()
+}
+
+// describeReset says when a quota resets: the local time, with the date when
+// it is not today, and how long from now.
+func describeReset(reset, now time.Time) string {
+	if reset.IsZero() {
+		return "unknown"
+	}
+	local := reset.Local()
+	at := local.Format("15:04:05 MST")
+	if y, m, d := local.Date(); y != now.Local().Year() || m != now.Local().Month() || d != now.Local().Day() {
+		at = local.Format("Jan 2 15:04:05 MST")
+	}
+	if !reset.After(now) {
+		return at + " (already reset)"
+	}
+	return fmt.Sprintf("%s (in %s)", at, reset.Sub(now).Round(time.Second))
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestPrintRateLimits(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)
+	var out bytes.Buffer
+	printRateLimits(&out, []api.RateLimitStatus{
+		{Family: "GET /2/users/:id/followers", Limit: 15, Remaining: 0, Reset: now.Add(12*time.Minute + 30*time.Second)},
+		{Family: "GET /2/users/me", Limit: 75, Remaining: 74, Reset: now.Add(-time.Minute)},
+		{Family: "POST /2/tweets", Limit: 100, Remaining: 99, Reset: now.Add(26 * time.Hour)},
+	}, now)
+
+	assert.Equal(t, "Rate limits:\n"+
+		"  ENDPOINT                    REMAINING  RESETS\n"+
+		"  GET /2/users/:id/followers  0/15       14:12:30 "+now.Format("MST")+" (in 12m30s)\n"+
+		"  GET /2/users/me             74/75      13:59:00 "+now.Format("MST")+" (already reset)\n"+
+		"  POST /2/tweets              99/100     Mar 11 16:00:00 "+now.Format("MST")+" (in 26h0m0s)\n", out.String())
+
+	out.Reset()
+	printRateLimits(&out, nil, now)
+	assert.Equal(t, "Rate limits: no response carried rate-limit headers.\n", out.String())
+}
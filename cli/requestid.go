@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+// resolveRequestID returns the --request-id value, or a new ID when none was
+// given.
+func resolveRequestID(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	return api.NewRequestID()
+}
+
+// markRequestSent is the request hook configureClient installs. It records
+// that at least one request carrying the request ID went out, so that
+// failures after it can quote the ID.
+func (g *globalOptions) markRequestSent(*http.Request) {
+	g.requestSent.Store(true)
+}
+
+// reportRequestID writes the invocation's request ID to w, once, when a
+// failing run has sent at least one request, so the user can quote it.
+func (g *globalOptions) reportRequestID(w io.Writer) {
+	if g.requestID == "" || !g.requestSent.Load() || !g.requestIDReported.CompareAndSwap(false, true) {
+		return
+	}
+	fmt.Fprintf(w, "Request ID: %s\n", g.requestID)
+}
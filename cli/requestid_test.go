@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRequestID(t *testing.T) {
+	id, err := resolveRequestID("my-id")
+	require.NoError(t, err)
+	assert.Equal(t, "my-id", id)
+
+	id, err = resolveRequestID("")
+	require.NoError(t, err)
+	assert.Len(t, id, 36, "a UUID is generated")
+}
+
+func TestReportRequestID(t *testing.T) {
+	g := newGlobalOptions()
+	g.requestID = "run-123"
+
+	var buf bytes.Buffer
+	g.reportRequestID(&buf)
+	assert.Empty(t, buf.String(), "nothing to quote before a request was sent")
+
+	g.markRequestSent(nil)
+	g.reportRequestID(&buf)
+	g.reportRequestID(&buf)
+	assert.Equal(t, "Request ID: run-123\n", buf.String(), "printed once")
+}
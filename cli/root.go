@@ -2,13 +2,23 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
 
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+	"github.com/xdevplatform/xurl/utils"
 	"github.com/xdevplatform/xurl/version"
 )
 
@@ -20,11 +30,114 @@ const (
 	groupManage = "manage"
 )
 
+// globalOptions holds what the global flags ask for. CreateRootCommand
+// makes one and hands it to every command it adds; the root command's
+// PersistentPreRun fills it in from the flags before any command runs, and
+// the commands read it when they build clients and request options and print
+// responses (see newClient, baseOpts and printResult).
+type globalOptions struct {
+	// noWarnings (--no-warnings) keeps deprecation and sunset notices from
+	// API responses off stderr.
+	noWarnings bool
+	// showAuthMethod (--show-auth-method) prints the credential each request
+	// is signed with to stderr.
+	showAuthMethod bool
+	// insecureRedirectAuth (--allow-insecure-redirect-auth) forwards the
+	// Authorization header across redirects to other hosts or to http.
+	insecureRedirectAuth bool
+	// retries (--retry) is how many times idempotent requests that fail with
+	// a 429 or 5xx are retried, waiting as retryAfter allows.
+	retries int
+	// retryAfter is set from --honor-retry-after and --retry-after-cap and
+	// used by every retrying wait.
+	retryAfter api.RetryAfterPolicy
+	// retryLog is the --retry-log file, opened for appending by
+	// PersistentPreRun and closed by PersistentPostRun; every retry is
+	// logged to it. nil logs nothing.
+	retryLog io.Writer
+	// transport is built from --pin-sha256 and --proxy-auth. API clients,
+	// the OAuth2 flows and the cli's other HTTP clients send through it; nil
+	// uses http.DefaultTransport.
+	transport http.RoundTripper
+	// maxHeaderSize (--max-header-size) is how many bytes of each header
+	// value verbose traces print.
+	maxHeaderSize int
+	// showErrorBody says whether API error bodies are printed. It starts
+	// from show_body_on_error in config.yml and is overridden by --fail and
+	// --fail-with-body.
+	showErrorBody bool
+	// fileHeaders are the headers read from --header-file (or config.yml's
+	// default_header_file), sent with every request.
+	fileHeaders []string
+	// responseCache is set from --cache-ttl and --no-cache; nil means no
+	// caching.
+	responseCache *api.ResponseCache
+	// dryRun (--dry-run), progress (--progress, nil for the default human
+	// output), locale (--accept-language, --tweet-lang and --place-country)
+	// and rich (--rich) are copied into the request options of the root
+	// command and every shortcut (see baseOpts).
+	dryRun   bool
+	progress api.ProgressReporter
+	locale   api.Locale
+	rich     bool
+	// format prints responses as --output-format, --jq, --redact,
+	// --preserve-order, the color flags and --sync-interval ask.
+	format *utils.Formatter
+	// rateLimits records the rate-limit headers of every response when
+	// --explain-rate-limit is set (see reportRateLimits).
+	rateLimits         *api.RateLimitTracker
+	rateLimitsReported atomic.Bool
+	// requestID identifies this invocation: it is --request-id, or else a
+	// generated ID, and is sent as the X-Request-ID header of every request
+	// the command makes (see reportRequestID).
+	requestID         string
+	requestSent       atomic.Bool
+	requestIDReported atomic.Bool
+	// log is the --log file, opened for appending by PersistentPreRun and
+	// closed by PersistentPostRun; the notices of every response and the
+	// error a command fails with are appended to it (see logRecord). nil
+	// logs nothing.
+	log io.Writer
+	// jsonErrors (--error-format json) makes fprintError print errors as
+	// JSON records.
+	jsonErrors bool
+	// notices are the notices of every response so far (see recordNotices);
+	// logMu guards them and writes to log.
+	notices []api.Notice
+	logMu   sync.Mutex
+}
+
+// newGlobalOptions returns the settings in effect when no global flag is
+// given.
+func newGlobalOptions() *globalOptions {
+	return &globalOptions{
+		maxHeaderSize: api.DefaultMaxHeaderSize,
+		showErrorBody: true,
+		format:        utils.NewFormatter(),
+	}
+}
+
+// autoIdempotencyKey is the value of a bare --idempotency-key, asking for a
+// generated key.
+const autoIdempotencyKey = "auto"
+
+// progressInterval bounds how often --progress json reports a phase.
+const progressInterval = 250 * time.Millisecond
+
 // CreateRootCommand creates the root command for the xurl CLI
 func CreateRootCommand(cfg *config.Config, a *auth.Auth) *cobra.Command {
+	g := newGlobalOptions()
 	var rootCmd = &cobra.Command{
-		Use:     "xurl [flags] URL",
-		Short:   "Auth enabled curl-like interface for the X API",
+		Use:   "xurl [flags] URL",
+		Short: "Auth enabled curl-like interface for the X API",
+		Example: `  xurl /2/users/me
+  xurl -X POST /2/tweets -d '{"text":"Hello world!"}'
+  xurl --auth app "/2/tweets/search/recent?query=golang"
+  xurl --auth app /2/tweets/search/stream
+  xurl --backfill --max-pages 20 /2/users/123/mentions
+  xurl -o me.json /2/users/me
+  xurl --accept csv -o report.csv /2/some/report
+  xurl --paginate --resume-file state.json -o followers.ndjson "/2/users/123/followers?max_results=1000"`,
 		Version: version.Version,
 		Long: `A command-line tool for making authenticated requests to the X API.
 
@@ -43,11 +156,235 @@ Authentication:
 
 Commands are grouped by purpose below. Run 'xurl <command> --help' for details.`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Apply --env-file before anything reads the configuration
+			envFile, _ := cmd.Flags().GetString("env-file")
+			envOverride, _ := cmd.Flags().GetBool("env-file-override")
+			if envOverride && envFile == "" {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --env-file-override requires --env-file\033[0m")
+				os.Exit(1)
+			}
+			if envFile != "" {
+				if err := config.LoadDotEnv(envFile, envOverride, os.Stderr); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				*cfg = *config.NewConfigForApp(cfg.AppName)
+				a.WithConfig(cfg)
+			}
+			// Apply --store-path (or an XURL_STORE from --env-file): rebuild the
+			// auth against that token store before anything reads credentials
+			if storePath, _ := cmd.Flags().GetString("store-path"); storePath != "" {
+				cfg.StorePath = storePath
+			}
+			if cfg.StorePath != "" && cfg.StorePath != a.TokenStore.FilePath {
+				cfg.RedirectURI, cfg.RedirectURIFromEnv, _ = config.ResolveRedirectURI(cfg.StorePath, cfg.AppName)
+				*a = *auth.NewAuth(cfg)
+			}
+			// Apply --read-only-store before anything could save the store
+			if readOnlyStore, _ := cmd.Flags().GetBool("read-only-store"); readOnlyStore {
+				a.TokenStore.SetReadOnly("--read-only-store is set")
+			}
+			// Import ~/.twurlrc unless --no-twurl-import or
+			// XURL_NO_TWURL_IMPORT opts out
+			if noTwurlImport, _ := cmd.Flags().GetBool("no-twurl-import"); !noTwurlImport && !cfg.NoTwurlImport {
+				a.TokenStore.AutoImportTwurlrc()
+			}
 			// Apply --app override if provided
 			appOverride, _ := cmd.Flags().GetString("app")
 			if appOverride != "" {
 				a.WithAppName(appOverride)
 			}
+			if debugOAuth1, _ := cmd.Flags().GetBool("debug-oauth1"); debugOAuth1 {
+				a.WithOAuth1Debug(os.Stderr)
+			}
+			// Apply --strict-auth (disables the credential fallback chain)
+			if strictAuth, _ := cmd.Flags().GetBool("strict-auth"); strictAuth {
+				a.WithStrictAuth(true)
+			}
+			// Build the transport of --pin-sha256 and --proxy-auth. It is given
+			// to every HTTP client xurl builds (API requests, OAuth2 token
+			// exchange, chat); http.DefaultTransport itself is left alone.
+			var transport *http.Transport
+			if pinValues, _ := cmd.Flags().GetStringArray("pin-sha256"); len(pinValues) > 0 {
+				pins, err := api.ParsePublicKeyPins(pinValues)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				transport = api.PinTransport(api.CloneDefaultTransport(), pins)
+			}
+			// Add --proxy-auth (or XURL_PROXY_AUTH, which keeps the password
+			// out of shell history) to the same transport.
+			proxyAuth, _ := cmd.Flags().GetString("proxy-auth")
+			if proxyAuth == "" {
+				proxyAuth = os.Getenv("XURL_PROXY_AUTH")
+			}
+			if proxyAuth != "" {
+				user, password, err := api.ParseProxyAuth(proxyAuth)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				if transport == nil {
+					transport = api.CloneDefaultTransport()
+				}
+				transport = api.ProxyAuthTransport(transport, user, password)
+			}
+			g.transport = nil
+			if transport != nil {
+				g.transport = transport
+			}
+			a.WithTransport(g.transport)
+			headerFile, _ := cmd.Flags().GetString("header-file")
+			if headerFile == "" {
+				headerFile = cfg.DefaultHeaderFile
+			}
+			if headerFile != "" {
+				headers, err := api.ReadHeaderFile(headerFile)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				g.fileHeaders = headers
+			}
+			g.noWarnings, _ = cmd.Flags().GetBool("no-warnings")
+			g.showAuthMethod, _ = cmd.Flags().GetBool("show-auth-method")
+			g.maxHeaderSize, _ = cmd.Flags().GetInt("max-header-size")
+			g.insecureRedirectAuth, _ = cmd.Flags().GetBool("allow-insecure-redirect-auth")
+			g.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			g.rich, _ = cmd.Flags().GetBool("rich")
+			honorRetryAfter, _ := cmd.Flags().GetBool("honor-retry-after")
+			g.retryAfter.Ignore = !honorRetryAfter
+			g.retryAfter.Cap, _ = cmd.Flags().GetDuration("retry-after-cap")
+			if g.retryAfter.Cap < 0 {
+				fmt.Fprintf(os.Stderr, "\033[31mError: --retry-after-cap must not be negative\033[0m\n")
+				os.Exit(1)
+			}
+			g.retries, _ = cmd.Flags().GetInt("retry")
+			if g.retries < 0 {
+				fmt.Fprintf(os.Stderr, "\033[31mError: --retry must not be negative\033[0m\n")
+				os.Exit(1)
+			}
+			if path, _ := cmd.Flags().GetString("retry-log"); path != "" {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: could not open --retry-log: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				g.retryLog = f
+			}
+			switch errorFormat, _ := cmd.Flags().GetString("error-format"); errorFormat {
+			case "text":
+			case "json":
+				g.jsonErrors = true
+			default:
+				fmt.Fprintf(os.Stderr, "\033[31mError: --error-format must be text or json\033[0m\n")
+				os.Exit(1)
+			}
+			if path, _ := cmd.Flags().GetString("log"); path != "" {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: could not open --log: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				g.log = f
+			}
+			cacheTTL, _ := cmd.Flags().GetDuration("cache-ttl")
+			if cacheTTL < 0 {
+				fmt.Fprintf(os.Stderr, "\033[31mError: --cache-ttl must not be negative\033[0m\n")
+				os.Exit(1)
+			}
+			g.rateLimits = nil
+			if explain, _ := cmd.Flags().GetBool("explain-rate-limit"); explain {
+				g.rateLimits = api.NewRateLimitTracker()
+			}
+			g.responseCache = nil
+			if noCache, _ := cmd.Flags().GetBool("no-cache"); cacheTTL > 0 && !noCache {
+				g.responseCache = api.NewResponseCache(store.ResponseCacheDir(), cacheTTL)
+			}
+			g.locale.AcceptLanguage, _ = cmd.Flags().GetString("accept-language")
+			g.locale.TweetLang, _ = cmd.Flags().GetString("tweet-lang")
+			g.locale.PlaceCountry, _ = cmd.Flags().GetString("place-country")
+			switch mode, _ := cmd.Flags().GetString("progress"); mode {
+			case "":
+				g.progress = nil
+			case "json":
+				g.progress = api.NewJSONProgressReporter(os.Stderr, progressInterval)
+			default:
+				fmt.Fprintf(os.Stderr, "\033[31mError: unknown --progress mode %q (want json)\033[0m\n", mode)
+				os.Exit(1)
+			}
+			var err error
+			g.showErrorBody, err = resolveShowErrorBody(cfg.ShowBodyOnError, cmd.Flags().Changed("fail"), cmd.Flags().Changed("fail-with-body"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			flagRequestID, _ := cmd.Flags().GetString("request-id")
+			if g.requestID, err = resolveRequestID(flagRequestID); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			jq, _ := cmd.Flags().GetString("jq")
+			if err := g.format.SetJQ(jq); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			redact, _ := cmd.Flags().GetString("redact")
+			g.format.SetRedactFields(utils.ParseRedactFields(redact))
+			// Colors are on only for a terminal, unless --no-color or
+			// --force-color says otherwise
+			noColor, _ := cmd.Flags().GetBool("no-color")
+			forceColor, _ := cmd.Flags().GetBool("force-color")
+			switch {
+			case noColor && forceColor:
+				fmt.Fprintln(os.Stderr, "\033[31mError: --no-color and --force-color cannot be combined\033[0m")
+				os.Exit(1)
+			case noColor:
+				g.format.SetColorMode(utils.ColorNever)
+			case forceColor:
+				g.format.SetColorMode(utils.ColorAlways)
+			}
+			preserveOrder, _ := cmd.Flags().GetBool("preserve-order")
+			g.format.SetPreserveOrder(preserveOrder)
+			outputFormat, _ := cmd.Flags().GetString("output-format")
+			if err := g.format.SetOutputFormat(outputFormat); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			if cmd.Flags().Changed("sync-interval") {
+				if !g.format.IsJSONL() {
+					fmt.Fprintf(os.Stderr, "\033[31mError: --sync-interval needs --output-format jsonl\033[0m\n")
+					os.Exit(1)
+				}
+				interval, _ := cmd.Flags().GetString("sync-interval")
+				policy, err := utils.ParseSyncPolicy(interval)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				g.format.SetSyncPolicy(policy)
+			}
+			if copyOutput, _ := cmd.Flags().GetBool("copy"); copyOutput {
+				g.format.StartCapture()
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if f, ok := g.retryLog.(io.Closer); ok {
+				f.Close()
+				g.retryLog = nil
+			}
+			if f, ok := g.log.(io.Closer); ok {
+				f.Close()
+				g.log = nil
+			}
+			g.reportRateLimits(os.Stderr)
+			if copyOutput, _ := cmd.Flags().GetBool("copy"); copyOutput {
+				if err := copyCapturedOutput(g.format); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+			}
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
 			return nil
@@ -56,11 +393,19 @@ Commands are grouped by purpose below. Run 'xurl <command> --help' for details.`
 			headers, _ := cmd.Flags().GetStringArray("header")
 			data, _ := cmd.Flags().GetString("data")
 
+			getData, _ := cmd.Flags().GetBool("get")
+			urlencoded, _ := cmd.Flags().GetStringArray("data-urlencode")
 			method, _ := cmd.Flags().GetString("method")
+			// -X get works like -X GET; the checks below compare upper case.
+			method = strings.ToUpper(method)
+			dataTemplate, _ := cmd.Flags().GetString("data-template")
+			hasBody := cmd.Flags().Changed("data") || cmd.Flags().Changed("field") || cmd.Flags().Changed("data-binary") || dataTemplate != ""
 			if method == "" {
 				// Mirror curl: providing a request body (-d/--data) implies POST
-				// unless -X says otherwise — even for an explicitly empty body.
-				if cmd.Flags().Changed("data") {
+				// unless -X or -G says otherwise — even for an explicitly empty body.
+				if getData {
+					method = "GET"
+				} else if hasBody {
 					method = "POST"
 				} else {
 					method = "GET"
@@ -72,7 +417,27 @@ Commands are grouped by purpose below. Run 'xurl <command> --help' for details.`
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			trace, _ := cmd.Flags().GetBool("trace")
 			forceStream, _ := cmd.Flags().GetBool("stream")
+			streamJSONArray, _ := cmd.Flags().GetBool("stream-json-array")
 			mediaFile, _ := cmd.Flags().GetString("file")
+			fields, _ := cmd.Flags().GetStringArray("field")
+			printBody, _ := cmd.Flags().GetBool("print-body")
+			validateOnly, _ := cmd.Flags().GetBool("validate-only")
+			dataBinary, _ := cmd.Flags().GetString("data-binary")
+			chunked, _ := cmd.Flags().GetBool("chunked-request")
+			charset, _ := cmd.Flags().GetString("charset")
+			sinceID, _ := cmd.Flags().GetString("since-id")
+			untilID, _ := cmd.Flags().GetString("until-id")
+			backfill, _ := cmd.Flags().GetBool("backfill")
+			maxPages, _ := cmd.Flags().GetInt("max-pages")
+			printNewestID, _ := cmd.Flags().GetBool("print-newest-id")
+			paginate, _ := cmd.Flags().GetBool("paginate")
+			resumeFile, _ := cmd.Flags().GetString("resume-file")
+			output, _ := cmd.Flags().GetString("output")
+			outputAppend, _ := cmd.Flags().GetBool("output-append")
+			graceful, _ := cmd.Flags().GetBool("graceful-rate-limit")
+			maxItems, _ := cmd.Flags().GetInt("max-items")
+			expandTemplate, _ := cmd.Flags().GetBool("expand-template")
+			templateVars, _ := cmd.Flags().GetStringArray("var")
 
 			if len(args) == 0 {
 				fmt.Fprintln(os.Stderr, "No URL provided")
@@ -81,40 +446,280 @@ Commands are grouped by purpose below. Run 'xurl <command> --help' for details.`
 				os.Exit(1)
 			}
 
-			url := args[0]
+			endpoint := args[0]
+			if len(templateVars) > 0 && !expandTemplate && dataTemplate == "" {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --var needs --expand-template or --data-template\033[0m")
+				os.Exit(1)
+			}
+			if dataTemplate != "" && (cmd.Flags().Changed("data") || cmd.Flags().Changed("field") || cmd.Flags().Changed("data-binary") || getData) {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --data-template cannot be combined with -d, --field, --data-binary or --get\033[0m")
+				os.Exit(1)
+			}
+			vars, err := api.ParseTemplateVars(templateVars)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			// Only the -d value itself names a file: bodies from
+			// --data-binary, templates or a file's contents are never
+			// resolved again.
+			if data, err = api.ReadDataArg(data, os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			if expandTemplate {
+				expanded := api.RequestOptions{Endpoint: endpoint, Headers: headers, Data: data}
+				if err := api.ExpandRequestTemplates(&expanded, vars); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				endpoint, headers, data = expanded.Endpoint, expanded.Headers, expanded.Data
+			}
+			if dataTemplate != "" {
+				if data, err = api.RenderBodyTemplate(dataTemplate, vars); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+			}
+
+			url, err := api.SetIDWindow(endpoint, sinceID, untilID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+
+			if len(urlencoded) > 0 && !getData {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --data-urlencode needs --get (-G)\033[0m")
+				os.Exit(1)
+			}
+			if getData {
+				if cmd.Flags().Changed("field") || cmd.Flags().Changed("data-binary") || chunked {
+					fmt.Fprintln(os.Stderr, "\033[31mError: --get only takes -d and --data-urlencode, not --field or --data-binary\033[0m")
+					os.Exit(1)
+				}
+				if url, err = api.AppendQueryData(url, data, urlencoded); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				data = ""
+			}
+
+			var bodyReader io.ReadCloser
+			if cmd.Flags().Changed("data-binary") || chunked {
+				if cmd.Flags().Changed("data") {
+					fmt.Fprintf(os.Stderr, "\033[31mError: -d and --data-binary cannot be combined\033[0m\n")
+					os.Exit(1)
+				}
+				var err error
+				data, bodyReader, err = dataBinaryBody(dataBinary, chunked, os.Stdin)
+				if err == nil && charset != "" {
+					data, bodyReader, err = transcodeBody(data, bodyReader, charset)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				if bodyReader != nil {
+					defer bodyReader.Close()
+				}
+			} else if charset != "" {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --charset needs --data-binary\033[0m")
+				os.Exit(1)
+			}
+
+			if method == "DELETE" && hasBody {
+				fmt.Fprintln(os.Stderr, "\033[33mWarning: DELETE requests are sent without a body; the request body is ignored\033[0m")
+			}
+			if method == "GET" && !getData && hasBody {
+				fmt.Fprintln(os.Stderr, "\033[33mWarning: GET requests are sent without a body; the request body is ignored. Use --get (-G) to send -d as query parameters\033[0m")
+			}
+			if cmd.Flags().Changed("idempotency-key") {
+				key, _ := cmd.Flags().GetString("idempotency-key")
+				if key == autoIdempotencyKey {
+					key = ""
+				}
+				headers, key, err = api.AddIdempotencyKey(headers, key)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				// Printed so a failed request can be retried with the same key.
+				fmt.Fprintf(os.Stderr, "%s: %s\n", api.IdempotencyKeyHeader, key)
+			}
 
-			client := api.NewApiClient(cfg, a)
+			client := g.configureClient(api.NewApiClient(cfg, a))
 
 			requestOptions := api.RequestOptions{
-				Method:   method,
-				Endpoint: url,
-				Headers:  headers,
-				Data:     data,
-				AuthType: authType,
-				Username: username,
-				Verbose:  verbose,
-				Trace:    trace,
-			}
-			err := api.HandleRequest(requestOptions, forceStream, mediaFile, client)
+				Method:          method,
+				Endpoint:        url,
+				Headers:         headers,
+				Data:            data,
+				AuthType:        authType,
+				Username:        username,
+				Verbose:         verbose,
+				Trace:           trace,
+				Fields:          fields,
+				PrintBody:       printBody,
+				ValidateOnly:    validateOnly,
+				HideErrorBody:   !g.showErrorBody,
+				StreamJSONArray: streamJSONArray,
+				DryRun:          g.dryRun,
+				Progress:        g.progress,
+				Locale:          g.locale,
+				Rich:            g.rich,
+				Format:          g.format,
+			}
+			if bodyReader != nil {
+				requestOptions.BodyReader = bodyReader
+			}
+			if accept, _ := cmd.Flags().GetString("accept"); accept != "" {
+				if requestOptions.Accept, err = api.ResolveAccept(accept); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				// Reshaping and paging only make sense for JSON; a response in
+				// the negotiated type is printed or saved verbatim instead.
+				if api.AcceptsNonJSON(requestOptions.Accept) && (g.format.IsNDJSON() || cmd.Flags().Changed("jq") || paginate || backfill || printNewestID) {
+					fmt.Fprintf(os.Stderr, "\033[31mError: --accept %s asks for a response that is not JSON; it cannot be combined with --output-format ndjson/jsonl, --jq, --paginate, --backfill or --print-newest-id\033[0m\n", accept)
+					os.Exit(1)
+				}
+			}
+			if (resumeFile != "" || graceful || maxItems != 0) && !paginate {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --resume-file, --max-items and --graceful-rate-limit need --paginate\033[0m")
+				os.Exit(1)
+			}
+			if maxItems < 0 {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --max-items must be positive\033[0m")
+				os.Exit(1)
+			}
+			if outputAppend && output == "" {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --output-append needs -o/--output\033[0m")
+				os.Exit(1)
+			}
+			if paginate {
+				if method != "GET" || forceStream || streamJSONArray || mediaFile != "" || backfill || printNewestID {
+					fmt.Fprintln(os.Stderr, "\033[31mError: --paginate only works with plain GET requests, without --backfill or --print-newest-id\033[0m")
+					os.Exit(1)
+				}
+				if code := g.paginateRequest(requestOptions, client, resumeFile, output, maxItems, graceful); code != 0 {
+					g.reportRateLimits(os.Stderr)
+					os.Exit(code)
+				}
+				return
+			}
+			if output != "" {
+				if backfill || printNewestID || printBody || validateOnly || cmd.Flags().Changed("jq") || cmd.Flags().Changed("copy") {
+					fmt.Fprintln(os.Stderr, "\033[31mError: --output writes the raw response body; it cannot be combined with --backfill, --print-newest-id, --print-body, --validate-only, --jq or --copy\033[0m")
+					os.Exit(1)
+				}
+				requestOptions.OutputFile = output
+				requestOptions.OutputAppend = outputAppend
+			}
+			if backfill || printNewestID {
+				if method != "GET" || forceStream || streamJSONArray || mediaFile != "" {
+					fmt.Fprintln(os.Stderr, "\033[31mError: --backfill and --print-newest-id only work with plain GET requests\033[0m")
+					os.Exit(1)
+				}
+				newest, err := api.ExecuteTimelineRequest(requestOptions, client, backfill, maxPages)
+				if err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
+				}
+				if printNewestID {
+					// With nothing newer, hand back the cursor we were given.
+					if newest == "" {
+						newest = sinceID
+					}
+					fmt.Fprintln(os.Stderr, newest)
+				}
+				return
+			}
+			err = api.HandleRequest(requestOptions, forceStream, mediaFile, client)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				g.fprintError(os.Stderr, "Error: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
-	// Global persistent flag: --app
+	// Global flags, inherited by every subcommand.
+	rootCmd.PersistentFlags().String("accept-language", "", "Send this Accept-Language header, e.g. en-US (-H Accept-Language wins)")
+	rootCmd.PersistentFlags().Bool("allow-insecure-redirect-auth", false, "Forward the Authorization header across redirects to another host or from https to http, where it is dropped by default (only for trusted gateways)")
 	rootCmd.PersistentFlags().String("app", "", "Use a specific registered app (overrides default)")
+	rootCmd.PersistentFlags().Duration("cache-ttl", 0, "Serve identical GET requests from an on-disk cache of successful responses younger than this, e.g. 60s (0 means no caching)")
+	rootCmd.PersistentFlags().Bool("copy", false, "Copy the final response to the system clipboard")
+	rootCmd.PersistentFlags().Bool("explain-rate-limit", false, "At the end of the run, print the remaining quota of every endpoint called and when it resets, to stderr")
+	rootCmd.PersistentFlags().Bool("fail", false, "Do not print the body of an API error response (overrides show_body_on_error)")
+	rootCmd.PersistentFlags().Bool("fail-with-body", false, "Print the body of an API error response (overrides show_body_on_error)")
+	rootCmd.PersistentFlags().Bool("debug-oauth1", false, "Print the OAuth1 signature base string and other signing inputs to stderr (secrets are redacted)")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the request that would be sent (method, URL, headers, body) instead of sending it; lookups of user IDs still run")
+	rootCmd.PersistentFlags().String("env-file", "", "Load environment variables from this file (./.env is loaded automatically)")
+	rootCmd.PersistentFlags().Bool("env-file-override", false, "Let --env-file values replace variables already set in the environment")
+	rootCmd.PersistentFlags().Bool("force-color", false, "Color output even when it is not written to a terminal, e.g. for 'less -R'")
+	rootCmd.PersistentFlags().String("header-file", "", "Send the headers in this file (one 'Name: value' per line) with every request; -H wins on conflict")
+	rootCmd.PersistentFlags().Bool("honor-retry-after", true, "Wait as long as Retry-After or a rate-limit reset says before retrying; =false uses exponential backoff instead")
+	rootCmd.PersistentFlags().String("jq", "", "Run this jq expression over the response (or each NDJSON line or streamed message) and print its outputs, e.g. '.data[].id'")
+	rootCmd.PersistentFlags().Int("max-header-size", api.DefaultMaxHeaderSize, "In verbose output, cut header values longer than this many bytes short, noting their full length (0 prints them whole)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Ignore --cache-ttl: send every request and cache nothing")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Never color output (NO_COLOR=1 works too)")
+	rootCmd.PersistentFlags().Bool("no-twurl-import", false, "Do not import credentials from ~/.twurlrc automatically (env: XURL_NO_TWURL_IMPORT); see 'auth import'")
+	rootCmd.PersistentFlags().Bool("no-warnings", false, "Do not print deprecation/sunset notices from API responses")
+	rootCmd.PersistentFlags().String("output-format", utils.OutputJSON, "Output format: json (pretty-printed), ndjson (one line per item of a list response, streamed across pages) or jsonl (ndjson, with stream captures to a file synced to disk; see --sync-interval)")
+	rootCmd.PersistentFlags().StringArray("pin-sha256", nil, "Require the server's public key to match this base64 SHA-256 pin (repeatable, or ';'-separated)")
+	rootCmd.PersistentFlags().String("place-country", "", "Search endpoints: only match posts tagged with a place in this country code, e.g. US (adds place_country: to the query)")
+	rootCmd.PersistentFlags().Bool("preserve-order", false, "Pretty-print responses with object keys in the order the server sent them")
+	rootCmd.PersistentFlags().String("progress", "", "Report progress of uploads, pagination and streams as JSON lines on stderr (json); see README for the event schema")
+	rootCmd.PersistentFlags().String("proxy-auth", "", "Authenticate to the HTTPS_PROXY/HTTP_PROXY proxy with user:password (Basic; env: XURL_PROXY_AUTH)")
+	rootCmd.PersistentFlags().Bool("read-only-store", false, "Never write the token store: keep changes such as refreshed tokens in memory for this run (env: XURL_READONLY_STORE)")
+	rootCmd.PersistentFlags().String("redact", "", "Mask these response fields with *** before printing, e.g. email,phone (preset: pii)")
+	rootCmd.PersistentFlags().String("request-id", "", "Send this ID as the X-Request-ID header of every request the command makes, and print it if the command fails (default: a random UUID)")
+	rootCmd.PersistentFlags().Int("retry", 0, "Retry GET, HEAD, OPTIONS, PUT and DELETE requests, and any with an Idempotency-Key, up to N times when they fail with a 429 or 5xx, waiting as the rate-limit reset or Retry-After says, or else backing off exponentially")
+	rootCmd.PersistentFlags().Duration("retry-after-cap", 0, "Wait at most this long when Retry-After or a rate-limit reset asks for longer, e.g. 30s (0 means no cap)")
+	rootCmd.PersistentFlags().String("retry-log", "", "Append a line to this file for every --retry attempt: time, attempt number, reason and backoff")
+	rootCmd.PersistentFlags().String("log", "", "Append a JSON line to this file for the deprecation and warning notices of every response and for the error a command fails with")
+	rootCmd.PersistentFlags().String("error-format", "text", "How errors are printed to stderr: text, or json for one JSON object per error with the request ID and notices")
+	rootCmd.PersistentFlags().Bool("rich", false, "Request useful default fields and expansions (created_at, author_id, public_metrics, ...) for common tweet and user endpoints, unless the URL sets them")
+	rootCmd.PersistentFlags().Bool("show-auth-method", false, "Print which credential signs each request to stderr, e.g. 'using oauth2 (username: alice)' or 'using bearer'")
+	rootCmd.PersistentFlags().String("store-path", "", "Read and write tokens in this file instead of the default auth.yml (env: XURL_STORE)")
+	rootCmd.PersistentFlags().Bool("strict-auth", false, "Fail instead of falling back to another stored credential when the selected one is unusable")
+	rootCmd.PersistentFlags().String("sync-interval", "1s", "With --output-format jsonl, sync a stream capture to disk every N records, every duration, or both (e.g. 100, 5s, 100,5s)")
+	rootCmd.PersistentFlags().String("tweet-lang", "", "Search endpoints: only match posts in this language, e.g. en (adds lang: to the query)")
 
 	rootCmd.Flags().StringP("method", "X", "", "HTTP method (GET by default, POST when -d is given)")
 	rootCmd.Flags().StringArrayP("header", "H", []string{}, "Request headers")
-	rootCmd.Flags().StringP("data", "d", "", "Request body data")
+	rootCmd.Flags().String("accept", "", "Ask for this response type: json, csv, txt or a full media type (-H Accept wins); other types than JSON are printed or saved verbatim")
+	rootCmd.Flags().StringP("data", "d", "", "Request body data; @FILE reads it from FILE and @- from stdin")
+	rootCmd.Flags().BoolP("get", "G", false, "Send -d name=value pairs and --data-urlencode values as query parameters of a GET request, like curl -G")
+	rootCmd.Flags().StringArray("data-urlencode", nil, "With --get, add a query parameter, URL-encoding the value: name=value or just value (repeatable)")
+	rootCmd.Flags().StringArrayP("field", "f", []string{}, "Add a JSON body field: key=value (string) or key:=json (raw JSON)")
+	rootCmd.Flags().String("data-binary", "", "Request body sent as-is; @FILE reads a file and @- reads stdin")
+	rootCmd.Flags().String("charset", "", "Transcode the --data-binary body from this character set to UTF-8 before sending it, e.g. latin1 or windows-1252")
+	rootCmd.Flags().Bool("chunked-request", false, "Stream the --data-binary @FILE/@- body with chunked transfer encoding instead of buffering it")
+	rootCmd.Flags().Bool("expand-template", false, "Render the URL, header values and -d body as Go templates over the --var values, e.g. '/2/users/{{.id}}'")
+	rootCmd.Flags().String("data-template", "", "Render this Go template file, with --var values, env, readFile and date helpers, and send it as the JSON request body")
+	rootCmd.Flags().StringArray("var", nil, "With --expand-template or --data-template, set a template variable: name=value (repeatable)")
+	rootCmd.Flags().Bool("print-body", false, "Print the constructed request body and exit without sending")
+	rootCmd.Flags().Bool("validate-only", false, "Check that the request body (-d, --field or --data-binary) is valid JSON, reporting the line and column of any error, and exit without sending")
 	rootCmd.Flags().String("auth", "", "Authentication type (oauth1, oauth2, or app)")
 	rootCmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
 	rootCmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
 	rootCmd.Flags().BoolP("trace", "t", false, "Add trace header to request")
 	rootCmd.Flags().BoolP("stream", "s", false, "Force streaming mode for non-streaming endpoints")
+	rootCmd.Flags().Bool("stream-json-array", false, "Stream a response that is one large JSON array, printing each element as it arrives (implies --stream)")
 	rootCmd.Flags().StringP("file", "F", "", "File to upload (for multipart requests)")
+	rootCmd.Flags().String("idempotency-key", "", "Send an Idempotency-Key header: a random UUID, or the value given as --idempotency-key=VALUE")
+	rootCmd.Flags().Lookup("idempotency-key").NoOptDefVal = autoIdempotencyKey
+	rootCmd.Flags().String("since-id", "", "Only return posts newer than this ID (sets the since_id query parameter)")
+	rootCmd.Flags().String("until-id", "", "Only return posts older than this ID (sets the until_id query parameter)")
+	rootCmd.Flags().Bool("backfill", false, "Walk a timeline backwards with until_id, merging pages until an empty page or --max-pages")
+	rootCmd.Flags().Int("max-pages", 10, "Maximum number of pages fetched by --backfill")
+	rootCmd.Flags().Bool("paginate", false, "Follow meta.next_token through every page of a GET request, printing each page's items as NDJSON lines as they arrive")
+	rootCmd.Flags().Int("max-items", 0, "With --paginate, stop after this many items, cutting the last page short")
+	rootCmd.Flags().Bool("graceful-rate-limit", false, "With --paginate, wait for a rate limit to reset, with a countdown on stderr, and carry on instead of stopping")
+	rootCmd.Flags().String("resume-file", "", "With --paginate, keep the pagination cursor in this file and resume from it on the next run")
+	rootCmd.Flags().StringP("output", "o", "", "Write the raw response body to this file, replacing it, instead of printing it (streams line by line; with --paginate, append the items as NDJSON lines)")
+	rootCmd.Flags().Bool("output-append", false, "With -o, append to the file instead of replacing it, ending each response with a newline (--paginate always appends)")
+	rootCmd.Flags().Bool("print-newest-id", false, "Print the newest post ID seen to stderr, for use as the next --since-id")
 
 	// Organise subcommands into scannable help sections.
 	rootCmd.AddGroup(
@@ -124,28 +729,160 @@ Commands are grouped by purpose below. Run 'xurl <command> --help' for details.`
 		&cobra.Group{ID: groupManage, Title: "Management:"},
 	)
 
-	chatCmd := CreateChatCommand(a)
+	chatCmd := CreateChatCommand(a, g)
 	chatCmd.GroupID = groupWrite
 	rootCmd.AddCommand(chatCmd)
 
-	authCmd := CreateAuthCommand(a)
-	mediaCmd := CreateMediaCommand(a)
+	tweetsCmd := CreateTweetsCommand(a, g)
+	tweetsCmd.GroupID = groupWrite
+	rootCmd.AddCommand(tweetsCmd)
+
+	threadCmd := CreateThreadCommand(a, g)
+	threadCmd.GroupID = groupWrite
+	rootCmd.AddCommand(threadCmd)
+
+	usersCmd := CreateUsersCommand(a, g)
+	usersCmd.GroupID = groupSocial
+	rootCmd.AddCommand(usersCmd)
+
+	spacesCmd := CreateSpacesCommand(a, g)
+	spacesCmd.GroupID = groupRead
+	rootCmd.AddCommand(spacesCmd)
+
+	listsCmd := CreateListsCommand(a, g)
+	listsCmd.GroupID = groupRead
+	rootCmd.AddCommand(listsCmd)
+
+	bookmarksCmd := CreateBookmarksCommand(a, g)
+	bookmarksCmd.GroupID = groupRead
+	rootCmd.AddCommand(bookmarksCmd)
+
+	profileCmd := CreateProfileCommand(a, g)
+	profileCmd.GroupID = groupSocial
+	rootCmd.AddCommand(profileCmd)
+
+	authCmd := CreateAuthCommand(a, g)
+	batchCmd := CreateBatchCommand(a, g)
+	cacheCmd := CreateCacheCommand()
+	chainCmd := CreateChainCommand(a, g)
+	complianceCmd := CreateComplianceCommand(a, g)
+	diffCmd := CreateDiffCommand(a, g)
+	endpointsCmd := CreateEndpointsCommand()
+	mediaCmd := CreateMediaCommand(a, g)
 	versionCmd := CreateVersionCommand()
 	webhookCmd := CreateWebhookCommand(a)
-	tokenCmd := CreateTokenCommand(a)
-	mcpCmd := CreateMCPCommand(a)
-	for _, c := range []*cobra.Command{authCmd, mediaCmd, tokenCmd, mcpCmd, versionCmd, webhookCmd} {
+	tokenCmd := CreateTokenCommand(a, g)
+	mcpCmd := CreateMCPCommand(a, g)
+	scheduleCmd := CreateScheduleCommand(a, g)
+	configCmd := CreateConfigCommand(a)
+	doctorCmd := CreateDoctorCommand(a, cfg, g)
+	initCmd := CreateInitCommand(a, g)
+	pingCmd := CreatePingCommand(a, cfg, g)
+	specCmd := CreateSpecCommand(a, cfg, g)
+	usageCmd := CreateUsageCommand(a, g)
+	for _, c := range []*cobra.Command{authCmd, batchCmd, cacheCmd, chainCmd, complianceCmd, configCmd, diffCmd, doctorCmd, endpointsCmd, initCmd, mediaCmd, pingCmd, tokenCmd, mcpCmd, scheduleCmd, specCmd, usageCmd, versionCmd, webhookCmd} {
 		c.GroupID = groupManage
 		rootCmd.AddCommand(c)
 	}
 
+	rootCmd.AddCommand(CreateDocsCommand())
+
 	// Place the auto-generated help/completion commands in the Management group
 	// too, so the help screen has no ungrouped "Additional Commands" section.
 	rootCmd.SetHelpCommandGroupID(groupManage)
 	rootCmd.SetCompletionCommandGroupID(groupManage)
 
 	// Register streamlined shortcut commands (post, reply, read, search, etc.)
-	CreateShortcutCommands(rootCmd, a)
+	CreateShortcutCommands(rootCmd, a, g)
 
 	return rootCmd
 }
+
+// resolveShowErrorBody applies the --fail / --fail-with-body overrides to the
+// configured show_body_on_error default.
+func resolveShowErrorBody(configDefault, fail, failWithBody bool) (bool, error) {
+	switch {
+	case fail && failWithBody:
+		return false, fmt.Errorf("--fail and --fail-with-body cannot be combined")
+	case fail:
+		return false, nil
+	case failWithBody:
+		return true, nil
+	default:
+		return configDefault, nil
+	}
+}
+
+// copyCapturedOutput puts the last response format printed on the clipboard
+// (see --copy) and reports how much was copied on stderr.
+func copyCapturedOutput(format *utils.Formatter) error {
+	out := format.CapturedOutput()
+	if out == nil {
+		return fmt.Errorf("--copy: nothing was printed to copy")
+	}
+	via, err := utils.CopyToClipboard(out)
+	if err != nil {
+		return fmt.Errorf("--copy: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Copied %d bytes to clipboard (%s)\n", len(out), via)
+	return nil
+}
+
+// dataBinaryBody resolves a --data-binary value. A literal value, or the
+// contents of @FILE / @- (stdin), is returned as data. With chunked set the
+// source must be @FILE or @- and is instead returned unread as body, to be
+// streamed with chunked transfer encoding.
+func dataBinaryBody(value string, chunked bool, stdin io.Reader) (data string, body io.ReadCloser, err error) {
+	source, isRef := strings.CutPrefix(value, "@")
+	if !isRef || source == "" {
+		if chunked {
+			return "", nil, fmt.Errorf("--chunked-request requires --data-binary @- (stdin) or @FILE")
+		}
+		return value, nil, nil
+	}
+
+	var r io.ReadCloser
+	if source == "-" {
+		r = io.NopCloser(stdin)
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not read --data-binary file: %v", err)
+		}
+		r = f
+	}
+	if chunked {
+		return "", r, nil
+	}
+	defer r.Close()
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read --data-binary %s: %v", value, err)
+	}
+	return string(raw), nil, nil
+}
+
+// transcodeBody converts a --data-binary body, as returned by
+// dataBinaryBody, from charset (an IANA name or alias, such as latin1,
+// ISO-8859-1 or windows-1252) to UTF-8. A streamed body is transcoded as it
+// is read.
+func transcodeBody(data string, body io.ReadCloser, charset string) (string, io.ReadCloser, error) {
+	enc, err := ianaindex.IANA.Encoding(charset)
+	if err == nil && enc == nil {
+		err = fmt.Errorf("not supported")
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unknown --charset %q: %v", charset, err)
+	}
+	if body != nil {
+		return "", struct {
+			io.Reader
+			io.Closer
+		}{transform.NewReader(body, enc.NewDecoder()), body}, nil
+	}
+	utf8, err := enc.NewDecoder().String(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not transcode the body from %s: %v", charset, err)
+	}
+	return utf8, nil, nil
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataBinaryBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.bin")
+	require.NoError(t, os.WriteFile(path, []byte("from file"), 0600))
+
+	data, body, err := dataBinaryBody("literal", false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "literal", data)
+	assert.Nil(t, body)
+
+	data, body, err = dataBinaryBody("@-", false, strings.NewReader("from stdin"))
+	require.NoError(t, err)
+	assert.Equal(t, "from stdin", data)
+	assert.Nil(t, body)
+
+	data, _, err = dataBinaryBody("@"+path, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from file", data)
+
+	// Chunked mode hands back the unread source instead of buffering it.
+	data, body, err = dataBinaryBody("@-", true, strings.NewReader("streamed"))
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	require.NotNil(t, body)
+	raw, _ := io.ReadAll(body)
+	assert.Equal(t, "streamed", string(raw))
+
+	_, _, err = dataBinaryBody("literal", true, nil)
+	assert.Error(t, err, "chunked mode needs a stream source")
+
+	_, _, err = dataBinaryBody("@"+filepath.Join(t.TempDir(), "missing"), false, nil)
+	assert.Error(t, err)
+}
+
+func TestTranscodeBody(t *testing.T) {
+	// {"text":"café ñ"} in ISO-8859-1.
+	latin1 := []byte("{\"text\":\"caf\xe9 \xf1\"}")
+	path := filepath.Join(t.TempDir(), "body.json")
+	require.NoError(t, os.WriteFile(path, latin1, 0600))
+
+	data, body, err := dataBinaryBody("@"+path, false, nil)
+	require.NoError(t, err)
+	data, body, err = transcodeBody(data, body, "latin1")
+	require.NoError(t, err)
+	assert.Nil(t, body)
+	assert.Equal(t, `{"text":"café ñ"}`, data)
+
+	_, body, err = dataBinaryBody("@"+path, true, nil)
+	require.NoError(t, err)
+	_, body, err = transcodeBody("", body, "ISO-8859-1")
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(body)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, `{"text":"café ñ"}`, string(raw), "a streamed body is transcoded too")
+
+	_, _, err = transcodeBody("x", nil, "klingon")
+	assert.ErrorContains(t, err, `unknown --charset "klingon"`)
+}
+
+func TestResolveShowErrorBody(t *testing.T) {
+	for _, configDefault := range []bool{true, false} {
+		show, err := resolveShowErrorBody(configDefault, false, false)
+		require.NoError(t, err)
+		assert.Equal(t, configDefault, show, "config default is used without flags")
+
+		show, err = resolveShowErrorBody(configDefault, true, false)
+		require.NoError(t, err)
+		assert.False(t, show, "--fail overrides the config default")
+
+		show, err = resolveShowErrorBody(configDefault, false, true)
+		require.NoError(t, err)
+		assert.True(t, show, "--fail-with-body overrides the config default")
+	}
+
+	_, err := resolveShowErrorBody(true, true, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config/paths"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// CreateScheduleCommand creates the `schedule` command: a lightweight local
+// scheduler for recurring requests. It is not a daemon — `schedule run` is
+// meant to be invoked periodically by cron or a systemd timer and replays
+// whatever is due.
+func CreateScheduleCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Schedule recurring requests (driven by cron/systemd)",
+		Long: `Store requests with a cron schedule and replay the ones that are due.
+
+xurl does not run in the background: add an entry with 'schedule add', then
+invoke 'xurl schedule run' from cron or a systemd timer (every minute is fine).
+Each run replays every request whose schedule fired since it last ran; missed
+runs are coalesced into a single replay.`,
+		Example: `  xurl schedule add --at '0 9 * * *' --request-file daily-status.json
+  xurl schedule list
+  xurl schedule run
+  xurl schedule remove 2
+
+  # crontab entry
+  * * * * * xurl schedule run >> ` + scheduleLogPath("schedule.log") + ` 2>&1`,
+	}
+
+	cmd.AddCommand(scheduleAddCmd(g), scheduleListCmd(g), scheduleRemoveCmd(g), scheduleRunCmd(a, g))
+	return cmd
+}
+
+// scheduleLogPath returns the file named name in the cache directory, where
+// the examples suggest keeping the output of `schedule run`.
+func scheduleLogPath(name string) string {
+	return filepath.Join(paths.CacheDir(), name)
+}
+
+// ─── request files ──────────────────────────────────────────────────
+
+// requestFile is the JSON form accepted by --request-file. Data may be a
+// string or any JSON value; non-string values are sent as compact JSON.
+type requestFile struct {
+	Method   string          `json:"method"`
+	Endpoint string          `json:"endpoint"`
+	URL      string          `json:"url"`
+	Headers  []string        `json:"headers"`
+	Data     json.RawMessage `json:"data"`
+	Auth     string          `json:"auth"`
+	Username string          `json:"username"`
+}
+
+// loadRequestFile reads a request file into a ScheduledRequest (without an
+// id or cron expression).
+func loadRequestFile(path string) (*store.ScheduledRequest, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRequestFile(path, raw)
+}
+
+// parseRequestFile parses the contents of a request file; path is only used
+// in error messages.
+func parseRequestFile(path string, raw []byte) (*store.ScheduledRequest, error) {
+	var rf requestFile
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rf); err != nil {
+		return nil, fmt.Errorf("invalid request file %s: %v", path, err)
+	}
+
+	endpoint := rf.Endpoint
+	if endpoint == "" {
+		endpoint = rf.URL
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("invalid request file %s: \"endpoint\" is required", path)
+	}
+
+	var data string
+	if len(rf.Data) > 0 && string(rf.Data) != "null" {
+		if err := json.Unmarshal(rf.Data, &data); err != nil {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, rf.Data); err != nil {
+				return nil, fmt.Errorf("invalid request file %s: %v", path, err)
+			}
+			data = compact.String()
+		}
+	}
+
+	method := strings.ToUpper(rf.Method)
+	if method == "" {
+		method = "GET"
+		if data != "" {
+			method = "POST"
+		}
+	}
+
+	return &store.ScheduledRequest{
+		Method:   method,
+		Endpoint: endpoint,
+		Headers:  rf.Headers,
+		Data:     data,
+		AuthType: rf.Auth,
+		Username: rf.Username,
+	}, nil
+}
+
+// ─── schedule add / list / remove ───────────────────────────────────
+
+func scheduleAddCmd(g *globalOptions) *cobra.Command {
+	var at, requestFilePath string
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Schedule a request from a JSON request file",
+		Long: `Schedule a request from a JSON request file.
+
+--at takes a five-field cron expression (minute hour day-of-month month
+day-of-week), evaluated in local time. The request file is copied into the
+schedule, so later edits to it have no effect.
+
+Request file format:
+  {
+    "method": "POST",
+    "endpoint": "/2/tweets",
+    "data": {"text": "Good morning!"},
+    "headers": ["X-Custom: value"],
+    "auth": "oauth2",
+    "username": "alice"
+  }`,
+		Example: `  xurl schedule add --at '0 9 * * 1-5' --request-file weekday-post.json`,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			req, err := loadRequestFile(requestFilePath)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			req.Cron = at
+
+			s := store.NewScheduleStore()
+			if err := s.Add(req); err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\033[32mScheduled request %s: %s %s (%s), next run %s\033[0m\n",
+				req.ID, req.Method, req.Endpoint, req.Cron, formatScheduleTime(req.NextRun()))
+		},
+	}
+	cmd.Flags().StringVar(&at, "at", "", "Cron expression (minute hour day-of-month month day-of-week)")
+	cmd.Flags().StringVar(&requestFilePath, "request-file", "", "JSON file describing the request")
+	cmd.MarkFlagRequired("at")
+	cmd.MarkFlagRequired("request-file")
+	return cmd
+}
+
+func scheduleListCmd(g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled requests",
+		Long: `List scheduled requests with their cron schedule, when each last ran and
+when it is next due.`,
+		Example: `  xurl schedule list`,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			s := store.NewScheduleStore()
+			if err := s.LoadErr(); err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			if len(s.Requests) == 0 {
+				fmt.Println("No scheduled requests. Add one with: xurl schedule add --at CRON --request-file FILE")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSCHEDULE\tREQUEST\tLAST RUN\tNEXT RUN")
+			for _, r := range s.Requests {
+				fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\t%s\n",
+					r.ID, r.Cron, r.Method, r.Endpoint, formatScheduleTime(r.LastRun), formatScheduleTime(r.NextRun()))
+			}
+			w.Flush()
+		},
+	}
+}
+
+func scheduleRemoveCmd(g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove ID",
+		Short:   "Remove a scheduled request",
+		Long:    `Remove a scheduled request by the ID shown in 'xurl schedule list'.`,
+		Example: `  xurl schedule remove 2`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s := store.NewScheduleStore()
+			if err := s.Remove(args[0]); err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\033[32mRemoved scheduled request %s\033[0m\n", args[0])
+		},
+	}
+}
+
+// ─── schedule run ───────────────────────────────────────────────────
+
+func scheduleRunCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run",
+		Short: "Replay every scheduled request that is due",
+		Long: `Replay every scheduled request whose schedule fired since it last ran.
+
+Intended to be invoked by cron or a systemd timer. Exits non-zero if any
+replayed request failed; a failed request is still marked as run so it is
+not retried until its next scheduled time.`,
+		Example: `  xurl schedule run
+
+  # crontab entry
+  * * * * * xurl schedule run >> ` + scheduleLogPath("schedule.log") + ` 2>&1`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			s := store.NewScheduleStore()
+			if err := s.LoadErr(); err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			_, failed, err := g.runDueRequests(s, g.newClient(a), time.Now())
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// runDueRequests replays every request due at now through client, marking
+// each as run. It returns how many ran and how many failed; err is only set
+// when the schedule file could not be updated.
+func (g *globalOptions) runDueRequests(s *store.ScheduleStore, client api.Client, now time.Time) (ran, failed int, err error) {
+	for _, r := range s.Due(now) {
+		fmt.Fprintf(os.Stderr, "▸ [%s] %s %s\n", r.ID, r.Method, r.Endpoint)
+		resp, sendErr := client.SendRequest(api.RequestOptions{
+			Method:   r.Method,
+			Endpoint: r.Endpoint,
+			Headers:  r.Headers,
+			Data:     r.Data,
+			AuthType: r.AuthType,
+			Username: r.Username,
+		})
+		ran++
+		if sendErr != nil {
+			failed++
+			g.fprintError(os.Stderr, "Error: scheduled request %s failed: %v", r.ID, sendErr)
+		} else {
+			g.format.Print(resp)
+		}
+		if err := s.MarkRun(r.ID, now); err != nil {
+			return ran, failed, err
+		}
+	}
+	return ran, failed, nil
+}
+
+func formatScheduleTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestLoadRequestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "req.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"endpoint": "/2/tweets",
+		"data": {"text": "gm"},
+		"headers": ["X-Test: 1"],
+		"username": "alice"
+	}`), 0600))
+
+	req, err := loadRequestFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method, "method defaults to POST when data is present")
+	assert.Equal(t, "/2/tweets", req.Endpoint)
+	assert.Equal(t, `{"text":"gm"}`, req.Data)
+	assert.Equal(t, []string{"X-Test: 1"}, req.Headers)
+	assert.Equal(t, "alice", req.Username)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"method":"get","endpoint":"/2/users/me","typo":1}`), 0600))
+	_, err = loadRequestFile(path)
+	assert.Error(t, err, "unknown fields are rejected")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"method":"GET"}`), 0600))
+	_, err = loadRequestFile(path)
+	assert.Error(t, err, "endpoint is required")
+}
+
+func TestRunDueRequestsReplaysThroughClient(t *testing.T) {
+	s := store.NewScheduleStoreWithPath(filepath.Join(t.TempDir(), "schedule.yml"))
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.Local)
+	require.NoError(t, s.Add(&store.ScheduledRequest{
+		Cron: "0 9 * * *", Method: "POST", Endpoint: "/2/tweets",
+		Data: `{"text":"gm"}`, Headers: []string{"X-Test: 1"}, Username: "alice", CreatedAt: created,
+	}))
+	require.NoError(t, s.Add(&store.ScheduledRequest{
+		Cron: "0 9 * * *", Method: "DELETE", Endpoint: "/2/tweets/1", CreatedAt: created,
+	}))
+	require.NoError(t, s.Add(&store.ScheduledRequest{
+		Cron: "0 21 * * *", Method: "GET", Endpoint: "/2/users/me", CreatedAt: created,
+	}))
+
+	var sent []api.RequestOptions
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			sent = append(sent, options)
+			if options.Method == "DELETE" {
+				return nil, fmt.Errorf("boom")
+			}
+			return json.RawMessage(`{"data":{}}`), nil
+		},
+	}
+
+	g := newGlobalOptions()
+	now := created.Add(2 * time.Hour)
+	ran, failed, err := g.runDueRequests(s, client, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ran)
+	assert.Equal(t, 1, failed)
+
+	require.Len(t, sent, 2)
+	assert.Equal(t, api.RequestOptions{
+		Method: "POST", Endpoint: "/2/tweets", Data: `{"text":"gm"}`,
+		Headers: []string{"X-Test: 1"}, Username: "alice",
+	}, sent[0])
+	assert.Equal(t, "/2/tweets/1", sent[1].Endpoint)
+
+	// Both replayed requests (including the failed one) are marked as run,
+	// so a second invocation in the same window replays nothing.
+	reloaded := store.NewScheduleStoreWithPath(s.FilePath())
+	ran, _, err = g.runDueRequests(reloaded, client, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, ran)
+}
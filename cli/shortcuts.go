@@ -2,15 +2,20 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
-	"github.com/xdevplatform/xurl/utils"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
 // -----------------------------------------------------------------
@@ -18,7 +23,7 @@ import (
 // -----------------------------------------------------------------
 
 // baseOpts builds a RequestOptions from the common persistent flags.
-func baseOpts(cmd *cobra.Command) api.RequestOptions {
+func (g *globalOptions) baseOpts(cmd *cobra.Command) api.RequestOptions {
 	authType, _ := cmd.Flags().GetString("auth")
 	username, _ := cmd.Flags().GetString("username")
 	verbose, _ := cmd.Flags().GetBool("verbose")
@@ -29,35 +34,125 @@ func baseOpts(cmd *cobra.Command) api.RequestOptions {
 		Username: username,
 		Verbose:  verbose,
 		Trace:    trace,
+		DryRun:   g.dryRun,
+		Progress: g.progress,
+		Locale:   g.locale,
+		Rich:     g.rich,
+		Format:   g.format,
 	}
 }
 
 // newClient creates an ApiClient from the auth object.
-func newClient(a *auth.Auth) *api.ApiClient {
+func (g *globalOptions) newClient(a *auth.Auth) *api.ApiClient {
 	cfg := config.NewConfig()
-	return api.NewApiClient(cfg, a)
+	return g.configureClient(api.NewApiClient(cfg, a))
+}
+
+// configureClient wires a freshly created client to the terminal, with
+// verbose traces on stdout and notices on stderr, and applies the global
+// flags the root command's PersistentPreRun has read.
+func (g *globalOptions) configureClient(c *api.ApiClient) *api.ApiClient {
+	c.WithVerboseWriter(os.Stdout).WithMaxHeaderSize(g.maxHeaderSize)
+	c.WithDefaultHeaders(g.fileHeaders)
+	c.WithResponseCache(g.responseCache)
+	c.WithRateLimitTracker(g.rateLimits)
+	c.WithRequestID(g.requestID).WithRequestHook(g.markRequestSent)
+	if !g.noWarnings {
+		c.WithNoticeWriter(os.Stderr)
+	}
+	c.WithNoticeHook(g.recordNotices)
+	if g.showAuthMethod {
+		c.WithAuthMethodWriter(os.Stderr)
+	}
+	c.WithInsecureRedirectAuth(g.insecureRedirectAuth)
+	c.WithRetry(g.retries, g.retryAfter).WithRetryLog(g.retryLog)
+	c.WithTransport(g.transport)
+	return c
 }
 
 // printResult pretty‑prints a JSON response or exits on error.
 //
-// API error bodies (valid JSON) are intentionally written to stdout so they can
-// be piped/parsed the same way as a successful response; only non-JSON errors
-// (network/auth failures) go to stderr.
-func printResult(resp json.RawMessage, err error) {
+// API error bodies (valid JSON) are pretty-printed to stderr, unless
+// suppressed via --fail or show_body_on_error, so that stdout only ever
+// carries successful responses; non-JSON errors (network/auth failures) are
+// reported on stderr as a one-line message.
+func (g *globalOptions) printResult(resp json.RawMessage, err error) {
 	if err != nil {
 		var raw json.RawMessage
-		if json.Unmarshal([]byte(err.Error()), &raw) == nil {
-			utils.FormatAndPrintResponse(raw)
-		} else {
-			fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+		isJSON := json.Unmarshal([]byte(err.Error()), &raw) == nil
+		if isJSON && g.showErrorBody {
+			g.format.PrintTo(color.Error, raw)
+		}
+		switch {
+		case xurlErrors.IsRateLimitError(err):
+			g.fprintError(os.Stderr, "Error: %s", xurlErrors.DescribeRateLimit(err, time.Now()))
+		case !isJSON:
+			g.fprintError(os.Stderr, "Error: %v", err)
+		case g.jsonErrors:
+			g.fprintError(os.Stderr, "Error: request failed")
+		default:
+			// The error body printed above says what went wrong.
+			g.logError("Error: request failed")
+			g.reportRequestID(os.Stderr)
+			g.reportRateLimits(os.Stderr)
 		}
 		os.Exit(1)
 	}
-	utils.FormatAndPrintResponse(resp)
+	if err := g.format.Print(resp); err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+		os.Exit(1)
+	}
+}
+
+// userIDCacher is implemented by clients that can cache the authenticated
+// user's identity on the stored credential (see api.ApiClient.CachedUserID).
+type userIDCacher interface {
+	CachedUserID(authType, username string) (userID, pinned string)
+	SaveIdentity(authType, username, userID, name string) error
+}
+
+// printConfirmation prints a one-line confirmation built from format and
+// args, or, when err is set, reports it and exits like printResult. For a
+// --dry-run it prints the request that was not sent instead.
+func (g *globalOptions) printConfirmation(resp json.RawMessage, err error, format string, args ...any) {
+	if err != nil || api.IsDryRun(resp) {
+		g.printResult(resp, err)
+		return
+	}
+	fmt.Printf("\033[32m"+format+"\033[0m\n", args...)
 }
 
-// resolveMyUserID calls /2/users/me and returns the authenticated user's ID.
+// followPending reports whether a follow response says the request awaits
+// approval, as it does for protected accounts.
+func followPending(resp json.RawMessage) bool {
+	var r struct {
+		Data struct {
+			PendingFollow bool `json:"pending_follow"`
+		} `json:"data"`
+	}
+	return json.Unmarshal(resp, &r) == nil && r.Data.PendingFollow
+}
+
+// resolveMyUserID returns the authenticated user's ID. When the client keeps a
+// per-token cache it is used, and filled on a miss by calling /2/users/me with
+// exactly that token, so the ID is only looked up once per credential.
 func resolveMyUserID(client api.Client, opts api.RequestOptions) (string, error) {
+	if cacher, ok := client.(userIDCacher); ok {
+		userID, pinned := cacher.CachedUserID(opts.AuthType, opts.Username)
+		if userID != "" {
+			return userID, nil
+		}
+		if pinned != "" {
+			pinnedOpts := opts
+			pinnedOpts.AuthType = pinned
+			if userID, name, err := fetchMyIdentity(client, pinnedOpts); err == nil {
+				// A failed save only costs another lookup next time.
+				_ = cacher.SaveIdentity(opts.AuthType, opts.Username, userID, name)
+				return userID, nil
+			}
+		}
+	}
+
 	if opts.Username != "" {
 		userID, err := resolveUserID(client, opts.Username, opts)
 		if err != nil {
@@ -66,26 +161,83 @@ func resolveMyUserID(client api.Client, opts api.RequestOptions) (string, error)
 		return userID, nil
 	}
 
+	userID, _, err := fetchMyIdentity(client, opts)
+	return userID, err
+}
+
+// fetchMyIdentity calls /2/users/me and returns the authenticated user's ID
+// and display name.
+func fetchMyIdentity(client api.Client, opts api.RequestOptions) (userID, name string, err error) {
+	// A lookup is needed to build the request, so it runs even for --dry-run.
+	opts.DryRun = false
 	resp, err := api.GetMe(client, opts)
 	if err != nil {
-		return "", fmt.Errorf("could not resolve your user ID (are you authenticated? try --username if /2/users/me is unavailable): %w", err)
+		return "", "", fmt.Errorf("could not resolve your user ID (are you authenticated? try --username if /2/users/me is unavailable): %w", err)
 	}
 	var me struct {
 		Data struct {
-			ID string `json:"id"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(resp, &me); err != nil {
-		return "", fmt.Errorf("could not parse /2/users/me response: %w", err)
+		return "", "", fmt.Errorf("could not parse /2/users/me response: %w", err)
 	}
 	if me.Data.ID == "" {
-		return "", fmt.Errorf("user ID was empty – check your auth tokens")
+		return "", "", fmt.Errorf("user ID was empty – check your auth tokens")
+	}
+	return me.Data.ID, me.Data.Name, nil
+}
+
+// withMyUserID calls do with the authenticated user's ID (see
+// resolveMyUserID). If the ID came from the token cache and do is refused
+// with a 403, the token may now belong to a different account: the cached
+// identity is dropped and looked up again, and do is retried once if the ID
+// changed.
+func withMyUserID(client api.Client, opts api.RequestOptions, do func(userID string) (json.RawMessage, error)) (json.RawMessage, error) {
+	cacher, canCache := client.(userIDCacher)
+	var cached string
+	if canCache {
+		cached, _ = cacher.CachedUserID(opts.AuthType, opts.Username)
+	}
+
+	userID, err := resolveMyUserID(client, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := do(userID)
+	if err == nil || cached == "" || !isForbidden(err) {
+		return resp, err
+	}
+
+	if cacher.SaveIdentity(opts.AuthType, opts.Username, "", "") != nil {
+		return resp, err
+	}
+	fresh, lookupErr := resolveMyUserID(client, opts)
+	if lookupErr != nil || fresh == userID {
+		return resp, err
 	}
-	return me.Data.ID, nil
+	return do(fresh)
 }
 
+// isForbidden reports whether err is an API error with status 403.
+func isForbidden(err error) bool {
+	var e *xurlErrors.Error
+	return errors.As(err, &e) && e.Type == xurlErrors.ErrTypeAPI && e.StatusCode == http.StatusForbidden
+}
+
+// userIDCache remembers username → user ID lookups for the life of the
+// process, keyed by lower-cased username (usernames are case-insensitive).
+var userIDCache = map[string]string{}
+
 // resolveUserID looks up a username and returns its user ID.
 func resolveUserID(client api.Client, username string, opts api.RequestOptions) (string, error) {
+	key := strings.ToLower(api.ResolveUsername(username))
+	if id, ok := userIDCache[key]; ok {
+		return id, nil
+	}
+
+	opts.DryRun = false // see fetchMyIdentity
 	resp, err := api.LookupUser(client, username, opts)
 	if err != nil {
 		return "", fmt.Errorf("could not look up user @%s: %w", username, err)
@@ -101,6 +253,7 @@ func resolveUserID(client api.Client, username string, opts api.RequestOptions)
 	if user.Data.ID == "" {
 		return "", fmt.Errorf("user @%s not found", username)
 	}
+	userIDCache[key] = user.Data.ID
 	return user.Data.ID, nil
 }
 
@@ -117,7 +270,7 @@ func addCommonFlags(cmd *cobra.Command) {
 // on the given root command.
 // -----------------------------------------------------------------
 
-func CreateShortcutCommands(rootCmd *cobra.Command, a *auth.Auth) {
+func CreateShortcutCommands(rootCmd *cobra.Command, a *auth.Auth, g *globalOptions) {
 	add := func(groupID string, cmds ...*cobra.Command) {
 		for _, c := range cmds {
 			c.GroupID = groupID
@@ -126,18 +279,18 @@ func CreateShortcutCommands(rootCmd *cobra.Command, a *auth.Auth) {
 	}
 
 	add(groupWrite,
-		postCmd(a), replyCmd(a), quoteCmd(a), deleteCmd(a), dmCmd(a),
-		likeCmd(a), unlikeCmd(a), repostCmd(a), unrepostCmd(a),
-		bookmarkCmd(a), unbookmarkCmd(a),
+		postCmd(a, g), replyCmd(a, g), quoteCmd(a, g), deleteCmd(a, g), dmCmd(a, g),
+		likeCmd(a, g), unlikeCmd(a, g), repostCmd(a, g), unrepostCmd(a, g),
+		bookmarkCmd(a, g), unbookmarkCmd(a, g),
 	)
 	add(groupSocial,
-		whoamiCmd(a), userCmd(a),
-		followCmd(a), unfollowCmd(a), followingCmd(a), followersCmd(a),
-		blockCmd(a), unblockCmd(a), muteCmd(a), unmuteCmd(a),
+		whoamiCmd(a, g), userCmd(a, g),
+		followCmd(a, g), unfollowCmd(a, g), followingCmd(a, g), followersCmd(a, g),
+		blockCmd(a, g), unblockCmd(a, g), muteCmd(a, g), unmuteCmd(a, g),
 	)
 	add(groupRead,
-		readCmd(a), searchCmd(a), postsCmd(a), timelineCmd(a), mentionsCmd(a),
-		dmsCmd(a), bookmarksCmd(a), likesCmd(a),
+		readCmd(a, g), searchCmd(a, g), postsCmd(a, g), timelineCmd(a, g), mentionsCmd(a, g),
+		dmsCmd(a, g), likesCmd(a, g), trendsCmd(a, g),
 	)
 }
 
@@ -145,22 +298,20 @@ func CreateShortcutCommands(rootCmd *cobra.Command, a *auth.Auth) {
 //  POSTING
 // =================================================================
 
-func postCmd(a *auth.Auth) *cobra.Command {
+func postCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var mediaIDs []string
 	cmd := &cobra.Command{
 		Use:   `post "TEXT"`,
 		Short: "Post to X",
-		Long: `Post a new post to X.
-
-Examples:
-  xurl post "Hello world!"
+		Long:  `Post a new post to X.`,
+		Example: `  xurl post "Hello world!"
   xurl post "Check this out" --media-id 12345
   xurl post "Multiple images" --media-id 111 --media-id 222`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.CreatePost(client, args[0], mediaIDs, opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.CreatePost(client, args[0], mediaIDs, opts))
 		},
 	}
 	cmd.Flags().StringArrayVar(&mediaIDs, "media-id", nil, "Media ID(s) to attach (repeatable)")
@@ -168,21 +319,19 @@ Examples:
 	return cmd
 }
 
-func replyCmd(a *auth.Auth) *cobra.Command {
+func replyCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var mediaIDs []string
 	cmd := &cobra.Command{
 		Use:   `reply POST_ID_OR_URL "TEXT"`,
 		Short: "Reply to a post",
-		Long: `Reply to an existing post. Accepts a post ID or full URL.
-
-Examples:
-  xurl reply 1234567890 "Great thread!"
+		Long:  `Reply to an existing post. Accepts a post ID or full URL.`,
+		Example: `  xurl reply 1234567890 "Great thread!"
   xurl reply https://x.com/user/status/1234567890 "Nice post!"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.ReplyToPost(client, args[0], args[1], mediaIDs, opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.ReplyToPost(client, args[0], args[1], mediaIDs, opts))
 		},
 	}
 	cmd.Flags().StringArrayVar(&mediaIDs, "media-id", nil, "Media ID(s) to attach (repeatable)")
@@ -190,42 +339,44 @@ Examples:
 	return cmd
 }
 
-func quoteCmd(a *auth.Auth) *cobra.Command {
+func quoteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   `quote POST_ID_OR_URL "TEXT"`,
 		Short: "Quote a post",
-		Long: `Quote an existing post with your own commentary.
-
-Examples:
-  xurl quote 1234567890 "This is so true"
+		Long:  `Quote an existing post with your own commentary.`,
+		Example: `  xurl quote 1234567890 "This is so true"
   xurl quote https://x.com/user/status/1234567890 "Interesting take"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.QuotePost(client, args[0], args[1], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.QuotePost(client, args[0], args[1], opts))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func deleteCmd(a *auth.Auth) *cobra.Command {
+func deleteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var yes bool
 	cmd := &cobra.Command{
 		Use:   "delete POST_ID_OR_URL",
 		Short: "Delete a post",
-		Long: `Delete one of your posts. Accepts a post ID or full URL.
-
-Examples:
-  xurl delete 1234567890
-  xurl delete https://x.com/user/status/1234567890`,
+		Long: `Delete one of your posts. Accepts a post ID or full URL. xurl asks first;
+--yes skips the question, and is required when not running in a terminal.`,
+		Example: `  xurl delete 1234567890
+  xurl delete https://x.com/user/status/1234567890 --yes`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.DeletePost(client, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			if !confirmDestructive(yes || opts.DryRun, "delete a post", "post "+api.ResolvePostID(args[0])) {
+				return
+			}
+			g.printResult(api.DeletePost(client, args[0], opts))
 		},
 	}
+	addYesFlag(cmd, &yes)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -234,42 +385,38 @@ Examples:
 //  READING
 // =================================================================
 
-func readCmd(a *auth.Auth) *cobra.Command {
+func readCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "read POST_ID_OR_URL",
 		Short: "Read a post",
-		Long: `Fetch and display a single post with author info and metrics.
-
-Examples:
-  xurl read 1234567890
+		Long:  `Fetch and display a single post with author info and metrics.`,
+		Example: `  xurl read 1234567890
   xurl read https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.ReadPost(client, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.ReadPost(client, args[0], opts))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func searchCmd(a *auth.Auth) *cobra.Command {
+func searchCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	cmd := &cobra.Command{
 		Use:   `search "QUERY"`,
 		Short: "Search recent posts",
-		Long: `Search recent posts matching a query.
-
-Examples:
-  xurl search "golang"
+		Long:  `Search recent posts matching a query.`,
+		Example: `  xurl search "golang"
   xurl search "from:elonmusk" -n 20
   xurl search "#buildinpublic" -n 15`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.SearchPosts(client, args[0], maxResults, opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.SearchPosts(client, args[0], maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (min 10, max 100)")
@@ -277,26 +424,24 @@ Examples:
 	return cmd
 }
 
-func postsCmd(a *auth.Auth) *cobra.Command {
+func postsCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	cmd := &cobra.Command{
 		Use:   "posts USERNAME",
 		Short: "List a user's recent posts",
-		Long: `Fetch recent posts authored by a user (by @username).
-
-Examples:
-  xurl posts elonmusk
+		Long:  `Fetch recent posts authored by a user (by @username).`,
+		Example: `  xurl posts elonmusk
   xurl posts @XDevelopers -n 50`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			userID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetUserPosts(client, userID, maxResults, opts))
+			g.printResult(api.GetUserPosts(client, userID, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (5–100)")
@@ -308,43 +453,39 @@ Examples:
 //  USER INFO
 // =================================================================
 
-func whoamiCmd(a *auth.Auth) *cobra.Command {
+func whoamiCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "whoami",
-		Short: "Show the authenticated user's profile",
-		Long: `Fetch profile information for the currently authenticated user.
-
-Examples:
-  xurl whoami`,
-		Args: cobra.NoArgs,
+		Use:     "whoami",
+		Short:   "Show the authenticated user's profile",
+		Long:    `Fetch profile information for the currently authenticated user.`,
+		Example: `  xurl whoami`,
+		Args:    cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			if opts.Username != "" {
-				printResult(api.LookupUser(client, opts.Username, opts))
+				g.printResult(api.LookupUser(client, opts.Username, opts))
 				return
 			}
-			printResult(api.GetMe(client, opts))
+			g.printResult(api.GetMe(client, opts))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func userCmd(a *auth.Auth) *cobra.Command {
+func userCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "user USERNAME",
 		Short: "Look up a user by username",
-		Long: `Fetch profile information for any user by their @username.
-
-Examples:
-  xurl user elonmusk
+		Long:  `Fetch profile information for any user by their @username.`,
+		Example: `  xurl user elonmusk
   xurl user @XDevelopers`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.LookupUser(client, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.LookupUser(client, args[0], opts))
 		},
 	}
 	addCommonFlags(cmd)
@@ -355,53 +496,79 @@ Examples:
 //  TIMELINE & MENTIONS
 // =================================================================
 
-func timelineCmd(a *auth.Auth) *cobra.Command {
-	var maxResults int
+func timelineCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var maxResults, max int
 	cmd := &cobra.Command{
-		Use:   "timeline",
-		Short: "Show your home timeline",
+		Use:   "timeline [USERNAME]",
+		Short: "Show your home timeline, or a user's recent posts",
 		Long: `Fetch your reverse‑chronological home timeline.
 
-Examples:
-  xurl timeline
-  xurl timeline -n 25`,
-		Args: cobra.NoArgs,
+Given a USERNAME, fetch that user's recent posts instead: the username is
+resolved to a user ID and /2/users/:id/tweets is paged through until --max
+posts have been collected or the timeline runs out. The pages are merged into
+one response, or with --output-format ndjson each post is printed as soon as
+its page arrives.`,
+		Example: `  xurl timeline
+  xurl timeline -n 25
+  xurl timeline @XDevelopers
+  xurl timeline @XDevelopers --max 200`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			if len(args) == 1 {
+				userID, err := resolveUserID(client, args[0], opts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				if g.format.IsNDJSON() {
+					if err := api.StreamToNDJSON(g.format, func(items chan<- json.RawMessage) error {
+						return api.StreamUserTimeline(client, userID, max, opts, items)
+					}); err != nil {
+						g.printResult(nil, err)
+					}
+					return
+				}
+				g.printResult(api.GetUserTimeline(client, userID, max, opts))
+				return
+			}
+			if cmd.Flags().Changed("max") {
+				fmt.Fprintln(os.Stderr, "\033[31mError: --max needs a USERNAME; use -n for your home timeline\033[0m")
+				os.Exit(1)
+			}
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetTimeline(client, userID, maxResults, opts))
+			g.printResult(api.GetTimeline(client, userID, maxResults, opts))
 		},
 	}
-	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
+	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results for the home timeline (1–100)")
+	cmd.Flags().IntVar(&max, "max", 100, "Maximum number of posts to fetch for USERNAME, across pages")
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func mentionsCmd(a *auth.Auth) *cobra.Command {
+func mentionsCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	cmd := &cobra.Command{
 		Use:   "mentions",
 		Short: "Show your recent mentions",
-		Long: `Fetch posts that mention the authenticated user.
-
-Examples:
-  xurl mentions
+		Long:  `Fetch posts that mention the authenticated user.`,
+		Example: `  xurl mentions
   xurl mentions -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetMentions(client, userID, maxResults, opts))
+			g.printResult(api.GetMentions(client, userID, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (5–100)")
@@ -413,188 +580,142 @@ Examples:
 //  ENGAGEMENT — Like / Repost / Bookmark
 // =================================================================
 
-func likeCmd(a *auth.Auth) *cobra.Command {
+func likeCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "like POST_ID_OR_URL",
 		Short: "Like a post",
-		Long: `Like a post. Accepts a post ID or full URL.
-
-Examples:
-  xurl like 1234567890
+		Long:  `Like a post. Accepts a post ID or full URL.`,
+		Example: `  xurl like 1234567890
   xurl like https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.LikePost(client, userID, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			resp, err := withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+				return api.LikePost(client, userID, args[0], opts)
+			})
+			g.printConfirmation(resp, err, "Liked post %s", api.ResolvePostID(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unlikeCmd(a *auth.Auth) *cobra.Command {
+func unlikeCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unlike POST_ID_OR_URL",
 		Short: "Unlike a post",
-		Args:  cobra.ExactArgs(1),
+		Long:  `Unlike a post. Accepts a post ID or full URL.`,
+		Example: `  xurl unlike 1234567890
+  xurl unlike https://x.com/user/status/1234567890`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.UnlikePost(client, userID, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			resp, err := withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+				return api.UnlikePost(client, userID, args[0], opts)
+			})
+			g.printConfirmation(resp, err, "Unliked post %s", api.ResolvePostID(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func repostCmd(a *auth.Auth) *cobra.Command {
+func repostCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "repost POST_ID_OR_URL",
-		Short: "Repost a post",
-		Long: `Repost a post. Accepts a post ID or full URL.
-
-Examples:
-  xurl repost 1234567890
+		Use:     "repost POST_ID_OR_URL",
+		Aliases: []string{"retweet"},
+		Short:   "Repost a post",
+		Long:    `Repost a post. Accepts a post ID or full URL.`,
+		Example: `  xurl repost 1234567890
   xurl repost https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.Repost(client, userID, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			resp, err := withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+				return api.Repost(client, userID, args[0], opts)
+			})
+			g.printConfirmation(resp, err, "Reposted post %s", api.ResolvePostID(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unrepostCmd(a *auth.Auth) *cobra.Command {
+func unrepostCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "unrepost POST_ID_OR_URL",
-		Short: "Undo a repost",
-		Args:  cobra.ExactArgs(1),
+		Use:     "unrepost POST_ID_OR_URL",
+		Aliases: []string{"unretweet"},
+		Short:   "Undo a repost",
+		Long:    `Undo a repost. Accepts a post ID or full URL.`,
+		Example: `  xurl unrepost 1234567890
+  xurl unrepost https://x.com/user/status/1234567890`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.Unrepost(client, userID, args[0], opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			resp, err := withMyUserID(client, opts, func(userID string) (json.RawMessage, error) {
+				return api.Unrepost(client, userID, args[0], opts)
+			})
+			g.printConfirmation(resp, err, "Undid repost of post %s", api.ResolvePostID(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func bookmarkCmd(a *auth.Auth) *cobra.Command {
+func bookmarkCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "bookmark POST_ID_OR_URL",
 		Short: "Bookmark a post",
-		Long: `Bookmark a post. Accepts a post ID or full URL.
-
-Examples:
-  xurl bookmark 1234567890
+		Long:  `Bookmark a post. Accepts a post ID or full URL.`,
+		Example: `  xurl bookmark 1234567890
   xurl bookmark https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.Bookmark(client, userID, args[0], opts))
+			g.printResult(addBookmark(g.newClient(a), g.baseOpts(cmd), a.AppName(), args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unbookmarkCmd(a *auth.Auth) *cobra.Command {
+func unbookmarkCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unbookmark POST_ID_OR_URL",
 		Short: "Remove a bookmark",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.Unbookmark(client, userID, args[0], opts))
-		},
-	}
-	addCommonFlags(cmd)
-	return cmd
-}
-
-func bookmarksCmd(a *auth.Auth) *cobra.Command {
-	var maxResults int
-	cmd := &cobra.Command{
-		Use:   "bookmarks",
-		Short: "List your bookmarks",
-		Long: `Fetch your bookmarked posts.
-
-Examples:
-  xurl bookmarks
-  xurl bookmarks -n 25`,
-		Args: cobra.NoArgs,
+		Long:  `Remove a post from your bookmarks. Accepts a post ID or full URL.`,
+		Example: `  xurl unbookmark 1234567890
+  xurl unbookmark https://x.com/user/status/1234567890`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			userID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
-			printResult(api.GetBookmarks(client, userID, maxResults, opts))
+			g.printResult(removeBookmark(g.newClient(a), g.baseOpts(cmd), a.AppName(), args[0]))
 		},
 	}
-	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func likesCmd(a *auth.Auth) *cobra.Command {
+func likesCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	cmd := &cobra.Command{
 		Use:   "likes",
 		Short: "List your liked posts",
-		Long: `Fetch posts you have liked.
-
-Examples:
-  xurl likes
+		Long:  `Fetch posts you have liked.`,
+		Example: `  xurl likes
   xurl likes -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetLikedPosts(client, userID, maxResults, opts))
+			g.printResult(api.GetLikedPosts(client, userID, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (5–100)")
@@ -606,76 +727,75 @@ Examples:
 //  SOCIAL GRAPH — Follow / Block / Mute
 // =================================================================
 
-func followCmd(a *auth.Auth) *cobra.Command {
+func followCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "follow USERNAME",
 		Short: "Follow a user",
-		Long: `Follow a user by their @username.
-
-Examples:
-  xurl follow elonmusk
+		Long:  `Follow a user by their @username.`,
+		Example: `  xurl follow elonmusk
   xurl follow @XDevelopers`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.FollowUser(client, myID, targetID, opts))
+			resp, err := withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.FollowUser(client, myID, targetID, opts)
+			})
+			if err == nil && followPending(resp) {
+				g.printConfirmation(resp, nil, "Follow request sent to @%s (their posts are protected)", api.ResolveUsername(args[0]))
+				return
+			}
+			g.printConfirmation(resp, err, "Followed @%s", api.ResolveUsername(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unfollowCmd(a *auth.Auth) *cobra.Command {
+func unfollowCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unfollow USERNAME",
 		Short: "Unfollow a user",
-		Args:  cobra.ExactArgs(1),
+		Long:  `Unfollow a user. The leading @ is optional.`,
+		Example: `  xurl unfollow @XDevelopers
+  xurl unfollow XDevelopers`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.UnfollowUser(client, myID, targetID, opts))
+			resp, err := withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.UnfollowUser(client, myID, targetID, opts)
+			})
+			g.printConfirmation(resp, err, "Unfollowed @%s", api.ResolveUsername(args[0]))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func followingCmd(a *auth.Auth) *cobra.Command {
+func followingCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	var targetUser string
 	cmd := &cobra.Command{
 		Use:   "following",
 		Short: "List users you follow",
-		Long: `Fetch the list of users you (or another user) follow.
-
-Examples:
-  xurl following
+		Long:  `Fetch the list of users you (or another user) follow.`,
+		Example: `  xurl following
   xurl following --of elonmusk -n 50`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			var userID string
 			var err error
 			if targetUser != "" {
@@ -687,7 +807,7 @@ Examples:
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetFollowing(client, userID, maxResults, opts))
+			g.printResult(api.GetFollowing(client, userID, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–1000)")
@@ -696,21 +816,19 @@ Examples:
 	return cmd
 }
 
-func followersCmd(a *auth.Auth) *cobra.Command {
+func followersCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	var targetUser string
 	cmd := &cobra.Command{
 		Use:   "followers",
 		Short: "List your followers",
-		Long: `Fetch the list of your (or another user's) followers.
-
-Examples:
-  xurl followers
+		Long:  `Fetch the list of your (or another user's) followers.`,
+		Example: `  xurl followers
   xurl followers --of elonmusk -n 50`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			var userID string
 			var err error
 			if targetUser != "" {
@@ -722,7 +840,7 @@ Examples:
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.GetFollowers(client, userID, maxResults, opts))
+			g.printResult(api.GetFollowers(client, userID, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–1000)")
@@ -731,100 +849,96 @@ Examples:
 	return cmd
 }
 
-func blockCmd(a *auth.Auth) *cobra.Command {
+func blockCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "block USERNAME",
-		Short: "Block a user",
-		Args:  cobra.ExactArgs(1),
+		Use:     "block USERNAME",
+		Short:   "Block a user",
+		Long:    `Block a user. The leading @ is optional.`,
+		Example: `  xurl block @spammer`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.BlockUser(client, myID, targetID, opts))
+			g.printResult(withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.BlockUser(client, myID, targetID, opts)
+			}))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unblockCmd(a *auth.Auth) *cobra.Command {
+func unblockCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "unblock USERNAME",
-		Short: "Unblock a user",
-		Args:  cobra.ExactArgs(1),
+		Use:     "unblock USERNAME",
+		Short:   "Unblock a user",
+		Long:    `Unblock a user. The leading @ is optional.`,
+		Example: `  xurl unblock @spammer`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.UnblockUser(client, myID, targetID, opts))
+			g.printResult(withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.UnblockUser(client, myID, targetID, opts)
+			}))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func muteCmd(a *auth.Auth) *cobra.Command {
+func muteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "mute USERNAME",
-		Short: "Mute a user",
-		Args:  cobra.ExactArgs(1),
+		Use:     "mute USERNAME",
+		Short:   "Mute a user",
+		Long:    `Mute a user. The leading @ is optional.`,
+		Example: `  xurl mute @noisy`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.MuteUser(client, myID, targetID, opts))
+			g.printResult(withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.MuteUser(client, myID, targetID, opts)
+			}))
 		},
 	}
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func unmuteCmd(a *auth.Auth) *cobra.Command {
+func unmuteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "unmute USERNAME",
-		Short: "Unmute a user",
-		Args:  cobra.ExactArgs(1),
+		Use:     "unmute USERNAME",
+		Short:   "Unmute a user",
+		Long:    `Unmute a user. The leading @ is optional.`,
+		Example: `  xurl unmute @noisy`,
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			myID, err := resolveMyUserID(client, opts)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
-			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
-			printResult(api.UnmuteUser(client, myID, targetID, opts))
+			g.printResult(withMyUserID(client, opts, func(myID string) (json.RawMessage, error) {
+				return api.UnmuteUser(client, myID, targetID, opts)
+			}))
 		},
 	}
 	addCommonFlags(cmd)
@@ -835,46 +949,142 @@ func unmuteCmd(a *auth.Auth) *cobra.Command {
 //  DIRECT MESSAGES
 // =================================================================
 
-func dmCmd(a *auth.Auth) *cobra.Command {
+func dmCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   `dm USERNAME "TEXT"`,
 		Short: "Send a direct message",
 		Long: `Send a direct message to a user.
 
-Examples:
-  xurl dm @elonmusk "Hey, great post!"
-  xurl dm someuser "Hello there"`,
+'dm USERNAME "TEXT"' is shorthand for 'dm send --to USERNAME "TEXT"'; use
+'dm send' to attach media and 'dm list' to read a conversation.`,
+		Example: `  xurl dm @elonmusk "Hey, great post!"
+  xurl dm someuser "Hello there"
+  xurl dm send --to @someuser "Photo attached" --media-id 1234567890
+  xurl dm list --with @someuser --limit 20`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			targetID, err := resolveUserID(client, args[0], opts)
+			sendDM(g.newClient(a), args[0], args[1], nil, g.baseOpts(cmd), g)
+		},
+	}
+	addCommonFlags(cmd)
+	cmd.AddCommand(dmSendCmd(a, g), dmListCmd(a, g))
+	return cmd
+}
+
+func dmSendCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var to string
+	var mediaIDs []string
+	cmd := &cobra.Command{
+		Use:   `send --to USERNAME|USER_ID "TEXT"`,
+		Short: "Send a direct message, optionally with media",
+		Long: `Send a direct message to a user, given as @handle or numeric user ID.
+
+Handles are resolved to user IDs once per run. Attach uploaded media with
+--media-id. The ID of the created message is printed to stderr.`,
+		Example: `  xurl dm send --to @someuser "Hello there"
+  xurl dm send --to 1234567890 "Photo attached" --media-id 555`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sendDM(g.newClient(a), to, args[0], mediaIDs, g.baseOpts(cmd), g)
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "Recipient @handle or user ID")
+	cmd.Flags().StringArrayVar(&mediaIDs, "media-id", nil, "Media ID to attach (repeatable)")
+	cmd.MarkFlagRequired("to")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func dmListCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var with string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recent direct messages, optionally with one user",
+		Long: `List recent direct message events. With --with, only the one-to-one
+conversation with that user (@handle or user ID) is listed.`,
+		Example: `  xurl dm list
+  xurl dm list --with @someuser --limit 20`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			if with == "" {
+				g.printResult(api.GetDMEvents(client, limit, opts))
+				return
+			}
+			participantID, err := resolveDMParticipant(client, with, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				g.fprintError(os.Stderr, "Error: %v", err)
 				os.Exit(1)
 			}
-			printResult(api.SendDM(client, targetID, args[1], opts))
+			g.printResult(api.GetDMConversationEvents(client, participantID, limit, opts))
 		},
 	}
+	cmd.Flags().StringVar(&with, "with", "", "Only show the conversation with this @handle or user ID")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 10, "Number of messages (1–100)")
 	addCommonFlags(cmd)
 	return cmd
 }
 
-func dmsCmd(a *auth.Auth) *cobra.Command {
+// sendDM sends text (and media) to a recipient given as handle or ID and
+// prints the result, explaining the usual reason a DM is refused.
+func sendDM(client api.Client, to, text string, mediaIDs []string, opts api.RequestOptions, g *globalOptions) {
+	participantID, err := resolveDMParticipant(client, to, opts)
+	if err != nil {
+		g.fprintError(os.Stderr, "Error: %v", err)
+		os.Exit(1)
+	}
+	resp, err := api.SendDM(client, participantID, text, mediaIDs, opts)
+	if err != nil {
+		if hint := dmErrorHint(err); hint != "" {
+			g.fprintError(os.Stderr, "Error: %s", hint)
+		}
+		g.printResult(nil, err)
+	}
+	g.printResult(resp, nil)
+	var created struct {
+		Data struct {
+			DMEventID string `json:"dm_event_id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(resp, &created) == nil && created.Data.DMEventID != "" {
+		fmt.Fprintf(os.Stderr, "Sent message %s\n", created.Data.DMEventID)
+	}
+}
+
+// resolveDMParticipant returns the user ID for a recipient given as a numeric
+// user ID or an @handle (looked up, and cached for the run, otherwise).
+func resolveDMParticipant(client api.Client, to string, opts api.RequestOptions) (string, error) {
+	to = strings.TrimSpace(to)
+	if isNumericID(to) {
+		return to, nil
+	}
+	return resolveUserID(client, to, opts)
+}
+
+// dmErrorHint explains a refused DM: X answers 403 when the recipient does
+// not accept messages from the sender.
+func dmErrorHint(err error) string {
+	if isForbidden(err) {
+		return "cannot DM this user: they may only accept messages from accounts they follow, or have blocked you (also check that your token has the dm.write scope)"
+	}
+	return ""
+}
+
+func dmsCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
 	var maxResults int
 	cmd := &cobra.Command{
 		Use:   "dms",
 		Short: "List recent direct messages",
-		Long: `Fetch your recent direct message events.
-
-Examples:
-  xurl dms
+		Long:  `Fetch your recent direct message events.`,
+		Example: `  xurl dms
   xurl dms -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
-			opts := baseOpts(cmd)
-			printResult(api.GetDMEvents(client, maxResults, opts))
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+			g.printResult(api.GetDMEvents(client, maxResults, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/xdevplatform/xurl/api"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
 type fakeClient struct {
@@ -28,7 +30,7 @@ func (f fakeClient) SendRequest(options api.RequestOptions) (json.RawMessage, er
 	return f.sendRequest(options)
 }
 
-func (f fakeClient) StreamRequest(options api.RequestOptions) error {
+func (f fakeClient) StreamRequest(options api.RequestOptions, handler api.StreamHandler) error {
 	return fmt.Errorf("not implemented")
 }
 
@@ -61,3 +63,190 @@ func TestResolveMyUserIDReturnsHelpfulErrorWhenGetMeFails(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "try --username")
 }
+
+func TestResolveUserIDCachesLookups(t *testing.T) {
+	t.Cleanup(func() { userIDCache = map[string]string{} })
+	userIDCache = map[string]string{}
+
+	lookups := 0
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			lookups++
+			require.Contains(t, options.Endpoint, "/2/users/by/username/Alice")
+			return json.RawMessage(`{"data":{"id":"42"}}`), nil
+		},
+	}
+
+	for _, name := range []string{"@Alice", "Alice", "@alice"} {
+		userID, err := resolveUserID(client, name, api.RequestOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "42", userID)
+	}
+	assert.Equal(t, 1, lookups, "later lookups must be served from the cache")
+}
+
+func TestUserTimelineResolvesUsernameThenPaginates(t *testing.T) {
+	t.Cleanup(func() { userIDCache = map[string]string{} })
+	userIDCache = map[string]string{}
+
+	var endpoints []string
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			endpoints = append(endpoints, options.Endpoint)
+			switch {
+			case strings.HasPrefix(options.Endpoint, "/2/users/by/username/XDevelopers"):
+				return json.RawMessage(`{"data":{"id":"7"}}`), nil
+			case strings.Contains(options.Endpoint, "pagination_token=next"):
+				return json.RawMessage(`{"data":[{"id":"3"}],"meta":{}}`), nil
+			case strings.HasPrefix(options.Endpoint, "/2/users/7/tweets"):
+				return json.RawMessage(`{"data":[{"id":"1"},{"id":"2"}],"meta":{"next_token":"next"}}`), nil
+			}
+			return nil, fmt.Errorf("unexpected endpoint %s", options.Endpoint)
+		},
+	}
+
+	userID, err := resolveUserID(client, "@XDevelopers", api.RequestOptions{})
+	require.NoError(t, err)
+	resp, err := api.GetUserTimeline(client, userID, 200, api.RequestOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, endpoints, 3)
+	assert.JSONEq(t, `{"data":[{"id":"1"},{"id":"2"},{"id":"3"}],"meta":{"result_count":3}}`, string(resp))
+}
+
+func TestResolveDMParticipant(t *testing.T) {
+	lookups := 0
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			lookups++
+			assert.Contains(t, options.Endpoint, "/2/users/by/username/DMTarget")
+			return json.RawMessage(`{"data":{"id":"77"}}`), nil
+		},
+	}
+
+	id, err := resolveDMParticipant(client, "1234", api.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1234", id, "numeric IDs are used as-is")
+	assert.Equal(t, 0, lookups)
+
+	for _, handle := range []string{"@DMTarget", "dmtarget"} {
+		id, err = resolveDMParticipant(client, handle, api.RequestOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "77", id)
+	}
+	assert.Equal(t, 1, lookups, "handles are looked up once per run")
+}
+
+func TestDMErrorHint(t *testing.T) {
+	forbidden := xurlErrors.NewAPIError([]byte(`{"title":"Forbidden","detail":"You are not permitted to send messages to this user"}`))
+	forbidden.StatusCode = http.StatusForbidden
+	assert.Contains(t, dmErrorHint(forbidden), "cannot DM this user")
+
+	badRequest := xurlErrors.NewAPIError([]byte(`{"title":"Invalid Request"}`))
+	badRequest.StatusCode = http.StatusBadRequest
+	assert.Empty(t, dmErrorHint(badRequest))
+	assert.Empty(t, dmErrorHint(fmt.Errorf("connection refused")))
+}
+
+// cachingClient is a fakeClient with a per-credential user ID cache.
+type cachingClient struct {
+	fakeClient
+	cached string
+	saved  string
+}
+
+func (c *cachingClient) CachedUserID(authType, username string) (string, string) {
+	return c.cached, "oauth2"
+}
+
+func (c *cachingClient) SaveIdentity(authType, username, userID, name string) error {
+	c.saved = userID
+	c.cached = userID
+	return nil
+}
+
+func TestResolveMyUserIDUsesTokenCache(t *testing.T) {
+	calls := 0
+	client := &cachingClient{fakeClient: fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			calls++
+			assert.Contains(t, options.Endpoint, "/2/users/me")
+			assert.Equal(t, "oauth2", options.AuthType, "the lookup must use the credential the ID is cached on")
+			return json.RawMessage(`{"data":{"id":"42"}}`), nil
+		},
+	}}
+
+	userID, err := resolveMyUserID(client, api.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "42", userID)
+	assert.Equal(t, "42", client.saved)
+
+	client.cached = "42"
+	userID, err = resolveMyUserID(client, api.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "42", userID)
+	assert.Equal(t, 1, calls, "a cached ID needs no request")
+}
+
+func TestFollowPending(t *testing.T) {
+	assert.True(t, followPending(json.RawMessage(`{"data":{"following":false,"pending_follow":true}}`)))
+	assert.False(t, followPending(json.RawMessage(`{"data":{"following":true,"pending_follow":false}}`)))
+}
+
+func TestDryRunStillResolvesIDs(t *testing.T) {
+	userIDCache = map[string]string{}
+	defer func() { userIDCache = map[string]string{} }()
+	client := fakeClient{sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+		assert.False(t, options.DryRun, "lookups are sent even for --dry-run: %s", options.Endpoint)
+		if strings.HasPrefix(options.Endpoint, "/2/users/me") {
+			return json.RawMessage(`{"data":{"id":"1"}}`), nil
+		}
+		return json.RawMessage(`{"data":{"id":"2"}}`), nil
+	}}
+	opts := api.RequestOptions{DryRun: true}
+
+	myID, err := resolveMyUserID(client, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "1", myID)
+	targetID, err := resolveUserID(client, "bob", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "2", targetID)
+}
+
+func TestWithMyUserIDRefreshesStaleCache(t *testing.T) {
+	var paths []string
+	client := &cachingClient{cached: "1", fakeClient: fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			paths = append(paths, options.Endpoint)
+			switch {
+			case strings.HasPrefix(options.Endpoint, "/2/users/me"):
+				return json.RawMessage(`{"data":{"id":"2","name":"Bob"}}`), nil
+			case options.Endpoint == "/2/users/1/likes":
+				e := xurlErrors.NewAPIError(json.RawMessage(`{"title":"Forbidden"}`))
+				e.StatusCode = http.StatusForbidden
+				return nil, e
+			}
+			return json.RawMessage(`{"data":{"liked":true}}`), nil
+		},
+	}}
+
+	like := func(userID string) (json.RawMessage, error) {
+		return client.SendRequest(api.RequestOptions{Endpoint: "/2/users/" + userID + "/likes"})
+	}
+	resp, err := withMyUserID(client, api.RequestOptions{}, like)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"liked":true}}`, string(resp))
+	assert.Equal(t, "2", client.saved, "the stale cached ID is replaced")
+	require.Len(t, paths, 3)
+	assert.Equal(t, "/2/users/2/likes", paths[2])
+
+	t.Run("an uncached ID is not retried", func(t *testing.T) {
+		paths = nil
+		client.cached = ""
+		_, err := withMyUserID(client, api.RequestOptions{}, func(string) (json.RawMessage, error) {
+			return like("1")
+		})
+		assert.True(t, isForbidden(err))
+		assert.Len(t, paths, 2, "one /2/users/me lookup and one attempt")
+	})
+}
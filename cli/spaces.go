@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// spaceTimeLayout is how Space times are shown, in local time.
+const spaceTimeLayout = "Mon 2 Jan 2006 15:04 MST"
+
+// spaceUser is a user from a Spaces response's includes.
+type spaceUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+func (u spaceUser) String() string {
+	if u.Username == "" {
+		return u.ID
+	}
+	if u.Name == "" {
+		return "@" + u.Username
+	}
+	return u.Name + " (@" + u.Username + ")"
+}
+
+// space is a Space as returned by /2/spaces, with the IDs of its creator,
+// hosts and speakers joined to their users by joinSpaceUsers.
+type space struct {
+	ID               string   `json:"id"`
+	Title            string   `json:"title"`
+	State            string   `json:"state"`
+	CreatorID        string   `json:"creator_id"`
+	HostIDs          []string `json:"host_ids"`
+	SpeakerIDs       []string `json:"speaker_ids"`
+	ParticipantCount *int     `json:"participant_count"`
+	SubscriberCount  *int     `json:"subscriber_count"`
+	ScheduledStart   string   `json:"scheduled_start"`
+	StartedAt        string   `json:"started_at"`
+	EndedAt          string   `json:"ended_at"`
+	Lang             string   `json:"lang"`
+	IsTicketed       bool     `json:"is_ticketed"`
+
+	Creator  *spaceUser  `json:"-"`
+	Hosts    []spaceUser `json:"-"`
+	Speakers []spaceUser `json:"-"`
+}
+
+// CreateSpacesCommand creates the `spaces` command and its subcommands, which
+// search and show Spaces.
+func CreateSpacesCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spaces",
+		Short: "Search and show Spaces",
+		Long: `Search Spaces by title and show a Space by ID, as a readable summary: the
+title, hosts, state, participant count and start time (in local time). The
+creator, hosts and, with --with-speakers, the speakers are expanded so they
+are shown by name rather than by user ID. --json prints the raw response.`,
+		Example: `  xurl spaces search "golang" --state live
+  xurl spaces show 1DXxyRYNejbKM --with-speakers`,
+	}
+	cmd.AddCommand(spacesSearchCmd(a, g), spacesShowCmd(a, g))
+	return cmd
+}
+
+func spacesSearchCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var state string
+	var maxResults int
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   `search "KEYWORD"`,
+		Short: "Search Spaces by title",
+		Long: `Search live and scheduled Spaces whose title matches KEYWORD. --state limits
+the results to live or scheduled Spaces.`,
+		Example: `  xurl spaces search "golang"
+  xurl spaces search "product launch" --state scheduled --json`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			switch state {
+			case "", "all", "live", "scheduled":
+			default:
+				g.fprintError(os.Stderr, "Error: --state must be live, scheduled or all")
+				os.Exit(1)
+			}
+			resp, err := api.SearchSpaces(g.newClient(a), args[0], state, maxResults, g.baseOpts(cmd))
+			g.printSpacesResult(resp, err, asJSON)
+		},
+	}
+	cmd.Flags().StringVar(&state, "state", "", "Only show live or scheduled Spaces (live, scheduled, all)")
+	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 20, "Number of results (1–100)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the raw response instead of a summary")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func spacesShowCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var withSpeakers, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "show SPACE_ID",
+		Short: "Show a Space",
+		Long:  `Show a Space by ID. --with-speakers also lists its speakers.`,
+		Example: `  xurl spaces show 1DXxyRYNejbKM
+  xurl spaces show 1DXxyRYNejbKM --with-speakers`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			resp, err := api.GetSpace(g.newClient(a), args[0], withSpeakers, g.baseOpts(cmd))
+			g.printSpacesResult(resp, err, asJSON)
+		},
+	}
+	cmd.Flags().BoolVar(&withSpeakers, "with-speakers", false, "Expand and list the Space's speakers")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the raw response instead of a summary")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// printSpacesResult prints a Spaces response as a summary, or as it is with
+// asJSON, in a dry run or on error.
+func (g *globalOptions) printSpacesResult(resp json.RawMessage, err error, asJSON bool) {
+	if err != nil || asJSON || api.IsDryRun(resp) {
+		g.printResult(resp, err)
+		return
+	}
+	spaces, err := parseSpaces(resp)
+	if err != nil {
+		g.fprintError(os.Stderr, "Error: could not parse the Spaces response: %v", err)
+		os.Exit(1)
+	}
+	printSpaces(os.Stdout, spaces, time.Local)
+}
+
+// parseSpaces reads the Spaces of a lookup (one Space) or search (a list)
+// response and joins their user IDs to the users in includes.
+func parseSpaces(resp json.RawMessage) ([]space, error) {
+	var body struct {
+		Data     json.RawMessage `json:"data"`
+		Includes struct {
+			Users []spaceUser `json:"users"`
+		} `json:"includes"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return nil, err
+	}
+	var spaces []space
+	data := strings.TrimSpace(string(body.Data))
+	switch {
+	case data == "" || data == "null":
+	case strings.HasPrefix(data, "["):
+		if err := json.Unmarshal(body.Data, &spaces); err != nil {
+			return nil, err
+		}
+	default:
+		var s space
+		if err := json.Unmarshal(body.Data, &s); err != nil {
+			return nil, err
+		}
+		spaces = []space{s}
+	}
+	joinSpaceUsers(spaces, body.Includes.Users)
+	return spaces, nil
+}
+
+// joinSpaceUsers fills in the Creator, Hosts and Speakers of each Space from
+// users. An ID missing from users (not expanded, or a suspended account)
+// becomes a spaceUser with only the ID, so it is still shown.
+func joinSpaceUsers(spaces []space, users []spaceUser) {
+	byID := make(map[string]spaceUser, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	lookup := func(id string) spaceUser {
+		if u, ok := byID[id]; ok {
+			return u
+		}
+		return spaceUser{ID: id}
+	}
+	lookupAll := func(ids []string) []spaceUser {
+		var out []spaceUser
+		for _, id := range ids {
+			out = append(out, lookup(id))
+		}
+		return out
+	}
+	for i := range spaces {
+		s := &spaces[i]
+		if s.CreatorID != "" {
+			creator := lookup(s.CreatorID)
+			s.Creator = &creator
+		}
+		s.Hosts = lookupAll(s.HostIDs)
+		s.Speakers = lookupAll(s.SpeakerIDs)
+	}
+}
+
+// formatSpaceTime shows an API timestamp in loc, or as it is if it does not
+// parse.
+func formatSpaceTime(ts string, loc *time.Location) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.In(loc).Format(spaceTimeLayout)
+}
+
+// printSpaces writes a summary of each Space, with times in loc.
+func printSpaces(w io.Writer, spaces []space, loc *time.Location) {
+	if len(spaces) == 0 {
+		fmt.Fprintln(w, "No Spaces found.")
+		return
+	}
+	for i, s := range spaces {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		title := s.Title
+		if title == "" {
+			title = "(untitled Space)"
+		}
+		fmt.Fprintln(w, title)
+		line := func(label, value string) {
+			if value != "" {
+				fmt.Fprintf(w, "  %-14s%s\n", label+":", value)
+			}
+		}
+		line("ID", s.ID)
+		state := s.State
+		if s.IsTicketed {
+			state += ", ticketed"
+		}
+		line("State", state)
+
+		hosts := s.Hosts
+		if len(hosts) == 0 && s.Creator != nil {
+			hosts = []spaceUser{*s.Creator}
+		}
+		label := "Host"
+		if len(hosts) > 1 {
+			label = "Hosts"
+		}
+		line(label, joinSpaceUserNames(hosts))
+		if s.ParticipantCount != nil {
+			line("Participants", strconv.Itoa(*s.ParticipantCount))
+		}
+		if s.SubscriberCount != nil && s.State == "scheduled" {
+			line("Subscribers", strconv.Itoa(*s.SubscriberCount))
+		}
+		if s.ScheduledStart != "" {
+			line("Scheduled", formatSpaceTime(s.ScheduledStart, loc))
+		}
+		if s.StartedAt != "" {
+			line("Started", formatSpaceTime(s.StartedAt, loc))
+		}
+		if s.EndedAt != "" {
+			line("Ended", formatSpaceTime(s.EndedAt, loc))
+		}
+		line("Language", s.Lang)
+		line("Speakers", joinSpaceUserNames(s.Speakers))
+	}
+}
+
+func joinSpaceUserNames(users []spaceUser) string {
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spaceLookupFixture = `{
+  "data": {
+    "id": "1DXxyRYNejbKM",
+    "title": "Go office hours",
+    "state": "live",
+    "creator_id": "100",
+    "host_ids": ["100", "101"],
+    "speaker_ids": ["102", "999"],
+    "participant_count": 120,
+    "started_at": "2026-10-17T16:00:00.000Z",
+    "lang": "en"
+  },
+  "includes": {
+    "users": [
+      {"id": "100", "name": "Gopher", "username": "golang"},
+      {"id": "101", "name": "Rob", "username": "rob"},
+      {"id": "102", "username": "speaker"}
+    ]
+  }
+}`
+
+const spaceSearchFixture = `{
+  "data": [
+    {"id": "1", "title": "Launch", "state": "scheduled", "creator_id": "100",
+     "scheduled_start": "2026-12-01T09:30:00Z", "subscriber_count": 42, "is_ticketed": true},
+    {"id": "2", "state": "live", "creator_id": "200", "participant_count": 0}
+  ],
+  "includes": {"users": [{"id": "100", "name": "Gopher", "username": "golang"}]},
+  "meta": {"result_count": 2}
+}`
+
+func TestParseSpacesJoinsUsers(t *testing.T) {
+	spaces, err := parseSpaces(json.RawMessage(spaceLookupFixture))
+	require.NoError(t, err)
+	require.Len(t, spaces, 1)
+
+	s := spaces[0]
+	require.NotNil(t, s.Creator)
+	assert.Equal(t, "Gopher (@golang)", s.Creator.String())
+	assert.Equal(t, []spaceUser{
+		{ID: "100", Name: "Gopher", Username: "golang"},
+		{ID: "101", Name: "Rob", Username: "rob"},
+	}, s.Hosts)
+	assert.Equal(t, []spaceUser{
+		{ID: "102", Username: "speaker"},
+		{ID: "999"},
+	}, s.Speakers, "users missing from includes keep their ID")
+
+	spaces, err = parseSpaces(json.RawMessage(`{"meta":{"result_count":0}}`))
+	require.NoError(t, err)
+	assert.Empty(t, spaces)
+}
+
+func TestPrintSpaces(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	spaces, err := parseSpaces(json.RawMessage(spaceLookupFixture))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	printSpaces(&buf, spaces, berlin)
+	assert.Equal(t, `Go office hours
+  ID:           1DXxyRYNejbKM
+  State:        live
+  Hosts:        Gopher (@golang), Rob (@rob)
+  Participants: 120
+  Started:      Sat 17 Oct 2026 18:00 CEST
+  Language:     en
+  Speakers:     @speaker, 999
+`, buf.String())
+
+	spaces, err = parseSpaces(json.RawMessage(spaceSearchFixture))
+	require.NoError(t, err)
+	buf.Reset()
+	printSpaces(&buf, spaces, time.UTC)
+	assert.Equal(t, `Launch
+  ID:           1
+  State:        scheduled, ticketed
+  Host:         Gopher (@golang)
+  Subscribers:  42
+  Scheduled:    Tue 1 Dec 2026 09:30 UTC
+
+(untitled Space)
+  ID:           2
+  State:        live
+  Host:         200
+  Participants: 0
+`, buf.String())
+
+	buf.Reset()
+	printSpaces(&buf, nil, time.UTC)
+	assert.Equal(t, "No Spaces found.\n", buf.String())
+}
+
+func TestFormatSpaceTime(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, "Sun 18 Oct 2026 01:00 JST", formatSpaceTime("2026-10-17T16:00:00.000Z", tokyo))
+	assert.Equal(t, "not a time", formatSpaceTime("not a time", tokyo))
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/config"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// Baselines `xurl spec diff --against` accepts besides a file path.
+const (
+	specAgainstCached   = "cached"
+	specAgainstPrevious = "previous"
+)
+
+// specDiffResult is what `xurl spec diff --json` prints.
+type specDiffResult struct {
+	Baseline       string           `json:"baseline"`
+	CatalogChanged bool             `json:"catalog_changed"`
+	Changes        []api.SpecChange `json:"changes"`
+}
+
+// CreateSpecCommand creates the `spec` command, which works with the X API's
+// OpenAPI spec.
+func CreateSpecCommand(a *auth.Auth, cfg *config.Config, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spec",
+		Short: "Track changes to the X API's OpenAPI spec",
+		Long: `Track changes to the X API's OpenAPI spec.
+
+xurl keeps the last spec it fetched in the cache directory (see 'xurl config
+paths'), along with the version before the last change.`,
+		Example: `  xurl spec diff`,
+	}
+	cmd.AddCommand(specDiffCmd(a, cfg, g))
+	return cmd
+}
+
+func specDiffCmd(a *auth.Auth, cfg *config.Config, g *globalOptions) *cobra.Command {
+	var against, specFile string
+	var all, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the current OpenAPI spec with the cached one",
+		Long: `Fetch the X API's OpenAPI spec and compare it with the cached copy, operation
+by operation, then cache the new spec.
+
+For each added, removed or changed operation on an endpoint in xurl's catalog
+(see 'xurl endpoints') it prints what changed: parameters added, removed, made
+required or optional, or retyped; top-level request body fields likewise; and
+deprecation. Descriptions and examples are ignored. --all includes operations
+outside the catalog.
+
+--against previous compares with the version the cache held before the spec
+last changed, to see that change again; --against FILE compares with a saved
+spec. --spec FILE compares a saved spec instead of fetching one.
+
+Exits 1 when an endpoint in the catalog changed, so a scheduled job can alert
+on it, and 0 otherwise. The first run only fills the cache.`,
+		Example: `  xurl spec diff
+  xurl spec diff --against previous
+  xurl spec diff --all --json > spec-changes.json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var fresh []byte
+			var err error
+			if specFile != "" {
+				fresh, err = os.ReadFile(specFile)
+			} else {
+				authHeader, _ := a.GetBearerTokenHeader()
+				fresh, err = fetchSpec(g.transport, cfg.APIBaseURL, authHeader)
+			}
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+
+			result, err := diffSpec(fresh, against, store.SpecFilePath(), store.PreviousSpecFilePath())
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			if result == nil {
+				fmt.Fprintf(os.Stderr, "No cached spec to compare against yet; saved this one to %s\n", store.SpecFilePath())
+				return
+			}
+			if asJSON {
+				shown := *result
+				if !all {
+					shown.Changes = catalogSpecChanges(result.Changes)
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(shown); err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
+				}
+			} else {
+				printSpecDiff(os.Stdout, result, all)
+			}
+			if result.CatalogChanged {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&against, "against", specAgainstCached, "Spec to compare with: cached, previous or a file path")
+	cmd.Flags().StringVar(&specFile, "spec", "", "Compare this saved spec instead of fetching the current one")
+	cmd.Flags().BoolVar(&all, "all", false, "Also show changes to operations outside xurl's endpoint catalog")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the changes as JSON")
+	return cmd
+}
+
+// fetchSpec downloads the OpenAPI spec from baseURL, with authHeader when
+// set.
+func fetchSpec(transport http.RoundTripper, baseURL, authHeader string) ([]byte, error) {
+	target := strings.TrimRight(baseURL, "/") + api.SpecEndpoint
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	resp, err := (&http.Client{Transport: transport, Timeout: time.Minute}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s: %v", target, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s: %v", target, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch %s: %s", target, resp.Status)
+	}
+	return body, nil
+}
+
+// diffSpec compares fresh with the baseline named by against and then
+// caches fresh at cachePath, moving the spec it replaces to previousPath
+// when they differ. It returns nil when against is "cached" and there is no
+// cached spec yet.
+func diffSpec(fresh []byte, against, cachePath, previousPath string) (*specDiffResult, error) {
+	baselinePath := against
+	switch against {
+	case specAgainstCached:
+		baselinePath = cachePath
+	case specAgainstPrevious:
+		baselinePath = previousPath
+	}
+	baseline, err := os.ReadFile(baselinePath)
+	if os.IsNotExist(err) && against == specAgainstCached {
+		if !json.Valid(fresh) {
+			return nil, fmt.Errorf("the spec is not valid JSON")
+		}
+		return nil, updateSpecCache(fresh, cachePath, previousPath)
+	}
+	if os.IsNotExist(err) && against == specAgainstPrevious {
+		return nil, fmt.Errorf("no previous spec is cached yet; it is kept once the spec changes")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := api.DiffSpecs(baseline, fresh)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateSpecCache(fresh, cachePath, previousPath); err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []api.SpecChange{}
+	}
+	return &specDiffResult{
+		Baseline:       baselinePath,
+		CatalogChanged: len(catalogSpecChanges(changes)) > 0,
+		Changes:        changes,
+	}, nil
+}
+
+// updateSpecCache stores fresh at cachePath, first moving a different cached
+// spec to previousPath.
+func updateSpecCache(fresh []byte, cachePath, previousPath string) error {
+	cached, err := os.ReadFile(cachePath)
+	if err == nil && bytes.Equal(cached, fresh) {
+		return nil
+	}
+	if err == nil {
+		if err := os.WriteFile(previousPath, cached, 0600); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(cachePath, fresh, 0600)
+}
+
+// catalogSpecChanges returns the changes to operations in the catalog.
+func catalogSpecChanges(changes []api.SpecChange) []api.SpecChange {
+	out := []api.SpecChange{}
+	for _, c := range changes {
+		if c.InCatalog {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// printSpecDiff writes result for humans: one line per operation, marked
+// +, - or ~, followed by the details of changed ones.
+func printSpecDiff(w io.Writer, result *specDiffResult, all bool) {
+	fmt.Fprintf(w, "Compared with %s\n", result.Baseline)
+	shown := result.Changes
+	if !all {
+		shown = catalogSpecChanges(result.Changes)
+	}
+	for _, c := range shown {
+		mark := map[string]string{"added": "+", "removed": "-", "changed": "~"}[c.Kind]
+		note := ""
+		if all && !c.InCatalog {
+			note = " (not in catalog)"
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", mark, c.Method, c.Path, note)
+		for _, d := range c.Details {
+			fmt.Fprintf(w, "    %s\n", d)
+		}
+	}
+	others := len(result.Changes) - len(catalogSpecChanges(result.Changes))
+	switch {
+	case len(result.Changes) == 0:
+		fmt.Fprintln(w, "No changes.")
+	case !result.CatalogChanged:
+		fmt.Fprintf(w, "No changes to endpoints in xurl's catalog (%d other changes; --all shows them).\n", others)
+	case others > 0 && !all:
+		fmt.Fprintf(w, "%d other changes outside the catalog; --all shows them.\n", others)
+	}
+}
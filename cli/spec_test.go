@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSpecV1 = `{"paths": {"/2/users/{id}/followers": {"get": {"parameters": [{"name": "max_results", "in": "query"}]}}, "/2/other": {"get": {}}}}`
+	testSpecV2 = `{"paths": {"/2/users/{id}/followers": {"get": {"parameters": [{"name": "max_results", "in": "query", "required": true}]}}, "/2/other": {"get": {}}}}`
+	testSpecV3 = `{"paths": {"/2/users/{id}/followers": {"get": {"parameters": [{"name": "max_results", "in": "query", "required": true}]}}, "/2/other": {"post": {}}}}`
+)
+
+func TestDiffSpecCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "openapi.json")
+	previous := filepath.Join(dir, "openapi.previous.json")
+
+	// The first run only fills the cache.
+	result, err := diffSpec([]byte(testSpecV1), specAgainstCached, cache, previous)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	_, err = diffSpec([]byte(testSpecV1), specAgainstPrevious, cache, previous)
+	assert.ErrorContains(t, err, "no previous spec")
+
+	result, err = diffSpec([]byte(testSpecV1), specAgainstCached, cache, previous)
+	require.NoError(t, err)
+	assert.False(t, result.CatalogChanged)
+	assert.Empty(t, result.Changes)
+
+	result, err = diffSpec([]byte(testSpecV2), specAgainstCached, cache, previous)
+	require.NoError(t, err)
+	assert.True(t, result.CatalogChanged)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, []string{"~ parameter query max_results: now required"}, result.Changes[0].Details)
+
+	var out bytes.Buffer
+	printSpecDiff(&out, result, false)
+	assert.Equal(t, "Compared with "+cache+"\n~ GET /2/users/{id}/followers\n    ~ parameter query max_results: now required\n", out.String())
+
+	cached, _ := os.ReadFile(cache)
+	assert.Equal(t, testSpecV2, string(cached))
+	prev, _ := os.ReadFile(previous)
+	assert.Equal(t, testSpecV1, string(prev))
+
+	// An unchanged spec keeps the previous version, so --against previous
+	// shows the last change again.
+	result, err = diffSpec([]byte(testSpecV2), specAgainstPrevious, cache, previous)
+	require.NoError(t, err)
+	assert.True(t, result.CatalogChanged)
+	prev, _ = os.ReadFile(previous)
+	assert.Equal(t, testSpecV1, string(prev))
+
+	// Changes outside the catalog do not count.
+	result, err = diffSpec([]byte(testSpecV3), specAgainstCached, cache, previous)
+	require.NoError(t, err)
+	assert.False(t, result.CatalogChanged)
+	assert.Len(t, result.Changes, 2)
+	out.Reset()
+	printSpecDiff(&out, result, false)
+	assert.Contains(t, out.String(), "No changes to endpoints in xurl's catalog (2 other changes; --all shows them).")
+	out.Reset()
+	printSpecDiff(&out, result, true)
+	assert.Contains(t, out.String(), "+ POST /2/other (not in catalog)")
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// threadSeparator is the line that separates the posts of a thread file
+// whose posts span several lines.
+const threadSeparator = "---"
+
+// CreateThreadCommand creates the `thread` command, which posts a thread: a
+// post and a chain of replies, each to the one before it.
+func CreateThreadCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var fromFile string
+	cmd := &cobra.Command{
+		Use:   "thread --from-file FILE",
+		Short: "Post a thread, each post replying to the one before",
+		Long: `Post a thread from a file: the first post, then each following one as a
+reply to the post before it. The ID of every post is printed as it is posted.
+
+In the file, each line is a post. If any line is exactly '---', the posts are
+the blocks between those lines instead, so a post can span several lines.
+Blank lines around posts are ignored. Use --from-file - to read stdin.
+
+Every post is checked like 'xurl tweets post' checks one before anything is
+sent. If a post fails, the thread stops there; xurl reports which posts were
+posted, and their IDs, and exits non-zero.`,
+		Example: `  xurl thread --from-file thread.txt
+  printf 'First\n---\nSecond,\nover two lines\n' | xurl thread --from-file -`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if fromFile == "" {
+				g.fprintError(os.Stderr, "Error: --from-file is required")
+				os.Exit(1)
+			}
+			texts, err := readThreadFile(fromFile, os.Stdin)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			ids, err := postThread(g.newClient(a), texts, g.baseOpts(cmd), func(i int, id string, resp json.RawMessage) {
+				if api.IsDryRun(resp) {
+					g.format.Print(resp)
+					return
+				}
+				fmt.Println(id)
+			})
+			if err != nil {
+				if len(ids) > 0 {
+					fmt.Fprintf(os.Stderr, "Posted %d of %d posts of the thread: %s\n", len(ids), len(texts), strings.Join(ids, ", "))
+				}
+				fmt.Fprintf(os.Stderr, "Post %d of the thread failed.\n", len(ids)+1)
+				g.printResult(nil, err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "File with the posts of the thread, one per line or separated by '---' lines (- for stdin)")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// readThreadFile reads the posts of a thread from path, or from stdin when
+// path is "-", and checks each of them.
+func readThreadFile(path string, stdin io.Reader) ([]string, error) {
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = io.ReadAll(stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the thread: %v", err)
+	}
+	texts := splitThread(string(raw))
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("%s has no posts", path)
+	}
+	for i, text := range texts {
+		if _, err := buildTweetBody(tweetParams{Text: text}); err != nil {
+			return nil, fmt.Errorf("post %d of the thread: %v", i+1, err)
+		}
+	}
+	return texts, nil
+}
+
+// splitThread splits a thread file into posts: the blocks between '---'
+// lines if there are any, and otherwise its lines. Blank posts are dropped.
+func splitThread(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	blocks := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == threadSeparator {
+			blocks = true
+			break
+		}
+	}
+
+	var texts []string
+	var block []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(block, "\n")); text != "" {
+			texts = append(texts, text)
+		}
+		block = nil
+	}
+	for _, line := range lines {
+		switch {
+		case !blocks:
+			block = append(block, line)
+			flush()
+		case strings.TrimSpace(line) == threadSeparator:
+			flush()
+		default:
+			block = append(block, line)
+		}
+	}
+	flush()
+	return texts
+}
+
+// postThread posts texts in order, each but the first as a reply to the
+// post before it, and returns the IDs of the posts it posted. onPosted is
+// called with each post's ID and response. It stops at the first failure,
+// returning the IDs posted until then. In a dry run nothing has an ID, so
+// each reply names the post it would reply to instead.
+func postThread(client api.Client, texts []string, opts api.RequestOptions, onPosted func(i int, id string, resp json.RawMessage)) ([]string, error) {
+	var ids []string
+	replyTo := ""
+	for i, text := range texts {
+		body, err := buildTweetBody(tweetParams{Text: text, ReplyTo: replyTo})
+		if err != nil {
+			return ids, err
+		}
+		resp, err := sendTweet(client, body, opts)
+		if err != nil {
+			return ids, err
+		}
+		if api.IsDryRun(resp) {
+			replyTo = fmt.Sprintf("ID_OF_POST_%d", i+1)
+			onPosted(i, "", resp)
+			continue
+		}
+
+		var created struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(resp, &created) != nil || created.Data.ID == "" {
+			return ids, fmt.Errorf("the response has no post ID: %s", resp)
+		}
+		ids = append(ids, created.Data.ID)
+		replyTo = created.Data.ID
+		onPosted(i, created.Data.ID, resp)
+	}
+	return ids, nil
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestSplitThread(t *testing.T) {
+	assert.Equal(t, []string{"one", "two", "three"}, splitThread("one\n\ntwo\r\nthree\n"))
+	assert.Equal(t, []string{"first\nstill first", "second"}, splitThread("\nfirst\nstill first\n---\n\nsecond\n---\n"))
+	assert.Empty(t, splitThread("\n  \n"))
+}
+
+func TestReadThreadFile(t *testing.T) {
+	texts, err := readThreadFile("-", strings.NewReader("a\n---\nb\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, texts)
+
+	path := filepath.Join(t.TempDir(), "thread.txt")
+	require.NoError(t, os.WriteFile(path, []byte("ok\n"+strings.Repeat("x", 281)+"\n"), 0600))
+	_, err = readThreadFile(path, nil)
+	assert.ErrorContains(t, err, "post 2 of the thread: the post is too long")
+
+	_, err = readThreadFile("-", strings.NewReader("\n"))
+	assert.ErrorContains(t, err, "no posts")
+}
+
+// threadTestClient is a mock of POST /2/tweets that numbers the posts it
+// creates from 100 and fails the one with failText.
+func threadTestClient(sent *[]string, failText string) fakeClient {
+	return fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			if options.Method != "POST" || options.Endpoint != "/2/tweets" {
+				return nil, errors.New("unexpected request")
+			}
+			if strings.Contains(options.Data, failText) {
+				return nil, errors.New(`{"title":"Forbidden","status":403}`)
+			}
+			*sent = append(*sent, options.Data)
+			return json.RawMessage(`{"data":{"id":"` + strconv.Itoa(100*len(*sent)) + `","text":"..."}}`), nil
+		},
+	}
+}
+
+func TestPostThread(t *testing.T) {
+	t.Run("each post replies to the one before", func(t *testing.T) {
+		var sent, printed []string
+		ids, err := postThread(threadTestClient(&sent, "\x00"), []string{"one", "two", "three"}, api.RequestOptions{}, func(i int, id string, resp json.RawMessage) {
+			printed = append(printed, id)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"100", "200", "300"}, ids)
+		assert.Equal(t, ids, printed)
+		require.Len(t, sent, 3)
+		assert.JSONEq(t, `{"text":"one"}`, sent[0])
+		assert.JSONEq(t, `{"text":"two","reply":{"in_reply_to_tweet_id":"100"}}`, sent[1])
+		assert.JSONEq(t, `{"text":"three","reply":{"in_reply_to_tweet_id":"200"}}`, sent[2])
+	})
+
+	t.Run("a failure reports the posts before it", func(t *testing.T) {
+		var sent []string
+		ids, err := postThread(threadTestClient(&sent, "three"), []string{"one", "two", "three", "four"}, api.RequestOptions{}, func(int, string, json.RawMessage) {})
+		assert.ErrorContains(t, err, "Forbidden")
+		assert.Equal(t, []string{"100", "200"}, ids)
+		assert.Len(t, sent, 2, "nothing is posted after the failure")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var sent []string
+		client := fakeClient{sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			sent = append(sent, options.Data)
+			return json.RawMessage(`{"dry_run":true}`), nil
+		}}
+		ids, err := postThread(client, []string{"one", "two"}, api.RequestOptions{DryRun: true}, func(int, string, json.RawMessage) {})
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+		assert.JSONEq(t, `{"text":"two","reply":{"in_reply_to_tweet_id":"ID_OF_POST_1"}}`, sent[1])
+	})
+}
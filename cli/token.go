@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -11,8 +12,10 @@ import (
 
 // CreateTokenCommand creates the `token` command, which prints a valid OAuth2
 // access token for the active app to stdout. It refreshes (and persists) an
-// expired token but never opens a browser, so it stays scriptable.
-func CreateTokenCommand(a *auth.Auth) *cobra.Command {
+// expired token but never opens a browser, so it stays scriptable. The same
+// command is registered as `auth token`.
+func CreateTokenCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var exportEnv, force, verbose bool
 	cmd := &cobra.Command{
 		Use:   "token",
 		Short: "Print a valid OAuth2 access token for the active app",
@@ -22,34 +25,88 @@ If the stored token has expired it is refreshed and persisted first. This
 command never opens a browser, so it is safe to use in scripts. If no token is
 available it exits non-zero and tells you to run 'xurl auth oauth2'.
 
-Examples:
-  xurl token
+--export-env prints 'export X_ACCESS_TOKEN=...' instead, for a shell to
+eval, and nothing else: no color, and notes such as --verbose's go to
+stderr. To keep the token out of a terminal's scrollback it is not printed
+when stdout is a terminal; pass --force to print it anyway.`,
+		Example: `  xurl token
   xurl token --app my-app
-  TOKEN=$(xurl token)`,
+  TOKEN=$(xurl token)
+  eval "$(xurl auth token --export-env -u alice)"`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			username, _ := cmd.Flags().GetString("username")
 
-			token, err := a.GetValidOAuth2Token(username)
-			if err != nil {
-				appName := a.TokenStore.GetActiveAppName(a.AppName())
-				target := fmt.Sprintf("app %q", appName)
-				if username != "" {
-					target = fmt.Sprintf("app %q (user %q)", appName, username)
+			if exportEnv {
+				if err := checkExportTarget(isTerminal(os.Stdout), force); err != nil {
+					g.fprintError(os.Stderr, "Error: %v", err)
+					os.Exit(1)
 				}
-				fprintError(os.Stderr, "Error: no valid oauth2 token for %s: %v", target, err)
+			}
+			if err := printToken(os.Stdout, os.Stderr, a, username, exportEnv, verbose); err != nil {
+				g.fprintError(os.Stderr, "Error: no valid oauth2 token for %s: %v", tokenTarget(a, username), err)
 				fmt.Fprintf(os.Stderr, "Run: xurl auth oauth2%s\n", appFlagHint(a.AppName()))
 				os.Exit(1)
 			}
-
-			fmt.Println(token)
 		},
 	}
 
 	cmd.Flags().StringP("username", "u", "", "OAuth2 username to act as")
+	cmd.Flags().BoolVar(&exportEnv, "export-env", false, "Print 'export X_ACCESS_TOKEN=...' for a shell to eval, e.g. eval \"$(xurl auth token --export-env)\"")
+	cmd.Flags().BoolVar(&force, "force", false, "With --export-env, print the token even when stdout is a terminal")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print which app and user the token belongs to, to stderr")
 	return cmd
 }
 
+// printToken writes a valid OAuth2 token for username to stdout: the bare
+// token, or with exportEnv the export line of writeTokenExports. stdout gets
+// nothing else, so it can be captured or eval'd; the verbose note goes to
+// stderr.
+func printToken(stdout, stderr io.Writer, a *auth.Auth, username string, exportEnv, verbose bool) error {
+	token, err := a.GetValidOAuth2Token(username)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		stored := username
+		if stored == "" {
+			stored, _ = a.TokenStore.GetFirstOAuth2TokenRecordForApp(a.AppName())
+		}
+		fmt.Fprintf(stderr, "Using the OAuth2 token of %s\n", tokenTarget(a, stored))
+	}
+	if exportEnv {
+		writeTokenExports(stdout, token)
+	} else {
+		fmt.Fprintln(stdout, token)
+	}
+	return nil
+}
+
+// writeTokenExports writes the shell lines that export an OAuth2 access
+// token, as `xurl token --export-env` and `xurl auth oauth2 --export-env`
+// print them.
+func writeTokenExports(w io.Writer, token string) {
+	fmt.Fprintf(w, "export X_ACCESS_TOKEN=%s\n", shellQuote(token))
+}
+
+// checkExportTarget refuses to print --export-env lines to a terminal, where
+// the token would stay in the scrollback, unless force is set.
+func checkExportTarget(terminal, force bool) error {
+	if terminal && !force {
+		return fmt.Errorf("not printing a token to a terminal; eval it, as in eval \"$(xurl auth token --export-env)\", or pass --force")
+	}
+	return nil
+}
+
+// tokenTarget names the app, and the user when given, a token is for.
+func tokenTarget(a *auth.Auth, username string) string {
+	appName := a.TokenStore.GetActiveAppName(a.AppName())
+	if username != "" {
+		return fmt.Sprintf("app %q (user %q)", appName, username)
+	}
+	return fmt.Sprintf("app %q", appName)
+}
+
 // appFlagHint returns a " --app NAME" suffix for help messages when an explicit
 // app override is active, or an empty string otherwise.
 func appFlagHint(appName string) string {
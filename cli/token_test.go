@@ -1,12 +1,36 @@
 package cli
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAppFlagHint(t *testing.T) {
 	assert.Equal(t, "", appFlagHint(""), "no app override yields no hint")
 	assert.Equal(t, " --app my-app", appFlagHint("my-app"))
 }
+
+func TestPrintTokenExportEnv(t *testing.T) {
+	a := mcpTestAuth(t, "tok/en+1=")
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, printToken(&stdout, &stderr, a, "", true, true))
+	assert.Equal(t, "export X_ACCESS_TOKEN='tok/en+1='\n", stdout.String(), "only the export line, even with --verbose")
+	assert.Contains(t, stderr.String(), `app "default" (user "alice")`)
+
+	stdout.Reset()
+	require.NoError(t, printToken(&stdout, &stderr, a, "alice", false, false))
+	assert.Equal(t, "tok/en+1=\n", stdout.String())
+
+	stdout.Reset()
+	assert.Error(t, printToken(&stdout, &stderr, a, "bob", true, false))
+	assert.Empty(t, stdout.String())
+}
+
+func TestCheckExportTarget(t *testing.T) {
+	assert.NoError(t, checkExportTarget(false, false), "a pipe or $(...) is fine")
+	assert.ErrorContains(t, checkExportTarget(true, false), "--force")
+	assert.NoError(t, checkExportTarget(true, true))
+}
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// worldwideWOEID is the WOEID of the worldwide trends, used when no place is
+// given.
+const worldwideWOEID = 1
+
+// knownPlaces maps common place names, normalized with store.NormalizePlace,
+// to their WOEIDs. Other places are remembered in the place cache once given
+// with --woeid.
+var knownPlaces = map[string]int{
+	"worldwide":      worldwideWOEID,
+	"united states":  23424977,
+	"usa":            23424977,
+	"united kingdom": 23424975,
+	"uk":             23424975,
+	"canada":         23424775,
+	"mexico":         23424900,
+	"brazil":         23424768,
+	"germany":        23424829,
+	"france":         23424819,
+	"spain":          23424950,
+	"india":          23424848,
+	"japan":          23424856,
+	"australia":      23424748,
+	"new york":       2459115,
+	"nyc":            2459115,
+	"los angeles":    2442047,
+	"chicago":        2379574,
+	"san francisco":  2487956,
+	"seattle":        2490383,
+	"boston":         2367105,
+	"toronto":        4118,
+	"london":         44418,
+	"paris":          615702,
+	"berlin":         638242,
+	"madrid":         766273,
+	"mumbai":         2295411,
+	"tokyo":          1118370,
+	"sydney":         1105779,
+	"sao paulo":      455827,
+	"são paulo":      455827,
+}
+
+// trendVolume is a trend's post count, which the trends endpoint sends as a
+// number and the personalized endpoint as text such as "31.5K posts".
+type trendVolume string
+
+func (v *trendVolume) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = trendVolume(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	*v = trendVolume(data)
+	return nil
+}
+
+// trend is one row of a trends response.
+type trend struct {
+	Name       string      `json:"trend_name"`
+	TweetCount trendVolume `json:"tweet_count"`
+	PostCount  trendVolume `json:"post_count"`
+	Category   string      `json:"category"`
+}
+
+func (t trend) volume() string {
+	if t.TweetCount != "" {
+		return string(t.TweetCount)
+	}
+	return string(t.PostCount)
+}
+
+// trendsCmd creates the `trends` command, which shows the trending topics of
+// a place or the user's personalized trends.
+func trendsCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var woeid, maxTrends int
+	var place, filter string
+	var personalized, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "trends",
+		Short: "Show trending topics for a place",
+		Long: `Show the trending topics of a place, by WOEID (Yahoo! Where On Earth ID) or
+by name, as a table of trend names and post volumes. Without a place the
+worldwide trends are shown; --personalized shows the trends personalized for
+the authenticated user instead.
+
+--place knows a few dozen countries and major cities. For any other place,
+give its WOEID and a name once with --woeid and --place together; the name
+is remembered in places.yml in the cache directory, so --place alone works
+from then on.
+
+--filter keeps only trends whose name contains the given text (ignoring
+case), and applies to --json output as well.`,
+		Example: `  xurl trends
+  xurl trends --place "new york"
+  xurl trends --woeid 2459115 --place home
+  xurl trends --place home --filter golang
+  xurl trends --personalized --json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if personalized && (woeid != 0 || place != "") {
+				g.fprintError(os.Stderr, "Error: --personalized cannot be combined with --woeid or --place")
+				os.Exit(1)
+			}
+			client := g.newClient(a)
+			opts := g.baseOpts(cmd)
+
+			var resp json.RawMessage
+			var err error
+			if personalized {
+				resp, err = api.GetPersonalizedTrends(client, opts)
+			} else {
+				id, rerr := resolveWOEID(woeid, place, store.NewPlaceCache())
+				if rerr != nil {
+					g.fprintError(os.Stderr, "Error: %v", rerr)
+					os.Exit(1)
+				}
+				resp, err = api.GetTrends(client, id, maxTrends, opts)
+			}
+			if err != nil || api.IsDryRun(resp) {
+				g.printResult(resp, err)
+				return
+			}
+
+			if asJSON {
+				filtered, err := filterTrendsJSON(resp, filter)
+				if err != nil {
+					g.fprintError(os.Stderr, "Error: could not parse the trends response: %v", err)
+					os.Exit(1)
+				}
+				g.format.Print(filtered)
+				return
+			}
+			trends, err := parseTrends(resp)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: could not parse the trends response: %v", err)
+				os.Exit(1)
+			}
+			printTrends(os.Stdout, filterTrends(trends, filter))
+		},
+	}
+	cmd.Flags().IntVar(&woeid, "woeid", 0, "WOEID of the place (1 is worldwide); with --place, remember it under that name")
+	cmd.Flags().StringVar(&place, "place", "", `Name of the place, e.g. "new york" or a name saved with --woeid`)
+	cmd.Flags().BoolVar(&personalized, "personalized", false, "Show the trends personalized for the authenticated user")
+	cmd.Flags().IntVarP(&maxTrends, "max-trends", "n", 20, "Number of trends (1–50)")
+	cmd.Flags().StringVar(&filter, "filter", "", "Only show trends whose name contains this text")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the response as JSON instead of a table")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// resolveWOEID returns the WOEID to look up. A WOEID given with a place name
+// is remembered in cache under that name; a name alone is looked up in
+// knownPlaces, then in cache. With neither, the worldwide WOEID is used.
+func resolveWOEID(woeid int, place string, cache *store.PlaceCache) (int, error) {
+	if woeid < 0 {
+		return 0, fmt.Errorf("--woeid must be positive")
+	}
+	if woeid > 0 {
+		if place != "" {
+			cache.Remember(place, woeid)
+		}
+		return woeid, nil
+	}
+	if place == "" {
+		return worldwideWOEID, nil
+	}
+	if id, ok := knownPlaces[store.NormalizePlace(place)]; ok {
+		return id, nil
+	}
+	if id, ok := cache.Lookup(place); ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown place %q; give its WOEID once with --woeid N --place %q to remember it (known places: %s)",
+		place, place, strings.Join(knownPlaceNames(), ", "))
+}
+
+// knownPlaceNames lists the names in knownPlaces, sorted.
+func knownPlaceNames() []string {
+	names := make([]string, 0, len(knownPlaces))
+	for name := range knownPlaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTrends reads the trends of a trends or personalized trends response.
+func parseTrends(resp json.RawMessage) ([]trend, error) {
+	var body struct {
+		Data []trend `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// filterTrends keeps the trends whose name contains filter, ignoring case.
+func filterTrends(trends []trend, filter string) []trend {
+	if filter == "" {
+		return trends
+	}
+	filter = strings.ToLower(filter)
+	var kept []trend
+	for _, t := range trends {
+		if strings.Contains(strings.ToLower(t.Name), filter) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// filterTrendsJSON applies filterTrends to the data of a response, keeping
+// the rest of it as it is.
+func filterTrendsJSON(resp json.RawMessage, filter string) (json.RawMessage, error) {
+	if filter == "" {
+		return resp, nil
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if data, ok := body["data"]; ok {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+	}
+	kept := []json.RawMessage{}
+	for _, item := range items {
+		var t trend
+		if err := json.Unmarshal(item, &t); err != nil {
+			return nil, err
+		}
+		if len(filterTrends([]trend{t}, filter)) == 1 {
+			kept = append(kept, item)
+		}
+	}
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return nil, err
+	}
+	body["data"] = data
+	return json.Marshal(body)
+}
+
+// printTrends writes the trends as a table of rank, name and volume.
+func printTrends(w io.Writer, trends []trend) {
+	if len(trends) == 0 {
+		fmt.Fprintln(w, "No trends.")
+		return
+	}
+	showCategory := false
+	for _, t := range trends {
+		showCategory = showCategory || t.Category != ""
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if showCategory {
+		fmt.Fprintln(tw, "#\tTREND\tPOSTS\tCATEGORY")
+	} else {
+		fmt.Fprintln(tw, "#\tTREND\tPOSTS")
+	}
+	for i, t := range trends {
+		volume := t.volume()
+		if volume == "" {
+			volume = "-"
+		}
+		row := strconv.Itoa(i+1) + "\t" + t.Name + "\t" + volume
+		if showCategory {
+			row += "\t" + t.Category
+		}
+		fmt.Fprintln(tw, row)
+	}
+	tw.Flush()
+}
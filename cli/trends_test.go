@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/store"
+)
+
+func TestResolveWOEID(t *testing.T) {
+	cache := store.NewPlaceCacheWithPath(filepath.Join(t.TempDir(), "places.yml"))
+
+	id, err := resolveWOEID(0, "", cache)
+	require.NoError(t, err)
+	assert.Equal(t, worldwideWOEID, id)
+
+	id, err = resolveWOEID(0, "  New York ", cache)
+	require.NoError(t, err)
+	assert.Equal(t, 2459115, id)
+
+	_, err = resolveWOEID(0, "Springfield", cache)
+	assert.ErrorContains(t, err, `unknown place "Springfield"`)
+
+	// A WOEID given with a name is remembered under that name.
+	id, err = resolveWOEID(2508428, "Springfield", cache)
+	require.NoError(t, err)
+	assert.Equal(t, 2508428, id)
+	id, err = resolveWOEID(0, "springfield", cache)
+	require.NoError(t, err)
+	assert.Equal(t, 2508428, id)
+
+	_, err = resolveWOEID(-1, "", cache)
+	assert.Error(t, err)
+}
+
+func TestParseAndPrintTrends(t *testing.T) {
+	resp := json.RawMessage(`{"data":[
+		{"trend_name":"#GoLang","tweet_count":12000},
+		{"trend_name":"Rust"},
+		{"trend_name":"golang generics","tweet_count":"3400"}
+	]}`)
+	trends, err := parseTrends(resp)
+	require.NoError(t, err)
+	require.Len(t, trends, 3)
+
+	var buf bytes.Buffer
+	printTrends(&buf, trends)
+	assert.Equal(t, "#  TREND            POSTS\n"+
+		"1  #GoLang          12000\n"+
+		"2  Rust             -\n"+
+		"3  golang generics  3400\n", buf.String())
+
+	buf.Reset()
+	printTrends(&buf, filterTrends(trends, "GOLANG"))
+	assert.Contains(t, buf.String(), "#GoLang")
+	assert.NotContains(t, buf.String(), "Rust")
+
+	buf.Reset()
+	printTrends(&buf, filterTrends(trends, "python"))
+	assert.Equal(t, "No trends.\n", buf.String())
+}
+
+func TestPrintPersonalizedTrends(t *testing.T) {
+	trends, err := parseTrends(json.RawMessage(`{"data":[
+		{"trend_name":"Go 1.25","post_count":"31.5K posts","category":"Technology"}
+	]}`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	printTrends(&buf, trends)
+	assert.Equal(t, "#  TREND    POSTS        CATEGORY\n"+
+		"1  Go 1.25  31.5K posts  Technology\n", buf.String())
+}
+
+func TestFilterTrendsJSON(t *testing.T) {
+	resp := json.RawMessage(`{"data":[{"trend_name":"#GoLang","tweet_count":1},{"trend_name":"Rust"}],"meta":{"x":1}}`)
+
+	filtered, err := filterTrendsJSON(resp, "golang")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"trend_name":"#GoLang","tweet_count":1}],"meta":{"x":1}}`, string(filtered))
+
+	filtered, err = filterTrendsJSON(resp, "python")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[],"meta":{"x":1}}`, string(filtered))
+
+	unchanged, err := filterTrendsJSON(resp, "")
+	require.NoError(t, err)
+	assert.Equal(t, resp, unchanged)
+}
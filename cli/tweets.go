@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// Limits checked locally before a post is sent, so a bad payload fails fast
+// with a clear message instead of an API 400.
+const (
+	maxPostLength       = 280
+	maxPostMedia        = 4
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5
+	maxPollDuration     = 7 * 24 * 60
+	// postURLLength is what every link counts as, whatever its real length
+	// (links are shortened to t.co).
+	postURLLength = 23
+)
+
+// tweetParams are the inputs of `xurl tweets post`.
+type tweetParams struct {
+	Text         string
+	ReplyTo      string
+	Quote        string
+	MediaIDs     []string
+	PollOptions  []string
+	PollDuration int
+}
+
+// CreateTweetsCommand creates the tweets command and its subcommands
+func CreateTweetsCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tweets",
+		Short: "Post and delete posts with full payload options",
+		Long: `Post and delete posts without writing JSON by hand.
+
+'tweets post' builds the /2/tweets payload from flags (reply, quote, media,
+poll) and checks it locally first: the weighted text length, the number of
+media, and the poll constraints.`,
+		Example: `  xurl tweets post "Hello world!"
+  xurl tweets post "Which one?" --poll "Go,Rust" --poll-duration 1440
+  xurl tweets delete 1234567890`,
+	}
+	cmd.AddCommand(createTweetsPostCmd(a, g))
+	cmd.AddCommand(createTweetsDeleteCmd(a, g))
+	return cmd
+}
+
+func createTweetsPostCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var p tweetParams
+	var poll string
+
+	cmd := &cobra.Command{
+		Use:   `post "TEXT"`,
+		Short: "Post to X, optionally as a reply, quote, with media or a poll",
+		Long: `Post to X. The JSON payload is built from the flags and validated before
+anything is sent:
+  - the text may be at most 280 characters, where most CJK characters and
+    emoji count as two and every link counts as 23;
+  - at most 4 media IDs may be attached, and not together with a poll;
+  - a poll has 2 to 4 comma-separated options of at most 25 characters each,
+    and runs for 5 to 10080 minutes (default 1440, one day).
+
+--reply-to and --quote accept a post ID or URL.`,
+		Example: `  xurl tweets post "Hello world!"
+  xurl tweets post "Agreed" --reply-to 1234567890
+  xurl tweets post "Look at this" --quote https://x.com/user/status/1234567890
+  xurl tweets post "Photos" --media-ids 111,222
+  xurl tweets post "Tabs or spaces?" --poll "Tabs,Spaces" --poll-duration 60`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			p.Text = args[0]
+			if poll != "" {
+				p.PollOptions = strings.Split(poll, ",")
+			} else if cmd.Flags().Changed("poll-duration") {
+				g.fprintError(os.Stderr, "Error: --poll-duration requires --poll")
+				os.Exit(1)
+			}
+			body, err := buildTweetBody(p)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: %v", err)
+				os.Exit(1)
+			}
+			g.printResult(sendTweet(g.newClient(a), body, g.baseOpts(cmd)))
+		},
+	}
+	cmd.Flags().StringVar(&p.ReplyTo, "reply-to", "", "Post ID or URL to reply to")
+	cmd.Flags().StringVar(&p.Quote, "quote", "", "Post ID or URL to quote")
+	cmd.Flags().StringSliceVar(&p.MediaIDs, "media-ids", nil, "Comma-separated media IDs to attach (at most 4)")
+	cmd.Flags().StringVar(&poll, "poll", "", "Comma-separated poll options (2 to 4)")
+	cmd.Flags().IntVar(&p.PollDuration, "poll-duration", 24*60, "Poll duration in minutes (5 to 10080)")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+func createTweetsDeleteCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "delete POST_ID_OR_URL",
+		Short: "Delete a post",
+		Long: `Delete one of your posts. Accepts a post ID or full URL. xurl asks first;
+--yes skips the question, and is required when not running in a terminal.`,
+		Example: `  xurl tweets delete 1234567890
+  xurl tweets delete https://x.com/user/status/1234567890 --yes`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := g.baseOpts(cmd)
+			if !confirmDestructive(yes || opts.DryRun, "delete a post", "post "+api.ResolvePostID(args[0])) {
+				return
+			}
+			g.printResult(api.DeletePost(g.newClient(a), args[0], opts))
+		},
+	}
+	addYesFlag(cmd, &yes)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// buildTweetBody validates p and turns it into a /2/tweets payload.
+func buildTweetBody(p tweetParams) (api.PostBody, error) {
+	body := api.PostBody{Text: p.Text}
+
+	if strings.TrimSpace(p.Text) == "" && len(p.MediaIDs) == 0 {
+		return body, fmt.Errorf("the post text is empty")
+	}
+	if n := weightedLength(p.Text); n > maxPostLength {
+		return body, fmt.Errorf("the post is too long: %d characters (weighted), the limit is %d", n, maxPostLength)
+	}
+
+	if p.ReplyTo != "" {
+		body.Reply = &api.PostReply{InReplyToPostID: api.ResolvePostID(p.ReplyTo)}
+	}
+	if p.Quote != "" {
+		id := api.ResolvePostID(p.Quote)
+		body.Quote = &id
+	}
+
+	if len(p.MediaIDs) > 0 {
+		if len(p.MediaIDs) > maxPostMedia {
+			return body, fmt.Errorf("%d media IDs given, at most %d can be attached", len(p.MediaIDs), maxPostMedia)
+		}
+		for _, id := range p.MediaIDs {
+			if !isNumericID(id) {
+				return body, fmt.Errorf("invalid media ID %q", id)
+			}
+		}
+		body.Media = &api.PostMedia{MediaIDs: p.MediaIDs}
+	}
+
+	if len(p.PollOptions) > 0 {
+		if body.Media != nil {
+			return body, fmt.Errorf("a post cannot have both media and a poll")
+		}
+		if len(p.PollOptions) < minPollOptions || len(p.PollOptions) > maxPollOptions {
+			return body, fmt.Errorf("a poll needs %d to %d options, got %d", minPollOptions, maxPollOptions, len(p.PollOptions))
+		}
+		options := make([]string, len(p.PollOptions))
+		for i, o := range p.PollOptions {
+			o = strings.TrimSpace(o)
+			if o == "" {
+				return body, fmt.Errorf("poll option %d is empty", i+1)
+			}
+			if n := len([]rune(o)); n > maxPollOptionLength {
+				return body, fmt.Errorf("poll option %q is %d characters, the limit is %d", o, n, maxPollOptionLength)
+			}
+			options[i] = o
+		}
+		if p.PollDuration < minPollDuration || p.PollDuration > maxPollDuration {
+			return body, fmt.Errorf("poll duration must be %d to %d minutes, got %d", minPollDuration, maxPollDuration, p.PollDuration)
+		}
+		body.Poll = &api.PostPoll{Options: options, DurationMinutes: p.PollDuration}
+	}
+
+	return body, nil
+}
+
+// sendTweet POSTs body to /2/tweets.
+func sendTweet(client api.Client, body api.PostBody, opts api.RequestOptions) (json.RawMessage, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal post body: %w", err)
+	}
+	opts.Method = "POST"
+	opts.Endpoint = "/2/tweets"
+	opts.Data = string(data)
+	return client.SendRequest(opts)
+}
+
+var postURLPattern = regexp.MustCompile(`https?://\S+`)
+
+// weightedLength estimates a post's length the way X counts it: every link
+// counts as postURLLength, characters in the Latin, general punctuation and
+// similar ranges count as one, and everything else (CJK, emoji, ...) as two.
+func weightedLength(text string) int {
+	n := 0
+	text = postURLPattern.ReplaceAllStringFunc(text, func(string) string {
+		n += postURLLength
+		return ""
+	})
+	for _, r := range text {
+		switch {
+		case r <= 0x10FF,
+			r >= 0x2000 && r <= 0x200D,
+			r >= 0x2010 && r <= 0x201F,
+			r >= 0x2032 && r <= 0x2037:
+			n++
+		default:
+			n += 2
+		}
+	}
+	return n
+}
+
+// isNumericID reports whether s is a non-empty string of digits.
+func isNumericID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
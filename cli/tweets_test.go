@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/api"
+)
+
+func TestWeightedLength(t *testing.T) {
+	assert.Equal(t, 5, weightedLength("hello"))
+	assert.Equal(t, 6, weightedLength("héllo!"), "accented Latin counts as one")
+	assert.Equal(t, 4, weightedLength("日本"), "CJK counts as two")
+	assert.Equal(t, 2, weightedLength("🎉"), "emoji count as two")
+	assert.Equal(t, 4+postURLLength, weightedLength("see https://example.com/a/very/long/path/that/is/way/over/23/chars"))
+	assert.Equal(t, 3, weightedLength("a—b"), "general punctuation counts as one")
+}
+
+func TestBuildTweetBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  tweetParams
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "text only",
+			params: tweetParams{Text: "hello world"},
+			want:   `{"text":"hello world"}`,
+		},
+		{
+			name:   "reply and quote accept URLs",
+			params: tweetParams{Text: "hi", ReplyTo: "https://x.com/a/status/111", Quote: "222"},
+			want:   `{"text":"hi","reply":{"in_reply_to_tweet_id":"111"},"quote_tweet_id":"222"}`,
+		},
+		{
+			name:   "media",
+			params: tweetParams{Text: "pics", MediaIDs: []string{"1", "2"}},
+			want:   `{"text":"pics","media":{"media_ids":["1","2"]}}`,
+		},
+		{
+			name:   "media without text",
+			params: tweetParams{MediaIDs: []string{"1"}},
+			want:   `{"text":"","media":{"media_ids":["1"]}}`,
+		},
+		{
+			name:   "poll options are trimmed",
+			params: tweetParams{Text: "which?", PollOptions: []string{"Go", " Rust "}, PollDuration: 60},
+			want:   `{"text":"which?","poll":{"options":["Go","Rust"],"duration_minutes":60}}`,
+		},
+		{name: "empty text", params: tweetParams{Text: "  "}, wantErr: "empty"},
+		{name: "too long", params: tweetParams{Text: strings.Repeat("a", 281)}, wantErr: "281 characters"},
+		{name: "too long when weighted", params: tweetParams{Text: strings.Repeat("日", 141)}, wantErr: "282 characters"},
+		{name: "too many media", params: tweetParams{Text: "x", MediaIDs: []string{"1", "2", "3", "4", "5"}}, wantErr: "at most 4"},
+		{name: "bad media id", params: tweetParams{Text: "x", MediaIDs: []string{"12a"}}, wantErr: `invalid media ID "12a"`},
+		{name: "media and poll", params: tweetParams{Text: "x", MediaIDs: []string{"1"}, PollOptions: []string{"a", "b"}, PollDuration: 60}, wantErr: "both media and a poll"},
+		{name: "one poll option", params: tweetParams{Text: "x", PollOptions: []string{"a"}, PollDuration: 60}, wantErr: "2 to 4 options"},
+		{name: "five poll options", params: tweetParams{Text: "x", PollOptions: []string{"a", "b", "c", "d", "e"}, PollDuration: 60}, wantErr: "2 to 4 options"},
+		{name: "empty poll option", params: tweetParams{Text: "x", PollOptions: []string{"a", ""}, PollDuration: 60}, wantErr: "option 2 is empty"},
+		{name: "long poll option", params: tweetParams{Text: "x", PollOptions: []string{"a", strings.Repeat("b", 26)}, PollDuration: 60}, wantErr: "26 characters"},
+		{name: "short poll", params: tweetParams{Text: "x", PollOptions: []string{"a", "b"}, PollDuration: 4}, wantErr: "5 to 10080 minutes"},
+		{name: "long poll", params: tweetParams{Text: "x", PollOptions: []string{"a", "b"}, PollDuration: 10081}, wantErr: "5 to 10080 minutes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := buildTweetBody(tt.params)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := json.Marshal(body)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSendTweet(t *testing.T) {
+	client := fakeClient{
+		sendRequest: func(options api.RequestOptions) (json.RawMessage, error) {
+			assert.Equal(t, "POST", options.Method)
+			assert.Equal(t, "/2/tweets", options.Endpoint)
+			assert.Equal(t, "alice", options.Username)
+			assert.JSONEq(t, `{"text":"hi","reply":{"in_reply_to_tweet_id":"9"}}`, options.Data)
+			return json.RawMessage(`{"data":{"id":"10"}}`), nil
+		},
+	}
+	body, err := buildTweetBody(tweetParams{Text: "hi", ReplyTo: "9"})
+	require.NoError(t, err)
+	resp, err := sendTweet(client, body, api.RequestOptions{Username: "alice"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"id":"10"}}`, string(resp))
+}
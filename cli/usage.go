@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// usageBarWidth is the width of the longest bar in the daily usage table.
+const usageBarWidth = 40
+
+// usageCount is a post count, which the usage endpoint sends as a string.
+type usageCount int64
+
+func (c *usageCount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*c = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid count %s", data)
+	}
+	*c = usageCount(n)
+	return nil
+}
+
+// usageResponse is the part of a /2/usage/tweets response `xurl usage` reads.
+type usageResponse struct {
+	Data struct {
+		ProjectID         string     `json:"project_id"`
+		ProjectCap        usageCount `json:"project_cap"`
+		ProjectUsage      usageCount `json:"project_usage"`
+		CapResetDay       int        `json:"cap_reset_day"`
+		DailyProjectUsage struct {
+			Usage []struct {
+				Date  string     `json:"date"`
+				Usage usageCount `json:"usage"`
+			} `json:"usage"`
+		} `json:"daily_project_usage"`
+	} `json:"data"`
+}
+
+// usageDay is one row of the daily breakdown.
+type usageDay struct {
+	Date  string `json:"date"`
+	Usage int64  `json:"usage"`
+}
+
+// usageSummary is what `xurl usage` reports, and prints with --json.
+type usageSummary struct {
+	ProjectID     string     `json:"project_id"`
+	ProjectCap    int64      `json:"project_cap"`
+	ProjectUsage  int64      `json:"project_usage"`
+	Percent       float64    `json:"percent"`
+	CapResetDay   int        `json:"cap_reset_day,omitempty"`
+	ResetsOn      string     `json:"resets_on,omitempty"`
+	DaysRemaining int        `json:"days_remaining"`
+	Daily         []usageDay `json:"daily"`
+}
+
+// CreateUsageCommand creates the `usage` command, which reports the project's
+// post consumption against its monthly cap.
+func CreateUsageCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var days int
+	var asJSON bool
+	var failAt float64
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report the project's API usage against its monthly cap",
+		Long: `Fetch /2/usage/tweets and summarise how many posts the project has consumed
+this billing cycle: the cap, the usage so far and its percentage, the days
+left until the cap resets, and a daily breakdown for the last --days days.
+
+The endpoint only accepts app-only auth, which is used unless --auth says
+otherwise; store a Bearer Token with 'xurl auth app-only' first. Projects
+without access to usage data get an explicit error.
+
+--fail-at exits with status 1 when usage is above the given percentage of
+the cap, to stop CI jobs that consume quota before the cap is reached.`,
+		Example: `  xurl usage
+  xurl usage --days 7
+  xurl usage --json | jq .percent
+  xurl usage --fail-at 90 && ./run-backfill.sh`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if days < 1 || days > 90 {
+				g.fprintError(os.Stderr, "Error: --days must be between 1 and 90")
+				os.Exit(1)
+			}
+			opts := g.baseOpts(cmd)
+			if opts.AuthType == "" {
+				opts.AuthType = "app"
+			}
+			client := g.newClient(a)
+			resp, err := api.GetUsage(client, days, opts)
+			if err != nil {
+				if msg := describeUsageError(err); msg != "" {
+					g.fprintError(os.Stderr, "Error: %s", msg)
+				}
+				g.printResult(nil, err)
+			}
+			if api.IsDryRun(resp) {
+				g.printResult(resp, nil)
+				return
+			}
+
+			summary, err := summarizeUsage(resp, time.Now())
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: could not parse the usage response: %v", err)
+				os.Exit(1)
+			}
+			if asJSON {
+				g.format.Print(summary)
+			} else {
+				printUsage(os.Stdout, summary)
+			}
+			if failAt > 0 && summary.Percent > failAt {
+				g.fprintError(os.Stderr, "Error: usage is %.1f%% of the cap, above --fail-at %g%%", summary.Percent, failAt)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().IntVar(&days, "days", 30, "Days of daily usage to show (1-90)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the summary as JSON")
+	cmd.Flags().Float64Var(&failAt, "fail-at", 0, "Exit with status 1 when usage is above this percentage of the cap")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// describeUsageError explains the errors of a project that cannot read usage
+// data. It returns "" for any other error.
+func describeUsageError(err error) string {
+	if xurlErrors.IsAuthError(err) {
+		return "usage data needs app-only auth; store a Bearer Token with 'xurl auth app-only'"
+	}
+	var e *xurlErrors.Error
+	if !errors.As(err, &e) || e.Type != xurlErrors.ErrTypeAPI {
+		return ""
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return "the usage endpoint rejected these credentials; it only accepts app-only auth (--auth app)"
+	case http.StatusForbidden:
+		return "this project has no access to usage data (/2/usage/tweets); check its access level in the developer portal"
+	}
+	return ""
+}
+
+// summarizeUsage builds the summary of a usage response as of now.
+func summarizeUsage(raw json.RawMessage, now time.Time) (usageSummary, error) {
+	var resp usageResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return usageSummary{}, err
+	}
+	d := resp.Data
+	s := usageSummary{
+		ProjectID:    d.ProjectID,
+		ProjectCap:   int64(d.ProjectCap),
+		ProjectUsage: int64(d.ProjectUsage),
+		CapResetDay:  d.CapResetDay,
+		Daily:        []usageDay{},
+	}
+	if s.ProjectCap > 0 {
+		s.Percent = float64(s.ProjectUsage) / float64(s.ProjectCap) * 100
+	}
+	if reset := nextCapReset(now, d.CapResetDay); !reset.IsZero() {
+		s.ResetsOn = reset.Format(time.DateOnly)
+		s.DaysRemaining = int(reset.Sub(startOfDay(now)).Hours() / 24)
+	}
+	for _, u := range d.DailyProjectUsage.Usage {
+		date := u.Date
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			date = t.UTC().Format(time.DateOnly)
+		}
+		s.Daily = append(s.Daily, usageDay{Date: date, Usage: int64(u.Usage)})
+	}
+	return s, nil
+}
+
+// nextCapReset returns the next date, after today (UTC), on which a cap that
+// resets on day resetDay of the month resets. In shorter months the reset
+// falls on the last day. It returns the zero time when resetDay is unknown.
+func nextCapReset(now time.Time, resetDay int) time.Time {
+	if resetDay < 1 {
+		return time.Time{}
+	}
+	today := startOfDay(now)
+	resetIn := func(year int, month time.Month) time.Time {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return time.Date(year, month, min(resetDay, last), 0, 0, 0, 0, time.UTC)
+	}
+	reset := resetIn(today.Year(), today.Month())
+	if !reset.After(today) {
+		reset = resetIn(today.Year(), today.Month()+1)
+	}
+	return reset
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// printUsage writes the human-readable summary: the totals, then one row per
+// day with a bar scaled to the busiest day.
+func printUsage(w io.Writer, s usageSummary) {
+	if s.ProjectID != "" {
+		fmt.Fprintf(w, "Project %s\n", s.ProjectID)
+	}
+	fmt.Fprintf(w, "Usage:      %d / %d posts (%.1f%%)\n", s.ProjectUsage, s.ProjectCap, s.Percent)
+	if s.ResetsOn != "" {
+		fmt.Fprintf(w, "Cap resets: %s (in %d days)\n", s.ResetsOn, s.DaysRemaining)
+	}
+	if len(s.Daily) == 0 {
+		return
+	}
+
+	var busiest int64
+	for _, d := range s.Daily {
+		busiest = max(busiest, d.Usage)
+	}
+	width := len(strconv.FormatInt(busiest, 10))
+	fmt.Fprintf(w, "\nDaily usage (last %d days):\n", len(s.Daily))
+	for _, d := range s.Daily {
+		bar := 0
+		if busiest > 0 {
+			bar = int(d.Usage * usageBarWidth / busiest)
+			if d.Usage > 0 && bar == 0 {
+				bar = 1
+			}
+		}
+		row := fmt.Sprintf("%s  %*d  %s", d.Date, width, d.Usage, strings.Repeat("█", bar))
+		fmt.Fprintln(w, strings.TrimRight(row, " "))
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+const usageBody = `{"data":{
+	"project_id":"1500",
+	"project_cap":"2000000",
+	"project_usage":"1850000",
+	"cap_reset_day":19,
+	"daily_project_usage":{"project_id":"1500","usage":[
+		{"date":"2026-10-15T00:00:00.000Z","usage":"40000"},
+		{"date":"2026-10-16T00:00:00.000Z","usage":"80000"},
+		{"date":"2026-10-17T00:00:00.000Z","usage":"0"}
+	]}
+}}`
+
+func TestSummarizeUsage(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	s, err := summarizeUsage(json.RawMessage(usageBody), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1500", s.ProjectID)
+	assert.Equal(t, int64(2000000), s.ProjectCap)
+	assert.Equal(t, int64(1850000), s.ProjectUsage)
+	assert.InDelta(t, 92.5, s.Percent, 0.001)
+	assert.Equal(t, "2026-10-19", s.ResetsOn)
+	assert.Equal(t, 2, s.DaysRemaining)
+	assert.Equal(t, []usageDay{{"2026-10-15", 40000}, {"2026-10-16", 80000}, {"2026-10-17", 0}}, s.Daily)
+
+	// Counts may also arrive as numbers.
+	s, err = summarizeUsage(json.RawMessage(`{"data":{"project_cap":100,"project_usage":5}}`), now)
+	require.NoError(t, err)
+	assert.InDelta(t, 5.0, s.Percent, 0.001)
+	assert.Empty(t, s.ResetsOn)
+	assert.NotNil(t, s.Daily)
+
+	_, err = summarizeUsage(json.RawMessage(`{"data":{"project_cap":"lots"}}`), now)
+	assert.Error(t, err)
+}
+
+func TestNextCapReset(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 12, 0, 0, 0, time.UTC) }
+	date := func(t time.Time) string { return t.Format(time.DateOnly) }
+
+	assert.Equal(t, "2026-10-19", date(nextCapReset(day(2026, 10, 17), 19)))
+	assert.Equal(t, "2026-11-17", date(nextCapReset(day(2026, 10, 17), 17)), "resetting today means the next cycle")
+	assert.Equal(t, "2026-02-28", date(nextCapReset(day(2026, 2, 10), 31)), "short months reset on their last day")
+	assert.Equal(t, "2027-01-05", date(nextCapReset(day(2026, 12, 20), 5)))
+	assert.True(t, nextCapReset(day(2026, 10, 17), 0).IsZero())
+}
+
+func TestPrintUsage(t *testing.T) {
+	s, err := summarizeUsage(json.RawMessage(usageBody), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printUsage(&out, s)
+	assert.Equal(t, `Project 1500
+Usage:      1850000 / 2000000 posts (92.5%)
+Cap resets: 2026-10-19 (in 2 days)
+
+Daily usage (last 3 days):
+2026-10-15  40000  ████████████████████
+2026-10-16  80000  ████████████████████████████████████████
+2026-10-17      0
+`, out.String())
+}
+
+func TestDescribeUsageError(t *testing.T) {
+	forbidden := xurlErrors.NewAPIError(json.RawMessage(`{"title":"Client Forbidden"}`))
+	forbidden.StatusCode = http.StatusForbidden
+	assert.Contains(t, describeUsageError(forbidden), "no access to usage data")
+
+	unauthorized := xurlErrors.NewAPIError(json.RawMessage(`{"title":"Unauthorized"}`))
+	unauthorized.StatusCode = http.StatusUnauthorized
+	assert.Contains(t, describeUsageError(unauthorized), "only accepts app-only auth")
+
+	assert.Contains(t, describeUsageError(xurlErrors.NewAuthError("NoAuthMethod", errors.New("none"))), "xurl auth app-only")
+
+	serverError := xurlErrors.NewAPIError(json.RawMessage(`{}`))
+	serverError.StatusCode = http.StatusInternalServerError
+	assert.Empty(t, describeUsageError(serverError))
+}
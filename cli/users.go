@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// userQuery is one argument of `users lookup`: a username or a numeric ID.
+type userQuery struct {
+	Arg      string
+	Username string
+	ID       string
+}
+
+// userMapping pairs a `users lookup` argument with the user it resolved to.
+type userMapping struct {
+	Query    string `json:"query"`
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+}
+
+// userLookupError is an argument of `users lookup` that did not resolve.
+type userLookupError struct {
+	Query  string `json:"query"`
+	Detail string `json:"detail"`
+}
+
+// userLookupResult is what `users lookup` prints, as a table or with --json.
+type userLookupResult struct {
+	Users  []userMapping     `json:"users"`
+	Errors []userLookupError `json:"errors,omitempty"`
+}
+
+// CreateUsersCommand creates the `users` command and its subcommands.
+func CreateUsersCommand(a *auth.Auth, g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Work with several users at once",
+		Long:  `Look up several users at once, by username or ID.`,
+		Example: `  xurl users lookup @jack 12 @XDevelopers
+  xurl users lookup 2244994945 --json`,
+	}
+	cmd.AddCommand(usersLookupCmd(a, g))
+	return cmd
+}
+
+func usersLookupCmd(a *auth.Auth, g *globalOptions) *cobra.Command {
+	var asJSON bool
+	var concurrency int
+	cmd := &cobra.Command{
+		Use:   "lookup USER...",
+		Short: "Resolve usernames to IDs and IDs to usernames",
+		Long: `Resolve each argument to a user: an @username (or a name that is not all
+digits) to its ID, and a numeric ID to its username. Usernames and IDs are
+looked up through /2/users/by and /2/users, 100 per request, and the results
+are printed as a table mapping each argument to its user, or as JSON with
+--json. Arguments that do not resolve (unknown or suspended users) are listed
+under errors rather than dropped.
+
+--concurrency N sends up to N of those requests at once. The output order
+does not change. When one of them is rate limited, no new request starts
+until the limit resets, with a countdown on stderr.`,
+		Example: `  xurl users lookup @jack 12 @XDevelopers
+  xurl users lookup $(cat handles.txt) --json | jq -r '.users[].id'
+  xurl users lookup $(cat 5000-handles.txt) --concurrency 8 --json`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if concurrency < 1 {
+				g.fprintError(os.Stderr, "Error: --concurrency must be at least 1")
+				os.Exit(1)
+			}
+			var pause *api.RateLimitPause
+			if concurrency > 1 {
+				pause = &api.RateLimitPause{RetryAfter: g.retryAfter, Countdown: rateLimitCountdown(os.Stderr, isTerminal(os.Stderr))}
+			}
+			queries, usernames, ids := partitionUserArgs(args)
+			resp, err := api.LookupUsersConcurrently(g.newClient(a), usernames, ids, g.baseOpts(cmd), concurrency, pause)
+			if err != nil || api.IsDryRun(resp) {
+				g.printResult(resp, err)
+				return
+			}
+			result, err := mapUserLookup(queries, resp)
+			if err != nil {
+				g.fprintError(os.Stderr, "Error: could not parse the users response: %v", err)
+				os.Exit(1)
+			}
+			if asJSON {
+				g.format.Print(result)
+				return
+			}
+			printUserLookup(os.Stdout, result)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the mapping as JSON")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Send up to this many lookup requests (100 users each) at once")
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// partitionUserArgs splits arguments into usernames and numeric IDs, each
+// without duplicates. An argument starting with "@" is always a username.
+func partitionUserArgs(args []string) (queries []userQuery, usernames, ids []string) {
+	seen := map[string]bool{}
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" || seen[strings.ToLower(arg)] {
+			continue
+		}
+		seen[strings.ToLower(arg)] = true
+		if !strings.HasPrefix(arg, "@") && isNumericID(arg) {
+			queries = append(queries, userQuery{Arg: arg, ID: arg})
+			ids = append(ids, arg)
+			continue
+		}
+		username := api.ResolveUsername(arg)
+		if username == "" {
+			continue
+		}
+		queries = append(queries, userQuery{Arg: arg, Username: username})
+		usernames = append(usernames, username)
+	}
+	return queries, usernames, ids
+}
+
+// mapUserLookup matches each query to a user of a LookupUsers response, in
+// argument order. A query matched by no user gets the API's error detail, or
+// a generic one if the API did not report it.
+func mapUserLookup(queries []userQuery, resp json.RawMessage) (userLookupResult, error) {
+	var body struct {
+		Data []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+			Name     string `json:"name"`
+		} `json:"data"`
+		Errors []struct {
+			Value      string `json:"value"`
+			ResourceID string `json:"resource_id"`
+			Detail     string `json:"detail"`
+			Title      string `json:"title"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return userLookupResult{}, err
+	}
+
+	result := userLookupResult{Users: []userMapping{}}
+	for _, q := range queries {
+		found := false
+		for _, u := range body.Data {
+			if (q.ID != "" && u.ID == q.ID) || (q.Username != "" && strings.EqualFold(u.Username, q.Username)) {
+				result.Users = append(result.Users, userMapping{Query: q.Arg, ID: u.ID, Username: u.Username, Name: u.Name})
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		detail := "not returned by the API"
+		key := q.ID
+		if key == "" {
+			key = q.Username
+		}
+		for _, e := range body.Errors {
+			if strings.EqualFold(e.Value, key) || strings.EqualFold(e.ResourceID, key) {
+				detail = e.Detail
+				if detail == "" {
+					detail = e.Title
+				}
+				break
+			}
+		}
+		result.Errors = append(result.Errors, userLookupError{Query: q.Arg, Detail: detail})
+	}
+	return result, nil
+}
+
+// printUserLookup writes the mapping as a table, followed by the errors.
+func printUserLookup(w io.Writer, result userLookupResult) {
+	if len(result.Users) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "QUERY\tID\tUSERNAME\tNAME")
+		for _, u := range result.Users {
+			fmt.Fprintf(tw, "%s\t%s\t@%s\t%s\n", u.Query, u.ID, u.Username, u.Name)
+		}
+		tw.Flush()
+	}
+	if len(result.Errors) == 0 {
+		return
+	}
+	if len(result.Users) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "Errors:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range result.Errors {
+		fmt.Fprintf(tw, "  %s\t%s\n", e.Query, e.Detail)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionUserArgs(t *testing.T) {
+	queries, usernames, ids := partitionUserArgs([]string{"@jack", "12", "xdevelopers", "@12", "@Jack", " ", "12"})
+	assert.Equal(t, []string{"jack", "xdevelopers", "12"}, usernames, `"@12" is a username, duplicates are dropped`)
+	assert.Equal(t, []string{"12"}, ids)
+	assert.Equal(t, []userQuery{
+		{Arg: "@jack", Username: "jack"},
+		{Arg: "12", ID: "12"},
+		{Arg: "xdevelopers", Username: "xdevelopers"},
+		{Arg: "@12", Username: "12"},
+	}, queries)
+}
+
+func TestMapUserLookup(t *testing.T) {
+	queries, _, _ := partitionUserArgs([]string{"@Jack", "2244994945", "@nobody", "999"})
+	resp := json.RawMessage(`{
+		"data": [
+			{"id": "2244994945", "username": "XDevelopers", "name": "Developers"},
+			{"id": "12", "username": "jack", "name": "jack"}
+		],
+		"errors": [
+			{"value": "nobody", "detail": "Could not find user with usernames: [nobody].", "title": "Not Found Error"}
+		]
+	}`)
+
+	result, err := mapUserLookup(queries, resp)
+	require.NoError(t, err)
+	assert.Equal(t, []userMapping{
+		{Query: "@Jack", ID: "12", Username: "jack", Name: "jack"},
+		{Query: "2244994945", ID: "2244994945", Username: "XDevelopers", Name: "Developers"},
+	}, result.Users)
+	assert.Equal(t, []userLookupError{
+		{Query: "@nobody", Detail: "Could not find user with usernames: [nobody]."},
+		{Query: "999", Detail: "not returned by the API"},
+	}, result.Errors)
+
+	var buf bytes.Buffer
+	printUserLookup(&buf, result)
+	assert.Equal(t, `QUERY       ID          USERNAME      NAME
+@Jack       12          @jack         jack
+2244994945  2244994945  @XDevelopers  Developers
+
+Errors:
+  @nobody  Could not find user with usernames: [nobody].
+  999      not returned by the API
+`, buf.String())
+}
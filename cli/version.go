@@ -11,8 +11,10 @@ import (
 // CreateVersionCommand creates the version command
 func CreateVersionCommand() *cobra.Command {
 	var versionCmd = &cobra.Command{
-		Use:   "version",
-		Short: "Show xurl version information",
+		Use:     "version",
+		Short:   "Show xurl version information",
+		Long:    `Print the xurl version. The same string is printed by 'xurl --version'.`,
+		Example: `  xurl version`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Printf("xurl %s\n", version.Version)
 		},
@@ -34,12 +34,17 @@ func CreateWebhookCommand(authInstance *auth.Auth) *cobra.Command {
 		Use:   "webhook",
 		Short: "Manage webhooks for the X API",
 		Long:  `Manages X API webhooks. Currently supports starting a local server with an ngrok tunnel to handle CRC checks.`,
+		Example: `  xurl webhook start
+  xurl webhook start -p 9000 -o events.jsonl -q`,
 	}
 
 	webhookStartCmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start a local webhook server with an ngrok tunnel",
 		Long:  `Starts a local HTTP server and an ngrok tunnel to listen for X API webhook events, including CRC checks. POST request bodies can be saved to a file using the -o flag. Use -q for quieter console logging of POST events. Use -P to pretty-print JSON POST bodies in the console.`,
+		Example: `  xurl webhook start
+  xurl webhook start --port 9000 --output events.jsonl
+  xurl webhook start -P`,
 		Run: func(cmd *cobra.Command, args []string) {
 			color.Cyan("Starting webhook server with ngrok...")
 
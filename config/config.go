@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/xdevplatform/xurl/store"
 )
@@ -11,7 +12,7 @@ const DefaultRedirectURI = "http://localhost:8080/callback"
 
 // Config holds the application configuration
 type Config struct {
-	// OAuth2 client tokens (may come from env vars or the active app in .xurl)
+	// OAuth2 client tokens (may come from env vars or the active app in auth.yml)
 	ClientID     string
 	ClientSecret string
 	// OAuth2 PKCE flow urls
@@ -26,23 +27,55 @@ type Config struct {
 	InfoURL string
 	// AppName is the explicit --app override; empty means "use default".
 	AppName string
+	// ShowBodyOnError is the default for printing API error bodies, from
+	// show_body_on_error in config.yml (true when unset). --fail and
+	// --fail-with-body override it per invocation.
+	ShowBodyOnError bool
+	// DefaultHeaderFile is default_header_file from config.yml: a header
+	// file applied to every request unless --header-file names another. A
+	// relative path is resolved against the config directory.
+	DefaultHeaderFile string
+	// StorePath is the token store file from --store-path or XURL_STORE;
+	// empty means the default auth.yml in the data directory.
+	StorePath string
+	// NoTwurlImport is set by XURL_NO_TWURL_IMPORT (or --no-twurl-import)
+	// to skip the automatic ~/.twurlrc import.
+	NoTwurlImport bool
 }
 
-// NewConfig creates a new Config from environment variables
+// NewConfig creates a new Config from environment variables. Variables
+// from a .env file in the current directory are loaded first (without
+// overriding anything already set in the environment).
 func NewConfig() *Config {
 	return NewConfigForApp("")
 }
 
 // NewConfigForApp creates a Config for the given app name.
 func NewConfigForApp(appName string) *Config {
+	loadDefaultDotEnv()
+
 	clientID := getEnvOrDefault("CLIENT_ID", "")
 	clientSecret := getEnvOrDefault("CLIENT_SECRET", "")
-	redirectURI, redirectURIFromEnv, _ := ResolveRedirectURI(appName)
+	storePath := getEnvOrDefault(store.StorePathEnv, "")
+	redirectURI, redirectURIFromEnv, _ := ResolveRedirectURI(storePath, appName)
 	authURL := getEnvOrDefault("AUTH_URL", "https://x.com/i/oauth2/authorize")
 	tokenURL := getEnvOrDefault("TOKEN_URL", "https://api.x.com/2/oauth2/token")
 	apiBaseURL := getEnvOrDefault("API_BASE_URL", "https://api.x.com")
 	infoURL := getEnvOrDefault("INFO_URL", fmt.Sprintf("%s/2/users/me", apiBaseURL))
 
+	showBodyOnError := true
+	settings, err := loadFileSettings(FilePath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config file: %v\n", err)
+	}
+	if settings.ShowBodyOnError != nil {
+		showBodyOnError = *settings.ShowBodyOnError
+	}
+	headerFile := settings.DefaultHeaderFile
+	if headerFile != "" && !filepath.IsAbs(headerFile) {
+		headerFile = filepath.Join(filepath.Dir(FilePath()), headerFile)
+	}
+
 	return &Config{
 		ClientID:           clientID,
 		ClientSecret:       clientSecret,
@@ -53,17 +86,23 @@ func NewConfigForApp(appName string) *Config {
 		APIBaseURL:         apiBaseURL,
 		InfoURL:            infoURL,
 		AppName:            appName,
+		ShowBodyOnError:    showBodyOnError,
+		DefaultHeaderFile:  headerFile,
+		StorePath:          storePath,
+		NoTwurlImport:      store.TwurlImportDisabled(),
 	}
 }
 
-// ResolveRedirectURI resolves the effective redirect URI for an app.
+// ResolveRedirectURI resolves the effective redirect URI for an app in the
+// token store at storePath (see store.NewTokenStore). It never imports
+// ~/.twurlrc.
 // Precedence: REDIRECT_URI env var, then stored app config, then built-in default.
-func ResolveRedirectURI(appName string) (value string, fromEnv bool, source string) {
+func ResolveRedirectURI(storePath, appName string) (value string, fromEnv bool, source string) {
 	if value, ok := os.LookupEnv("REDIRECT_URI"); ok {
 		return value, true, "REDIRECT_URI environment variable"
 	}
 
-	ts := store.NewTokenStore()
+	ts := store.OpenTokenStore(storePath, "", "")
 	app := ts.ResolveApp(appName)
 	if app != nil && app.RedirectURI != "" {
 		return app.RedirectURI, false, "app config"
@@ -17,8 +17,9 @@ func TestResolveRedirectURI(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
-	ts := store.NewTokenStore()
+	ts := store.NewTokenStore("")
 	err = ts.AddApp("my-app", "id", "secret")
 	require.NoError(t, err)
 	err = ts.SetDefaultApp("my-app")
@@ -29,7 +30,7 @@ func TestResolveRedirectURI(t *testing.T) {
 	t.Run("store redirect uri is used when env is absent", func(t *testing.T) {
 		t.Setenv("REDIRECT_URI", "")
 		_ = os.Unsetenv("REDIRECT_URI")
-		redirectURI, fromEnv, source := ResolveRedirectURI("my-app")
+		redirectURI, fromEnv, source := ResolveRedirectURI("", "my-app")
 		assert.Equal(t, "http://localhost:9090/callback", redirectURI)
 		assert.False(t, fromEnv)
 		assert.Equal(t, "app config", source)
@@ -37,7 +38,7 @@ func TestResolveRedirectURI(t *testing.T) {
 
 	t.Run("env redirect uri overrides stored value", func(t *testing.T) {
 		t.Setenv("REDIRECT_URI", "http://127.0.0.1:8080/callback")
-		redirectURI, fromEnv, source := ResolveRedirectURI("my-app")
+		redirectURI, fromEnv, source := ResolveRedirectURI("", "my-app")
 		assert.Equal(t, "http://127.0.0.1:8080/callback", redirectURI)
 		assert.True(t, fromEnv)
 		assert.Equal(t, "REDIRECT_URI environment variable", source)
@@ -45,9 +46,10 @@ func TestResolveRedirectURI(t *testing.T) {
 
 	t.Run("default redirect uri is used when nothing is configured", func(t *testing.T) {
 		t.Setenv("HOME", filepath.Join(tempDir, "other-home"))
+		t.Setenv("XDG_DATA_HOME", "")
 		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "other-home"), 0o755))
 		_ = os.Unsetenv("REDIRECT_URI")
-		redirectURI, fromEnv, source := ResolveRedirectURI("")
+		redirectURI, fromEnv, source := ResolveRedirectURI("", "")
 		assert.Equal(t, DefaultRedirectURI, redirectURI)
 		assert.False(t, fromEnv)
 		assert.Equal(t, "built-in default", source)
@@ -60,7 +62,8 @@ func TestNewConfigForAppUsesRedirectURIResolution(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	t.Setenv("HOME", tempDir)
-	ts := store.NewTokenStore()
+	t.Setenv("XDG_DATA_HOME", "")
+	ts := store.NewTokenStore("")
 	err = ts.AddApp("my-app", "id", "secret")
 	require.NoError(t, err)
 	err = ts.SetAppRedirectURI("my-app", "http://localhost:9090/callback")
@@ -75,3 +78,47 @@ func TestNewConfigForAppUsesRedirectURIResolution(t *testing.T) {
 	assert.Equal(t, "http://127.0.0.1:8080/callback", cfg.RedirectURI)
 	assert.True(t, cfg.RedirectURIFromEnv)
 }
+
+func TestShowBodyOnErrorFromConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "cfg"))
+
+	t.Run("defaults to showing bodies without a config file", func(t *testing.T) {
+		assert.True(t, NewConfig().ShowBodyOnError)
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "cfg", "xurl"), 0700))
+	path := filepath.Join(tempDir, "cfg", "xurl", "config.yml")
+	assert.Equal(t, path, FilePath())
+
+	t.Run("config file default is honored", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("show_body_on_error: false\n"), 0600))
+		assert.False(t, NewConfig().ShowBodyOnError)
+	})
+
+	t.Run("malformed config file falls back to the default", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("show_body_on_eror: false\n"), 0600))
+		assert.True(t, NewConfig().ShowBodyOnError)
+	})
+}
+
+func TestDefaultHeaderFileFromConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "cfg"))
+	dir := filepath.Join(tempDir, "cfg", "xurl")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	path := filepath.Join(dir, "config.yml")
+
+	assert.Empty(t, NewConfig().DefaultHeaderFile)
+
+	require.NoError(t, os.WriteFile(path, []byte("default_header_file: gateway.headers\n"), 0600))
+	assert.Equal(t, filepath.Join(dir, "gateway.headers"), NewConfig().DefaultHeaderFile, "relative paths are resolved against the config directory")
+
+	abs := filepath.Join(tempDir, "elsewhere.headers")
+	require.NoError(t, os.WriteFile(path, []byte("default_header_file: "+abs+"\n"), 0600))
+	assert.Equal(t, abs, NewConfig().DefaultHeaderFile)
+}
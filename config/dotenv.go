@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DotEnvFileName is the file NewConfig loads from the current directory.
+// Parent directories are deliberately never searched.
+const DotEnvFileName = ".env"
+
+// DotEnvEntry is one KEY=VALUE assignment parsed from a dotenv file.
+type DotEnvEntry struct {
+	Key   string
+	Value string
+}
+
+var (
+	loadDotEnvOnce sync.Once
+	// dotEnvKeys records variables that were set from a dotenv file rather
+	// than the real environment, so an explicit --env-file can replace values
+	// auto-loaded from ./.env without ever overriding the real environment.
+	dotEnvKeys = map[string]bool{}
+)
+
+// loadDefaultDotEnv loads ./.env (if present) once per process, warning about
+// malformed lines on stderr.
+func loadDefaultDotEnv() {
+	loadDotEnvOnce.Do(func() {
+		if _, err := os.Stat(DotEnvFileName); err != nil {
+			return
+		}
+		if err := LoadDotEnv(DotEnvFileName, false, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+}
+
+// LoadDotEnv reads a dotenv file and sets each variable that is not already
+// present in the environment; variables from the real environment win unless
+// override is set, while ones set by an earlier dotenv load are always
+// replaced. Malformed lines are skipped with a warning (including the line
+// number) written to warnOut, which may be nil. A missing or unreadable file
+// is an error.
+func LoadDotEnv(path string, override bool, warnOut io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not read env file: %v", err)
+	}
+	defer f.Close()
+
+	entries, warnings := ParseDotEnv(f)
+	if warnOut != nil {
+		for _, w := range warnings {
+			fmt.Fprintf(warnOut, "Warning: %s:%s\n", path, w)
+		}
+	}
+	for _, e := range entries {
+		if _, exists := os.LookupEnv(e.Key); exists && !override && !dotEnvKeys[e.Key] {
+			continue
+		}
+		os.Setenv(e.Key, e.Value)
+		dotEnvKeys[e.Key] = true
+	}
+	return nil
+}
+
+// ParseDotEnv parses dotenv syntax: KEY=VALUE lines with optional "export "
+// prefixes, "#" comments (whole-line, or after whitespace in an unquoted
+// value), single-quoted literal values, and double-quoted values supporting
+// \n, \t, \" and \\ escapes. Malformed lines are reported as "LINE: reason"
+// warnings and skipped.
+func ParseDotEnv(r io.Reader) (entries []DotEnvEntry, warnings []string) {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export "); ok {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%d: expected KEY=VALUE, ignoring line", lineNo))
+			continue
+		}
+		if !validEnvKey(key) {
+			warnings = append(warnings, fmt.Sprintf("%d: invalid variable name %q, ignoring line", lineNo, key))
+			continue
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%d: %v, ignoring line", lineNo, err))
+			continue
+		}
+		entries = append(entries, DotEnvEntry{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%d: %v", lineNo+1, err))
+	}
+	return entries, warnings
+}
+
+func validEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		if err := checkTrailing(raw[end+2:]); err != nil {
+			return "", err
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(raw[i])
+				}
+			case c == '"':
+				if err := checkTrailing(raw[i+1:]); err != nil {
+					return "", err
+				}
+				return b.String(), nil
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		} else if i := strings.Index(raw, "\t#"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+}
+
+// checkTrailing allows only whitespace and a comment after a quoted value.
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected text after closing quote")
+	}
+	return nil
+}
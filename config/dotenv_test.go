@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	input := "\ufeff# comment\n" +
+		"\n" +
+		"CLIENT_ID=abc123\n" +
+		"export CLIENT_SECRET = 's3cr#t $HOME'\n" +
+		"API_BASE_URL=\"https://api.example.com\" # trailing comment\n" +
+		"MULTI=\"line1\\nline2 \\\"quoted\\\"\"\n" +
+		"PLAIN=value # comment\n" +
+		"EMPTY=\n" +
+		"not a pair\n" +
+		"1BAD=x\n" +
+		"OPEN=\"unterminated\n" +
+		"TRAIL='x' junk\n"
+
+	entries, warnings := ParseDotEnv(strings.NewReader(input))
+
+	assert.Equal(t, []DotEnvEntry{
+		{Key: "CLIENT_ID", Value: "abc123"},
+		{Key: "CLIENT_SECRET", Value: "s3cr#t $HOME"},
+		{Key: "API_BASE_URL", Value: "https://api.example.com"},
+		{Key: "MULTI", Value: "line1\nline2 \"quoted\""},
+		{Key: "PLAIN", Value: "value"},
+		{Key: "EMPTY", Value: ""},
+	}, entries)
+
+	require.Len(t, warnings, 4)
+	assert.True(t, strings.HasPrefix(warnings[0], "9: "), warnings[0])
+	assert.True(t, strings.HasPrefix(warnings[1], "10: "), warnings[1])
+	assert.True(t, strings.HasPrefix(warnings[2], "11: "), warnings[2])
+	assert.True(t, strings.HasPrefix(warnings[3], "12: "), warnings[3])
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(path, []byte("XURL_TEST_NEW=from-file\nXURL_TEST_SET=from-file\nbroken line\n"), 0600))
+
+	t.Setenv("XURL_TEST_SET", "from-env")
+	t.Setenv("XURL_TEST_NEW", "")
+	require.NoError(t, os.Unsetenv("XURL_TEST_NEW"))
+
+	var warn bytes.Buffer
+	require.NoError(t, LoadDotEnv(path, false, &warn))
+
+	assert.Equal(t, "from-file", os.Getenv("XURL_TEST_NEW"))
+	assert.Equal(t, "from-env", os.Getenv("XURL_TEST_SET"), "existing environment must win")
+	assert.Contains(t, warn.String(), path+":3:")
+
+	t.Run("later file replaces dotenv values but not the environment", func(t *testing.T) {
+		other := filepath.Join(dir, "other.env")
+		require.NoError(t, os.WriteFile(other, []byte("XURL_TEST_NEW=from-other\nXURL_TEST_SET=from-other\n"), 0600))
+		require.NoError(t, LoadDotEnv(other, false, nil))
+		assert.Equal(t, "from-other", os.Getenv("XURL_TEST_NEW"))
+		assert.Equal(t, "from-env", os.Getenv("XURL_TEST_SET"))
+	})
+
+	t.Run("missing file is an error", func(t *testing.T) {
+		err := LoadDotEnv(filepath.Join(dir, "missing.env"), false, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("override replaces the environment", func(t *testing.T) {
+		override := filepath.Join(dir, "override.env")
+		require.NoError(t, os.WriteFile(override, []byte("XURL_TEST_SET='from override'\n"), 0600))
+		require.NoError(t, LoadDotEnv(override, true, nil))
+		assert.Equal(t, "from override", os.Getenv("XURL_TEST_SET"))
+	})
+}
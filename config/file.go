@@ -0,0 +1,47 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xdevplatform/xurl/config/paths"
+)
+
+// FileName is the optional settings file in the config directory.
+const FileName = "config.yml"
+
+// fileSettings is the layout of config.yml. Pointer fields distinguish "not
+// set" from an explicit false.
+type fileSettings struct {
+	ShowBodyOnError   *bool  `yaml:"show_body_on_error"`
+	DefaultHeaderFile string `yaml:"default_header_file"`
+}
+
+// FilePath returns the path of config.yml in the config directory.
+func FilePath() string {
+	return filepath.Join(paths.ConfigDir(), FileName)
+}
+
+// loadFileSettings reads config.yml. A missing file yields zero settings; a
+// malformed one is reported so the caller can warn and carry on.
+func loadFileSettings(path string) (fileSettings, error) {
+	var s fileSettings
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && err != io.EOF {
+		return fileSettings{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
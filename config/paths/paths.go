@@ -0,0 +1,106 @@
+// Package paths resolves where xurl keeps its files on disk. It follows the
+// XDG Base Directory specification on Linux and other Unix systems and the
+// platform conventions on macOS and Windows:
+//
+//	           Linux/Unix                  macOS                                  Windows
+//	config     $XDG_CONFIG_HOME/xurl       ~/Library/Application Support/xurl     %APPDATA%\xurl
+//	data       $XDG_DATA_HOME/xurl         ~/Library/Application Support/xurl     %LOCALAPPDATA%\xurl
+//	cache      $XDG_CACHE_HOME/xurl        ~/Library/Caches/xurl                  %LOCALAPPDATA%\xurl\cache
+//
+// The XDG variables are honoured on every platform when set to an absolute
+// path. Every package should obtain paths from here rather than calling
+// os.UserHomeDir directly. The package has no dependencies inside xurl so
+// that both config and store can use it.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appDirName = "xurl"
+
+// HomeDir returns the user's home directory, preferring $HOME so tests and
+// sandboxed environments can relocate it.
+func HomeDir() string {
+	if homeDir := os.Getenv("HOME"); homeDir != "" {
+		return homeDir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error getting home directory:", err)
+		return "."
+	}
+
+	return homeDir
+}
+
+// ConfigDir returns the directory for user-authored configuration.
+func ConfigDir() string {
+	return resolve("XDG_CONFIG_HOME", func(home string) string {
+		switch runtime.GOOS {
+		case "darwin":
+			return filepath.Join(home, "Library", "Application Support", appDirName)
+		case "windows":
+			return filepath.Join(windowsDir("APPDATA", home, "Roaming"), appDirName)
+		default:
+			return filepath.Join(home, ".config", appDirName)
+		}
+	})
+}
+
+// DataDir returns the directory for tokens, keys and other state that must
+// survive across runs.
+func DataDir() string {
+	return resolve("XDG_DATA_HOME", func(home string) string {
+		switch runtime.GOOS {
+		case "darwin":
+			return filepath.Join(home, "Library", "Application Support", appDirName)
+		case "windows":
+			return filepath.Join(windowsDir("LOCALAPPDATA", home, "Local"), appDirName)
+		default:
+			return filepath.Join(home, ".local", "share", appDirName)
+		}
+	})
+}
+
+// CacheDir returns the directory for disposable state; deleting it only
+// loses caches.
+func CacheDir() string {
+	return resolve("XDG_CACHE_HOME", func(home string) string {
+		switch runtime.GOOS {
+		case "darwin":
+			return filepath.Join(home, "Library", "Caches", appDirName)
+		case "windows":
+			return filepath.Join(windowsDir("LOCALAPPDATA", home, "Local"), appDirName, "cache")
+		default:
+			return filepath.Join(home, ".cache", appDirName)
+		}
+	})
+}
+
+// LegacyDir returns ~/.xurl, where xurl kept all of its state before
+// adopting the directories above.
+func LegacyDir() string {
+	return filepath.Join(HomeDir(), ".xurl")
+}
+
+// resolve returns $env/xurl when env holds an absolute path (relative values
+// are invalid per the XDG spec and ignored), otherwise the platform default.
+func resolve(env string, platformDefault func(home string) string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDirName)
+	}
+	return platformDefault(HomeDir())
+}
+
+// windowsDir returns %env%, falling back to home\AppData\<fallback>.
+func windowsDir(env, home, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, "AppData", fallback)
+}
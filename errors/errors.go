@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 const (
@@ -14,12 +17,28 @@ const (
 	ErrTypeJSON          = "JSON Error"
 	ErrTypeAuth          = "Auth Error"
 	ErrTypeTokenStore    = "Token Store Error"
+	ErrTypeRateLimit     = "Rate Limit Error"
+	ErrTypeSchedule      = "Schedule Error"
 )
 
 type Error struct {
 	Type    string
 	Message string
-	cause   error
+	// ResetAt is when the rate-limit window resets. It is only set on
+	// ErrTypeRateLimit errors and is zero when the server did not say.
+	ResetAt time.Time
+	// StatusCode and ServerDate describe the HTTP response an ErrTypeAPI
+	// error came from. ServerDate is the response's Date header and is zero
+	// when the server sent none.
+	StatusCode int
+	ServerDate time.Time
+	// OAuth1 reports whether the request an ErrTypeAPI error came from was
+	// signed with OAuth1, whose signatures embed a timestamp.
+	OAuth1 bool
+	// RetryAfter is when the response's Retry-After header said to try
+	// again. It is zero when the server sent none.
+	RetryAfter time.Time
+	cause      error
 }
 
 func (e *Error) Error() string {
@@ -82,6 +101,137 @@ func NewTokenStoreError(message string) *Error {
 	return NewError(ErrTypeTokenStore, message, nil)
 }
 
+// NewScheduleError creates an error about the schedule file or one of its
+// scheduled requests.
+func NewScheduleError(message string) *Error {
+	return NewError(ErrTypeSchedule, message, nil)
+}
+
+// NewRateLimitError creates an error for a 429 response. message is the
+// response body (JSON when the API returned one); resetAt may be zero.
+func NewRateLimitError(message string, resetAt time.Time) *Error {
+	e := NewError(ErrTypeRateLimit, message, nil)
+	e.ResetAt = resetAt
+	return e
+}
+
+// RateLimitReset returns the reset time carried by a rate-limit error. ok is
+// false when err is not a rate-limit error or the reset time is unknown.
+func RateLimitReset(err error) (resetAt time.Time, ok bool) {
+	var e *Error
+	if !errors.As(err, &e) || e.Type != ErrTypeRateLimit || e.ResetAt.IsZero() {
+		return time.Time{}, false
+	}
+	return e.ResetAt, true
+}
+
+// DescribeRateLimit renders a rate-limit error for humans, always stating how
+// long until the limit resets (or that the reset time is unknown/past).
+func DescribeRateLimit(err error, now time.Time) string {
+	resetAt, ok := RateLimitReset(err)
+	switch {
+	case !ok:
+		return "rate limit exceeded; reset time unknown (the server sent no reset header)"
+	case !resetAt.After(now):
+		return "rate limit exceeded; the limit has already reset, retry now"
+	default:
+		wait := resetAt.Sub(now).Round(time.Second)
+		return fmt.Sprintf("rate limit exceeded; resets in %s (at %s)", wait, resetAt.Local().Format("15:04:05"))
+	}
+}
+
+// RetryAfter returns when the response an error came from said to retry.
+// ok is false when err carries no Retry-After time.
+func RetryAfter(err error) (retryAt time.Time, ok bool) {
+	var e *Error
+	if !errors.As(err, &e) || e.RetryAfter.IsZero() {
+		return time.Time{}, false
+	}
+	return e.RetryAfter, true
+}
+
+// IsTransientError reports whether err may go away on retry: a 5xx response
+// or a request that failed without one (a network error).
+func IsTransientError(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Type {
+	case ErrTypeHTTP:
+		return e.StatusCode == 0 || e.StatusCode >= 500
+	case ErrTypeAPI:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// IsTimestampError reports whether err is a 401 API error whose body says the
+// OAuth1 timestamp was rejected (X error code 135, "Timestamp out of bounds").
+func IsTimestampError(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) || e.Type != ErrTypeAPI || e.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	body := strings.ToLower(e.Message)
+	return strings.Contains(body, "timestamp") || strings.Contains(body, `"code":135`)
+}
+
+// MaxClockSkew is how far the local clock may drift from the server's before
+// X rejects OAuth1 signatures.
+const MaxClockSkew = 5 * time.Minute
+
+// ClockSkew estimates how far now is ahead of (positive) or behind (negative)
+// the server's clock from an API error's Date header. ok is false when err
+// carries no Date header.
+func ClockSkew(err error, now time.Time) (skew time.Duration, ok bool) {
+	var e *Error
+	if !errors.As(err, &e) || e.ServerDate.IsZero() {
+		return 0, false
+	}
+	return now.Sub(e.ServerDate).Round(time.Second), true
+}
+
+// OAuth1ClockSkewHint explains a 401 to an OAuth1-signed request when its
+// Date header shows the local clock off by more than MaxClockSkew, which X
+// answers with a generic 401. It returns "" for any other error.
+func OAuth1ClockSkewHint(err error, now time.Time) string {
+	var e *Error
+	if !errors.As(err, &e) || !e.OAuth1 || e.StatusCode != http.StatusUnauthorized {
+		return ""
+	}
+	skew, ok := ClockSkew(err, now)
+	if !ok {
+		return ""
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	if skew <= MaxClockSkew {
+		return ""
+	}
+	return fmt.Sprintf("your system clock is %s %s server time — OAuth1 signatures will fail until it's corrected", skew, direction)
+}
+
+// DescribeClockSkew renders a timestamp error for humans, estimating how far
+// the local clock is from the server's Date header when the server sent one.
+func DescribeClockSkew(err error, now time.Time) string {
+	const hint = "OAuth1 timestamp rejected; check your system time"
+	skew, ok := ClockSkew(err, now)
+	if !ok {
+		return hint + " (the server sent no Date header to compare against)"
+	}
+	switch {
+	case skew > 0:
+		return fmt.Sprintf("%s: local clock is %s ahead of the server", hint, skew)
+	case skew < 0:
+		return fmt.Sprintf("%s: local clock is %s behind the server", hint, -skew)
+	default:
+		return hint + ": local clock matches the server, so the skew may be intermittent"
+	}
+}
+
 func IsErrorType(err error, errorType string) bool {
 	var e *Error
 	if ok := errors.As(err, &e); ok {
@@ -90,8 +240,13 @@ func IsErrorType(err error, errorType string) bool {
 	return false
 }
 
-func IsHTTPError(err error) bool { return IsErrorType(err, ErrTypeHTTP) }
-func IsIOError(err error) bool   { return IsErrorType(err, ErrTypeIO) }
-func IsAPIError(err error) bool  { return IsErrorType(err, ErrTypeAPI) }
-func IsJSONError(err error) bool { return IsErrorType(err, ErrTypeJSON) }
-func IsAuthError(err error) bool { return IsErrorType(err, ErrTypeAuth) }
+func IsHTTPError(err error) bool     { return IsErrorType(err, ErrTypeHTTP) }
+func IsIOError(err error) bool       { return IsErrorType(err, ErrTypeIO) }
+func IsAPIError(err error) bool      { return IsErrorType(err, ErrTypeAPI) }
+func IsJSONError(err error) bool     { return IsErrorType(err, ErrTypeJSON) }
+func IsAuthError(err error) bool     { return IsErrorType(err, ErrTypeAuth) }
+func IsScheduleError(err error) bool { return IsErrorType(err, ErrTypeSchedule) }
+
+// IsRateLimitError reports whether err is a 429 rate-limit error. It is the
+// single predicate retry, pagination, and stream reconnect logic should use.
+func IsRateLimitError(err error) bool { return IsErrorType(err, ErrTypeRateLimit) }
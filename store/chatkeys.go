@@ -21,8 +21,8 @@ type ChatKeys struct {
 }
 
 // ChatKeyStore persists XChat private keys per user id in a YAML file
-// (~/.xurl/keys.yml by default), following the same conventions as the
-// token store: 0600 permissions and $HOME resolution.
+// (keys.yml in the data directory by default), following the same
+// conventions as the token store: 0600 permissions and XDG path resolution.
 type ChatKeyStore struct {
 	Users    map[string]*ChatKeys `yaml:"users"`
 	filePath string
@@ -32,8 +32,8 @@ type ChatKeyStore struct {
 	loadErr error
 }
 
-// NewChatKeyStore loads (or initializes) the chat key store at
-// ~/.xurl/keys.yml.
+// NewChatKeyStore loads (or initializes) the chat key store at keys.yml in
+// the data directory.
 func NewChatKeyStore() *ChatKeyStore {
 	return NewChatKeyStoreWithPath(KeysFilePath())
 }
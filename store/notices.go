@@ -0,0 +1,55 @@
+package store
+
+import (
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NoticeCache remembers the day each API deprecation/warning notice was last
+// shown so the same endpoint is announced at most once per day. It is a
+// best-effort cache: load and save failures are ignored rather than allowed
+// to break the request that triggered the notice.
+type NoticeCache struct {
+	Shown    map[string]string `yaml:"shown"` // notice key -> YYYY-MM-DD
+	filePath string
+}
+
+// NewNoticeCache loads the notice cache at notices.yml in the cache
+// directory.
+func NewNoticeCache() *NoticeCache {
+	return NewNoticeCacheWithPath(NoticesFilePath())
+}
+
+// NewNoticeCacheWithPath loads the notice cache at the given path.
+func NewNoticeCacheWithPath(path string) *NoticeCache {
+	c := &NoticeCache{Shown: make(map[string]string), filePath: path}
+	if data, err := os.ReadFile(path); err == nil {
+		var loaded NoticeCache
+		if yaml.Unmarshal(data, &loaded) == nil && loaded.Shown != nil {
+			c.Shown = loaded.Shown
+		}
+	}
+	return c
+}
+
+// ShouldShow reports whether the notice identified by key has not yet been
+// shown on now's (local) day, and if so records it as shown.
+func (c *NoticeCache) ShouldShow(key string, now time.Time) bool {
+	today := now.Local().Format("2006-01-02")
+	if c.Shown[key] == today {
+		return false
+	}
+	// Drop entries from earlier days so the file never grows unbounded.
+	for k, day := range c.Shown {
+		if day != today {
+			delete(c.Shown, k)
+		}
+	}
+	c.Shown[key] = today
+	if data, err := yaml.Marshal(c); err == nil {
+		_ = os.WriteFile(c.filePath, data, 0600)
+	}
+	return true
+}
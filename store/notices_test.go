@@ -0,0 +1,27 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoticeCacheOncePerDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notices.yml")
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+
+	c := NewNoticeCacheWithPath(path)
+	assert.True(t, c.ShouldShow("GET /2/old", day1))
+	assert.False(t, c.ShouldShow("GET /2/old", day1.Add(time.Hour)))
+	assert.True(t, c.ShouldShow("GET /2/other", day1))
+
+	// Persisted across processes.
+	reloaded := NewNoticeCacheWithPath(path)
+	assert.False(t, reloaded.ShouldShow("GET /2/old", day1.Add(2*time.Hour)))
+
+	// Shown again the next day, and stale entries are pruned.
+	assert.True(t, reloaded.ShouldShow("GET /2/old", day1.AddDate(0, 0, 1)))
+	assert.NotContains(t, reloaded.Shown, "GET /2/other")
+}
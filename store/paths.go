@@ -1,32 +1,182 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/xdevplatform/xurl/config/paths"
 )
 
-// Names of the files inside the ~/.xurl directory.
+// Names of the files xurl keeps on disk.
 const (
-	authFileName = "auth.yml"
-	keysFileName = "keys.yml"
+	authFileName     = "auth.yml"
+	keysFileName     = "keys.yml"
+	scheduleFileName = "schedule.yml"
+	noticesFileName  = "notices.yml"
+	placesFileName   = "places.yml"
+	specFileName     = "openapi.json"
+	prevSpecFileName = "openapi.previous.json"
+	responsesDirName = "responses"
 )
 
-// resolveStoreDir returns ~/.xurl as a directory, creating it if needed and
-// migrating the legacy single-file layout on first use:
+// LegacyStubFileName is left in ~/.xurl once its files have been copied to
+// the XDG directories. Its presence marks the migration as complete.
+const LegacyStubFileName = "MOVED.txt"
+
+// storeFiles lists every store file with the directory it lives in:
+// credentials and keys are data, schedules are user configuration and the
+// notice log and place lookups are caches.
+func storeFiles() []struct{ name, dir string } {
+	return []struct{ name, dir string }{
+		{authFileName, paths.DataDir()},
+		{keysFileName, paths.DataDir()},
+		{scheduleFileName, paths.ConfigDir()},
+		{noticesFileName, paths.CacheDir()},
+		{placesFileName, paths.CacheDir()},
+	}
+}
+
+// storePath returns the path of the named store file inside dir, creating
+// dir (mode 0700) if needed. A legacy ~/.xurl store is migrated first; if a
+// file could not be migrated, its legacy path is returned instead so the
+// caller keeps working against the old layout.
+func storePath(dir, name string) string {
+	if legacy, pending := migrateLegacyStore(); pending {
+		if info, err := os.Stat(legacy); err == nil && !info.IsDir() {
+			// The single-file layout could not even be upgraded.
+			if name == authFileName {
+				return legacy
+			}
+		} else if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(legacy, name)); err == nil {
+				return filepath.Join(legacy, name)
+			}
+		}
+	}
+	_ = os.MkdirAll(dir, 0700)
+	return filepath.Join(dir, name)
+}
+
+// migrateLegacyStore moves state out of ~/.xurl into the XDG directories,
+// once. Each file is copied, read back and compared, and only when every
+// file made it is a stub written into ~/.xurl explaining where things went;
+// the legacy files themselves are left in place until the user removes them
+// (see RemoveLegacyStore). Existing files in the new locations are never
+// overwritten; the legacy files they shadow are listed in the stub as not
+// copied. It returns the legacy path and whether a migration is still
+// pending, i.e. legacy files must keep being used.
+func migrateLegacyStore() (legacy string, pending bool) {
+	legacy = paths.LegacyDir()
+	upgradeLegacyFile(legacy)
+
+	info, err := os.Stat(legacy)
+	if err != nil {
+		return legacy, false
+	}
+	if !info.IsDir() {
+		return legacy, true
+	}
+	if _, err := os.Stat(filepath.Join(legacy, LegacyStubFileName)); err == nil {
+		return legacy, false
+	}
+
+	var copied, skipped []string
+	for _, f := range storeFiles() {
+		src := filepath.Join(legacy, f.name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(f.dir, f.name)
+		if _, err := os.Stat(dst); err == nil {
+			skipped = append(skipped, fmt.Sprintf("  %s (%s already exists)", f.name, dst))
+			continue
+		}
+		if err := copyVerified(src, dst); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not copy %s to %s, still using the old location: %v\n", src, dst, err)
+			pending = true
+			continue
+		}
+		copied = append(copied, fmt.Sprintf("  %s -> %s", f.name, dst))
+	}
+	if pending {
+		return legacy, true
+	}
+
+	if err := os.WriteFile(filepath.Join(legacy, LegacyStubFileName), []byte(legacyStub(copied, skipped)), 0600); err != nil {
+		return legacy, true
+	}
+	if len(copied) > 0 {
+		fmt.Fprintf(os.Stderr, "Copied xurl files from %s to their new locations (see 'xurl config paths')\n", legacy)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: some files in %s were not copied because newer ones exist; see %s\n", legacy, LegacyStubFileName)
+	}
+	return legacy, false
+}
+
+// legacyStub is the text of the stub left in ~/.xurl: which files were
+// copied where, and which were not copied because the new location already
+// had a file of that name.
+func legacyStub(copied, skipped []string) string {
+	var b strings.Builder
+	b.WriteString("xurl no longer reads this directory. The files in it were left in place.\n")
+	if len(copied) > 0 {
+		b.WriteString("\nThese were copied to their new locations:\n\n" + strings.Join(copied, "\n") + "\n")
+	}
+	if len(skipped) > 0 {
+		b.WriteString("\nThese were NOT copied, because the new location already had a file of\n" +
+			"that name; xurl uses that file and ignores the copy here:\n\n" + strings.Join(skipped, "\n") + "\n")
+	}
+	b.WriteString("\nRun 'xurl config paths' to see every location in use, and\n" +
+		"'xurl config paths --remove-legacy' to delete this directory.\n")
+	return b.String()
+}
+
+// copyVerified copies src to dst (mode 0600, via a temp file and rename) and
+// reads dst back to confirm the bytes match.
+func copyVerified(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	written, err := os.ReadFile(dst)
+	if err != nil || !bytes.Equal(written, data) {
+		_ = os.Remove(dst)
+		if err == nil {
+			err = fmt.Errorf("copy does not match the original")
+		}
+		return err
+	}
+	return nil
+}
+
+// upgradeLegacyFile converts the oldest layout, where ~/.xurl was a single
+// token file, into a ~/.xurl directory holding auth.yml so the directory
+// migration can pick it up:
 //
-//	~/.xurl (file) -> ~/.xurl/auth.yml  (tokens and app credentials)
+//	~/.xurl (file) -> ~/.xurl/auth.yml
 //
-// Migration is rename-based (atomic on the same filesystem) and
+// The upgrade is rename-based (atomic on the same filesystem) and
 // non-destructive: if any step fails, the legacy file is left (or restored)
-// where it was and the legacy path is returned so the caller keeps working
-// against the old layout.
-func resolveStoreDir() string {
-	homeDir := resolveHomeDir()
-	root := filepath.Join(homeDir, ".xurl")
+// where it was.
+func upgradeLegacyFile(root string) {
 	tmp := root + ".migrating"
 
-	// Recover from a migration interrupted between its two renames: the
+	// Recover from an upgrade interrupted between its two renames: the
 	// legacy file is stranded at the temp path and root is missing or an
 	// empty directory. Finish moving it into place before anything else
 	// reads the (seemingly empty) store.
@@ -42,52 +192,93 @@ func resolveStoreDir() string {
 	}
 
 	info, err := os.Stat(root)
-	switch {
-	case err == nil && info.IsDir():
-		// Already the new layout.
-	case err == nil:
-		// Legacy token file occupies the directory's path: move it aside,
-		// make the directory, and move it back in as auth.yml. A stranded
-		// temp file from an unrecoverable earlier attempt is preserved as
-		// .bak rather than overwritten.
-		if _, err := os.Stat(tmp); err == nil {
-			_ = os.Rename(tmp, tmp+".bak")
-		}
-		if err := os.Rename(root, tmp); err != nil {
-			return root
-		}
-		if err := os.MkdirAll(root, 0700); err != nil {
-			_ = os.Rename(tmp, root)
-			return root
-		}
-		if err := os.Rename(tmp, filepath.Join(root, authFileName)); err != nil {
-			_ = os.RemoveAll(root)
-			_ = os.Rename(tmp, root)
-			return root
-		}
-		fmt.Fprintf(os.Stderr, "Migrated %s to %s\n", root, filepath.Join(root, authFileName))
-	default:
-		if err := os.MkdirAll(root, 0700); err != nil {
-			return root
-		}
+	if err != nil || info.IsDir() {
+		return
 	}
 
-	return root
+	// Legacy token file occupies the directory's path: move it aside, make
+	// the directory, and move it back in as auth.yml. A stranded temp file
+	// from an unrecoverable earlier attempt is preserved as .bak rather than
+	// overwritten.
+	if _, err := os.Stat(tmp); err == nil {
+		_ = os.Rename(tmp, tmp+".bak")
+	}
+	if err := os.Rename(root, tmp); err != nil {
+		return
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		_ = os.Rename(tmp, root)
+		return
+	}
+	if err := os.Rename(tmp, filepath.Join(root, authFileName)); err != nil {
+		_ = os.RemoveAll(root)
+		_ = os.Rename(tmp, root)
+	}
 }
 
-// AuthFilePath returns the token-store file inside the resolved ~/.xurl
-// directory (migrating any legacy layout first).
-func AuthFilePath() string {
-	dir := resolveStoreDir()
-	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
-		// Migration failed and the legacy file layout is still in effect.
-		return dir
+// LegacyStorePath returns ~/.xurl and whether it still exists.
+func LegacyStorePath() (string, bool) {
+	legacy := paths.LegacyDir()
+	_, err := os.Stat(legacy)
+	return legacy, err == nil
+}
+
+// RemoveLegacyStore deletes ~/.xurl. It refuses unless the directory has
+// been fully migrated (its stub file is present), so no state is lost.
+func RemoveLegacyStore() error {
+	legacy, pending := migrateLegacyStore()
+	if pending {
+		return fmt.Errorf("%s has not been fully migrated; not removing it", legacy)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, LegacyStubFileName)); err != nil {
+		return fmt.Errorf("%s is not a migrated xurl directory; not removing it", legacy)
 	}
-	return filepath.Join(dir, authFileName)
+	return os.RemoveAll(legacy)
 }
 
-// KeysFilePath returns the chat-key file inside the resolved ~/.xurl
-// directory.
+// AuthFilePath returns the token-store file in the data directory
+// (migrating any legacy ~/.xurl store first).
+func AuthFilePath() string {
+	return storePath(paths.DataDir(), authFileName)
+}
+
+// KeysFilePath returns the chat-key file in the data directory.
 func KeysFilePath() string {
-	return filepath.Join(resolveStoreDir(), keysFileName)
+	return storePath(paths.DataDir(), keysFileName)
+}
+
+// ScheduleFilePath returns the scheduled-request file in the config
+// directory.
+func ScheduleFilePath() string {
+	return storePath(paths.ConfigDir(), scheduleFileName)
+}
+
+// NoticesFilePath returns the file recording when API deprecation notices
+// were last shown, in the cache directory.
+func NoticesFilePath() string {
+	return storePath(paths.CacheDir(), noticesFileName)
+}
+
+// PlacesFilePath returns the file of place names resolved to WOEIDs by
+// `xurl trends`, in the cache directory.
+func PlacesFilePath() string {
+	return storePath(paths.CacheDir(), placesFileName)
+}
+
+// SpecFilePath returns the cached copy of the X API's OpenAPI spec that
+// `xurl spec diff` compares against, in the cache directory.
+func SpecFilePath() string {
+	return storePath(paths.CacheDir(), specFileName)
+}
+
+// PreviousSpecFilePath returns the version of the cached OpenAPI spec that
+// SpecFilePath replaced when the spec last changed.
+func PreviousSpecFilePath() string {
+	return storePath(paths.CacheDir(), prevSpecFileName)
+}
+
+// ResponseCacheDir returns the directory of GET responses cached by
+// --cache-ttl, in the cache directory. It is created on first write.
+func ResponseCacheDir() string {
+	return filepath.Join(paths.CacheDir(), responsesDirName)
 }
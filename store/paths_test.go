@@ -3,37 +3,150 @@ package store
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestResolveStoreDirFreshHome(t *testing.T) {
+// setTestHome points HOME at dir and clears the XDG overrides, so every store
+// path resolves under dir.
+func setTestHome(t *testing.T, dir string) {
+	t.Helper()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+}
+
+func skipUnlessXDGDefaults(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("platform uses its own default directories")
+	}
+}
+
+func TestStorePathsFreshHome(t *testing.T) {
+	skipUnlessXDGDefaults(t)
 	tempDir := t.TempDir()
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
 
-	assert.Equal(t, filepath.Join(tempDir, ".xurl", "auth.yml"), AuthFilePath())
-	assert.Equal(t, filepath.Join(tempDir, ".xurl", "keys.yml"), KeysFilePath())
+	dataDir := filepath.Join(tempDir, ".local", "share", "xurl")
+	assert.Equal(t, filepath.Join(dataDir, "auth.yml"), AuthFilePath())
+	assert.Equal(t, filepath.Join(dataDir, "keys.yml"), KeysFilePath())
+	assert.Equal(t, filepath.Join(tempDir, ".config", "xurl", "schedule.yml"), ScheduleFilePath())
+	assert.Equal(t, filepath.Join(tempDir, ".cache", "xurl", "notices.yml"), NoticesFilePath())
 
-	info, err := os.Stat(filepath.Join(tempDir, ".xurl"))
+	info, err := os.Stat(dataDir)
 	require.NoError(t, err)
 	assert.True(t, info.IsDir())
 	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	// No legacy directory is created for a fresh install.
+	_, err = os.Stat(filepath.Join(tempDir, ".xurl"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStorePathsHonourXDGVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tempDir, "cache"))
+
+	assert.Equal(t, filepath.Join(tempDir, "data", "xurl", "auth.yml"), AuthFilePath())
+	assert.Equal(t, filepath.Join(tempDir, "cfg", "xurl", "schedule.yml"), ScheduleFilePath())
+	assert.Equal(t, filepath.Join(tempDir, "cache", "xurl", "notices.yml"), NoticesFilePath())
+
+	// Relative values are invalid per the spec and ignored.
+	t.Setenv("XDG_DATA_HOME", "relative/data")
+	assert.NotContains(t, AuthFilePath(), "relative")
+}
+
+func TestStorePathsMigrateLegacyDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tempDir, "cache"))
+
+	legacy := filepath.Join(tempDir, ".xurl")
+	require.NoError(t, os.MkdirAll(legacy, 0700))
+	content := "apps:\n  my-app:\n    client_id: cid\n"
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, "auth.yml"), []byte(content), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, "schedule.yml"), []byte("requests: []\n"), 0600))
+
+	authPath := AuthFilePath()
+	assert.Equal(t, filepath.Join(tempDir, "data", "xurl", "auth.yml"), authPath)
+	migrated, err := os.ReadFile(authPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(migrated))
+	info, err := os.Stat(authPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	schedule, err := os.ReadFile(filepath.Join(tempDir, "cfg", "xurl", "schedule.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "requests: []\n", string(schedule))
+
+	// The legacy files stay behind next to a stub describing the move.
+	stub, err := os.ReadFile(filepath.Join(legacy, LegacyStubFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(stub), authPath)
+	_, err = os.Stat(filepath.Join(legacy, "auth.yml"))
+	assert.NoError(t, err)
+
+	// Later changes to the legacy copy are ignored: migration happens once.
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, "auth.yml"), []byte("apps: {}\n"), 0600))
+	ts := NewTokenStore("")
+	assert.Equal(t, authPath, ts.FilePath)
+	require.NotNil(t, ts.Apps["my-app"])
+	assert.Equal(t, "cid", ts.Apps["my-app"].ClientID)
+
+	require.NoError(t, RemoveLegacyStore())
+	_, exists := LegacyStorePath()
+	assert.False(t, exists)
+}
+
+func TestStorePathsMigrationNeverOverwrites(t *testing.T) {
+	tempDir := t.TempDir()
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "data", "xurl"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data", "xurl", "auth.yml"), []byte("new\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, ".xurl"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".xurl", "auth.yml"), []byte("old\n"), 0600))
+
+	data, err := os.ReadFile(AuthFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+
+	// The legacy file stays, and the stub says it was not copied.
+	data, err = os.ReadFile(filepath.Join(tempDir, ".xurl", "auth.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(data))
+	stub, err := os.ReadFile(filepath.Join(tempDir, ".xurl", LegacyStubFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(stub), "NOT copied")
+	assert.Contains(t, string(stub), "auth.yml ("+filepath.Join(tempDir, "data", "xurl", "auth.yml")+" already exists)")
+	assert.NotContains(t, string(stub), "These were copied")
 }
 
-func TestResolveStoreDirMigratesLegacyTokenFile(t *testing.T) {
+func TestStorePathsMigrateLegacyTokenFile(t *testing.T) {
 	tempDir := t.TempDir()
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
 
 	legacy := filepath.Join(tempDir, ".xurl")
 	content := "apps:\n  my-app:\n    client_id: cid\n"
 	require.NoError(t, os.WriteFile(legacy, []byte(content), 0600))
 
 	authPath := AuthFilePath()
-	assert.Equal(t, filepath.Join(legacy, "auth.yml"), authPath)
+	assert.Equal(t, filepath.Join(tempDir, "data", "xurl", "auth.yml"), authPath)
 
-	// The legacy file's bytes moved into the directory untouched.
+	// The legacy file's bytes moved into place untouched.
 	migrated, err := os.ReadFile(authPath)
 	require.NoError(t, err)
 	assert.Equal(t, content, string(migrated))
@@ -43,7 +156,7 @@ func TestResolveStoreDirMigratesLegacyTokenFile(t *testing.T) {
 	assert.True(t, info.IsDir())
 
 	// The token store loads the migrated file.
-	ts := NewTokenStore()
+	ts := NewTokenStore("")
 	assert.Equal(t, authPath, ts.FilePath)
 	require.NotNil(t, ts.Apps["my-app"])
 	assert.Equal(t, "cid", ts.Apps["my-app"].ClientID)
@@ -51,12 +164,13 @@ func TestResolveStoreDirMigratesLegacyTokenFile(t *testing.T) {
 
 func TestKeysFilePathAlongsideMigratedTokenFile(t *testing.T) {
 	tempDir := t.TempDir()
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
 
-	// A legacy token file migrates into the directory; the chat-key store
-	// then lands next to it.
+	// A legacy token file migrates into the data directory; the chat-key
+	// store then lands next to it.
 	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".xurl"), []byte("apps: {}\n"), 0600))
-	assert.Equal(t, filepath.Join(tempDir, ".xurl", "keys.yml"), KeysFilePath())
+	assert.Equal(t, filepath.Join(tempDir, "data", "xurl", "keys.yml"), KeysFilePath())
 
 	cs := NewChatKeyStore()
 	require.NoError(t, cs.SaveKeys("42", &ChatKeys{PrivateKeysB64: "c2VjcmV0", KeyVersion: "7"}))
@@ -65,34 +179,21 @@ func TestKeysFilePathAlongsideMigratedTokenFile(t *testing.T) {
 	assert.Equal(t, "7", reloaded.GetKeys("42").KeyVersion)
 }
 
-func TestResolveStoreDirRecoversInterruptedMigration(t *testing.T) {
+func TestStorePathsRecoverInterruptedUpgrade(t *testing.T) {
 	tempDir := t.TempDir()
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
 
-	// A crash between the migration's two renames leaves the legacy file at
-	// the temp path and no ~/.xurl at all.
+	// A crash between the single-file upgrade's two renames leaves the
+	// legacy file at the temp path and no ~/.xurl at all.
 	content := "apps:\n  my-app:\n    client_id: cid\n"
 	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".xurl.migrating"), []byte(content), 0600))
 
 	authPath := AuthFilePath()
-	assert.Equal(t, filepath.Join(tempDir, ".xurl", "auth.yml"), authPath)
+	assert.Equal(t, filepath.Join(tempDir, "data", "xurl", "auth.yml"), authPath)
 	migrated, err := os.ReadFile(authPath)
 	require.NoError(t, err)
 	assert.Equal(t, content, string(migrated))
 	_, err = os.Stat(filepath.Join(tempDir, ".xurl.migrating"))
 	assert.True(t, os.IsNotExist(err), "temp file must be consumed by the recovery")
 }
-
-func TestResolveStoreDirIdempotent(t *testing.T) {
-	tempDir := t.TempDir()
-	t.Setenv("HOME", tempDir)
-
-	first := resolveStoreDir()
-	require.NoError(t, os.WriteFile(filepath.Join(first, "auth.yml"), []byte("apps: {}\n"), 0600))
-	second := resolveStoreDir()
-	assert.Equal(t, first, second)
-
-	data, err := os.ReadFile(filepath.Join(first, "auth.yml"))
-	require.NoError(t, err)
-	assert.Equal(t, "apps: {}\n", string(data))
-}
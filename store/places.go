@@ -0,0 +1,57 @@
+package store
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PlaceCache remembers the WOEIDs of place names looked up by `xurl trends`,
+// so a place given once with --woeid can later be named with --place alone.
+// Like NoticeCache it is best-effort: load and save failures are ignored.
+type PlaceCache struct {
+	Places   map[string]int `yaml:"places"` // normalized place name -> WOEID
+	filePath string
+}
+
+// NewPlaceCache loads the place cache at places.yml in the cache directory.
+func NewPlaceCache() *PlaceCache {
+	return NewPlaceCacheWithPath(PlacesFilePath())
+}
+
+// NewPlaceCacheWithPath loads the place cache at the given path.
+func NewPlaceCacheWithPath(path string) *PlaceCache {
+	c := &PlaceCache{Places: make(map[string]int), filePath: path}
+	if data, err := os.ReadFile(path); err == nil {
+		var loaded PlaceCache
+		if yaml.Unmarshal(data, &loaded) == nil && loaded.Places != nil {
+			c.Places = loaded.Places
+		}
+	}
+	return c
+}
+
+// NormalizePlace folds a place name for lookup: lower case, with runs of
+// spaces collapsed, so "New  York" and "new york" are the same place.
+func NormalizePlace(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Lookup returns the cached WOEID of a place.
+func (c *PlaceCache) Lookup(name string) (int, bool) {
+	woeid, ok := c.Places[NormalizePlace(name)]
+	return woeid, ok
+}
+
+// Remember records the WOEID of a place and saves the cache.
+func (c *PlaceCache) Remember(name string, woeid int) {
+	key := NormalizePlace(name)
+	if key == "" || c.Places[key] == woeid {
+		return
+	}
+	c.Places[key] = woeid
+	if data, err := yaml.Marshal(c); err == nil {
+		_ = os.WriteFile(c.filePath, data, 0600)
+	}
+}
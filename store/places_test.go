@@ -0,0 +1,32 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "places.yml")
+
+	c := NewPlaceCacheWithPath(path)
+	_, ok := c.Lookup("Springfield")
+	assert.False(t, ok)
+
+	c.Remember("  Springfield ", 2508428)
+	woeid, ok := c.Lookup("springfield")
+	assert.True(t, ok)
+	assert.Equal(t, 2508428, woeid)
+
+	// Persisted across processes, and names are normalized.
+	reloaded := NewPlaceCacheWithPath(path)
+	woeid, ok = reloaded.Lookup("SPRINGFIELD")
+	assert.True(t, ok)
+	assert.Equal(t, 2508428, woeid)
+}
+
+func TestNormalizePlace(t *testing.T) {
+	assert.Equal(t, "new york", NormalizePlace("  New   York "))
+	assert.Equal(t, "", NormalizePlace("   "))
+}
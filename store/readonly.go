@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ReadOnlyStoreEnv names the environment variable that, set to a true value
+// such as 1, keeps the token store from being written, like the
+// --read-only-store flag.
+const ReadOnlyStoreEnv = "XURL_READONLY_STORE"
+
+// ReadOnlyStoreRequested reports whether $XURL_READONLY_STORE asks for a
+// read-only token store.
+func ReadOnlyStoreRequested() bool {
+	readOnly, _ := strconv.ParseBool(os.Getenv(ReadOnlyStoreEnv))
+	return readOnly
+}
+
+// SetReadOnly makes the store read-only, for reason: loads work as usual,
+// but changes, such as a refreshed OAuth2 token, are only kept in memory
+// and never written to FilePath. OpenTokenStore does this itself when
+// $XURL_READONLY_STORE is set or the file cannot be written.
+func (s *TokenStore) SetReadOnly(reason string) {
+	s.readOnlyReason = reason
+}
+
+// ReadOnly reports whether the store is read-only, and why.
+func (s *TokenStore) ReadOnly() (readOnly bool, reason string) {
+	return s.readOnlyReason != "", s.readOnlyReason
+}
+
+// saveQuietly saves the store like saveToFile, but skips a read-only store
+// without a warning. It is for writes xurl makes on its own, such as
+// migrating a legacy store or importing ~/.twurlrc, which are just as
+// useful in memory.
+func (s *TokenStore) saveQuietly() error {
+	if s.readOnlyReason != "" {
+		return nil
+	}
+	return s.saveToFile()
+}
+
+// storeWritable reports whether the token store file at path could be
+// written, without writing anything: the file itself when it exists, and
+// otherwise the nearest existing directory above it, where saving would
+// create it.
+func storeWritable(path string) bool {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		f.Close()
+		return true
+	}
+	if !os.IsNotExist(err) {
+		return false
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil {
+			return info.IsDir() && dirWritable(dir)
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
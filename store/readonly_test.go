@@ -0,0 +1,128 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readOnlyTestStore = `apps:
+  default:
+    bearer_token:
+      type: bearer
+      bearer: stored-bearer
+    oauth2_tokens:
+      alice:
+        type: oauth2
+        oauth2:
+          access_token: old-access
+          refresh_token: old-refresh
+          expiration_time: 1
+default_app: default
+`
+
+const readOnlyTestTwurlrc = `profiles:
+  someone:
+    key:
+      username: someone
+      consumer_key: key
+      consumer_secret: secret
+      token: twurl-token
+      secret: token-secret
+configuration:
+  default_profile:
+  - someone
+  - key`
+
+// checkReadOnlyStore checks that s, opened on path, loads as usual but keeps
+// its changes in memory, warning once.
+func checkReadOnlyStore(t *testing.T, s *TokenStore, path, reason string) {
+	t.Helper()
+	readOnly, got := s.ReadOnly()
+	require.True(t, readOnly)
+	assert.Equal(t, reason, got)
+	var warnings bytes.Buffer
+	s.warnings = &warnings
+
+	require.NotNil(t, s.GetBearerToken(), "loads succeed")
+	assert.Equal(t, "stored-bearer", s.GetBearerToken().Bearer)
+
+	// A refresh, then another: both are kept in memory.
+	require.NoError(t, s.SaveOAuth2Token("alice", "new-access", "new-refresh", 2))
+	require.NoError(t, s.SaveOAuth2Token("alice", "newer-access", "newer-refresh", 3))
+	assert.Equal(t, "newer-access", s.GetOAuth2Token("alice").OAuth2.AccessToken)
+	assert.Equal(t, 1, strings.Count(warnings.String(), "Warning:"), "a single warning")
+	assert.Contains(t, warnings.String(), path)
+	assert.Contains(t, warnings.String(), reason)
+
+	// An automatic ~/.twurlrc import is kept in memory, silently.
+	twurlrc := filepath.Join(t.TempDir(), ".twurlrc")
+	require.NoError(t, os.WriteFile(twurlrc, []byte(readOnlyTestTwurlrc), 0600))
+	warnings.Reset()
+	require.NoError(t, s.importFromTwurlrc(twurlrc))
+	require.NotNil(t, s.GetOAuth1Tokens())
+	assert.Equal(t, "twurl-token", s.GetOAuth1Tokens().OAuth1.AccessToken)
+	assert.Empty(t, warnings.String())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, readOnlyTestStore, string(data), "nothing is written")
+}
+
+func TestReadOnlyStoreDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "home")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	path := filepath.Join(dir, "auth.yml")
+	require.NoError(t, os.WriteFile(path, []byte(readOnlyTestStore), 0400))
+	require.NoError(t, os.Chmod(dir, 0500))
+	t.Cleanup(func() { os.Chmod(dir, 0700) })
+	if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		f.Close()
+		t.Skip("file permissions are not enforced for this user (running as root?)")
+	}
+
+	checkReadOnlyStore(t, OpenTokenStore(path, "", ""), path, "it is not writable")
+
+	missing := filepath.Join(dir, "sub", "auth.yml")
+	s := OpenTokenStore(missing, "", "")
+	readOnly, _ := s.ReadOnly()
+	assert.True(t, readOnly, "a store that would be created in a read-only directory is read-only too")
+	s.warnings = &bytes.Buffer{}
+	require.NoError(t, s.SaveBearerToken("b"))
+	_, err := os.Stat(filepath.Dir(missing))
+	assert.True(t, os.IsNotExist(err), "no directory is created")
+}
+
+func TestReadOnlyStoreRequested(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.yml")
+	require.NoError(t, os.WriteFile(path, []byte(readOnlyTestStore), 0600))
+
+	t.Setenv(ReadOnlyStoreEnv, "1")
+	assert.True(t, ReadOnlyStoreRequested())
+	checkReadOnlyStore(t, OpenTokenStore(path, "", ""), path, ReadOnlyStoreEnv+" is set")
+
+	t.Run("SetReadOnly", func(t *testing.T) {
+		t.Setenv(ReadOnlyStoreEnv, "")
+		s := OpenTokenStore(path, "", "")
+		readOnly, _ := s.ReadOnly()
+		require.False(t, readOnly)
+		s.SetReadOnly("--read-only-store is set")
+		checkReadOnlyStore(t, s, path, "--read-only-store is set")
+	})
+}
+
+func TestStoreWritable(t *testing.T) {
+	dir := t.TempDir()
+	assert.True(t, storeWritable(filepath.Join(dir, "auth.yml")))
+	assert.True(t, storeWritable(filepath.Join(dir, "a", "b", "auth.yml")), "missing directories would be created")
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0600))
+	assert.True(t, storeWritable(file))
+	assert.False(t, storeWritable(filepath.Join(file, "auth.yml")), "a file is in the way")
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xdevplatform/xurl/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ─── Schedule types ─────────────────────────────────────────────────
+
+// ScheduledRequest is a stored request replayed by `xurl schedule run`
+// whenever its cron expression has fired since the last run.
+type ScheduledRequest struct {
+	ID        string    `yaml:"id"`
+	Cron      string    `yaml:"cron"`
+	Method    string    `yaml:"method"`
+	Endpoint  string    `yaml:"endpoint"`
+	Headers   []string  `yaml:"headers,omitempty"`
+	Data      string    `yaml:"data,omitempty"`
+	AuthType  string    `yaml:"auth,omitempty"`
+	Username  string    `yaml:"username,omitempty"`
+	CreatedAt time.Time `yaml:"created_at"`
+	LastRun   time.Time `yaml:"last_run,omitempty"`
+}
+
+// NextRun returns the first time after the last run (or creation) at which
+// the request becomes due. It returns the zero time for an invalid or
+// never-matching cron expression.
+func (r *ScheduledRequest) NextRun() time.Time {
+	cron, err := ParseCron(r.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	from := r.LastRun
+	if from.IsZero() {
+		from = r.CreatedAt
+	}
+	return cron.Next(from.Local())
+}
+
+// IsDue reports whether the request's schedule has fired at or before now
+// since it last ran. Missed runs coalesce into a single replay.
+func (r *ScheduledRequest) IsDue(now time.Time) bool {
+	next := r.NextRun()
+	return !next.IsZero() && !next.After(now)
+}
+
+// ScheduleStore persists scheduled requests in a YAML file
+// (schedule.yml in the config directory by default), following the same
+// conventions as the other stores: 0600 permissions and write-then-rename
+// saves.
+type ScheduleStore struct {
+	Requests []*ScheduledRequest `yaml:"requests"`
+	filePath string
+	loadErr  error
+}
+
+// NewScheduleStore loads (or initializes) the schedule store at
+// schedule.yml in the config directory.
+func NewScheduleStore() *ScheduleStore {
+	return NewScheduleStoreWithPath(ScheduleFilePath())
+}
+
+// NewScheduleStoreWithPath loads (or initializes) a schedule store at the given path.
+func NewScheduleStoreWithPath(path string) *ScheduleStore {
+	s := &ScheduleStore{filePath: path}
+	s.loadErr = s.loadFromFile()
+	return s
+}
+
+// FilePath returns the location of the backing file.
+func (s *ScheduleStore) FilePath() string {
+	return s.filePath
+}
+
+// LoadErr reports whether an existing schedule file failed to load.
+// Mutating methods refuse to run while it is set so a corrupt file is never
+// silently replaced by an empty one.
+func (s *ScheduleStore) LoadErr() error {
+	return s.loadErr
+}
+
+// Add validates the cron expression, assigns an id, and persists the request.
+func (s *ScheduleStore) Add(req *ScheduledRequest) error {
+	if s.loadErr != nil {
+		return s.refuseErr()
+	}
+	if _, err := ParseCron(req.Cron); err != nil {
+		return err
+	}
+	if req.Endpoint == "" {
+		return errors.NewScheduleError("scheduled request has no endpoint")
+	}
+	maxID := 0
+	for _, r := range s.Requests {
+		if n, err := strconv.Atoi(r.ID); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+	req.ID = strconv.Itoa(maxID + 1)
+	if req.CreatedAt.IsZero() {
+		req.CreatedAt = time.Now()
+	}
+	s.Requests = append(s.Requests, req)
+	return s.saveToFile()
+}
+
+// Get returns the request with the given id, or nil.
+func (s *ScheduleStore) Get(id string) *ScheduledRequest {
+	for _, r := range s.Requests {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// Remove deletes the request with the given id.
+func (s *ScheduleStore) Remove(id string) error {
+	if s.loadErr != nil {
+		return s.refuseErr()
+	}
+	for i, r := range s.Requests {
+		if r.ID == id {
+			s.Requests = append(s.Requests[:i], s.Requests[i+1:]...)
+			return s.saveToFile()
+		}
+	}
+	return errors.NewScheduleError(fmt.Sprintf("no scheduled request with id %q", id))
+}
+
+// Due returns the requests due at now, ordered by id.
+func (s *ScheduleStore) Due(now time.Time) []*ScheduledRequest {
+	var due []*ScheduledRequest
+	for _, r := range s.Requests {
+		if r.IsDue(now) {
+			due = append(due, r)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		a, _ := strconv.Atoi(due[i].ID)
+		b, _ := strconv.Atoi(due[j].ID)
+		return a < b
+	})
+	return due
+}
+
+// MarkRun records that the request with the given id ran at t and persists the store.
+func (s *ScheduleStore) MarkRun(id string, t time.Time) error {
+	if s.loadErr != nil {
+		return s.refuseErr()
+	}
+	r := s.Get(id)
+	if r == nil {
+		return errors.NewScheduleError(fmt.Sprintf("no scheduled request with id %q", id))
+	}
+	r.LastRun = t
+	return s.saveToFile()
+}
+
+func (s *ScheduleStore) refuseErr() error {
+	return errors.NewScheduleError(fmt.Sprintf("refusing to overwrite %s, which exists but could not be loaded (fix or remove it first): %v", s.filePath, s.loadErr))
+}
+
+// ─── Persistence ────────────────────────────────────────────────────
+
+func (s *ScheduleStore) loadFromFile() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.NewIOError(err)
+	}
+	var loaded ScheduleStore
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return errors.NewScheduleError(fmt.Sprintf("failed to parse schedule file %s: %v", s.filePath, err))
+	}
+	s.Requests = loaded.Requests
+	return nil
+}
+
+func (s *ScheduleStore) saveToFile() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return errors.NewScheduleError(fmt.Sprintf("failed to serialize schedule file: %v", err))
+	}
+	// Scheduled requests can carry post bodies and usernames: owner only.
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.NewIOError(err)
+	}
+	if err := os.Rename(tmp, s.filePath); err != nil {
+		_ = os.Remove(tmp)
+		return errors.NewIOError(err)
+	}
+	return nil
+}
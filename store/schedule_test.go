@@ -0,0 +1,115 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+func tempScheduleStore(t *testing.T) *ScheduleStore {
+	t.Helper()
+	return NewScheduleStoreWithPath(filepath.Join(t.TempDir(), "schedule.yml"))
+}
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "0 9 * * 1-5", "*/15 0,12 1 1-12/2 7"} {
+		_, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	loc := time.UTC
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		require.NoError(t, err)
+		return tm
+	}
+	cases := []struct {
+		expr, from, want string
+	}{
+		{"0 9 * * *", "2026-03-01 08:59", "2026-03-01 09:00"},
+		{"0 9 * * *", "2026-03-01 09:00", "2026-03-02 09:00"},
+		{"*/15 * * * *", "2026-03-01 10:07", "2026-03-01 10:15"},
+		{"0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00"},
+		// 2026-03-07 is a Saturday; the next weekday is Monday the 9th.
+		{"30 8 * * 1-5", "2026-03-07 12:00", "2026-03-09 08:30"},
+		// Sunday may be written as 7.
+		{"0 12 * * 7", "2026-03-07 12:00", "2026-03-08 12:00"},
+		// Both day fields restricted: either matches (the 10th or a Sunday).
+		{"0 0 10 * 0", "2026-03-07 00:00", "2026-03-08 00:00"},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		require.NoError(t, err)
+		assert.Equal(t, at(c.want), cron.Next(at(c.from)), "%s from %s", c.expr, c.from)
+	}
+
+	never, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(at("2026-01-01 00:00")).IsZero())
+}
+
+func TestScheduledRequestIsDue(t *testing.T) {
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.Local)
+	r := &ScheduledRequest{Cron: "0 9 * * *", Endpoint: "/2/tweets", CreatedAt: created}
+
+	assert.False(t, r.IsDue(created.Add(59*time.Minute)), "not due before the first fire time")
+	assert.True(t, r.IsDue(created.Add(time.Hour)), "due exactly at the fire time")
+	assert.True(t, r.IsDue(created.Add(72*time.Hour)), "missed runs stay due")
+
+	r.LastRun = created.Add(72 * time.Hour)
+	assert.False(t, r.IsDue(created.Add(72*time.Hour+time.Minute)), "coalesced after running")
+
+	r.Cron = "not a cron"
+	assert.False(t, r.IsDue(created.Add(1000*time.Hour)), "invalid cron is never due")
+}
+
+func TestScheduleStoreRoundTrip(t *testing.T) {
+	s := tempScheduleStore(t)
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.Local)
+
+	require.NoError(t, s.Add(&ScheduledRequest{Cron: "0 9 * * *", Method: "POST", Endpoint: "/2/tweets", Data: `{"text":"gm"}`, CreatedAt: created}))
+	require.NoError(t, s.Add(&ScheduledRequest{Cron: "0 12 * * *", Method: "GET", Endpoint: "/2/users/me", CreatedAt: created}))
+	assert.Error(t, s.Add(&ScheduledRequest{Cron: "bad", Endpoint: "/2/users/me"}))
+
+	reloaded := NewScheduleStoreWithPath(s.FilePath())
+	require.Len(t, reloaded.Requests, 2)
+	assert.Equal(t, "1", reloaded.Requests[0].ID)
+	assert.Equal(t, "2", reloaded.Requests[1].ID)
+	assert.Equal(t, `{"text":"gm"}`, reloaded.Requests[0].Data)
+
+	due := reloaded.Due(created.Add(90 * time.Minute))
+	require.Len(t, due, 1)
+	assert.Equal(t, "1", due[0].ID)
+
+	require.NoError(t, reloaded.MarkRun("1", created.Add(90*time.Minute)))
+	assert.Empty(t, NewScheduleStoreWithPath(s.FilePath()).Due(created.Add(2*time.Hour)))
+
+	require.NoError(t, reloaded.Remove("1"))
+	assert.True(t, xurlErrors.IsScheduleError(reloaded.Remove("1")))
+	assert.Len(t, NewScheduleStoreWithPath(s.FilePath()).Requests, 1)
+
+	info, err := os.Stat(s.FilePath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestScheduleStoreRefusesToOverwriteCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.yml")
+	require.NoError(t, os.WriteFile(path, []byte("requests: [unterminated"), 0600))
+
+	s := NewScheduleStoreWithPath(path)
+	require.True(t, xurlErrors.IsScheduleError(s.LoadErr()))
+	assert.Error(t, s.Add(&ScheduledRequest{Cron: "* * * * *", Endpoint: "/2/users/me"}))
+}
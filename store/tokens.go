@@ -3,10 +3,14 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"sync"
 
+	"github.com/xdevplatform/xurl/config/paths"
 	"github.com/xdevplatform/xurl/errors"
 
 	"gopkg.in/yaml.v3"
@@ -20,6 +24,9 @@ type OAuth1Token struct {
 	TokenSecret    string `yaml:"token_secret" json:"token_secret"`
 	ConsumerKey    string `yaml:"consumer_key" json:"consumer_key"`
 	ConsumerSecret string `yaml:"consumer_secret" json:"consumer_secret"`
+	// UserID caches the ID of the account the token acts as (see
+	// OAuth2Token.UserID).
+	UserID string `yaml:"user_id,omitempty" json:"user_id,omitempty"`
 }
 
 // Represents OAuth2 authentication tokens
@@ -27,6 +34,16 @@ type OAuth2Token struct {
 	AccessToken    string `yaml:"access_token" json:"access_token"`
 	RefreshToken   string `yaml:"refresh_token" json:"refresh_token"`
 	ExpirationTime uint64 `yaml:"expiration_time" json:"expiration_time"`
+	// UserID and Name cache the ID and display name of the account the token
+	// acts as, so user-context commands can skip a rate-limited /2/users/me
+	// call. They are dropped whenever the token is replaced by one that may
+	// belong to a different account.
+	UserID string `yaml:"user_id,omitempty" json:"user_id,omitempty"`
+	Name   string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Scopes are the scopes the token was granted, as the token endpoint
+	// reported them. They are empty for tokens stored before xurl recorded
+	// them, whose scopes are unknown.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
 }
 
 // Represents the type of token
@@ -61,7 +78,7 @@ type App struct {
 
 // ─── On-disk YAML structure ─────────────────────────────────────────
 
-// storeFile is the serialised YAML layout of ~/.xurl
+// storeFile is the serialised YAML layout of auth.yml
 type storeFile struct {
 	Apps       map[string]*App `yaml:"apps"`
 	DefaultApp string          `yaml:"default_app"`
@@ -84,39 +101,75 @@ type TokenStore struct {
 	Apps       map[string]*App `yaml:"apps"`
 	DefaultApp string          `yaml:"default_app"`
 	FilePath   string          `yaml:"-"`
+	// custom is set when the file was chosen with --store-path or
+	// $XURL_STORE rather than defaulting to auth.yml.
+	custom bool
+	// readOnlyReason is set when the store is read-only (see SetReadOnly)
+	// and says why.
+	readOnlyReason string
+	// warnings receives the warning about a skipped save; nil means stderr.
+	warnings         io.Writer
+	readOnlyWarnOnce sync.Once
+}
+
+// StorePathEnv names the environment variable that points the token store at
+// a specific file, like the --store-path flag.
+const StorePathEnv = "XURL_STORE"
+
+// NoTwurlImportEnv names the environment variable that, set to a true value
+// such as 1, stops the token store from importing ~/.twurlrc automatically,
+// like the --no-twurl-import flag.
+const NoTwurlImportEnv = "XURL_NO_TWURL_IMPORT"
+
+// TwurlImportDisabled reports whether $XURL_NO_TWURL_IMPORT turns off the
+// automatic ~/.twurlrc import.
+func TwurlImportDisabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv(NoTwurlImportEnv))
+	return disabled
+}
+
+// Creates a new TokenStore backed by the file at path. An empty path means
+// $XURL_STORE, or else auth.yml in the data directory (migrating a legacy
+// ~/.xurl store on first use).
+func NewTokenStore(path string) *TokenStore {
+	return NewTokenStoreWithCredentials(path, "", "")
+}
+
+// NewTokenStoreWithCredentials opens a TokenStore like OpenTokenStore and
+// then imports ~/.twurlrc into it (see AutoImportTwurlrc), unless
+// $XURL_NO_TWURL_IMPORT is set.
+func NewTokenStoreWithCredentials(path, clientID, clientSecret string) *TokenStore {
+	store := OpenTokenStore(path, clientID, clientSecret)
+	if !TwurlImportDisabled() {
+		store.AutoImportTwurlrc()
+	}
+	return store
 }
 
-func resolveHomeDir() string {
-	if homeDir := os.Getenv("HOME"); homeDir != "" {
-		return homeDir
+// OpenTokenStore creates a TokenStore without importing ~/.twurlrc, and
+// backfills the given client credentials into any app that has tokens but no
+// stored client ID/secret (e.g. apps authenticated with CLIENT_ID /
+// CLIENT_SECRET coming from env vars), so later refreshes work without the
+// env vars present. path is as for NewTokenStore.
+func OpenTokenStore(path, clientID, clientSecret string) *TokenStore {
+	if path == "" {
+		path = os.Getenv(StorePathEnv)
 	}
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Println("Error getting home directory:", err)
-		return "."
+	custom := path != ""
+	filePath := path
+	if !custom {
+		filePath = AuthFilePath()
 	}
 
-	return homeDir
-}
-
-// Creates a new TokenStore, loading from ~/.xurl/auth.yml (migrating a
-// legacy single-file ~/.xurl on first use).
-func NewTokenStore() *TokenStore {
-	return NewTokenStoreWithCredentials("", "")
-}
-
-// NewTokenStoreWithCredentials creates a TokenStore and backfills the given
-// client credentials into any app that has tokens but no stored client
-// ID/secret (e.g. apps authenticated with CLIENT_ID / CLIENT_SECRET coming
-// from env vars), so later refreshes work without the env vars present.
-func NewTokenStoreWithCredentials(clientID, clientSecret string) *TokenStore {
-	homeDir := resolveHomeDir()
-	filePath := AuthFilePath()
-
 	store := &TokenStore{
 		Apps:     make(map[string]*App),
 		FilePath: filePath,
+		custom:   custom,
+	}
+	if ReadOnlyStoreRequested() {
+		store.SetReadOnly(ReadOnlyStoreEnv + " is set")
+	} else if !storeWritable(filePath) {
+		store.SetReadOnly("it is not writable")
 	}
 
 	if _, err := os.Stat(filePath); err == nil {
@@ -141,18 +194,7 @@ func NewTokenStoreWithCredentials(clientID, clientSecret string) *TokenStore {
 			}
 		}
 		if dirty {
-			_ = store.saveToFile()
-		}
-	}
-
-	// Import from .twurlrc if we have no apps or the default app is missing OAuth1/Bearer
-	app := store.activeApp()
-	if app == nil || app.OAuth1Token == nil || app.BearerToken == nil {
-		twurlPath := filepath.Join(homeDir, ".twurlrc")
-		if _, err := os.Stat(twurlPath); err == nil {
-			if err := store.importFromTwurlrc(twurlPath); err != nil {
-				fmt.Println("Error importing from .twurlrc:", err)
-			}
+			_ = store.saveQuietly()
 		}
 	}
 
@@ -189,7 +231,7 @@ func (s *TokenStore) loadFromData(data []byte) {
 		}
 		s.DefaultApp = "default"
 		// Persist in new YAML format immediately
-		_ = s.saveToFile()
+		_ = s.saveQuietly()
 	}
 }
 
@@ -348,8 +390,53 @@ func (s *TokenStore) ResolveApp(name string) *App {
 
 // ─── Twurlrc import ─────────────────────────────────────────────────
 
-// Imports tokens from a twurlrc file into the active app.
+// AutoImportTwurlrc imports ~/.twurlrc, if there is one, when the active app
+// is missing an OAuth1 token or Bearer token. An explicitly chosen store
+// (--store-path or $XURL_STORE) is kept isolated and never imported into.
+func (s *TokenStore) AutoImportTwurlrc() {
+	app := s.activeApp()
+	if s.custom || (app != nil && app.OAuth1Token != nil && app.BearerToken != nil) {
+		return
+	}
+	twurlPath := TwurlrcPath()
+	if _, err := os.Stat(twurlPath); err == nil {
+		if err := s.importFromTwurlrc(twurlPath); err != nil {
+			fmt.Println("Error importing from .twurlrc:", err)
+		}
+	}
+}
+
+// TwurlrcPath returns the path of twurl's credentials file, ~/.twurlrc.
+func TwurlrcPath() string {
+	return filepath.Join(paths.HomeDir(), ".twurlrc")
+}
+
+// ImportTwurlrcForApp imports the first OAuth1 profile and Bearer token from
+// the twurl credentials file at path (~/.twurlrc when empty) into the named
+// app. Unlike AutoImportTwurlrc it works on any store, and it fails when the
+// file is missing. An OAuth1 token already in the app is kept.
+func (s *TokenStore) ImportTwurlrcForApp(appName, path string) error {
+	if path == "" {
+		path = TwurlrcPath()
+	}
+	if err := s.importTwurlrcInto(s.ResolveApp(appName), path); err != nil {
+		return err
+	}
+	return s.saveToFile()
+}
+
+// Imports tokens from a twurlrc file into the active app. On a read-only
+// store they are only kept in memory.
 func (s *TokenStore) importFromTwurlrc(filePath string) error {
+	if err := s.importTwurlrcInto(s.activeAppOrCreate(), filePath); err != nil {
+		return err
+	}
+	return s.saveQuietly()
+}
+
+// importTwurlrcInto imports tokens from a twurlrc file into app, without
+// saving.
+func (s *TokenStore) importTwurlrcInto(app *App, filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return errors.NewIOError(err)
@@ -373,8 +460,6 @@ func (s *TokenStore) importFromTwurlrc(filePath string) error {
 		return errors.NewJSONError(err)
 	}
 
-	app := s.activeAppOrCreate()
-
 	// Import the first OAuth1 tokens from twurlrc
 	for _, consumerKeys := range twurlConfig.Profiles {
 		for consumerKey, profile := range consumerKeys {
@@ -405,7 +490,7 @@ func (s *TokenStore) importFromTwurlrc(filePath string) error {
 		}
 	}
 
-	return s.saveToFile()
+	return nil
 }
 
 // ─── Token operations (delegate to active / named app) ──────────────
@@ -436,13 +521,25 @@ func (s *TokenStore) SaveOAuth2TokenForApp(appName, username, accessToken, refre
 	if app.OAuth2Tokens == nil {
 		app.OAuth2Tokens = make(map[string]Token)
 	}
+	token := &OAuth2Token{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		ExpirationTime: expirationTime,
+	}
+	// A token stored under a username belongs to that account however often
+	// it is refreshed or re-authorized, so its cached identity survives; one
+	// stored under "" may be anyone's.
+	if prev, ok := app.OAuth2Tokens[username]; ok && username != "" && prev.OAuth2 != nil {
+		token.UserID, token.Name = prev.OAuth2.UserID, prev.OAuth2.Name
+	}
+	// A refresh keeps the grant's scopes unless it reports new ones (see
+	// SetOAuth2ScopesForApp).
+	if prev, ok := app.OAuth2Tokens[username]; ok && prev.OAuth2 != nil {
+		token.Scopes = prev.OAuth2.Scopes
+	}
 	app.OAuth2Tokens[username] = Token{
-		Type: OAuth2TokenType,
-		OAuth2: &OAuth2Token{
-			AccessToken:    accessToken,
-			RefreshToken:   refreshToken,
-			ExpirationTime: expirationTime,
-		},
+		Type:   OAuth2TokenType,
+		OAuth2: token,
 	}
 	return s.saveToFile()
 }
@@ -455,18 +552,79 @@ func (s *TokenStore) SaveOAuth1Tokens(accessToken, tokenSecret, consumerKey, con
 // SaveOAuth1TokensForApp saves OAuth1 tokens into the named app.
 func (s *TokenStore) SaveOAuth1TokensForApp(appName, accessToken, tokenSecret, consumerKey, consumerSecret string) error {
 	app := s.ResolveApp(appName)
+	token := &OAuth1Token{
+		AccessToken:    accessToken,
+		TokenSecret:    tokenSecret,
+		ConsumerKey:    consumerKey,
+		ConsumerSecret: consumerSecret,
+	}
+	// The cached user ID only carries over when the access token, which
+	// identifies the account, is unchanged.
+	if prev := app.OAuth1Token; prev != nil && prev.OAuth1 != nil && prev.OAuth1.AccessToken == accessToken {
+		token.UserID = prev.OAuth1.UserID
+	}
 	app.OAuth1Token = &Token{
-		Type: OAuth1TokenType,
-		OAuth1: &OAuth1Token{
-			AccessToken:    accessToken,
-			TokenSecret:    tokenSecret,
-			ConsumerKey:    consumerKey,
-			ConsumerSecret: consumerSecret,
-		},
+		Type:   OAuth1TokenType,
+		OAuth1: token,
 	}
 	return s.saveToFile()
 }
 
+// SetOAuth2IdentityForApp caches the user ID and display name of the OAuth2
+// token stored under username in the named app. Empty values clear them.
+func (s *TokenStore) SetOAuth2IdentityForApp(appName, username, userID, name string) error {
+	app := s.ResolveApp(appName)
+	token, ok := app.OAuth2Tokens[username]
+	if !ok || token.OAuth2 == nil {
+		return errors.NewAuthError("TokenNotFound", fmt.Errorf("no OAuth2 token stored for %q", username))
+	}
+	oauth2 := *token.OAuth2
+	oauth2.UserID, oauth2.Name = userID, name
+	token.OAuth2 = &oauth2
+	app.OAuth2Tokens[username] = token
+	return s.saveToFile()
+}
+
+// SetOAuth2ScopesForApp records the scopes granted to the OAuth2 token stored
+// under username in the named app.
+func (s *TokenStore) SetOAuth2ScopesForApp(appName, username string, scopes []string) error {
+	app := s.ResolveApp(appName)
+	token, ok := app.OAuth2Tokens[username]
+	if !ok || token.OAuth2 == nil {
+		return errors.NewAuthError("TokenNotFound", fmt.Errorf("no OAuth2 token stored for %q", username))
+	}
+	oauth2 := *token.OAuth2
+	oauth2.Scopes = scopes
+	token.OAuth2 = &oauth2
+	app.OAuth2Tokens[username] = token
+	return s.saveToFile()
+}
+
+// SetOAuth1UserIDForApp caches the user ID of the named app's OAuth1 token.
+func (s *TokenStore) SetOAuth1UserIDForApp(appName, userID string) error {
+	app := s.ResolveApp(appName)
+	if app.OAuth1Token == nil || app.OAuth1Token.OAuth1 == nil {
+		return errors.NewAuthError("TokenNotFound", fmt.Errorf("no OAuth1 token stored"))
+	}
+	app.OAuth1Token.OAuth1.UserID = userID
+	return s.saveToFile()
+}
+
+// GetUserID returns the cached user ID of the OAuth2 token stored under
+// username in the resolved app, or "" if none is cached.
+func (s *TokenStore) GetUserID(username string) string {
+	return s.GetUserIDForApp("", username)
+}
+
+// GetUserIDForApp returns the cached user ID of the OAuth2 token stored under
+// username in the named app, or "" if none is cached.
+func (s *TokenStore) GetUserIDForApp(appName, username string) string {
+	if token := s.GetOAuth2TokenForApp(appName, username); token != nil && token.OAuth2 != nil {
+		return token.OAuth2.UserID
+	}
+	return ""
+}
+
 // GetOAuth2Token gets an OAuth2 token for a username from the resolved app.
 func (s *TokenStore) GetOAuth2Token(username string) *Token {
 	return s.GetOAuth2TokenForApp("", username)
@@ -620,8 +778,21 @@ func (s *TokenStore) HasBearerToken() bool {
 
 // ─── Persistence ────────────────────────────────────────────────────
 
-// Saves the token store to ~/.xurl in YAML format.
+// Saves the token store to auth.yml in YAML format. A read-only store is
+// not saved: the first skipped save prints a warning, and the change stays
+// in memory.
 func (s *TokenStore) saveToFile() error {
+	if s.readOnlyReason != "" {
+		s.readOnlyWarnOnce.Do(func() {
+			w := s.warnings
+			if w == nil {
+				w = os.Stderr
+			}
+			fmt.Fprintf(w, "Warning: not saving the token store %s because %s; changes such as refreshed tokens last until xurl exits\n", s.FilePath, s.readOnlyReason)
+		})
+		return nil
+	}
+
 	sf := storeFile{
 		Apps:       s.Apps,
 		DefaultApp: s.DefaultApp,
@@ -631,6 +802,9 @@ func (s *TokenStore) saveToFile() error {
 		return errors.NewJSONError(err)
 	}
 
+	if err := os.MkdirAll(filepath.Dir(s.FilePath), 0700); err != nil {
+		return errors.NewIOError(err)
+	}
 	err = os.WriteFile(s.FilePath, data, 0600)
 	if err != nil {
 		return errors.NewIOError(err)
@@ -28,7 +28,7 @@ func createTempTokenStore(t *testing.T) (*TokenStore, string) {
 }
 
 func TestNewTokenStore(t *testing.T) {
-	store := NewTokenStore()
+	store := NewTokenStore("")
 
 	assert.NotNil(t, store, "Expected non-nil TokenStore")
 	assert.NotNil(t, store.Apps, "Expected non-nil Apps map")
@@ -303,11 +303,11 @@ func TestCredentialBackfill(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
 
 	// An app with tokens but no stored client credentials (authenticated
 	// with CLIENT_ID/CLIENT_SECRET coming from env vars).
-	s1 := NewTokenStore()
+	s1 := NewTokenStore("")
 	require.NoError(t, s1.AddApp("default", "", ""))
 	require.NoError(t, s1.SaveOAuth2TokenForApp("default", "user1", "at", "rt", 9999))
 	app1 := s1.GetApp("default")
@@ -315,7 +315,7 @@ func TestCredentialBackfill(t *testing.T) {
 	assert.Empty(t, app1.ClientID, "Should have no client ID without backfill")
 
 	// Loading WITH credentials should backfill the credential-less app.
-	s2 := NewTokenStoreWithCredentials("env-id", "env-secret")
+	s2 := NewTokenStoreWithCredentials("", "env-id", "env-secret")
 	app2 := s2.GetApp("default")
 	require.NotNil(t, app2)
 	assert.Equal(t, "env-id", app2.ClientID, "Should have backfilled client ID")
@@ -324,7 +324,7 @@ func TestCredentialBackfill(t *testing.T) {
 	// App that already has credentials should NOT be overwritten
 	s2.AddApp("has-creds", "existing-id", "existing-secret")
 	s2.SaveOAuth2TokenForApp("has-creds", "u", "t", "r", 1)
-	s3 := NewTokenStoreWithCredentials("other-id", "other-secret")
+	s3 := NewTokenStoreWithCredentials("", "other-id", "other-secret")
 	app3 := s3.GetApp("has-creds")
 	assert.Equal(t, "existing-id", app3.ClientID, "Should not overwrite existing credentials")
 }
@@ -459,7 +459,7 @@ func TestLegacyJSONMigration(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
 
 	// Write a pre-v1.0 legacy JSON .xurl file
 	legacy := map[string]interface{}{
@@ -483,7 +483,7 @@ func TestLegacyJSONMigration(t *testing.T) {
 	err = os.WriteFile(xurlPath, data, 0600)
 	require.NoError(t, err)
 
-	store := NewTokenStore()
+	store := NewTokenStore("")
 
 	// Should have migrated into a "default" app
 	assert.Equal(t, "default", store.GetDefaultApp())
@@ -500,9 +500,10 @@ func TestLegacyJSONMigration(t *testing.T) {
 	require.NotNil(t, bearer)
 	assert.Equal(t, "leg-bearer", bearer.Bearer)
 
-	// File should now be YAML, living inside the ~/.xurl directory after
-	// the legacy-file migration.
-	assert.Equal(t, filepath.Join(xurlPath, "auth.yml"), store.FilePath)
+	// File should now be YAML, living in the data directory after the
+	// legacy-file migration.
+	assert.Equal(t, AuthFilePath(), store.FilePath)
+	assert.NotEqual(t, filepath.Join(xurlPath, "auth.yml"), store.FilePath)
 	raw, _ := os.ReadFile(store.FilePath)
 	assert.Contains(t, string(raw), "apps:")
 	assert.Contains(t, string(raw), "default_app:")
@@ -549,7 +550,7 @@ func TestTwurlrc(t *testing.T) {
 	require.NoError(t, err, "Failed to create temp directory")
 	defer os.RemoveAll(tempDir)
 
-	t.Setenv("HOME", tempDir)
+	setTestHome(t, tempDir)
 
 	twurlContent := `profiles:
   testuser:
@@ -605,7 +606,7 @@ configuration:
 	t.Run("Auto-import when no xurl file exists", func(t *testing.T) {
 		os.Remove(xurlPath)
 
-		store := NewTokenStore()
+		store := NewTokenStore("")
 
 		oauth1Token := store.GetOAuth1Tokens()
 		require.NotNil(t, oauth1Token, "OAuth1Token is nil after auto-import")
@@ -613,18 +614,18 @@ configuration:
 		oauth1 := oauth1Token.OAuth1
 		assert.Equal(t, "test_access_token", oauth1.AccessToken, "Unexpected access token")
 
-		_, err := os.Stat(xurlPath)
-		assert.False(t, os.IsNotExist(err), ".xurl file was not created")
+		_, err := os.Stat(store.FilePath)
+		assert.False(t, os.IsNotExist(err), "auth.yml was not created")
 	})
 
 	// Test 3: Auto-import when .xurl exists but has no OAuth1 token
 	t.Run("Auto-import when xurl exists but has no OAuth1 token", func(t *testing.T) {
-		store := NewTokenStore()
+		store := NewTokenStore("")
 
 		// Clear OAuth1 from the active app
 		store.ClearOAuth1Tokens()
 
-		store = NewTokenStore()
+		store = NewTokenStore("")
 
 		oauth1Token := store.GetOAuth1Tokens()
 		require.NotNil(t, oauth1Token, "OAuth1Token is nil after re-import")
@@ -654,3 +655,144 @@ configuration:
 		assert.Error(t, err, "Expected error when importing from malformed .twurlrc")
 	})
 }
+
+func TestUserIDCache(t *testing.T) {
+	store, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	t.Run("OAuth2 identity survives a refresh of the same user's token", func(t *testing.T) {
+		require.NoError(t, store.SaveOAuth2TokenForApp("default", "alice", "access-1", "refresh-1", 1))
+		require.NoError(t, store.SetOAuth2IdentityForApp("default", "alice", "42", "Alice"))
+		assert.Equal(t, "42", store.GetUserIDForApp("default", "alice"))
+
+		require.NoError(t, store.SaveOAuth2TokenForApp("default", "alice", "access-2", "refresh-2", 2))
+		assert.Equal(t, "42", store.GetUserIDForApp("default", "alice"))
+		assert.Equal(t, "Alice", store.GetOAuth2TokenForApp("default", "alice").OAuth2.Name)
+
+		require.NoError(t, store.ClearOAuth2TokenForApp("default", "alice"))
+		require.NoError(t, store.SaveOAuth2TokenForApp("default", "alice", "access-3", "refresh-3", 3))
+		assert.Empty(t, store.GetUserIDForApp("default", "alice"), "a cleared token takes its ID with it")
+
+		assert.Error(t, store.SetOAuth2IdentityForApp("default", "bob", "7", ""))
+	})
+
+	t.Run("OAuth2 ID under an unknown username is dropped on save", func(t *testing.T) {
+		require.NoError(t, store.SaveOAuth2TokenForApp("default", "", "access-1", "refresh-1", 1))
+		require.NoError(t, store.SetOAuth2IdentityForApp("default", "", "42", ""))
+		require.NoError(t, store.SaveOAuth2TokenForApp("default", "", "access-2", "refresh-2", 2))
+		assert.Empty(t, store.GetUserIDForApp("default", ""))
+	})
+
+	t.Run("OAuth1 ID is dropped when the access token changes", func(t *testing.T) {
+		require.NoError(t, store.SaveOAuth1TokensForApp("default", "access-1", "secret", "ck", "cs"))
+		require.NoError(t, store.SetOAuth1UserIDForApp("default", "42"))
+
+		require.NoError(t, store.SaveOAuth1TokensForApp("default", "access-1", "secret-2", "ck", "cs"))
+		assert.Equal(t, "42", store.GetOAuth1TokensForApp("default").OAuth1.UserID)
+
+		require.NoError(t, store.SaveOAuth1TokensForApp("default", "access-2", "secret", "ck", "cs"))
+		assert.Empty(t, store.GetOAuth1TokensForApp("default").OAuth1.UserID)
+	})
+
+	t.Run("cached IDs are persisted", func(t *testing.T) {
+		require.NoError(t, store.SetOAuth1UserIDForApp("default", "99"))
+		data, err := os.ReadFile(store.FilePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "user_id: \"99\"")
+	})
+}
+
+func TestOAuth2Scopes(t *testing.T) {
+	store, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	require.NoError(t, store.SaveOAuth2TokenForApp("default", "alice", "access-1", "refresh-1", 1))
+	assert.Empty(t, store.GetOAuth2TokenForApp("default", "alice").OAuth2.Scopes, "unknown until recorded")
+
+	require.NoError(t, store.SetOAuth2ScopesForApp("default", "alice", []string{"tweet.read", "bookmark.read"}))
+	require.NoError(t, store.SaveOAuth2TokenForApp("default", "alice", "access-2", "refresh-2", 2))
+	assert.Equal(t, []string{"tweet.read", "bookmark.read"}, store.GetOAuth2TokenForApp("default", "alice").OAuth2.Scopes, "a refresh keeps the scopes")
+
+	data, err := os.ReadFile(store.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- bookmark.read")
+
+	assert.Error(t, store.SetOAuth2ScopesForApp("default", "bob", nil))
+}
+
+func TestCustomStorePath(t *testing.T) {
+	tempDir := t.TempDir()
+	setTestHome(t, tempDir)
+	// A twurl profile must not leak into an explicitly chosen store.
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".twurlrc"), []byte(`profiles:
+  someone:
+    key:
+      username: someone
+      consumer_key: key
+      consumer_secret: secret
+      token: token
+      secret: token-secret
+configuration:
+  default_profile:
+  - someone
+  - key`), 0600))
+
+	custom := filepath.Join(tempDir, "project", "tokens.yml")
+	s := NewTokenStore(custom)
+	assert.Equal(t, custom, s.FilePath)
+	assert.Nil(t, s.GetOAuth1Tokens(), ".twurlrc is not imported into a custom store")
+	require.NoError(t, s.SaveBearerToken("custom-bearer"))
+
+	_, err := os.Stat(custom)
+	require.NoError(t, err, "tokens are written at the custom path, creating its directory")
+	reopened := NewTokenStore(custom)
+	require.NotNil(t, reopened.GetBearerToken())
+	assert.Equal(t, "custom-bearer", reopened.GetBearerToken().Bearer)
+
+	t.Run("XURL_STORE is the default path", func(t *testing.T) {
+		t.Setenv(StorePathEnv, custom)
+		fromEnv := NewTokenStore("")
+		assert.Equal(t, custom, fromEnv.FilePath)
+		require.NotNil(t, fromEnv.GetBearerToken())
+		assert.Equal(t, "custom-bearer", fromEnv.GetBearerToken().Bearer)
+	})
+
+	t.Run("the default store is untouched", func(t *testing.T) {
+		_, err := os.Stat(AuthFilePath())
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestNoTwurlImport(t *testing.T) {
+	tempDir := t.TempDir()
+	setTestHome(t, tempDir)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".twurlrc"), []byte(`profiles:
+  someone:
+    key:
+      username: someone
+      consumer_key: key
+      consumer_secret: secret
+      token: token
+      secret: token-secret
+configuration:
+  default_profile:
+  - someone
+  - key
+bearer_tokens:
+  key: twurl-bearer`), 0600))
+	t.Setenv(NoTwurlImportEnv, "1")
+
+	s := NewTokenStore("")
+	assert.Nil(t, s.GetOAuth1Tokens(), "the automatic import is skipped when disabled")
+	assert.Nil(t, s.GetBearerToken())
+	_, err := os.Stat(s.FilePath)
+	assert.True(t, os.IsNotExist(err), "nothing is written to the store")
+
+	require.NoError(t, s.ImportTwurlrcForApp("", ""), "the explicit import still works")
+	require.NotNil(t, s.GetOAuth1Tokens())
+	assert.Equal(t, "token", s.GetOAuth1Tokens().OAuth1.AccessToken)
+	require.NotNil(t, s.GetBearerToken())
+	assert.Equal(t, "twurl-bearer", s.GetBearerToken().Bearer)
+
+	assert.Error(t, s.ImportTwurlrcForApp("", filepath.Join(tempDir, "missing")))
+}
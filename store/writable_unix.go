@@ -0,0 +1,10 @@
+//go:build unix
+
+package store
+
+import "golang.org/x/sys/unix"
+
+// dirWritable reports whether files can be created in dir.
+func dirWritable(dir string) bool {
+	return unix.Access(dir, unix.W_OK) == nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardLookPath, clipboardRun and stderrIsTerminal are swapped out in
+// tests.
+var (
+	clipboardLookPath = exec.LookPath
+	stderrIsTerminal  = func() bool {
+		info, err := os.Stderr.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	clipboardRun = func(name string, args []string, input []byte) error {
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+)
+
+// clipboardCommands returns the clipboard tools to look for, in order of
+// preference, for goos. Wayland's wl-copy is preferred when a Wayland session
+// is running; under WSL the Windows clip.exe is tried after the native tools.
+func clipboardCommands(goos string, wayland, wsl bool) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}, {"powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard"}}
+	}
+
+	var cmds [][]string
+	if wayland {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	cmds = append(cmds,
+		[]string{"xclip", "-selection", "clipboard"},
+		[]string{"xsel", "--clipboard", "--input"},
+	)
+	if !wayland {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if wsl {
+		cmds = append(cmds, []string{"clip.exe"})
+	}
+	return cmds
+}
+
+// CopyToClipboard places data on the system clipboard using the first
+// available clipboard tool, and returns a short description of how it was
+// copied. When no tool is installed but stderr is a terminal, it falls back
+// to the OSC 52 escape sequence, which most modern terminals (including over
+// SSH) turn into a clipboard write. Otherwise it fails, naming every tool it
+// looked for.
+func CopyToClipboard(data []byte) (string, error) {
+	wayland := os.Getenv("WAYLAND_DISPLAY") != ""
+	wsl := os.Getenv("WSL_DISTRO_NAME") != ""
+	cmds := clipboardCommands(runtime.GOOS, wayland, wsl)
+
+	var looked []string
+	for _, c := range cmds {
+		looked = append(looked, c[0])
+		path, err := clipboardLookPath(c[0])
+		if err != nil {
+			continue
+		}
+		if err := clipboardRun(path, c[1:], data); err != nil {
+			return "", fmt.Errorf("%s failed: %v", c[0], err)
+		}
+		return c[0], nil
+	}
+
+	if stderrIsTerminal() {
+		fmt.Fprintf(os.Stderr, "\033]52;c;%s\a", base64.StdEncoding.EncodeToString(data))
+		return "terminal (OSC 52)", nil
+	}
+	return "", fmt.Errorf("no clipboard tool found (looked for %s) and stderr is not a terminal", strings.Join(looked, ", "))
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipboardCommands(t *testing.T) {
+	first := func(cmds [][]string) []string {
+		var names []string
+		for _, c := range cmds {
+			names = append(names, c[0])
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"pbcopy"}, first(clipboardCommands("darwin", false, false)))
+	assert.Equal(t, []string{"clip.exe", "powershell.exe"}, first(clipboardCommands("windows", false, false)))
+	assert.Equal(t, []string{"xclip", "xsel", "wl-copy"}, first(clipboardCommands("linux", false, false)))
+	assert.Equal(t, []string{"wl-copy", "xclip", "xsel"}, first(clipboardCommands("linux", true, false)))
+	assert.Equal(t, []string{"xclip", "xsel", "wl-copy", "clip.exe"}, first(clipboardCommands("linux", false, true)))
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	prevLook, prevRun, prevTerm := clipboardLookPath, clipboardRun, stderrIsTerminal
+	defer func() { clipboardLookPath, clipboardRun, stderrIsTerminal = prevLook, prevRun, prevTerm }()
+	stderrIsTerminal = func() bool { return false }
+
+	available := map[string]bool{}
+	clipboardLookPath = func(name string) (string, error) {
+		if available[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+	var ran string
+	var got []byte
+	clipboardRun = func(name string, args []string, input []byte) error {
+		ran, got = name, input
+		return nil
+	}
+
+	t.Run("uses the first available tool", func(t *testing.T) {
+		available["pbcopy"], available["xsel"], available["clip.exe"] = true, true, true
+		defer func() { available = map[string]bool{} }()
+
+		via, err := CopyToClipboard([]byte("12345"))
+		require.NoError(t, err)
+		assert.NotEmpty(t, via)
+		assert.Contains(t, ran, via)
+		assert.Equal(t, "12345", string(got))
+	})
+
+	t.Run("fails naming what it looked for", func(t *testing.T) {
+		_, err := CopyToClipboard([]byte("x"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no clipboard tool found (looked for ")
+	})
+}
+
+func TestCapturedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor = prevOut, prevNoColor }()
+
+	f := NewFormatter()
+	f.StartCapture()
+	require.NoError(t, f.Print(json.RawMessage(`{"data":{"id":"1"}}`)))
+	assert.Equal(t, "{\n  \"data\": {\n    \"id\": \"1\"\n  }\n}\n", string(f.CapturedOutput()))
+
+	// A bare JSON string is copied without its quotes.
+	require.NoError(t, f.Print("1234567890"))
+	assert.Equal(t, "1234567890", string(f.CapturedOutput()))
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Color modes for Formatter.SetColorMode.
+const (
+	// ColorAuto colors output written to a terminal, unless NO_COLOR is
+	// set or TERM is dumb.
+	ColorAuto = "auto"
+	// ColorAlways colors all output, even into a pipe or file
+	// (--force-color).
+	ColorAlways = "always"
+	// ColorNever colors nothing (--no-color).
+	ColorNever = "never"
+)
+
+// stdoutWriter and stderrWriter are fatih/color's own writers for stdout
+// and stderr, which are not *os.File on every platform.
+var stdoutWriter, stderrWriter = color.Output, color.Error
+
+// SetColorMode sets when printed responses are colored: ColorAuto,
+// ColorAlways or ColorNever. Always and never also apply to everything
+// else printed through fatih/color.
+func (f *Formatter) SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto:
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid color mode %q: use auto, always or never", mode)
+	}
+	f.colorMode = mode
+	return nil
+}
+
+// colorEnabled reports whether output written to w is colored.
+func (f *Formatter) colorEnabled(w io.Writer) bool {
+	switch f.colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return !color.NoColor && isTerminalWriter(w)
+}
+
+// isTerminalWriter reports whether w writes to a terminal; tests swap it out.
+var isTerminalWriter = func(w io.Writer) bool {
+	switch w {
+	case stdoutWriter:
+		w = os.Stdout
+	case stderrWriter:
+		w = os.Stderr
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// StripANSI returns a writer that passes everything written to it on to w
+// except ANSI escape sequences, such as colors. A sequence may be split
+// across writes.
+func StripANSI(w io.Writer) io.Writer {
+	return &ansiStripper{w: w}
+}
+
+// States of ansiStripper.
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+)
+
+type ansiStripper struct {
+	w     io.Writer
+	state int
+}
+
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			if b == 0x1b {
+				s.state = ansiEscape
+				continue
+			}
+			out = append(out, b)
+		case ansiEscape:
+			// ESC [ starts a control sequence; otherwise intermediate
+			// bytes run until the final byte of the escape.
+			switch {
+			case b == '[':
+				s.state = ansiCSI
+			case b < 0x20 || b > 0x2f:
+				s.state = ansiText
+			}
+		case ansiCSI:
+			// Parameter and intermediate bytes run until a final byte.
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		}
+	}
+	if _, err := s.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAndPrintResponseColor(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor, prevTerminal := color.Output, color.NoColor, isTerminalWriter
+	color.Output, color.NoColor = &buf, false
+	defer func() {
+		color.Output, color.NoColor, isTerminalWriter = prevOut, prevNoColor, prevTerminal
+	}()
+
+	terminal := false
+	isTerminalWriter = func(io.Writer) bool { return terminal }
+	f := NewFormatter()
+	print := func() string {
+		buf.Reset()
+		require.NoError(t, f.Print(map[string]any{"id": "1", "n": 2}))
+		return buf.String()
+	}
+
+	out := print()
+	assert.NotContains(t, out, "\x1b", "no colors when the writer is not a terminal")
+	assert.JSONEq(t, `{"id": "1", "n": 2}`, out)
+
+	terminal = true
+	assert.Contains(t, print(), "\x1b[", "colors on a terminal")
+
+	require.NoError(t, f.SetColorMode(ColorNever))
+	assert.NotContains(t, print(), "\x1b")
+
+	terminal = false
+	require.NoError(t, f.SetColorMode(ColorAlways))
+	assert.Contains(t, print(), "\x1b[", "--force-color colors a pipe")
+
+	require.NoError(t, f.SetColorMode(ColorAuto))
+	color.NoColor = true
+	terminal = true
+	assert.NotContains(t, print(), "\x1b", "NO_COLOR wins on a terminal")
+
+	assert.Error(t, f.SetColorMode("sometimes"))
+}
+
+func TestStripANSI(t *testing.T) {
+	var buf bytes.Buffer
+	w := StripANSI(&buf)
+
+	for _, chunk := range []string{"\x1b[1;3", "4m\"id\"\x1b", "[0m: ", "\x1b", "(B1\n"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "\"id\": 1\n", buf.String(), "sequences split across writes are removed")
+}
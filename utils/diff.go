@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/fatih/color"
+)
+
+// Kinds of JSONDiff differences.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// How JSONDiff pairs up the elements of two arrays.
+const (
+	// ArraysByIndex compares the elements at the same position.
+	ArraysByIndex = "index"
+	// ArraysByKey pairs objects with the same value of DiffOptions.ArrayKey,
+	// whatever their position.
+	ArraysByKey = "key"
+	// ArraysUnordered pairs equal elements whatever their position, so only
+	// elements missing from one side are reported.
+	ArraysUnordered = "unordered"
+)
+
+// DefaultArrayKey is the field ArraysByKey matches objects on when
+// DiffOptions.ArrayKey is empty.
+const DefaultArrayKey = "id"
+
+// DiffOptions tunes JSONDiff. The zero value compares arrays by index and
+// ignores nothing.
+type DiffOptions struct {
+	// Ignore holds object keys to skip at any depth, for volatile fields
+	// such as timestamps.
+	Ignore map[string]bool
+	// Arrays is ArraysByIndex, ArraysByKey or ArraysUnordered; empty means
+	// ArraysByIndex.
+	Arrays string
+	// ArrayKey is the field ArraysByKey matches on; empty means
+	// DefaultArrayKey.
+	ArrayKey string
+}
+
+// Difference is one difference between two JSON documents. Path locates it
+// in jq style (".data[0].id"); Old and New hold the value on each side, and
+// only the side that has one is set.
+type Difference struct {
+	Path string          `json:"path"`
+	Kind string          `json:"kind"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// DecodeJSON decodes data for JSONDiff, keeping numbers as json.Number so
+// they compare exactly.
+func DecodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// JSONDiff compares two decoded JSON values (see DecodeJSON) and returns their
+// differences, walking object keys in sorted order so the result does not
+// depend on key order. opts.Arrays picks how array elements are paired up.
+func JSONDiff(a, b any, opts DiffOptions) []Difference {
+	var diffs []Difference
+	diffValue(".", a, b, opts, &diffs)
+	return diffs
+}
+
+func diffValue(path string, a, b any, opts DiffOptions, diffs *[]Difference) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			diffObject(path, av, bv, opts, diffs)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			switch opts.Arrays {
+			case ArraysByKey:
+				if diffArrayByKey(path, av, bv, opts, diffs) {
+					return
+				}
+			case ArraysUnordered:
+				diffArrayUnordered(path, av, bv, opts, diffs)
+				return
+			}
+			diffArray(path, av, bv, opts, diffs)
+			return
+		}
+	default:
+		if _, isObj := b.(map[string]any); !isObj {
+			if _, isArr := b.([]any); !isArr && a == b {
+				return
+			}
+		}
+	}
+	*diffs = append(*diffs, Difference{Path: path, Kind: DiffChanged, Old: marshalRaw(a), New: marshalRaw(b)})
+}
+
+func diffObject(path string, a, b map[string]any, opts DiffOptions, diffs *[]Difference) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if opts.Ignore[k] {
+			continue
+		}
+		child := joinPath(path, keyPath(k))
+		av, inA := a[k]
+		bv, inB := b[k]
+		switch {
+		case !inB:
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffRemoved, Old: marshalRaw(av)})
+		case !inA:
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffAdded, New: marshalRaw(bv)})
+		default:
+			diffValue(child, av, bv, opts, diffs)
+		}
+	}
+}
+
+func diffArray(path string, a, b []any, opts DiffOptions, diffs *[]Difference) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		child := joinPath(path, "["+strconv.Itoa(i)+"]")
+		switch {
+		case i >= len(b):
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffRemoved, Old: marshalRaw(a[i])})
+		case i >= len(a):
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffAdded, New: marshalRaw(b[i])})
+		default:
+			diffValue(child, a[i], b[i], opts, diffs)
+		}
+	}
+}
+
+// diffArrayByKey pairs the objects of a and b on their opts.ArrayKey field,
+// reporting each pair's differences under a path such as .data[id="1"], and
+// the objects found on one side only as removed or added. Order does not
+// matter. It returns false, having reported nothing, when an element is not
+// an object with a string or number key or a key repeats, so that the
+// caller falls back to comparing by index.
+func diffArrayByKey(path string, a, b []any, opts DiffOptions, diffs *[]Difference) bool {
+	key := opts.ArrayKey
+	if key == "" {
+		key = DefaultArrayKey
+	}
+	aKeys, ok := arrayKeys(a, key)
+	if !ok {
+		return false
+	}
+	bKeys, ok := arrayKeys(b, key)
+	if !ok {
+		return false
+	}
+	bIndex := make(map[string]int, len(b))
+	for j, k := range bKeys {
+		bIndex[k] = j
+	}
+
+	aSeen := make(map[string]bool, len(a))
+	for i, k := range aKeys {
+		aSeen[k] = true
+		child := joinPath(path, "["+key+"="+k+"]")
+		if j, ok := bIndex[k]; ok {
+			diffValue(child, a[i], b[j], opts, diffs)
+		} else {
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffRemoved, Old: marshalRaw(a[i])})
+		}
+	}
+	for j, k := range bKeys {
+		if !aSeen[k] {
+			child := joinPath(path, "["+key+"="+k+"]")
+			*diffs = append(*diffs, Difference{Path: child, Kind: DiffAdded, New: marshalRaw(b[j])})
+		}
+	}
+	return true
+}
+
+// arrayKeys returns the key field of each element of arr, as it appears in
+// a path: quoted for strings, bare for numbers. ok is false unless every
+// element has a distinct one.
+func arrayKeys(arr []any, key string) (keys []string, ok bool) {
+	seen := make(map[string]bool, len(arr))
+	for _, el := range arr {
+		obj, isObj := el.(map[string]any)
+		if !isObj {
+			return nil, false
+		}
+		var k string
+		switch v := obj[key].(type) {
+		case string:
+			k = strconv.Quote(v)
+		case json.Number:
+			k = v.String()
+		default:
+			return nil, false
+		}
+		if seen[k] {
+			return nil, false
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys, true
+}
+
+// diffArrayUnordered pairs each element of a with an equal element of b
+// (ignored fields aside), whatever their positions, and reports the
+// elements left over on either side as removed or added at their own index.
+// Duplicates count: [1,1] and [1] differ by one removed 1.
+func diffArrayUnordered(path string, a, b []any, opts DiffOptions, diffs *[]Difference) {
+	matched := make([]bool, len(b))
+	var removed []int
+	for i := range a {
+		found := false
+		for j := range b {
+			if !matched[j] && len(JSONDiff(a[i], b[j], DiffOptions{Ignore: opts.Ignore, Arrays: ArraysUnordered})) == 0 {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, i)
+		}
+	}
+	for _, i := range removed {
+		*diffs = append(*diffs, Difference{Path: joinPath(path, "["+strconv.Itoa(i)+"]"), Kind: DiffRemoved, Old: marshalRaw(a[i])})
+	}
+	for j := range b {
+		if !matched[j] {
+			*diffs = append(*diffs, Difference{Path: joinPath(path, "["+strconv.Itoa(j)+"]"), Kind: DiffAdded, New: marshalRaw(b[j])})
+		}
+	}
+}
+
+// Colors of PrintDifferences.
+var (
+	diffAddedColor   = color.New(color.FgGreen)
+	diffRemovedColor = color.New(color.FgRed)
+	diffChangedColor = color.New(color.FgYellow)
+)
+
+// PrintDifferences writes diffs to w for reading rather than parsing, one
+// line each: "+ path: new" in green for added values, "- path: old" in red
+// for removed ones and "~ path: old -> new" in yellow for changed ones.
+func PrintDifferences(w io.Writer, diffs []Difference) {
+	for _, d := range diffs {
+		switch d.Kind {
+		case DiffAdded:
+			diffAddedColor.Fprintf(w, "+ %s: %s\n", d.Path, d.New)
+		case DiffRemoved:
+			diffRemovedColor.Fprintf(w, "- %s: %s\n", d.Path, d.Old)
+		default:
+			diffChangedColor.Fprintf(w, "~ %s: %s -> %s\n", d.Path, d.Old, d.New)
+		}
+	}
+}
+
+// keyPath renders an object key as a path segment, quoting keys that are not
+// plain identifiers.
+func keyPath(k string) string {
+	for i, r := range k {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return "[" + strconv.Quote(k) + "]"
+		}
+	}
+	if k == "" {
+		return `[""]`
+	}
+	return "." + k
+}
+
+func joinPath(path, segment string) string {
+	if path == "." {
+		if segment[0] == '.' {
+			return segment
+		}
+		return "." + segment
+	}
+	return path + segment
+}
+
+func marshalRaw(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	v, err := DecodeJSON([]byte(s))
+	require.NoError(t, err)
+	return v
+}
+
+func TestJSONDiff(t *testing.T) {
+	a := decode(t, `{"data":{"id":"1","text":"old","gone":true,"tags":["a","b"]},"meta":{"count":10000000000000001}}`)
+	b := decode(t, `{"meta":{"count":10000000000000002},"data":{"tags":["a"],"text":"new","id":"1","lang":"en","edit":null}}`)
+
+	diffs := JSONDiff(a, b, DiffOptions{})
+	got := map[string]Difference{}
+	var paths []string
+	for _, d := range diffs {
+		got[d.Path] = d
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{".data.edit", ".data.gone", ".data.lang", ".data.tags[1]", ".data.text", ".meta.count"}, paths, "paths are reported in sorted order")
+
+	assert.Equal(t, Difference{Path: ".data.lang", Kind: DiffAdded, New: []byte(`"en"`)}, got[".data.lang"])
+	assert.Equal(t, Difference{Path: ".data.edit", Kind: DiffAdded, New: []byte(`null`)}, got[".data.edit"])
+	assert.Equal(t, Difference{Path: ".data.gone", Kind: DiffRemoved, Old: []byte(`true`)}, got[".data.gone"])
+	assert.Equal(t, Difference{Path: ".data.tags[1]", Kind: DiffRemoved, Old: []byte(`"b"`)}, got[".data.tags[1]"])
+	assert.Equal(t, Difference{Path: ".data.text", Kind: DiffChanged, Old: []byte(`"old"`), New: []byte(`"new"`)}, got[".data.text"])
+	assert.Equal(t, DiffChanged, got[".meta.count"].Kind, "large numbers compare exactly")
+}
+
+func TestJSONDiffIgnoresFields(t *testing.T) {
+	a := decode(t, `{"data":[{"id":"1","created_at":"2020"}],"meta":{"created_at":"x"}}`)
+	b := decode(t, `{"data":[{"id":"1","created_at":"2021"}],"meta":{}}`)
+
+	assert.Empty(t, JSONDiff(a, b, DiffOptions{Ignore: map[string]bool{"created_at": true}}), "ignored fields are skipped at any depth")
+	assert.Len(t, JSONDiff(a, b, DiffOptions{}), 2)
+}
+
+func TestJSONDiffTypeChangeAndPaths(t *testing.T) {
+	diffs := JSONDiff(decode(t, `{"a-b":{"x":1},"n":[1]}`), decode(t, `{"a-b":[1],"n":[1]}`), DiffOptions{})
+	require.Len(t, diffs, 1)
+	assert.Equal(t, `.["a-b"]`, diffs[0].Path)
+	assert.Equal(t, DiffChanged, diffs[0].Kind)
+
+	assert.Empty(t, JSONDiff(decode(t, `[1,{"a":2}]`), decode(t, `[1,{"a":2}]`), DiffOptions{}))
+	assert.Equal(t, ".[1].a", JSONDiff(decode(t, `[1,{"a":2}]`), decode(t, `[1,{"a":3}]`), DiffOptions{})[0].Path)
+
+	_, err := DecodeJSON([]byte(`{} {}`))
+	assert.Error(t, err)
+}
+
+func diffPaths(diffs []Difference) []string {
+	var paths []string
+	for _, d := range diffs {
+		paths = append(paths, d.Kind+" "+d.Path)
+	}
+	return paths
+}
+
+func TestJSONDiffArraysByIndex(t *testing.T) {
+	a := decode(t, `{"data":[{"id":"1","n":1},{"id":"2","n":2}]}`)
+	b := decode(t, `{"data":[{"id":"0","n":0},{"id":"1","n":1},{"id":"2","n":2}]}`)
+
+	// An element inserted at the front shifts every position.
+	assert.Equal(t, []string{
+		"changed .data[0].id", "changed .data[0].n",
+		"changed .data[1].id", "changed .data[1].n",
+		"added .data[2]",
+	}, diffPaths(JSONDiff(a, b, DiffOptions{Arrays: ArraysByIndex})))
+	assert.Equal(t, JSONDiff(a, b, DiffOptions{Arrays: ArraysByIndex}), JSONDiff(a, b, DiffOptions{}), "index is the default")
+}
+
+func TestJSONDiffArraysByKey(t *testing.T) {
+	a := decode(t, `{"data":[{"id":"1","text":"a"},{"id":"2","text":"b"},{"id":"3","text":"c"}]}`)
+	b := decode(t, `{"data":[{"id":"4","text":"d"},{"id":"2","text":"B"},{"id":"1","text":"a"}]}`)
+
+	diffs := JSONDiff(a, b, DiffOptions{Arrays: ArraysByKey})
+	assert.Equal(t, []string{
+		`changed .data[id="2"].text`,
+		`removed .data[id="3"]`,
+		`added .data[id="4"]`,
+	}, diffPaths(diffs), "elements are paired on id, whatever their order")
+	assert.JSONEq(t, `{"id":"3","text":"c"}`, string(diffs[1].Old))
+	assert.JSONEq(t, `{"id":"4","text":"d"}`, string(diffs[2].New))
+
+	t.Run("custom key and numbers", func(t *testing.T) {
+		a := decode(t, `[{"n":1,"v":"x"},{"n":2,"v":"y"}]`)
+		b := decode(t, `[{"n":2,"v":"z"},{"n":1,"v":"x"}]`)
+		assert.Equal(t, []string{`changed .[n=2].v`}, diffPaths(JSONDiff(a, b, DiffOptions{Arrays: ArraysByKey, ArrayKey: "n"})))
+	})
+
+	t.Run("falls back to index", func(t *testing.T) {
+		for name, pair := range map[string][2]string{
+			"scalars":       {`["a","b"]`, `["b","a"]`},
+			"missing key":   {`[{"id":"1"},{"x":1}]`, `[{"x":1},{"id":"1"}]`},
+			"duplicate key": {`[{"id":"1","v":1},{"id":"1","v":2}]`, `[{"id":"1","v":2},{"id":"1","v":1}]`},
+			"object key":    {`[{"id":{"k":1}}]`, `[{"id":{"k":2}}]`},
+		} {
+			a, b := decode(t, pair[0]), decode(t, pair[1])
+			assert.Equal(t, JSONDiff(a, b, DiffOptions{}), JSONDiff(a, b, DiffOptions{Arrays: ArraysByKey}), name)
+		}
+	})
+
+	t.Run("nested arrays", func(t *testing.T) {
+		a := decode(t, `{"users":[{"id":"1","pinned":[{"id":"10"},{"id":"11"}]}]}`)
+		b := decode(t, `{"users":[{"id":"1","pinned":[{"id":"11"}]}]}`)
+		assert.Equal(t, []string{`removed .users[id="1"].pinned[id="10"]`}, diffPaths(JSONDiff(a, b, DiffOptions{Arrays: ArraysByKey})))
+	})
+}
+
+func TestJSONDiffArraysUnordered(t *testing.T) {
+	a := decode(t, `{"tags":["go","cli","api"],"n":[1,1,2]}`)
+	b := decode(t, `{"tags":["api","go","x"],"n":[2,1]}`)
+
+	diffs := JSONDiff(a, b, DiffOptions{Arrays: ArraysUnordered})
+	assert.Equal(t, []string{"removed .n[1]", "removed .tags[1]", "added .tags[2]"}, diffPaths(diffs), "duplicates count; moves are not differences")
+	assert.Equal(t, `"cli"`, string(diffs[1].Old))
+	assert.Equal(t, `"x"`, string(diffs[2].New))
+
+	t.Run("objects compare whole, with ignored fields", func(t *testing.T) {
+		a := decode(t, `[{"id":"1","seen":"mon"},{"id":"2","seen":"mon"}]`)
+		b := decode(t, `[{"id":"2","seen":"tue"},{"id":"1","seen":"tue"}]`)
+		assert.Len(t, JSONDiff(a, b, DiffOptions{Arrays: ArraysUnordered}), 4)
+		assert.Empty(t, JSONDiff(a, b, DiffOptions{Arrays: ArraysUnordered, Ignore: map[string]bool{"seen": true}}))
+	})
+
+	t.Run("nested arrays are unordered too", func(t *testing.T) {
+		assert.Empty(t, JSONDiff(decode(t, `[[1,2],[3]]`), decode(t, `[[3],[2,1]]`), DiffOptions{Arrays: ArraysUnordered}))
+	})
+}
+
+func TestJSONDiffNormalizes(t *testing.T) {
+	// Key order and whitespace do not matter. Numbers compare by their text,
+	// so 1 and 1.0 differ.
+	a := decode(t, `{"b": 1, "a": {"y": [1, 2], "x": "s"}}`)
+	b := decode(t, "{\"a\":{\"x\":\"s\",\"y\":[1,2]},\"b\":1}")
+	assert.Empty(t, JSONDiff(a, b, DiffOptions{}))
+	assert.Len(t, JSONDiff(decode(t, `{"n":1}`), decode(t, `{"n":1.0}`), DiffOptions{}), 1)
+}
+
+func TestPrintDifferences(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	diffs := []Difference{
+		{Path: ".a", Kind: DiffAdded, New: []byte(`1`)},
+		{Path: ".b", Kind: DiffRemoved, Old: []byte(`"x"`)},
+		{Path: ".c", Kind: DiffChanged, Old: []byte(`true`), New: []byte(`false`)},
+	}
+
+	color.NoColor = true
+	var buf bytes.Buffer
+	PrintDifferences(&buf, diffs)
+	assert.Equal(t, "+ .a: 1\n- .b: \"x\"\n~ .c: true -> false\n", buf.String())
+
+	color.NoColor = false
+	buf.Reset()
+	PrintDifferences(&buf, diffs)
+	assert.Contains(t, buf.String(), "\x1b[32m+ .a: 1")
+	assert.Contains(t, buf.String(), "\x1b[31m- .b")
+	assert.Contains(t, buf.String(), "\x1b[33m~ .c")
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// SetJQ makes the formatter run the jq expression expr over each response and
+// print its outputs instead of the response. An empty expr turns it off. It
+// returns an error if expr does not parse.
+func (f *Formatter) SetJQ(expr string) error {
+	if expr == "" {
+		f.jq = nil
+		return nil
+	}
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid --jq expression: %v", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return fmt.Errorf("invalid --jq expression: %v", err)
+	}
+	f.jq = code
+	return nil
+}
+
+// RunJQ runs a compiled jq expression over the JSON value data and returns
+// each of its outputs as JSON, like jq does for one input.
+func RunJQ(code *gojq.Code, data []byte) ([]json.RawMessage, error) {
+	var input any
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("jq: input is not JSON: %v", err)
+	}
+	var outputs []json.RawMessage
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return outputs, nil
+		}
+		if err, ok := v.(error); ok {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				return outputs, nil
+			}
+			return outputs, fmt.Errorf("jq: %v", err)
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return outputs, fmt.Errorf("jq: %v", err)
+		}
+		outputs = append(outputs, out)
+	}
+}
+
+// JQLine applies the formatter's --jq expression to a single line of JSON,
+// such as one streamed message, returning its outputs as compact lines. Lines
+// that are not JSON are returned unchanged.
+func (f *Formatter) JQLine(line string) ([]string, error) {
+	if f.jq == nil || !json.Valid([]byte(line)) {
+		return []string{line}, nil
+	}
+	outputs, err := RunJQ(f.jq, []byte(line))
+	lines := make([]string, len(outputs))
+	for i, out := range outputs {
+		lines[i] = string(out)
+	}
+	return lines, err
+}
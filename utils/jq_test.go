@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/itchyny/gojq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jqFixture = `{
+	"data": [
+		{"id": "1", "text": "hello", "author_id": "10", "public_metrics": {"like_count": 3}},
+		{"id": "2", "text": "world", "author_id": "20", "public_metrics": {"like_count": 12}}
+	],
+	"includes": {"users": [{"id": "10", "username": "alice"}, {"id": "20", "username": "bob"}]},
+	"meta": {"result_count": 2}
+}`
+
+func compileJQ(t *testing.T, expr string) *gojq.Code {
+	t.Helper()
+	f := NewFormatter()
+	require.NoError(t, f.SetJQ(expr))
+	return f.jq
+}
+
+func TestRunJQ(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{"filter", ".meta.result_count", []string{`2`}},
+		{"iterate", ".data[].id", []string{`"1"`, `"2"`}},
+		{"map", "[.data[] | {id, likes: .public_metrics.like_count}]", []string{`[{"id":"1","likes":3},{"id":"2","likes":12}]`}},
+		{"select", `.data[] | select(.public_metrics.like_count > 10) | .text`, []string{`"world"`}},
+		{"join includes", `(.includes.users | map({(.id): .username}) | add) as $u | .data | map($u[.author_id])`, []string{`["alice","bob"]`}},
+		{"no output", "empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := RunJQ(compileJQ(t, tt.expr), []byte(jqFixture))
+			require.NoError(t, err)
+			var got []string
+			for _, out := range outputs {
+				got = append(got, string(out))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunJQErrors(t *testing.T) {
+	assert.ErrorContains(t, NewFormatter().SetJQ(".data["), "invalid --jq expression")
+
+	outputs, err := RunJQ(compileJQ(t, ".data[] | .id, error(\"stop\")"), []byte(jqFixture))
+	assert.ErrorContains(t, err, "stop")
+	assert.Len(t, outputs, 1, "outputs before the error are kept")
+}
+
+func TestFormatAndPrintResponseJQ(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor = prevOut, prevNoColor }()
+
+	f := NewFormatter()
+	require.NoError(t, f.SetJQ(`.data[] | {id, text}`))
+	require.NoError(t, f.Print(json.RawMessage(jqFixture)))
+	assert.Equal(t, "{\n  \"id\":\"1\",\n  \"text\":\"hello\"\n}\n{\n  \"id\":\"2\",\n  \"text\":\"world\"\n}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, f.SetOutputFormat(OutputNDJSON))
+	require.NoError(t, f.SetJQ(".id"))
+	require.NoError(t, f.Print(json.RawMessage(jqFixture)))
+	assert.Equal(t, "\"1\"\n\"2\"\n", buf.String(), "with ndjson the expression runs over each line")
+
+	buf.Reset()
+	f.SetRedactFields([]string{"text"})
+	require.NoError(t, f.SetOutputFormat(OutputJSON))
+	require.NoError(t, f.SetJQ(".data[0].text"))
+	require.NoError(t, f.Print(json.RawMessage(jqFixture)))
+	assert.Equal(t, "\"***\"\n", buf.String(), "--redact applies before --jq")
+
+	var errBuf bytes.Buffer
+	require.NoError(t, f.PrintTo(&errBuf, json.RawMessage(`{"title":"Not Found"}`)))
+	assert.True(t, strings.Contains(errBuf.String(), "Not Found"), "error bodies are not filtered")
+}
+
+func TestJQLine(t *testing.T) {
+	f := NewFormatter()
+	lines, err := f.JQLine(`{"data":{"id":"1"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"data":{"id":"1"}}`}, lines, "unchanged without --jq")
+
+	require.NoError(t, f.SetJQ(".data.id"))
+	lines, err = f.JQLine(`{"data":{"id":"1"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"1"`}, lines)
+
+	lines, err = f.JQLine("not json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"not json"}, lines)
+}
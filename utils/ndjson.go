@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"github.com/itchyny/gojq"
+)
+
+// Output formats accepted by Formatter.SetOutputFormat.
+const (
+	OutputJSON   = "json"
+	OutputNDJSON = "ndjson"
+	OutputJSONL  = "jsonl"
+)
+
+// SetOutputFormat selects the formatter's output: "json" (pretty-printed, the
+// default), "ndjson" (one compact JSON value per line) or "jsonl" (NDJSON
+// that is also synced to disk when capturing a stream; see IsJSONL).
+func (f *Formatter) SetOutputFormat(format string) error {
+	switch format {
+	case "", OutputJSON:
+		f.outputFormat = OutputJSON
+	case OutputNDJSON, OutputJSONL:
+		f.outputFormat = format
+	default:
+		return fmt.Errorf("unknown output format %q (want %s, %s or %s)", format, OutputJSON, OutputNDJSON, OutputJSONL)
+	}
+	return nil
+}
+
+// IsNDJSON reports whether the formatter prints NDJSON, so callers that page
+// through results can stream items to PrintNDJSONItems instead of merging
+// the pages first.
+func (f *Formatter) IsNDJSON() bool {
+	return f.outputFormat == OutputNDJSON || f.outputFormat == OutputJSONL
+}
+
+// IsJSONL reports whether the formatter prints jsonl, in which streams are
+// written through a SyncWriter (see SetSyncPolicy) when standard output is a
+// file.
+func (f *Formatter) IsJSONL() bool {
+	return f.outputFormat == OutputJSONL
+}
+
+// PrintNDJSONItems prints every value received from items on its own line as
+// soon as it arrives, until items is closed. It keeps draining items after a
+// bad value so the sender never blocks, and returns the first error.
+func (f *Formatter) PrintNDJSONItems(items <-chan json.RawMessage) error {
+	var firstErr error
+	for item := range items {
+		if err := f.printNDJSONLine(color.Output, item, f.capturing, f.jq); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteNDJSONItems writes items to w as NDJSON lines, redacted and run
+// through --jq like PrintNDJSONItems. Lines written to standard output are
+// captured for --copy.
+func (f *Formatter) WriteNDJSONItems(w io.Writer, items []json.RawMessage) error {
+	for _, item := range items {
+		if err := f.printNDJSONLine(w, item, f.capturing && w == color.Output, f.jq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printNDJSON writes response to w as NDJSON: each element of a top-level
+// "data" array on its own line, or else the whole response as one line. jq,
+// if set, is run over each line.
+func (f *Formatter) printNDJSON(w io.Writer, response any, capture bool, jq *gojq.Code) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	var list struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(raw, &list) == nil && list.Data != nil {
+		for _, item := range list.Data {
+			if err := f.printNDJSONLine(w, item, capture, jq); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.printNDJSONLine(w, raw, capture, jq)
+}
+
+// printNDJSONLine writes one value, compacted and redacted, as a line, or
+// with jq each output of jq run over the value.
+func (f *Formatter) printNDJSONLine(w io.Writer, item json.RawMessage, capture bool, jq *gojq.Code) error {
+	if len(f.redactFields) > 0 {
+		redacted, err := RedactJSON(item, f.redactFields)
+		if err != nil {
+			return fmt.Errorf("error redacting JSON: %v", err)
+		}
+		item = redacted
+	}
+	if jq != nil {
+		outputs, err := RunJQ(jq, item)
+		for _, out := range outputs {
+			if werr := f.writeNDJSONLine(w, out, capture); werr != nil {
+				return werr
+			}
+		}
+		return err
+	}
+	return f.writeNDJSONLine(w, item, capture)
+}
+
+func (f *Formatter) writeNDJSONLine(w io.Writer, item json.RawMessage, capture bool) error {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, item); err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	buf.WriteByte('\n')
+	if capture {
+		f.lastOutput = append(f.lastOutput, buf.Bytes()...)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
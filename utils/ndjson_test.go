@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAndPrintResponseNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor = prevOut, prevNoColor }()
+
+	f := NewFormatter()
+	require.NoError(t, f.SetOutputFormat(OutputNDJSON))
+	assert.True(t, f.IsNDJSON())
+
+	require.NoError(t, f.Print(json.RawMessage(`{"data":[{"id":"1"},{"id":"2"}],"meta":{"result_count":2}}`)))
+	assert.Equal(t, "{\"id\":\"1\"}\n{\"id\":\"2\"}\n", buf.String(), "each data element is its own line")
+
+	buf.Reset()
+	require.NoError(t, f.Print(json.RawMessage(`{"data": {"id": "1"}}`)))
+	assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n", buf.String(), "a non-list response is one compact line")
+}
+
+func TestSetOutputFormat(t *testing.T) {
+	f := NewFormatter()
+	assert.Error(t, f.SetOutputFormat("yaml"))
+	require.NoError(t, f.SetOutputFormat(""))
+	assert.False(t, f.IsNDJSON())
+
+	require.NoError(t, f.SetOutputFormat(OutputJSONL))
+	assert.True(t, f.IsNDJSON(), "jsonl prints like ndjson")
+	assert.True(t, f.IsJSONL())
+}
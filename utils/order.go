@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SetPreserveOrder switches the formatter between re-marshaling (the default)
+// and the order-preserving variant used by --preserve-order, which indents
+// the response's own bytes so object keys keep the order the server sent.
+func (f *Formatter) SetPreserveOrder(on bool) {
+	f.preserveOrder = on
+}
+
+// IndentOrdered pretty-prints data by streaming its tokens, so object keys
+// stay in their original order and numbers keep their original text. Strings
+// are written without HTML escaping.
+func IndentOrdered(data []byte, indent string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := indentValue(dec, &buf, indent, 0); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return buf.Bytes(), nil
+}
+
+func indentValue(dec *json.Decoder, buf *bytes.Buffer, indent string, depth int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		open, close := byte('{'), byte('}')
+		if t == '[' {
+			open, close = '[', ']'
+		}
+		buf.WriteByte(open)
+		n := 0
+		for ; dec.More(); n++ {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(indent, depth+1))
+			if t == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				writeUnescapedJSON(buf, key)
+				buf.WriteString(": ")
+			}
+			if err := indentValue(dec, buf, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		if n > 0 {
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(indent, depth))
+		}
+		buf.WriteByte(close)
+		// Consume the closing delimiter
+		_, err := dec.Token()
+		return err
+	case json.Number:
+		buf.WriteString(t.String())
+	default:
+		writeUnescapedJSON(buf, t)
+	}
+	return nil
+}
+
+// writeUnescapedJSON writes v as JSON without escaping <, > and &.
+func writeUnescapedJSON(buf *bytes.Buffer, v any) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
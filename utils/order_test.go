@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndentOrdered(t *testing.T) {
+	input := `{"zeta":1,"alpha":{"y":"<b>","x":12345678901234567890},"mid":[],"empty":{},"list":[true,null,1.50]}`
+
+	out, err := IndentOrdered([]byte(input), "  ")
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "zeta": 1,
+  "alpha": {
+    "y": "<b>",
+    "x": 12345678901234567890
+  },
+  "mid": [],
+  "empty": {},
+  "list": [
+    true,
+    null,
+    1.50
+  ]
+}`, string(out), "keys keep their order and numbers their text")
+
+	_, err = IndentOrdered([]byte(`{"a":1} {}`), "  ")
+	assert.Error(t, err)
+}
+
+func TestFormatAndPrintResponsePreserveOrder(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor = prevOut, prevNoColor }()
+
+	f := NewFormatter()
+	f.SetPreserveOrder(true)
+
+	require.NoError(t, f.Print(json.RawMessage(`{"data":{"text":"hi","id":"1","author_id":"2"}}`)))
+	out := buf.String()
+	text, id, author := bytes.Index(buf.Bytes(), []byte(`"text"`)), bytes.Index(buf.Bytes(), []byte(`"id"`)), bytes.Index(buf.Bytes(), []byte(`"author_id"`))
+	assert.True(t, text < id && id < author, "key order must match the response: %s", out)
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RedactedValue replaces the value of every redacted field.
+const RedactedValue = "***"
+
+// RedactPresetPII is the --redact preset name for common personal data.
+const RedactPresetPII = "pii"
+
+// piiFields are the fields masked by the "pii" preset.
+var piiFields = []string{"email", "phone", "phone_number", "username", "name", "location"}
+
+// ParseRedactFields turns a --redact value ("email,phone" or a preset such as
+// "pii", which may be mixed with field names) into a list of field names.
+func ParseRedactFields(spec string) []string {
+	var fields []string
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.TrimSpace(f)
+		switch {
+		case f == "":
+		case strings.EqualFold(f, RedactPresetPII):
+			fields = append(fields, piiFields...)
+		default:
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// SetRedactFields makes the formatter mask the values of the given fields,
+// at any depth, before printing. Passing no fields turns redaction off.
+func (f *Formatter) SetRedactFields(fields []string) {
+	if len(fields) == 0 {
+		f.redactFields = nil
+		return
+	}
+	f.redactFields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		f.redactFields[field] = true
+	}
+}
+
+// RedactJSON returns data with the value of every object key in fields
+// replaced by "***", at any depth. Key order and all other values are
+// preserved.
+func RedactJSON(data []byte, fields map[string]bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := redactValue(dec, &buf, fields); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return buf.Bytes(), nil
+}
+
+// RedactLine applies the formatter's redaction to a single line of JSON, such
+// as one streamed message. Lines that are not JSON are returned unchanged.
+func (f *Formatter) RedactLine(line string) string {
+	if len(f.redactFields) == 0 {
+		return line
+	}
+	redacted, err := RedactJSON([]byte(line), f.redactFields)
+	if err != nil {
+		return line
+	}
+	return string(redacted)
+}
+
+func redactValue(dec *json.Decoder, buf *bytes.Buffer, fields map[string]bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			buf.WriteByte('{')
+			for i := 0; dec.More(); i++ {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeJSON(buf, key)
+				buf.WriteByte(':')
+				if fields[key] {
+					if err := skipValue(dec); err != nil {
+						return err
+					}
+					writeJSON(buf, RedactedValue)
+					continue
+				}
+				if err := redactValue(dec, buf, fields); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte('}')
+		case '[':
+			buf.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err := redactValue(dec, buf, fields); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte(']')
+		}
+		// Consume the closing delimiter
+		_, err := dec.Token()
+		return err
+	case json.Number:
+		buf.WriteString(t.String())
+	default:
+		writeJSON(buf, t)
+	}
+	return nil
+}
+
+// skipValue consumes one complete value (scalar, object or array).
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, v any) {
+	b, _ := json.Marshal(v)
+	buf.Write(b)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRedactFields(t *testing.T) {
+	assert.Equal(t, []string{"email", "phone"}, ParseRedactFields("email, phone"))
+	assert.Equal(t, append(append([]string{}, piiFields...), "id"), ParseRedactFields("PII,id"))
+	assert.Empty(t, ParseRedactFields(""))
+}
+
+func TestRedactJSON(t *testing.T) {
+	input := `{"data":{"id":"123","username":"alice","email":"a@example.com","profile":{"name":"Alice","followers":42}},` +
+		`"includes":[{"username":"bob","verified":true},{"email":null}]}`
+
+	out, err := RedactJSON([]byte(input), map[string]bool{"username": true, "email": true, "profile": true})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"data":{"id":"123","username":"***","email":"***","profile":"***"},`+
+		`"includes":[{"username":"***","verified":true},{"email":"***"}]}`, string(out))
+
+	t.Run("unmatched fields are untouched", func(t *testing.T) {
+		out, err := RedactJSON([]byte(input), map[string]bool{"phone": true})
+		require.NoError(t, err)
+		assert.JSONEq(t, input, string(out))
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		_, err := RedactJSON([]byte(`{"email":`), map[string]bool{"email": true})
+		assert.Error(t, err)
+	})
+}
+
+func TestFormatAndPrintResponseRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor = prevOut, prevNoColor }()
+
+	f := NewFormatter()
+	f.SetRedactFields(ParseRedactFields("email"))
+
+	require.NoError(t, f.Print(json.RawMessage(`{"data":{"email":"a@example.com","id":"1"}}`)))
+	assert.Contains(t, buf.String(), `"***"`)
+	assert.NotContains(t, buf.String(), "a@example.com")
+
+	assert.Equal(t, `{"email":"***"}`, f.RedactLine(`{"email":"x"}`))
+	assert.Equal(t, "not json", f.RedactLine("not json"))
+}
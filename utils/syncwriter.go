@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy says how often a SyncWriter flushes its buffer and syncs the
+// file to disk: after every Records records, every Interval, or both. The
+// zero value only syncs on Close.
+type SyncPolicy struct {
+	Records  int
+	Interval time.Duration
+}
+
+// DefaultSyncPolicy syncs a jsonl stream capture once a second.
+var DefaultSyncPolicy = SyncPolicy{Interval: time.Second}
+
+// SetSyncPolicy sets how often stream captures in jsonl format are synced
+// (--sync-interval).
+func (f *Formatter) SetSyncPolicy(p SyncPolicy) {
+	f.syncPolicy = p
+}
+
+// SyncPolicy returns the policy set with SetSyncPolicy.
+func (f *Formatter) SyncPolicy() SyncPolicy {
+	return f.syncPolicy
+}
+
+// ParseSyncPolicy parses a --sync-interval value: a record count ("100"), a
+// duration ("5s"), or both separated by a comma ("100,5s").
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	var p SyncPolicy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if n, err := strconv.Atoi(part); err == nil {
+			if n <= 0 || p.Records != 0 {
+				return SyncPolicy{}, fmt.Errorf("invalid sync interval %q: want N records, a duration, or both (e.g. 100,5s)", s)
+			}
+			p.Records = n
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 || p.Interval != 0 {
+			return SyncPolicy{}, fmt.Errorf("invalid sync interval %q: want N records, a duration, or both (e.g. 100,5s)", s)
+		}
+		p.Interval = d
+	}
+	return p, nil
+}
+
+// Syncer is a writer that can commit what was written to stable storage,
+// such as an *os.File.
+type Syncer interface {
+	io.Writer
+	Sync() error
+}
+
+// SyncWriter writes records (lines) to a file through a buffer, flushing and
+// syncing it as its SyncPolicy says, so that a crash during a long capture
+// loses at most the records since the last sync. It is safe for use by one
+// writer while its timer syncs in the background.
+type SyncWriter struct {
+	mu      sync.Mutex
+	buf     *bufio.Writer
+	file    Syncer
+	policy  SyncPolicy
+	pending int   // records written since the last sync
+	err     error // the first error of a background sync
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// NewSyncWriter returns a SyncWriter for file. Call Close when done to sync
+// the remaining records and stop the timer.
+func NewSyncWriter(file Syncer, policy SyncPolicy) *SyncWriter {
+	w := &SyncWriter{buf: bufio.NewWriter(file), file: file, policy: policy}
+	if policy.Interval > 0 {
+		w.stop = make(chan struct{})
+		w.stopped = make(chan struct{})
+		go w.syncEvery(policy.Interval)
+	}
+	return w
+}
+
+func (w *SyncWriter) syncEvery(interval time.Duration) {
+	defer close(w.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.pending > 0 {
+				if err := w.syncLocked(); err != nil && w.err == nil {
+					w.err = err
+				}
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// WriteRecord writes line and a newline, syncing if the record count says
+// so. It returns an error of an earlier background sync.
+func (w *SyncWriter) WriteRecord(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := w.buf.Write(line); err != nil {
+		return err
+	}
+	if err := w.buf.WriteByte('\n'); err != nil {
+		return err
+	}
+	w.pending++
+	if w.policy.Records > 0 && w.pending >= w.policy.Records {
+		return w.syncLocked()
+	}
+	return nil
+}
+
+func (w *SyncWriter) syncLocked() error {
+	w.pending = 0
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close stops the timer and syncs any records written since the last sync.
+// It does not close the file, and may be called more than once.
+func (w *SyncWriter) Close() error {
+	w.once.Do(func() {
+		if w.stop != nil {
+			close(w.stop)
+			<-w.stopped
+		}
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.pending == 0 && w.buf.Buffered() == 0 {
+		return nil
+	}
+	return w.syncLocked()
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFile is a Syncer that records how many lines were on "disk" at
+// each sync.
+type countingFile struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	synced []int // lines written at each sync
+}
+
+func (f *countingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.Write(p)
+}
+
+func (f *countingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.synced = append(f.synced, strings.Count(f.buf.String(), "\n"))
+	return nil
+}
+
+func (f *countingFile) syncs() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.synced...)
+}
+
+func TestSyncWriterEveryNRecords(t *testing.T) {
+	f := &countingFile{}
+	w := NewSyncWriter(f, SyncPolicy{Records: 3})
+	for i := 0; i < 7; i++ {
+		require.NoError(t, w.WriteRecord([]byte(`{"id":"1"}`)))
+	}
+	assert.Equal(t, []int{3, 6}, f.syncs(), "synced after every third record")
+	assert.Equal(t, 6, strings.Count(f.buf.String(), "\n"), "the seventh record is still buffered")
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, []int{3, 6, 7}, f.syncs(), "Close syncs the rest")
+	require.NoError(t, w.Close())
+	assert.Len(t, f.syncs(), 3, "a second Close has nothing to sync")
+}
+
+func TestSyncWriterEveryInterval(t *testing.T) {
+	f := &countingFile{}
+	w := NewSyncWriter(f, SyncPolicy{Interval: 10 * time.Millisecond})
+	defer w.Close()
+
+	require.NoError(t, w.WriteRecord([]byte(`{"id":"1"}`)))
+	require.NoError(t, w.WriteRecord([]byte(`{"id":"2"}`)))
+	require.Eventually(t, func() bool { return len(f.syncs()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []int{2}, f.syncs())
+
+	// Without new records the timer does not sync again.
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, f.syncs(), 1)
+
+	require.NoError(t, w.WriteRecord([]byte(`{"id":"3"}`)))
+	require.Eventually(t, func() bool { return len(f.syncs()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "{\"id\":\"1\"}\n{\"id\":\"2\"}\n{\"id\":\"3\"}\n", f.buf.String())
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for in, want := range map[string]SyncPolicy{
+		"100":     {Records: 100},
+		"5s":      {Interval: 5 * time.Second},
+		"100,5s":  {Records: 100, Interval: 5 * time.Second},
+		"2m, 500": {Records: 500, Interval: 2 * time.Minute},
+	} {
+		got, err := ParseSyncPolicy(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "0", "-1s", "soon", "1,2", "1s,2s"} {
+		_, err := ParseSyncPolicy(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -3,9 +3,12 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/itchyny/gojq"
 )
 
 var keyColor = color.New(color.FgCyan, color.Bold)
@@ -15,8 +18,8 @@ var boolColor = color.New(color.FgMagenta)
 var nullColor = color.New(color.FgRed)
 var structureColor = color.New(color.FgWhite, color.Bold)
 
-// colorizeAndPrintJSON prints JSON with syntax highlighting
-func colorizeAndPrintJSON(jsonStr string) {
+// colorizeAndPrintJSON writes JSON to w with syntax highlighting
+func colorizeAndPrintJSON(w io.Writer, jsonStr string) {
 	lines := strings.Split(jsonStr, "\n")
 	for _, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
@@ -25,7 +28,7 @@ func colorizeAndPrintJSON(jsonStr string) {
 			trimmedLine == "[" || trimmedLine == "]" ||
 			trimmedLine == "," || trimmedLine == "}," ||
 			trimmedLine == "]," {
-			structureColor.Println(line)
+			structureColor.Fprintln(w, line)
 			continue
 		}
 
@@ -34,15 +37,15 @@ func colorizeAndPrintJSON(jsonStr string) {
 			key := parts[0]
 			value := strings.TrimSpace(parts[1])
 
-			keyColor.Print(key)
-			fmt.Print(":")
+			keyColor.Fprint(w, key)
+			fmt.Fprint(w, ":")
 
 			if strings.HasSuffix(value, "{") || strings.HasSuffix(value, "[") {
 				valueWithoutBracket := strings.TrimSuffix(strings.TrimSuffix(value, "{"), "[")
 				if valueWithoutBracket != "" {
-					fmt.Print(valueWithoutBracket)
+					fmt.Fprint(w, valueWithoutBracket)
 				}
-				structureColor.Println(value[len(valueWithoutBracket):])
+				structureColor.Fprintln(w, value[len(valueWithoutBracket):])
 				continue
 			}
 
@@ -59,46 +62,208 @@ func colorizeAndPrintJSON(jsonStr string) {
 					valueBeforeBracket := value[:lastBracketPos]
 					bracketPart := value[lastBracketPos:]
 
-					colorizeValue(valueBeforeBracket)
-					structureColor.Println(bracketPart)
+					colorizeValue(w, valueBeforeBracket)
+					structureColor.Fprintln(w, bracketPart)
 				} else {
-					structureColor.Println(value)
+					structureColor.Fprintln(w, value)
 				}
 				continue
 			}
 
-			colorizeValue(value)
+			colorizeValue(w, value)
 		} else {
-			colorizeValue(line)
+			colorizeValue(w, line)
 		}
 	}
 }
 
 // Helper function to colorize values based on their type
-func colorizeValue(value string) {
+func colorizeValue(w io.Writer, value string) {
 	trimmedValue := strings.TrimSpace(value)
 
 	switch {
 	case strings.HasPrefix(trimmedValue, "\"") && (strings.HasSuffix(trimmedValue, "\"") || strings.HasSuffix(trimmedValue, "\",")):
-		stringColor.Println(value)
+		stringColor.Fprintln(w, value)
 	case trimmedValue == "true" || trimmedValue == "false" ||
 		strings.HasSuffix(trimmedValue, "true,") || strings.HasSuffix(trimmedValue, "false,"):
-		boolColor.Println(value)
+		boolColor.Fprintln(w, value)
 	case trimmedValue == "null" || strings.HasSuffix(trimmedValue, "null,"):
-		nullColor.Println(value)
+		nullColor.Fprintln(w, value)
 	case strings.HasPrefix(trimmedValue, "{") || strings.HasPrefix(trimmedValue, "["):
-		structureColor.Println(value)
+		structureColor.Fprintln(w, value)
 	default:
-		numberColor.Println(value)
+		numberColor.Fprintln(w, value)
 	}
 }
 
+// stdoutIsTerminal reports whether stdout is a terminal; tests swap it out.
+var stdoutIsTerminal = func() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// noContentColor is the color of the success line printed for a response
+// without a body.
+var noContentColor = color.New(color.FgGreen, color.Bold)
+
+// NoContent is the response the api package returns for a successful
+// request whose reply has no body (api.NoContent). It is JSON null, so
+// unmarshalling it leaves the target untouched, but it is told apart from a
+// body that is a literal null by identity, not by its bytes: see
+// IsNoContent. Its capacity is its length, so appending to it never writes
+// into the shared array.
+var NoContent = json.RawMessage("null")[:4:4]
+
+// IsNoContent reports whether response is NoContent itself. A null that was
+// actually sent by the server is printed like any other JSON value.
+func IsNoContent(response any) bool {
+	raw, ok := response.(json.RawMessage)
+	return ok && len(raw) == len(NoContent) && &raw[0] == &NoContent[0]
+}
+
+// Formatter prints responses the way the global output flags ask: as pretty
+// JSON or NDJSON (SetOutputFormat), through a jq expression (SetJQ), with
+// fields masked (SetRedactFields), with keys in the server's order
+// (SetPreserveOrder) and colored per SetColorMode. It also holds how often
+// jsonl stream captures are synced (SetSyncPolicy) and, after StartCapture,
+// the last value printed, for --copy. Use NewFormatter to create one.
+type Formatter struct {
+	outputFormat  string
+	jq            *gojq.Code
+	redactFields  map[string]bool
+	preserveOrder bool
+	colorMode     string
+	syncPolicy    SyncPolicy
+	capturing     bool
+	lastOutput    []byte
+}
+
+// NewFormatter returns a Formatter that pretty-prints JSON, colored when it
+// is written to a terminal, and syncs jsonl captures per DefaultSyncPolicy.
+func NewFormatter() *Formatter {
+	return &Formatter{outputFormat: OutputJSON, colorMode: ColorAuto, syncPolicy: DefaultSyncPolicy}
+}
+
+// StartCapture makes the formatter remember the last value it prints, for
+// CapturedOutput.
+func (f *Formatter) StartCapture() {
+	f.capturing = true
+	f.lastOutput = nil
+}
+
+// CapturedOutput returns the last value printed since StartCapture: the
+// pretty-printed JSON, or just the text of a JSON string, so that a single id
+// lands on the clipboard without quotes. It is nil if nothing was printed.
+func (f *Formatter) CapturedOutput() []byte {
+	return f.lastOutput
+}
+
+// FormatAndPrintResponse pretty-prints response as JSON with the settings of
+// NewFormatter; see Formatter.Print.
 func FormatAndPrintResponse(response any) error {
-	prettyJSON, err := json.MarshalIndent(response, "", "  ")
+	return NewFormatter().Print(response)
+}
+
+// Print pretty-prints response as JSON, colorized when it is written to a
+// terminal (see SetColorMode), masking any fields configured with
+// SetRedactFields. With SetPreserveOrder a json.RawMessage is indented token
+// by token (see IndentOrdered) so its keys print in the order the server
+// sent them. With SetOutputFormat("ndjson") it prints NDJSON instead (see
+// IsNDJSON). With SetJQ it prints the outputs of the jq expression run over
+// response, or over each NDJSON line. NoContent, which is what the api
+// package returns for a successful response without a body, prints a
+// "No Content" success line when stdout is a terminal and pretty JSON is
+// printed, and nothing otherwise, so scripts can rely on the exit code alone.
+func (f *Formatter) Print(response any) error {
+	return f.printResponse(color.Output, response, f.capturing, f.jq)
+}
+
+// PrintTo formats response like Print but writes it to w, such as
+// color.Error for an API error body, and never keeps it for --copy or runs
+// --jq over it.
+func (f *Formatter) PrintTo(w io.Writer, response any) error {
+	return f.printResponse(w, response, false, nil)
+}
+
+// PrintVerbatim prints a response body that is not JSON, such as CSV or
+// plain text asked for with an Accept header, exactly as it was received.
+// It is kept for --copy like a formatted response.
+func (f *Formatter) PrintVerbatim(body []byte) error {
+	if f.capturing {
+		f.lastOutput = body
+	}
+	_, err := color.Output.Write(body)
+	return err
+}
+
+func (f *Formatter) printResponse(w io.Writer, response any, capture bool, jq *gojq.Code) error {
+	if !f.colorEnabled(w) {
+		w = StripANSI(w)
+	}
+	if IsNoContent(response) {
+		if !f.IsNDJSON() && jq == nil && stdoutIsTerminal() {
+			fmt.Fprintln(w, noContentColor.Sprint("No Content (the request succeeded)"))
+		}
+		return nil
+	}
+	if f.IsNDJSON() {
+		return f.printNDJSON(w, response, capture, jq)
+	}
+	if len(f.redactFields) > 0 {
+		raw, err := json.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		redacted, err := RedactJSON(raw, f.redactFields)
+		if err != nil {
+			return fmt.Errorf("error redacting JSON: %v", err)
+		}
+		response = json.RawMessage(redacted)
+	}
+	if jq != nil {
+		raw, err := json.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		outputs, err := RunJQ(jq, raw)
+		for _, out := range outputs {
+			if perr := f.printPretty(w, out, capture); perr != nil {
+				return perr
+			}
+		}
+		return err
+	}
+	return f.printPretty(w, response, capture)
+}
+
+// printPretty writes response as indented, colorized JSON.
+func (f *Formatter) printPretty(w io.Writer, response any, capture bool) error {
+	var prettyJSON []byte
+	var err error
+	if f.preserveOrder {
+		raw, ok := response.(json.RawMessage)
+		if !ok {
+			if raw, err = json.Marshal(response); err != nil {
+				return fmt.Errorf("error formatting JSON: %v", err)
+			}
+		}
+		prettyJSON, err = IndentOrdered(raw, "  ")
+	} else {
+		prettyJSON, err = json.MarshalIndent(response, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("error formatting JSON: %v", err)
 	}
 
-	colorizeAndPrintJSON(string(prettyJSON))
+	if capture {
+		var str string
+		if json.Unmarshal(prettyJSON, &str) == nil {
+			f.lastOutput = []byte(str)
+		} else {
+			f.lastOutput = append(prettyJSON, '\n')
+		}
+	}
+
+	colorizeAndPrintJSON(w, string(prettyJSON))
 	return nil
 }
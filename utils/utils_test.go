@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAndPrintResponseNoContent(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevNoColor, prevTerminal := color.Output, color.NoColor, stdoutIsTerminal
+	color.Output, color.NoColor = &buf, true
+	defer func() { color.Output, color.NoColor, stdoutIsTerminal = prevOut, prevNoColor, prevTerminal }()
+
+	terminal := true
+	stdoutIsTerminal = func() bool { return terminal }
+	f := NewFormatter()
+	print := func() string {
+		buf.Reset()
+		require.NoError(t, f.Print(NoContent))
+		return buf.String()
+	}
+
+	assert.Equal(t, "No Content (the request succeeded)\n", print())
+
+	terminal = false
+	assert.Empty(t, print(), "nothing on a piped stdout")
+
+	terminal = true
+	require.NoError(t, f.SetOutputFormat(OutputNDJSON))
+	assert.Empty(t, print(), "nothing with ndjson")
+	require.NoError(t, f.SetOutputFormat(OutputJSON))
+
+	require.NoError(t, f.SetJQ("."))
+	assert.Empty(t, print(), "nothing with --jq")
+	require.NoError(t, f.SetJQ(""))
+
+	buf.Reset()
+	require.NoError(t, f.Print(json.RawMessage(`{}`)))
+	assert.Equal(t, "{}\n", buf.String(), "an empty object is still printed")
+
+	buf.Reset()
+	require.NoError(t, f.Print(json.RawMessage("null")))
+	assert.Equal(t, "null\n", buf.String(), "a null sent by the server is printed")
+}